@@ -0,0 +1,27 @@
+// Command analytics-schema writes the analytics export schema documentation
+// generated from the fact structs in internal/analytics
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/moabdelazem/mutlitier_app/internal/analytics"
+)
+
+func main() {
+	output := flag.String("o", "", "file to write, stdout when empty")
+	flag.Parse()
+
+	doc := analytics.Markdown()
+	if *output == "" {
+		fmt.Print(doc)
+		return
+	}
+
+	if err := os.WriteFile(*output, []byte(doc), 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "analytics-schema: %v\n", err)
+		os.Exit(1)
+	}
+}
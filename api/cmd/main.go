@@ -2,6 +2,10 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"database/sql"
+	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/signal"
@@ -11,7 +15,11 @@ import (
 	"github.com/moabdelazem/mutlitier_app/internal/config"
 	"github.com/moabdelazem/mutlitier_app/internal/database"
 	"github.com/moabdelazem/mutlitier_app/internal/handler"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
 	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+	"github.com/moabdelazem/mutlitier_app/pkg/server"
+	"github.com/moabdelazem/mutlitier_app/pkg/worker"
+	"github.com/rs/zerolog"
 )
 
 func main() {
@@ -28,52 +36,186 @@ func main() {
 		Str("log_format", cfg.LogConfig.Format).
 		Msg("Starting application")
 
-	// Connect to database
-	db, err := database.NewPostgresConnection(&cfg.DatabaseConfig)
+	if err := cfg.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("Invalid configuration")
+	}
+
+	// Connect to database (demo mode runs entirely in memory)
+	var db, shadowDB *database.DB
+	var sqlDB *sql.DB
+	if !cfg.Demo.Enabled {
+		var err error
+		db, err = database.NewPostgresConnection(&cfg.DatabaseConfig, &cfg.Failover)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to connect to database")
+		}
+		defer db.Close()
+		sqlDB = db.DB
+
+		log.Info().Msg("Database connection established")
+
+		// Copy of the database the task repository is migrating to
+		if cfg.Shadow.Enabled() && cfg.Shadow.Backend == "postgres" {
+			shadowDB, err = database.NewPostgresConnection(cfg.Shadow.Database(&cfg.DatabaseConfig), &config.FailoverConfig{})
+			if err != nil {
+				log.Fatal().Err(err).Msg("Failed to connect to shadow database")
+			}
+			defer shadowDB.Close()
+		}
+	} else {
+		cfg.KVStore.Backend = kvstore.BackendMemory
+		log.Warn().Msg("Demo mode enabled, running without a database")
+	}
+
+	logStartupSummary(log, cfg, db)
+
+	// Shared state for rate limits and idempotency keys
+	store, err := kvstore.New(cfg.KVStore.Backend, cfg.KVStore.RedisURL, sqlDB)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to connect to database")
+		log.Fatal().Err(err).Msg("Failed to create kv store")
 	}
-	defer db.Close()
 
-	log.Info().Msg("Database connection established")
+	// Cancelled on shutdown to stop background work and end event streams
+	appCtx, stopApp := context.WithCancel(context.Background())
+	defer stopApp()
+
+	// Background workers holding leases or claims, drained on shutdown
+	workers := worker.NewGroup(context.Background())
 
 	// Setup router with config and logger
-	router := handler.SetupRouter(db, cfg, log)
+	handlers := handler.SetupRouter(appCtx, workers, db, shadowDB, store, cfg, log)
 
-	// Configure HTTP server
-	srv := &http.Server{
+	// Configure HTTP servers. The API drains first, metrics last so the
+	// shutdown itself can still be scraped.
+	api := &http.Server{
 		Addr:           cfg.SrvPort,
-		Handler:        router,
+		Handler:        handlers.API,
 		ReadTimeout:    time.Second * 15,
 		WriteTimeout:   time.Second * 15,
 		IdleTimeout:    time.Second * 60,
 		MaxHeaderBytes: 1 << 20, // 1mb
 	}
+	api.RegisterOnShutdown(stopApp)
+
+	// TLS, and client certificates with MTLS_ENABLED, for the API and admin
+	// listeners; metrics and profiles stay plain for in-cluster scrapers
+	var tlsConfig *tls.Config
+	if cfg.TLS.Enabled() {
+		clientCA := ""
+		if cfg.TLS.MTLS {
+			clientCA = cfg.TLS.ClientCAFile
+		}
+		if tlsConfig, err = server.LoadTLS(cfg.TLS.CertFile, cfg.TLS.KeyFile, clientCA); err != nil {
+			log.Fatal().Err(err).Msg("Failed to load TLS configuration")
+		}
+		api.TLSConfig = tlsConfig
+	}
+
+	listeners := server.NewGroup()
+	listeners.Add("api", api)
+	if handlers.Admin != nil {
+		admin := internalServer(cfg.Listeners.AdminPort, handlers.Admin)
+		admin.TLSConfig = tlsConfig
+		listeners.Add("admin", admin)
+	}
+	if handlers.Debug != nil {
+		// Profiles run for as long as they are asked to, so no write timeout
+		listeners.Add("debug", &http.Server{Addr: cfg.Listeners.DebugPort, Handler: handlers.Debug, ReadHeaderTimeout: 15 * time.Second})
+	}
+	if handlers.Metrics != nil {
+		listeners.Add("metrics", internalServer(cfg.Listeners.MetricsPort, handlers.Metrics))
+	}
 
 	// Graceful shutdown setup
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 
+	if err := listeners.Start(); err != nil {
+		log.Fatal().Err(err).Msg("Server failed to start")
+	}
+
+	select {
+	case <-quit:
+		log.Info().Msg("Shutdown signal received")
+	case err := <-listeners.Err():
+		log.Error().Err(err).Msg("Listener failed, shutting down")
+	}
+
+	// Stop claiming background work right away and let in-flight work
+	// finish alongside in-flight requests
+	drained := make(chan error, 1)
 	go func() {
-		log.Info().Str("addr", cfg.SrvPort).Msg("Server started")
-		if err := srv.ListenAndServe(); err != http.ErrServerClosed && err != nil {
-			log.Fatal().Err(err).Msg("Server failed to start")
-		}
+		drainCtx, cancel := context.WithTimeout(context.Background(), cfg.Workers.DrainTimeout)
+		defer cancel()
+		drained <- workers.Shutdown(drainCtx)
 	}()
 
-	<-quit
-	log.Info().Msg("Shutdown signal received")
+	if err := listeners.Shutdown(context.Background(), cfg.Listeners.ShutdownTimeout); err != nil {
+		log.Error().Err(err).Msg("Server forced to shutdown")
+	}
+
+	// Workers release their claims through the kv store, so it stays open until they are done
+	if err := <-drained; err != nil {
+		log.Warn().Err(err).Msg("Background work was cut short by the drain deadline")
+	}
+
+	if closer, ok := store.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Error().Err(err).Msg("Error closing kv store")
+		}
+	}
+
+	if db != nil {
+		if err := db.Close(); err != nil {
+			log.Error().Err(err).Msg("Error closing database")
+		}
+	}
+
+	log.Info().Msg("Server stopped")
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+// internalServer returns a server for a listener kept off the public
+// ingress, with the API's timeouts
+func internalServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:           addr,
+		Handler:        handler,
+		ReadTimeout:    time.Second * 15,
+		WriteTimeout:   time.Second * 15,
+		IdleTimeout:    time.Second * 60,
+		MaxHeaderBytes: 1 << 20,
+	}
+}
+
+// logStartupSummary logs enabled subsystems and settings that differ from
+// their defaults, which makes overlay mistakes easy to spot in pod logs
+func logStartupSummary(log *logger.Logger, cfg *config.Config, db *database.DB) {
+	subsystems := zerolog.Dict()
+	for name, state := range cfg.Subsystems() {
+		subsystems.Str(name, state)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal().Err(err).Msg("Server forced to shutdown")
+	if db == nil {
+		subsystems.Str("migrations", "none")
+	} else if version, dirty, err := db.MigrationVersion(ctx); err != nil {
+		subsystems.Str("migrations", "unknown")
+	} else if dirty {
+		subsystems.Str("migrations", fmt.Sprintf("v%d (dirty)", version))
+	} else {
+		subsystems.Str("migrations", fmt.Sprintf("v%d", version))
 	}
 
-	if err := db.Close(); err != nil {
-		log.Error().Err(err).Msg("Error closing database")
+	overrides := zerolog.Dict()
+	for _, o := range cfg.Overrides() {
+		overrides.Str(o.Key, fmt.Sprintf("%s (default %s)", o.Value, o.Default))
 	}
 
-	log.Info().Msg("Server stopped")
+	log.Info().
+		Dict("subsystems", subsystems).
+		Dict("overrides", overrides).
+		Int("override_count", len(cfg.Overrides())).
+		Msg("Startup summary")
 }
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS request_nonces (
+    nonce VARCHAR(128) PRIMARY KEY,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_request_nonces_expires_at ON request_nonces(expires_at);
@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS trg_tasks_updated_at ON tasks;
+DROP FUNCTION IF EXISTS set_updated_at();
+
+ALTER TABLE tasks
+    ALTER COLUMN created_at DROP NOT NULL,
+    ALTER COLUMN updated_at DROP NOT NULL;
@@ -0,0 +1,21 @@
+UPDATE tasks SET created_at = NOW() WHERE created_at IS NULL;
+UPDATE tasks SET updated_at = created_at WHERE updated_at IS NULL;
+
+ALTER TABLE tasks
+    ALTER COLUMN created_at SET NOT NULL,
+    ALTER COLUMN updated_at SET NOT NULL;
+
+-- Keep updated_at in the database so every replica agrees on the clock,
+-- and never let it move backwards
+CREATE OR REPLACE FUNCTION set_updated_at() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.created_at = OLD.created_at;
+    NEW.updated_at = GREATEST(NOW(), OLD.updated_at + INTERVAL '1 microsecond');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_tasks_updated_at
+    BEFORE UPDATE ON tasks
+    FOR EACH ROW
+    EXECUTE FUNCTION set_updated_at();
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_tasks_project_key_number;
+
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS number,
+    DROP COLUMN IF EXISTS project_key;
+
+DROP TABLE IF EXISTS task_sequences;
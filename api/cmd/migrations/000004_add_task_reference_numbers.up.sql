@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS task_sequences (
+    project_key VARCHAR(16) PRIMARY KEY,
+    last_number BIGINT NOT NULL
+);
+
+ALTER TABLE tasks
+    ADD COLUMN project_key VARCHAR(16) NOT NULL DEFAULT 'TASK',
+    ADD COLUMN number BIGINT;
+
+-- Number existing tasks in creation order
+UPDATE tasks t
+SET number = numbered.rn
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY project_key ORDER BY created_at, id) AS rn
+    FROM tasks
+) numbered
+WHERE t.id = numbered.id;
+
+INSERT INTO task_sequences (project_key, last_number)
+SELECT project_key, MAX(number) FROM tasks GROUP BY project_key
+ON CONFLICT (project_key) DO NOTHING;
+
+ALTER TABLE tasks ALTER COLUMN number SET NOT NULL;
+
+CREATE UNIQUE INDEX idx_tasks_project_key_number ON tasks(project_key, number);
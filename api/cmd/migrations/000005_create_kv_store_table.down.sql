@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_kv_store_expires_at;
+DROP TABLE IF EXISTS kv_store;
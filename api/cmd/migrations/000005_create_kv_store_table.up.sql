@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS kv_store (
+    key VARCHAR(512) PRIMARY KEY,
+    value BYTEA,
+    counter BIGINT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_kv_store_expires_at ON kv_store(expires_at);
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS health_probes (
+    id UUID PRIMARY KEY,
+    payload TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_tasks_title;
+DROP INDEX IF EXISTS idx_tasks_updated_at;
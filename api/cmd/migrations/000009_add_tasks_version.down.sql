@@ -0,0 +1,9 @@
+CREATE OR REPLACE FUNCTION set_updated_at() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.created_at = OLD.created_at;
+    NEW.updated_at = GREATEST(NOW(), OLD.updated_at + INTERVAL '1 microsecond');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS version;
@@ -0,0 +1,12 @@
+ALTER TABLE tasks ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+-- Bump the version on every update alongside updated_at, so optimistic
+-- concurrency checks hold no matter which statement modified the row
+CREATE OR REPLACE FUNCTION set_updated_at() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.created_at = OLD.created_at;
+    NEW.updated_at = GREATEST(NOW(), OLD.updated_at + INTERVAL '1 microsecond');
+    NEW.version = OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,11 @@
+-- Short-retention change log backing the /events stream; the id doubles as
+-- the resume cursor clients send back in Last-Event-ID
+CREATE TABLE IF NOT EXISTS task_events (
+    id BIGSERIAL PRIMARY KEY,
+    type VARCHAR(32) NOT NULL,
+    task_id UUID NOT NULL,
+    payload JSONB,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_task_events_created_at ON task_events(created_at);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_tasks_deleted_at;
+
+-- Soft-deleted rows would reappear once the column is gone
+DELETE FROM tasks WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS deleted_at;
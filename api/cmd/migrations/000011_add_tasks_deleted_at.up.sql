@@ -0,0 +1,4 @@
+ALTER TABLE tasks ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- Reads only ever look at live tasks, deleted ones are found by id for restore
+CREATE INDEX idx_tasks_deleted_at ON tasks (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_tasks_priority_rank;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS priority_rank;
+ALTER TABLE tasks DROP COLUMN IF EXISTS priority;
@@ -0,0 +1,14 @@
+ALTER TABLE tasks ADD COLUMN priority VARCHAR(16) NOT NULL DEFAULT 'medium'
+    CHECK (priority IN ('low', 'medium', 'high', 'urgent'));
+
+-- Sorting by priority must follow urgency, not the alphabet
+ALTER TABLE tasks ADD COLUMN priority_rank SMALLINT GENERATED ALWAYS AS (
+    CASE priority
+        WHEN 'low' THEN 1
+        WHEN 'medium' THEN 2
+        WHEN 'high' THEN 3
+        WHEN 'urgent' THEN 4
+    END
+) STORED;
+
+CREATE INDEX idx_tasks_priority_rank ON tasks (priority_rank, id) WHERE deleted_at IS NULL;
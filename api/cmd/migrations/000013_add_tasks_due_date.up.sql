@@ -0,0 +1,5 @@
+ALTER TABLE tasks ADD COLUMN due_date TIMESTAMPTZ;
+
+-- The overdue filter only ever looks at open tasks
+CREATE INDEX idx_tasks_due_date_open ON tasks (due_date)
+    WHERE due_date IS NOT NULL AND deleted_at IS NULL AND status <> 'completed';
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS tags (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(32) NOT NULL UNIQUE,
+    color VARCHAR(7) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Links go away with either side, so deleting a tag untags its tasks and a
+-- hard-deleted task leaves no links behind
+CREATE TABLE IF NOT EXISTS task_tags (
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    tag_id UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (task_id, tag_id)
+);
+
+-- The primary key covers lookups by task; the tag filter looks up by tag
+CREATE INDEX idx_task_tags_tag_id ON task_tags(tag_id);
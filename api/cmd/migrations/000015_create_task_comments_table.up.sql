@@ -0,0 +1,11 @@
+-- Comments always cascade at the database level; COMMENTS_ON_TASK_DELETE=block
+-- is enforced by the API before a task is deleted
+CREATE TABLE IF NOT EXISTS task_comments (
+    id UUID PRIMARY KEY,
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    author VARCHAR(100) NOT NULL DEFAULT '',
+    body TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_task_comments_task_id_created_at ON task_comments(task_id, created_at, id);
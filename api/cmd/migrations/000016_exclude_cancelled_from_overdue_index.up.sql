@@ -0,0 +1,5 @@
+-- Cancelled tasks are closed like completed ones and never overdue
+DROP INDEX IF EXISTS idx_tasks_due_date_open;
+
+CREATE INDEX idx_tasks_due_date_open ON tasks (due_date)
+    WHERE due_date IS NOT NULL AND deleted_at IS NULL AND status NOT IN ('completed', 'cancelled');
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS trg_tasks_history ON tasks;
+DROP FUNCTION IF EXISTS record_task_history();
+DROP TABLE IF EXISTS task_history;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS updated_by;
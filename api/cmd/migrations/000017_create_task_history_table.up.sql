@@ -0,0 +1,61 @@
+-- Who last wrote a task, set by every write so the history trigger can
+-- attribute the change
+ALTER TABLE tasks ADD COLUMN updated_by VARCHAR(255);
+
+-- Permanent audit trail of task writes, unlike the short-retention
+-- task_events stream. Rows go with their task on a hard delete.
+CREATE TABLE IF NOT EXISTS task_history (
+    id BIGSERIAL PRIMARY KEY,
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    action VARCHAR(16) NOT NULL,
+    actor VARCHAR(255),
+    changes JSONB NOT NULL DEFAULT '{}',
+    version BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_task_history_task_id ON task_history(task_id, id);
+
+-- Runs in the transaction of the write it records, so history and task
+-- cannot disagree
+CREATE OR REPLACE FUNCTION record_task_history() RETURNS TRIGGER AS $$
+DECLARE
+    old_row JSONB := '{}';
+    new_row JSONB := to_jsonb(NEW);
+    field TEXT;
+    changes JSONB := '{}';
+    action TEXT := 'updated';
+BEGIN
+    IF TG_OP = 'UPDATE' THEN
+        old_row := to_jsonb(OLD);
+    END IF;
+
+    FOREACH field IN ARRAY ARRAY['title', 'description', 'status', 'priority', 'due_date'] LOOP
+        IF COALESCE(old_row -> field, 'null') IS DISTINCT FROM COALESCE(new_row -> field, 'null') THEN
+            changes := changes || jsonb_build_object(field, jsonb_build_object(
+                'from', COALESCE(old_row -> field, 'null'),
+                'to', COALESCE(new_row -> field, 'null')));
+        END IF;
+    END LOOP;
+
+    IF TG_OP = 'INSERT' THEN
+        action := 'created';
+    ELSIF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+        action := 'deleted';
+    ELSIF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+        action := 'restored';
+    ELSIF changes = '{}' THEN
+        -- Writes that only touch bookkeeping columns are not history
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO task_history (task_id, action, actor, changes, version)
+    VALUES (NEW.id, action, NEW.updated_by, changes, NEW.version);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_tasks_history
+    AFTER INSERT OR UPDATE ON tasks
+    FOR EACH ROW
+    EXECUTE FUNCTION record_task_history();
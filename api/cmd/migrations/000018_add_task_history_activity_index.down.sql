@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_task_history_created_at;
@@ -0,0 +1,2 @@
+-- Serves the activity feed across all tasks, newest first
+CREATE INDEX idx_task_history_created_at ON task_history(created_at, id);
@@ -0,0 +1,17 @@
+-- Authentication and authorization events, kept for SECURITY_EVENTS_RETENTION
+CREATE TABLE IF NOT EXISTS security_events (
+    id BIGSERIAL PRIMARY KEY,
+    type VARCHAR(32) NOT NULL,
+    user_id VARCHAR(255),
+    credential VARCHAR(32),
+    token_id UUID,
+    reason TEXT,
+    ip VARCHAR(64) NOT NULL,
+    method VARCHAR(16) NOT NULL,
+    path TEXT NOT NULL,
+    request_id VARCHAR(128),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_security_events_created_at ON security_events(created_at DESC, id DESC);
+CREATE INDEX idx_security_events_user_id ON security_events(user_id, created_at DESC);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_tasks_recurrence_pending;
+ALTER TABLE tasks DROP COLUMN IF EXISTS next_occurrence_id;
+ALTER TABLE tasks DROP COLUMN IF EXISTS recurrence;
@@ -0,0 +1,9 @@
+-- Recurring tasks carry a cron expression; completing one creates the next
+-- occurrence, linked through next_occurrence_id so it is created only once
+ALTER TABLE tasks ADD COLUMN recurrence VARCHAR(100);
+ALTER TABLE tasks ADD COLUMN next_occurrence_id UUID REFERENCES tasks(id) ON DELETE SET NULL;
+
+-- The recurrence scheduler looks for completed tasks still waiting for theirs
+CREATE INDEX idx_tasks_recurrence_pending ON tasks(updated_at)
+    WHERE recurrence IS NOT NULL AND next_occurrence_id IS NULL AND status = 'completed'
+        AND deleted_at IS NULL AND NOT archived;
@@ -0,0 +1,13 @@
+-- Checklist items are ordered by position; reordering rewrites the
+-- positions of a whole checklist, so they are not unique
+CREATE TABLE IF NOT EXISTS task_checklist_items (
+    id UUID PRIMARY KEY,
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    text VARCHAR(500) NOT NULL,
+    done BOOLEAN NOT NULL DEFAULT FALSE,
+    position INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_task_checklist_items_task_id_position ON task_checklist_items(task_id, position, id);
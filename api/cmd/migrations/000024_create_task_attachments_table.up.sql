@@ -0,0 +1,15 @@
+-- Attachment metadata; the contents live in the storage backend under
+-- storage_key, so a moved bucket only needs the backend URL changed
+CREATE TABLE IF NOT EXISTS task_attachments (
+    id UUID PRIMARY KEY,
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL CHECK (size_bytes >= 0),
+    sha256 CHAR(64) NOT NULL,
+    storage_key VARCHAR(500) NOT NULL,
+    uploaded_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_task_attachments_task_id_created_at ON task_attachments(task_id, created_at, id);
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS cdc_heartbeat;
+
+DROP TRIGGER IF EXISTS trg_task_checklist_items_updated_at ON task_checklist_items;
+DROP TRIGGER IF EXISTS trg_tags_updated_at ON tags;
+DROP FUNCTION IF EXISTS touch_updated_at();
+
+ALTER TABLE task_attachments REPLICA IDENTITY DEFAULT;
+ALTER TABLE task_checklist_items REPLICA IDENTITY DEFAULT;
+ALTER TABLE task_comments REPLICA IDENTITY DEFAULT;
+ALTER TABLE task_tags REPLICA IDENTITY DEFAULT;
+ALTER TABLE tags REPLICA IDENTITY DEFAULT;
+ALTER TABLE tasks REPLICA IDENTITY DEFAULT;
@@ -0,0 +1,39 @@
+-- Change data capture (Debezium) support. Deletes and updates of captured
+-- tables carry the whole old row, so consumers never see a before image
+-- with only the primary key and tombstones can be keyed and filtered.
+ALTER TABLE tasks REPLICA IDENTITY FULL;
+ALTER TABLE tags REPLICA IDENTITY FULL;
+ALTER TABLE task_tags REPLICA IDENTITY FULL;
+ALTER TABLE task_comments REPLICA IDENTITY FULL;
+ALTER TABLE task_checklist_items REPLICA IDENTITY FULL;
+ALTER TABLE task_attachments REPLICA IDENTITY FULL;
+
+-- updated_at is the watermark for incremental snapshots, so it must move on
+-- every write, not only on the ones the API makes
+CREATE OR REPLACE FUNCTION touch_updated_at() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.created_at = OLD.created_at;
+    NEW.updated_at = GREATEST(NOW(), OLD.updated_at + INTERVAL '1 microsecond');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_tags_updated_at
+    BEFORE UPDATE ON tags
+    FOR EACH ROW
+    EXECUTE FUNCTION touch_updated_at();
+
+CREATE TRIGGER trg_task_checklist_items_updated_at
+    BEFORE UPDATE ON task_checklist_items
+    FOR EACH ROW
+    EXECUTE FUNCTION touch_updated_at();
+
+-- Single row the connector (heartbeat.action.query) or the API
+-- (CDC_HEARTBEAT_INTERVAL) keeps updating, so the replication slot
+-- advances while the captured tables are idle
+CREATE TABLE IF NOT EXISTS cdc_heartbeat (
+    id SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    beat_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO cdc_heartbeat (id) VALUES (1) ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,45 @@
+-- Back to the history trigger of 000020
+CREATE OR REPLACE FUNCTION record_task_history() RETURNS TRIGGER AS $$
+DECLARE
+    old_row JSONB := '{}';
+    new_row JSONB := to_jsonb(NEW);
+    field TEXT;
+    changes JSONB := '{}';
+    action TEXT := 'updated';
+BEGIN
+    IF TG_OP = 'UPDATE' THEN
+        old_row := to_jsonb(OLD);
+    END IF;
+
+    FOREACH field IN ARRAY ARRAY['title', 'description', 'status', 'priority', 'due_date'] LOOP
+        IF COALESCE(old_row -> field, 'null') IS DISTINCT FROM COALESCE(new_row -> field, 'null') THEN
+            changes := changes || jsonb_build_object(field, jsonb_build_object(
+                'from', COALESCE(old_row -> field, 'null'),
+                'to', COALESCE(new_row -> field, 'null')));
+        END IF;
+    END LOOP;
+
+    IF TG_OP = 'INSERT' THEN
+        action := 'created';
+    ELSIF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+        action := 'deleted';
+    ELSIF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+        action := 'restored';
+    ELSIF NOT OLD.archived AND NEW.archived THEN
+        action := 'archived';
+    ELSIF OLD.archived AND NOT NEW.archived THEN
+        action := 'unarchived';
+    ELSIF changes = '{}' THEN
+        -- Writes that only touch bookkeeping columns are not history
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO task_history (task_id, action, actor, changes, version)
+    VALUES (NEW.id, action, NEW.updated_by, changes, NEW.version);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP INDEX IF EXISTS idx_tasks_assignee;
+ALTER TABLE tasks DROP COLUMN IF EXISTS assignee;
+DROP TABLE IF EXISTS users;
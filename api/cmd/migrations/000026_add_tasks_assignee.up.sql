@@ -0,0 +1,63 @@
+-- Everyone who has authenticated, so tasks can only be assigned to real
+-- users. A row is added the first time a user signs in and kept.
+CREATE TABLE IF NOT EXISTS users (
+    id VARCHAR(255) PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE users REPLICA IDENTITY FULL;
+
+-- Token holders are the users known so far
+INSERT INTO users (id, created_at, last_seen_at)
+SELECT user_id, MIN(created_at), MAX(COALESCE(last_used_at, created_at))
+FROM api_tokens
+GROUP BY user_id
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE tasks ADD COLUMN assignee VARCHAR(255) REFERENCES users(id);
+
+-- Serves ?assignee=, which only ever lists live tasks
+CREATE INDEX idx_tasks_assignee ON tasks (assignee) WHERE assignee IS NOT NULL AND deleted_at IS NULL;
+
+-- Same as 000020, plus assignee changes
+CREATE OR REPLACE FUNCTION record_task_history() RETURNS TRIGGER AS $$
+DECLARE
+    old_row JSONB := '{}';
+    new_row JSONB := to_jsonb(NEW);
+    field TEXT;
+    changes JSONB := '{}';
+    action TEXT := 'updated';
+BEGIN
+    IF TG_OP = 'UPDATE' THEN
+        old_row := to_jsonb(OLD);
+    END IF;
+
+    FOREACH field IN ARRAY ARRAY['title', 'description', 'status', 'priority', 'due_date', 'assignee'] LOOP
+        IF COALESCE(old_row -> field, 'null') IS DISTINCT FROM COALESCE(new_row -> field, 'null') THEN
+            changes := changes || jsonb_build_object(field, jsonb_build_object(
+                'from', COALESCE(old_row -> field, 'null'),
+                'to', COALESCE(new_row -> field, 'null')));
+        END IF;
+    END LOOP;
+
+    IF TG_OP = 'INSERT' THEN
+        action := 'created';
+    ELSIF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+        action := 'deleted';
+    ELSIF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+        action := 'restored';
+    ELSIF NOT OLD.archived AND NEW.archived THEN
+        action := 'archived';
+    ELSIF OLD.archived AND NOT NEW.archived THEN
+        action := 'unarchived';
+    ELSIF changes = '{}' THEN
+        -- Writes that only touch bookkeeping columns are not history
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO task_history (task_id, action, actor, changes, version)
+    VALUES (NEW.id, action, NEW.updated_by, changes, NEW.version);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS notifications;
+ALTER TABLE task_events DROP COLUMN IF EXISTS actor;
+DROP TABLE IF EXISTS task_watchers;
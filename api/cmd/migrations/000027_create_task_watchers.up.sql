@@ -0,0 +1,34 @@
+-- Users following a task. Rows go with the task on a hard delete and stay
+-- while it is soft deleted, so a restored task keeps its watchers.
+CREATE TABLE IF NOT EXISTS task_watchers (
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (task_id, user_id)
+);
+
+CREATE INDEX idx_task_watchers_user_id ON task_watchers(user_id);
+
+ALTER TABLE task_watchers REPLICA IDENTITY FULL;
+
+-- Who made each change, so watchers are not notified of their own
+ALTER TABLE task_events ADD COLUMN actor VARCHAR(255);
+
+-- Per-user queue of changes to watched tasks, filled from task_events by
+-- the notification fan-out and drained by delivery channels. There is no
+-- foreign key on task_id: notifications outlive hard-deleted tasks.
+CREATE TABLE IF NOT EXISTS notifications (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    event_id BIGINT NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    task_id UUID NOT NULL,
+    actor VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    read_at TIMESTAMP WITH TIME ZONE,
+    -- A batch fanned out twice after a lease handover is a no-op
+    UNIQUE (user_id, event_id)
+);
+
+CREATE INDEX idx_notifications_user_id ON notifications(user_id, id DESC);
+CREATE INDEX idx_notifications_created_at ON notifications(created_at);
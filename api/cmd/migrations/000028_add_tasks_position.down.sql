@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_tasks_position;
+ALTER TABLE tasks DROP COLUMN IF EXISTS position;
+DROP SEQUENCE IF EXISTS task_positions;
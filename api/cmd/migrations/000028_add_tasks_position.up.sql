@@ -0,0 +1,20 @@
+-- Manual order for sort=position. Positions are spaced 1024 apart so a
+-- task can be moved between two others by taking the midpoint; only when
+-- no integer is left between them is the whole order renumbered.
+CREATE SEQUENCE IF NOT EXISTS task_positions;
+
+ALTER TABLE tasks ADD COLUMN position BIGINT;
+
+-- Existing tasks keep their creation order
+UPDATE tasks SET position = ranked.rank * 1024
+FROM (SELECT id, ROW_NUMBER() OVER (ORDER BY created_at, id) AS rank FROM tasks) AS ranked
+WHERE tasks.id = ranked.id;
+
+SELECT setval('task_positions', (SELECT COUNT(*) + 1 FROM tasks), false);
+
+-- New tasks go after every other one, without a lookup of the current last
+ALTER TABLE tasks
+    ALTER COLUMN position SET DEFAULT nextval('task_positions') * 1024,
+    ALTER COLUMN position SET NOT NULL;
+
+CREATE INDEX idx_tasks_position ON tasks (position, id) WHERE deleted_at IS NULL;
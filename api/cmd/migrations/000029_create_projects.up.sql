@@ -0,0 +1,27 @@
+-- Projects group tasks by the key that prefixes their references. The key
+-- is the primary key and never changes, so tasks keep referencing it.
+CREATE TABLE IF NOT EXISTS projects (
+    key VARCHAR(16) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER trg_projects_updated_at
+    BEFORE UPDATE ON projects
+    FOR EACH ROW
+    EXECUTE FUNCTION touch_updated_at();
+
+ALTER TABLE projects REPLICA IDENTITY FULL;
+
+-- Every project tasks were ever numbered in, named after its key
+INSERT INTO projects (key, name)
+SELECT project_key, project_key FROM task_sequences
+UNION
+SELECT DISTINCT project_key, project_key FROM tasks
+ON CONFLICT (key) DO NOTHING;
+
+-- A project cannot be deleted while it has tasks, deleted ones included
+ALTER TABLE tasks
+    ADD CONSTRAINT fk_tasks_project_key FOREIGN KEY (project_key) REFERENCES projects(key);
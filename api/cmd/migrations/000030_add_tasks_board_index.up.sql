@@ -0,0 +1,4 @@
+-- Serves the board, which numbers the live, unarchived tasks of each
+-- status column of a project, most urgent first by default
+CREATE INDEX IF NOT EXISTS idx_tasks_board ON tasks (project_key, status, priority_rank, id)
+    WHERE deleted_at IS NULL AND NOT archived;
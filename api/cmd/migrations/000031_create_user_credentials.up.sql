@@ -0,0 +1,9 @@
+-- Passwords of users who registered through POST /auth/register. Kept
+-- out of users, which is replicated in full for change data capture, so
+-- hashes never leave the database.
+CREATE TABLE IF NOT EXISTS user_credentials (
+    user_id VARCHAR(255) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    password_hash TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
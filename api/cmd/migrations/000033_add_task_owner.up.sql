@@ -0,0 +1,6 @@
+-- The user who created a task. Signed-in users other than admins only
+-- reach their own tasks; tasks created anonymously have no owner.
+ALTER TABLE tasks ADD COLUMN owner_id VARCHAR(255);
+
+-- Every scoped task query filters on the owner
+CREATE INDEX idx_tasks_owner_id ON tasks (owner_id) WHERE owner_id IS NOT NULL;
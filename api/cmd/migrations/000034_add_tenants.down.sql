@@ -0,0 +1,31 @@
+DROP INDEX IF EXISTS idx_tasks_tenant_id;
+
+-- Fails if two tenants have a tag of the same name
+ALTER TABLE tags DROP CONSTRAINT IF EXISTS tags_tenant_id_name_key;
+ALTER TABLE tags ADD CONSTRAINT tags_name_key UNIQUE (name);
+
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY[
+        'task_tags', 'task_comments', 'task_history', 'task_events',
+        'task_checklist_items', 'task_attachments', 'task_watchers', 'notifications'
+    ] LOOP
+        EXECUTE format('DROP TRIGGER IF EXISTS trg_%s_tenant ON %I', t, t);
+    END LOOP;
+
+    FOREACH t IN ARRAY ARRAY[
+        'tasks', 'tags', 'task_tags', 'task_comments', 'task_history', 'task_events',
+        'task_checklist_items', 'task_attachments', 'task_watchers', 'notifications',
+        'api_tokens', 'refresh_tokens'
+    ] LOOP
+        EXECUTE format('DROP POLICY IF EXISTS tenant_isolation ON %I', t);
+        EXECUTE format('ALTER TABLE %I NO FORCE ROW LEVEL SECURITY', t);
+        EXECUTE format('ALTER TABLE %I DISABLE ROW LEVEL SECURITY', t);
+        EXECUTE format('ALTER TABLE %I DROP COLUMN IF EXISTS tenant_id', t);
+    END LOOP;
+END $$;
+
+DROP FUNCTION IF EXISTS set_tenant_from_task();
+DROP FUNCTION IF EXISTS current_tenant();
@@ -0,0 +1,54 @@
+-- Tenants share one schema. Every tenant-owned table gets a tenant_id,
+-- existing rows belong to the default tenant, and row level security
+-- limits each statement to the tenant in the app.tenant_id setting, which
+-- the API keeps in step with the request on every connection. Sessions
+-- without the setting, such as background jobs or TENANCY_ENABLED=false,
+-- reach every tenant. Projects, users and the audit trail stay shared.
+CREATE OR REPLACE FUNCTION current_tenant() RETURNS TEXT AS $$
+    SELECT NULLIF(current_setting('app.tenant_id', true), '')
+$$ LANGUAGE sql STABLE;
+
+-- Rows hanging off a task belong to the task's tenant, whoever writes them
+CREATE OR REPLACE FUNCTION set_tenant_from_task() RETURNS TRIGGER AS $$
+DECLARE
+    parent TEXT;
+BEGIN
+    SELECT tenant_id INTO parent FROM tasks WHERE id = NEW.task_id;
+    IF FOUND THEN
+        NEW.tenant_id := parent;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY[
+        'tasks', 'tags', 'task_tags', 'task_comments', 'task_history', 'task_events',
+        'task_checklist_items', 'task_attachments', 'task_watchers', 'notifications',
+        'api_tokens', 'refresh_tokens'
+    ] LOOP
+        EXECUTE format('ALTER TABLE %I ADD COLUMN tenant_id VARCHAR(63) NOT NULL DEFAULT %L', t, 'default');
+        EXECUTE format('ALTER TABLE %I ALTER COLUMN tenant_id SET DEFAULT COALESCE(current_tenant(), %L)', t, 'default');
+        -- FORCE applies the policy to the table owner too, which the API
+        -- usually connects as
+        EXECUTE format('ALTER TABLE %I ENABLE ROW LEVEL SECURITY', t);
+        EXECUTE format('ALTER TABLE %I FORCE ROW LEVEL SECURITY', t);
+        EXECUTE format('CREATE POLICY tenant_isolation ON %I USING (current_tenant() IS NULL OR tenant_id = current_tenant())', t);
+    END LOOP;
+
+    FOREACH t IN ARRAY ARRAY[
+        'task_tags', 'task_comments', 'task_history', 'task_events',
+        'task_checklist_items', 'task_attachments', 'task_watchers', 'notifications'
+    ] LOOP
+        EXECUTE format('CREATE TRIGGER trg_%s_tenant BEFORE INSERT ON %I FOR EACH ROW EXECUTE FUNCTION set_tenant_from_task()', t, t);
+    END LOOP;
+END $$;
+
+-- Tag names are unique within a tenant
+ALTER TABLE tags DROP CONSTRAINT tags_name_key;
+ALTER TABLE tags ADD CONSTRAINT tags_tenant_id_name_key UNIQUE (tenant_id, name);
+
+CREATE INDEX idx_tasks_tenant_id ON tasks (tenant_id);
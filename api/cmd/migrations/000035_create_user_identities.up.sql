@@ -0,0 +1,12 @@
+-- Accounts at OpenID Connect providers that sign users in. An identity is
+-- the provider's issuer and its stable subject ID; usernames and emails
+-- at the provider may change or be reused, so they never identify a user.
+CREATE TABLE IF NOT EXISTS user_identities (
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities(user_id);
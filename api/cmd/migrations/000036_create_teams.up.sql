@@ -0,0 +1,37 @@
+-- Teams share tasks between their members. A task assigned to a team is
+-- reached by its owner and every member; the scoped task queries look
+-- membership up in team_members. Teams belong to a tenant like tasks.
+CREATE TABLE IF NOT EXISTS teams (
+    id UUID PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    tenant_id VARCHAR(63) NOT NULL DEFAULT COALESCE(current_tenant(), 'default'),
+    CONSTRAINT teams_tenant_id_name_key UNIQUE (tenant_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS team_members (
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    tenant_id VARCHAR(63) NOT NULL DEFAULT COALESCE(current_tenant(), 'default'),
+    PRIMARY KEY (team_id, user_id)
+);
+
+-- Scoped task queries look up the teams of the caller
+CREATE INDEX IF NOT EXISTS idx_team_members_user_id ON team_members(user_id);
+
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY['teams', 'team_members'] LOOP
+        EXECUTE format('ALTER TABLE %I ENABLE ROW LEVEL SECURITY', t);
+        EXECUTE format('ALTER TABLE %I FORCE ROW LEVEL SECURITY', t);
+        EXECUTE format('CREATE POLICY tenant_isolation ON %I USING (current_tenant() IS NULL OR tenant_id = current_tenant())', t);
+    END LOOP;
+END $$;
+
+-- Deleting a team leaves its tasks to their owners
+ALTER TABLE tasks ADD COLUMN team_id UUID REFERENCES teams(id) ON DELETE SET NULL;
+CREATE INDEX idx_tasks_team_id ON tasks (team_id) WHERE team_id IS NOT NULL;
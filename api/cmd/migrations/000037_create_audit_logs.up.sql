@@ -0,0 +1,22 @@
+-- Successful mutations made by identified callers, kept for AUDIT_RETENTION.
+-- Written in batches outside any request, so like security_events they are
+-- not scoped to a tenant.
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    token_id UUID,
+    method VARCHAR(16) NOT NULL,
+    endpoint TEXT NOT NULL,
+    path TEXT NOT NULL,
+    entity_id VARCHAR(255),
+    status SMALLINT NOT NULL,
+    before JSONB,
+    after JSONB,
+    ip VARCHAR(64) NOT NULL,
+    request_id VARCHAR(128),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_logs_created_at ON audit_logs(created_at DESC, id DESC);
+CREATE INDEX idx_audit_logs_user_id ON audit_logs(user_id, created_at DESC);
+CREATE INDEX idx_audit_logs_entity_id ON audit_logs(entity_id, created_at DESC) WHERE entity_id IS NOT NULL;
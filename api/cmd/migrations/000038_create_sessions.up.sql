@@ -0,0 +1,21 @@
+-- Sign-in sessions, one per refresh token family and sharing its ID. The
+-- session tokens of a sign-in name it, and the auth middleware refuses
+-- them once it is revoked. The device and address are kept for users
+-- reviewing where they are signed in.
+CREATE TABLE IF NOT EXISTS sessions (
+    id UUID PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    tenant_id VARCHAR(63) NOT NULL DEFAULT COALESCE(current_tenant(), 'default')
+);
+
+CREATE INDEX idx_sessions_user_id ON sessions(user_id);
+
+ALTER TABLE sessions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE sessions FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON sessions USING (current_tenant() IS NULL OR tenant_id = current_tenant());
@@ -0,0 +1,46 @@
+-- Escalation rules of a project fire once a task is due within, or
+-- overdue by, their threshold. Like projects they are not tenant-scoped.
+CREATE TABLE IF NOT EXISTS escalation_rules (
+    id UUID PRIMARY KEY,
+    project_key VARCHAR(16) NOT NULL REFERENCES projects(key) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    trigger VARCHAR(16) NOT NULL CHECK (trigger IN ('due_soon', 'overdue')),
+    threshold_hours INTEGER NOT NULL DEFAULT 0,
+    notify_owner BOOLEAN NOT NULL DEFAULT FALSE,
+    notify_assignee BOOLEAN NOT NULL DEFAULT FALSE,
+    notify_users TEXT[] NOT NULL DEFAULT '{}',
+    bump_priority BOOLEAN NOT NULL DEFAULT FALSE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_escalation_rules_project_key ON escalation_rules(project_key);
+
+-- The actions each rule took, one row per rule, task and due date, which
+-- is also what keeps a rule from firing twice. Rows outlive their rule,
+-- so the name is kept, and belong to the task's tenant.
+CREATE TABLE IF NOT EXISTS task_escalations (
+    id BIGSERIAL PRIMARY KEY,
+    rule_id UUID NOT NULL,
+    rule_name VARCHAR(100) NOT NULL,
+    project_key VARCHAR(16) NOT NULL,
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    due_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    notified TEXT[] NOT NULL DEFAULT '{}',
+    priority_from VARCHAR(20),
+    priority_to VARCHAR(20),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    tenant_id VARCHAR(63) NOT NULL DEFAULT COALESCE(current_tenant(), 'default'),
+    UNIQUE (rule_id, task_id, due_date)
+);
+
+CREATE INDEX idx_task_escalations_project_key ON task_escalations(project_key, created_at DESC);
+
+CREATE TRIGGER trg_task_escalations_tenant BEFORE INSERT ON task_escalations
+    FOR EACH ROW EXECUTE FUNCTION set_tenant_from_task();
+
+ALTER TABLE task_escalations ENABLE ROW LEVEL SECURITY;
+ALTER TABLE task_escalations FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON task_escalations USING (current_tenant() IS NULL OR tenant_id = current_tenant());
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_task_events_task_id;
+DROP TABLE IF EXISTS automation_runs;
+DROP TABLE IF EXISTS automation_rules;
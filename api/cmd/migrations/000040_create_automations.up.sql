@@ -0,0 +1,51 @@
+-- Automation rules of a project: a trigger, conditions and actions kept as
+-- the validated JSON the API accepted. Like projects they are not
+-- tenant-scoped.
+CREATE TABLE IF NOT EXISTS automation_rules (
+    id UUID PRIMARY KEY,
+    project_key VARCHAR(16) NOT NULL REFERENCES projects(key) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    trigger JSONB NOT NULL,
+    conditions JSONB NOT NULL DEFAULT '[]',
+    actions JSONB NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_automation_rules_project_key ON automation_rules(project_key);
+
+-- Execution log of the rules, one row per rule and event that fired it, or
+-- per rule, task and due date for due_date_passed rules. The unique keys
+-- are what keep a rule from running twice for the same cause. Rows outlive
+-- their rule, so the name is kept, and belong to the task's tenant.
+CREATE TABLE IF NOT EXISTS automation_runs (
+    id BIGSERIAL PRIMARY KEY,
+    rule_id UUID NOT NULL,
+    rule_name VARCHAR(100) NOT NULL,
+    project_key VARCHAR(16) NOT NULL,
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    event_id BIGINT,
+    due_date TIMESTAMP WITH TIME ZONE,
+    status VARCHAR(16) NOT NULL CHECK (status IN ('running', 'succeeded', 'failed', 'skipped')),
+    reason TEXT NOT NULL DEFAULT '',
+    actions JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    tenant_id VARCHAR(63) NOT NULL DEFAULT COALESCE(current_tenant(), 'default')
+);
+
+CREATE UNIQUE INDEX idx_automation_runs_event ON automation_runs(rule_id, event_id) WHERE event_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_automation_runs_due ON automation_runs(rule_id, task_id, due_date) WHERE due_date IS NOT NULL;
+CREATE INDEX idx_automation_runs_project_key ON automation_runs(project_key, created_at DESC);
+CREATE INDEX idx_automation_runs_rule_task ON automation_runs(rule_id, task_id, created_at);
+
+CREATE TRIGGER trg_automation_runs_tenant BEFORE INSERT ON automation_runs
+    FOR EACH ROW EXECUTE FUNCTION set_tenant_from_task();
+
+ALTER TABLE automation_runs ENABLE ROW LEVEL SECURITY;
+ALTER TABLE automation_runs FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON automation_runs USING (current_tenant() IS NULL OR tenant_id = current_tenant());
+
+-- Rules compare a task with its previous event to see what changed
+CREATE INDEX IF NOT EXISTS idx_task_events_task_id ON task_events(task_id, id);
@@ -0,0 +1,5 @@
+-- Tokens holding only stats:read are left without scopes and reject
+-- every request
+UPDATE api_tokens
+SET scopes = array_remove(array_replace(array_replace(scopes, 'tasks:read', 'read:tasks'), 'tasks:write', 'write:tasks'), 'stats:read')
+WHERE scopes && ARRAY['tasks:read', 'tasks:write', 'stats:read'];
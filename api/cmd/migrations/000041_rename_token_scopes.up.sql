@@ -0,0 +1,6 @@
+-- Scopes are named resource:action. Tokens that could read tasks could
+-- also read their stats, so they keep that as stats:read.
+UPDATE api_tokens
+SET scopes = array_replace(array_replace(scopes, 'read:tasks', 'tasks:read'), 'write:tasks', 'tasks:write')
+    || CASE WHEN 'read:tasks' = ANY(scopes) THEN ARRAY['stats:read'] ELSE ARRAY[]::TEXT[] END
+WHERE scopes && ARRAY['read:tasks', 'write:tasks'];
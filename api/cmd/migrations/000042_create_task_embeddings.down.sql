@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS task_embeddings;
+DROP EXTENSION IF EXISTS vector;
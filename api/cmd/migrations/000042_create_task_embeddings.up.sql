@@ -0,0 +1,30 @@
+-- Duplicate detection compares tasks by the embedding of their title and
+-- description. pgvector must be available to the database; the compose
+-- files use the pgvector/pgvector image.
+CREATE EXTENSION IF NOT EXISTS vector;
+
+-- One embedding per task, from the model that made it. task_updated_at is
+-- the task version it was made for, so tasks changed since are found and
+-- embedded again; content_hash lets that skip the model when only fields
+-- other than the title and description changed. The column has no fixed
+-- dimension since that depends on EMBEDDINGS_MODEL, so similarity is an
+-- exact scan of the tenant's embeddings; with a known dimension an HNSW
+-- index on (embedding::vector(N)) can be added per deployment.
+CREATE TABLE IF NOT EXISTS task_embeddings (
+    task_id UUID PRIMARY KEY REFERENCES tasks(id) ON DELETE CASCADE,
+    model VARCHAR(255) NOT NULL,
+    embedding vector NOT NULL,
+    content_hash CHAR(64) NOT NULL,
+    task_updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    embedded_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    tenant_id VARCHAR(63) NOT NULL DEFAULT COALESCE(current_tenant(), 'default')
+);
+
+CREATE INDEX idx_task_embeddings_model ON task_embeddings(model);
+
+CREATE TRIGGER trg_task_embeddings_tenant BEFORE INSERT ON task_embeddings
+    FOR EACH ROW EXECUTE FUNCTION set_tenant_from_task();
+
+ALTER TABLE task_embeddings ENABLE ROW LEVEL SECURITY;
+ALTER TABLE task_embeddings FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON task_embeddings USING (current_tenant() IS NULL OR tenant_id = current_tenant());
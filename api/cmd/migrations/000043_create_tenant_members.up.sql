@@ -0,0 +1,22 @@
+-- Users allowed to sign in to a tenant. The table is shared like users,
+-- so the API can check membership before the request is scoped to the
+-- tenant. Users holding credentials in a tenant, or owning its tasks,
+-- become members of it.
+CREATE TABLE IF NOT EXISTS tenant_members (
+    tenant_id VARCHAR(63) NOT NULL,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tenant_members_user_id ON tenant_members(user_id);
+
+INSERT INTO tenant_members (tenant_id, user_id)
+SELECT DISTINCT m.tenant_id, m.user_id FROM (
+    SELECT tenant_id, user_id FROM api_tokens
+    UNION SELECT tenant_id, user_id FROM refresh_tokens
+    UNION SELECT tenant_id, user_id FROM sessions
+    UNION SELECT tenant_id, owner_id FROM tasks WHERE owner_id IS NOT NULL
+) m
+JOIN users u ON u.id = m.user_id
+ON CONFLICT DO NOTHING;
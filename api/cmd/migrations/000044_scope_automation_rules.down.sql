@@ -0,0 +1,8 @@
+DROP POLICY IF EXISTS tenant_isolation ON automation_rules;
+ALTER TABLE automation_rules NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE automation_rules DISABLE ROW LEVEL SECURITY;
+
+DROP INDEX IF EXISTS idx_automation_rules_tenant_id;
+
+ALTER TABLE automation_rules DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE automation_rules DROP COLUMN IF EXISTS owner_id;
@@ -0,0 +1,16 @@
+-- Automation rules run in the tenant they were created in and as the user
+-- who created them, on the tasks that user reaches; owner_id is NULL for
+-- rules created by admins, which reach every task of their tenant.
+-- Existing rules belong to the default tenant, and those created by a
+-- known user run as that user.
+ALTER TABLE automation_rules ADD COLUMN owner_id VARCHAR(255);
+ALTER TABLE automation_rules ADD COLUMN tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+ALTER TABLE automation_rules ALTER COLUMN tenant_id SET DEFAULT COALESCE(current_tenant(), 'default');
+
+UPDATE automation_rules SET owner_id = created_by WHERE created_by IN (SELECT id FROM users);
+
+CREATE INDEX idx_automation_rules_tenant_id ON automation_rules(tenant_id);
+
+ALTER TABLE automation_rules ENABLE ROW LEVEL SECURITY;
+ALTER TABLE automation_rules FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON automation_rules USING (current_tenant() IS NULL OR tenant_id = current_tenant());
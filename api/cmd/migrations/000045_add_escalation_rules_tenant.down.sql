@@ -0,0 +1,7 @@
+DROP POLICY IF EXISTS tenant_isolation ON escalation_rules;
+ALTER TABLE escalation_rules NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE escalation_rules DISABLE ROW LEVEL SECURITY;
+
+DROP INDEX IF EXISTS idx_escalation_rules_tenant_id;
+
+ALTER TABLE escalation_rules DROP COLUMN IF EXISTS tenant_id;
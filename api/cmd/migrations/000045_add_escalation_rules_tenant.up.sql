@@ -0,0 +1,10 @@
+-- Escalation rules belong to the tenant they were created in and only
+-- escalate its tasks. Existing rules belong to the default tenant.
+ALTER TABLE escalation_rules ADD COLUMN tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+ALTER TABLE escalation_rules ALTER COLUMN tenant_id SET DEFAULT COALESCE(current_tenant(), 'default');
+
+CREATE INDEX idx_escalation_rules_tenant_id ON escalation_rules(tenant_id);
+
+ALTER TABLE escalation_rules ENABLE ROW LEVEL SECURITY;
+ALTER TABLE escalation_rules FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON escalation_rules USING (current_tenant() IS NULL OR tenant_id = current_tenant());
@@ -0,0 +1,3 @@
+-- Title prefix searches compare lowercased titles with LIKE 'prefix%',
+-- which text_pattern_ops can answer from the index whatever the collation
+CREATE INDEX IF NOT EXISTS idx_tasks_title_lower ON tasks (lower(title) text_pattern_ops);
@@ -0,0 +1,39 @@
+DROP POLICY IF EXISTS tenant_isolation ON task_sequences;
+ALTER TABLE task_sequences NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE task_sequences DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON projects;
+ALTER TABLE projects NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE projects DISABLE ROW LEVEL SECURITY;
+
+-- Fails if two tenants have tasks of the same reference
+DROP INDEX IF EXISTS idx_tasks_project_key_number;
+CREATE UNIQUE INDEX idx_tasks_project_key_number ON tasks(project_key, number);
+
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS fk_tasks_project_key;
+ALTER TABLE escalation_rules DROP CONSTRAINT IF EXISTS escalation_rules_project_key_fkey;
+ALTER TABLE automation_rules DROP CONSTRAINT IF EXISTS automation_rules_project_key_fkey;
+
+-- Each key keeps one project, the default tenant's if it has one, and the
+-- highest number handed out under it
+DELETE FROM projects WHERE (tenant_id, key) NOT IN (
+    SELECT DISTINCT ON (key) tenant_id, key FROM projects ORDER BY key, tenant_id = 'default' DESC, tenant_id
+);
+DELETE FROM task_sequences WHERE (tenant_id, project_key) NOT IN (
+    SELECT DISTINCT ON (project_key) tenant_id, project_key FROM task_sequences ORDER BY project_key, last_number DESC
+);
+
+ALTER TABLE task_sequences DROP CONSTRAINT task_sequences_pkey;
+ALTER TABLE task_sequences DROP COLUMN tenant_id;
+ALTER TABLE task_sequences ADD CONSTRAINT task_sequences_pkey PRIMARY KEY (project_key);
+
+ALTER TABLE projects DROP CONSTRAINT projects_pkey;
+ALTER TABLE projects DROP COLUMN tenant_id;
+ALTER TABLE projects ADD CONSTRAINT projects_pkey PRIMARY KEY (key);
+
+ALTER TABLE tasks
+    ADD CONSTRAINT fk_tasks_project_key FOREIGN KEY (project_key) REFERENCES projects(key);
+ALTER TABLE escalation_rules
+    ADD CONSTRAINT escalation_rules_project_key_fkey FOREIGN KEY (project_key) REFERENCES projects(key) ON DELETE CASCADE;
+ALTER TABLE automation_rules
+    ADD CONSTRAINT automation_rules_project_key_fkey FOREIGN KEY (project_key) REFERENCES projects(key) ON DELETE CASCADE;
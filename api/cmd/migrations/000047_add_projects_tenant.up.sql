@@ -0,0 +1,61 @@
+-- Projects and their numbering belong to a tenant, so tenants only see
+-- their own projects and may each use any key. Existing projects belong
+-- to the default tenant, and are copied into every other tenant that has
+-- tasks or rules in them, numbering included.
+ALTER TABLE tasks DROP CONSTRAINT fk_tasks_project_key;
+ALTER TABLE escalation_rules DROP CONSTRAINT escalation_rules_project_key_fkey;
+ALTER TABLE automation_rules DROP CONSTRAINT automation_rules_project_key_fkey;
+
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY['projects', 'task_sequences'] LOOP
+        EXECUTE format('ALTER TABLE %I ADD COLUMN tenant_id VARCHAR(63) NOT NULL DEFAULT %L', t, 'default');
+        EXECUTE format('ALTER TABLE %I ALTER COLUMN tenant_id SET DEFAULT COALESCE(current_tenant(), %L)', t, 'default');
+    END LOOP;
+END $$;
+
+-- Keys are unique within a tenant
+ALTER TABLE projects DROP CONSTRAINT projects_pkey;
+ALTER TABLE projects ADD CONSTRAINT projects_pkey PRIMARY KEY (tenant_id, key);
+ALTER TABLE task_sequences DROP CONSTRAINT task_sequences_pkey;
+ALTER TABLE task_sequences ADD CONSTRAINT task_sequences_pkey PRIMARY KEY (tenant_id, project_key);
+
+INSERT INTO projects (tenant_id, key, name, description, created_at, updated_at)
+SELECT used.tenant_id, p.key, p.name, p.description, p.created_at, p.updated_at
+FROM projects p
+JOIN (
+    SELECT tenant_id, project_key FROM tasks
+    UNION
+    SELECT tenant_id, project_key FROM escalation_rules
+    UNION
+    SELECT tenant_id, project_key FROM automation_rules
+) used ON used.project_key = p.key
+WHERE used.tenant_id <> 'default'
+ON CONFLICT (tenant_id, key) DO NOTHING;
+
+-- Copies continue the shared numbering, so no reference is handed out twice
+INSERT INTO task_sequences (tenant_id, project_key, last_number)
+SELECT p.tenant_id, s.project_key, s.last_number
+FROM task_sequences s
+JOIN projects p ON p.key = s.project_key AND p.tenant_id <> 'default'
+ON CONFLICT (tenant_id, project_key) DO NOTHING;
+
+ALTER TABLE tasks
+    ADD CONSTRAINT fk_tasks_project_key FOREIGN KEY (tenant_id, project_key) REFERENCES projects(tenant_id, key);
+ALTER TABLE escalation_rules
+    ADD CONSTRAINT escalation_rules_project_key_fkey FOREIGN KEY (tenant_id, project_key) REFERENCES projects(tenant_id, key) ON DELETE CASCADE;
+ALTER TABLE automation_rules
+    ADD CONSTRAINT automation_rules_project_key_fkey FOREIGN KEY (tenant_id, project_key) REFERENCES projects(tenant_id, key) ON DELETE CASCADE;
+
+DROP INDEX idx_tasks_project_key_number;
+CREATE UNIQUE INDEX idx_tasks_project_key_number ON tasks(tenant_id, project_key, number);
+
+ALTER TABLE projects ENABLE ROW LEVEL SECURITY;
+ALTER TABLE projects FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON projects USING (current_tenant() IS NULL OR tenant_id = current_tenant());
+
+ALTER TABLE task_sequences ENABLE ROW LEVEL SECURITY;
+ALTER TABLE task_sequences FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON task_sequences USING (current_tenant() IS NULL OR tenant_id = current_tenant());
@@ -0,0 +1,299 @@
+// Command smoketest exercises the task API of a deployed instance end to end.
+// It is intended to run as an Argo CD PostSync hook: a non-zero exit marks
+// the sync as failed so a bad rollout degrades application health.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/mutlitier_app/pkg/middleware"
+)
+
+// result is the outcome of a single smoke test step
+type result struct {
+	Name     string        `json:"name"`
+	Passed   bool          `json:"passed"`
+	Duration time.Duration `json:"duration_ns"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// task is the subset of the task response the smoke test asserts on
+type task struct {
+	ID      string `json:"id"`
+	Ref     string `json:"ref"`
+	Title   string `json:"title"`
+	Status  string `json:"status"`
+	Version int64  `json:"version"`
+}
+
+// ifMatch builds the If-Match header required by writes
+func ifMatch(version int64) http.Header {
+	return http.Header{"If-Match": {fmt.Sprintf(`"%d"`, version)}}
+}
+
+type client struct {
+	baseURL       string
+	signingSecret string
+	http          *http.Client
+}
+
+func main() {
+	baseURL := flag.String("base-url", envOr("SMOKE_BASE_URL", "http://localhost:8080"), "base URL of the deployed API")
+	format := flag.String("format", envOr("SMOKE_FORMAT", "text"), "report format: text, json or junit")
+	output := flag.String("output", envOr("SMOKE_OUTPUT", ""), "write the report to this file instead of stdout")
+	timeout := flag.Duration("timeout", 10*time.Second, "timeout for each HTTP request")
+	flag.Parse()
+
+	c := &client{
+		baseURL:       *baseURL,
+		signingSecret: os.Getenv("SIGNING_SECRET"),
+		http:          &http.Client{Timeout: *timeout},
+	}
+	results := run(c)
+
+	out := io.Writer(os.Stdout)
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to create report: %v\n", err)
+			os.Exit(2)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if err := report(out, *format, results); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write report: %v\n", err)
+		os.Exit(2)
+	}
+
+	for _, r := range results {
+		if !r.Passed {
+			os.Exit(1)
+		}
+	}
+}
+
+// run executes the CRUD cycle, stopping at the first failed step since
+// later steps depend on earlier ones
+func run(c *client) []result {
+	var (
+		results []result
+		created task
+	)
+
+	steps := []struct {
+		name string
+		fn   func() error
+	}{
+		{"health", func() error {
+			return c.do(http.MethodGet, "/health", nil, nil, http.StatusOK, nil)
+		}},
+		{"create", func() error {
+			body := map[string]string{"title": "smoketest " + time.Now().UTC().Format(time.RFC3339), "description": "created by cmd/smoketest"}
+			if err := c.do(http.MethodPost, "/tasks", nil, body, http.StatusCreated, &created); err != nil {
+				return err
+			}
+			if created.ID == "" || created.Status != "pending" {
+				return fmt.Errorf("unexpected task: %+v", created)
+			}
+			return nil
+		}},
+		{"get", func() error {
+			var got task
+			if err := c.do(http.MethodGet, "/tasks/"+created.ID, nil, nil, http.StatusOK, &got); err != nil {
+				return err
+			}
+			if got.ID != created.ID {
+				return fmt.Errorf("expected id %s, got %s", created.ID, got.ID)
+			}
+			return nil
+		}},
+		{"get_by_ref", func() error {
+			var got task
+			if err := c.do(http.MethodGet, "/tasks/by-ref/"+created.Ref, nil, nil, http.StatusOK, &got); err != nil {
+				return err
+			}
+			if got.ID != created.ID {
+				return fmt.Errorf("expected id %s, got %s", created.ID, got.ID)
+			}
+			return nil
+		}},
+		{"update", func() error {
+			var got task
+			body := map[string]string{"status": "in_progress"}
+			if err := c.do(http.MethodPut, "/tasks/"+created.ID, ifMatch(created.Version), body, http.StatusOK, &got); err != nil {
+				return err
+			}
+			if got.Status != "in_progress" {
+				return fmt.Errorf("expected status in_progress, got %s", got.Status)
+			}
+			if got.Version <= created.Version {
+				return fmt.Errorf("expected version to increase past %d, got %d", created.Version, got.Version)
+			}
+			return nil
+		}},
+		{"update_stale", func() error {
+			body := map[string]string{"status": "completed"}
+			return c.do(http.MethodPut, "/tasks/"+created.ID, ifMatch(created.Version), body, http.StatusPreconditionFailed, nil)
+		}},
+		{"list", func() error {
+			return c.do(http.MethodGet, "/tasks?per_page=1", nil, nil, http.StatusOK, nil)
+		}},
+		{"delete", func() error {
+			return c.do(http.MethodDelete, "/tasks/"+created.ID, http.Header{"If-Match": {"*"}}, nil, http.StatusNoContent, nil)
+		}},
+		{"get_deleted", func() error {
+			return c.do(http.MethodGet, "/tasks/"+created.ID, nil, nil, http.StatusNotFound, nil)
+		}},
+	}
+
+	for _, step := range steps {
+		start := time.Now()
+		err := step.fn()
+
+		r := result{Name: step.name, Passed: err == nil, Duration: time.Since(start)}
+		if err != nil {
+			r.Error = err.Error()
+		}
+		results = append(results, r)
+
+		if err != nil {
+			break
+		}
+	}
+
+	return results
+}
+
+// do sends a JSON request with optional extra headers and asserts the response status
+func (c *client) do(method, path string, header http.Header, body any, expectedStatus int, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	if c.signingSecret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		nonce := uuid.NewString()
+		req.Header.Set(middleware.TimestampHeader, timestamp)
+		req.Header.Set(middleware.NonceHeader, nonce)
+		req.Header.Set(middleware.SignatureHeader, middleware.Sign(c.signingSecret, timestamp, nonce, method, req.URL.RequestURI(), payload))
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != expectedStatus {
+		return fmt.Errorf("%s %s: expected status %d, got %d: %s", method, path, expectedStatus, resp.StatusCode, bytes.TrimSpace(respBody))
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("%s %s: invalid response: %w", method, path, err)
+		}
+	}
+
+	return nil
+}
+
+// JUnit report structure understood by CI systems
+type junitSuite struct {
+	XMLName  xml.Name    `xml:"testsuite"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Time     float64     `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name    string        `xml:"name,attr"`
+	Time    float64       `xml:"time,attr"`
+	Failure *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+}
+
+func report(w io.Writer, format string, results []result) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+
+	case "junit":
+		suite := junitSuite{Name: "smoketest", Tests: len(results)}
+		for _, r := range results {
+			tc := junitCase{Name: r.Name, Time: r.Duration.Seconds()}
+			if !r.Passed {
+				suite.Failures++
+				tc.Failure = &junitFailure{Message: r.Error}
+			}
+			suite.Time += r.Duration.Seconds()
+			suite.Cases = append(suite.Cases, tc)
+		}
+		if _, err := io.WriteString(w, xml.Header); err != nil {
+			return err
+		}
+		enc := xml.NewEncoder(w)
+		enc.Indent("", "  ")
+		return enc.Encode(suite)
+
+	case "text":
+		for _, r := range results {
+			status := "PASS"
+			if !r.Passed {
+				status = "FAIL"
+			}
+			fmt.Fprintf(w, "%s  %-12s %s", status, r.Name, r.Duration.Round(time.Millisecond))
+			if r.Error != "" {
+				fmt.Fprintf(w, "  %s", r.Error)
+			}
+			fmt.Fprintln(w)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+func envOr(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,49 @@
+package analytics
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkdown_UpToDate(t *testing.T) {
+	doc, err := os.ReadFile("../../docs/analytics-schema.md")
+	require.NoError(t, err)
+	assert.Equal(t, Markdown(), string(doc), "run go generate ./internal/analytics")
+}
+
+func TestEncode(t *testing.T) {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	facts := []EventFact{
+		NewEventFact(&model.TaskEvent{ID: 1, Type: model.EventTaskCreated, TaskID: "a", CreatedAt: created,
+			Task: &model.TaskResponse{Status: model.StatusPending, Version: 1}}),
+		NewEventFact(&model.TaskEvent{ID: 2, Type: model.EventTaskDeleted, TaskID: "a", CreatedAt: created}),
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, FormatCSV, facts))
+	assert.Equal(t, "id,type,task_id,status,version,created_at\n"+
+		"1,task.created,a,pending,1,2026-01-02T03:04:05Z\n"+
+		"2,task.deleted,a,,,2026-01-02T03:04:05Z\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, Encode(&buf, FormatParquet, facts))
+	rows, err := parquet.Read[EventFact](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	assert.Equal(t, facts, rows)
+
+	file, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	createdAt, ok := file.Schema().Lookup("created_at")
+	require.True(t, ok)
+	assert.Equal(t, "TIMESTAMP(isAdjustedToUTC=true,unit=MICROS)", createdAt.Node.Type().LogicalType().String())
+	status, ok := file.Schema().Lookup("status")
+	require.True(t, ok)
+	assert.True(t, status.Node.Optional())
+}
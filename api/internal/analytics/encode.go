@@ -0,0 +1,94 @@
+package analytics
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Supported file formats
+const (
+	FormatParquet = "parquet"
+	FormatCSV     = "csv"
+)
+
+// ContentType returns the MIME type of a format
+func ContentType(format string) string {
+	if format == FormatCSV {
+		return "text/csv"
+	}
+	return "application/vnd.apache.parquet"
+}
+
+// Encode writes rows of a fact struct in the given format
+func Encode[T any](w io.Writer, format string, rows []T) error {
+	switch format {
+	case FormatParquet:
+		return encodeParquet(w, rows)
+	case FormatCSV:
+		return encodeCSV(w, rows)
+	default:
+		return fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+func encodeParquet[T any](w io.Writer, rows []T) error {
+	if err := parquet.Write(w, rows, parquet.Compression(&parquet.Zstd)); err != nil {
+		return fmt.Errorf("failed to write parquet file: %w", err)
+	}
+	return nil
+}
+
+// encodeCSV writes a header row of column names, timestamps as RFC 3339
+// and nulls as empty fields
+func encodeCSV[T any](w io.Writer, rows []T) error {
+	var zero T
+	columns := Columns(zero)
+
+	cw := csv.NewWriter(w)
+
+	record := make([]string, len(columns))
+	for i, column := range columns {
+		record[i] = column.Name
+	}
+	if err := cw.Write(record); err != nil {
+		return fmt.Errorf("failed to write csv header: %w", err)
+	}
+
+	for _, row := range rows {
+		v := reflect.ValueOf(row)
+		for i, column := range columns {
+			record[i] = formatCSV(v.Field(column.field), column.Timestamp)
+		}
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("failed to write csv row: %w", err)
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatCSV(v reflect.Value, timestamp bool) string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Int64:
+		if timestamp {
+			return time.UnixMicro(v.Int()).UTC().Format(time.RFC3339Nano)
+		}
+		return strconv.FormatInt(v.Int(), 10)
+	default:
+		return v.String()
+	}
+}
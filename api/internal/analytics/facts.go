@@ -0,0 +1,157 @@
+// Package analytics defines the facts exported for downstream analytics
+// and how they are encoded and stored.
+package analytics
+
+//go:generate go run ../../cmd/analytics-schema -o ../../docs/analytics-schema.md
+
+import (
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// Table is an exported dataset, written once per export date under
+// <name>/date=YYYY-MM-DD/ so DuckDB and BigQuery see a partition column
+type Table struct {
+	Name        string
+	Description string
+	Row         any
+}
+
+// Tables lists every exported dataset, in the order they are documented
+var Tables = []Table{
+	{Name: "tasks", Description: "Snapshot of every live task at export time.", Row: TaskFact{}},
+	{Name: "task_events", Description: "Task writes recorded since the previous export, the audit trail of creates, updates, deletes and restores.", Row: EventFact{}},
+	{Name: "audit_logs", Description: "Mutations made by identified callers since the previous export. The changed values are not exported.", Row: AuditFact{}},
+	{Name: "security_events", Description: "Authentication and authorization events since the previous export: sign-ins, failed logins, token use, denied permissions and detected abuse.", Row: SecurityFact{}},
+}
+
+// TaskFact is one row of the tasks table
+type TaskFact struct {
+	ID          string `parquet:"id" doc:"Task UUID"`
+	Ref         string `parquet:"ref" doc:"Human-readable reference such as TASK-42"`
+	ProjectKey  string `parquet:"project_key" doc:"Project the task belongs to"`
+	Number      int64  `parquet:"number" doc:"Sequential number within the project"`
+	Title       string `parquet:"title" doc:"Task title"`
+	Description string `parquet:"description" doc:"Task description, possibly empty"`
+	Status      string `parquet:"status" doc:"pending, in_progress, completed or cancelled"`
+	Priority    string `parquet:"priority" doc:"low, medium, high or urgent"`
+	DueDate     *int64 `parquet:"due_date,optional,timestamp(microsecond)" doc:"When the task is due (UTC), null when it has no due date"`
+	Version     int64  `parquet:"version" doc:"Optimistic concurrency version, incremented on every write"`
+	CreatedAt   int64  `parquet:"created_at,timestamp(microsecond)" doc:"When the task was created (UTC)"`
+	UpdatedAt   int64  `parquet:"updated_at,timestamp(microsecond)" doc:"When the task was last written (UTC)"`
+	ExportedAt  int64  `parquet:"exported_at,timestamp(microsecond)" doc:"When the snapshot was taken (UTC)"`
+}
+
+// NewTaskFact flattens a task for export
+func NewTaskFact(task *model.Task, exportedAt time.Time) TaskFact {
+	var dueDate *int64
+	if task.DueDate != nil {
+		micros := task.DueDate.UnixMicro()
+		dueDate = &micros
+	}
+
+	return TaskFact{
+		ID:          task.ID,
+		Ref:         task.Ref().String(),
+		ProjectKey:  task.ProjectKey,
+		Number:      task.Number,
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      string(task.Status),
+		Priority:    string(task.Priority),
+		DueDate:     dueDate,
+		Version:     task.Version,
+		CreatedAt:   task.CreatedAt.UnixMicro(),
+		UpdatedAt:   task.UpdatedAt.UnixMicro(),
+		ExportedAt:  exportedAt.UnixMicro(),
+	}
+}
+
+// EventFact is one row of the task_events table
+type EventFact struct {
+	ID        int64   `parquet:"id" doc:"Event ID, increasing in write order"`
+	Type      string  `parquet:"type" doc:"task.created, task.updated, task.deleted or task.restored"`
+	TaskID    string  `parquet:"task_id" doc:"UUID of the task written"`
+	Status    *string `parquet:"status,optional" doc:"Task status after the write, null for deletes"`
+	Version   *int64  `parquet:"version,optional" doc:"Task version after the write, null for deletes"`
+	CreatedAt int64   `parquet:"created_at,timestamp(microsecond)" doc:"When the write happened (UTC)"`
+}
+
+// NewEventFact flattens a task event for export
+func NewEventFact(event *model.TaskEvent) EventFact {
+	fact := EventFact{
+		ID:        event.ID,
+		Type:      string(event.Type),
+		TaskID:    event.TaskID,
+		CreatedAt: event.CreatedAt.UnixMicro(),
+	}
+	if event.Task != nil {
+		status := string(event.Task.Status)
+		fact.Status = &status
+		fact.Version = &event.Task.Version
+	}
+	return fact
+}
+
+// AuditFact is one row of the audit_logs table
+type AuditFact struct {
+	ID        int64  `parquet:"id" doc:"Audit log ID"`
+	User      string `parquet:"user" doc:"Who made the change"`
+	TokenID   string `parquet:"token_id" doc:"API token the change was made with, empty for other credentials"`
+	Method    string `parquet:"method" doc:"HTTP method of the request"`
+	Endpoint  string `parquet:"endpoint" doc:"Route pattern such as /tasks/{id}"`
+	EntityID  string `parquet:"entity_id" doc:"ID of the entity changed, empty when the route names none"`
+	Status    int64  `parquet:"status" doc:"HTTP status of the response"`
+	IP        string `parquet:"ip" doc:"Client IP address"`
+	RequestID string `parquet:"request_id" doc:"Request ID, to correlate with logs"`
+	CreatedAt int64  `parquet:"created_at,timestamp(microsecond)" doc:"When the request was made (UTC)"`
+}
+
+// NewAuditFact flattens an audit log for export
+func NewAuditFact(log *model.AuditLog) AuditFact {
+	return AuditFact{
+		ID:        log.ID,
+		User:      log.User,
+		TokenID:   log.TokenID,
+		Method:    log.Method,
+		Endpoint:  log.Endpoint,
+		EntityID:  log.EntityID,
+		Status:    int64(log.Status),
+		IP:        log.IP,
+		RequestID: log.RequestID,
+		CreatedAt: log.CreatedAt.UnixMicro(),
+	}
+}
+
+// SecurityFact is one row of the security_events table
+type SecurityFact struct {
+	ID         int64  `parquet:"id" doc:"Security event ID"`
+	Type       string `parquet:"type" doc:"Event type such as login_failed, token_used or permission_denied"`
+	User       string `parquet:"user" doc:"User the event is about, possibly unknown for failed logins"`
+	Credential string `parquet:"credential" doc:"Credential presented: api_token, admin_token, proxy_header, password, session_token, refresh_token or oidc"`
+	TokenID    string `parquet:"token_id" doc:"API token involved, empty for other credentials"`
+	Reason     string `parquet:"reason" doc:"Why the request was refused, empty otherwise"`
+	IP         string `parquet:"ip" doc:"Client IP address"`
+	Method     string `parquet:"method" doc:"HTTP method of the request"`
+	Path       string `parquet:"path" doc:"Request path"`
+	RequestID  string `parquet:"request_id" doc:"Request ID, to correlate with logs"`
+	CreatedAt  int64  `parquet:"created_at,timestamp(microsecond)" doc:"When the event happened (UTC)"`
+}
+
+// NewSecurityFact flattens a security event for export
+func NewSecurityFact(event *model.SecurityEvent) SecurityFact {
+	return SecurityFact{
+		ID:         event.ID,
+		Type:       string(event.Type),
+		User:       event.User,
+		Credential: event.Credential,
+		TokenID:    event.TokenID,
+		Reason:     event.Reason,
+		IP:         event.IP,
+		Method:     event.Method,
+		Path:       event.Path,
+		RequestID:  event.RequestID,
+		CreatedAt:  event.CreatedAt.UnixMicro(),
+	}
+}
@@ -0,0 +1,74 @@
+package analytics
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Column describes one exported column, derived from a fact struct's tags
+type Column struct {
+	Name        string
+	Type        string
+	Nullable    bool
+	Timestamp   bool
+	Description string
+	field       int
+}
+
+// Columns returns the columns of a fact struct in field order. Pointer
+// fields are nullable and int64 fields tagged timestamp hold microseconds.
+func Columns(row any) []Column {
+	t := reflect.TypeOf(row)
+	columns := make([]Column, 0, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("parquet"), ",")
+
+		column := Column{
+			Name:        name,
+			Nullable:    field.Type.Kind() == reflect.Pointer,
+			Timestamp:   strings.Contains(options, "timestamp"),
+			Description: field.Tag.Get("doc"),
+			field:       i,
+		}
+		kind := field.Type.Kind()
+		if column.Nullable {
+			kind = field.Type.Elem().Kind()
+		}
+		switch {
+		case column.Timestamp:
+			column.Type = "TIMESTAMP"
+		case kind == reflect.String:
+			column.Type = "STRING"
+		default:
+			column.Type = "INT64"
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+// Markdown documents every exported table. docs/analytics-schema.md is
+// generated from it, so the documentation cannot drift from the structs.
+func Markdown() string {
+	var b strings.Builder
+	b.WriteString("# Analytics Export Schema\n\n")
+	b.WriteString("<!-- Generated by go generate ./internal/analytics, do not edit. -->\n\n")
+	b.WriteString("Files are written under `ANALYTICS_EXPORT_URL` to `<table>/date=YYYY-MM-DD/`, partitioned by export date. Timestamps are UTC with microsecond precision.\n")
+
+	for _, table := range Tables {
+		fmt.Fprintf(&b, "\n## %s\n\n%s\n\n", table.Name, table.Description)
+		b.WriteString("| Column | Type | Nullable | Description |\n")
+		b.WriteString("|--------|------|----------|-------------|\n")
+		for _, column := range Columns(table.Row) {
+			nullable := "no"
+			if column.Nullable {
+				nullable = "yes"
+			}
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", column.Name, column.Type, nullable, column.Description)
+		}
+	}
+	return b.String()
+}
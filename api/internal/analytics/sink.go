@@ -0,0 +1,110 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+)
+
+// Sink stores export files
+type Sink interface {
+	// Put stores data under key, replacing any existing file
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+
+	// Location describes where key is stored, for logs and API responses
+	Location(key string) string
+}
+
+// NewSink creates the Sink for ANALYTICS_EXPORT_URL: s3://bucket/prefix for
+// S3-compatible object storage, or file:///path for a local directory
+func NewSink(cfg *config.AnalyticsConfig) (Sink, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid export URL: %w", err)
+	}
+
+	switch u.Scheme {
+	case "s3":
+		// Without static keys fall back to the instance or pod IAM role
+		creds := credentials.NewIAM("")
+		if cfg.S3AccessKey != "" {
+			creds = credentials.NewStaticV4(cfg.S3AccessKey, cfg.S3SecretKey, "")
+		}
+		client, err := minio.New(cfg.S3Endpoint, &minio.Options{
+			Creds:  creds,
+			Secure: cfg.S3UseSSL,
+			Region: cfg.S3Region,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create s3 client: %w", err)
+		}
+		return &S3Sink{client: client, bucket: u.Host, prefix: strings.Trim(u.Path, "/")}, nil
+	case "file":
+		return &FileSink{dir: u.Path}, nil
+	default:
+		return nil, fmt.Errorf("unsupported export URL scheme %q, use s3:// or file://", u.Scheme)
+	}
+}
+
+// S3Sink stores files in an S3-compatible bucket
+type S3Sink struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+// Put implements Sink
+func (s *S3Sink) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, s.object(key), bytes.NewReader(data), int64(len(data)),
+		minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", s.Location(key), err)
+	}
+	return nil
+}
+
+// Location implements Sink
+func (s *S3Sink) Location(key string) string {
+	return "s3://" + s.bucket + "/" + s.object(key)
+}
+
+func (s *S3Sink) object(key string) string {
+	return path.Join(s.prefix, key)
+}
+
+// FileSink stores files in a local directory, mainly for development
+type FileSink struct {
+	dir string
+}
+
+// Put implements Sink, writing through a temporary file so readers never
+// see a partial export
+func (s *FileSink) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	target := s.Location(key)
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	tmp := target + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		return fmt.Errorf("failed to move %s into place: %w", target, err)
+	}
+	return nil
+}
+
+// Location implements Sink
+func (s *FileSink) Location(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(key))
+}
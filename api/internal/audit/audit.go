@@ -0,0 +1,52 @@
+// Package audit carries the identity behind a request down to the code
+// that records who changed what
+package audit
+
+import "context"
+
+// Anonymous is the actor of requests that carry no identity
+const Anonymous = "anonymous"
+
+type contextKey struct{}
+
+// WithActor returns a context attributing writes to actor
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, contextKey{}, actor)
+}
+
+// Actor returns who the request acts as, Anonymous when unknown
+func Actor(ctx context.Context) string {
+	if actor, ok := ctx.Value(contextKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return Anonymous
+}
+
+type changeKey struct{}
+
+// Change is what an audited request changed, filled in while it is handled
+type Change struct {
+	// Before is the state of the entity before the change
+	Before any
+}
+
+// WithChange returns a context whose request is audited, and the Change
+// code handling it fills in
+func WithChange(ctx context.Context) (context.Context, *Change) {
+	change := &Change{}
+	return context.WithValue(ctx, changeKey{}, change), change
+}
+
+// Audited reports whether the request is audited, so the state before a
+// change is worth reading
+func Audited(ctx context.Context) bool {
+	_, ok := ctx.Value(changeKey{}).(*Change)
+	return ok
+}
+
+// Before records the state of the entity the request is about to change
+func Before(ctx context.Context, state any) {
+	if change, ok := ctx.Value(changeKey{}).(*Change); ok {
+		change.Before = state
+	}
+}
@@ -0,0 +1,99 @@
+// Package auth describes who a request is authenticated as and what it
+// may do, independent of how the credentials were presented
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+// ErrInvalidToken is returned for unknown, revoked or expired credentials
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// Scope is a permission granted to a principal
+type Scope string
+
+const (
+	ScopeReadTasks  Scope = "tasks:read"
+	ScopeWriteTasks Scope = "tasks:write"
+	ScopeReadStats  Scope = "stats:read"
+	ScopeAdmin      Scope = "admin"
+)
+
+// Scopes returns all known scopes
+func Scopes() []Scope {
+	return []Scope{ScopeReadTasks, ScopeWriteTasks, ScopeReadStats, ScopeAdmin}
+}
+
+// legacyScopes maps the names scopes had before they were split by
+// resource to their current names
+var legacyScopes = map[string]Scope{
+	"read:tasks":  ScopeReadTasks,
+	"write:tasks": ScopeWriteTasks,
+}
+
+// ParseScope returns the scope named name, accepting the legacy names
+// read:tasks and write:tasks, and reports whether it is known
+func ParseScope(name string) (Scope, bool) {
+	if scope, ok := legacyScopes[name]; ok {
+		return scope, true
+	}
+	scope := Scope(name)
+	return scope, scope.Valid()
+}
+
+// Valid reports whether the scope is a known scope
+func (s Scope) Valid() bool {
+	return slices.Contains(Scopes(), s)
+}
+
+// Principal is an authenticated caller
+type Principal struct {
+	User string
+	// TokenID is set when the request authenticated with an API token
+	TokenID string
+	// SessionID is set when it authenticated with a session token
+	SessionID string
+	Scopes    []Scope
+	// Tenant is set when the credentials were issued in a tenant, which
+	// the request is then bound to
+	Tenant string
+}
+
+// Has reports whether the principal was granted scope
+func (p *Principal) Has(scope Scope) bool {
+	return p != nil && slices.Contains(p.Scopes, scope)
+}
+
+type contextKey struct{}
+
+// WithPrincipal returns a context authenticated as p
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the request's principal, nil when anonymous
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(contextKey{}).(*Principal)
+	return p
+}
+
+// Client describes the device a sign-in comes from
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+type clientKey struct{}
+
+// WithClient returns a context whose sign-ins come from c
+func WithClient(ctx context.Context, c Client) context.Context {
+	return context.WithValue(ctx, clientKey{}, c)
+}
+
+// ClientFrom returns the client set by WithClient, empty when unknown
+func ClientFrom(ctx context.Context) Client {
+	c, _ := ctx.Value(clientKey{}).(Client)
+	return c
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"slices"
+)
+
+// Certificate is the identity of a client certificate verified by mutual
+// TLS
+type Certificate struct {
+	CommonName string
+	DNSNames   []string
+	URIs       []string
+	Emails     []string
+	IPs        []string
+	Serial     string
+}
+
+// CertificateOf returns the identity of a verified client certificate
+func CertificateOf(cert *x509.Certificate) *Certificate {
+	c := &Certificate{
+		CommonName: cert.Subject.CommonName,
+		DNSNames:   cert.DNSNames,
+		Emails:     cert.EmailAddresses,
+		Serial:     cert.SerialNumber.String(),
+	}
+	for _, uri := range cert.URIs {
+		c.URIs = append(c.URIs, uri.String())
+	}
+	for _, ip := range cert.IPAddresses {
+		c.IPs = append(c.IPs, ip.String())
+	}
+	return c
+}
+
+// Names returns the common name followed by every subject alternative name
+func (c *Certificate) Names() []string {
+	names := []string{}
+	if c.CommonName != "" {
+		names = append(names, c.CommonName)
+	}
+	return slices.Concat(names, c.DNSNames, c.URIs, c.Emails, c.IPs)
+}
+
+// Matches reports whether the certificate's common name or one of its
+// subject alternative names is among identities
+func (c *Certificate) Matches(identities []string) bool {
+	if c == nil {
+		return false
+	}
+	return slices.ContainsFunc(c.Names(), func(name string) bool {
+		return slices.Contains(identities, name)
+	})
+}
+
+type certificateKey struct{}
+
+// WithCertificate returns a context whose client presented c
+func WithCertificate(ctx context.Context, c *Certificate) context.Context {
+	return context.WithValue(ctx, certificateKey{}, c)
+}
+
+// CertificateFrom returns the client certificate set by WithCertificate,
+// nil when the client presented none
+func CertificateFrom(ctx context.Context) *Certificate {
+	c, _ := ctx.Value(certificateKey{}).(*Certificate)
+	return c
+}
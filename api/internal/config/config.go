@@ -14,9 +14,58 @@ import (
 type Config struct {
 	SrvPort        string
 	Environment    string
+	BasePath       string // BASE_PATH: path prefix every route is served under, such as /api
+	Replicas       int    // REPLICAS: replicas serving the API, which then need secrets they share
+	Listeners      ListenerConfig
 	DatabaseConfig DatabaseConfig
 	CORSConfig     CORSConfig
 	LogConfig      LogConfig
+	AdminConfig    AdminConfig
+	Auth           AuthConfig
+	JWT            JWTConfig
+	OIDC           OIDCConfig
+	Security       SecurityConfig
+	Audit          AuditConfig
+	SigningConfig  SigningConfig
+	RateLimit      RateLimitConfig
+	Abuse          AbuseConfig
+	IPFilter       IPFilterConfig
+	TLS            TLSConfig
+	Concurrency    ConcurrencyConfig
+	Tenancy        TenancyConfig
+	QueryGuard     QueryGuardConfig
+	QueryCount     QueryCountConfig
+	Autoscaling    AutoscalingConfig
+	Tasks          TaskConfig
+	Recurrence     RecurrenceConfig
+	Escalation     EscalationConfig
+	Automation     AutomationConfig
+	CDC            CDCConfig
+	Comments       CommentConfig
+	Notifications  NotificationConfig
+	Workers        WorkerConfig
+	KVStore        KVStoreConfig
+	Idempotency    IdempotencyConfig
+	Batch          BatchConfig
+	Demo           DemoConfig
+	Health         HealthConfig
+	Degradation    DegradationConfig
+	Failover       FailoverConfig
+	Expansions     ExpansionConfig
+	FeatureToggles FeatureToggleConfig
+	Shadow         ShadowConfig
+	Events         EventsConfig
+	Search         SearchConfig
+	AI             AIConfig
+	Embeddings     EmbeddingsConfig
+	Analytics      AnalyticsConfig
+	Attachments    AttachmentConfig
+	Snapshots      SnapshotConfig
+	PublicIDs      PublicIDConfig
+	JSON           JSONConfig
+	Watchdog       WatchdogConfig
+
+	overrides []Override
 }
 
 type DatabaseConfig struct {
@@ -30,14 +79,16 @@ type DatabaseConfig struct {
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
 	ConnMaxIdleTime time.Duration
+	ReplicaHost     string // DB_REPLICA_HOST: read replica serving reads during a failover, empty for none
+	ReplicaPort     int    // DB_REPLICA_PORT
 }
 
 // CORSConfig holds CORS settings - all configurable via environment variables
 type CORSConfig struct {
 	AllowedOrigins   []string // * or list of origins
-	AllowedMethods   []string // GET, POST, PUT, DELETE, OPTIONS
+	AllowedMethods   []string // GET, POST, PUT, PATCH, DELETE, OPTIONS
 	AllowedHeaders   []string // Accept, Authorization, Content-Type, X-Request-ID
-	ExposedHeaders   []string // X-Request-ID
+	ExposedHeaders   []string // X-Request-ID, X-Trace-ID
 	AllowCredentials bool     
 	MaxAge           int     
 }
@@ -49,6 +100,552 @@ type LogConfig struct {
 	TimeFormat string // LOG_TIME_FORMAT: unix, rfc3339, etc.
 }
 
+// ListenerConfig moves operational endpoints off the API port, so they can
+// be kept off the public ingress. An empty port keeps them on PORT.
+type ListenerConfig struct {
+	AdminPort       string        // ADMIN_PORT: serve /admin on its own listener
+	MetricsPort     string        // METRICS_PORT: serve /metrics on its own listener
+	DebugPort       string        // DEBUG_PORT: serve /debug/pprof on its own listener, empty disables profiling
+	ShutdownTimeout time.Duration // SHUTDOWN_TIMEOUT: how long each listener may take to drain its requests
+}
+
+// AdminConfig controls the operational /admin endpoints
+type AdminConfig struct {
+	Enabled bool   // ADMIN_ENABLED: expose /admin routes
+	Token   string // ADMIN_TOKEN: required in X-Admin-Token for admin-only features
+}
+
+// AuthConfig controls who requests are authenticated as. API tokens are
+// always accepted; users sign in through a trusted proxy naming them in
+// UserHeader.
+type AuthConfig struct {
+	Required        bool          // AUTH_REQUIRED: reject anonymous requests to task, tag and activity routes
+	UserHeader      string        // AUTH_USER_HEADER: header set by a trusted proxy naming the signed-in user
+	TrustedProxies  []string      // AUTH_TRUSTED_PROXIES: CIDRs of the proxies whose UserHeader is believed
+	TokenDefaultTTL time.Duration // API_TOKEN_DEFAULT_TTL: lifetime of tokens created without expires_at
+	TokenMaxTTL     time.Duration // API_TOKEN_MAX_TTL: longest lifetime a token may be given
+	Denial          string        // AUTHZ_DENIAL: hide (404, as if missing) or forbid (403) another user's resource
+}
+
+// HideDenied reports whether requests for another user's resource are
+// answered as if the resource did not exist
+func (c *AuthConfig) HideDenied() bool {
+	return c.Denial != "forbid"
+}
+
+// JWTConfig controls password sign-in and the session tokens issued by
+// POST /auth/login
+type JWTConfig struct {
+	Secret     string        // JWT_SECRET: HMAC-SHA256 key signing session tokens, empty disables /auth
+	Issuer     string        // JWT_ISSUER: iss claim set on session tokens and required of them
+	TTL        time.Duration // JWT_TTL: how long a session token is valid
+	RefreshTTL time.Duration // JWT_REFRESH_TTL: how long a sign-in can be kept going with refresh tokens
+}
+
+// Enabled reports whether users can register and sign in with a password
+func (c *JWTConfig) Enabled() bool {
+	return c.Secret != ""
+}
+
+// OIDCConfig controls sign-in through an OpenID Connect provider
+type OIDCConfig struct {
+	IssuerURL     string        // OIDC_ISSUER_URL: provider whose discovery document is at /.well-known/openid-configuration, empty disables OIDC
+	ClientID      string        // OIDC_CLIENT_ID: client registered with the provider
+	ClientSecret  string        // OIDC_CLIENT_SECRET: secret of the client
+	RedirectURL   string        // OIDC_REDIRECT_URL: public URL of GET /auth/oidc/callback, as registered with the provider
+	Scopes        []string      // OIDC_SCOPES: scopes requested, openid is always added
+	UsernameClaim string        // OIDC_USERNAME_CLAIM: ID token claim new users are named after
+	LoginTimeout  time.Duration // OIDC_LOGIN_TIMEOUT: how long a user has to complete the provider's sign-in
+}
+
+// Enabled reports whether users can sign in through an OIDC provider
+func (c *OIDCConfig) Enabled() bool {
+	return c.IssuerURL != "" && c.ClientID != ""
+}
+
+// SecurityConfig controls the audit trail of authentication events
+type SecurityConfig struct {
+	Retention     time.Duration // SECURITY_EVENTS_RETENTION: how long security events are kept
+	PurgeInterval time.Duration // SECURITY_EVENTS_PURGE_INTERVAL: how often expired security events are removed
+	DedupWindow   time.Duration // SECURITY_EVENTS_DEDUP_WINDOW: successful sign-ins and token uses are recorded once per window per credential
+	BufferSize    int           // SECURITY_EVENTS_BUFFER: events queued for writing before new ones are dropped
+	BatchSize     int           // SECURITY_EVENTS_BATCH_SIZE: most events written in one insert
+}
+
+// SigningConfig controls HMAC request signing and replay protection
+type SigningConfig struct {
+	Secret  string        // SIGNING_SECRET: shared HMAC secret, empty disables signing
+	Window  time.Duration // SIGNING_WINDOW: max clock skew and nonce retention
+	MaxBody int64         // SIGNING_MAX_BODY_BYTES: largest body read to verify a signature
+}
+
+// AuditConfig controls the audit log of mutations made by identified callers
+type AuditConfig struct {
+	Enabled       bool          // AUDIT_ENABLED
+	Retention     time.Duration // AUDIT_RETENTION: how long audit logs are kept
+	PurgeInterval time.Duration // AUDIT_PURGE_INTERVAL: how often expired audit logs are removed
+	BufferSize    int           // AUDIT_BUFFER: audit logs queued for writing before new ones are dropped
+	BatchSize     int           // AUDIT_BATCH_SIZE: most audit logs written in one insert
+}
+
+// RateLimitConfig holds the two-tier per-client rate limit
+type RateLimitConfig struct {
+	Enabled   bool             // RATE_LIMIT_ENABLED
+	SoftLimit int              // RATE_LIMIT_SOFT: requests per window before warning headers
+	HardLimit int              // RATE_LIMIT_HARD: requests per window before 429s
+	Window    time.Duration    // RATE_LIMIT_WINDOW
+	Algorithm string           // RATE_LIMIT_ALGORITHM: window, or token-bucket refilling HardLimit per Window
+	Key       string           // RATE_LIMIT_KEY: what a client is, ip, user or key
+	Scope     string           // separates the counters of independent limiters
+	Groups    []RateLimitGroup // RATE_LIMIT_GROUPS: name:soft:hard:window,... for expensive routes
+}
+
+// Rate limit algorithms
+const (
+	RateLimitWindow      = "window"
+	RateLimitTokenBucket = "token-bucket"
+)
+
+// Rate limit keys. Signed-in clients fall back to their IP when anonymous,
+// and API keys to their user when signed in otherwise.
+const (
+	RateLimitByIP   = "ip"
+	RateLimitByUser = "user"
+	RateLimitByKey  = "key"
+)
+
+// defaultRateLimitGroups are the RATE_LIMIT_GROUPS of expensive routes
+var defaultRateLimitGroups = []string{"search:20:30:1m", "export:2:2:1h", "import:5:5:1h", "stats:30:60:1m", "ai:10:20:1m"}
+
+// AbuseConfig controls temporary blocks of clients whose traffic looks like
+// scanning, credential stuffing or oversized uploads. Limits count events
+// per client per Window; 0 disables that check.
+type AbuseConfig struct {
+	Enabled           bool          // ABUSE_DETECTION_ENABLED
+	Window            time.Duration // ABUSE_WINDOW: period the limits below are counted over
+	BlockDuration     time.Duration // ABUSE_BLOCK_DURATION: how long an offending client is blocked
+	NotFoundLimit     int           // ABUSE_NOT_FOUND_LIMIT: 404 responses per window before a block
+	LoginFailureLimit int           // ABUSE_LOGIN_FAILURE_LIMIT: failed sign-ins per window before a block
+	MaxPayloadBytes   int64         // ABUSE_MAX_PAYLOAD_BYTES: request bodies larger than this count as oversized
+	OversizedLimit    int           // ABUSE_OVERSIZED_LIMIT: oversized request bodies per window before a block
+}
+
+// IPFilterConfig holds CIDR allow and deny rules for every route and for
+// admin routes. Deny rules win; a scope with allow rules rejects addresses
+// matching none of them. Bare addresses are single-address prefixes.
+type IPFilterConfig struct {
+	Allow          []string      // IP_ALLOW: CIDRs allowed to reach any route, empty allows all
+	Deny           []string      // IP_DENY: CIDRs rejected on every route
+	AdminAllow     []string      // IP_ADMIN_ALLOW: CIDRs allowed to reach admin routes, empty allows all
+	AdminDeny      []string      // IP_ADMIN_DENY: CIDRs rejected on admin routes
+	RulesFile      string        // IP_RULES_FILE: more rules as "scope allow|deny cidr" lines, e.g. a mounted ConfigMap
+	ReloadInterval time.Duration // IP_RULES_RELOAD_INTERVAL: how often IP_RULES_FILE is checked for changes
+	TrustedProxies []string      // TRUSTED_PROXIES: CIDRs of the proxies whose X-Forwarded-For names the client
+}
+
+// TLSConfig serves the API and admin listeners over TLS. With MTLS the
+// handshake also requires a client certificate chaining to ClientCAFile,
+// and Allow restricts route groups to the certificate identities listed.
+type TLSConfig struct {
+	CertFile     string              // TLS_CERT_FILE: PEM certificate chain of the server, empty serves plain HTTP
+	KeyFile      string              // TLS_KEY_FILE: PEM private key of the server
+	MTLS         bool                // MTLS_ENABLED: require client certificates
+	ClientCAFile string              // MTLS_CLIENT_CA_FILE: PEM bundle of the CAs client certificates must chain to
+	Allow        map[string][]string // MTLS_ALLOW: group:identity|identity entries, an identity being a certificate's CN or a SAN
+}
+
+// Enabled reports whether the listeners are served over TLS
+func (c *TLSConfig) Enabled() bool {
+	return c.CertFile != "" && c.KeyFile != ""
+}
+
+// Rate limit groups for endpoints that hit the database much harder than CRUD
+const (
+	RateLimitGroupSearch = "search"
+	RateLimitGroupExport = "export"
+	RateLimitGroupImport = "import"
+	RateLimitGroupStats  = "stats"
+	RateLimitGroupAI     = "ai"
+)
+
+// RateLimitGroup is a separate, usually stricter, limit for a group of routes
+type RateLimitGroup struct {
+	Name      string
+	SoftLimit int
+	HardLimit int
+	Window    time.Duration
+}
+
+// Group returns the limit for a route group with its own counters, or nil
+// when the group has no limits configured
+func (c *RateLimitConfig) Group(name string) *RateLimitConfig {
+	for _, group := range c.Groups {
+		if group.Name == name {
+			return &RateLimitConfig{
+				Enabled:   c.Enabled,
+				SoftLimit: group.SoftLimit,
+				HardLimit: group.HardLimit,
+				Window:    group.Window,
+				Algorithm: c.Algorithm,
+				Key:       c.Key,
+				Scope:     "group-" + group.Name,
+			}
+		}
+	}
+	return nil
+}
+
+// ConcurrencyConfig holds per-tenant in-flight request limits
+type ConcurrencyConfig struct {
+	Enabled     bool              // CONCURRENCY_ENABLED
+	MaxWait     time.Duration     // CONCURRENCY_MAX_WAIT: how long a request may queue for a slot
+	DefaultPlan string            // CONCURRENCY_DEFAULT_PLAN: plan for tenants without an assignment
+	Plans       []ConcurrencyPlan // CONCURRENCY_PLANS: name:limit:status,...
+	TenantPlans map[string]string // CONCURRENCY_TENANT_PLANS: tenant:plan,...
+}
+
+// TenancyConfig isolates tenants' data with Postgres row level security
+type TenancyConfig struct {
+	Enabled bool   // TENANCY_ENABLED: resolve a tenant for every request and scope its queries to it
+	Header  string // TENANT_HEADER: header naming the tenant
+	Domain  string // TENANT_DOMAIN: base domain whose subdomains name tenants, empty to ignore the host
+	Default string // TENANT_DEFAULT: tenant of requests that name none, empty to reject them
+}
+
+// ConcurrencyPlan is a named in-flight limit and the status returned when it is exhausted
+type ConcurrencyPlan struct {
+	Name         string
+	Limit        int
+	RejectStatus int // 429 or 503
+}
+
+// Plan returns the plan assigned to a tenant, falling back to the default plan
+func (c *ConcurrencyConfig) Plan(tenant string) ConcurrencyPlan {
+	name, ok := c.TenantPlans[tenant]
+	if !ok {
+		name = c.DefaultPlan
+	}
+	for _, plan := range c.Plans {
+		if plan.Name == name {
+			return plan
+		}
+	}
+	return ConcurrencyPlan{Name: name, Limit: 10, RejectStatus: 429}
+}
+
+// QueryGuardConfig bounds the cost of list requests
+type QueryGuardConfig struct {
+	DefaultPerPage int           // LIST_DEFAULT_PER_PAGE: page size when none is requested
+	MaxPerPage     int           // LIST_MAX_PER_PAGE: largest page size allowed
+	Mode           string        // LIST_GUARD_MODE: reject (400) or downgrade (clamp silently)
+	CursorSecret   string        // LIST_CURSOR_SECRET: HMAC key for pagination cursors, random per process when empty
+	CursorTTL      time.Duration // LIST_CURSOR_TTL: how long a pagination cursor stays valid
+	MaxResponse    int           // LIST_MAX_RESPONSE_BYTES: largest list response sent, 0 for no limit
+}
+
+// QueryCountConfig controls per-request SQL query counting, used to spot
+// N+1 query patterns
+type QueryCountConfig struct {
+	Enabled      bool // QUERY_COUNT_ENABLED: count the SQL statements of each request
+	Threshold    int  // QUERY_COUNT_THRESHOLD: warn when a request runs more statements than this
+	ServerTiming bool // QUERY_COUNT_SERVER_TIMING: report the count in the Server-Timing header
+}
+
+// AutoscalingConfig sizes the utilization signal exported for autoscalers
+type AutoscalingConfig struct {
+	Capacity int // AUTOSCALING_CAPACITY: in-flight requests one replica is sized to serve
+}
+
+// TaskConfig holds task defaults
+type TaskConfig struct {
+	DefaultProject    string              // TASK_DEFAULT_PROJECT: project key for tasks created without one
+	BulkMaxIDs        int                 // TASK_BULK_MAX_IDS: most task IDs accepted by one bulk request
+	BulkPlanRequired  bool                // TASK_BULK_PLAN_REQUIRED: bulk updates and deletes need a confirm token from a plan
+	BulkPlanTTL       time.Duration       // TASK_BULK_PLAN_TTL: how long a plan's confirm token can be used
+	StatusTransitions map[string][]string // TASK_STATUS_TRANSITIONS: from:to|to,... replacing the default state machine
+	BoardColumnLimit  int                 // TASK_BOARD_COLUMN_LIMIT: most tasks shown per board column
+	StatsCacheTTL     time.Duration       // TASK_STATS_CACHE_TTL: how long computed stats are served before recomputing
+	StatsMaxDays      int                 // TASK_STATS_MAX_DAYS: longest created-per-day history stats may cover
+	ImportMaxBytes    int64               // TASK_IMPORT_MAX_BYTES: largest upload POST /tasks/import accepts
+	ImportMaxRows     int                 // TASK_IMPORT_MAX_ROWS: most rows one import may contain
+	ImportBatchSize   int                 // TASK_IMPORT_BATCH_SIZE: rows inserted per transaction during an import
+	SyncMaxTasks      int                 // TASK_SYNC_MAX_TASKS: most tasks one project sync may declare
+	ChecklistMaxItems int                 // TASK_CHECKLIST_MAX_ITEMS: most checklist items one task may have
+}
+
+// RecurrenceConfig controls creating the next occurrences of recurring tasks
+type RecurrenceConfig struct {
+	Enabled      bool          // RECURRENCE_ENABLED: run the recurrence scheduler on this replica
+	PollInterval time.Duration // RECURRENCE_POLL_INTERVAL: how often completed recurring tasks are checked
+	BatchSize    int           // RECURRENCE_BATCH_SIZE: most occurrences created per check
+}
+
+// EscalationConfig controls the job applying the escalation rules of
+// projects to tasks due soon or overdue
+type EscalationConfig struct {
+	Enabled      bool          // ESCALATION_ENABLED: run the escalation job on this replica
+	PollInterval time.Duration // ESCALATION_POLL_INTERVAL: how often tasks are checked against the rules
+	BatchSize    int           // ESCALATION_BATCH_SIZE: most tasks escalated per rule and check
+}
+
+// AutomationConfig controls the engine running the automation rules of
+// projects on task events and passed due dates
+type AutomationConfig struct {
+	Enabled        bool          // AUTOMATION_ENABLED: run the automation engine on this replica
+	PollInterval   time.Duration // AUTOMATION_POLL_INTERVAL: how often new events and passed due dates are checked
+	BatchSize      int           // AUTOMATION_BATCH_SIZE: most events, or tasks per due date rule, handled per check
+	LoopLimit      int           // AUTOMATION_LOOP_LIMIT: most runs of a rule for one task within AUTOMATION_LOOP_WINDOW
+	LoopWindow     time.Duration // AUTOMATION_LOOP_WINDOW: period AUTOMATION_LOOP_LIMIT is counted over
+	WebhookHosts   []string      // AUTOMATION_WEBHOOK_HOSTS: hosts webhook actions may call, * for any, empty disables webhooks
+	WebhookTimeout time.Duration // AUTOMATION_WEBHOOK_TIMEOUT: how long a webhook call may take
+	WebhookSecret  string        // AUTOMATION_WEBHOOK_SECRET: HMAC-SHA256 key signing webhook bodies, empty sends them unsigned
+}
+
+// JSONConfig selects the JSON implementation responses and request
+// bodies go through
+type JSONConfig struct {
+	Engine string // JSON_ENGINE: std or go-json, empty for the build default
+}
+
+// WatchdogConfig controls the leak watchdog, which warns when goroutines
+// or open database connections keep growing
+type WatchdogConfig struct {
+	Interval time.Duration // WATCHDOG_INTERVAL: how often goroutines and connections are counted, 0 disables the watchdog
+	Samples  int           // WATCHDOG_SAMPLES: consecutive counts that must each grow before a warning is logged
+}
+
+// PublicIDConfig selects the IDs clients see
+type PublicIDConfig struct {
+	Mode   string // PUBLIC_IDS: uuid (the database IDs) or opaque (keyed, unguessable IDs)
+	Secret string // PUBLIC_IDS_SECRET: key opaque IDs are derived from, at least 16 characters
+}
+
+// Opaque reports whether clients see opaque IDs instead of UUIDs
+func (c *PublicIDConfig) Opaque() bool {
+	return c.Mode == "opaque"
+}
+
+// CDCConfig controls support for change data capture connectors
+type CDCConfig struct {
+	HeartbeatInterval time.Duration // CDC_HEARTBEAT_INTERVAL: how often the cdc_heartbeat row is written, 0 leaves it to the connector
+}
+
+// NotificationConfig controls fanning task changes out to watchers
+type NotificationConfig struct {
+	Enabled       bool          // NOTIFICATIONS_ENABLED: run the notification fan-out on this replica
+	PollInterval  time.Duration // NOTIFICATIONS_POLL_INTERVAL: how often the fan-out checks for new events
+	BatchSize     int           // NOTIFICATIONS_BATCH_SIZE: events fanned out per batch
+	Retention     time.Duration // NOTIFICATIONS_RETENTION: how long notifications are kept
+	PurgeInterval time.Duration // NOTIFICATIONS_PURGE_INTERVAL: how often expired notifications are removed
+}
+
+// CommentConfig holds task comment settings
+type CommentConfig struct {
+	OnTaskDelete string // COMMENTS_ON_TASK_DELETE: cascade (delete with the task) or block (refuse to delete a commented task)
+}
+
+// Blocking reports whether tasks with comments must not be deleted
+func (c *CommentConfig) Blocking() bool {
+	return c.OnTaskDelete == "block"
+}
+
+// WorkerConfig holds background worker settings
+type WorkerConfig struct {
+	DrainTimeout time.Duration // WORKER_DRAIN_TIMEOUT: how long in-flight background work may run after SIGTERM
+}
+
+// KVStoreConfig selects the shared state backend for rate limits and idempotency keys
+type KVStoreConfig struct {
+	Backend  string // KV_BACKEND: memory, redis or postgres
+	RedisURL string // REDIS_URL: used when KV_BACKEND=redis
+}
+
+// IdempotencyConfig controls Idempotency-Key handling for POST requests
+type IdempotencyConfig struct {
+	TTL time.Duration // IDEMPOTENCY_TTL: how long responses are kept for replay
+}
+
+// BatchConfig limits POST /batch
+type BatchConfig struct {
+	MaxRequests    int // BATCH_MAX_REQUESTS: sub-requests allowed in one batch
+	MaxConcurrency int // BATCH_MAX_CONCURRENCY: sub-requests of one batch run at the same time
+}
+
+// DemoConfig controls the self-contained demo mode
+type DemoConfig struct {
+	Enabled       bool          // DEMO_MODE: run in memory with sample data, no database
+	MaxTasks      int           // DEMO_MAX_TASKS: creates are rejected once this many tasks exist
+	ResetInterval time.Duration // DEMO_RESET_INTERVAL: how often state is wiped and reseeded
+}
+
+// HealthConfig controls the health probes and the /health/deep canary
+type HealthConfig struct {
+	FastPath       bool          // HEALTH_FAST_PATH: serve /health, /health/live and /metrics ahead of the middleware chain
+	DeepRateLimit  int           // HEALTH_DEEP_RATE_LIMIT: deep probes per client per window
+	DeepRateWindow time.Duration // HEALTH_DEEP_RATE_WINDOW
+}
+
+// DegradationConfig holds the startup position of the degradation switches,
+// which can be flipped at runtime through /admin/degradation
+type DegradationConfig struct {
+	DisableSearch     bool // DEGRADE_DISABLE_SEARCH: reject list searches with 503
+	DisableExpansions bool // DEGRADE_DISABLE_EXPANSIONS: skip expanding related resources
+	CachedStatsOnly   bool // DEGRADE_CACHED_STATS_ONLY: never recompute stats on request
+}
+
+// FailoverConfig throttles requests while the database fails over
+type FailoverConfig struct {
+	Enabled   bool          // FAILOVER_ENABLED: detect database failovers and degrade while they last
+	Threshold int           // FAILOVER_ERROR_THRESHOLD: connection errors within the window that start a failover
+	Window    time.Duration // FAILOVER_ERROR_WINDOW: window connection errors are counted over
+	Hold      time.Duration // FAILOVER_HOLD: how long the degraded mode lasts after the last failover error
+	WriteWait time.Duration // FAILOVER_WRITE_WAIT: how long an idempotent write may queue for the failover to end
+	MaxQueued int           // FAILOVER_MAX_QUEUED: writes that may queue at once on this replica
+}
+
+// ExpansionConfig controls loading related resources into task responses
+type ExpansionConfig struct {
+	MaxConcurrency int           // EXPAND_MAX_CONCURRENCY: expansions of one request loaded at the same time
+	Timeout        time.Duration // EXPAND_TIMEOUT: how long a single expansion may take
+	OnError        string        // EXPAND_ON_ERROR: fail (error the request) or partial (leave the expansion out)
+	CommentsLimit  int           // EXPAND_COMMENTS_LIMIT: most recent comments embedded per task
+}
+
+// Partial reports whether a failed expansion is left out instead of
+// failing the request
+func (c *ExpansionConfig) Partial() bool {
+	return c.OnError == "partial"
+}
+
+// FeatureToggleConfig controls per-request feature flags for canary testing
+type FeatureToggleConfig struct {
+	Allowed []string // FEATURE_TOGGLES_ALLOWED: flags admins may enable with X-Feature-Flags
+}
+
+// ShadowConfig controls shadow reads and dual writes during a repository migration
+type ShadowConfig struct {
+	Mode        string        // SHADOW_MODE: off, read (shadow reads) or dual-write (shadow reads and writes)
+	Backend     string        // SHADOW_BACKEND: postgres (a copy of the database) or memory (tasks written since start)
+	DBHost      string        // SHADOW_DB_HOST: server of the postgres shadow, with the DB_USER credentials
+	DBPort      int           // SHADOW_DB_PORT
+	DBName      string        // SHADOW_DB_NAME
+	MaxTasks    int           // SHADOW_MAX_TASKS: tasks the memory shadow holds before new ones are left out
+	Timeout     time.Duration // SHADOW_TIMEOUT: how long a shadow read may take
+	MaxInFlight int           // SHADOW_MAX_IN_FLIGHT: concurrent shadow reads before comparisons are skipped
+}
+
+// EventsConfig controls the task event log and the /events stream
+type EventsConfig struct {
+	Retention         time.Duration // EVENTS_RETENTION: how long events are kept for resuming streams
+	PurgeInterval     time.Duration // EVENTS_PURGE_INTERVAL: how often expired events are removed
+	PollInterval      time.Duration // EVENTS_POLL_INTERVAL: how often streams check for events from other replicas
+	MaxStreamDuration time.Duration // EVENTS_MAX_STREAM_DURATION: streams end after this and clients reconnect
+	ReconnectDelay    time.Duration // EVENTS_RECONNECT_DELAY: retry hint sent to clients when a stream ends
+	ReplayLimit       int           // EVENTS_REPLAY_LIMIT: default page size of GET /events?since=
+	ReplayMaxLimit    int           // EVENTS_REPLAY_MAX_LIMIT: largest page size a replay may request
+	SettleWindow      time.Duration // EVENTS_SETTLE_WINDOW: how long readers wait for an event still committing
+}
+
+// SearchConfig controls mirroring tasks into an external search engine
+type SearchConfig struct {
+	Backend           string        // SEARCH_BACKEND: none, meilisearch, opensearch or memory
+	URL               string        // SEARCH_URL: search engine base URL, opensearch credentials go in the URL
+	APIKey            string        // SEARCH_API_KEY: meilisearch API key
+	Index             string        // SEARCH_INDEX: index holding task documents
+	Timeout           time.Duration // SEARCH_TIMEOUT: how long a single search engine request may take
+	BatchSize         int           // SEARCH_BATCH_SIZE: events applied or tasks reindexed per batch
+	PollInterval      time.Duration // SEARCH_POLL_INTERVAL: how often the indexer checks for new events
+	ConsistencySample int           // SEARCH_CONSISTENCY_SAMPLE: recently updated tasks compared by the consistency check
+}
+
+// AIConfig controls the language model behind task summaries and triage.
+// Nothing is sent to it unless a backend is set.
+type AIConfig struct {
+	Backend   string        // AI_BACKEND: none or openai, any OpenAI-compatible chat completions API
+	URL       string        // AI_URL: API base URL, e.g. http://vllm.ai.svc:8000/v1
+	APIKey    string        // AI_API_KEY: bearer token sent to the API, empty sends none
+	Model     string        // AI_MODEL: model named in every request
+	Timeout   time.Duration // AI_TIMEOUT: how long a single model request may take
+	MaxInput  int           // AI_MAX_INPUT: most characters of task text sent per request, longer text is cut
+	MaxTokens int           // AI_MAX_TOKENS: most tokens a reply may use
+	CacheTTL  time.Duration // AI_CACHE_TTL: how long replies are reused for the same input
+}
+
+// EmbeddingsConfig controls the embedding model behind duplicate task
+// detection. Nothing is sent to it unless a backend is set.
+type EmbeddingsConfig struct {
+	Backend      string        // EMBEDDINGS_BACKEND: none or openai, any OpenAI-compatible embeddings API
+	URL          string        // EMBEDDINGS_URL: API base URL, e.g. http://tei.ai.svc:8080/v1
+	APIKey       string        // EMBEDDINGS_API_KEY: bearer token sent to the API, empty sends none
+	Model        string        // EMBEDDINGS_MODEL: model named in every request
+	Timeout      time.Duration // EMBEDDINGS_TIMEOUT: how long a single embedding request may take
+	MaxInput     int           // EMBEDDINGS_MAX_INPUT: most characters of task text embedded, longer text is cut
+	Threshold    float64       // EMBEDDINGS_THRESHOLD: least cosine similarity, 0 to 1, for a task to count as similar
+	Limit        int           // EMBEDDINGS_LIMIT: most similar tasks returned
+	OnCreate     bool          // EMBEDDINGS_ON_CREATE: embed new tasks as they are created and return likely duplicates
+	BatchSize    int           // EMBEDDINGS_BATCH_SIZE: tasks embedded per background pass
+	PollInterval time.Duration // EMBEDDINGS_POLL_INTERVAL: how often missing and stale embeddings are looked for
+}
+
+// AnalyticsConfig controls the nightly export of task, audit and security
+// facts for analytics
+type AnalyticsConfig struct {
+	Enabled     bool   // ANALYTICS_EXPORT_ENABLED: run the scheduled export
+	At          string // ANALYTICS_EXPORT_AT: time of day the export runs, HH:MM in UTC
+	Format      string // ANALYTICS_EXPORT_FORMAT: parquet or csv
+	URL         string // ANALYTICS_EXPORT_URL: s3://bucket/prefix or file:///path
+	PageSize    int    // ANALYTICS_EXPORT_PAGE_SIZE: tasks or events read per query
+	S3Endpoint  string // ANALYTICS_S3_ENDPOINT: S3-compatible endpoint host
+	S3Region    string // ANALYTICS_S3_REGION: bucket region
+	S3AccessKey string // ANALYTICS_S3_ACCESS_KEY: access key ID
+	S3SecretKey string // ANALYTICS_S3_SECRET_KEY: secret access key
+	S3UseSSL    bool   // ANALYTICS_S3_USE_SSL: connect to the endpoint over TLS
+}
+
+// TimeOfDay returns ANALYTICS_EXPORT_AT as the time past midnight UTC
+func (c *AnalyticsConfig) TimeOfDay() (time.Duration, error) {
+	at, err := time.Parse("15:04", c.At)
+	if err != nil {
+		return 0, fmt.Errorf("ANALYTICS_EXPORT_AT must be HH:MM, got %q", c.At)
+	}
+	return time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute, nil
+}
+
+// AttachmentConfig controls files uploaded to tasks
+type AttachmentConfig struct {
+	URL          string   // ATTACHMENTS_URL: s3://bucket/prefix or file:///path where files are stored
+	MaxBytes     int64    // ATTACHMENTS_MAX_BYTES: largest file accepted
+	MaxPerTask   int      // ATTACHMENTS_MAX_PER_TASK: most files one task may have
+	AllowedTypes []string // ATTACHMENTS_ALLOWED_TYPES: media types accepted, after sniffing the content
+	S3Endpoint   string   // ATTACHMENTS_S3_ENDPOINT: S3-compatible endpoint host
+	S3Region     string   // ATTACHMENTS_S3_REGION: bucket region
+	S3AccessKey  string   // ATTACHMENTS_S3_ACCESS_KEY: access key ID
+	S3SecretKey  string   // ATTACHMENTS_S3_SECRET_KEY: secret access key
+	S3UseSSL     bool     // ATTACHMENTS_S3_USE_SSL: connect to the endpoint over TLS
+}
+
+// SnapshotConfig controls project snapshots kept in object storage
+type SnapshotConfig struct {
+	URL         string // SNAPSHOTS_URL: s3://bucket/prefix or file:///path where snapshots are stored
+	Keep        int    // SNAPSHOTS_KEEP: newest snapshots kept per project, 0 keeps all
+	S3Endpoint  string // SNAPSHOTS_S3_ENDPOINT: S3-compatible endpoint host
+	S3Region    string // SNAPSHOTS_S3_REGION: bucket region
+	S3AccessKey string // SNAPSHOTS_S3_ACCESS_KEY: access key ID
+	S3SecretKey string // SNAPSHOTS_S3_SECRET_KEY: secret access key
+	S3UseSSL    bool   // SNAPSHOTS_S3_USE_SSL: connect to the endpoint over TLS
+}
+
+// DeepRateLimitConfig returns the hard-only rate limit applied to /health/deep
+func (c *HealthConfig) DeepRateLimitConfig() *RateLimitConfig {
+	return &RateLimitConfig{
+		Enabled:   true,
+		SoftLimit: c.DeepRateLimit,
+		HardLimit: c.DeepRateLimit,
+		Window:    c.DeepRateWindow,
+		Scope:     "health-deep",
+	}
+}
+
 // Create new config struct
 func NewConfig() *Config {
 	// Only load .env in development - in Kubernetes, env vars come from ConfigMap/Secrets
@@ -56,44 +653,405 @@ func NewConfig() *Config {
 		godotenv.Load()
 	}
 
-	return &Config{
-		SrvPort:     getEnv("PORT", ":8080"),
-		Environment: getEnv("ENVIRONMENT", "development"),
+	// The profile is picked first, as it changes the defaults read below
+	l := &loader{}
+	environment := l.getEnv("ENVIRONMENT", "development")
+	l.profile = profiles[environment]
+
+	cfg := &Config{
+		SrvPort:     l.getEnv("PORT", ":8080"),
+		Environment: environment,
+		BasePath:    basePath(l.getEnv("BASE_PATH", "")),
+		Replicas:    l.getEnvAsInt("REPLICAS", 1),
+		Listeners: ListenerConfig{
+			AdminPort:       l.getEnv("ADMIN_PORT", ""),
+			MetricsPort:     l.getEnv("METRICS_PORT", ""),
+			DebugPort:       l.getEnv("DEBUG_PORT", ""),
+			ShutdownTimeout: l.getEnvAsDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+		},
 		DatabaseConfig: DatabaseConfig{
-			Host:            getEnv("DB_HOST", "localhost"),
-			Port:            getEnvAsInt("DB_PORT", 5432),
-			User:            getEnv("DB_USER", "postgres"),
-			Password:        getEnv("DB_PASSWORD", "postgres"),
-			DBName:          getEnv("DB_NAME", "multi_tier_db"),
-			SSLMode:         getEnv("DB_SSLMODE", "disable"),
-			MaxOpenConns:    getEnvAsInt("DB_MAX_OPEN_CONNS", 25),
-			MaxIdleConns:    getEnvAsInt("DB_MAX_IDLE_CONNS", 5),
-			ConnMaxLifetime: getEnvAsDuration("DB_CONN_MAX_LIFETIME", 5*time.Minute),
-			ConnMaxIdleTime: getEnvAsDuration("DB_CONN_MAX_IDLE_TIME", 10*time.Minute),
+			Host:            l.getEnv("DB_HOST", "localhost"),
+			Port:            l.getEnvAsInt("DB_PORT", 5432),
+			User:            l.getEnv("DB_USER", "postgres"),
+			Password:        l.getEnv("DB_PASSWORD", "postgres"),
+			DBName:          l.getEnv("DB_NAME", "multi_tier_db"),
+			SSLMode:         l.getEnv("DB_SSLMODE", "disable"),
+			MaxOpenConns:    l.getEnvAsInt("DB_MAX_OPEN_CONNS", 25),
+			MaxIdleConns:    l.getEnvAsInt("DB_MAX_IDLE_CONNS", 5),
+			ConnMaxLifetime: l.getEnvAsDuration("DB_CONN_MAX_LIFETIME", 5*time.Minute),
+			ConnMaxIdleTime: l.getEnvAsDuration("DB_CONN_MAX_IDLE_TIME", 10*time.Minute),
+			ReplicaHost:     l.getEnv("DB_REPLICA_HOST", ""),
+			ReplicaPort:     l.getEnvAsInt("DB_REPLICA_PORT", l.getEnvAsInt("DB_PORT", 5432)),
 		},
 		CORSConfig: CORSConfig{
-			AllowedOrigins:   getEnvAsSlice("CORS_ALLOWED_ORIGINS", []string{"*"}),
-			AllowedMethods:   getEnvAsSlice("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
-			AllowedHeaders:   getEnvAsSlice("CORS_ALLOWED_HEADERS", []string{"Accept", "Authorization", "Content-Type", "X-Request-ID"}),
-			ExposedHeaders:   getEnvAsSlice("CORS_EXPOSED_HEADERS", []string{"X-Request-ID"}),
-			AllowCredentials: getEnvAsBool("CORS_ALLOW_CREDENTIALS", false),
-			MaxAge:           getEnvAsInt("CORS_MAX_AGE", 300),
+			AllowedOrigins:   l.getEnvAsSlice("CORS_ALLOWED_ORIGINS", []string{"*"}),
+			AllowedMethods:   l.getEnvAsSlice("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}),
+			AllowedHeaders:   l.getEnvAsSlice("CORS_ALLOWED_HEADERS", []string{"Accept", "Authorization", "Content-Type", "X-Request-ID", "If-Match", "If-None-Match"}),
+			ExposedHeaders:   l.getEnvAsSlice("CORS_EXPOSED_HEADERS", []string{"X-Request-ID", "X-Trace-ID", "ETag"}),
+			AllowCredentials: l.getEnvAsBool("CORS_ALLOW_CREDENTIALS", false),
+			MaxAge:           l.getEnvAsInt("CORS_MAX_AGE", 300),
 		},
 		LogConfig: LogConfig{
-			Level:      getEnv("LOG_LEVEL", "info"),
-			Format:     getEnv("LOG_FORMAT", "json"),
-			TimeFormat: getEnv("LOG_TIME_FORMAT", "rfc3339"),
+			Level:      l.getEnv("LOG_LEVEL", "info"),
+			Format:     l.getEnv("LOG_FORMAT", "json"),
+			TimeFormat: l.getEnv("LOG_TIME_FORMAT", "rfc3339"),
+		},
+		AdminConfig: AdminConfig{
+			Enabled: l.getEnvAsBool("ADMIN_ENABLED", false),
+			Token:   l.getEnv("ADMIN_TOKEN", ""),
+		},
+		Auth: AuthConfig{
+			Required:        l.getEnvAsBool("AUTH_REQUIRED", false),
+			UserHeader:      l.getEnv("AUTH_USER_HEADER", ""),
+			TrustedProxies:  l.getEnvAsSlice("AUTH_TRUSTED_PROXIES", []string{}),
+			TokenDefaultTTL: l.getEnvAsDuration("API_TOKEN_DEFAULT_TTL", 90*24*time.Hour),
+			TokenMaxTTL:     l.getEnvAsDuration("API_TOKEN_MAX_TTL", 365*24*time.Hour),
+			Denial:          l.getEnv("AUTHZ_DENIAL", "hide"),
+		},
+		JWT: JWTConfig{
+			Secret:     l.getEnv("JWT_SECRET", ""),
+			Issuer:     l.getEnv("JWT_ISSUER", "tasks-api"),
+			TTL:        l.getEnvAsDuration("JWT_TTL", 15*time.Minute),
+			RefreshTTL: l.getEnvAsDuration("JWT_REFRESH_TTL", 720*time.Hour),
+		},
+		OIDC: OIDCConfig{
+			IssuerURL:     strings.TrimSuffix(l.getEnv("OIDC_ISSUER_URL", ""), "/"),
+			ClientID:      l.getEnv("OIDC_CLIENT_ID", ""),
+			ClientSecret:  l.getEnv("OIDC_CLIENT_SECRET", ""),
+			RedirectURL:   l.getEnv("OIDC_REDIRECT_URL", ""),
+			Scopes:        l.getEnvAsSlice("OIDC_SCOPES", []string{"openid", "profile", "email"}),
+			UsernameClaim: l.getEnv("OIDC_USERNAME_CLAIM", "preferred_username"),
+			LoginTimeout:  l.getEnvAsDuration("OIDC_LOGIN_TIMEOUT", 10*time.Minute),
+		},
+		Security: SecurityConfig{
+			Retention:     l.getEnvAsDuration("SECURITY_EVENTS_RETENTION", 90*24*time.Hour),
+			PurgeInterval: l.getEnvAsDuration("SECURITY_EVENTS_PURGE_INTERVAL", time.Hour),
+			DedupWindow:   l.getEnvAsDuration("SECURITY_EVENTS_DEDUP_WINDOW", time.Minute),
+			BufferSize:    l.getEnvAsInt("SECURITY_EVENTS_BUFFER", 1024),
+			BatchSize:     l.getEnvAsInt("SECURITY_EVENTS_BATCH_SIZE", 100),
+		},
+		Audit: AuditConfig{
+			Enabled:       l.getEnvAsBool("AUDIT_ENABLED", true),
+			Retention:     l.getEnvAsDuration("AUDIT_RETENTION", 365*24*time.Hour),
+			PurgeInterval: l.getEnvAsDuration("AUDIT_PURGE_INTERVAL", time.Hour),
+			BufferSize:    l.getEnvAsInt("AUDIT_BUFFER", 1024),
+			BatchSize:     l.getEnvAsInt("AUDIT_BATCH_SIZE", 100),
+		},
+		SigningConfig: SigningConfig{
+			Secret:  l.getEnv("SIGNING_SECRET", ""),
+			Window:  l.getEnvAsDuration("SIGNING_WINDOW", 5*time.Minute),
+			MaxBody: int64(l.getEnvAsInt("SIGNING_MAX_BODY_BYTES", 10<<20)),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:   l.getEnvAsBool("RATE_LIMIT_ENABLED", false),
+			SoftLimit: l.getEnvAsInt("RATE_LIMIT_SOFT", 100),
+			HardLimit: l.getEnvAsInt("RATE_LIMIT_HARD", 200),
+			Window:    l.getEnvAsDuration("RATE_LIMIT_WINDOW", time.Minute),
+			Algorithm: l.getEnv("RATE_LIMIT_ALGORITHM", RateLimitWindow),
+			Key:       l.getEnv("RATE_LIMIT_KEY", RateLimitByIP),
+			Groups:    parseRateLimitGroups(l.getEnvAsSlice("RATE_LIMIT_GROUPS", defaultRateLimitGroups)),
+		},
+		Abuse: AbuseConfig{
+			Enabled:           l.getEnvAsBool("ABUSE_DETECTION_ENABLED", true),
+			Window:            l.getEnvAsDuration("ABUSE_WINDOW", time.Minute),
+			BlockDuration:     l.getEnvAsDuration("ABUSE_BLOCK_DURATION", 15*time.Minute),
+			NotFoundLimit:     l.getEnvAsInt("ABUSE_NOT_FOUND_LIMIT", 100),
+			LoginFailureLimit: l.getEnvAsInt("ABUSE_LOGIN_FAILURE_LIMIT", 20),
+			MaxPayloadBytes:   int64(l.getEnvAsInt("ABUSE_MAX_PAYLOAD_BYTES", 1<<20)),
+			OversizedLimit:    l.getEnvAsInt("ABUSE_OVERSIZED_LIMIT", 5),
+		},
+		IPFilter: IPFilterConfig{
+			Allow:          l.getEnvAsSlice("IP_ALLOW", []string{}),
+			Deny:           l.getEnvAsSlice("IP_DENY", []string{}),
+			AdminAllow:     l.getEnvAsSlice("IP_ADMIN_ALLOW", []string{}),
+			AdminDeny:      l.getEnvAsSlice("IP_ADMIN_DENY", []string{}),
+			RulesFile:      l.getEnv("IP_RULES_FILE", ""),
+			ReloadInterval: l.getEnvAsDuration("IP_RULES_RELOAD_INTERVAL", 10*time.Second),
+			TrustedProxies: l.getEnvAsSlice("TRUSTED_PROXIES", []string{}),
+		},
+		TLS: TLSConfig{
+			CertFile:     l.getEnv("TLS_CERT_FILE", ""),
+			KeyFile:      l.getEnv("TLS_KEY_FILE", ""),
+			MTLS:         l.getEnvAsBool("MTLS_ENABLED", false),
+			ClientCAFile: l.getEnv("MTLS_CLIENT_CA_FILE", ""),
+			Allow:        parseCertAllow(l.getEnvAsSlice("MTLS_ALLOW", nil)),
+		},
+		Concurrency: ConcurrencyConfig{
+			Enabled:     l.getEnvAsBool("CONCURRENCY_ENABLED", false),
+			MaxWait:     l.getEnvAsDuration("CONCURRENCY_MAX_WAIT", 2*time.Second),
+			DefaultPlan: l.getEnv("CONCURRENCY_DEFAULT_PLAN", "default"),
+			Plans:       parseConcurrencyPlans(l.getEnvAsSlice("CONCURRENCY_PLANS", []string{"default:10:429"})),
+			TenantPlans: l.getEnvAsMap("CONCURRENCY_TENANT_PLANS", map[string]string{}),
+		},
+		Tenancy: TenancyConfig{
+			Enabled: l.getEnvAsBool("TENANCY_ENABLED", false),
+			Header:  l.getEnv("TENANT_HEADER", "X-Tenant-ID"),
+			Domain:  strings.ToLower(l.getEnv("TENANT_DOMAIN", "")),
+			Default: l.getEnv("TENANT_DEFAULT", "default"),
+		},
+		QueryGuard: QueryGuardConfig{
+			DefaultPerPage: l.getEnvAsInt("LIST_DEFAULT_PER_PAGE", 50),
+			MaxPerPage:     l.getEnvAsInt("LIST_MAX_PER_PAGE", 100),
+			Mode:           l.getEnv("LIST_GUARD_MODE", "reject"),
+			CursorSecret:   l.getEnv("LIST_CURSOR_SECRET", ""),
+			CursorTTL:      l.getEnvAsDuration("LIST_CURSOR_TTL", time.Hour),
+			MaxResponse:    l.getEnvAsInt("LIST_MAX_RESPONSE_BYTES", 8<<20),
+		},
+		Autoscaling: AutoscalingConfig{
+			Capacity: l.getEnvAsInt("AUTOSCALING_CAPACITY", 25),
+		},
+		QueryCount: QueryCountConfig{
+			Enabled:      l.getEnvAsBool("QUERY_COUNT_ENABLED", true),
+			Threshold:    l.getEnvAsInt("QUERY_COUNT_THRESHOLD", 20),
+			ServerTiming: l.getEnvAsBool("QUERY_COUNT_SERVER_TIMING", true),
+		},
+		Tasks: TaskConfig{
+			DefaultProject:    l.getEnv("TASK_DEFAULT_PROJECT", "TASK"),
+			BulkMaxIDs:        l.getEnvAsInt("TASK_BULK_MAX_IDS", 100),
+			BulkPlanRequired:  l.getEnvAsBool("TASK_BULK_PLAN_REQUIRED", true),
+			BulkPlanTTL:       l.getEnvAsDuration("TASK_BULK_PLAN_TTL", 5*time.Minute),
+			StatusTransitions: parseStatusTransitions(l.getEnvAsSlice("TASK_STATUS_TRANSITIONS", nil)),
+			BoardColumnLimit:  l.getEnvAsInt("TASK_BOARD_COLUMN_LIMIT", 50),
+			StatsCacheTTL:     l.getEnvAsDuration("TASK_STATS_CACHE_TTL", 30*time.Second),
+			StatsMaxDays:      l.getEnvAsInt("TASK_STATS_MAX_DAYS", 365),
+			ImportMaxBytes:    int64(l.getEnvAsInt("TASK_IMPORT_MAX_BYTES", 1<<20)),
+			ImportMaxRows:     l.getEnvAsInt("TASK_IMPORT_MAX_ROWS", 5000),
+			ImportBatchSize:   l.getEnvAsInt("TASK_IMPORT_BATCH_SIZE", 100),
+			SyncMaxTasks:      l.getEnvAsInt("TASK_SYNC_MAX_TASKS", 500),
+			ChecklistMaxItems: l.getEnvAsInt("TASK_CHECKLIST_MAX_ITEMS", 100),
+		},
+		Recurrence: RecurrenceConfig{
+			Enabled:      l.getEnvAsBool("RECURRENCE_ENABLED", true),
+			PollInterval: l.getEnvAsDuration("RECURRENCE_POLL_INTERVAL", 30*time.Second),
+			BatchSize:    l.getEnvAsInt("RECURRENCE_BATCH_SIZE", 100),
+		},
+		Escalation: EscalationConfig{
+			Enabled:      l.getEnvAsBool("ESCALATION_ENABLED", true),
+			PollInterval: l.getEnvAsDuration("ESCALATION_POLL_INTERVAL", time.Minute),
+			BatchSize:    l.getEnvAsInt("ESCALATION_BATCH_SIZE", 100),
+		},
+		Automation: AutomationConfig{
+			Enabled:        l.getEnvAsBool("AUTOMATION_ENABLED", true),
+			PollInterval:   l.getEnvAsDuration("AUTOMATION_POLL_INTERVAL", 30*time.Second),
+			BatchSize:      l.getEnvAsInt("AUTOMATION_BATCH_SIZE", 100),
+			LoopLimit:      l.getEnvAsInt("AUTOMATION_LOOP_LIMIT", 5),
+			LoopWindow:     l.getEnvAsDuration("AUTOMATION_LOOP_WINDOW", time.Hour),
+			WebhookHosts:   l.getEnvAsSlice("AUTOMATION_WEBHOOK_HOSTS", []string{}),
+			WebhookTimeout: l.getEnvAsDuration("AUTOMATION_WEBHOOK_TIMEOUT", 5*time.Second),
+			WebhookSecret:  l.getEnv("AUTOMATION_WEBHOOK_SECRET", ""),
+		},
+		PublicIDs: PublicIDConfig{
+			Mode:   l.getEnv("PUBLIC_IDS", "uuid"),
+			Secret: l.getEnv("PUBLIC_IDS_SECRET", ""),
+		},
+		JSON: JSONConfig{
+			Engine: l.getEnv("JSON_ENGINE", ""),
+		},
+		Watchdog: WatchdogConfig{
+			Interval: l.getEnvAsDuration("WATCHDOG_INTERVAL", time.Minute),
+			Samples:  l.getEnvAsInt("WATCHDOG_SAMPLES", 10),
+		},
+		CDC: CDCConfig{
+			HeartbeatInterval: l.getEnvAsDuration("CDC_HEARTBEAT_INTERVAL", 0),
+		},
+		Workers: WorkerConfig{
+			DrainTimeout: l.getEnvAsDuration("WORKER_DRAIN_TIMEOUT", 20*time.Second),
+		},
+		Comments: CommentConfig{
+			OnTaskDelete: l.getEnv("COMMENTS_ON_TASK_DELETE", "cascade"),
+		},
+		Notifications: NotificationConfig{
+			Enabled:       l.getEnvAsBool("NOTIFICATIONS_ENABLED", true),
+			PollInterval:  l.getEnvAsDuration("NOTIFICATIONS_POLL_INTERVAL", 5*time.Second),
+			BatchSize:     l.getEnvAsInt("NOTIFICATIONS_BATCH_SIZE", 500),
+			Retention:     l.getEnvAsDuration("NOTIFICATIONS_RETENTION", 30*24*time.Hour),
+			PurgeInterval: l.getEnvAsDuration("NOTIFICATIONS_PURGE_INTERVAL", time.Hour),
+		},
+		KVStore: KVStoreConfig{
+			Backend:  l.getEnv("KV_BACKEND", "memory"),
+			RedisURL: l.getEnv("REDIS_URL", "redis://localhost:6379/0"),
+		},
+		Idempotency: IdempotencyConfig{
+			TTL: l.getEnvAsDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+		},
+		Batch: BatchConfig{
+			MaxRequests:    l.getEnvAsInt("BATCH_MAX_REQUESTS", 20),
+			MaxConcurrency: l.getEnvAsInt("BATCH_MAX_CONCURRENCY", 5),
+		},
+		Demo: DemoConfig{
+			Enabled:       l.getEnvAsBool("DEMO_MODE", false),
+			MaxTasks:      l.getEnvAsInt("DEMO_MAX_TASKS", 100),
+			ResetInterval: l.getEnvAsDuration("DEMO_RESET_INTERVAL", time.Hour),
+		},
+		Health: HealthConfig{
+			FastPath:       l.getEnvAsBool("HEALTH_FAST_PATH", true),
+			DeepRateLimit:  l.getEnvAsInt("HEALTH_DEEP_RATE_LIMIT", 6),
+			DeepRateWindow: l.getEnvAsDuration("HEALTH_DEEP_RATE_WINDOW", time.Minute),
+		},
+		Degradation: DegradationConfig{
+			DisableSearch:     l.getEnvAsBool("DEGRADE_DISABLE_SEARCH", false),
+			DisableExpansions: l.getEnvAsBool("DEGRADE_DISABLE_EXPANSIONS", false),
+			CachedStatsOnly:   l.getEnvAsBool("DEGRADE_CACHED_STATS_ONLY", false),
+		},
+		Failover: FailoverConfig{
+			Enabled:   l.getEnvAsBool("FAILOVER_ENABLED", false),
+			Threshold: l.getEnvAsInt("FAILOVER_ERROR_THRESHOLD", 5),
+			Window:    l.getEnvAsDuration("FAILOVER_ERROR_WINDOW", 10*time.Second),
+			Hold:      l.getEnvAsDuration("FAILOVER_HOLD", 30*time.Second),
+			WriteWait: l.getEnvAsDuration("FAILOVER_WRITE_WAIT", 5*time.Second),
+			MaxQueued: l.getEnvAsInt("FAILOVER_MAX_QUEUED", 100),
+		},
+		Expansions: ExpansionConfig{
+			MaxConcurrency: l.getEnvAsInt("EXPAND_MAX_CONCURRENCY", 4),
+			Timeout:        l.getEnvAsDuration("EXPAND_TIMEOUT", 2*time.Second),
+			OnError:        l.getEnv("EXPAND_ON_ERROR", "partial"),
+			CommentsLimit:  l.getEnvAsInt("EXPAND_COMMENTS_LIMIT", 20),
+		},
+		FeatureToggles: FeatureToggleConfig{
+			Allowed: l.getEnvAsSlice("FEATURE_TOGGLES_ALLOWED", []string{}),
+		},
+		Shadow: ShadowConfig{
+			Mode:        l.getEnv("SHADOW_MODE", "off"),
+			Backend:     l.getEnv("SHADOW_BACKEND", "postgres"),
+			DBHost:      l.getEnv("SHADOW_DB_HOST", ""),
+			DBPort:      l.getEnvAsInt("SHADOW_DB_PORT", l.getEnvAsInt("DB_PORT", 5432)),
+			DBName:      l.getEnv("SHADOW_DB_NAME", l.getEnv("DB_NAME", "multi_tier_db")),
+			MaxTasks:    l.getEnvAsInt("SHADOW_MAX_TASKS", 10000),
+			Timeout:     l.getEnvAsDuration("SHADOW_TIMEOUT", 2*time.Second),
+			MaxInFlight: l.getEnvAsInt("SHADOW_MAX_IN_FLIGHT", 64),
+		},
+		Search: SearchConfig{
+			Backend:           l.getEnv("SEARCH_BACKEND", "none"),
+			URL:               l.getEnv("SEARCH_URL", ""),
+			APIKey:            l.getEnv("SEARCH_API_KEY", ""),
+			Index:             l.getEnv("SEARCH_INDEX", "tasks"),
+			Timeout:           l.getEnvAsDuration("SEARCH_TIMEOUT", 5*time.Second),
+			BatchSize:         l.getEnvAsInt("SEARCH_BATCH_SIZE", 100),
+			PollInterval:      l.getEnvAsDuration("SEARCH_POLL_INTERVAL", time.Second),
+			ConsistencySample: l.getEnvAsInt("SEARCH_CONSISTENCY_SAMPLE", 100),
+		},
+		AI: AIConfig{
+			Backend:   l.getEnv("AI_BACKEND", "none"),
+			URL:       l.getEnv("AI_URL", ""),
+			APIKey:    l.getEnv("AI_API_KEY", ""),
+			Model:     l.getEnv("AI_MODEL", ""),
+			Timeout:   l.getEnvAsDuration("AI_TIMEOUT", 15*time.Second),
+			MaxInput:  l.getEnvAsInt("AI_MAX_INPUT", 8000),
+			MaxTokens: l.getEnvAsInt("AI_MAX_TOKENS", 400),
+			CacheTTL:  l.getEnvAsDuration("AI_CACHE_TTL", 24*time.Hour),
+		},
+		Embeddings: EmbeddingsConfig{
+			Backend:      l.getEnv("EMBEDDINGS_BACKEND", "none"),
+			URL:          l.getEnv("EMBEDDINGS_URL", ""),
+			APIKey:       l.getEnv("EMBEDDINGS_API_KEY", ""),
+			Model:        l.getEnv("EMBEDDINGS_MODEL", ""),
+			Timeout:      l.getEnvAsDuration("EMBEDDINGS_TIMEOUT", 10*time.Second),
+			MaxInput:     l.getEnvAsInt("EMBEDDINGS_MAX_INPUT", 8000),
+			Threshold:    l.getEnvAsFloat("EMBEDDINGS_THRESHOLD", 0.85),
+			Limit:        l.getEnvAsInt("EMBEDDINGS_LIMIT", 5),
+			OnCreate:     l.getEnvAsBool("EMBEDDINGS_ON_CREATE", true),
+			BatchSize:    l.getEnvAsInt("EMBEDDINGS_BATCH_SIZE", 50),
+			PollInterval: l.getEnvAsDuration("EMBEDDINGS_POLL_INTERVAL", 30*time.Second),
+		},
+		Analytics: AnalyticsConfig{
+			Enabled:     l.getEnvAsBool("ANALYTICS_EXPORT_ENABLED", false),
+			At:          l.getEnv("ANALYTICS_EXPORT_AT", "02:00"),
+			Format:      l.getEnv("ANALYTICS_EXPORT_FORMAT", "parquet"),
+			URL:         l.getEnv("ANALYTICS_EXPORT_URL", "file:///tmp/analytics"),
+			PageSize:    l.getEnvAsInt("ANALYTICS_EXPORT_PAGE_SIZE", 500),
+			S3Endpoint:  l.getEnv("ANALYTICS_S3_ENDPOINT", "s3.amazonaws.com"),
+			S3Region:    l.getEnv("ANALYTICS_S3_REGION", "us-east-1"),
+			S3AccessKey: l.getEnv("ANALYTICS_S3_ACCESS_KEY", ""),
+			S3SecretKey: l.getEnv("ANALYTICS_S3_SECRET_KEY", ""),
+			S3UseSSL:    l.getEnvAsBool("ANALYTICS_S3_USE_SSL", true),
+		},
+		Attachments: AttachmentConfig{
+			URL:        l.getEnv("ATTACHMENTS_URL", "file:///tmp/attachments"),
+			MaxBytes:   int64(l.getEnvAsInt("ATTACHMENTS_MAX_BYTES", 10<<20)),
+			MaxPerTask: l.getEnvAsInt("ATTACHMENTS_MAX_PER_TASK", 20),
+			AllowedTypes: l.getEnvAsSlice("ATTACHMENTS_ALLOWED_TYPES", []string{
+				"image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf", "text/plain", "text/csv", "application/zip",
+			}),
+			S3Endpoint:  l.getEnv("ATTACHMENTS_S3_ENDPOINT", "s3.amazonaws.com"),
+			S3Region:    l.getEnv("ATTACHMENTS_S3_REGION", "us-east-1"),
+			S3AccessKey: l.getEnv("ATTACHMENTS_S3_ACCESS_KEY", ""),
+			S3SecretKey: l.getEnv("ATTACHMENTS_S3_SECRET_KEY", ""),
+			S3UseSSL:    l.getEnvAsBool("ATTACHMENTS_S3_USE_SSL", true),
+		},
+		Snapshots: SnapshotConfig{
+			URL:         l.getEnv("SNAPSHOTS_URL", "file:///tmp/snapshots"),
+			Keep:        l.getEnvAsInt("SNAPSHOTS_KEEP", 30),
+			S3Endpoint:  l.getEnv("SNAPSHOTS_S3_ENDPOINT", "s3.amazonaws.com"),
+			S3Region:    l.getEnv("SNAPSHOTS_S3_REGION", "us-east-1"),
+			S3AccessKey: l.getEnv("SNAPSHOTS_S3_ACCESS_KEY", ""),
+			S3SecretKey: l.getEnv("SNAPSHOTS_S3_SECRET_KEY", ""),
+			S3UseSSL:    l.getEnvAsBool("SNAPSHOTS_S3_USE_SSL", true),
+		},
+		Events: EventsConfig{
+			Retention:         l.getEnvAsDuration("EVENTS_RETENTION", time.Hour),
+			PurgeInterval:     l.getEnvAsDuration("EVENTS_PURGE_INTERVAL", 5*time.Minute),
+			PollInterval:      l.getEnvAsDuration("EVENTS_POLL_INTERVAL", time.Second),
+			MaxStreamDuration: l.getEnvAsDuration("EVENTS_MAX_STREAM_DURATION", 50*time.Second),
+			ReconnectDelay:    l.getEnvAsDuration("EVENTS_RECONNECT_DELAY", time.Second),
+			ReplayLimit:       l.getEnvAsInt("EVENTS_REPLAY_LIMIT", 100),
+			ReplayMaxLimit:    l.getEnvAsInt("EVENTS_REPLAY_MAX_LIMIT", 1000),
+			SettleWindow:      l.getEnvAsDuration("EVENTS_SETTLE_WINDOW", 10*time.Second),
 		},
 	}
+
+	cfg.overrides = l.overrides
+
+	return cfg
 }
 
 func (c *DatabaseConfig) DSN() string {
 	return fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s timezone=UTC",
 		c.Host, c.Port, c.User, c.Password, c.DBName, c.SSLMode,
 	)
 }
 
+// ReplicaDSN returns the connection string of the read replica
+func (c *DatabaseConfig) ReplicaDSN() string {
+	replica := *c
+	replica.Host, replica.Port = c.ReplicaHost, c.ReplicaPort
+	return replica.DSN()
+}
+
+// Enabled returns true if reads are shadowed
+func (c *ShadowConfig) Enabled() bool {
+	return c.Mode == "read" || c.Mode == "dual-write"
+}
+
+// Database returns the settings of the postgres shadow: primary's, on
+// the shadow server and database and without a replica
+func (c *ShadowConfig) Database(primary *DatabaseConfig) *DatabaseConfig {
+	shadow := *primary
+	shadow.Host, shadow.Port, shadow.DBName = c.DBHost, c.DBPort, c.DBName
+	shadow.ReplicaHost = ""
+	return &shadow
+}
+
+// Enabled returns true if a signing secret is configured
+func (c *SigningConfig) Enabled() bool {
+	return c.Secret != ""
+}
+
+// Enabled returns true if a search backend is configured
+func (c *SearchConfig) Enabled() bool {
+	return c.Backend != "" && c.Backend != "none"
+}
+
+// Enabled reports whether a language model is configured
+func (c *AIConfig) Enabled() bool {
+	return c.Backend != "" && c.Backend != "none"
+}
+
+// Enabled reports whether an embedding model is configured
+func (c *EmbeddingsConfig) Enabled() bool {
+	return c.Backend != "" && c.Backend != "none"
+}
+
 // IsDevelopment returns true if running in development mode
 func (c *Config) IsDevelopment() bool {
 	return c.Environment == "development"
@@ -104,54 +1062,222 @@ func (c *Config) IsProduction() bool {
 	return c.Environment == "production"
 }
 
+// basePath normalizes a path prefix to a leading slash and no trailing
+// one, empty for the root
+func basePath(path string) string {
+	path = strings.Trim(strings.TrimSpace(path), "/")
+	if path == "" {
+		return ""
+	}
+	return "/" + path
+}
+
+// loader reads the environment for NewConfig. Defaults are those of the
+// ENVIRONMENT profile when it sets one, and settings that differ from
+// their default are collected as overrides.
+type loader struct {
+	profile   map[string]string
+	overrides []Override
+}
+
 // Get The Environment Variables
-func getEnv(key, fallback string) string {
+func (l *loader) getEnv(key, fallback string) string {
+	if value, ok := l.profiled(key); ok {
+		fallback = value
+	}
 	if value, ok := os.LookupEnv(key); ok {
+		l.record(key, value, fallback)
 		return value
 	}
 	return fallback
 }
 
-func getEnvAsInt(key string, defaultValue int) int {
+func (l *loader) getEnvAsInt(key string, defaultValue int) int {
+	if value, ok := l.profiled(key); ok {
+		if intVal, err := strconv.Atoi(value); err == nil {
+			defaultValue = intVal
+		}
+	}
 	if value := os.Getenv(key); value != "" {
 		if intVal, err := strconv.Atoi(value); err == nil {
+			l.record(key, strconv.Itoa(intVal), strconv.Itoa(defaultValue))
 			return intVal
 		}
 	}
 	return defaultValue
 }
 
-func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+func (l *loader) getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value, ok := l.profiled(key); ok {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			defaultValue = floatVal
+		}
+	}
+	if value := os.Getenv(key); value != "" {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			l.record(key, strconv.FormatFloat(floatVal, 'g', -1, 64), strconv.FormatFloat(defaultValue, 'g', -1, 64))
+			return floatVal
+		}
+	}
+	return defaultValue
+}
+
+func (l *loader) getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, ok := l.profiled(key); ok {
+		if duration, err := time.ParseDuration(value); err == nil {
+			defaultValue = duration
+		}
+	}
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
+			l.record(key, duration.String(), defaultValue.String())
 			return duration
 		}
 	}
 	return defaultValue
 }
 
-func getEnvAsBool(key string, defaultValue bool) bool {
+func (l *loader) getEnvAsBool(key string, defaultValue bool) bool {
+	if value, ok := l.profiled(key); ok {
+		if boolVal, err := strconv.ParseBool(value); err == nil {
+			defaultValue = boolVal
+		}
+	}
 	if value := os.Getenv(key); value != "" {
 		if boolVal, err := strconv.ParseBool(value); err == nil {
+			l.record(key, strconv.FormatBool(boolVal), strconv.FormatBool(defaultValue))
 			return boolVal
 		}
 	}
 	return defaultValue
 }
 
-func getEnvAsSlice(key string, defaultValue []string) []string {
-	if value := os.Getenv(key); value != "" {
-		parts := strings.Split(value, ",")
-		result := make([]string, 0, len(parts))
-		for _, part := range parts {
-			trimmed := strings.TrimSpace(part)
-			if trimmed != "" {
-				result = append(result, trimmed)
+func (l *loader) getEnvAsSlice(key string, defaultValue []string) []string {
+	if value, ok := l.profiled(key); ok {
+		// An empty profile default means an empty list
+		defaultValue = splitList(value)
+	}
+	if result := splitList(os.Getenv(key)); len(result) > 0 {
+		l.record(key, strings.Join(result, ","), strings.Join(defaultValue, ","))
+		return result
+	}
+	return defaultValue
+}
+
+// splitList splits a comma-separated value, dropping empty entries
+func splitList(value string) []string {
+	var result []string
+	for _, part := range strings.Split(value, ",") {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+	return result
+}
+
+// getEnvAsMap parses "key:value,key:value" pairs, skipping malformed entries
+func (l *loader) getEnvAsMap(key string, defaultValue map[string]string) map[string]string {
+	pairs := l.getEnvAsSlice(key, nil)
+	if len(pairs) == 0 {
+		return defaultValue
+	}
+
+	result := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		k, v, ok := strings.Cut(pair, ":")
+		if ok && k != "" {
+			result[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return result
+}
+
+// parseStatusTransitions parses from:to|to entries; statuses are checked
+// by the task service, which knows them
+func parseStatusTransitions(entries []string) map[string][]string {
+	if len(entries) == 0 {
+		return nil
+	}
+	transitions := make(map[string][]string, len(entries))
+	for _, entry := range entries {
+		from, to, ok := strings.Cut(entry, ":")
+		if !ok {
+			continue
+		}
+		from = strings.TrimSpace(from)
+		for _, next := range strings.Split(to, "|") {
+			if next = strings.TrimSpace(next); next != "" {
+				transitions[from] = append(transitions[from], next)
 			}
 		}
-		if len(result) > 0 {
-			return result
+		if _, ok := transitions[from]; !ok {
+			// A status listed without targets is a final status
+			transitions[from] = []string{}
 		}
 	}
-	return defaultValue
+	return transitions
+}
+
+// parseCertAllow parses group:identity|identity entries. Only the first
+// colon separates the group, so identities may be URIs.
+func parseCertAllow(entries []string) map[string][]string {
+	allow := make(map[string][]string, len(entries))
+	for _, entry := range entries {
+		group, identities, ok := strings.Cut(entry, ":")
+		if !ok {
+			continue
+		}
+		group = strings.TrimSpace(group)
+		for _, identity := range strings.Split(identities, "|") {
+			if identity = strings.TrimSpace(identity); identity != "" {
+				allow[group] = append(allow[group], identity)
+			}
+		}
+	}
+	return allow
+}
+
+func parseRateLimitGroups(entries []string) []RateLimitGroup {
+	groups := make([]RateLimitGroup, 0, len(entries))
+	for _, entry := range entries {
+		parts := strings.Split(entry, ":")
+		if len(parts) != 4 {
+			continue
+		}
+		soft, err := strconv.Atoi(parts[1])
+		if err != nil || soft <= 0 {
+			continue
+		}
+		hard, err := strconv.Atoi(parts[2])
+		if err != nil || hard < soft {
+			continue
+		}
+		window, err := time.ParseDuration(parts[3])
+		if err != nil || window <= 0 {
+			continue
+		}
+		groups = append(groups, RateLimitGroup{Name: parts[0], SoftLimit: soft, HardLimit: hard, Window: window})
+	}
+	return groups
+}
+
+// parseConcurrencyPlans parses "name:limit:status" entries, skipping malformed ones
+func parseConcurrencyPlans(entries []string) []ConcurrencyPlan {
+	plans := make([]ConcurrencyPlan, 0, len(entries))
+	for _, entry := range entries {
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 {
+			continue
+		}
+		limit, err := strconv.Atoi(parts[1])
+		if err != nil || limit <= 0 {
+			continue
+		}
+		status, err := strconv.Atoi(parts[2])
+		if err != nil || (status != 429 && status != 503) {
+			continue
+		}
+		plans = append(plans, ConcurrencyPlan{Name: parts[0], Limit: limit, RejectStatus: status})
+	}
+	return plans
 }
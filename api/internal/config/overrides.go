@@ -0,0 +1,244 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Override describes a setting whose effective value differs from its default
+type Override struct {
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Default string `json:"default"`
+}
+
+// secretMarkers identify keys whose values must never be logged
+var secretMarkers = []string{"PASSWORD", "SECRET", "TOKEN", "KEY"}
+
+// record stores an override when the environment value differs from the default
+func (l *loader) record(key, value, fallback string) {
+	if value == fallback {
+		return
+	}
+	if isSecret(key) {
+		value, fallback = "********", "********"
+	}
+	value, fallback = redactURL(value), redactURL(fallback)
+	l.overrides = append(l.overrides, Override{Key: key, Value: value, Default: fallback})
+}
+
+// Overrides returns the settings that differ from their defaults, sorted by key.
+// Secret values are masked so the result is safe to log.
+func (c *Config) Overrides() []Override {
+	overrides := make([]Override, len(c.overrides))
+	copy(overrides, c.overrides)
+	sort.Slice(overrides, func(i, j int) bool {
+		return overrides[i].Key < overrides[j].Key
+	})
+	return overrides
+}
+
+// redactURL hides credentials embedded in URL values such as REDIS_URL
+func redactURL(value string) string {
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	return value
+}
+
+func isSecret(key string) bool {
+	for _, marker := range secretMarkers {
+		if strings.Contains(key, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// Subsystems summarizes which optional subsystems are enabled and how
+func (c *Config) Subsystems() map[string]string {
+	cors := "on"
+	if len(c.CORSConfig.AllowedOrigins) == 0 {
+		cors = "off"
+	}
+
+	admin := "off"
+	if c.AdminConfig.Enabled {
+		admin = "on"
+	}
+
+	authn := "tokens"
+	if c.JWT.Enabled() {
+		authn += ", passwords (sessions " + c.JWT.TTL.String() + ", refresh " + c.JWT.RefreshTTL.String() + ")"
+	}
+	if c.Auth.UserHeader != "" {
+		authn += ", proxy " + c.Auth.UserHeader
+	}
+	if c.Auth.Required {
+		authn += ", required"
+	}
+	authn += ", audit kept " + c.Security.Retention.String()
+
+	auditLog := "off"
+	if c.Audit.Enabled {
+		auditLog = "kept " + c.Audit.Retention.String()
+	}
+
+	recurrence := "off"
+	if c.Recurrence.Enabled {
+		recurrence = "every " + c.Recurrence.PollInterval.String()
+	}
+
+	escalation := "off"
+	if c.Escalation.Enabled {
+		escalation = "every " + c.Escalation.PollInterval.String()
+	}
+
+	automation := "off"
+	if c.Automation.Enabled {
+		automation = fmt.Sprintf("every %s, %d runs per task and %s", c.Automation.PollInterval, c.Automation.LoopLimit, c.Automation.LoopWindow)
+	}
+
+	notifications := "off"
+	if c.Notifications.Enabled {
+		notifications = "every " + c.Notifications.PollInterval.String() + ", kept " + c.Notifications.Retention.String()
+	}
+
+	cdc := "off"
+	if c.CDC.HeartbeatInterval > 0 {
+		cdc = "heartbeat every " + c.CDC.HeartbeatInterval.String()
+	}
+
+	signing := "off"
+	if c.SigningConfig.Enabled() {
+		signing = "hmac"
+	}
+
+	rateLimit := "off"
+	if c.RateLimit.Enabled {
+		rateLimit = fmt.Sprintf("%d/%d per %s by %s", c.RateLimit.SoftLimit, c.RateLimit.HardLimit, c.RateLimit.Window, c.RateLimit.Key)
+		if c.RateLimit.Algorithm == RateLimitTokenBucket {
+			rateLimit += ", token bucket"
+		}
+		for _, group := range c.RateLimit.Groups {
+			rateLimit += fmt.Sprintf(", %s %d/%d per %s", group.Name, group.SoftLimit, group.HardLimit, group.Window)
+		}
+	}
+
+	abuse := "off"
+	if c.Abuse.Enabled {
+		abuse = "block " + c.Abuse.BlockDuration.String()
+	}
+
+	ipFilter := fmt.Sprintf("%d allow/%d deny, admin %d allow/%d deny",
+		len(c.IPFilter.Allow), len(c.IPFilter.Deny), len(c.IPFilter.AdminAllow), len(c.IPFilter.AdminDeny))
+	if c.IPFilter.RulesFile != "" {
+		ipFilter += ", file " + c.IPFilter.RulesFile
+	}
+
+	tlsMode := "off"
+	if c.TLS.Enabled() {
+		tlsMode = "on"
+		if c.TLS.MTLS {
+			tlsMode = fmt.Sprintf("mutual, %d route groups allowlisted", len(c.TLS.Allow))
+		}
+	}
+
+	shadow := "off"
+	if c.Shadow.Enabled() {
+		shadow = c.Shadow.Mode + "/" + c.Shadow.Backend
+	}
+
+	search := "off"
+	if c.Search.Enabled() {
+		search = c.Search.Backend
+	}
+
+	ai := "off"
+	if c.AI.Enabled() {
+		ai = c.AI.Backend + " " + c.AI.Model
+	}
+
+	embeddings := "off"
+	if c.Embeddings.Enabled() {
+		embeddings = fmt.Sprintf("%s %s, similarity %.2f", c.Embeddings.Backend, c.Embeddings.Model, c.Embeddings.Threshold)
+	}
+
+	analytics := "off"
+	if c.Analytics.Enabled {
+		analytics = c.Analytics.Format + " at " + c.Analytics.At + " UTC"
+	}
+
+	queryCount := "off"
+	if c.QueryCount.Enabled {
+		queryCount = fmt.Sprintf("warn above %d", c.QueryCount.Threshold)
+	}
+
+	var degraded []string
+	if c.Degradation.DisableSearch {
+		degraded = append(degraded, "search")
+	}
+	if c.Degradation.DisableExpansions {
+		degraded = append(degraded, "expansions")
+	}
+	if c.Degradation.CachedStatsOnly {
+		degraded = append(degraded, "stats")
+	}
+	degradation := "off"
+	if len(degraded) > 0 {
+		degradation = strings.Join(degraded, ",")
+	}
+
+	json := c.JSON.Engine
+	if json == "" {
+		json = "build default"
+	}
+
+	listeners := "api " + c.SrvPort
+	for _, l := range [][2]string{{"admin", c.Listeners.AdminPort}, {"metrics", c.Listeners.MetricsPort}, {"debug", c.Listeners.DebugPort}} {
+		if l[1] != "" {
+			listeners += ", " + l[0] + " " + l[1]
+		}
+	}
+
+	watchdog := "off"
+	if c.Watchdog.Interval > 0 {
+		watchdog = fmt.Sprintf("every %s, warn after %d", c.Watchdog.Interval, c.Watchdog.Samples)
+	}
+
+	return map[string]string{
+		"database":    "postgres",
+		"degradation": degradation,
+		"ratelimit":   rateLimit,
+		"abuse":       abuse,
+		"ipfilter":    ipFilter,
+		"tls":         tlsMode,
+		"shadow":      shadow,
+		"search":      search,
+		"ai":          ai,
+		"embeddings":  embeddings,
+		"analytics":   analytics,
+		"attachments": c.Attachments.URL,
+		"snapshots":   fmt.Sprintf("%s, keep %d", c.Snapshots.URL, c.Snapshots.Keep),
+		"cdc":         cdc,
+		"publicids":   c.PublicIDs.Mode,
+		"json":        json,
+		"watchdog":    watchdog,
+		"querycount":  queryCount,
+		"autoscaling": fmt.Sprintf("capacity %d", c.Autoscaling.Capacity),
+		"comments":    "on task delete " + c.Comments.OnTaskDelete,
+		"recurrence":  recurrence,
+		"escalation":  escalation,
+		"automation":  automation,
+		"notify":      notifications,
+		"auth":        authn,
+		"audit":       auditLog,
+		"signing":     signing,
+		"admin":       admin,
+		"listeners":   listeners,
+		"cors":        cors,
+		"logging":     c.LogConfig.Format + "/" + c.LogConfig.Level,
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// profiles bundle the defaults that change with ENVIRONMENT. A variable set
+// in the environment still wins over its profile default, and settings a
+// profile leaves out keep their usual default.
+var profiles = map[string]map[string]string{
+	// development runs self-contained with readable logs and seeded data
+	"development": {
+		"LOG_LEVEL":            "debug",
+		"LOG_FORMAT":           "console",
+		"CORS_ALLOWED_ORIGINS": "*",
+		"DEMO_MODE":            "true",
+	},
+	// staging runs against a real database with the usual defaults
+	"staging": {},
+	// production denies cross-origin requests until origins are listed and
+	// talks to the database over TLS; Validate enforces both
+	"production": {
+		"CORS_ALLOWED_ORIGINS": "",
+		"DB_SSLMODE":           "require",
+	},
+}
+
+// profiled returns the profile default for key, if the profile sets one
+func (l *loader) profiled(key string) (string, bool) {
+	value, ok := l.profile[key]
+	return value, ok
+}
+
+// Validate reports settings that are unsafe for the environment. Every
+// environment must be a known profile, have complete mutual TLS settings
+// when MTLS_ENABLED is set, trusted proxies for AUTH_USER_HEADER, a
+// shadow it can compare against when SHADOW_MODE is, a shared cursor
+// secret when REPLICAS is above one and a valid ANALYTICS_EXPORT_AT; production also refuses wildcard
+// CORS, plain-text database connections, demo mode and sign-in redirects
+// over HTTP.
+func (c *Config) Validate() error {
+	if _, ok := profiles[c.Environment]; !ok {
+		return fmt.Errorf("unknown ENVIRONMENT %q, expected development, staging or production", c.Environment)
+	}
+	if c.TLS.MTLS && (!c.TLS.Enabled() || c.TLS.ClientCAFile == "") {
+		return errors.New("MTLS_ENABLED needs TLS_CERT_FILE, TLS_KEY_FILE and MTLS_CLIENT_CA_FILE")
+	}
+	if len(c.TLS.Allow) > 0 && !c.TLS.MTLS {
+		return errors.New("MTLS_ALLOW needs MTLS_ENABLED")
+	}
+	if c.AI.Enabled() && (c.AI.Backend != "openai" || c.AI.URL == "" || c.AI.Model == "") {
+		return errors.New("AI_BACKEND must be none or openai, and openai needs AI_URL and AI_MODEL")
+	}
+	if c.Embeddings.Enabled() && (c.Embeddings.Backend != "openai" || c.Embeddings.URL == "" || c.Embeddings.Model == "") {
+		return errors.New("EMBEDDINGS_BACKEND must be none or openai, and openai needs EMBEDDINGS_URL and EMBEDDINGS_MODEL")
+	}
+	if c.Embeddings.Threshold < 0 || c.Embeddings.Threshold > 1 {
+		return errors.New("EMBEDDINGS_THRESHOLD must be between 0 and 1")
+	}
+	if c.Auth.UserHeader != "" && len(c.Auth.TrustedProxies) == 0 {
+		// Otherwise any client could name itself in the header
+		return errors.New("AUTH_USER_HEADER needs AUTH_TRUSTED_PROXIES")
+	}
+	if c.Replicas > 1 && c.QueryGuard.CursorSecret == "" {
+		// Each replica would sign cursors with its own random key
+		return errors.New("REPLICAS above 1 needs LIST_CURSOR_SECRET")
+	}
+	if c.Analytics.Enabled {
+		if _, err := c.Analytics.TimeOfDay(); err != nil {
+			return err
+		}
+	}
+	if c.Shadow.Enabled() {
+		switch c.Shadow.Backend {
+		case "postgres":
+			if c.Shadow.DBHost == "" {
+				return errors.New("SHADOW_BACKEND=postgres needs SHADOW_DB_HOST")
+			}
+		case "memory":
+			// It starts empty and only learns tasks from dual writes
+			if c.Shadow.Mode != "dual-write" {
+				return errors.New("SHADOW_BACKEND=memory needs SHADOW_MODE=dual-write")
+			}
+		default:
+			return fmt.Errorf("unknown SHADOW_BACKEND %q, expected postgres or memory", c.Shadow.Backend)
+		}
+	}
+	if !c.IsProduction() {
+		return nil
+	}
+
+	var errs []error
+	if slices.Contains(c.CORSConfig.AllowedOrigins, "*") {
+		errs = append(errs, errors.New("CORS_ALLOWED_ORIGINS must list origins, not *"))
+	}
+	switch c.DatabaseConfig.SSLMode {
+	case "disable", "allow", "prefer":
+		errs = append(errs, fmt.Errorf("DB_SSLMODE=%s does not enforce TLS, use require, verify-ca or verify-full", c.DatabaseConfig.SSLMode))
+	}
+	if c.Demo.Enabled {
+		errs = append(errs, errors.New("DEMO_MODE must be off"))
+	}
+	if c.OIDC.Enabled() && !strings.HasPrefix(c.OIDC.RedirectURL, "https://") {
+		errs = append(errs, errors.New("OIDC_REDIRECT_URL must use https"))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid production configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfiles(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "development")
+	cfg := NewConfig()
+	assert.True(t, cfg.Demo.Enabled)
+	assert.Equal(t, "console", cfg.LogConfig.Format)
+	assert.Equal(t, []string{"*"}, cfg.CORSConfig.AllowedOrigins)
+	assert.NoError(t, cfg.Validate())
+
+	// Variables set in the environment win over the profile
+	t.Setenv("DEMO_MODE", "false")
+	cfg = NewConfig()
+	assert.False(t, cfg.Demo.Enabled)
+	assert.Equal(t, []Override{{Key: "DEMO_MODE", Value: "false", Default: "true"}}, cfg.Overrides())
+
+	t.Setenv("ENVIRONMENT", "staging")
+	cfg = NewConfig()
+	assert.Equal(t, "json", cfg.LogConfig.Format)
+	assert.Equal(t, "disable", cfg.DatabaseConfig.SSLMode)
+	assert.NoError(t, cfg.Validate())
+
+	t.Setenv("ENVIRONMENT", "production")
+	cfg = NewConfig()
+	assert.Empty(t, cfg.CORSConfig.AllowedOrigins)
+	assert.Equal(t, "require", cfg.DatabaseConfig.SSLMode)
+	assert.NoError(t, cfg.Validate())
+
+	// Production refuses settings that weaken its defaults
+	t.Setenv("CORS_ALLOWED_ORIGINS", "*")
+	t.Setenv("DB_SSLMODE", "disable")
+	err := NewConfig().Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CORS_ALLOWED_ORIGINS")
+	assert.Contains(t, err.Error(), "DB_SSLMODE")
+
+	t.Setenv("ENVIRONMENT", "prod")
+	assert.ErrorContains(t, NewConfig().Validate(), "unknown ENVIRONMENT")
+}
+
+func TestValidateMTLS(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "staging")
+	t.Setenv("MTLS_ENABLED", "true")
+	assert.ErrorContains(t, NewConfig().Validate(), "MTLS_CLIENT_CA_FILE")
+
+	t.Setenv("TLS_CERT_FILE", "/etc/tls/tls.crt")
+	t.Setenv("TLS_KEY_FILE", "/etc/tls/tls.key")
+	t.Setenv("MTLS_CLIENT_CA_FILE", "/etc/tls/ca.crt")
+	t.Setenv("MTLS_ALLOW", "admin:ops-cli|spiffe://cluster.local/ns/ops/sa/runner, tasks:billing")
+	cfg := NewConfig()
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, map[string][]string{
+		"admin": {"ops-cli", "spiffe://cluster.local/ns/ops/sa/runner"},
+		"tasks": {"billing"},
+	}, cfg.TLS.Allow)
+
+	t.Setenv("MTLS_ENABLED", "false")
+	assert.ErrorContains(t, NewConfig().Validate(), "MTLS_ALLOW needs MTLS_ENABLED")
+}
+
+func TestValidateShadow(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "staging")
+	t.Setenv("SHADOW_MODE", "read")
+	assert.ErrorContains(t, NewConfig().Validate(), "SHADOW_DB_HOST")
+
+	t.Setenv("SHADOW_DB_HOST", "db-next")
+	t.Setenv("DB_NAME", "tasks")
+	cfg := NewConfig()
+	require.NoError(t, cfg.Validate())
+	shadow := cfg.Shadow.Database(&cfg.DatabaseConfig)
+	assert.Equal(t, "db-next", shadow.Host)
+	assert.Equal(t, "tasks", shadow.DBName)
+	assert.Equal(t, cfg.DatabaseConfig.User, shadow.User)
+
+	// An empty memory shadow only learns tasks from dual writes
+	t.Setenv("SHADOW_BACKEND", "memory")
+	assert.ErrorContains(t, NewConfig().Validate(), "SHADOW_MODE=dual-write")
+	t.Setenv("SHADOW_MODE", "dual-write")
+	require.NoError(t, NewConfig().Validate())
+}
+
+func TestValidateReplicas(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "staging")
+	t.Setenv("REPLICAS", "3")
+	assert.ErrorContains(t, NewConfig().Validate(), "LIST_CURSOR_SECRET")
+
+	t.Setenv("LIST_CURSOR_SECRET", "shared")
+	require.NoError(t, NewConfig().Validate())
+}
+
+func TestValidateUserHeader(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "staging")
+	t.Setenv("AUTH_USER_HEADER", "X-Forwarded-User")
+	assert.ErrorContains(t, NewConfig().Validate(), "AUTH_TRUSTED_PROXIES")
+
+	t.Setenv("AUTH_TRUSTED_PROXIES", "10.0.0.0/8")
+	require.NoError(t, NewConfig().Validate())
+}
+
+func TestValidateAnalytics(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "staging")
+	t.Setenv("ANALYTICS_EXPORT_ENABLED", "true")
+	t.Setenv("ANALYTICS_EXPORT_AT", "0 2 * * *")
+	assert.ErrorContains(t, NewConfig().Validate(), "ANALYTICS_EXPORT_AT must be HH:MM")
+
+	t.Setenv("ANALYTICS_EXPORT_AT", "23:30")
+	cfg := NewConfig()
+	require.NoError(t, cfg.Validate())
+	at, err := cfg.Analytics.TimeOfDay()
+	require.NoError(t, err)
+	assert.Equal(t, 23*time.Hour+30*time.Minute, at)
+}
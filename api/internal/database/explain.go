@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+type explainKey struct{}
+
+// WithExplain marks the context so queries run through DB are explained
+func WithExplain(ctx context.Context) context.Context {
+	return context.WithValue(ctx, explainKey{}, true)
+}
+
+// ExplainEnabled reports whether queries in this context should be explained
+func ExplainEnabled(ctx context.Context) bool {
+	enabled, _ := ctx.Value(explainKey{}).(bool)
+	return enabled
+}
+
+// QueryContext runs a query, explaining it first when requested. During a
+// failover reads run on the replica, unless ctx joins a transaction.
+func (db *DB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	db.explain(ctx, query, args)
+	defer countQuery(ctx, time.Now())
+	if tx := db.TxFrom(ctx); tx != nil {
+		return tx.QueryContext(ctx, query, args...)
+	}
+	if replica := db.replicaFor(query); replica != nil {
+		return replica.QueryContext(ctx, query, args...)
+	}
+	rows, err := db.DB.QueryContext(ctx, query, args...)
+	if replica := db.retryOn(query, err); replica != nil {
+		return replica.QueryContext(ctx, query, args...)
+	}
+	return rows, err
+}
+
+// QueryRowContext runs a single-row query, explaining it first when
+// requested. During a failover reads run on the replica, unless ctx joins
+// a transaction.
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	db.explain(ctx, query, args)
+	defer countQuery(ctx, time.Now())
+	if tx := db.TxFrom(ctx); tx != nil {
+		return tx.QueryRowContext(ctx, query, args...)
+	}
+	if replica := db.replicaFor(query); replica != nil {
+		return replica.QueryRowContext(ctx, query, args...)
+	}
+	row := db.DB.QueryRowContext(ctx, query, args...)
+	if replica := db.retryOn(query, row.Err()); replica != nil {
+		return replica.QueryRowContext(ctx, query, args...)
+	}
+	return row
+}
+
+// ExecContext runs a statement, explaining it first when requested. A
+// statement that succeeds ends a failover in progress.
+func (db *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	db.explain(ctx, query, args)
+	defer countQuery(ctx, time.Now())
+	if tx := db.TxFrom(ctx); tx != nil {
+		return tx.ExecContext(ctx, query, args...)
+	}
+	result, err := db.DB.ExecContext(ctx, query, args...)
+	if !db.Failover.Observe(err) && err == nil {
+		db.Failover.Recovered()
+	}
+	return result, err
+}
+
+// explain runs EXPLAIN (ANALYZE, BUFFERS) inside a rolled back transaction so
+// that writes are never applied twice, and logs the resulting plan
+func (db *DB) explain(ctx context.Context, query string, args []any) {
+	if !ExplainEnabled(ctx) {
+		return
+	}
+
+	log := logger.Ctx(ctx)
+
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to begin explain transaction")
+		return
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, "EXPLAIN (ANALYZE, BUFFERS) "+query, args...)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to explain query")
+		return
+	}
+	defer rows.Close()
+
+	var plan []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			log.Warn().Err(err).Msg("Failed to read query plan")
+			return
+		}
+		plan = append(plan, line)
+	}
+
+	log.Info().
+		Str("request_id", chimw.GetReqID(ctx)).
+		Str("query", strings.Join(strings.Fields(query), " ")).
+		Str("plan", strings.Join(plan, "\n")).
+		Msg("Query plan")
+}
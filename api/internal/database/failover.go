@@ -0,0 +1,248 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+	"github.com/moabdelazem/mutlitier_app/pkg/metrics"
+)
+
+// readOnlyTransaction is the SQLSTATE of a write refused by a primary that
+// was demoted to a standby
+const readOnlyTransaction = "25006"
+
+// probeInterval is how often waiting writes check whether the primary
+// accepts writes again
+const probeInterval = time.Second
+
+// writeKeyword spots statements that write even though they start with
+// SELECT or WITH, like data-modifying CTEs and row locks
+var writeKeyword = regexp.MustCompile(`(?i)\b(insert|update|delete|merge)\b|\bfor\s+(no\s+key\s+)?(update|share|key\s+share)\b`)
+
+// Failover detects a database failover from the errors statements fail with
+// and holds a degraded mode while it lasts. A burst of connection errors or
+// a single write refused as read-only starts it; it ends once a write
+// succeeds again or no failover error was seen for FAILOVER_HOLD. A nil
+// Failover never starts one.
+type Failover struct {
+	cfg *config.FailoverConfig
+	now func() time.Time
+
+	// Probe checks whether the primary accepts writes again, nil to wait
+	// for a write or the hold to end the failover
+	Probe   func(ctx context.Context) error
+	probing atomic.Bool
+
+	mu     sync.Mutex
+	errors []time.Time
+	until  time.Time
+
+	// recovered is closed when the current failover ends early
+	recovered chan struct{}
+}
+
+// NewFailover creates a failover detector
+func NewFailover(cfg *config.FailoverConfig) *Failover {
+	f := &Failover{cfg: cfg, now: time.Now, recovered: make(chan struct{})}
+	metrics.SetFailoverProbe(f.Active)
+	return f
+}
+
+// Observe records the outcome of a statement on the primary and reports
+// whether err is a failover error
+func (f *Failover) Observe(err error) bool {
+	if f == nil || err == nil {
+		return false
+	}
+	failover, readOnly := isFailoverError(err)
+	if !failover {
+		return false
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.now()
+	if f.activeLocked(now) {
+		f.until = now.Add(f.cfg.Hold)
+		return true
+	}
+
+	cutoff := now.Add(-f.cfg.Window)
+	kept := f.errors[:0]
+	for _, at := range f.errors {
+		if at.After(cutoff) {
+			kept = append(kept, at)
+		}
+	}
+	f.errors = append(kept, now)
+
+	if readOnly || len(f.errors) >= f.cfg.Threshold {
+		f.errors = nil
+		f.until = now.Add(f.cfg.Hold)
+		f.recovered = make(chan struct{})
+		metrics.FailoverEntered.Inc()
+		logger.Get().Warn().Err(err).Dur("hold", f.cfg.Hold).Msg("Database failover detected, entering degraded mode")
+	}
+	return true
+}
+
+// Recovered ends the current failover, as a successful write on the
+// primary shows it is over
+func (f *Failover) Recovered() {
+	if f == nil {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.activeLocked(f.now()) {
+		return
+	}
+	f.until = time.Time{}
+	close(f.recovered)
+	logger.Get().Info().Msg("Database failover over, leaving degraded mode")
+}
+
+// Active reports whether a failover is in progress
+func (f *Failover) Active() bool {
+	if f == nil {
+		return false
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.activeLocked(f.now())
+}
+
+func (f *Failover) activeLocked(now time.Time) bool {
+	return now.Before(f.until)
+}
+
+// RetryAfter returns how long until the current failover ends unless
+// another failover error extends it
+func (f *Failover) RetryAfter() time.Duration {
+	if f == nil {
+		return 0
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return max(f.until.Sub(f.now()), 0)
+}
+
+// Wait blocks until the failover ends, for at most limit, and reports
+// whether it did
+func (f *Failover) Wait(ctx context.Context, limit time.Duration) bool {
+	deadline := time.NewTimer(limit)
+	defer deadline.Stop()
+
+	for {
+		f.mu.Lock()
+		now := f.now()
+		if !f.activeLocked(now) {
+			f.mu.Unlock()
+			return true
+		}
+		recovered, remaining := f.recovered, f.until.Sub(now)
+		f.mu.Unlock()
+
+		hold := time.NewTimer(min(remaining, probeInterval))
+		select {
+		case <-recovered:
+		case <-hold.C:
+			f.probe(ctx)
+		case <-deadline.C:
+			hold.Stop()
+			return !f.Active()
+		case <-ctx.Done():
+			hold.Stop()
+			return false
+		}
+		hold.Stop()
+	}
+}
+
+// probe runs Probe, once at a time however many writes wait, and ends the
+// failover when it succeeds
+func (f *Failover) probe(ctx context.Context) {
+	if f.Probe == nil || !f.probing.CompareAndSwap(false, true) {
+		return
+	}
+	defer f.probing.Store(false)
+
+	if err := f.Probe(ctx); err == nil {
+		f.Recovered()
+	}
+}
+
+// isFailoverError reports whether err shows the primary is unreachable or
+// going away, and whether it refused a write as read-only
+func isFailoverError(err error) (failover, readOnly bool) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch {
+		case pqErr.Code == readOnlyTransaction:
+			return true, true
+		case pqErr.Code.Class() == "08":
+			// connection exceptions
+			return true, false
+		case pqErr.Code == "57P01", pqErr.Code == "57P02", pqErr.Code == "57P03":
+			// admin_shutdown, crash_shutdown, cannot_connect_now
+			return true, false
+		}
+		return false, false
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true, false
+	}
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, driver.ErrBadConn), false
+}
+
+// isRead reports whether query only reads, so a replica can serve it
+func isRead(query string) bool {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return false
+	}
+	switch strings.ToUpper(fields[0]) {
+	case "SELECT", "WITH":
+		return !writeKeyword.MatchString(query)
+	}
+	return false
+}
+
+// replicaFor returns the replica when it should serve query instead of the
+// primary, which it does for reads while a failover is in progress
+func (db *DB) replicaFor(query string) *sql.DB {
+	if db.Replica == nil || !db.Failover.Active() || !isRead(query) {
+		return nil
+	}
+	metrics.FailoverReplicaReads.Inc()
+	return db.Replica
+}
+
+// retryOn records the outcome of query on the primary and returns the
+// replica when a read that failed over should be retried on it
+func (db *DB) retryOn(query string, err error) *sql.DB {
+	if !db.Failover.Observe(err) || db.Replica == nil || !isRead(query) {
+		return nil
+	}
+	metrics.FailoverReplicaReads.Inc()
+	return db.Replica
+}
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestFailover(now *time.Time) *Failover {
+	f := NewFailover(&config.FailoverConfig{Enabled: true, Threshold: 3, Window: 10 * time.Second, Hold: 30 * time.Second})
+	f.now = func() time.Time { return *now }
+	return f
+}
+
+func TestFailover_EntersOnErrorBurst(t *testing.T) {
+	now := time.Now()
+	f := newTestFailover(&now)
+	refused := fmt.Errorf("failed to list tasks: %w", &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED})
+
+	assert.False(t, f.Observe(errors.New("syntax error")))
+	assert.False(t, f.Observe(&pq.Error{Code: "23505"}))
+
+	// Errors outside the window do not add up
+	assert.True(t, f.Observe(refused))
+	now = now.Add(11 * time.Second)
+	assert.True(t, f.Observe(refused))
+	assert.True(t, f.Observe(driver.ErrBadConn))
+	assert.False(t, f.Active())
+
+	assert.True(t, f.Observe(&pq.Error{Code: "57P01"}))
+	assert.True(t, f.Active())
+	assert.Equal(t, 30*time.Second, f.RetryAfter())
+
+	// Further errors extend the hold, which ends it without them
+	now = now.Add(20 * time.Second)
+	f.Observe(refused)
+	now = now.Add(20 * time.Second)
+	assert.True(t, f.Active())
+	now = now.Add(11 * time.Second)
+	assert.False(t, f.Active())
+	assert.Zero(t, f.RetryAfter())
+}
+
+func TestFailover_ReadOnlyEntersAtOnceAndWriteRecovers(t *testing.T) {
+	now := time.Now()
+	f := newTestFailover(&now)
+
+	assert.True(t, f.Observe(&pq.Error{Code: "25006"}))
+	assert.True(t, f.Active())
+
+	waited := make(chan bool)
+	go func() { waited <- f.Wait(context.Background(), time.Minute) }()
+	f.Recovered()
+	assert.True(t, <-waited)
+	assert.False(t, f.Active())
+
+	var none *Failover
+	assert.False(t, none.Observe(&pq.Error{Code: "25006"}))
+	assert.False(t, none.Active())
+}
+
+func TestFailover_WaitProbesPrimary(t *testing.T) {
+	now := time.Now()
+	f := newTestFailover(&now)
+	f.Observe(&pq.Error{Code: "25006"})
+
+	probes := 0
+	f.Probe = func(ctx context.Context) error {
+		probes++
+		if probes < 2 {
+			return errors.New("still in recovery")
+		}
+		return nil
+	}
+
+	assert.True(t, f.Wait(context.Background(), 5*time.Second))
+	assert.Equal(t, 2, probes)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	f.Probe = nil
+	f.Observe(&pq.Error{Code: "25006"})
+	assert.False(t, f.Wait(ctx, time.Minute))
+}
+
+func TestIsRead(t *testing.T) {
+	for _, query := range []string{
+		"SELECT id FROM tasks WHERE id = $1",
+		"\n\t\twith recent AS (SELECT * FROM tasks) SELECT * FROM recent",
+		"SELECT updated_at FROM tasks",
+	} {
+		assert.True(t, isRead(query), query)
+	}
+	for _, query := range []string{
+		"",
+		"INSERT INTO tasks (title) VALUES ($1)",
+		"UPDATE tasks SET title = $1",
+		"WITH moved AS (DELETE FROM tasks RETURNING *) SELECT * FROM moved",
+		"SELECT id FROM task_events ORDER BY id FOR UPDATE SKIP LOCKED",
+		"SELECT id FROM tasks FOR NO KEY UPDATE",
+	} {
+		assert.False(t, isRead(query), query)
+	}
+}
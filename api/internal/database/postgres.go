@@ -3,31 +3,33 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/moabdelazem/mutlitier_app/internal/config"
 )
 
 // DB is a wrapper around sql.DB
 type DB struct {
 	*sql.DB
+
+	// Replica serves reads during a failover, nil without DB_REPLICA_HOST
+	Replica *sql.DB
+	// Failover detects database failovers, nil unless FAILOVER_ENABLED
+	Failover *Failover
 }
 
-// NewPostgresConnection creates a new PostgreSQL connection
-func NewPostgresConnection(cfg *config.DatabaseConfig) (*DB, error) {
-	db, err := sql.Open("postgres", cfg.DSN())
+// NewPostgresConnection creates a new PostgreSQL connection whose
+// statements run as the tenant of their context
+func NewPostgresConnection(cfg *config.DatabaseConfig, failover *config.FailoverConfig) (*DB, error) {
+	db, err := open(cfg, cfg.DSN())
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		return nil, err
 	}
 
-	db.SetMaxOpenConns(cfg.MaxOpenConns)
-	db.SetMaxIdleConns(cfg.MaxIdleConns)
-	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
-	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
-
 	// Verify connection with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -38,12 +40,60 @@ func NewPostgresConnection(cfg *config.DatabaseConfig) (*DB, error) {
 
 	log.Println("Database connection established successfully, let's rock!")
 
-	return &DB{db}, nil
+	conn := &DB{DB: db}
+	if failover.Enabled {
+		conn.Failover = NewFailover(failover)
+		conn.Failover.Probe = conn.primaryWritable
+		if cfg.ReplicaHost != "" {
+			// The replica is only needed once the primary fails, so one
+			// that is down now is not fatal
+			if conn.Replica, err = open(cfg, cfg.ReplicaDSN()); err != nil {
+				return nil, err
+			}
+			if err := conn.Replica.PingContext(ctx); err != nil {
+				log.Printf("Read replica not reachable yet: %v", err)
+			}
+		}
+	}
+
+	return conn, nil
+}
+
+// primaryWritable checks that the primary is reachable and no longer a
+// standby, bypassing DB so the probe itself does not count as a failover
+// error
+func (db *DB) primaryWritable(ctx context.Context) error {
+	var standby bool
+	if err := db.DB.QueryRowContext(ctx, `SELECT pg_is_in_recovery()`).Scan(&standby); err != nil {
+		return err
+	}
+	if standby {
+		return errors.New("primary is in recovery")
+	}
+	return nil
+}
+
+// open opens a connection pool to dsn
+func open(cfg *config.DatabaseConfig, dsn string) (*sql.DB, error) {
+	connector, err := pq.NewConnector(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db := sql.OpenDB(tenantConnector{connector})
+
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	return db, nil
 }
 
 // Close closes the database connection
 func (db *DB) Close() error {
 	log.Println("Closing database connection...")
+	if db.Replica != nil {
+		db.Replica.Close()
+	}
 	return db.DB.Close()
 }
 
@@ -56,3 +106,18 @@ func (db *DB) HealthCheck(ctx context.Context) error {
 func (db *DB) GetStats() sql.DBStats {
 	return db.Stats()
 }
+
+// MigrationVersion returns the schema version recorded by golang-migrate
+func (db *DB) MigrationVersion(ctx context.Context) (uint, bool, error) {
+	var (
+		version uint
+		dirty   bool
+	)
+
+	err := db.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read migration version: %w", err)
+	}
+
+	return version, dirty, nil
+}
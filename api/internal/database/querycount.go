@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+type queryCounterKey struct{}
+
+// QueryCounter counts the statements run through DB for one request.
+// It is safe for concurrent use, since repositories may query in parallel.
+type QueryCounter struct {
+	queries  atomic.Int64
+	duration atomic.Int64
+}
+
+// WithQueryCounter returns a copy of ctx whose queries are counted by the
+// returned QueryCounter
+func WithQueryCounter(ctx context.Context) (context.Context, *QueryCounter) {
+	counter := &QueryCounter{}
+	return context.WithValue(ctx, queryCounterKey{}, counter), counter
+}
+
+// Queries returns the number of statements run so far
+func (c *QueryCounter) Queries() int {
+	return int(c.queries.Load())
+}
+
+// Duration returns the time spent waiting for the database so far
+func (c *QueryCounter) Duration() time.Duration {
+	return time.Duration(c.duration.Load())
+}
+
+// ServerTiming formats the count as a Server-Timing metric
+func (c *QueryCounter) ServerTiming() string {
+	return fmt.Sprintf(`db;dur=%.3f;desc="%d queries"`, float64(c.Duration().Microseconds())/1000, c.Queries())
+}
+
+// observe records a statement that started at start
+func (c *QueryCounter) observe(start time.Time) {
+	c.queries.Add(1)
+	c.duration.Add(int64(time.Since(start)))
+}
+
+// countQuery records a statement against the context's counter, if any
+func countQuery(ctx context.Context, start time.Time) {
+	if counter, ok := ctx.Value(queryCounterKey{}).(*QueryCounter); ok {
+		counter.observe(start)
+	}
+}
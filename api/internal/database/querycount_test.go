@@ -0,0 +1,23 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryCounter(t *testing.T) {
+	// Queries outside a counted context are ignored
+	countQuery(context.Background(), time.Now())
+
+	ctx, counter := WithQueryCounter(context.Background())
+	for range 3 {
+		countQuery(ctx, time.Now().Add(-2*time.Millisecond))
+	}
+
+	assert.Equal(t, 3, counter.Queries())
+	assert.GreaterOrEqual(t, counter.Duration(), 6*time.Millisecond)
+	assert.Regexp(t, `^db;dur=\d+\.\d{3};desc="3 queries"$`, counter.ServerTiming())
+}
@@ -0,0 +1,175 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+)
+
+// Row level security policies read the statement's tenant from the
+// app.tenant_id setting; an empty or missing setting reaches every tenant.
+// SET LOCAL would need every statement wrapped in a transaction, which rows
+// returned by QueryContext outlive, so each connection instead keeps the
+// session setting in step with the context of the statement it runs. That
+// costs one extra round trip only when a connection switches tenants.
+//
+// The setting lives on the server session, so every client connection must
+// keep one server session: connect directly or through a pooler in session
+// mode. Transaction pooling, such as PgBouncer's pool_mode=transaction,
+// hands sessions between clients and would leak a tenant's setting into
+// another's statements, so it is not supported with tenancy.
+const (
+	setTenant      = `SELECT set_config('app.tenant_id', $1, false)`
+	setLocalTenant = `SELECT set_config('app.tenant_id', $1, true)`
+)
+
+// driverConn is what database/sql needs of a lib/pq connection
+type driverConn interface {
+	driver.Conn
+	driver.ConnBeginTx
+	driver.ConnPrepareContext
+	driver.ExecerContext
+	driver.QueryerContext
+	driver.Pinger
+	driver.SessionResetter
+	driver.Validator
+}
+
+// tenantConnector opens connections that follow the tenant of each
+// statement's context
+type tenantConnector struct {
+	driver.Connector
+}
+
+// Connect implements driver.Connector
+func (c tenantConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dc, ok := conn.(driverConn)
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf("database driver connection %T cannot follow tenants", conn)
+	}
+	return &tenantConn{driverConn: dc, synced: true}, nil
+}
+
+// tenantConn sets app.tenant_id before a statement whose context names
+// another tenant than the session was last set to
+type tenantConn struct {
+	driverConn
+
+	// session is the tenant set on the session, and synced false when a
+	// failed statement left it unknown
+	session string
+	synced  bool
+
+	// inTx marks an open transaction, where a change of tenant is set
+	// locally so a rollback cannot undo it behind session's back
+	inTx  bool
+	local *string
+}
+
+// follow sets the connection's tenant to the one of ctx
+func (c *tenantConn) follow(ctx context.Context) error {
+	id := tenant.From(ctx)
+	current := c.session
+	if c.local != nil {
+		current = *c.local
+	}
+	if c.synced && id == current {
+		return nil
+	}
+
+	query := setTenant
+	if c.inTx {
+		query = setLocalTenant
+	}
+	if _, err := c.driverConn.ExecContext(ctx, query, []driver.NamedValue{{Ordinal: 1, Value: id}}); err != nil {
+		c.synced = false
+		return fmt.Errorf("failed to set tenant: %w", err)
+	}
+
+	if c.inTx {
+		c.local = &id
+	} else {
+		c.session, c.synced = id, true
+	}
+	return nil
+}
+
+// QueryContext implements driver.QueryerContext
+func (c *tenantConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := c.follow(ctx); err != nil {
+		return nil, err
+	}
+	return c.driverConn.QueryContext(ctx, query, args)
+}
+
+// ExecContext implements driver.ExecerContext
+func (c *tenantConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := c.follow(ctx); err != nil {
+		return nil, err
+	}
+	return c.driverConn.ExecContext(ctx, query, args)
+}
+
+// PrepareContext implements driver.ConnPrepareContext. Prepared statements
+// run with the tenant they were prepared under.
+func (c *tenantConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if err := c.follow(ctx); err != nil {
+		return nil, err
+	}
+	return c.driverConn.PrepareContext(ctx, query)
+}
+
+// BeginTx implements driver.ConnBeginTx, setting the tenant on the session
+// before the transaction starts
+func (c *tenantConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if err := c.follow(ctx); err != nil {
+		return nil, err
+	}
+	tx, err := c.driverConn.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	c.inTx = true
+	return &tenantTx{Tx: tx, conn: c}, nil
+}
+
+// tenantTx drops the transaction's local tenant once it ends
+type tenantTx struct {
+	driver.Tx
+	conn *tenantConn
+}
+
+// Commit implements driver.Tx
+func (tx *tenantTx) Commit() error {
+	defer tx.end()
+	return tx.Tx.Commit()
+}
+
+// Rollback implements driver.Tx
+func (tx *tenantTx) Rollback() error {
+	defer tx.end()
+	return tx.Tx.Rollback()
+}
+
+func (tx *tenantTx) end() {
+	tx.conn.inTx = false
+	tx.conn.local = nil
+}
+
+// BypassesRLS reports whether the connected role skips row level security,
+// as superusers and BYPASSRLS roles do even on tables that force it
+func (db *DB) BypassesRLS(ctx context.Context) (bool, error) {
+	var bypass bool
+	err := db.QueryRowContext(ctx, `SELECT rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = current_user`).Scan(&bypass)
+	if err != nil {
+		return false, fmt.Errorf("failed to read database role: %w", err)
+	}
+	return bypass, nil
+}
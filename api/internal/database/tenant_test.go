@@ -0,0 +1,108 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingConn records the statements run on it, rendering tenant
+// settings as "set <tenant>" or "local <tenant>"
+type recordingConn struct {
+	statements []string
+}
+
+func (c *recordingConn) record(query string, args []driver.NamedValue) {
+	switch query {
+	case setTenant:
+		query = "set " + args[0].Value.(string)
+	case setLocalTenant:
+		query = "local " + args[0].Value.(string)
+	}
+	c.statements = append(c.statements, query)
+}
+
+func (c *recordingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.record(query, args)
+	return driver.RowsAffected(0), nil
+}
+
+func (c *recordingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.record(query, args)
+	return nil, nil
+}
+
+func (c *recordingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.record("BEGIN", nil)
+	return c, nil
+}
+
+func (c *recordingConn) Commit() error {
+	c.record("COMMIT", nil)
+	return nil
+}
+
+func (c *recordingConn) Rollback() error {
+	c.record("ROLLBACK", nil)
+	return nil
+}
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) { return nil, nil }
+func (c *recordingConn) PrepareContext(ctx context.Context, q string) (driver.Stmt, error) {
+	return nil, nil
+}
+func (c *recordingConn) Close() error                           { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error)              { return c, nil }
+func (c *recordingConn) Ping(ctx context.Context) error         { return nil }
+func (c *recordingConn) ResetSession(ctx context.Context) error { return nil }
+func (c *recordingConn) IsValid() bool                          { return true }
+
+func TestTenantConn(t *testing.T) {
+	raw := &recordingConn{}
+	conn := &tenantConn{driverConn: raw, synced: true}
+	background := context.Background()
+	acme := tenant.With(background, "acme")
+	globex := tenant.With(background, "globex")
+
+	exec := func(ctx context.Context, query string) {
+		_, err := conn.ExecContext(ctx, query, nil)
+		require.NoError(t, err)
+	}
+
+	// A fresh connection is unscoped; the tenant is only set when it changes
+	exec(background, "q1")
+	exec(acme, "q2")
+	exec(acme, "q3")
+	exec(globex, "q4")
+	exec(background, "q5")
+	assert.Equal(t, []string{"q1", "set acme", "q2", "q3", "set globex", "q4", "set ", "q5"}, raw.statements)
+
+	// Transactions start with the session set, and a change of tenant
+	// inside one is local to it
+	raw.statements = nil
+	tx, err := conn.BeginTx(acme, driver.TxOptions{})
+	require.NoError(t, err)
+	exec(acme, "q6")
+	exec(globex, "q7")
+	exec(globex, "q8")
+	require.NoError(t, tx.Rollback())
+	exec(acme, "q9")
+	exec(globex, "q10")
+	assert.Equal(t, []string{
+		"set acme", "BEGIN", "q6", "local globex", "q7", "q8", "ROLLBACK",
+		"q9", "set globex", "q10",
+	}, raw.statements)
+}
+
+func TestTenantValid(t *testing.T) {
+	for _, id := range []string{"default", "acme", "acme-eu-1", "a"} {
+		assert.True(t, tenant.Valid(id), id)
+	}
+	for _, id := range []string{"", "Acme", "-acme", "acme-", "acme.eu", "acme_eu", string(make([]byte, 64))} {
+		assert.False(t, tenant.Valid(id), id)
+	}
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// txKey keys the transaction of a DB in a context, so a statement on
+// another database, such as a shadow, does not join it
+type txKey struct{ db *DB }
+
+// InTx runs fn in a transaction. Statements run through db with the
+// context fn is given join the transaction, so repositories take part in
+// it without knowing. A call made inside fn joins the outer transaction,
+// which commits when the outermost fn returns nil and rolls back otherwise.
+func (db *DB) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if db.TxFrom(ctx) != nil {
+		return fn(ctx)
+	}
+
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(context.WithValue(ctx, txKey{db}, tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// TxFrom returns the transaction statements run on db with ctx join, nil
+// outside InTx
+func (db *DB) TxFrom(ctx context.Context) *sql.Tx {
+	tx, _ := ctx.Value(txKey{db}).(*sql.Tx)
+	return tx
+}
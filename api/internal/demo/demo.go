@@ -0,0 +1,83 @@
+package demo
+
+import (
+	"context"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+// sampleTasks are created on startup and after every reset
+var sampleTasks = []struct {
+	title       string
+	description string
+	status      model.Status
+	priority    model.Priority
+}{
+	{"Set up CI pipeline", "Build, test and push the API image on every commit", model.StatusCompleted, model.PriorityHigh},
+	{"Write Kubernetes manifests", "Deployment, Service and ConfigMap for the API", model.StatusCompleted, model.PriorityMedium},
+	{"Configure Argo CD application", "Sync the manifests repository into the cluster", model.StatusInProgress, model.PriorityUrgent},
+	{"Add Prometheus alerts", "Alert on error rate and latency SLO burn", model.StatusPending, model.PriorityHigh},
+	{"Document the release process", "Describe how image tags are promoted between environments", model.StatusPending, model.PriorityLow},
+}
+
+// Seed creates the sample tasks through the service so they get IDs and references
+func Seed(ctx context.Context, tasks *service.TaskService) error {
+	for _, sample := range sampleTasks {
+		created, err := tasks.Create(ctx, &model.CreateTaskRequest{
+			Title:       sample.title,
+			Description: sample.description,
+			Priority:    sample.priority,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Walk the lifecycle, completed tasks were in progress first
+		var path []model.Status
+		switch sample.status {
+		case model.StatusInProgress:
+			path = []model.Status{model.StatusInProgress}
+		case model.StatusCompleted:
+			path = []model.Status{model.StatusInProgress, model.StatusCompleted}
+		}
+		for _, status := range path {
+			if _, err := tasks.Update(ctx, created.ID, &model.UpdateTaskRequest{Status: &status}, repository.AnyVersion); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Resetter is an in-memory store that can be wiped
+type Resetter interface {
+	Reset()
+}
+
+// ResetEvery wipes the demo stores and reseeds tasks on every interval until ctx is done
+func ResetEvery(ctx context.Context, interval time.Duration, tasks *service.TaskService, stores ...Resetter) {
+	log := logger.Get().WithComponent("demo")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, store := range stores {
+				store.Reset()
+			}
+			if err := Seed(ctx, tasks); err != nil {
+				log.Error().Err(err).Msg("Failed to reseed demo data")
+				continue
+			}
+			log.Info().Msg("Demo data reset")
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Package features carries per-request feature flags through the context so
+// trusted clients can opt individual requests into flagged code paths
+package features
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// Flag names a flagged code path
+type Flag string
+
+const (
+	// FullTextListSearch makes GET /tasks?q= use ranked full-text search
+	// instead of title prefix matching
+	FullTextListSearch Flag = "fulltext_list_search"
+)
+
+type contextKey struct{}
+
+// With returns a context with the given flags enabled
+func With(ctx context.Context, flags []Flag) context.Context {
+	enabled := make(map[Flag]bool, len(flags))
+	for _, flag := range flags {
+		enabled[flag] = true
+	}
+	return context.WithValue(ctx, contextKey{}, enabled)
+}
+
+// Enabled reports whether a flag was turned on for the request
+func Enabled(ctx context.Context, flag Flag) bool {
+	enabled, _ := ctx.Value(contextKey{}).(map[Flag]bool)
+	return enabled[flag]
+}
+
+// Parse reads a comma-separated flag list, keeping only allowed flags.
+// The result is sorted and deduplicated.
+func Parse(header string, allowed []string) []Flag {
+	var flags []Flag
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(header, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] || !contains(allowed, name) {
+			continue
+		}
+		seen[name] = true
+		flags = append(flags, Flag(name))
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i] < flags[j] })
+	return flags
+}
+
+// Variant names the combination of flags, "control" when none are enabled
+func Variant(flags []Flag) string {
+	if len(flags) == 0 {
+		return "control"
+	}
+	names := make([]string, len(flags))
+	for i, flag := range flags {
+		names[i] = string(flag)
+	}
+	return strings.Join(names, "+")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
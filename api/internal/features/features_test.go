@@ -0,0 +1,25 @@
+package features
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	allowed := []string{"fulltext_list_search", "other"}
+
+	flags := Parse(" other, FULLTEXT_LIST_SEARCH,unknown,other", allowed)
+
+	assert.Equal(t, []Flag{FullTextListSearch, "other"}, flags)
+	assert.Equal(t, "fulltext_list_search+other", Variant(flags))
+	assert.Equal(t, "control", Variant(Parse("unknown", allowed)))
+}
+
+func TestEnabled(t *testing.T) {
+	ctx := With(context.Background(), []Flag{FullTextListSearch})
+
+	assert.True(t, Enabled(ctx, FullTextListSearch))
+	assert.False(t, Enabled(context.Background(), FullTextListSearch))
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/middleware"
+)
+
+// AccessPolicy answers requests refused because the resource belongs to
+// another user, the service.ErrForbidden errors. Under AUTHZ_DENIAL=hide
+// they get the resource's own 404, so whether an ID exists cannot be
+// probed; under forbid they get 403. Both are recorded as a
+// permission_denied security event. Every handler of per-user resources
+// checks errors with Deny before mapping not found, so the choice is made
+// in one place.
+type AccessPolicy struct {
+	hide     bool
+	security middleware.SecurityRecorder
+}
+
+// NewAccessPolicy creates a new AccessPolicy
+func NewAccessPolicy(cfg *config.AuthConfig, security middleware.SecurityRecorder) *AccessPolicy {
+	return &AccessPolicy{hide: cfg.HideDenied(), security: security}
+}
+
+// Deny writes the response for err and returns true when err is a denied
+// access, answering with notFound under the hide policy
+func (p *AccessPolicy) Deny(w http.ResponseWriter, r *http.Request, err error, notFound string) bool {
+	if !errors.Is(err, service.ErrForbidden) {
+		return false
+	}
+
+	p.security.Record(r.Context(), middleware.SecurityEvent(r, model.SecurityPermissionDenied, err.Error()))
+	if p.hide {
+		pkg.NotFound(w, notFound)
+	} else {
+		pkg.Forbidden(w, "Access to this resource is not allowed")
+	}
+	return true
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/codec"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+	"github.com/moabdelazem/mutlitier_app/pkg/middleware"
+)
+
+// RouteInfo describes a single registered route
+type RouteInfo struct {
+	Method      string   `json:"method"`
+	Pattern     string   `json:"pattern"`
+	Middlewares []string `json:"middlewares"`
+}
+
+// AdminHandler serves operational endpoints under /admin
+type AdminHandler struct {
+	routes      chi.Routes
+	degradation *service.Degradation
+	indexer     *service.SearchIndexer
+	exporter    *service.AnalyticsExporter
+	requests    *middleware.RequestTracker
+}
+
+// NewAdminHandler creates a new AdminHandler that inspects the given router.
+// indexer and exporter are nil when their subsystems are disabled.
+func NewAdminHandler(routes chi.Routes, degradation *service.Degradation, indexer *service.SearchIndexer, exporter *service.AnalyticsExporter, requests *middleware.RequestTracker) *AdminHandler {
+	return &AdminHandler{routes: routes, degradation: degradation, indexer: indexer, exporter: exporter, requests: requests}
+}
+
+// Routes handles GET /admin/routes
+func (h *AdminHandler) Routes(w http.ResponseWriter, r *http.Request) {
+	var routes []RouteInfo
+
+	err := chi.Walk(h.routes, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		names := make([]string, 0, len(middlewares))
+		for _, mw := range middlewares {
+			names = append(names, middlewareName(mw))
+		}
+
+		routes = append(routes, RouteInfo{
+			Method:      method,
+			Pattern:     strings.ReplaceAll(route, "/*/", "/"),
+			Middlewares: names,
+		})
+		return nil
+	})
+	if err != nil {
+		pkg.InternalError(w, "Failed to walk routes")
+		return
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Pattern == routes[j].Pattern {
+			return routes[i].Method < routes[j].Method
+		}
+		return routes[i].Pattern < routes[j].Pattern
+	})
+
+	pkg.JSONSuccess(w, routes)
+}
+
+// Requests handles GET /admin/requests, listing the requests in flight
+// oldest first; min_duration leaves out the ones younger than it
+func (h *AdminHandler) Requests(w http.ResponseWriter, r *http.Request) {
+	var minAge time.Duration
+	if value := r.URL.Query().Get("min_duration"); value != "" {
+		var err error
+		if minAge, err = time.ParseDuration(value); err != nil || minAge < 0 {
+			pkg.BadRequest(w, "min_duration must be a duration such as 500ms or 5s")
+			return
+		}
+	}
+
+	pkg.JSONSuccess(w, h.requests.List(minAge))
+}
+
+// Degradation handles GET /admin/degradation
+func (h *AdminHandler) Degradation(w http.ResponseWriter, r *http.Request) {
+	pkg.JSONSuccess(w, h.degradation.Modes())
+}
+
+// UpdateDegradation handles PUT /admin/degradation; omitted switches are left unchanged
+func (h *AdminHandler) UpdateDegradation(w http.ResponseWriter, r *http.Request) {
+	var update service.DegradationUpdate
+	if err := codec.NewDecoder(r.Body).Decode(&update); err != nil {
+		pkg.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+
+	modes := h.degradation.Update(update)
+
+	logger.Ctx(r.Context()).Warn().
+		Bool("disable_search", modes.DisableSearch).
+		Bool("disable_expansions", modes.DisableExpansions).
+		Bool("cached_stats_only", modes.CachedStatsOnly).
+		Msg("Degradation modes changed")
+
+	pkg.JSONSuccess(w, modes)
+}
+
+// SearchStatus handles GET /admin/search
+func (h *AdminHandler) SearchStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := h.indexer.Status(r.Context())
+	if err != nil {
+		pkg.InternalError(w, "Failed to get search index status")
+		return
+	}
+
+	pkg.JSONSuccess(w, status)
+}
+
+// Reindex handles POST /admin/search/reindex; the rebuild runs in the background
+func (h *AdminHandler) Reindex(w http.ResponseWriter, r *http.Request) {
+	if err := h.indexer.Reindex(r.Context()); err != nil {
+		pkg.InternalError(w, "Failed to schedule reindex")
+		return
+	}
+
+	logger.Ctx(r.Context()).Warn().Msg("Search reindex requested")
+
+	pkg.WriteJSON(w, http.StatusAccepted, pkg.Response{Message: "Reindex scheduled, follow progress at GET /admin/search"})
+}
+
+// SearchConsistency handles GET /admin/search/consistency
+func (h *AdminHandler) SearchConsistency(w http.ResponseWriter, r *http.Request) {
+	result, err := h.indexer.Consistency(r.Context())
+	if err != nil {
+		pkg.InternalError(w, "Failed to check search index consistency")
+		return
+	}
+
+	pkg.JSONSuccess(w, result)
+}
+
+// ExportAnalytics handles POST /admin/analytics/export, running an export
+// for today's partition immediately even if one already ran
+func (h *AdminHandler) ExportAnalytics(w http.ResponseWriter, r *http.Request) {
+	result, err := h.exporter.Export(r.Context(), time.Now(), true)
+	if err != nil {
+		logger.Ctx(r.Context()).Error().Err(err).Msg("Manual analytics export failed")
+		pkg.InternalError(w, "Failed to export analytics")
+		return
+	}
+
+	pkg.JSONSuccess(w, result)
+}
+
+// middlewareName resolves a readable name such as "middleware.CORS" from a middleware func
+func middlewareName(mw func(http.Handler) http.Handler) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(mw).Pointer())
+	if fn == nil {
+		return "unknown"
+	}
+
+	name := fn.Name()
+	if idx := strings.LastIndex(name, "/"); idx >= 0 {
+		name = name[idx+1:]
+	}
+
+	// Closures returned by middleware constructors are named like "CORS.func1"
+	parts := strings.Split(name, ".")
+	for len(parts) > 2 && strings.HasPrefix(parts[len(parts)-1], "func") {
+		parts = parts[:len(parts)-1]
+	}
+
+	return strings.Join(parts, ".")
+}
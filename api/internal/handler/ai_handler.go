@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/codec"
+)
+
+// AIHandler handles HTTP requests for language model task summaries and
+// triage
+type AIHandler struct {
+	service *service.AIService
+}
+
+// NewAIHandler creates a new AIHandler
+func NewAIHandler(service *service.AIService) *AIHandler {
+	return &AIHandler{service: service}
+}
+
+// Summarize handles POST /tasks/{id}/summarize
+func (h *AIHandler) Summarize(w http.ResponseWriter, r *http.Request) {
+	summary, err := h.service.Summarize(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeAIError(w, err, "Failed to summarize task")
+		return
+	}
+
+	pkg.JSONSuccess(w, summary)
+}
+
+// Triage handles POST /tasks/triage
+func (h *AIHandler) Triage(w http.ResponseWriter, r *http.Request) {
+	var req model.TriageRequest
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+
+	suggestion, err := h.service.Triage(r.Context(), &req)
+	if err != nil {
+		writeAIError(w, err, "Failed to triage task")
+		return
+	}
+
+	pkg.JSONSuccess(w, suggestion)
+}
+
+// writeAIError answers a failed summary or triage request, with message
+// for unexpected errors
+func writeAIError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrValidation) || errors.Is(err, service.ErrQueryTooExpensive):
+		pkg.BadRequest(w, err.Error())
+	case errors.Is(err, service.ErrTaskNotFound):
+		pkg.NotFound(w, "Task not found")
+	case errors.Is(err, service.ErrAIUnavailable):
+		pkg.ServiceUnavailable(w, pkg.ErrorResponse{Error: err.Error()})
+	default:
+		pkg.InternalError(w, message)
+	}
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+// AttachmentHandler handles HTTP requests for task attachments
+type AttachmentHandler struct {
+	service *service.AttachmentService
+}
+
+// NewAttachmentHandler creates a new AttachmentHandler
+func NewAttachmentHandler(service *service.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{service: service}
+}
+
+// List handles GET /tasks/{id}/attachments
+func (h *AttachmentHandler) List(w http.ResponseWriter, r *http.Request) {
+	attachments, err := h.service.List(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeAttachmentError(w, err, "Failed to retrieve attachments")
+		return
+	}
+
+	pkg.JSONSuccess(w, attachments)
+}
+
+// Upload handles POST /tasks/{id}/attachments, a multipart/form-data
+// upload with the file in its "file" field
+func (h *AttachmentHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		pkg.UnsupportedMediaType(w, "Content-Type must be multipart/form-data")
+		return
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		pkg.BadRequest(w, "Invalid multipart body")
+		return
+	}
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			pkg.BadRequest(w, "Missing file field")
+			return
+		}
+		if err != nil {
+			pkg.BadRequest(w, "Invalid multipart body")
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		attachment, err := h.service.Upload(r.Context(), chi.URLParam(r, "id"), part.FileName(), part.Header.Get("Content-Type"), part)
+		if err != nil {
+			writeAttachmentError(w, err, "Failed to upload attachment")
+			return
+		}
+
+		pkg.Created(w, attachment)
+		return
+	}
+}
+
+// Download handles GET /tasks/{id}/attachments/{attachmentID}, always as
+// a download so uploaded files are never rendered in the API's origin
+func (h *AttachmentHandler) Download(w http.ResponseWriter, r *http.Request) {
+	attachment, contents, err := h.service.Open(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "attachmentID"))
+	if err != nil {
+		writeAttachmentError(w, err, "Failed to download attachment")
+		return
+	}
+	defer contents.Close()
+
+	etag := `"` + attachment.SHA256 + `"`
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, contents); err != nil {
+		// Headers are sent, so the client only sees a short body
+		logger.Ctx(r.Context()).Warn().Err(err).Str("attachment_id", attachment.ID).Msg("Attachment download interrupted")
+	}
+}
+
+// Delete handles DELETE /tasks/{id}/attachments/{attachmentID}
+func (h *AttachmentHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.Delete(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "attachmentID")); err != nil {
+		writeAttachmentError(w, err, "Failed to delete attachment")
+		return
+	}
+
+	pkg.NoContent(w)
+}
+
+// writeAttachmentError answers a failed attachment request, with message
+// for unexpected errors
+func writeAttachmentError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrValidation):
+		pkg.BadRequest(w, err.Error())
+	case errors.Is(err, service.ErrAttachmentTooLarge):
+		pkg.RequestEntityTooLarge(w, err.Error())
+	case errors.Is(err, service.ErrAttachmentType):
+		pkg.UnsupportedMediaType(w, err.Error())
+	case errors.Is(err, service.ErrTaskNotFound):
+		pkg.NotFound(w, "Task not found")
+	case errors.Is(err, service.ErrAttachmentNotFound):
+		pkg.NotFound(w, "Attachment not found")
+	case errors.Is(err, service.ErrArchived):
+		pkg.Conflict(w, "Task is archived, unarchive it first")
+	default:
+		pkg.InternalError(w, message)
+	}
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+)
+
+// AuditHandler serves the audit log of mutations to admins
+type AuditHandler struct {
+	service *service.AuditService
+}
+
+// NewAuditHandler creates a new AuditHandler
+func NewAuditHandler(service *service.AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+// List handles GET /audit
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter, err := auditLogFilter(query)
+	if err != nil {
+		pkg.BadRequest(w, err.Error())
+		return
+	}
+
+	var opts model.ListOptions
+	if opts.Params, err = listing.Parse(query); err != nil {
+		pkg.BadRequest(w, err.Error())
+		return
+	}
+
+	logs, err := h.service.List(r.Context(), filter, &opts)
+	if err != nil {
+		if errors.Is(err, service.ErrValidation) || errors.Is(err, service.ErrQueryTooExpensive) {
+			pkg.BadRequest(w, err.Error())
+			return
+		}
+		pkg.InternalError(w, "Failed to retrieve audit logs")
+		return
+	}
+
+	pkg.JSONList(w, logs)
+}
+
+// auditLogFilter parses the user, entity, from and to query parameters
+func auditLogFilter(query url.Values) (*model.AuditLogFilter, error) {
+	filter := model.AuditLogFilter{User: query.Get("user"), EntityID: query.Get("entity")}
+	var err error
+	if filter.From, err = listing.Time(query, "from"); err != nil {
+		return nil, err
+	}
+	if filter.To, err = listing.Time(query, "to"); err != nil {
+		return nil, err
+	}
+	return &filter, nil
+}
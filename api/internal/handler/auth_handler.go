@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/codec"
+	"github.com/moabdelazem/mutlitier_app/pkg/middleware"
+)
+
+// AuthHandler handles password registration, sign-in, refresh and
+// logout, and the caller's sessions. Sign-ins and refreshes are recorded
+// as security events, so failed ones also count towards credential
+// stuffing blocks. Another user's session is answered by the access
+// policy.
+type AuthHandler struct {
+	service  *service.AuthService
+	security middleware.SecurityRecorder
+	access   *AccessPolicy
+}
+
+// NewAuthHandler creates a new AuthHandler
+func NewAuthHandler(service *service.AuthService, security middleware.SecurityRecorder, access *AccessPolicy) *AuthHandler {
+	return &AuthHandler{service: service, security: security, access: access}
+}
+
+// Register handles POST /auth/register
+func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
+	var req model.RegisterRequest
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+
+	user, err := h.service.Register(r.Context(), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrValidation):
+			pkg.BadRequest(w, err.Error())
+		case errors.Is(err, service.ErrUserExists):
+			pkg.Conflict(w, "Username is taken")
+		default:
+			pkg.InternalError(w, "Failed to register user")
+		}
+		return
+	}
+
+	pkg.Created(w, user)
+}
+
+// Login handles POST /auth/login
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req model.LoginRequest
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+
+	session, err := h.service.Login(r.Context(), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrValidation):
+			pkg.BadRequest(w, err.Error())
+		case errors.Is(err, service.ErrInvalidCredentials):
+			event := middleware.SecurityEvent(r, model.SecurityLoginFailed, "invalid username or password")
+			event.User, event.Credential = req.Username, model.CredentialPassword
+			h.security.Record(r.Context(), event)
+			pkg.Unauthorized(w, "Invalid username or password")
+		case errors.Is(err, service.ErrNotTenantMember):
+			event := middleware.SecurityEvent(r, model.SecurityLoginFailed, "not a member of the tenant")
+			event.User, event.Credential = req.Username, model.CredentialPassword
+			h.security.Record(r.Context(), event)
+			pkg.Forbidden(w, "Not a member of this tenant")
+		default:
+			pkg.InternalError(w, "Failed to sign in")
+		}
+		return
+	}
+
+	event := middleware.SecurityEvent(r, model.SecurityLoginSucceeded, "")
+	event.User, event.Credential = req.Username, model.CredentialPassword
+	h.security.Record(r.Context(), event)
+
+	w.Header().Set("Cache-Control", "no-store")
+	pkg.JSONSuccess(w, session)
+}
+
+// Refresh handles POST /auth/refresh
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req model.RefreshRequest
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+	if req.RefreshToken == "" {
+		pkg.BadRequest(w, "refresh_token is required")
+		return
+	}
+
+	session, user, err := h.service.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidRefreshToken), errors.Is(err, service.ErrRefreshTokenReused):
+			reason := "unknown, revoked or expired refresh token"
+			if errors.Is(err, service.ErrRefreshTokenReused) {
+				reason = "refresh token reused, all sessions revoked"
+			}
+			event := middleware.SecurityEvent(r, model.SecurityLoginFailed, reason)
+			event.User, event.Credential = user, model.CredentialRefresh
+			h.security.Record(r.Context(), event)
+			pkg.Unauthorized(w, "Invalid refresh token")
+		case errors.Is(err, service.ErrNotTenantMember):
+			event := middleware.SecurityEvent(r, model.SecurityLoginFailed, "not a member of the tenant")
+			event.User, event.Credential = user, model.CredentialRefresh
+			h.security.Record(r.Context(), event)
+			pkg.Forbidden(w, "Not a member of this tenant")
+		default:
+			pkg.InternalError(w, "Failed to refresh session")
+		}
+		return
+	}
+
+	event := middleware.SecurityEvent(r, model.SecurityLoginSucceeded, "")
+	event.User, event.Credential = user, model.CredentialRefresh
+	h.security.Record(r.Context(), event)
+
+	w.Header().Set("Cache-Control", "no-store")
+	pkg.JSONSuccess(w, session)
+}
+
+// Logout handles POST /auth/logout
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req model.RefreshRequest
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+	if req.RefreshToken == "" {
+		pkg.BadRequest(w, "refresh_token is required")
+		return
+	}
+
+	user, err := h.service.Logout(r.Context(), req.RefreshToken)
+	if err != nil {
+		pkg.InternalError(w, "Failed to sign out")
+		return
+	}
+
+	if user != "" {
+		event := middleware.SecurityEvent(r, model.SecurityTokenRevoked, "logout")
+		event.User, event.Credential = user, model.CredentialRefresh
+		h.security.Record(r.Context(), event)
+	}
+
+	pkg.NoContent(w)
+}
+
+// Sessions handles GET /auth/sessions
+func (h *AuthHandler) Sessions(w http.ResponseWriter, r *http.Request) {
+	sessions, err := h.service.Sessions(r.Context(), auth.FromContext(r.Context()))
+	if err != nil {
+		pkg.InternalError(w, "Failed to retrieve sessions")
+		return
+	}
+
+	pkg.JSONSuccess(w, sessions)
+}
+
+// RevokeSession handles DELETE /auth/sessions/{id}
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	principal := auth.FromContext(r.Context())
+	if err := h.service.RevokeSession(r.Context(), principal.User, id); err != nil {
+		if h.access.Deny(w, r, err, "Session not found") {
+			return
+		}
+		if errors.Is(err, service.ErrSessionNotFound) {
+			pkg.NotFound(w, "Session not found")
+			return
+		}
+		pkg.InternalError(w, "Failed to revoke session")
+		return
+	}
+
+	event := middleware.SecurityEvent(r, model.SecurityTokenRevoked, "session revoked")
+	event.Credential, event.TokenID = model.CredentialSession, id
+	h.security.Record(r.Context(), event)
+
+	pkg.NoContent(w)
+}
+
+// RevokeSessions handles DELETE /auth/sessions. With keep_current=true the
+// session the request was made with stays signed in.
+func (h *AuthHandler) RevokeSessions(w http.ResponseWriter, r *http.Request) {
+	keepCurrent := false
+	if value := r.URL.Query().Get("keep_current"); value != "" {
+		var err error
+		if keepCurrent, err = strconv.ParseBool(value); err != nil {
+			pkg.BadRequest(w, "keep_current must be true or false")
+			return
+		}
+	}
+
+	principal := auth.FromContext(r.Context())
+	keep := ""
+	if keepCurrent {
+		keep = principal.SessionID
+	}
+
+	revoked, err := h.service.RevokeSessions(r.Context(), principal.User, keep)
+	if err != nil {
+		pkg.InternalError(w, "Failed to revoke sessions")
+		return
+	}
+
+	if revoked > 0 {
+		event := middleware.SecurityEvent(r, model.SecurityTokenRevoked, strconv.Itoa(revoked)+" sessions revoked")
+		event.Credential = model.CredentialSession
+		h.security.Record(r.Context(), event)
+	}
+
+	pkg.JSONSuccess(w, &model.SessionsRevokedResponse{Revoked: revoked})
+}
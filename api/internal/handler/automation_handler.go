@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/codec"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+)
+
+// AutomationHandler handles HTTP requests for the automation rules of a
+// project and their execution log
+type AutomationHandler struct {
+	service *service.AutomationService
+}
+
+// NewAutomationHandler creates a new AutomationHandler
+func NewAutomationHandler(service *service.AutomationService) *AutomationHandler {
+	return &AutomationHandler{service: service}
+}
+
+// CreateRule handles POST /projects/{key}/automation-rules
+func (h *AutomationHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	var req model.AutomationRuleRequest
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+
+	rule, err := h.service.CreateRule(r.Context(), chi.URLParam(r, "key"), &req)
+	if err != nil {
+		writeAutomationError(w, err, "Failed to create automation rule")
+		return
+	}
+
+	pkg.Created(w, rule)
+}
+
+// ListRules handles GET /projects/{key}/automation-rules
+func (h *AutomationHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.service.ListRules(r.Context(), chi.URLParam(r, "key"))
+	if err != nil {
+		pkg.InternalError(w, "Failed to retrieve automation rules")
+		return
+	}
+
+	pkg.JSONSuccess(w, rules)
+}
+
+// GetRule handles GET /projects/{key}/automation-rules/{id}
+func (h *AutomationHandler) GetRule(w http.ResponseWriter, r *http.Request) {
+	rule, err := h.service.GetRule(r.Context(), chi.URLParam(r, "key"), chi.URLParam(r, "id"))
+	if err != nil {
+		writeAutomationError(w, err, "Failed to retrieve automation rule")
+		return
+	}
+
+	pkg.JSONSuccess(w, rule)
+}
+
+// UpdateRule handles PUT /projects/{key}/automation-rules/{id}
+func (h *AutomationHandler) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	var req model.AutomationRuleRequest
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+
+	rule, err := h.service.UpdateRule(r.Context(), chi.URLParam(r, "key"), chi.URLParam(r, "id"), &req)
+	if err != nil {
+		writeAutomationError(w, err, "Failed to update automation rule")
+		return
+	}
+
+	pkg.JSONSuccess(w, rule)
+}
+
+// DeleteRule handles DELETE /projects/{key}/automation-rules/{id}
+func (h *AutomationHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteRule(r.Context(), chi.URLParam(r, "key"), chi.URLParam(r, "id")); err != nil {
+		writeAutomationError(w, err, "Failed to delete automation rule")
+		return
+	}
+
+	pkg.NoContent(w)
+}
+
+// ListRuns handles GET /projects/{key}/automation-runs
+func (h *AutomationHandler) ListRuns(w http.ResponseWriter, r *http.Request) {
+	var opts model.ListOptions
+	var err error
+	if opts.Params, err = listing.Parse(r.URL.Query()); err != nil {
+		pkg.BadRequest(w, err.Error())
+		return
+	}
+
+	runs, err := h.service.ListRuns(r.Context(), chi.URLParam(r, "key"), &opts)
+	if err != nil {
+		if errors.Is(err, service.ErrValidation) || errors.Is(err, service.ErrQueryTooExpensive) {
+			pkg.BadRequest(w, err.Error())
+			return
+		}
+		pkg.InternalError(w, "Failed to retrieve automation runs")
+		return
+	}
+
+	pkg.JSONList(w, runs)
+}
+
+// writeAutomationError answers a failed rule request, with message for
+// unexpected errors
+func writeAutomationError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrAutomationRuleNotFound):
+		pkg.NotFound(w, "Automation rule not found")
+	case errors.Is(err, service.ErrAnonymous):
+		pkg.Unauthorized(w, err.Error())
+	default:
+		writeProjectError(w, err, message)
+	}
+}
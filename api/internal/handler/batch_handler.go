@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/codec"
+)
+
+// batchResponseHeaders are the sub-response headers passed back to clients
+var batchResponseHeaders = []string{"Content-Type", "ETag", "Location", "Retry-After"}
+
+// batchExcludedPaths cannot be batched: streams never finish and batches
+// do not nest
+var batchExcludedPaths = []string{"/batch", "/events"}
+
+// BatchHandler runs batched GET requests against the router
+type BatchHandler struct {
+	router   http.Handler
+	cfg      *config.BatchConfig
+	basePath string
+}
+
+// NewBatchHandler creates a new BatchHandler dispatching to router, which
+// is mounted under basePath
+func NewBatchHandler(router http.Handler, cfg *config.BatchConfig, basePath string) *BatchHandler {
+	return &BatchHandler{router: router, cfg: cfg, basePath: basePath}
+}
+
+// Batch handles POST /batch. Sub-requests run concurrently through the
+// full middleware chain, so they are rate limited and authorized exactly
+// like separate requests; each gets its own status in the response. They
+// carry the batch request's context, so they keep its connection peer and
+// its caller, anonymous or not, instead of signing in again.
+func (h *BatchHandler) Batch(w http.ResponseWriter, r *http.Request) {
+	var req model.BatchRequest
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+	if len(req.Requests) == 0 {
+		pkg.BadRequest(w, "requests must not be empty")
+		return
+	}
+	if len(req.Requests) > h.cfg.MaxRequests {
+		pkg.BadRequest(w, fmt.Sprintf("at most %d requests can be batched", h.cfg.MaxRequests))
+		return
+	}
+
+	responses := make([]model.BatchItemResponse, len(req.Requests))
+	slots := make(chan struct{}, max(h.cfg.MaxConcurrency, 1))
+	var wg sync.WaitGroup
+	for i, item := range req.Requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			responses[i] = h.run(r, item)
+		}()
+	}
+	wg.Wait()
+
+	pkg.JSONSuccess(w, model.BatchResponse{Responses: responses})
+}
+
+// run executes one sub-request with the batch request's headers
+func (h *BatchHandler) run(r *http.Request, item model.BatchItem) model.BatchItemResponse {
+	failed := func(status int, message string) model.BatchItemResponse {
+		body, _ := json.Marshal(pkg.ErrorResponse{Error: message})
+		return model.BatchItemResponse{ID: item.ID, Status: status, Body: body}
+	}
+
+	if item.Method != "" && !strings.EqualFold(item.Method, http.MethodGet) {
+		return failed(http.StatusMethodNotAllowed, "Only GET requests can be batched")
+	}
+	target, err := url.Parse(item.Path)
+	if err != nil || target.Scheme != "" || target.Host != "" || !strings.HasPrefix(target.Path, "/") {
+		return failed(http.StatusBadRequest, "path must be an absolute path such as /tasks?page=2")
+	}
+	// Paths may name routes as clients see them, under the base path
+	if h.basePath != "" {
+		if path, ok := strings.CutPrefix(target.Path, h.basePath); ok && (path == "" || path[0] == '/') {
+			target.Path, target.RawPath = "/"+strings.TrimPrefix(path, "/"), ""
+		}
+	}
+	for _, excluded := range batchExcludedPaths {
+		if target.Path == excluded || strings.HasPrefix(target.Path, excluded+"/") {
+			return failed(http.StatusBadRequest, fmt.Sprintf("%s cannot be batched", excluded))
+		}
+	}
+
+	// Drop the batch request's routing state so the router matches the
+	// sub-request from scratch
+	ctx := context.WithValue(r.Context(), chi.RouteCtxKey, (*chi.Context)(nil))
+	sub, err := http.NewRequestWithContext(ctx, http.MethodGet, target.RequestURI(), nil)
+	if err != nil {
+		return failed(http.StatusBadRequest, "Invalid path")
+	}
+	sub.Header = r.Header.Clone()
+	sub.Header.Del("Content-Type")
+	sub.Header.Del("Content-Length")
+	sub.Header.Del("Idempotency-Key")
+	for name, value := range item.Headers {
+		sub.Header.Set(name, value)
+	}
+	sub.RemoteAddr = r.RemoteAddr
+	sub.Host = r.Host
+
+	rec := newBatchRecorder()
+	h.router.ServeHTTP(rec, sub)
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+
+	resp := model.BatchItemResponse{ID: item.ID, Status: rec.status}
+	for _, name := range batchResponseHeaders {
+		if value := rec.header.Get(name); value != "" {
+			if resp.Headers == nil {
+				resp.Headers = make(map[string]string)
+			}
+			resp.Headers[name] = value
+		}
+	}
+	if rec.body.Len() > 0 {
+		mediaType, _, _ := mime.ParseMediaType(rec.header.Get("Content-Type"))
+		if mediaType == "application/json" && json.Valid(rec.body.Bytes()) {
+			resp.Body = bytes.TrimSpace(rec.body.Bytes())
+		} else {
+			resp.Body, _ = json.Marshal(rec.body.String())
+		}
+	}
+	return resp
+}
+
+// batchRecorder captures a sub-response in memory
+type batchRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBatchRecorder() *batchRecorder {
+	return &batchRecorder{header: make(http.Header)}
+}
+
+func (r *batchRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *batchRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *batchRecorder) Write(data []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(data)
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchHandler(t *testing.T) {
+	r := chi.NewRouter()
+	r.Get("/tasks", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		pkg.JSONSuccess(w, map[string]string{"page": r.URL.Query().Get("page"), "tenant": r.Header.Get("X-Tenant-ID")})
+	})
+	r.Get("/tasks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		pkg.NotFound(w, "Task not found")
+	})
+	r.Post("/batch", NewBatchHandler(r, &config.BatchConfig{MaxRequests: 4, MaxConcurrency: 2}, "/api").Batch)
+
+	body := `{"requests": [
+		{"id": "list", "path": "/api/tasks?page=2"},
+		{"id": "missing", "path": "/tasks/nope"},
+		{"id": "write", "method": "DELETE", "path": "/tasks/nope"},
+		{"id": "stream", "path": "/events"}
+	]}`
+	req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body))
+	req.Header.Set("X-Tenant-ID", "acme")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp model.BatchResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Responses, 4)
+
+	assert.Equal(t, "list", resp.Responses[0].ID)
+	assert.Equal(t, http.StatusOK, resp.Responses[0].Status)
+	assert.Equal(t, `"v1"`, resp.Responses[0].Headers["ETag"])
+	assert.JSONEq(t, `{"page": "2", "tenant": "acme"}`, string(resp.Responses[0].Body))
+	assert.Equal(t, http.StatusNotFound, resp.Responses[1].Status)
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.Responses[2].Status)
+	assert.Equal(t, http.StatusBadRequest, resp.Responses[3].Status)
+
+	// Batches over the limit are rejected as a whole
+	req = httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(`{"requests": [
+		{"path": "/tasks"}, {"path": "/tasks"}, {"path": "/tasks"}, {"path": "/tasks"}, {"path": "/tasks"}
+	]}`))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestBatchHandler_TrustedProxy(t *testing.T) {
+	security := &recordedSecurity{}
+	authCfg := &config.AuthConfig{UserHeader: "X-Forwarded-User", TrustedProxies: []string{"10.0.0.0/8"}}
+
+	r := chi.NewRouter()
+	r.Use(middleware.Peer)
+	r.Use(middleware.RealIP(middleware.ParseProxies([]string{"10.0.0.0/8"}, "TRUSTED_PROXIES")))
+	r.Use(middleware.Authenticate(authCfg, &config.AdminConfig{}, nil, nil, security))
+	r.Get("/me", func(w http.ResponseWriter, r *http.Request) {
+		user := "anonymous"
+		if principal := auth.FromContext(r.Context()); principal != nil {
+			user = principal.User
+		}
+		pkg.JSONSuccess(w, map[string]string{"user": user, "ip": r.RemoteAddr})
+	})
+	r.Post("/batch", NewBatchHandler(r, &config.BatchConfig{MaxRequests: 20, MaxConcurrency: 4}, "").Batch)
+
+	items := make([]string, 20)
+	for i := range items {
+		items[i] = `{"path": "/me"}`
+	}
+	req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(`{"requests": [`+strings.Join(items, ",")+`]}`))
+	req.RemoteAddr = "10.0.0.5:4321"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	req.Header.Set("X-Forwarded-User", "alice")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp model.BatchResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Responses, 20)
+
+	// Every item runs as the proxy's user from the client's address, and
+	// the sign-in is recorded once, for the batch, with no failures
+	for _, item := range resp.Responses {
+		require.Equal(t, http.StatusOK, item.Status)
+		assert.JSONEq(t, `{"user": "alice", "ip": "203.0.113.7"}`, string(item.Body))
+	}
+	assert.Equal(t, 1, security.count(model.SecurityLoginSucceeded))
+	assert.Zero(t, security.count(model.SecurityLoginFailed))
+
+	// An anonymous batch cannot sign its items in with their own headers
+	req = httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(`{"requests": [
+		{"path": "/me", "headers": {"X-Forwarded-User": "bob"}}
+	]}`))
+	req.RemoteAddr = "10.0.0.5:4321"
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.JSONEq(t, `{"user": "anonymous", "ip": "10.0.0.5:4321"}`, string(resp.Responses[0].Body))
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/codec"
+)
+
+// ChecklistHandler handles HTTP requests for task checklists
+type ChecklistHandler struct {
+	service *service.ChecklistService
+}
+
+// NewChecklistHandler creates a new ChecklistHandler
+func NewChecklistHandler(service *service.ChecklistService) *ChecklistHandler {
+	return &ChecklistHandler{service: service}
+}
+
+// List handles GET /tasks/{id}/checklist
+func (h *ChecklistHandler) List(w http.ResponseWriter, r *http.Request) {
+	checklist, err := h.service.List(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeChecklistError(w, err, "Failed to retrieve checklist")
+		return
+	}
+
+	pkg.JSONSuccess(w, checklist)
+}
+
+// Create handles POST /tasks/{id}/checklist
+func (h *ChecklistHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req model.CreateChecklistItemRequest
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+
+	item, err := h.service.Create(r.Context(), chi.URLParam(r, "id"), &req)
+	if err != nil {
+		writeChecklistError(w, err, "Failed to create checklist item")
+		return
+	}
+
+	pkg.Created(w, item)
+}
+
+// Update handles PATCH /tasks/{id}/checklist/{itemID}
+func (h *ChecklistHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req model.UpdateChecklistItemRequest
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+
+	item, err := h.service.Update(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "itemID"), &req)
+	if err != nil {
+		writeChecklistError(w, err, "Failed to update checklist item")
+		return
+	}
+
+	pkg.JSONSuccess(w, item)
+}
+
+// Delete handles DELETE /tasks/{id}/checklist/{itemID}
+func (h *ChecklistHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.Delete(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "itemID")); err != nil {
+		writeChecklistError(w, err, "Failed to delete checklist item")
+		return
+	}
+
+	pkg.NoContent(w)
+}
+
+// Reorder handles PUT /tasks/{id}/checklist/order
+func (h *ChecklistHandler) Reorder(w http.ResponseWriter, r *http.Request) {
+	var req model.ReorderChecklistRequest
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+
+	checklist, err := h.service.Reorder(r.Context(), chi.URLParam(r, "id"), &req)
+	if err != nil {
+		writeChecklistError(w, err, "Failed to reorder checklist")
+		return
+	}
+
+	pkg.JSONSuccess(w, checklist)
+}
+
+// writeChecklistError answers a failed checklist request, with message for
+// unexpected errors
+func writeChecklistError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrValidation):
+		pkg.BadRequest(w, err.Error())
+	case errors.Is(err, service.ErrTaskNotFound):
+		pkg.NotFound(w, "Task not found")
+	case errors.Is(err, service.ErrChecklistItemNotFound):
+		pkg.NotFound(w, "Checklist item not found")
+	case errors.Is(err, service.ErrArchived):
+		pkg.Conflict(w, "Task is archived, unarchive it first")
+	default:
+		pkg.InternalError(w, message)
+	}
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/codec"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+)
+
+// CommentHandler handles HTTP requests for task comments
+type CommentHandler struct {
+	service *service.CommentService
+}
+
+// NewCommentHandler creates a new CommentHandler
+func NewCommentHandler(service *service.CommentService) *CommentHandler {
+	return &CommentHandler{service: service}
+}
+
+// Create handles POST /tasks/{id}/comments
+func (h *CommentHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req model.CreateCommentRequest
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+
+	comment, err := h.service.Create(r.Context(), chi.URLParam(r, "id"), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrValidation) {
+			pkg.BadRequest(w, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrTaskNotFound) {
+			pkg.NotFound(w, "Task not found")
+			return
+		}
+		pkg.InternalError(w, "Failed to create comment")
+		return
+	}
+
+	pkg.Created(w, comment)
+}
+
+// List handles GET /tasks/{id}/comments
+func (h *CommentHandler) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var opts model.ListOptions
+	var err error
+	if opts.Params, err = listing.Parse(query); err != nil {
+		pkg.BadRequest(w, err.Error())
+		return
+	}
+
+	comments, err := h.service.List(r.Context(), chi.URLParam(r, "id"), &opts)
+	if err != nil {
+		if errors.Is(err, service.ErrValidation) || errors.Is(err, service.ErrQueryTooExpensive) {
+			pkg.BadRequest(w, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrTaskNotFound) {
+			pkg.NotFound(w, "Task not found")
+			return
+		}
+		pkg.InternalError(w, "Failed to retrieve comments")
+		return
+	}
+
+	pkg.JSONList(w, comments)
+}
+
+// Delete handles DELETE /tasks/{id}/comments/{commentID}
+func (h *CommentHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	err := h.service.Delete(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "commentID"))
+	if err != nil {
+		if errors.Is(err, service.ErrTaskNotFound) {
+			pkg.NotFound(w, "Task not found")
+			return
+		}
+		if errors.Is(err, service.ErrCommentNotFound) {
+			pkg.NotFound(w, "Comment not found")
+			return
+		}
+		pkg.InternalError(w, "Failed to delete comment")
+		return
+	}
+
+	pkg.NoContent(w)
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/codec"
+)
+
+// CRUD is the service a CRUDHandler serves, such as a service.CRUDService
+type CRUD[C, U, R any] interface {
+	Create(ctx context.Context, req *C) (*R, error)
+	GetByID(ctx context.Context, id string) (*R, error)
+	List(ctx context.Context) ([]*R, error)
+	Update(ctx context.Context, id string, req *U) (*R, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// CRUDHandler handles HTTP requests for a simple resource. Invalid
+// requests get 400, NotFound 404 and Exists 409 with ExistsMessage.
+type CRUDHandler[C, U, R any] struct {
+	service CRUD[C, U, R]
+	// name and plural name the resource in messages, such as "tag" and
+	// "tags"
+	name, plural string
+
+	notFound, exists error
+	existsMessage    string
+}
+
+// NewCRUDHandler creates a new CRUDHandler. notFound and exists are the
+// service's errors for a missing or duplicate item.
+func NewCRUDHandler[C, U, R any](service CRUD[C, U, R], name, plural string, notFound, exists error, existsMessage string) *CRUDHandler[C, U, R] {
+	return &CRUDHandler[C, U, R]{
+		service:       service,
+		name:          name,
+		plural:        plural,
+		notFound:      notFound,
+		exists:        exists,
+		existsMessage: existsMessage,
+	}
+}
+
+// Routes mounts the handler: POST / and GET / for the collection, GET,
+// PATCH and DELETE /{id} for one item
+func (h *CRUDHandler[C, U, R]) Routes(r chi.Router) {
+	r.Post("/", h.Create)
+	r.Get("/", h.List)
+	r.Get("/{id}", h.GetByID)
+	r.Patch("/{id}", h.Update)
+	r.Delete("/{id}", h.Delete)
+}
+
+// Create handles POST /
+func (h *CRUDHandler[C, U, R]) Create(w http.ResponseWriter, r *http.Request) {
+	var req C
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+
+	item, err := h.service.Create(r.Context(), &req)
+	if err != nil {
+		h.writeError(w, err, "create "+h.name)
+		return
+	}
+
+	pkg.Created(w, item)
+}
+
+// List handles GET /
+func (h *CRUDHandler[C, U, R]) List(w http.ResponseWriter, r *http.Request) {
+	items, err := h.service.List(r.Context())
+	if err != nil {
+		pkg.InternalError(w, "Failed to retrieve "+h.plural)
+		return
+	}
+
+	pkg.JSONSuccess(w, items)
+}
+
+// GetByID handles GET /{id}
+func (h *CRUDHandler[C, U, R]) GetByID(w http.ResponseWriter, r *http.Request) {
+	item, err := h.service.GetByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeError(w, err, "retrieve "+h.name)
+		return
+	}
+
+	pkg.JSONSuccess(w, item)
+}
+
+// Update handles PATCH /{id}
+func (h *CRUDHandler[C, U, R]) Update(w http.ResponseWriter, r *http.Request) {
+	var req U
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+
+	item, err := h.service.Update(r.Context(), chi.URLParam(r, "id"), &req)
+	if err != nil {
+		h.writeError(w, err, "update "+h.name)
+		return
+	}
+
+	pkg.JSONSuccess(w, item)
+}
+
+// Delete handles DELETE /{id}
+func (h *CRUDHandler[C, U, R]) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.Delete(r.Context(), chi.URLParam(r, "id")); err != nil {
+		h.writeError(w, err, "delete "+h.name)
+		return
+	}
+
+	pkg.NoContent(w)
+}
+
+// writeError answers a failed operation, such as "create tag"
+func (h *CRUDHandler[C, U, R]) writeError(w http.ResponseWriter, err error, operation string) {
+	switch {
+	case errors.Is(err, service.ErrValidation):
+		pkg.BadRequest(w, err.Error())
+	case errors.Is(err, h.notFound):
+		pkg.NotFound(w, strings.ToUpper(h.name[:1])+h.name[1:]+" not found")
+	case h.exists != nil && errors.Is(err, h.exists):
+		pkg.Conflict(w, h.existsMessage)
+	default:
+		pkg.InternalError(w, "Failed to "+operation)
+	}
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/codec"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+)
+
+// EscalationHandler handles HTTP requests for the escalation rules of a
+// project and the escalations they made
+type EscalationHandler struct {
+	service *service.EscalationService
+}
+
+// NewEscalationHandler creates a new EscalationHandler
+func NewEscalationHandler(service *service.EscalationService) *EscalationHandler {
+	return &EscalationHandler{service: service}
+}
+
+// CreateRule handles POST /projects/{key}/escalation-rules
+func (h *EscalationHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	var req model.EscalationRuleRequest
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+
+	rule, err := h.service.CreateRule(r.Context(), chi.URLParam(r, "key"), &req)
+	if err != nil {
+		writeEscalationError(w, err, "Failed to create escalation rule")
+		return
+	}
+
+	pkg.Created(w, rule)
+}
+
+// ListRules handles GET /projects/{key}/escalation-rules
+func (h *EscalationHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.service.ListRules(r.Context(), chi.URLParam(r, "key"))
+	if err != nil {
+		pkg.InternalError(w, "Failed to retrieve escalation rules")
+		return
+	}
+
+	pkg.JSONSuccess(w, rules)
+}
+
+// GetRule handles GET /projects/{key}/escalation-rules/{id}
+func (h *EscalationHandler) GetRule(w http.ResponseWriter, r *http.Request) {
+	rule, err := h.service.GetRule(r.Context(), chi.URLParam(r, "key"), chi.URLParam(r, "id"))
+	if err != nil {
+		writeEscalationError(w, err, "Failed to retrieve escalation rule")
+		return
+	}
+
+	pkg.JSONSuccess(w, rule)
+}
+
+// UpdateRule handles PUT /projects/{key}/escalation-rules/{id}
+func (h *EscalationHandler) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	var req model.EscalationRuleRequest
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+
+	rule, err := h.service.UpdateRule(r.Context(), chi.URLParam(r, "key"), chi.URLParam(r, "id"), &req)
+	if err != nil {
+		writeEscalationError(w, err, "Failed to update escalation rule")
+		return
+	}
+
+	pkg.JSONSuccess(w, rule)
+}
+
+// DeleteRule handles DELETE /projects/{key}/escalation-rules/{id}
+func (h *EscalationHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteRule(r.Context(), chi.URLParam(r, "key"), chi.URLParam(r, "id")); err != nil {
+		writeEscalationError(w, err, "Failed to delete escalation rule")
+		return
+	}
+
+	pkg.NoContent(w)
+}
+
+// ListEscalations handles GET /projects/{key}/escalations
+func (h *EscalationHandler) ListEscalations(w http.ResponseWriter, r *http.Request) {
+	var opts model.ListOptions
+	var err error
+	if opts.Params, err = listing.Parse(r.URL.Query()); err != nil {
+		pkg.BadRequest(w, err.Error())
+		return
+	}
+
+	escalations, err := h.service.ListEscalations(r.Context(), chi.URLParam(r, "key"), &opts)
+	if err != nil {
+		if errors.Is(err, service.ErrValidation) || errors.Is(err, service.ErrQueryTooExpensive) {
+			pkg.BadRequest(w, err.Error())
+			return
+		}
+		pkg.InternalError(w, "Failed to retrieve escalations")
+		return
+	}
+
+	pkg.JSONList(w, escalations)
+}
+
+// writeEscalationError answers a failed rule request, with message for
+// unexpected errors
+func writeEscalationError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrEscalationRuleNotFound):
+		pkg.NotFound(w, "Escalation rule not found")
+	default:
+		writeProjectError(w, err, message)
+	}
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+)
+
+var (
+	errIfMatchRequired = errors.New("If-Match header is required, send the task's ETag or *")
+	errIfMatchInvalid  = errors.New("If-Match must be a single task ETag or *")
+)
+
+// taskETag formats a task version as a strong ETag
+func taskETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// setTaskETag sets the ETag header from the task's version
+func setTaskETag(w http.ResponseWriter, task *model.TaskResponse) {
+	w.Header().Set("ETag", taskETag(task.Version))
+}
+
+// ifMatchVersion returns the task version required by the If-Match header.
+// "*" matches any version and is returned as repository.AnyVersion.
+func ifMatchVersion(r *http.Request) (int64, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" {
+		return 0, errIfMatchRequired
+	}
+	if value == "*" {
+		return repository.AnyVersion, nil
+	}
+
+	// Weak validators are accepted since versions identify the whole task
+	value = strings.TrimPrefix(value, "W/")
+	if len(value) < 2 || !strings.HasPrefix(value, `"`) || !strings.HasSuffix(value, `"`) {
+		return 0, errIfMatchInvalid
+	}
+
+	version, err := strconv.ParseInt(value[1:len(value)-1], 10, 64)
+	if err != nil || version <= 0 {
+		return 0, errIfMatchInvalid
+	}
+	return version, nil
+}
+
+// writePreconditionError responds to a missing or malformed If-Match header
+func writePreconditionError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errIfMatchRequired) {
+		pkg.PreconditionRequired(w, err.Error())
+		return
+	}
+	pkg.BadRequest(w, err.Error())
+}
+
+// notModified reports whether the If-None-Match header matches etag
+func notModified(r *http.Request, etag string) bool {
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// ifNoneMatchToken returns the opaque value of a single If-None-Match ETag
+func ifNoneMatchToken(r *http.Request) string {
+	value := strings.TrimPrefix(strings.TrimSpace(r.Header.Get("If-None-Match")), "W/")
+	if len(value) < 2 || !strings.HasPrefix(value, `"`) || !strings.HasSuffix(value, `"`) {
+		return ""
+	}
+	return value[1 : len(value)-1]
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskHandler_ETag(t *testing.T) {
+	ctx := context.Background()
+	events := service.NewEventService(repository.NewMemoryEventRepository(), kvstore.NewMemory(), &config.EventsConfig{})
+	tasks := service.NewTaskService(repository.NewMemoryTaskRepository(0), nil, nil, events, nil, nil, nil, &config.TaskConfig{DefaultProject: "TASK"})
+
+	h := NewTaskHandler(tasks, nil, nil, nil, nil)
+	r := chi.NewRouter()
+	r.Get("/tasks/{id}", h.GetByID)
+	r.Put("/tasks/{id}", h.Update)
+	r.Patch("/tasks/{id}", h.Patch)
+	r.Delete("/tasks/{id}", h.Delete)
+
+	do := func(method, path, body string, headers ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		for i := 0; i < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	task, err := tasks.Create(ctx, &model.CreateTaskRequest{Title: "Release"})
+	require.NoError(t, err)
+	path := "/tasks/" + task.ID
+
+	t.Run("get", func(t *testing.T) {
+		w := do(http.MethodGet, path, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+
+		// If-None-Match compares weakly, so a weakened ETag still matches
+		for _, etag := range []string{`"1"`, `W/"1"`, `"7", W/"1"`, "*"} {
+			w = do(http.MethodGet, path, "", "If-None-Match", etag)
+			assert.Equal(t, http.StatusNotModified, w.Code, etag)
+			assert.Empty(t, w.Body.String())
+		}
+
+		w = do(http.MethodGet, path, "", "If-None-Match", `"2"`)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("missing If-Match", func(t *testing.T) {
+		for _, method := range []string{http.MethodPut, http.MethodPatch, http.MethodDelete} {
+			w := do(method, path, `{"title":"Ship"}`)
+			assert.Equal(t, http.StatusPreconditionRequired, w.Code, method)
+		}
+	})
+
+	t.Run("malformed If-Match", func(t *testing.T) {
+		for _, etag := range []string{"1", `"one"`, `"0"`, `"1", "2"`} {
+			w := do(http.MethodPut, path, `{"title":"Ship"}`, "If-Match", etag)
+			assert.Equal(t, http.StatusBadRequest, w.Code, etag)
+		}
+	})
+
+	t.Run("current If-Match", func(t *testing.T) {
+		w := do(http.MethodPut, path, `{"title":"Ship"}`, "If-Match", `"1"`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+
+		// A proxy that compresses responses may weaken the ETag a client
+		// echoes back; the version still names the whole task, so it matches
+		w = do(http.MethodPatch, path, `{"description":"Tag and push"}`, "If-Match", `W/"2"`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+
+		w = do(http.MethodPut, path, `{"title":"Ship it"}`, "If-Match", "*")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+	})
+
+	t.Run("stale If-Match", func(t *testing.T) {
+		for _, method := range []string{http.MethodPut, http.MethodPatch, http.MethodDelete} {
+			for _, etag := range []string{`"3"`, `W/"3"`} {
+				w := do(method, path, `{"title":"Lost update"}`, "If-Match", etag)
+				assert.Equal(t, http.StatusPreconditionFailed, w.Code, method+" "+etag)
+			}
+		}
+
+		w := do(http.MethodGet, path, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"title":"Ship it"`)
+
+		w = do(http.MethodDelete, path, "", "If-Match", `"4"`)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+}
@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+// eventBatchSize is the most events read from the store per wake-up
+const eventBatchSize = 100
+
+var errInvalidCursor = errors.New("invalid event cursor")
+
+// EventsHandler streams task events to clients over Server-Sent Events
+type EventsHandler struct {
+	events *service.EventService
+	cfg    *config.EventsConfig
+	appCtx context.Context
+}
+
+// NewEventsHandler creates a new EventsHandler. Streams end with a
+// shutdown event once appCtx is cancelled so the server can drain.
+func NewEventsHandler(appCtx context.Context, events *service.EventService, cfg *config.EventsConfig) *EventsHandler {
+	return &EventsHandler{events: events, cfg: cfg, appCtx: appCtx}
+}
+
+// Stream handles GET /events, or replays a page of history when since is given
+func (h *EventsHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Has("since") {
+		h.Replay(w, r)
+		return
+	}
+
+	ctx := r.Context()
+	stream := r.URL.Query().Get("stream")
+
+	cursor, err := h.startCursor(r, stream)
+	if errors.Is(err, errInvalidCursor) {
+		pkg.BadRequest(w, "Invalid event cursor, expected a non-negative event ID")
+		return
+	}
+	if err != nil {
+		pkg.InternalError(w, "Failed to resolve event cursor")
+		return
+	}
+
+	// Streams outlive the server write timeout, so they manage their own deadline
+	rc := http.NewResponseController(w)
+	extendDeadline := func() {
+		_ = rc.SetWriteDeadline(time.Now().Add(h.cfg.PollInterval + 10*time.Second))
+	}
+	extendDeadline()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", h.cfg.ReconnectDelay.Milliseconds())
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	log := logger.Ctx(r.Context()).WithComponent("events")
+
+	poll := time.NewTicker(h.cfg.PollInterval)
+	defer poll.Stop()
+	maxDuration := time.NewTimer(h.cfg.MaxStreamDuration)
+	defer maxDuration.Stop()
+
+	for {
+		// Subscribe before reading so a publish in between is not missed
+		changed := h.events.Changed()
+
+		events, gap, err := h.events.After(ctx, cursor, eventBatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Error().Err(err).Int64("cursor", cursor).Msg("Failed to read task events")
+			}
+			return
+		}
+
+		extendDeadline()
+		if gap {
+			// Events after the cursor were purged, the client has to refetch state
+			writeEvent(w, cursor, "reset", map[string]any{"cursor": cursor})
+		}
+		for _, event := range events {
+			data, err := json.Marshal(event)
+			if err != nil {
+				log.Error().Err(err).Int64("event_id", event.ID).Msg("Failed to encode task event")
+				continue
+			}
+			writeEvent(w, event.ID, string(event.Type), json.RawMessage(data))
+			cursor = event.ID
+		}
+		if len(events) > 0 || gap {
+			if err := rc.Flush(); err != nil {
+				return
+			}
+			h.saveCursor(ctx, stream, cursor)
+		}
+
+		// More events are waiting, read them before sleeping
+		if len(events) == eventBatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.appCtx.Done():
+			h.end(w, rc, cursor, "shutdown")
+			return
+		case <-maxDuration.C:
+			h.end(w, rc, cursor, "reconnect")
+			return
+		case <-changed:
+		case <-poll.C:
+			// Keep-alive comment, also stops idle proxies closing the stream
+			extendDeadline()
+			fmt.Fprint(w, ": ping\n\n")
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Replay handles GET /events?since=
+func (h *EventsHandler) Replay(w http.ResponseWriter, r *http.Request) {
+	since, err := strconv.ParseInt(r.URL.Query().Get("since"), 10, 64)
+	if err != nil {
+		pkg.BadRequest(w, "since must be an event ID")
+		return
+	}
+
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil {
+			pkg.BadRequest(w, "limit must be a number")
+			return
+		}
+	}
+
+	page, err := h.events.Replay(r.Context(), since, limit)
+	if err != nil {
+		if errors.Is(err, service.ErrValidation) {
+			pkg.BadRequest(w, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrEventsPurged) {
+			pkg.Gone(w, "Events after this cursor were purged, resync from GET /tasks before replaying")
+			return
+		}
+		pkg.InternalError(w, "Failed to replay events")
+		return
+	}
+
+	pkg.JSONList(w, page)
+}
+
+// startCursor resolves where a stream resumes from: the Last-Event-ID
+// header sent by reconnecting browsers, an explicit cursor, the saved
+// cursor of a named stream, or the newest event for fresh clients
+func (h *EventsHandler) startCursor(r *http.Request, stream string) (int64, error) {
+	for _, raw := range []string{r.Header.Get("Last-Event-ID"), r.URL.Query().Get("cursor")} {
+		if raw == "" {
+			continue
+		}
+		cursor, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || cursor < 0 {
+			return 0, errInvalidCursor
+		}
+		return cursor, nil
+	}
+
+	if stream != "" {
+		cursor, ok, err := h.events.LoadCursor(r.Context(), stream)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			return cursor, nil
+		}
+	}
+
+	return h.events.Latest(r.Context())
+}
+
+// saveCursor persists the cursor of a named stream; it runs detached from
+// the request's cancellation so the final position is kept when the client
+// disconnects, but keeps its caller, whom the cursor belongs to
+func (h *EventsHandler) saveCursor(ctx context.Context, stream string, cursor int64) {
+	if stream == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 2*time.Second)
+	defer cancel()
+
+	if err := h.events.SaveCursor(ctx, stream, cursor); err != nil {
+		logger.Get().Error().Err(err).Str("stream", stream).Msg("Failed to save stream cursor")
+	}
+}
+
+// end tells the client why the stream is closing and when to reconnect
+func (h *EventsHandler) end(w http.ResponseWriter, rc *http.ResponseController, cursor int64, reason string) {
+	fmt.Fprintf(w, "retry: %d\n", h.cfg.ReconnectDelay.Milliseconds())
+	writeEvent(w, cursor, reason, map[string]any{"cursor": cursor})
+	_ = rc.Flush()
+}
+
+func writeEvent(w http.ResponseWriter, id int64, event string, data any) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, encoded)
+}
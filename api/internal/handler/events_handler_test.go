@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// inFlightEvents is an event store where the event before held, when
+// set, is still committing
+type inFlightEvents struct {
+	repository.EventStore
+	held int64
+}
+
+func (s *inFlightEvents) Settled(ctx context.Context, after int64, window time.Duration) (int64, error) {
+	settled, err := s.EventStore.Settled(ctx, after, window)
+	if s.held > 0 {
+		settled = max(min(settled, s.held), after)
+	}
+	return settled, err
+}
+
+func TestEventsHandler_Stream(t *testing.T) {
+	cfg := &config.EventsConfig{
+		PollInterval:      time.Hour,
+		MaxStreamDuration: 20 * time.Millisecond,
+		ReconnectDelay:    time.Second,
+		Retention:         time.Hour,
+	}
+	store := &inFlightEvents{EventStore: repository.NewMemoryEventRepository()}
+	events := service.NewEventService(store, kvstore.NewMemory(), cfg)
+	h := NewEventsHandler(context.Background(), events, cfg)
+
+	ctx := context.Background()
+	record := func(taskID string) {
+		require.NoError(t, events.Atomically(ctx, func(ctx context.Context) error {
+			_, err := events.Record(ctx, model.EventTaskCreated, taskID, nil)
+			return err
+		}))
+	}
+	stream := func(user, query, lastEventID string) string {
+		req := httptest.NewRequest(http.MethodGet, "/events"+query, nil)
+		req = req.WithContext(auth.WithPrincipal(req.Context(), &auth.Principal{User: user}))
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		w := httptest.NewRecorder()
+		h.Stream(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+
+	for _, id := range []string{"a", "b", "c"} {
+		record(id)
+	}
+
+	t.Run("reconnect", func(t *testing.T) {
+		body := stream("alice", "", "1")
+		assert.NotContains(t, body, "id: 1\nevent: task.created")
+		assert.Contains(t, body, "id: 2\nevent: task.created")
+		assert.Contains(t, body, "id: 3\nevent: task.created")
+		assert.Contains(t, body, "id: 3\nevent: reconnect")
+	})
+
+	t.Run("named stream resumes for its caller only", func(t *testing.T) {
+		body := stream("alice", "?stream=board&cursor=2", "")
+		assert.Contains(t, body, "id: 3\nevent: task.created")
+
+		record("d")
+
+		// alice resumes after the last event delivered to her stream
+		body = stream("alice", "?stream=board", "")
+		assert.NotContains(t, body, "id: 3\nevent: task.created")
+		assert.Contains(t, body, "id: 4\nevent: task.created")
+
+		// bob's stream of the same name starts from now
+		body = stream("bob", "?stream=board", "")
+		assert.NotContains(t, body, "event: task.created")
+		assert.Contains(t, body, "id: 4\nevent: reconnect")
+	})
+
+	t.Run("events wait for earlier writes to commit", func(t *testing.T) {
+		record("e")
+		store.held = 4
+
+		body := stream("alice", "", "4")
+		assert.NotContains(t, body, "event: task.created")
+		assert.Contains(t, body, "id: 4\nevent: reconnect")
+
+		store.held = 0
+		body = stream("alice", "", "4")
+		assert.Contains(t, body, "id: 5\nevent: task.created")
+	})
+
+	t.Run("gap", func(t *testing.T) {
+		_, err := store.Purge(ctx, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		record("f")
+
+		body := stream("alice", "", "2")
+		assert.Contains(t, body, "id: 2\nevent: reset")
+		assert.Contains(t, body, "id: 6\nevent: task.created")
+	})
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+)
+
+// HistoryHandler handles HTTP requests for task history
+type HistoryHandler struct {
+	service *service.HistoryService
+}
+
+// NewHistoryHandler creates a new HistoryHandler
+func NewHistoryHandler(service *service.HistoryService) *HistoryHandler {
+	return &HistoryHandler{service: service}
+}
+
+// List handles GET /tasks/{id}/history
+func (h *HistoryHandler) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var opts model.ListOptions
+	var err error
+	if opts.Params, err = listing.Parse(query); err != nil {
+		pkg.BadRequest(w, err.Error())
+		return
+	}
+
+	history, err := h.service.History(r.Context(), chi.URLParam(r, "id"), &opts)
+	if err != nil {
+		if errors.Is(err, service.ErrValidation) || errors.Is(err, service.ErrQueryTooExpensive) {
+			pkg.BadRequest(w, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrTaskNotFound) {
+			pkg.NotFound(w, "Task not found")
+			return
+		}
+		pkg.InternalError(w, "Failed to retrieve task history")
+		return
+	}
+
+	pkg.JSONList(w, history)
+}
+
+// Activity handles GET /activity
+func (h *HistoryHandler) Activity(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var opts model.ListOptions
+	var filter model.ActivityFilter
+	var err error
+	if opts.Params, err = listing.Parse(query); err != nil {
+		pkg.BadRequest(w, err.Error())
+		return
+	}
+	if filter.Actions, err = model.ParseHistoryActions(query.Get("action")); err != nil {
+		pkg.BadRequest(w, err.Error())
+		return
+	}
+	if filter.From, err = listing.Time(query, "from"); err != nil {
+		pkg.BadRequest(w, err.Error())
+		return
+	}
+	if filter.To, err = listing.Time(query, "to"); err != nil {
+		pkg.BadRequest(w, err.Error())
+		return
+	}
+
+	activity, err := h.service.Activity(r.Context(), &filter, &opts)
+	if err != nil {
+		if errors.Is(err, service.ErrValidation) || errors.Is(err, service.ErrQueryTooExpensive) {
+			pkg.BadRequest(w, err.Error())
+			return
+		}
+		pkg.InternalError(w, "Failed to retrieve activity")
+		return
+	}
+
+	pkg.JSONList(w, activity)
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+)
+
+// Import handles POST /tasks/import, a multipart/form-data upload with the
+// file in its "file" field
+func (h *TaskHandler) Import(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		pkg.UnsupportedMediaType(w, "Content-Type must be multipart/form-data")
+		return
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		pkg.BadRequest(w, "Invalid multipart body")
+		return
+	}
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			pkg.BadRequest(w, "Missing file field")
+			return
+		}
+		if err != nil {
+			pkg.BadRequest(w, "Invalid multipart body")
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		format, ok := importFormat(r.URL.Query().Get("format"), part.Header.Get("Content-Type"), part.FileName())
+		if !ok {
+			pkg.BadRequest(w, "Unknown import format, send format=csv or format=ndjson")
+			return
+		}
+
+		report, err := h.service.Import(r.Context(), format, part)
+		if err != nil {
+			if errors.Is(err, service.ErrValidation) {
+				pkg.BadRequest(w, err.Error())
+				return
+			}
+			pkg.InternalError(w, "Failed to import tasks")
+			return
+		}
+
+		pkg.JSONSuccess(w, report)
+		return
+	}
+}
+
+// importFormat picks the format of an upload from the format query
+// parameter, else the file's content type, else its extension
+func importFormat(param, contentType, filename string) (model.ImportFormat, bool) {
+	if param != "" {
+		format := model.ImportFormat(strings.ToLower(param))
+		return format, format == model.ImportCSV || format == model.ImportNDJSON
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv":
+		return model.ImportCSV, true
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		return model.ImportNDJSON, true
+	}
+
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".csv":
+		return model.ImportCSV, true
+	case ".ndjson", ".jsonl":
+		return model.ImportNDJSON, true
+	}
+	return "", false
+}
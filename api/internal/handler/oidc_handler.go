@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/oidc"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+	"github.com/moabdelazem/mutlitier_app/pkg/middleware"
+)
+
+// oidcStateCookie keeps a started sign-in in the browser until the
+// provider redirects back
+const oidcStateCookie = "oidc_state"
+
+// OIDCHandler signs users in through an OpenID Connect provider. Sign-ins
+// are recorded as security events like password sign-ins.
+type OIDCHandler struct {
+	service  *service.OIDCService
+	security middleware.SecurityRecorder
+	cfg      *config.OIDCConfig
+	// path scopes the state cookie to the OIDC routes
+	path string
+}
+
+// NewOIDCHandler creates a new OIDCHandler whose routes are under basePath
+func NewOIDCHandler(service *service.OIDCService, security middleware.SecurityRecorder, cfg *config.OIDCConfig, basePath string) *OIDCHandler {
+	return &OIDCHandler{service: service, security: security, cfg: cfg, path: basePath + "/auth/oidc"}
+}
+
+// Login handles GET /auth/oidc/login, redirecting to the provider
+func (h *OIDCHandler) Login(w http.ResponseWriter, r *http.Request) {
+	login, err := h.service.Begin(r.Context())
+	if err != nil {
+		logger.Ctx(r.Context()).Error().Err(err).Msg("Failed to start OIDC sign-in")
+		pkg.WriteJSON(w, http.StatusBadGateway, pkg.ErrorResponse{Error: "Sign-in provider unavailable"})
+		return
+	}
+
+	http.SetCookie(w, h.cookie(login.State, int(h.cfg.LoginTimeout.Seconds())))
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, login.URL, http.StatusFound)
+}
+
+// Callback handles GET /auth/oidc/callback, where the provider sends the
+// user back with an authorization code
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	// The state is single use, whatever the outcome
+	http.SetCookie(w, h.cookie("", -1))
+	w.Header().Set("Cache-Control", "no-store")
+
+	query := r.URL.Query()
+	if reason := query.Get("error"); reason != "" {
+		h.failed(r, "", "provider refused sign-in: "+reason)
+		pkg.Unauthorized(w, "Sign-in was refused by the provider")
+		return
+	}
+
+	var stored string
+	if cookie, err := r.Cookie(oidcStateCookie); err == nil {
+		stored = cookie.Value
+	}
+
+	session, user, err := h.service.Complete(r.Context(), stored, query.Get("state"), query.Get("code"))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOIDCState):
+			pkg.BadRequest(w, "Sign-in expired or was not started here, start again")
+		case errors.Is(err, oidc.ErrInvalidIDToken):
+			h.failed(r, user, err.Error())
+			pkg.Unauthorized(w, "Invalid ID token")
+		case errors.Is(err, service.ErrNotTenantMember):
+			h.failed(r, user, "not a member of the tenant")
+			pkg.Forbidden(w, "Not a member of this tenant")
+		default:
+			logger.Ctx(r.Context()).Error().Err(err).Msg("Failed to complete OIDC sign-in")
+			pkg.WriteJSON(w, http.StatusBadGateway, pkg.ErrorResponse{Error: "Failed to complete sign-in"})
+		}
+		return
+	}
+
+	event := middleware.SecurityEvent(r, model.SecurityLoginSucceeded, "")
+	event.User, event.Credential = user, model.CredentialOIDC
+	h.security.Record(r.Context(), event)
+
+	pkg.JSONSuccess(w, session)
+}
+
+func (h *OIDCHandler) failed(r *http.Request, user, reason string) {
+	event := middleware.SecurityEvent(r, model.SecurityLoginFailed, reason)
+	event.User, event.Credential = user, model.CredentialOIDC
+	h.security.Record(r.Context(), event)
+}
+
+// cookie returns the state cookie, deleting it when maxAge is negative.
+// Lax lets it ride along on the provider's top-level redirect back.
+func (h *OIDCHandler) cookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    value,
+		Path:     h.path,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(h.cfg.RedirectURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	}
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskHandler_Priority(t *testing.T) {
+	events := service.NewEventService(repository.NewMemoryEventRepository(), kvstore.NewMemory(), &config.EventsConfig{})
+	guard := service.NewQueryGuard(&config.QueryGuardConfig{DefaultPerPage: 10, MaxPerPage: 10})
+	degradation := service.NewDegradation(&config.DegradationConfig{})
+	tasks := service.NewTaskService(repository.NewMemoryTaskRepository(0), guard, degradation, events, nil, nil, nil, &config.TaskConfig{DefaultProject: "TASK"})
+
+	h := NewTaskHandler(tasks, nil, nil, nil, nil)
+	r := chi.NewRouter()
+	r.Get("/tasks", h.GetAll)
+	r.Post("/tasks", h.Create)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	list := func(query string) []string {
+		w := do(http.MethodGet, "/tasks"+query, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp model.TaskListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		var titles []string
+		for _, task := range resp.Data {
+			titles = append(titles, task.Title)
+		}
+		return titles
+	}
+
+	t.Run("create", func(t *testing.T) {
+		w := do(http.MethodPost, "/tasks", `{"title":"Tidy docs"}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"priority":"medium"`)
+
+		for _, body := range []string{`{"title":"Fix outage","priority":"urgent"}`, `{"title":"Plan sprint","priority":"low"}`, `{"title":"Patch CVE","priority":"high"}`} {
+			w = do(http.MethodPost, "/tasks", body)
+			require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		}
+
+		w = do(http.MethodPost, "/tasks", `{"title":"Panic","priority":"critical"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"Fix outage", "Patch CVE"}, list("?priority=high,urgent"))
+		assert.Equal(t, []string{"Plan sprint"}, list("?priority=low"))
+
+		w := do(http.MethodGet, "/tasks?priority=critical", "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("sort by urgency", func(t *testing.T) {
+		assert.Equal(t, []string{"Fix outage", "Patch CVE", "Tidy docs", "Plan sprint"}, list("?sort=priority&order=desc"))
+		assert.Equal(t, []string{"Plan sprint", "Tidy docs", "Patch CVE", "Fix outage"}, list("?sort=priority&order=asc"))
+	})
+}
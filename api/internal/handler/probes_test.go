@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// headerWriter is a ResponseWriter that keeps nothing, so allocations
+// measured through it are the handler's own
+type headerWriter struct {
+	header http.Header
+}
+
+func (w *headerWriter) Header() http.Header         { return w.header }
+func (w *headerWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (w *headerWriter) WriteHeader(int)             {}
+
+func TestProbeMuxLiveDoesNotAllocate(t *testing.T) {
+	mux := &probeMux{
+		probes: map[string]http.Handler{"/health/live": http.HandlerFunc(liveHandler)},
+		next:   http.NotFoundHandler(),
+	}
+	w := &headerWriter{header: http.Header{}}
+	req := httptest.NewRequest(http.MethodGet, "/health/live", nil)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		mux.ServeHTTP(w, req)
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestProbeMuxBypassesChain(t *testing.T) {
+	chained := 0
+	mux := &probeMux{
+		probes: map[string]http.Handler{"/health/live": http.HandlerFunc(liveHandler)},
+		next: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			chained++
+		}),
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+	assert.Zero(t, chained)
+
+	// Other methods and paths still go through the chain
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/health/live", nil))
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/tasks", nil))
+	assert.Equal(t, 2, chained)
+}
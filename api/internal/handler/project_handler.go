@@ -0,0 +1,249 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/codec"
+)
+
+// ProjectHandler handles HTTP requests for projects and their tasks,
+// identified by the project key
+type ProjectHandler struct {
+	projects  *service.ProjectService
+	tasks     *service.TaskService
+	snapshots *service.SnapshotService
+	access    *AccessPolicy
+}
+
+// NewProjectHandler creates a new ProjectHandler. Without a snapshot
+// service the snapshot routes are not mounted.
+func NewProjectHandler(projects *service.ProjectService, tasks *service.TaskService, snapshots *service.SnapshotService, access *AccessPolicy) *ProjectHandler {
+	return &ProjectHandler{projects: projects, tasks: tasks, snapshots: snapshots, access: access}
+}
+
+// Create handles POST /projects
+func (h *ProjectHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req model.CreateProjectRequest
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+
+	project, err := h.projects.Create(r.Context(), &req)
+	if err != nil {
+		writeProjectError(w, err, "Failed to create project")
+		return
+	}
+
+	pkg.Created(w, project)
+}
+
+// List handles GET /projects
+func (h *ProjectHandler) List(w http.ResponseWriter, r *http.Request) {
+	projects, err := h.projects.List(r.Context())
+	if err != nil {
+		pkg.InternalError(w, "Failed to retrieve projects")
+		return
+	}
+
+	pkg.JSONSuccess(w, projects)
+}
+
+// Get handles GET /projects/{key}
+func (h *ProjectHandler) Get(w http.ResponseWriter, r *http.Request) {
+	project, err := h.projects.Get(r.Context(), chi.URLParam(r, "key"))
+	if err != nil {
+		writeProjectError(w, err, "Failed to retrieve project")
+		return
+	}
+
+	pkg.JSONSuccess(w, project)
+}
+
+// Update handles PATCH /projects/{key}
+func (h *ProjectHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req model.UpdateProjectRequest
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+
+	project, err := h.projects.Update(r.Context(), chi.URLParam(r, "key"), &req)
+	if err != nil {
+		if h.access.Deny(w, r, err, "Project not found") {
+			return
+		}
+		writeProjectError(w, err, "Failed to update project")
+		return
+	}
+
+	pkg.JSONSuccess(w, project)
+}
+
+// Delete handles DELETE /projects/{key}
+func (h *ProjectHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.projects.Delete(r.Context(), chi.URLParam(r, "key")); err != nil {
+		if h.access.Deny(w, r, err, "Project not found") {
+			return
+		}
+		writeProjectError(w, err, "Failed to delete project")
+		return
+	}
+
+	pkg.NoContent(w)
+}
+
+// RequireProject is a middleware answering 404 unless the {key} project
+// exists, for routes that read a project's tasks
+func (h *ProjectHandler) RequireProject(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := h.projects.Get(r.Context(), chi.URLParam(r, "key")); err != nil {
+			writeProjectError(w, err, "Failed to retrieve project")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// SyncTasks handles PUT /projects/{key}/tasks:sync. The declaration may
+// be sent, and the result read, as JSON or YAML; errors are always JSON.
+func (h *ProjectHandler) SyncTasks(w http.ResponseWriter, r *http.Request) {
+	dryRun, err := dryRunParam(r)
+	if err != nil {
+		pkg.BadRequest(w, err.Error())
+		return
+	}
+
+	// Declarations live in files, so a misspelt field is an error rather than a no-op
+	var req model.SyncRequest
+	if err := codec.Decode(r, &req); err != nil {
+		if errors.Is(err, codec.ErrUnsupportedMediaType) {
+			pkg.UnsupportedMediaType(w, "Content-Type must be application/json or application/yaml")
+			return
+		}
+		if errors.Is(err, codec.ErrInvalidYAML) {
+			pkg.BadRequest(w, "Invalid YAML payload: "+strings.TrimPrefix(err.Error(), codec.ErrInvalidYAML.Error()+": "))
+			return
+		}
+		pkg.BadRequest(w, decodeErrorMessage(err))
+		return
+	}
+
+	result, err := h.tasks.Sync(r.Context(), chi.URLParam(r, "key"), &req, dryRun)
+	if err != nil {
+		writeSyncError(w, err, "Failed to sync tasks")
+		return
+	}
+
+	codec.Write(w, r, http.StatusOK, result)
+}
+
+// CreateSnapshot handles POST /projects/{key}/snapshots
+func (h *ProjectHandler) CreateSnapshot(w http.ResponseWriter, r *http.Request) {
+	snapshot, err := h.snapshots.Create(r.Context(), chi.URLParam(r, "key"))
+	if err != nil {
+		writeSyncError(w, err, "Failed to create snapshot")
+		return
+	}
+
+	pkg.Created(w, snapshot)
+}
+
+// ListSnapshots handles GET /projects/{key}/snapshots
+func (h *ProjectHandler) ListSnapshots(w http.ResponseWriter, r *http.Request) {
+	snapshots, err := h.snapshots.List(r.Context(), chi.URLParam(r, "key"))
+	if err != nil {
+		writeSyncError(w, err, "Failed to list snapshots")
+		return
+	}
+
+	pkg.JSONSuccess(w, snapshots)
+}
+
+// GetSnapshot handles GET /projects/{key}/snapshots/{id}, as JSON or YAML
+func (h *ProjectHandler) GetSnapshot(w http.ResponseWriter, r *http.Request) {
+	document, err := h.snapshots.Get(r.Context(), chi.URLParam(r, "key"), chi.URLParam(r, "id"))
+	if err != nil {
+		writeSyncError(w, err, "Failed to retrieve snapshot")
+		return
+	}
+
+	codec.Write(w, r, http.StatusOK, document)
+}
+
+// RestoreSnapshot handles POST /projects/{key}/snapshots/{id}/restore
+func (h *ProjectHandler) RestoreSnapshot(w http.ResponseWriter, r *http.Request) {
+	dryRun, err := dryRunParam(r)
+	if err != nil {
+		pkg.BadRequest(w, err.Error())
+		return
+	}
+
+	result, err := h.snapshots.Restore(r.Context(), chi.URLParam(r, "key"), chi.URLParam(r, "id"), dryRun)
+	if err != nil {
+		writeSyncError(w, err, "Failed to restore snapshot")
+		return
+	}
+
+	codec.Write(w, r, http.StatusOK, result)
+}
+
+// writeProjectError answers a failed project request, with message for
+// unexpected errors
+func writeProjectError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrValidation):
+		pkg.BadRequest(w, err.Error())
+	case errors.Is(err, service.ErrAnonymous):
+		pkg.Unauthorized(w, err.Error())
+	case errors.Is(err, service.ErrProjectNotFound):
+		pkg.NotFound(w, "Project not found")
+	case errors.Is(err, service.ErrProjectExists):
+		pkg.Conflict(w, "A project with this key already exists")
+	case errors.Is(err, service.ErrProjectHasTasks):
+		pkg.Conflict(w, "Project has tasks, permanently delete them first")
+	default:
+		pkg.InternalError(w, message)
+	}
+}
+
+// writeSyncError answers a failed sync, snapshot or restore, with message
+// for unexpected errors
+func writeSyncError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrValidation):
+		pkg.BadRequest(w, err.Error())
+	case errors.Is(err, service.ErrSnapshotNotFound):
+		pkg.NotFound(w, "Snapshot not found")
+	case errors.Is(err, service.ErrSnapshotCorrupt):
+		pkg.InternalError(w, "Snapshot does not match its checksum")
+	case errors.Is(err, service.ErrInvalidTransition):
+		pkg.UnprocessableEntity(w, err.Error())
+	case errors.Is(err, service.ErrConflict) || errors.Is(err, service.ErrArchived) || errors.Is(err, service.ErrTaskNotFound):
+		pkg.Conflict(w, "Tasks changed during the sync, send it again: "+err.Error())
+	case errors.Is(err, service.ErrLimitReached):
+		pkg.Forbidden(w, "Task limit reached, try again after the next reset")
+	default:
+		pkg.InternalError(w, message)
+	}
+}
+
+// dryRunParam parses the optional dry_run query parameter
+func dryRunParam(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get("dry_run")
+	if value == "" {
+		return false, nil
+	}
+	dryRun, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.New("dry_run must be true or false")
+	}
+	return dryRun, nil
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectHandler_TenantAndAuthorization(t *testing.T) {
+	security := &recordedSecurity{}
+	h := NewProjectHandler(service.NewProjectService(repository.NewMemoryTaskRepository(0)), nil, nil,
+		NewAccessPolicy(&config.AuthConfig{Denial: "hide"}, security))
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := tenant.With(r.Context(), tenant.Default)
+			if id := r.Header.Get("X-Tenant"); id != "" {
+				ctx = tenant.With(ctx, id)
+			}
+			switch user := r.Header.Get("X-User"); user {
+			case "":
+			case "admin":
+				ctx = auth.WithPrincipal(ctx, &auth.Principal{User: user, Scopes: auth.Scopes()})
+			default:
+				ctx = auth.WithPrincipal(ctx, &auth.Principal{User: user})
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	r.Post("/projects", h.Create)
+	r.Get("/projects", h.List)
+	r.Get("/projects/{key}", h.Get)
+	r.Patch("/projects/{key}", h.Update)
+	r.Delete("/projects/{key}", h.Delete)
+
+	do := func(method, path, tenantID, user, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Tenant", tenantID)
+		req.Header.Set("X-User", user)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	list := func(tenantID, user string) []string {
+		w := do(http.MethodGet, "/projects", tenantID, user, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var projects []model.ProjectResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &projects))
+		var names []string
+		for _, project := range projects {
+			names = append(names, project.Name)
+		}
+		return names
+	}
+
+	w := do(http.MethodPost, "/projects", "acme", "", `{"key":"OPS","name":"Acme"}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+	w = do(http.MethodPost, "/projects", "acme", "alice", `{"key":"OPS","name":"Acme"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = do(http.MethodPost, "/projects", "", "bob", `{"key":"OPS","name":"Default"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	// An anonymous list stays in its tenant
+	assert.Equal(t, []string{"Default"}, list("", ""))
+	assert.Equal(t, []string{"Acme"}, list("acme", "alice"))
+
+	// Changing a project takes an admin of its tenant; under the hide
+	// policy a denied change looks like a missing project
+	w = do(http.MethodPatch, "/projects/OPS", "acme", "", `{"name":"Renamed"}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+	w = do(http.MethodPatch, "/projects/OPS", "acme", "alice", `{"name":"Renamed"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+	w = do(http.MethodDelete, "/projects/OPS", "acme", "alice", "")
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+	assert.Equal(t, 2, security.count(model.SecurityPermissionDenied))
+
+	w = do(http.MethodDelete, "/projects/OPS", "", "admin", "")
+	assert.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	assert.Empty(t, list("", ""))
+	assert.Equal(t, []string{"Acme"}, list("acme", "alice"))
+	w = do(http.MethodPatch, "/projects/OPS", "acme", "admin", `{"name":"Renamed"}`)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, []string{"Renamed"}, list("acme", "alice"))
+}
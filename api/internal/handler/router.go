@@ -2,18 +2,35 @@ package handler
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/mutlitier_app/internal/analytics"
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
 	"github.com/moabdelazem/mutlitier_app/internal/config"
 	"github.com/moabdelazem/mutlitier_app/internal/database"
+	"github.com/moabdelazem/mutlitier_app/internal/demo"
+	"github.com/moabdelazem/mutlitier_app/internal/llm"
+	"github.com/moabdelazem/mutlitier_app/internal/oidc"
 	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/internal/search"
 	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/internal/storage"
 	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/codec"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
 	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+	"github.com/moabdelazem/mutlitier_app/pkg/metrics"
 	"github.com/moabdelazem/mutlitier_app/pkg/middleware"
+	"github.com/moabdelazem/mutlitier_app/pkg/publicid"
+	"github.com/moabdelazem/mutlitier_app/pkg/tracing"
+	"github.com/moabdelazem/mutlitier_app/pkg/worker"
 )
 
 type HealthResponse struct {
@@ -28,51 +45,901 @@ type ServiceInfo struct {
 }
 
 type HealthHandler struct {
-	db *database.DB
+	db     *database.DB
+	probes *repository.ProbeRepository
 }
 
 func NewHealthHandler(db *database.DB) *HealthHandler {
-	return &HealthHandler{
+	h := &HealthHandler{
 		db: db,
 	}
+	if db != nil {
+		h.probes = repository.NewProbeRepository(db)
+	}
+	return h
+}
+
+// Handlers are the HTTP handlers of each listener. Admin and Metrics are
+// nil when their routes are served by API, Debug when profiling is off.
+type Handlers struct {
+	API     http.Handler
+	Admin   http.Handler
+	Metrics http.Handler
+	Debug   http.Handler
 }
 
-func SetupRouter(db *database.DB, cfg *config.Config, log *logger.Logger) http.Handler {
+// SetupRouter builds the HTTP handlers. Background work and long-lived
+// streams stop when ctx is cancelled, which main does on shutdown; workers
+// that claim shared work run in workers so main can drain them. shadowDB
+// is the postgres shadow of the task repository, nil unless one is set.
+func SetupRouter(ctx context.Context, workers *worker.Group, db, shadowDB *database.DB, store kvstore.Store, cfg *config.Config, log *logger.Logger) *Handlers {
 	r := chi.NewRouter()
+	handlers := &Handlers{}
+
+	// Routes are mounted under BASE_PATH when one is set, so an ingress can
+	// route a path prefix to the API without rewriting it
+	root := r
+	if cfg.BasePath != "" {
+		root = chi.NewRouter()
+	}
 
 	// Initialize handlers
 	healthHandler := NewHealthHandler(db)
 
-	// Initialize task dependencies
-	taskRepo := repository.NewTaskRepository(db)
-	taskService := service.NewTaskService(taskRepo)
-	taskHandler := NewTaskHandler(taskService)
+	// Initialize task dependencies (in memory when running the demo)
+	var taskRepo repository.TaskStore
+	var tagRepo repository.TagStore
+	var historyRepo repository.HistoryStore
+	var recurrenceRepo repository.RecurrenceStore
+	var statsRepo repository.StatsStore
+	var projectRepo repository.ProjectStore
+	var teamRepo repository.TeamStore
+	var escalationRepo repository.EscalationStore
+	var automationRepo repository.AutomationStore
+	var embeddingRepo repository.EmbeddingStore
+	var demoRepo *repository.MemoryTaskRepository
+	if cfg.Demo.Enabled {
+		demoRepo = repository.NewMemoryTaskRepository(cfg.Demo.MaxTasks)
+		taskRepo, tagRepo, historyRepo, recurrenceRepo, statsRepo, projectRepo = demoRepo, demoRepo, demoRepo, demoRepo, demoRepo, demoRepo
+		teamRepo, escalationRepo, automationRepo, embeddingRepo = demoRepo, demoRepo, demoRepo, demoRepo
+	} else {
+		sqlRepo := repository.NewTaskRepository(db)
+		taskRepo, tagRepo, historyRepo, recurrenceRepo, statsRepo, projectRepo = sqlRepo, sqlRepo, sqlRepo, sqlRepo, sqlRepo, sqlRepo
+		teamRepo, escalationRepo, automationRepo, embeddingRepo = sqlRepo, sqlRepo, sqlRepo, sqlRepo
+	}
+
+	// Shadow the primary repository while migrating to a new implementation
+	if cfg.Shadow.Enabled() {
+		dualWrite := cfg.Shadow.Mode == "dual-write"
+		switch {
+		case cfg.Shadow.Backend == "memory":
+			// Starts empty, so only tasks written since are compared
+			taskRepo = repository.NewShadowTaskStore(taskRepo, repository.NewMemoryTaskRepository(cfg.Shadow.MaxTasks),
+				dualWrite, cfg.Shadow.Timeout, cfg.Shadow.MaxInFlight).OnlyWritten()
+		case shadowDB != nil:
+			taskRepo = repository.NewShadowTaskStore(taskRepo, repository.NewTaskRepository(shadowDB),
+				dualWrite, cfg.Shadow.Timeout, cfg.Shadow.MaxInFlight)
+		default:
+			log.Error().Str("backend", cfg.Shadow.Backend).Msg("No shadow database, shadowing disabled")
+		}
+	}
+
+	// Task change log backing the resumable /events stream
+	var eventStore repository.EventStore
+	var commentRepo repository.CommentStore
+	var tokenRepo repository.TokenStore
+	var securityRepo repository.SecurityEventStore
+	var auditRepo repository.AuditLogStore
+	var checklistRepo repository.ChecklistStore
+	var attachmentRepo repository.AttachmentStore
+	var userRepo repository.UserStore
+	var refreshRepo repository.RefreshTokenStore
+	var sessionRepo repository.SessionStore
+	var memberRepo repository.MemberStore
+	var watcherRepo repository.WatcherStore
+	var notificationRepo repository.NotificationStore
+	var demoComments *repository.MemoryCommentRepository
+	var demoChecklists *repository.MemoryChecklistRepository
+	var demoAttachments *repository.MemoryAttachmentRepository
+	var demoWatchers *repository.MemoryWatcherRepository
+	var demoNotifications *repository.MemoryNotificationRepository
+	if cfg.Demo.Enabled {
+		eventStore = repository.NewMemoryEventRepository().FollowTasks(demoRepo)
+		demoComments = repository.NewMemoryCommentRepository()
+		demoRepo.FollowComments(demoComments)
+		commentRepo = demoComments
+		demoChecklists = repository.NewMemoryChecklistRepository()
+		checklistRepo = demoChecklists
+		demoAttachments = repository.NewMemoryAttachmentRepository()
+		attachmentRepo = demoAttachments
+		tokenRepo = repository.NewMemoryTokenRepository()
+		securityRepo = repository.NewMemorySecurityEventRepository()
+		auditRepo = repository.NewMemoryAuditLogRepository()
+		userRepo = repository.NewMemoryUserRepository()
+		refreshRepo = repository.NewMemoryRefreshTokenRepository()
+		sessionRepo = repository.NewMemorySessionRepository()
+		memberRepo = repository.NewMemoryMemberRepository()
+		demoWatchers = repository.NewMemoryWatcherRepository()
+		watcherRepo = demoWatchers
+		demoNotifications = repository.NewMemoryNotificationRepository()
+		notificationRepo = demoNotifications
+	} else {
+		eventStore = repository.NewEventRepository(db)
+		commentRepo = repository.NewCommentRepository(db)
+		checklistRepo = repository.NewChecklistRepository(db)
+		attachmentRepo = repository.NewAttachmentRepository(db)
+		tokenRepo = repository.NewTokenRepository(db)
+		securityRepo = repository.NewSecurityEventRepository(db)
+		auditRepo = repository.NewAuditLogRepository(db)
+		userRepo = repository.NewUserRepository(db)
+		refreshRepo = repository.NewRefreshTokenRepository(db)
+		sessionRepo = repository.NewSessionRepository(db)
+		memberRepo = repository.NewMemberRepository(db)
+		watcherRepo = repository.NewWatcherRepository(db)
+		notificationRepo = repository.NewNotificationRepository(db)
+	}
+	events := service.NewEventService(eventStore, store, &cfg.Events)
+	go events.PurgeEvery(ctx, cfg.Events.PurgeInterval)
+	eventsHandler := NewEventsHandler(ctx, events, &cfg.Events)
+
+	// Next occurrences of completed recurring tasks
+	if cfg.Recurrence.Enabled {
+		workers.Go("recurrence", service.NewRecurrenceScheduler(recurrenceRepo, events, &cfg.Recurrence).Run)
+	}
+
+	// Notifications for the watchers of changed tasks
+	if cfg.Notifications.Enabled {
+		workers.Go("notifications", service.NewNotificationFanout(events, watcherRepo, notificationRepo, store, &cfg.Notifications).Run)
+	}
+
+	// Keeps change data capture replication slots moving while tasks are idle
+	if db != nil && cfg.CDC.HeartbeatInterval > 0 {
+		go service.BeatEvery(ctx, repository.NewHeartbeatRepository(db), cfg.CDC.HeartbeatInterval)
+	}
+
+	// Warnings about goroutines and database connections that keep growing
+	if cfg.Watchdog.Interval > 0 {
+		var dbStats func() sql.DBStats
+		if db != nil {
+			dbStats = db.GetStats
+		}
+		go service.NewWatchdog(dbStats, &cfg.Watchdog).Run(ctx)
+	}
+
+	// Optional search engine mirror, kept up to date from the event log
+	var index search.Index
+	var indexer *service.SearchIndexer
+	if cfg.Search.Enabled() {
+		var err error
+		if index, err = search.New(&cfg.Search); err != nil {
+			log.Error().Err(err).Msg("Failed to create search index, searching Postgres instead")
+		} else {
+			indexer = service.NewSearchIndexer(index, events, taskRepo, store, &cfg.Search)
+			workers.Go("search-indexer", indexer.Run)
+		}
+	}
+
+	// Nightly analytics export to object storage
+	var exporter *service.AnalyticsExporter
+	if cfg.Analytics.Enabled {
+		sink, err := analytics.NewSink(&cfg.Analytics)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to create analytics sink, export disabled")
+		} else {
+			exporter = service.NewAnalyticsExporter(taskRepo, events, auditRepo, securityRepo, sink, store, &cfg.Analytics)
+			if err := exporter.Schedule(workers); err != nil {
+				log.Error().Err(err).Msg("Failed to schedule analytics export")
+			}
+			if cfg.Events.Retention < 24*time.Hour {
+				log.Warn().Dur("retention", cfg.Events.Retention).
+					Msg("EVENTS_RETENTION is shorter than a day, task events may be purged before the nightly analytics export")
+			}
+		}
+	}
+
+	// Attachment contents, kept in memory for the demo
+	var attachmentBackend storage.Backend
+	var demoFiles *storage.MemoryBackend
+	if cfg.Demo.Enabled {
+		demoFiles = storage.NewMemoryBackend()
+		attachmentBackend = demoFiles
+	} else {
+		var err error
+		attachmentBackend, err = storage.New(cfg.Attachments.URL, storage.S3Options{
+			Endpoint:  cfg.Attachments.S3Endpoint,
+			Region:    cfg.Attachments.S3Region,
+			AccessKey: cfg.Attachments.S3AccessKey,
+			SecretKey: cfg.Attachments.S3SecretKey,
+			UseSSL:    cfg.Attachments.S3UseSSL,
+		})
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to create attachment storage, attachments disabled")
+		}
+	}
+	// Project snapshots, kept in memory for the demo
+	var snapshotBackend storage.Backend
+	if cfg.Demo.Enabled {
+		snapshotBackend = storage.NewMemoryBackend()
+	} else {
+		var err error
+		snapshotBackend, err = storage.New(cfg.Snapshots.URL, storage.S3Options{
+			Endpoint:  cfg.Snapshots.S3Endpoint,
+			Region:    cfg.Snapshots.S3Region,
+			AccessKey: cfg.Snapshots.S3AccessKey,
+			SecretKey: cfg.Snapshots.S3SecretKey,
+			UseSSL:    cfg.Snapshots.S3UseSSL,
+		})
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to create snapshot storage, snapshots disabled")
+		}
+	}
+	if cfg.Abuse.Enabled && cfg.Abuse.MaxPayloadBytes > 0 && cfg.Abuse.MaxPayloadBytes < cfg.Attachments.MaxBytes {
+		log.Warn().Int64("abuse_max_payload_bytes", cfg.Abuse.MaxPayloadBytes).Int64("attachments_max_bytes", cfg.Attachments.MaxBytes).
+			Msg("ABUSE_MAX_PAYLOAD_BYTES is below ATTACHMENTS_MAX_BYTES, large uploads count as abuse")
+	}
+	if cfg.SigningConfig.Enabled() && cfg.SigningConfig.MaxBody < cfg.Attachments.MaxBytes {
+		log.Warn().Int64("signing_max_body_bytes", cfg.SigningConfig.MaxBody).Int64("attachments_max_bytes", cfg.Attachments.MaxBytes).
+			Msg("SIGNING_MAX_BODY_BYTES is below ATTACHMENTS_MAX_BYTES, large signed uploads are refused")
+	}
+
+	if cfg.Comments.OnTaskDelete != "cascade" && cfg.Comments.OnTaskDelete != "block" {
+		log.Warn().Str("policy", cfg.Comments.OnTaskDelete).Msg("Unknown COMMENTS_ON_TASK_DELETE, comments cascade with their task")
+	}
+
+	if cfg.Expansions.OnError != "partial" && cfg.Expansions.OnError != "fail" {
+		log.Warn().Str("policy", cfg.Expansions.OnError).Msg("Unknown EXPAND_ON_ERROR, failed expansions fail the request")
+	}
+
+	if cfg.Tenancy.Enabled {
+		if cfg.Demo.Enabled {
+			log.Warn().Msg("TENANCY_ENABLED has no effect in demo mode, the in-memory stores are shared by every tenant")
+		} else if bypass, err := db.BypassesRLS(ctx); err != nil {
+			log.Fatal().Err(err).Msg("Failed to check the database role for TENANCY_ENABLED")
+		} else if bypass {
+			// Superusers and BYPASSRLS roles see every tenant's rows
+			log.Fatal().Msg("TENANCY_ENABLED needs a database role that is not a superuser and lacks BYPASSRLS")
+		}
+	}
+
+	degradation := service.NewDegradation(&cfg.Degradation)
+	guard := service.NewQueryGuard(&cfg.QueryGuard)
+	pkg.SetMaxListBytes(int64(cfg.QueryGuard.MaxResponse))
+	if err := codec.Use(cfg.JSON.Engine); err != nil {
+		log.Warn().Err(err).Str("engine", codec.EngineName()).Msg("Unknown JSON_ENGINE, keeping the default")
+	}
+	commentService := service.NewCommentService(commentRepo, taskRepo, guard, &cfg.Comments)
+	users := service.NewUserService(userRepo)
+	taskService := service.NewTaskService(taskRepo, guard, degradation, events, index, commentService, users, &cfg.Tasks)
+	checklistService := service.NewChecklistService(checklistRepo, taskRepo, degradation, &cfg.Tasks)
+	watcherService := service.NewWatcherService(watcherRepo, notificationRepo, taskRepo, guard, &cfg.Notifications)
+	go watcherService.PurgeEvery(ctx, cfg.Notifications.PurgeInterval)
+	tagService := service.NewTagService(tagRepo, events)
+	expansions := service.NewExpansionService(checklistService, commentService, watcherService, tagService, degradation, &cfg.Expansions)
+	checklistHandler := NewChecklistHandler(checklistService)
+	watcherHandler := NewWatcherHandler(watcherService)
+	var attachmentHandler *AttachmentHandler
+	if attachmentBackend != nil {
+		attachmentHandler = NewAttachmentHandler(service.NewAttachmentService(attachmentRepo, taskRepo, attachmentBackend, &cfg.Attachments))
+	}
+	statsHandler := NewStatsHandler(service.NewStatsService(statsRepo, store, degradation, &cfg.Tasks))
+	commentHandler := NewCommentHandler(commentService)
+	tagHandler := NewTagHandler(tagService)
+	var snapshotService *service.SnapshotService
+	if snapshotBackend != nil {
+		snapshotService = service.NewSnapshotService(taskService, snapshotBackend, &cfg.Snapshots)
+	}
+
+	// Escalation of tasks due soon or overdue by the rules of their project
+	escalations := service.NewEscalationService(escalationRepo, taskService, events, notificationRepo, guard, &cfg.Escalation)
+	if cfg.Escalation.Enabled {
+		workers.Go("escalation", escalations.Run)
+	}
+	escalationHandler := NewEscalationHandler(escalations)
+
+	// Task summaries and triage by a language model, only when one is set up
+	var aiHandler *AIHandler
+	if cfg.AI.Enabled() {
+		if provider, err := llm.New(&cfg.AI); err != nil {
+			log.Error().Err(err).Msg("Failed to create language model client, summaries and triage disabled")
+		} else {
+			aiHandler = NewAIHandler(service.NewAIService(provider, taskService, commentService, tagRepo, store, &cfg.AI))
+		}
+	}
+
+	// Similar and likely duplicate tasks by embeddings, only when an
+	// embedding model is set up
+	var similarity *service.SimilarityService
+	var similarityHandler *SimilarityHandler
+	if cfg.Embeddings.Enabled() {
+		if embedder, err := llm.NewEmbedder(&cfg.Embeddings); err != nil {
+			log.Error().Err(err).Msg("Failed to create embedding model client, duplicate detection disabled")
+		} else {
+			similarity = service.NewSimilarityService(embedder, embeddingRepo, taskService, store, &cfg.Embeddings)
+			similarityHandler = NewSimilarityHandler(similarity)
+			workers.Go("embeddings", similarity.Run)
+		}
+	}
+	var duplicates *service.SimilarityService
+	if cfg.Embeddings.OnCreate {
+		duplicates = similarity
+	}
+
+	// Automation rules run on task events and passed due dates
+	automations := service.NewAutomationService(automationRepo, taskService, tagService, commentService, events, store, guard, &cfg.Automation)
+	if cfg.Automation.Enabled {
+		workers.Go("automation", automations.Run)
+	}
+	automationHandler := NewAutomationHandler(automations)
+
+	historyHandler := NewHistoryHandler(service.NewHistoryService(historyRepo, taskRepo, guard))
+	tokenService := service.NewTokenService(tokenRepo, &cfg.Auth)
+
+	// Who may sign in to which tenant
+	tenants := service.NewTenantService(memberRepo, tokenRepo, refreshRepo, sessionRepo, &cfg.Tenancy)
+
+	// Password sign-in, issuing JWT session tokens and refresh tokens
+	var authService *service.AuthService
+	var sessions middleware.TokenVerifier
+	if cfg.JWT.Enabled() {
+		if len(cfg.JWT.Secret) < 32 {
+			// A short HMAC key makes session tokens forgeable offline
+			log.Fatal().Msg("JWT_SECRET must be at least 32 characters")
+		}
+		authService = service.NewAuthService(userRepo, refreshRepo, sessionRepo, tenants, &cfg.JWT)
+		sessions = authService
+	}
+
+	// Authentication audit trail, written in batches in the background
+	security := service.NewSecurityService(securityRepo, guard, &cfg.Security)
+	workers.Go("security-events", security.Run)
+	go security.PurgeEvery(ctx, cfg.Security.PurgeInterval)
+	auditLog := service.NewAuditService(auditRepo, guard, &cfg.Audit)
+	if cfg.Audit.Enabled {
+		workers.Go("audit-logs", auditLog.Run)
+		go auditLog.PurgeEvery(ctx, cfg.Audit.PurgeInterval)
+	}
+	access := NewAccessPolicy(&cfg.Auth, security)
+	tokenHandler := NewTokenHandler(tokenService, security, access)
+	taskHandler := NewTaskHandler(taskService, service.NewBulkPlanner(taskService, store, &cfg.Tasks), expansions, access, duplicates)
+	teamHandler := NewTeamHandler(service.NewTeamService(teamRepo, taskService, users), access)
+	projectHandler := NewProjectHandler(service.NewProjectService(projectRepo), taskService, snapshotService, access)
+
+	if demoRepo != nil {
+		if err := demo.Seed(ctx, taskService); err != nil {
+			log.Error().Err(err).Msg("Failed to seed demo data")
+		}
+		go demo.ResetEvery(ctx, cfg.Demo.ResetInterval, taskService, demoRepo, demoComments, demoChecklists, demoAttachments, demoFiles, demoWatchers, demoNotifications)
+	}
+
+	// Nonces live in Postgres, or in the kv store when there is no database
+	var nonceStore middleware.NonceStore = middleware.NewKVNonceStore(store)
+	if db != nil {
+		nonceStore = repository.NewNonceRepository(db)
+	}
 
 	// Core middlewares
 	r.Use(chimw.RequestID)
-	r.Use(chimw.RealIP)
+	proxies := middleware.ParseProxies(cfg.IPFilter.TrustedProxies, "TRUSTED_PROXIES")
+	r.Use(middleware.Peer)
+	r.Use(middleware.RealIP(proxies))
 	r.Use(chimw.Recoverer)
 	r.Use(chimw.Timeout(60 * time.Second))
 
+	// Requests in flight, listed at /admin/requests to diagnose hangs
+	requests := middleware.NewRequestTracker()
+	if cfg.AdminConfig.Enabled {
+		r.Use(requests.Middleware)
+	}
+
+	// Opaque public IDs in place of UUIDs, translated in both directions
+	switch {
+	case cfg.PublicIDs.Opaque():
+		codec, err := publicid.New(cfg.PublicIDs.Secret)
+		if err != nil {
+			// Falling back to UUIDs would change every ID clients hold
+			log.Fatal().Err(err).Msg("PUBLIC_IDS=opaque needs a PUBLIC_IDS_SECRET of at least 16 characters")
+		}
+		r.Use(middleware.PublicIDs(codec))
+	case cfg.PublicIDs.Mode != "uuid":
+		log.Warn().Str("mode", cfg.PublicIDs.Mode).Msg("Unknown PUBLIC_IDS, exposing UUIDs")
+	}
+
 	// CORS middleware (configured via environment)
 	r.Use(middleware.CORS(&cfg.CORSConfig))
 
+	// A span per request, continuing the caller's W3C trace context, for
+	// metric exemplars, log correlation and the X-Trace-ID header
+	r.Use(tracing.Middleware)
+
 	// Structured request logging (replaces chi's DefaultLogger)
 	r.Use(middleware.RequestLogger(log))
 
-	// Health check route
+	// Per-route latency histogram with trace exemplars
+	r.Use(middleware.Metrics)
+
+	// CIDR allow and deny rules, reloaded when IP_RULES_FILE changes
+	ipFilter := middleware.NewIPFilter(&cfg.IPFilter, security)
+	if cfg.IPFilter.RulesFile != "" {
+		go ipFilter.WatchEvery(ctx, cfg.IPFilter.ReloadInterval)
+	}
+	r.Use(ipFilter.Middleware(middleware.IPScopeGlobal))
+
+	// Client certificate identity and the route groups it may reach
+	certs := middleware.NewCertAllowlist(&cfg.TLS, security)
+	if cfg.TLS.MTLS {
+		r.Use(middleware.ClientCertificate)
+		r.Use(certs.Middleware(middleware.CertGroupGlobal))
+	}
+
+	// Temporary blocks for path scans, credential stuffing and oversized
+	// uploads, fed failed sign-ins by Authenticate
+	var authSecurity middleware.SecurityRecorder = security
+	if cfg.Abuse.Enabled {
+		abuse := middleware.NewAbuseGuard(&cfg.Abuse, store, security)
+		r.Use(abuse.Middleware)
+		authSecurity = abuse
+	}
+
+	// Per-request SQL query counts (Server-Timing, N+1 warnings)
+	r.Use(middleware.QueryCount(&cfg.QueryCount))
+
+	// Principal from API tokens, the admin token or the sign-in proxy
+	r.Use(middleware.Authenticate(&cfg.Auth, &cfg.AdminConfig, tokenService, sessions, authSecurity))
+
+	// Tenant every database statement of the request is scoped to
+	r.Use(middleware.Tenant(&cfg.Tenancy, tenants, authSecurity))
+
+	// Writes held back while the database fails over
+	if db != nil && db.Failover != nil {
+		r.Use(middleware.Failover(&cfg.Failover, db.Failover))
+	}
+
+	// Directory of users tasks may be assigned to
+	r.Use(middleware.TrackUsers(users))
+
+	// Admin-only query plan logging (X-Debug-Explain)
+	r.Use(middleware.ExplainDebug(&cfg.AdminConfig))
+
+	// Who writes are attributed to in task history
+	r.Use(middleware.Actor(&cfg.AdminConfig))
+
+	// Audit log of mutations made by identified callers
+	if cfg.Audit.Enabled {
+		r.Use(middleware.Audit(auditLog))
+	}
+
+	// Admin-only per-request feature flags (X-Feature-Flags)
+	if len(cfg.FeatureToggles.Allowed) > 0 {
+		r.Use(middleware.FeatureToggles(&cfg.FeatureToggles, &cfg.AdminConfig))
+	}
+
+	// Prometheus metrics, unless scraped on their own listener
+	if cfg.Listeners.MetricsPort == "" {
+		r.Handle("/metrics", metrics.Handler())
+	} else {
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", metrics.Handler())
+		handlers.Metrics = mux
+	}
+
+	// Profiling only ever has a listener of its own
+	if cfg.Listeners.DebugPort != "" {
+		debug := chi.NewRouter()
+		debug.Mount("/debug", chimw.Profiler())
+		handlers.Debug = debug
+	}
+
+	// Health check routes
 	r.Get("/health", healthHandler.healthCheckHandler)
+	r.Get("/health/live", liveHandler)
+
+	// Canary write/read/delete probe, rate limited and admin-only
+	r.With(
+		ipFilter.Middleware(middleware.IPScopeAdmin),
+		certs.Middleware(middleware.CertGroupAdmin),
+		middleware.RateLimit(cfg.Health.DeepRateLimitConfig(), store),
+		middleware.RequireAdmin(&cfg.AdminConfig, security),
+	).Get("/health/deep", healthHandler.deepHealthCheckHandler)
+
+	// Audit log of mutations, admin-only
+	if cfg.Audit.Enabled {
+		r.With(
+			ipFilter.Middleware(middleware.IPScopeAdmin),
+			certs.Middleware(middleware.CertGroupAdmin),
+			middleware.RequireAdmin(&cfg.AdminConfig, security),
+		).Get("/audit", NewAuditHandler(auditLog).List)
+	}
+
+	// Task event stream, kept out of /tasks so open streams do not hold
+	// tenant concurrency slots
+	r.Group(func(r chi.Router) {
+		r.Use(certs.Middleware(middleware.CertGroupEvents))
+		if cfg.RateLimit.Enabled {
+			r.Use(middleware.RateLimit(&cfg.RateLimit, store))
+		}
+		r.Use(middleware.Authorize(&cfg.Auth, security))
+		r.Use(taskHandler.Scope)
+		r.Get("/events", eventsHandler.Stream)
+	})
 
 	// Task routes
 	r.Route("/tasks", func(r chi.Router) {
+		// Client certificates listed for tasks in MTLS_ALLOW
+		r.Use(certs.Middleware(middleware.CertGroupTasks))
+
+		// Load signal for autoscalers; streams and probes are left out on purpose
+		r.Use(middleware.InFlight(&cfg.Autoscaling))
+
+		// Two-tier rate limiting (soft warnings, then hard 429s), with
+		// separate buckets for search
+		if cfg.RateLimit.Enabled {
+			r.Use(middleware.RateLimitGroups(&cfg.RateLimit, store, taskRateLimitGroup))
+		}
+
+		// Token scopes, and AUTH_REQUIRED for anonymous requests
+		r.Use(middleware.AuthorizeRoutes(&cfg.Auth, security, taskScope))
+
+		// Non-admin users only reach the tasks they own
+		r.Use(taskHandler.Scope)
+
+		// Per-tenant in-flight request limits
+		if cfg.Concurrency.Enabled {
+			r.Use(middleware.TenantConcurrency(&cfg.Concurrency))
+		}
+
+		// Signed requests with replay protection (enabled via SIGNING_SECRET)
+		if cfg.SigningConfig.Enabled() {
+			r.Use(middleware.Signature(&cfg.SigningConfig, nonceStore))
+		}
+
+		// Safe retries for POST requests carrying an Idempotency-Key
+		r.Use(middleware.Idempotency(&cfg.Idempotency, store))
+
 		r.Post("/", taskHandler.Create)
 		r.Get("/", taskHandler.GetAll)
+		r.Get("/search", taskHandler.Search)
+		r.Get("/board", taskHandler.Board)
+		r.Get("/stats", statsHandler.Stats)
+		r.Get("/resolve", taskHandler.Resolve)
+		r.Post("/import", taskHandler.Import)
+		r.Get("/by-ref/{ref}", taskHandler.GetByRef)
 		r.Get("/{id}", taskHandler.GetByID)
 		r.Put("/{id}", taskHandler.Update)
+		r.Patch("/{id}", taskHandler.Patch)
 		r.Delete("/{id}", taskHandler.Delete)
+		r.Post("/{id}/restore", taskHandler.Restore)
+		r.Post("/{id}/duplicate", taskHandler.Duplicate)
+		r.Post("/{id}/archive", taskHandler.Archive)
+		r.Post("/{id}/unarchive", taskHandler.Unarchive)
+		r.Put("/{id}/assignee", taskHandler.Assign)
+		r.Delete("/{id}/assignee", taskHandler.Unassign)
+		r.With(middleware.RequireAuth(security)).Put("/{id}/team", teamHandler.Share)
+		r.With(middleware.RequireAuth(security)).Delete("/{id}/team", teamHandler.Unshare)
+		r.Patch("/{id}/move", taskHandler.Move)
+		r.Get("/{id}/history", historyHandler.List)
+		r.Post("/{id}/watch", watcherHandler.Watch)
+		r.Delete("/{id}/watch", watcherHandler.Unwatch)
+		r.Get("/{id}/watchers", watcherHandler.List)
+		r.Post("/bulk/update", taskHandler.BulkUpdate)
+		r.Post("/bulk/update/plan", taskHandler.PlanBulkUpdate)
+		r.Post("/bulk/delete", taskHandler.BulkDelete)
+		r.Post("/bulk/delete/plan", taskHandler.PlanBulkDelete)
+		r.Post("/{id}/tags", tagHandler.Attach)
+		r.Delete("/{id}/tags/{name}", tagHandler.Detach)
+		r.Post("/{id}/comments", commentHandler.Create)
+		r.Get("/{id}/comments", commentHandler.List)
+		r.Get("/{id}/checklist", checklistHandler.List)
+		r.Post("/{id}/checklist", checklistHandler.Create)
+		r.Put("/{id}/checklist/order", checklistHandler.Reorder)
+		r.Patch("/{id}/checklist/{itemID}", checklistHandler.Update)
+		r.Delete("/{id}/checklist/{itemID}", checklistHandler.Delete)
+		r.Delete("/{id}/comments/{commentID}", commentHandler.Delete)
+		if aiHandler != nil {
+			r.Post("/triage", aiHandler.Triage)
+			r.Post("/{id}/summarize", aiHandler.Summarize)
+		}
+		if similarityHandler != nil {
+			r.Get("/{id}/similar", similarityHandler.Similar)
+		}
+		if attachmentHandler != nil {
+			r.Post("/{id}/attachments", attachmentHandler.Upload)
+			r.Get("/{id}/attachments", attachmentHandler.List)
+			r.Get("/{id}/attachments/{attachmentID}", attachmentHandler.Download)
+			r.Delete("/{id}/attachments/{attachmentID}", attachmentHandler.Delete)
+		}
+	})
+
+	// Activity feed across all tasks
+	r.Group(func(r chi.Router) {
+		if cfg.RateLimit.Enabled {
+			r.Use(middleware.RateLimit(&cfg.RateLimit, store))
+		}
+		r.Use(middleware.Authorize(&cfg.Auth, security))
+		r.Use(taskHandler.Scope)
+		r.Get("/activity", historyHandler.Activity)
+	})
+
+	// Batched GET requests, each dispatched back through this router
+	r.Post("/batch", NewBatchHandler(r, &cfg.Batch, cfg.BasePath).Batch)
+
+	// Tag routes
+	r.Route("/tags", func(r chi.Router) {
+		r.Use(certs.Middleware(middleware.CertGroupTags))
+		if cfg.RateLimit.Enabled {
+			r.Use(middleware.RateLimit(&cfg.RateLimit, store))
+		}
+		if cfg.SigningConfig.Enabled() {
+			r.Use(middleware.Signature(&cfg.SigningConfig, nonceStore))
+		}
+
+		r.Use(middleware.Authorize(&cfg.Auth, security))
+
+		tagHandler.Routes(r)
+	})
+
+	// Project routes
+	r.Route("/projects", func(r chi.Router) {
+		r.Use(certs.Middleware(middleware.CertGroupProjects))
+		if cfg.RateLimit.Enabled {
+			r.Use(middleware.RateLimitGroups(&cfg.RateLimit, store, projectRateLimitGroup))
+		}
+		if cfg.SigningConfig.Enabled() {
+			r.Use(middleware.Signature(&cfg.SigningConfig, nonceStore))
+		}
+
+		r.Use(middleware.AuthorizeRoutes(&cfg.Auth, security, projectScope))
+		r.Use(taskHandler.Scope)
+
+		r.Post("/", projectHandler.Create)
+		r.Get("/", projectHandler.List)
+		r.Get("/{key}", projectHandler.Get)
+		r.Patch("/{key}", projectHandler.Update)
+		r.Delete("/{key}", projectHandler.Delete)
+		r.With(projectHandler.RequireProject).Get("/{key}/tasks", taskHandler.ListByProject)
+		r.With(projectHandler.RequireProject).Get("/{key}/stats", statsHandler.Stats)
+		r.With(projectHandler.RequireProject).Get("/{key}/board", taskHandler.Board)
+		r.Put("/{key}/tasks:sync", projectHandler.SyncTasks)
+		r.Post("/{key}/escalation-rules", escalationHandler.CreateRule)
+		r.With(projectHandler.RequireProject).Get("/{key}/escalation-rules", escalationHandler.ListRules)
+		r.Get("/{key}/escalation-rules/{id}", escalationHandler.GetRule)
+		r.Put("/{key}/escalation-rules/{id}", escalationHandler.UpdateRule)
+		r.Delete("/{key}/escalation-rules/{id}", escalationHandler.DeleteRule)
+		r.With(projectHandler.RequireProject).Get("/{key}/escalations", escalationHandler.ListEscalations)
+		r.Post("/{key}/automation-rules", automationHandler.CreateRule)
+		r.With(projectHandler.RequireProject).Get("/{key}/automation-rules", automationHandler.ListRules)
+		r.Get("/{key}/automation-rules/{id}", automationHandler.GetRule)
+		r.Put("/{key}/automation-rules/{id}", automationHandler.UpdateRule)
+		r.Delete("/{key}/automation-rules/{id}", automationHandler.DeleteRule)
+		r.With(projectHandler.RequireProject).Get("/{key}/automation-runs", automationHandler.ListRuns)
+		if snapshotService != nil {
+			r.Post("/{key}/snapshots", projectHandler.CreateSnapshot)
+			r.Get("/{key}/snapshots", projectHandler.ListSnapshots)
+			r.Get("/{key}/snapshots/{id}", projectHandler.GetSnapshot)
+			r.Post("/{key}/snapshots/{id}/restore", projectHandler.RestoreSnapshot)
+		}
+	})
+
+	// Teams sharing tasks between their members
+	r.Route("/teams", func(r chi.Router) {
+		r.Use(certs.Middleware(middleware.CertGroupTeams))
+		if cfg.RateLimit.Enabled {
+			r.Use(middleware.RateLimit(&cfg.RateLimit, store))
+		}
+		if cfg.SigningConfig.Enabled() {
+			r.Use(middleware.Signature(&cfg.SigningConfig, nonceStore))
+		}
+		r.Use(middleware.RequireAuth(security))
+		r.Use(middleware.Authorize(&cfg.Auth, security))
+
+		r.Post("/", teamHandler.Create)
+		r.Get("/", teamHandler.List)
+		r.Get("/{id}", teamHandler.Get)
+		r.Delete("/{id}", teamHandler.Delete)
+		r.Put("/{id}/members/{user}", teamHandler.AddMember)
+		r.Delete("/{id}/members/{user}", teamHandler.RemoveMember)
+	})
+
+	// Password registration and sign-in, and sign-in through an OIDC
+	// provider issuing the same sessions
+	if authService != nil {
+		authHandler := NewAuthHandler(authService, authSecurity, access)
+		var oidcHandler *OIDCHandler
+		if cfg.OIDC.Enabled() {
+			provider := oidc.NewProvider(&cfg.OIDC, nil)
+			oidcService := service.NewOIDCService(provider, userRepo, authService, &cfg.OIDC, []byte(cfg.JWT.Secret))
+			oidcHandler = NewOIDCHandler(oidcService, authSecurity, &cfg.OIDC, cfg.BasePath)
+		}
+
+		r.Route("/auth", func(r chi.Router) {
+			r.Use(certs.Middleware(middleware.CertGroupAuth))
+			if cfg.RateLimit.Enabled {
+				r.Use(middleware.RateLimit(&cfg.RateLimit, store))
+			}
+			r.Use(middleware.Client)
+			r.Post("/register", authHandler.Register)
+			r.Post("/login", authHandler.Login)
+			r.Post("/refresh", authHandler.Refresh)
+			r.Post("/logout", authHandler.Logout)
+			if oidcHandler != nil {
+				r.Get("/oidc/login", oidcHandler.Login)
+				r.Get("/oidc/callback", oidcHandler.Callback)
+			}
+
+			// The caller's sign-ins, to review and sign out
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.RequireAuth(security))
+				r.Get("/sessions", authHandler.Sessions)
+				r.Delete("/sessions", authHandler.RevokeSessions)
+				r.Delete("/sessions/{id}", authHandler.RevokeSession)
+			})
+		})
+	} else if cfg.OIDC.Enabled() {
+		log.Warn().Msg("OIDC sign-in needs JWT_SECRET to issue sessions, OIDC is disabled")
+	}
+
+	// The caller's own API tokens and notifications
+	r.Route("/me", func(r chi.Router) {
+		r.Use(certs.Middleware(middleware.CertGroupMe))
+		if cfg.RateLimit.Enabled {
+			r.Use(middleware.RateLimit(&cfg.RateLimit, store))
+		}
+		r.Use(middleware.RequireAuth(security))
+
+		r.Post("/tokens", tokenHandler.Create)
+		r.Get("/tokens", tokenHandler.List)
+		r.Get("/tokens/{id}", tokenHandler.Get)
+		r.Delete("/tokens/{id}", tokenHandler.Delete)
+		r.Get("/notifications", watcherHandler.Notifications)
+		r.Post("/notifications/read", watcherHandler.MarkRead)
 	})
 
-	return r
+	// Admin routes, on the API listener or a listener of their own with
+	// just enough middleware to log and measure them
+	if cfg.AdminConfig.Enabled {
+		adminHandler := NewAdminHandler(root, degradation, indexer, exporter, requests)
+		securityHandler := NewSecurityHandler(security)
+		admin := r
+		if cfg.Listeners.AdminPort != "" {
+			admin = chi.NewRouter()
+			admin.Use(chimw.RequestID)
+			admin.Use(middleware.Peer)
+			admin.Use(middleware.RealIP(proxies))
+			admin.Use(chimw.Recoverer)
+			admin.Use(tracing.Middleware)
+			admin.Use(middleware.RequestLogger(log))
+			admin.Use(middleware.Metrics)
+			if cfg.TLS.MTLS {
+				admin.Use(middleware.ClientCertificate)
+				admin.Use(certs.Middleware(middleware.CertGroupGlobal))
+			}
+			// Admin-scoped API tokens and sessions are let in as on the API listener
+			admin.Use(middleware.Authenticate(&cfg.Auth, &cfg.AdminConfig, tokenService, sessions, authSecurity))
+			admin.Use(middleware.Actor(&cfg.AdminConfig))
+			if cfg.Audit.Enabled {
+				admin.Use(middleware.Audit(auditLog))
+			}
+			handlers.Admin = admin
+		}
+		admin.Route("/admin", func(r chi.Router) {
+			r.Use(ipFilter.Middleware(middleware.IPScopeAdmin))
+			r.Use(certs.Middleware(middleware.CertGroupAdmin))
+			// Without ADMIN_TOKEN only admin-scoped API tokens get in, like /audit
+			r.Use(middleware.RequireAdmin(&cfg.AdminConfig, security))
+
+			r.Get("/routes", adminHandler.Routes)
+			r.Get("/requests", adminHandler.Requests)
+			r.Get("/degradation", adminHandler.Degradation)
+			r.Put("/degradation", adminHandler.UpdateDegradation)
+
+			var exportLimit chi.Middlewares
+			if cfg.RateLimit.Enabled {
+				exportLimit = append(exportLimit, middleware.RateLimitGroups(&cfg.RateLimit, store, middleware.RouteGroup(config.RateLimitGroupExport)))
+			}
+
+			tenantHandler := NewTenantHandler(tenants)
+			r.Get("/tenants/{tenant}/members", tenantHandler.Members)
+			r.Put("/tenants/{tenant}/members/{user}", tenantHandler.AddMember)
+			r.Delete("/tenants/{tenant}/members/{user}", tenantHandler.RemoveMember)
+
+			r.Get("/security-events", securityHandler.List)
+			r.With(exportLimit...).Get("/security-events/export", securityHandler.Export)
+
+			if indexer != nil {
+				r.Get("/search", adminHandler.SearchStatus)
+				r.Post("/search/reindex", adminHandler.Reindex)
+				r.Get("/search/consistency", adminHandler.SearchConsistency)
+			}
+
+			if exporter != nil {
+				r.With(exportLimit...).Post("/analytics/export", adminHandler.ExportAnalytics)
+			}
+		})
+	}
+
+	if root != r {
+		root.Mount(cfg.BasePath, r)
+	}
+
+	// Probes and scrapes skip the middleware chain unless disabled
+	handlers.API = root
+	if cfg.Health.FastPath {
+		probes := map[string]http.Handler{
+			cfg.BasePath + "/health":      http.HandlerFunc(healthHandler.healthCheckHandler),
+			cfg.BasePath + "/health/live": http.HandlerFunc(liveHandler),
+		}
+		if handlers.Metrics == nil {
+			probes[cfg.BasePath+"/metrics"] = metrics.Handler()
+		}
+		handlers.API = &probeMux{probes: probes, next: root}
+	}
+	return handlers
+}
+
+// taskRateLimitGroup sorts /tasks requests into rate limit groups. It runs
+// as /tasks middleware, where the route path is relative to /tasks.
+func taskRateLimitGroup(r *http.Request) string {
+	path := chi.RouteContext(r.Context()).RoutePath
+	if strings.HasSuffix(path, "/summarize") || strings.HasSuffix(path, "/similar") {
+		return config.RateLimitGroupAI
+	}
+	switch path {
+	case "/search":
+		return config.RateLimitGroupSearch
+	case "/stats":
+		return config.RateLimitGroupStats
+	case "/import":
+		return config.RateLimitGroupImport
+	case "/triage":
+		return config.RateLimitGroupAI
+	case "/":
+		if r.Method == http.MethodGet && r.URL.Query().Get("q") != "" {
+			return config.RateLimitGroupSearch
+		}
+	}
+	return ""
+}
+
+// taskScope is the token scope a /tasks request needs: stats:read for
+// the stats, so dashboards can be given tokens that read nothing else
+func taskScope(r *http.Request) auth.Scope {
+	path := chi.RouteContext(r.Context()).RoutePath
+	switch {
+	case r.Method == http.MethodGet && path == "/stats":
+		return auth.ScopeReadStats
+	case r.Method == http.MethodPost && (path == "/triage" || strings.HasSuffix(path, "/summarize")):
+		// Summaries and triage read tasks without changing them
+		return auth.ScopeReadTasks
+	}
+	return middleware.TaskScope(r)
+}
+
+// projectScope is the token scope a /projects request needs, as for
+// taskScope
+func projectScope(r *http.Request) auth.Scope {
+	if r.Method == http.MethodGet && strings.HasSuffix(chi.RouteContext(r.Context()).RoutePath, "/stats") {
+		return auth.ScopeReadStats
+	}
+	return middleware.TaskScope(r)
+}
+
+// projectRateLimitGroup sorts /projects requests into the groups of their
+// /tasks counterparts. Syncs and snapshot writes count as imports since
+// they read or write a whole project at once.
+func projectRateLimitGroup(r *http.Request) string {
+	path := chi.RouteContext(r.Context()).RoutePath
+	if r.Method != http.MethodGet {
+		if strings.HasSuffix(path, ":sync") || strings.Contains(path, "/snapshots") {
+			return config.RateLimitGroupImport
+		}
+		return ""
+	}
+	switch {
+	case strings.HasSuffix(path, "/stats"):
+		return config.RateLimitGroupStats
+	case strings.HasSuffix(path, "/tasks") && r.URL.Query().Get("q") != "":
+		return config.RateLimitGroupSearch
+	}
+	return ""
 }
 
 func (h *HealthHandler) healthCheckHandler(w http.ResponseWriter, r *http.Request) {
@@ -98,6 +965,13 @@ func (h *HealthHandler) healthCheckHandler(w http.ResponseWriter, r *http.Reques
 }
 
 func (h *HealthHandler) checkDatabase(ctx context.Context) ServiceInfo {
+	if h.db == nil {
+		return ServiceInfo{
+			Status:  "healthy",
+			Message: "Running without a database (demo mode)",
+		}
+	}
+
 	if err := h.db.PingContext(ctx); err != nil {
 		return ServiceInfo{
 			Status:  "unhealthy",
@@ -120,6 +994,77 @@ func (h *HealthHandler) checkDatabase(ctx context.Context) ServiceInfo {
 			"max_open":         stats.MaxOpenConnections,
 			"wait_count":       stats.WaitCount,
 			"wait_duration":    stats.WaitDuration.String(),
+			"failover":         h.db.Failover.Active(),
 		},
 	}
 }
+
+// deepHealthCheckHandler verifies the full write path by writing, reading
+// back and deleting a row in the health_probes table
+func (h *HealthHandler) deepHealthCheckHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	healthResp := HealthResponse{
+		Status:   "healthy",
+		Services: make(map[string]ServiceInfo),
+	}
+
+	dbStatus := h.checkDatabase(ctx)
+	healthResp.Services["database"] = dbStatus
+
+	if h.probes != nil && dbStatus.Status == "healthy" {
+		healthResp.Services["write_path"] = h.checkWritePath(ctx)
+	}
+
+	for _, service := range healthResp.Services {
+		if service.Status == "unhealthy" {
+			healthResp.Status = "unhealthy"
+			pkg.ServiceUnavailable(w, healthResp)
+			return
+		}
+	}
+
+	pkg.JSONSuccess(w, healthResp)
+}
+
+func (h *HealthHandler) checkWritePath(ctx context.Context) ServiceInfo {
+	id := uuid.NewString()
+	payload := "probe-" + id
+	details := map[string]any{}
+
+	unhealthy := func(message string, err error) ServiceInfo {
+		details["error"] = err.Error()
+		return ServiceInfo{Status: "unhealthy", Message: message, Details: details}
+	}
+
+	start := time.Now()
+	if err := h.probes.Write(ctx, id, payload); err != nil {
+		return unhealthy("Failed to write probe", err)
+	}
+	details["write"] = time.Since(start).String()
+
+	start = time.Now()
+	got, err := h.probes.Read(ctx, id)
+	if err == nil && got != payload {
+		err = fmt.Errorf("read back %q, expected %q", got, payload)
+	}
+	if err != nil {
+		// Best effort cleanup so failed probes do not accumulate
+		_ = h.probes.Delete(context.WithoutCancel(ctx), id)
+		return unhealthy("Failed to read probe", err)
+	}
+	details["read"] = time.Since(start).String()
+
+	start = time.Now()
+	if err := h.probes.Delete(ctx, id); err != nil {
+		return unhealthy("Failed to delete probe", err)
+	}
+	details["delete"] = time.Since(start).String()
+
+	return ServiceInfo{
+		Status:  "healthy",
+		Message: "Write, read and delete succeeded",
+		Details: details,
+	}
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+	"github.com/moabdelazem/mutlitier_app/pkg/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRouter sets up the demo router with the admin routes, adjusting
+// its configuration with configure, and stops its background work when
+// the test ends
+func newTestRouter(t *testing.T, configure func(cfg *config.Config)) *Handlers {
+	t.Setenv("ENVIRONMENT", "test")
+	t.Setenv("DEMO_MODE", "true")
+	t.Setenv("ADMIN_ENABLED", "true")
+	cfg := config.NewConfig()
+	configure(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	workers := worker.NewGroup(ctx)
+	handlers := SetupRouter(ctx, workers, nil, nil, kvstore.NewMemory(), cfg, logger.Get())
+	t.Cleanup(func() {
+		cancel()
+		shutdown, done := context.WithTimeout(context.Background(), 5*time.Second)
+		defer done()
+		require.NoError(t, workers.Shutdown(shutdown))
+	})
+	return handlers
+}
+
+func TestRouter_AdminRequiresCredential(t *testing.T) {
+	for name, token := range map[string]string{"token set": "secret", "token unset": ""} {
+		t.Run(name, func(t *testing.T) {
+			handlers := newTestRouter(t, func(cfg *config.Config) { cfg.AdminConfig.Token = token })
+
+			rec := httptest.NewRecorder()
+			handlers.API.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/routes", nil))
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+			if token != "" {
+				req := httptest.NewRequest(http.MethodGet, "/admin/routes", nil)
+				req.Header.Set("X-Admin-Token", token)
+				rec = httptest.NewRecorder()
+				handlers.API.ServeHTTP(rec, req)
+				assert.Equal(t, http.StatusOK, rec.Code)
+			}
+		})
+	}
+}
+
+func TestRouter_DegradationRequiresAdmin(t *testing.T) {
+	for name, port := range map[string]string{"api listener": "", "admin listener": ":0"} {
+		t.Run(name, func(t *testing.T) {
+			handlers := newTestRouter(t, func(cfg *config.Config) {
+				cfg.AdminConfig.Token = "secret"
+				cfg.Listeners.AdminPort = port
+			})
+			admin := handlers.API
+			if handlers.Admin != nil {
+				admin = handlers.Admin
+			}
+			update := func(token string) int {
+				req := httptest.NewRequest(http.MethodPut, "/admin/degradation", strings.NewReader(`{"disable_search": true}`))
+				if token != "" {
+					req.Header.Set("X-Admin-Token", token)
+				}
+				rec := httptest.NewRecorder()
+				admin.ServeHTTP(rec, req)
+				return rec.Code
+			}
+
+			assert.Equal(t, http.StatusUnauthorized, update(""))
+			assert.Equal(t, http.StatusUnauthorized, update("wrong"))
+
+			// The refused updates changed nothing
+			req := httptest.NewRequest(http.MethodGet, "/admin/degradation", nil)
+			req.Header.Set("X-Admin-Token", "secret")
+			rec := httptest.NewRecorder()
+			admin.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"disable_search":false`)
+
+			assert.Equal(t, http.StatusOK, update("secret"))
+		})
+	}
+}
+
+func TestRouter_AdminListenerAcceptsAdminToken(t *testing.T) {
+	handlers := newTestRouter(t, func(cfg *config.Config) {
+		cfg.AdminConfig.Token = "secret"
+		cfg.Listeners.AdminPort = ":0"
+	})
+	require.NotNil(t, handlers.Admin)
+
+	issue := func(scopes string) string {
+		req := httptest.NewRequest(http.MethodPost, "/me/tokens", strings.NewReader(`{"name":"ops","scopes":[`+scopes+`]}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Admin-Token", "secret")
+		rec := httptest.NewRecorder()
+		handlers.API.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		var created model.CreatedTokenResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+		return created.Token
+	}
+	routes := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/admin/routes", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handlers.Admin.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, routes(issue(`"admin"`)))
+	assert.Equal(t, http.StatusUnauthorized, routes(issue(`"tasks:read"`)))
+	assert.Equal(t, http.StatusUnauthorized, routes("not-a-token"))
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+)
+
+// SimilarityHandler handles HTTP requests for tasks similar to a task
+type SimilarityHandler struct {
+	service *service.SimilarityService
+}
+
+// NewSimilarityHandler creates a new SimilarityHandler
+func NewSimilarityHandler(service *service.SimilarityService) *SimilarityHandler {
+	return &SimilarityHandler{service: service}
+}
+
+// Similar handles GET /tasks/{id}/similar
+func (h *SimilarityHandler) Similar(w http.ResponseWriter, r *http.Request) {
+	similar, err := h.service.Similar(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrTaskNotFound):
+			pkg.NotFound(w, "Task not found")
+		case errors.Is(err, service.ErrEmbeddingUnavailable):
+			pkg.ServiceUnavailable(w, pkg.ErrorResponse{Error: err.Error()})
+		default:
+			pkg.InternalError(w, "Failed to find similar tasks")
+		}
+		return
+	}
+
+	pkg.JSONSuccess(w, similar)
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+)
+
+// StatsHandler serves task statistics
+type StatsHandler struct {
+	service *service.StatsService
+}
+
+// NewStatsHandler creates a new StatsHandler
+func NewStatsHandler(service *service.StatsService) *StatsHandler {
+	return &StatsHandler{service: service}
+}
+
+// Stats handles GET /tasks/stats and GET /projects/{key}/stats
+func (h *StatsHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := model.TaskStatsFilter{Days: service.DefaultStatsDays, Project: chi.URLParam(r, "key")}
+	if filter.Project == "" {
+		if filter.Project = query.Get("project"); filter.Project != "" && !model.ValidProjectKey(filter.Project) {
+			pkg.BadRequest(w, "project must be a project key such as PROJ")
+			return
+		}
+	}
+	var err error
+	if query.Get("days") != "" {
+		if filter.Days, err = listing.Int(query, "days"); err != nil {
+			pkg.BadRequest(w, err.Error())
+			return
+		}
+	}
+	if filter.IncludeArchived, err = listing.Bool(query, "include_archived"); err != nil {
+		pkg.BadRequest(w, err.Error())
+		return
+	}
+
+	stats, err := h.service.Stats(r.Context(), &filter)
+	if err != nil {
+		if errors.Is(err, service.ErrValidation) {
+			pkg.BadRequest(w, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrDegraded) {
+			pkg.ServiceUnavailable(w, pkg.ErrorResponse{Error: err.Error()})
+			return
+		}
+		pkg.InternalError(w, "Failed to compute task stats")
+		return
+	}
+
+	pkg.JSONSuccess(w, stats)
+}
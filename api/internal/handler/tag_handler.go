@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/codec"
+)
+
+// TagHandler handles HTTP requests for tags and tagging tasks. The /tags
+// routes are CRUDHandler's.
+type TagHandler struct {
+	*CRUDHandler[model.CreateTagRequest, model.UpdateTagRequest, model.TagResponse]
+	service *service.TagService
+}
+
+// NewTagHandler creates a new TagHandler
+func NewTagHandler(tags *service.TagService) *TagHandler {
+	return &TagHandler{
+		CRUDHandler: NewCRUDHandler(tags, "tag", "tags", service.ErrTagNotFound, service.ErrTagExists,
+			"A tag with this name already exists"),
+		service: tags,
+	}
+}
+
+// Attach handles POST /tasks/{id}/tags
+func (h *TagHandler) Attach(w http.ResponseWriter, r *http.Request) {
+	var req model.AttachTagsRequest
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+
+	task, err := h.service.AttachTags(r.Context(), chi.URLParam(r, "id"), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrValidation) {
+			pkg.BadRequest(w, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrTaskNotFound) {
+			pkg.NotFound(w, "Task not found")
+			return
+		}
+		if errors.Is(err, service.ErrArchived) {
+			pkg.Conflict(w, "Task is archived, unarchive it first")
+			return
+		}
+		pkg.InternalError(w, "Failed to tag task")
+		return
+	}
+
+	setTaskETag(w, task)
+	pkg.JSONSuccess(w, task)
+}
+
+// Detach handles DELETE /tasks/{id}/tags/{name}
+func (h *TagHandler) Detach(w http.ResponseWriter, r *http.Request) {
+	task, err := h.service.DetachTag(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "name"))
+	if err != nil {
+		if errors.Is(err, service.ErrTaskNotFound) {
+			pkg.NotFound(w, "Task not found")
+			return
+		}
+		if errors.Is(err, service.ErrArchived) {
+			pkg.Conflict(w, "Task is archived, unarchive it first")
+			return
+		}
+		pkg.InternalError(w, "Failed to untag task")
+		return
+	}
+
+	setTaskETag(w, task)
+	pkg.JSONSuccess(w, task)
+}
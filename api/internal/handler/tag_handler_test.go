@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagHandler_AttachDetach(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryTaskRepository(0)
+	events := service.NewEventService(repository.NewMemoryEventRepository(), kvstore.NewMemory(), &config.EventsConfig{})
+	tasks := service.NewTaskService(repo, nil, nil, events, nil, nil, nil, &config.TaskConfig{DefaultProject: "TASK"})
+	tags := service.NewTagService(repo, events)
+
+	task, err := tasks.Create(ctx, &model.CreateTaskRequest{Title: "Release"})
+	require.NoError(t, err)
+	archived, err := tasks.Create(ctx, &model.CreateTaskRequest{Title: "Old"})
+	require.NoError(t, err)
+	_, err = tasks.Archive(ctx, archived.ID)
+	require.NoError(t, err)
+	_, err = tags.Create(ctx, &model.CreateTagRequest{Name: "ops"})
+	require.NoError(t, err)
+
+	h := NewTagHandler(tags)
+	r := chi.NewRouter()
+	r.Post("/tasks/{id}/tags", h.Attach)
+	r.Delete("/tasks/{id}/tags/{name}", h.Detach)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	tagsOf := func(w *httptest.ResponseRecorder) []string {
+		var body model.TaskResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body.Tags
+	}
+
+	w := do(http.MethodPost, "/tasks/"+task.ID+"/tags", `{"tags":["ops"]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, []string{"ops"}, tagsOf(w))
+	assert.NotEmpty(t, w.Header().Get("ETag"))
+
+	w = do(http.MethodPost, "/tasks/"+task.ID+"/tags", `{"tags":["ops","nope"]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "nope")
+
+	w = do(http.MethodPost, "/tasks/"+task.ID+"/tags", `{"tags":`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do(http.MethodPost, "/tasks/00000000-0000-0000-0000-000000000000/tags", `{"tags":["ops"]}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = do(http.MethodPost, "/tasks/"+archived.ID+"/tags", `{"tags":["ops"]}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = do(http.MethodDelete, "/tasks/"+task.ID+"/tags/ops", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, tagsOf(w))
+
+	w = do(http.MethodDelete, "/tasks/"+archived.ID+"/tags/ops", "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
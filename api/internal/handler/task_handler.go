@@ -1,31 +1,51 @@
 package handler
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/moabdelazem/mutlitier_app/internal/model"
 	"github.com/moabdelazem/mutlitier_app/internal/service"
 	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/codec"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
 )
 
 // TaskHandler handles HTTP requests for tasks
 type TaskHandler struct {
-	service *service.TaskService
+	service    *service.TaskService
+	planner    *service.BulkPlanner
+	expansions *service.ExpansionService
+	access     *AccessPolicy
+	duplicates *service.SimilarityService // nil unless duplicates are looked for on create
 }
 
-// NewTaskHandler creates a new TaskHandler
-func NewTaskHandler(service *service.TaskService) *TaskHandler {
-	return &TaskHandler{service: service}
+// NewTaskHandler creates a new TaskHandler. duplicates may be nil.
+func NewTaskHandler(service *service.TaskService, planner *service.BulkPlanner, expansions *service.ExpansionService, access *AccessPolicy, duplicates *service.SimilarityService) *TaskHandler {
+	return &TaskHandler{service: service, planner: planner, expansions: expansions, access: access, duplicates: duplicates}
+}
+
+// Scope is a middleware limiting the task queries of a request to the
+// caller's own tasks, unless the caller is an admin or anonymous
+func (h *TaskHandler) Scope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(h.service.Scope(r.Context())))
+	})
 }
 
 // Create handles POST /tasks
 func (h *TaskHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var req model.CreateTaskRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		pkg.BadRequest(w, "Invalid JSON payload")
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, decodeErrorMessage(err))
 		return
 	}
 
@@ -35,22 +55,133 @@ func (h *TaskHandler) Create(w http.ResponseWriter, r *http.Request) {
 			pkg.BadRequest(w, err.Error())
 			return
 		}
+		if errors.Is(err, service.ErrLimitReached) {
+			pkg.Forbidden(w, "Task limit reached, try again after the next reset")
+			return
+		}
 		pkg.InternalError(w, "Failed to create task")
 		return
 	}
 
+	if h.duplicates != nil {
+		task.Duplicates = h.duplicates.Duplicates(r.Context(), task)
+	}
+
+	setTaskETag(w, task)
 	pkg.Created(w, task)
 }
 
 // GetAll handles GET /tasks
 func (h *TaskHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	tasks, err := h.service.GetAll(r.Context())
+	query := r.URL.Query()
+
+	opts := model.ListOptions{
+		Search: query.Get("q"),
+		Cursor: query.Get("cursor"),
+	}
+
+	var err error
+	if opts.Params, err = listing.Parse(query); err != nil {
+		pkg.BadRequest(w, err.Error())
+		return
+	}
+	if opts.Priorities, err = model.ParsePriorities(query.Get("priority")); err != nil {
+		pkg.BadRequest(w, err.Error())
+		return
+	}
+	if opts.Overdue, err = listing.Bool(query, "overdue"); err != nil {
+		pkg.BadRequest(w, err.Error())
+		return
+	}
+	if opts.Tags, err = model.ParseTagNames(query.Get("tag")); err != nil {
+		pkg.BadRequest(w, err.Error())
+		return
+	}
+	if opts.IncludeArchived, err = listing.Bool(query, "include_archived"); err != nil {
+		pkg.BadRequest(w, err.Error())
+		return
+	}
+	opts.Assignee = strings.TrimSpace(query.Get("assignee"))
+	if opts.Project = query.Get("project"); opts.Project != "" && !model.ValidProjectKey(opts.Project) {
+		pkg.BadRequest(w, "project must be a project key such as PROJ")
+		return
+	}
+	expansions, err := service.ParseExpansions(query.Get("expand"))
+	if err != nil {
+		pkg.BadRequest(w, err.Error())
+		return
+	}
+	if opts.Fields, err = model.ParseTaskFields(query.Get("fields")); err != nil {
+		pkg.BadRequest(w, err.Error())
+		return
+	}
+
+	tasks, err := h.service.GetAll(r.Context(), &opts)
 	if err != nil {
+		if errors.Is(err, service.ErrValidation) || errors.Is(err, service.ErrQueryTooExpensive) {
+			pkg.BadRequest(w, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrAnonymous) {
+			pkg.Unauthorized(w, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrDegraded) {
+			pkg.ServiceUnavailable(w, pkg.ErrorResponse{Error: err.Error()})
+			return
+		}
 		pkg.InternalError(w, "Failed to retrieve tasks")
 		return
 	}
 
-	pkg.JSONSuccess(w, tasks)
+	if !h.expand(w, r, expansions, tasks.Data...) {
+		return
+	}
+
+	pkg.JSONListFields(w, tasks, responseFields(opts.Fields, expansions))
+}
+
+// ListByProject handles GET /projects/{key}/tasks, which is GET /tasks
+// limited to one project
+func (h *TaskHandler) ListByProject(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	query.Set("project", chi.URLParam(r, "key"))
+	r.URL.RawQuery = query.Encode()
+
+	h.GetAll(w, r)
+}
+
+// Search handles GET /tasks/search?q=
+func (h *TaskHandler) Search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	opts := model.ListOptions{Search: query.Get("q")}
+
+	var err error
+	if opts.Params, err = listing.Parse(query); err != nil {
+		pkg.BadRequest(w, err.Error())
+		return
+	}
+	if opts.Fields, err = model.ParseTaskFields(query.Get("fields")); err != nil {
+		pkg.BadRequest(w, err.Error())
+		return
+	}
+
+	tasks, err := h.service.Search(r.Context(), &opts)
+	if err != nil {
+		if errors.Is(err, service.ErrValidation) || errors.Is(err, service.ErrQueryTooExpensive) {
+			pkg.BadRequest(w, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrDegraded) {
+			pkg.ServiceUnavailable(w, pkg.ErrorResponse{Error: err.Error()})
+			return
+		}
+		pkg.InternalError(w, "Failed to search tasks")
+		return
+	}
+
+	pkg.JSONListFields(w, tasks, opts.Fields)
 }
 
 // GetByID handles GET /tasks/{id}
@@ -61,8 +192,62 @@ func (h *TaskHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	expansions, err := service.ParseExpansions(r.URL.Query().Get("expand"))
+	if err != nil {
+		pkg.BadRequest(w, err.Error())
+		return
+	}
+	fields, err := model.ParseTaskFields(r.URL.Query().Get("fields"))
+	if err != nil {
+		pkg.BadRequest(w, err.Error())
+		return
+	}
+
 	task, err := h.service.GetByID(r.Context(), id)
 	if err != nil {
+		if h.access.Deny(w, r, err, "Task not found") {
+			return
+		}
+		if errors.Is(err, service.ErrTaskNotFound) {
+			pkg.NotFound(w, "Task not found")
+			return
+		}
+		pkg.InternalError(w, "Failed to retrieve task")
+		return
+	}
+
+	setTaskETag(w, task)
+	if len(expansions) > 0 {
+		// Changes to related resources leave the task version alone, so
+		// the ETag cannot vouch for them
+		if !h.expand(w, r, expansions, task) {
+			return
+		}
+	} else if notModified(r, taskETag(task.Version)) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	pkg.JSONFields(w, task, responseFields(fields, expansions))
+}
+
+// GetByRef handles GET /tasks/by-ref/{ref}
+func (h *TaskHandler) GetByRef(w http.ResponseWriter, r *http.Request) {
+	ref := chi.URLParam(r, "ref")
+	if ref == "" {
+		pkg.BadRequest(w, "Task reference is required")
+		return
+	}
+
+	task, err := h.service.GetByRef(r.Context(), ref)
+	if err != nil {
+		if errors.Is(err, service.ErrValidation) {
+			pkg.BadRequest(w, err.Error())
+			return
+		}
+		if h.access.Deny(w, r, err, "Task not found") {
+			return
+		}
 		if errors.Is(err, service.ErrTaskNotFound) {
 			pkg.NotFound(w, "Task not found")
 			return
@@ -74,6 +259,81 @@ func (h *TaskHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	pkg.JSONSuccess(w, task)
 }
 
+// Board handles GET /tasks/board and GET /projects/{key}/board. The token
+// of an earlier board, sent as ?since= or as the ETag in If-None-Match,
+// returns only changed columns and tasks.
+func (h *TaskHandler) Board(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	opts := model.BoardOptions{
+		Project: chi.URLParam(r, "key"),
+		Since:   query.Get("since"),
+		Sort:    query.Get("sort"),
+		Order:   query.Get("order"),
+	}
+	if opts.Project == "" {
+		opts.Project = query.Get("project")
+	}
+	if opts.Since == "" {
+		opts.Since = ifNoneMatchToken(r)
+	}
+
+	var err error
+	if opts.Limit, err = listing.Int(query, "limit"); err != nil {
+		pkg.BadRequest(w, err.Error())
+		return
+	}
+	for _, status := range model.Statuses() {
+		name := "limit_" + string(status)
+		if !query.Has(name) {
+			continue
+		}
+		limit, err := listing.Int(query, name)
+		if err != nil {
+			pkg.BadRequest(w, err.Error())
+			return
+		}
+		if opts.Limits == nil {
+			opts.Limits = make(map[model.Status]int)
+		}
+		opts.Limits[status] = limit
+	}
+
+	board, err := h.service.Board(r.Context(), &opts)
+	if err != nil {
+		if errors.Is(err, service.ErrValidation) {
+			pkg.BadRequest(w, err.Error())
+			return
+		}
+		pkg.InternalError(w, "Failed to retrieve board")
+		return
+	}
+
+	w.Header().Set("ETag", `"`+board.Token+`"`)
+	if board.Token == opts.Since {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	pkg.JSONSuccess(w, board)
+}
+
+// Resolve handles GET /tasks/resolve?text=
+func (h *TaskHandler) Resolve(w http.ResponseWriter, r *http.Request) {
+	text := r.URL.Query().Get("text")
+	if text == "" {
+		pkg.BadRequest(w, "text is required")
+		return
+	}
+
+	tasks, err := h.service.Resolve(r.Context(), text)
+	if err != nil {
+		pkg.InternalError(w, "Failed to resolve tasks")
+		return
+	}
+
+	pkg.JSONList(w, tasks)
+}
+
 // Update handles PUT /tasks/{id}
 func (h *TaskHandler) Update(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
@@ -82,30 +342,112 @@ func (h *TaskHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	expectedVersion, err := ifMatchVersion(r)
+	if err != nil {
+		writePreconditionError(w, err)
+		return
+	}
+
 	var req model.UpdateTaskRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		pkg.BadRequest(w, "Invalid JSON payload")
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, decodeErrorMessage(err))
 		return
 	}
 
-	task, err := h.service.Update(r.Context(), id, &req)
+	task, err := h.service.Update(r.Context(), id, &req, expectedVersion)
 	if err != nil {
 		if errors.Is(err, service.ErrValidation) {
 			pkg.BadRequest(w, err.Error())
 			return
 		}
+		if h.access.Deny(w, r, err, "Task not found") {
+			return
+		}
 		if errors.Is(err, service.ErrTaskNotFound) {
 			pkg.NotFound(w, "Task not found")
 			return
 		}
+		if errors.Is(err, service.ErrConflict) {
+			pkg.PreconditionFailed(w, "Task was modified, fetch it again and retry with the new ETag")
+			return
+		}
+		if errors.Is(err, service.ErrInvalidTransition) {
+			pkg.UnprocessableEntity(w, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrArchived) {
+			pkg.Conflict(w, "Task is archived, unarchive it first")
+			return
+		}
 		pkg.InternalError(w, "Failed to update task")
 		return
 	}
 
+	setTaskETag(w, task)
 	pkg.JSONSuccess(w, task)
 }
 
-// Delete handles DELETE /tasks/{id}
+// Patch handles PATCH /tasks/{id} with an RFC 7386 merge patch
+func (h *TaskHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		pkg.BadRequest(w, "Task ID is required")
+		return
+	}
+
+	// application/json is accepted too since a merge patch is plain JSON
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != model.MergePatchContentType && mediaType != "application/json" {
+		pkg.UnsupportedMediaType(w, "Content-Type must be "+model.MergePatchContentType)
+		return
+	}
+
+	expectedVersion, err := ifMatchVersion(r)
+	if err != nil {
+		writePreconditionError(w, err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		pkg.BadRequest(w, "Invalid request body")
+		return
+	}
+
+	task, err := h.service.Patch(r.Context(), id, body, expectedVersion)
+	if err != nil {
+		if errors.Is(err, service.ErrValidation) {
+			pkg.BadRequest(w, err.Error())
+			return
+		}
+		if h.access.Deny(w, r, err, "Task not found") {
+			return
+		}
+		if errors.Is(err, service.ErrTaskNotFound) {
+			pkg.NotFound(w, "Task not found")
+			return
+		}
+		if errors.Is(err, service.ErrConflict) {
+			pkg.PreconditionFailed(w, "Task was modified, fetch it again and retry with the new ETag")
+			return
+		}
+		if errors.Is(err, service.ErrInvalidTransition) {
+			pkg.UnprocessableEntity(w, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrArchived) {
+			pkg.Conflict(w, "Task is archived, unarchive it first")
+			return
+		}
+		pkg.InternalError(w, "Failed to update task")
+		return
+	}
+
+	setTaskETag(w, task)
+	pkg.JSONSuccess(w, task)
+}
+
+// Delete handles DELETE /tasks/{id}, a soft delete unless ?hard=true
 func (h *TaskHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	if id == "" {
@@ -113,15 +455,395 @@ func (h *TaskHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.service.Delete(r.Context(), id)
+	hard := false
+	if value := r.URL.Query().Get("hard"); value != "" {
+		var err error
+		if hard, err = strconv.ParseBool(value); err != nil {
+			pkg.BadRequest(w, "hard must be true or false")
+			return
+		}
+	}
+
+	expectedVersion, err := ifMatchVersion(r)
 	if err != nil {
+		writePreconditionError(w, err)
+		return
+	}
+
+	if hard {
+		err = h.service.HardDelete(r.Context(), id, expectedVersion)
+	} else {
+		err = h.service.Delete(r.Context(), id, expectedVersion)
+	}
+	if err != nil {
+		if h.access.Deny(w, r, err, "Task not found") {
+			return
+		}
 		if errors.Is(err, service.ErrTaskNotFound) {
 			pkg.NotFound(w, "Task not found")
 			return
 		}
+		if errors.Is(err, service.ErrConflict) {
+			pkg.PreconditionFailed(w, "Task was modified, fetch it again and retry with the new ETag")
+			return
+		}
+		if errors.Is(err, service.ErrTaskHasComments) {
+			pkg.Conflict(w, "Task has comments, delete them before deleting the task")
+			return
+		}
+		if errors.Is(err, service.ErrArchived) {
+			pkg.Conflict(w, "Task is archived, unarchive it first")
+			return
+		}
 		pkg.InternalError(w, "Failed to delete task")
 		return
 	}
 
 	pkg.NoContent(w)
 }
+
+// BulkUpdate handles POST /tasks/bulk/update
+func (h *TaskHandler) BulkUpdate(w http.ResponseWriter, r *http.Request) {
+	var req model.BulkUpdateRequest
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, decodeErrorMessage(err))
+		return
+	}
+
+	result, err := h.planner.Update(r.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrValidation) {
+			pkg.BadRequest(w, err.Error())
+			return
+		}
+		if writePlanError(w, err) {
+			return
+		}
+		pkg.InternalError(w, "Failed to update tasks")
+		return
+	}
+
+	pkg.JSONSuccess(w, result)
+}
+
+// BulkDelete handles POST /tasks/bulk/delete
+func (h *TaskHandler) BulkDelete(w http.ResponseWriter, r *http.Request) {
+	var req model.BulkDeleteRequest
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+
+	result, err := h.planner.Delete(r.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrValidation) {
+			pkg.BadRequest(w, err.Error())
+			return
+		}
+		if writePlanError(w, err) {
+			return
+		}
+		pkg.InternalError(w, "Failed to delete tasks")
+		return
+	}
+
+	pkg.JSONSuccess(w, result)
+}
+
+// PlanBulkUpdate handles POST /tasks/bulk/update/plan
+func (h *TaskHandler) PlanBulkUpdate(w http.ResponseWriter, r *http.Request) {
+	var req model.BulkUpdateRequest
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, decodeErrorMessage(err))
+		return
+	}
+
+	plan, err := h.planner.PlanUpdate(r.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrValidation) {
+			pkg.BadRequest(w, err.Error())
+			return
+		}
+		pkg.InternalError(w, "Failed to plan task update")
+		return
+	}
+
+	pkg.JSONSuccess(w, plan)
+}
+
+// PlanBulkDelete handles POST /tasks/bulk/delete/plan
+func (h *TaskHandler) PlanBulkDelete(w http.ResponseWriter, r *http.Request) {
+	var req model.BulkDeleteRequest
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+
+	plan, err := h.planner.PlanDelete(r.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrValidation) {
+			pkg.BadRequest(w, err.Error())
+			return
+		}
+		pkg.InternalError(w, "Failed to plan task delete")
+		return
+	}
+
+	pkg.JSONSuccess(w, plan)
+}
+
+// writePlanError answers bulk changes whose confirm token was missing or
+// did not check out, reporting whether err was one of those
+func writePlanError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, service.ErrPlanRequired):
+		pkg.PreconditionRequired(w, "Plan the change first and send its confirm_token")
+	case errors.Is(err, service.ErrPlanInvalid), errors.Is(err, service.ErrPlanStale):
+		pkg.Conflict(w, err.Error())
+	default:
+		return false
+	}
+	return true
+}
+
+// Restore handles POST /tasks/{id}/restore
+func (h *TaskHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		pkg.BadRequest(w, "Task ID is required")
+		return
+	}
+
+	task, err := h.service.Restore(r.Context(), id)
+	if err != nil {
+		if h.access.Deny(w, r, err, "Task not found") {
+			return
+		}
+		if errors.Is(err, service.ErrTaskNotFound) {
+			pkg.NotFound(w, "Task not found")
+			return
+		}
+		if errors.Is(err, service.ErrNotDeleted) {
+			pkg.Conflict(w, "Task is not deleted")
+			return
+		}
+		pkg.InternalError(w, "Failed to restore task")
+		return
+	}
+
+	setTaskETag(w, task)
+	pkg.JSONSuccess(w, task)
+}
+
+// Duplicate handles POST /tasks/{id}/duplicate. The tags, due_date and
+// recurrence query flags choose what is copied.
+func (h *TaskHandler) Duplicate(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	opts := model.DuplicateOptions{Tags: true, DueDate: true}
+	for name, flag := range map[string]*bool{"tags": &opts.Tags, "due_date": &opts.DueDate, "recurrence": &opts.Recurrence} {
+		if value := query.Get(name); value != "" {
+			var err error
+			if *flag, err = strconv.ParseBool(value); err != nil {
+				pkg.BadRequest(w, name+" must be true or false")
+				return
+			}
+		}
+	}
+
+	task, err := h.service.Duplicate(r.Context(), chi.URLParam(r, "id"), &opts)
+	if err != nil {
+		if h.access.Deny(w, r, err, "Task not found") {
+			return
+		}
+		if errors.Is(err, service.ErrTaskNotFound) {
+			pkg.NotFound(w, "Task not found")
+			return
+		}
+		if errors.Is(err, service.ErrLimitReached) {
+			pkg.Forbidden(w, "Task limit reached, try again after the next reset")
+			return
+		}
+		pkg.InternalError(w, "Failed to duplicate task")
+		return
+	}
+
+	setTaskETag(w, task)
+	pkg.Created(w, task)
+}
+
+// Archive handles POST /tasks/{id}/archive
+func (h *TaskHandler) Archive(w http.ResponseWriter, r *http.Request) {
+	task, err := h.service.Archive(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		if h.access.Deny(w, r, err, "Task not found") {
+			return
+		}
+		if errors.Is(err, service.ErrTaskNotFound) {
+			pkg.NotFound(w, "Task not found")
+			return
+		}
+		if errors.Is(err, service.ErrArchived) {
+			pkg.Conflict(w, "Task is already archived")
+			return
+		}
+		pkg.InternalError(w, "Failed to archive task")
+		return
+	}
+
+	setTaskETag(w, task)
+	pkg.JSONSuccess(w, task)
+}
+
+// Unarchive handles POST /tasks/{id}/unarchive
+func (h *TaskHandler) Unarchive(w http.ResponseWriter, r *http.Request) {
+	task, err := h.service.Unarchive(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		if h.access.Deny(w, r, err, "Task not found") {
+			return
+		}
+		if errors.Is(err, service.ErrTaskNotFound) {
+			pkg.NotFound(w, "Task not found")
+			return
+		}
+		if errors.Is(err, service.ErrNotArchived) {
+			pkg.Conflict(w, "Task is not archived")
+			return
+		}
+		pkg.InternalError(w, "Failed to unarchive task")
+		return
+	}
+
+	setTaskETag(w, task)
+	pkg.JSONSuccess(w, task)
+}
+
+// Assign handles PUT /tasks/{id}/assignee
+func (h *TaskHandler) Assign(w http.ResponseWriter, r *http.Request) {
+	var req model.AssignTaskRequest
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, decodeErrorMessage(err))
+		return
+	}
+
+	task, err := h.service.Assign(r.Context(), chi.URLParam(r, "id"), &req)
+	if err != nil {
+		writeAssignError(w, err)
+		return
+	}
+
+	setTaskETag(w, task)
+	pkg.JSONSuccess(w, task)
+}
+
+// Unassign handles DELETE /tasks/{id}/assignee
+func (h *TaskHandler) Unassign(w http.ResponseWriter, r *http.Request) {
+	task, err := h.service.Unassign(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeAssignError(w, err)
+		return
+	}
+
+	setTaskETag(w, task)
+	pkg.JSONSuccess(w, task)
+}
+
+// Move handles PATCH /tasks/{id}/move
+func (h *TaskHandler) Move(w http.ResponseWriter, r *http.Request) {
+	var req model.MoveTaskRequest
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, decodeErrorMessage(err))
+		return
+	}
+
+	task, err := h.service.Move(r.Context(), chi.URLParam(r, "id"), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrValidation):
+			pkg.BadRequest(w, err.Error())
+		case errors.Is(err, service.ErrTaskNotFound):
+			pkg.NotFound(w, "Task not found")
+		case errors.Is(err, service.ErrUnknownAnchor):
+			pkg.UnprocessableEntity(w, "Task to move next to not found")
+		case errors.Is(err, service.ErrArchived):
+			pkg.Conflict(w, "Task is archived")
+		default:
+			pkg.InternalError(w, "Failed to move task")
+		}
+		return
+	}
+
+	setTaskETag(w, task)
+	pkg.JSONSuccess(w, task)
+}
+
+// expand loads expansions into tasks, naming any that were left out in a
+// Warning header. It writes the error response and returns false when
+// expanding failed.
+func (h *TaskHandler) expand(w http.ResponseWriter, r *http.Request, expansions []service.Expansion, tasks ...*model.TaskResponse) bool {
+	incomplete, err := h.expansions.Expand(r.Context(), expansions, tasks...)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			pkg.ServiceUnavailable(w, pkg.ErrorResponse{Error: "Expanding related resources timed out"})
+			return false
+		}
+		pkg.InternalError(w, "Failed to expand related resources")
+		return false
+	}
+
+	if len(incomplete) > 0 {
+		names := make([]string, len(incomplete))
+		for i, expansion := range incomplete {
+			names[i] = string(expansion)
+		}
+		w.Header().Set("Warning", fmt.Sprintf(`199 - "Incomplete expansions: %s"`, strings.Join(names, ",")))
+	}
+	return true
+}
+
+// responseFields is the response key selection for a ?fields= selection,
+// keeping the requested expansions; nil when every field is returned
+func responseFields(fields []string, expansions []service.Expansion) []string {
+	if fields == nil {
+		return nil
+	}
+	selection := slices.Clone(fields)
+	for _, expansion := range expansions {
+		selection = append(selection, expansion.Field())
+	}
+	return selection
+}
+
+func writeAssignError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrValidation):
+		pkg.BadRequest(w, err.Error())
+	case errors.Is(err, service.ErrAnonymous):
+		pkg.Unauthorized(w, err.Error())
+	case errors.Is(err, service.ErrTaskNotFound):
+		pkg.NotFound(w, "Task not found")
+	case errors.Is(err, service.ErrArchived):
+		pkg.Conflict(w, "Task is archived")
+	case errors.Is(err, service.ErrUnknownUser):
+		pkg.UnprocessableEntity(w, "Assignee has never signed in")
+	default:
+		pkg.InternalError(w, "Failed to assign task")
+	}
+}
+
+// decodeErrorMessage explains a request body decode failure, naming the
+// allowed values when an enum field such as status or priority is invalid
+func decodeErrorMessage(err error) string {
+	var statusErr *model.InvalidStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Error()
+	}
+	var priorityErr *model.InvalidPriorityError
+	if errors.As(err, &priorityErr) {
+		return priorityErr.Error()
+	}
+	// Only decoders that disallow unknown fields report these
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return "Unknown field " + field
+	}
+	return "Invalid JSON payload"
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskHandler_AnonymousScope(t *testing.T) {
+	events := service.NewEventService(repository.NewMemoryEventRepository(), kvstore.NewMemory(), &config.EventsConfig{})
+	guard := service.NewQueryGuard(&config.QueryGuardConfig{DefaultPerPage: 10, MaxPerPage: 10})
+	degradation := service.NewDegradation(&config.DegradationConfig{})
+	tasks := service.NewTaskService(repository.NewMemoryTaskRepository(0), guard, degradation, events, nil, nil, nil, &config.TaskConfig{DefaultProject: "TASK"})
+
+	security := &recordedSecurity{}
+	h := NewTaskHandler(tasks, nil, nil, NewAccessPolicy(&config.AuthConfig{Denial: "hide"}, security), nil)
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if user := r.Header.Get("X-User"); user != "" {
+				r = r.WithContext(auth.WithPrincipal(r.Context(), &auth.Principal{User: user}))
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	r.Use(h.Scope)
+	r.Get("/tasks", h.GetAll)
+	r.Post("/tasks", h.Create)
+	r.Get("/tasks/{id}", h.GetByID)
+	r.Put("/tasks/{id}", h.Update)
+	r.Delete("/tasks/{id}", h.Delete)
+
+	do := func(method, path, user, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", "*")
+		if user != "" {
+			req.Header.Set("X-User", user)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	create := func(user, title string) string {
+		w := do(http.MethodPost, "/tasks", user, `{"title":"`+title+`"}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var task model.TaskResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &task))
+		return task.ID
+	}
+	list := func(user string) []string {
+		w := do(http.MethodGet, "/tasks", user, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp model.TaskListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		var ids []string
+		for _, task := range resp.Data {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+
+	owned := create("alice", "Payroll")
+
+	// Anonymous callers do not reach a signed-in user's task
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/tasks/"+owned, "", "").Code)
+	assert.Empty(t, list(""))
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPut, "/tasks/"+owned, "", `{"title":"Mine now"}`).Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/tasks/"+owned, "", "").Code)
+
+	// but do reach the tasks of no owner, which they create
+	shared := create("", "Water plants")
+	assert.Equal(t, []string{shared}, list(""))
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/tasks/"+shared, "", "").Code)
+
+	w := do(http.MethodGet, "/tasks/"+owned, "alice", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"title":"Payroll"`)
+	assert.Equal(t, []string{owned}, list("alice"))
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/codec"
+)
+
+// TeamHandler handles HTTP requests for teams and for sharing tasks with
+// them. Routes are mounted behind middleware.RequireAuth, so a principal
+// is present, and teams the caller does not belong to are answered by the
+// access policy.
+type TeamHandler struct {
+	service *service.TeamService
+	access  *AccessPolicy
+}
+
+// NewTeamHandler creates a new TeamHandler
+func NewTeamHandler(service *service.TeamService, access *AccessPolicy) *TeamHandler {
+	return &TeamHandler{service: service, access: access}
+}
+
+// Create handles POST /teams
+func (h *TeamHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req model.CreateTeamRequest
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+
+	team, err := h.service.Create(r.Context(), auth.FromContext(r.Context()), &req)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to create team")
+		return
+	}
+
+	pkg.Created(w, team)
+}
+
+// List handles GET /teams
+func (h *TeamHandler) List(w http.ResponseWriter, r *http.Request) {
+	teams, err := h.service.List(r.Context(), auth.FromContext(r.Context()))
+	if err != nil {
+		pkg.InternalError(w, "Failed to retrieve teams")
+		return
+	}
+
+	pkg.JSONSuccess(w, teams)
+}
+
+// Get handles GET /teams/{id}
+func (h *TeamHandler) Get(w http.ResponseWriter, r *http.Request) {
+	team, err := h.service.Get(r.Context(), auth.FromContext(r.Context()), chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeError(w, r, err, "Failed to retrieve team")
+		return
+	}
+
+	pkg.JSONSuccess(w, team)
+}
+
+// Delete handles DELETE /teams/{id}
+func (h *TeamHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.Delete(r.Context(), auth.FromContext(r.Context()), chi.URLParam(r, "id")); err != nil {
+		h.writeError(w, r, err, "Failed to delete team")
+		return
+	}
+
+	pkg.NoContent(w)
+}
+
+// AddMember handles PUT /teams/{id}/members/{user}
+func (h *TeamHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	team, err := h.service.AddMember(r.Context(), auth.FromContext(r.Context()), chi.URLParam(r, "id"), chi.URLParam(r, "user"))
+	if err != nil {
+		h.writeError(w, r, err, "Failed to add team member")
+		return
+	}
+
+	pkg.JSONSuccess(w, team)
+}
+
+// RemoveMember handles DELETE /teams/{id}/members/{user}
+func (h *TeamHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	team, err := h.service.RemoveMember(r.Context(), auth.FromContext(r.Context()), chi.URLParam(r, "id"), chi.URLParam(r, "user"))
+	if err != nil {
+		h.writeError(w, r, err, "Failed to remove team member")
+		return
+	}
+
+	pkg.JSONSuccess(w, team)
+}
+
+// Share handles PUT /tasks/{id}/team
+func (h *TeamHandler) Share(w http.ResponseWriter, r *http.Request) {
+	var req model.AssignTeamRequest
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, decodeErrorMessage(err))
+		return
+	}
+	if req.Team == "" {
+		pkg.BadRequest(w, "team is required")
+		return
+	}
+
+	h.share(w, r, req.Team)
+}
+
+// Unshare handles DELETE /tasks/{id}/team
+func (h *TeamHandler) Unshare(w http.ResponseWriter, r *http.Request) {
+	h.share(w, r, "")
+}
+
+func (h *TeamHandler) share(w http.ResponseWriter, r *http.Request, team string) {
+	task, err := h.service.Share(r.Context(), auth.FromContext(r.Context()), chi.URLParam(r, "id"), team)
+	if err != nil {
+		notFound := "Task not found"
+		if errors.Is(err, service.ErrTeamNotFound) {
+			notFound = "Team not found"
+		}
+		if h.access.Deny(w, r, err, notFound) {
+			return
+		}
+		switch {
+		case errors.Is(err, service.ErrTaskNotFound), errors.Is(err, service.ErrTeamNotFound):
+			pkg.NotFound(w, notFound)
+		case errors.Is(err, service.ErrArchived):
+			pkg.Conflict(w, "Task is archived")
+		default:
+			pkg.InternalError(w, "Failed to share task")
+		}
+		return
+	}
+
+	setTaskETag(w, task)
+	pkg.JSONSuccess(w, task)
+}
+
+func (h *TeamHandler) writeError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	if h.access.Deny(w, r, err, "Team not found") {
+		return
+	}
+	switch {
+	case errors.Is(err, service.ErrValidation):
+		pkg.BadRequest(w, err.Error())
+	case errors.Is(err, service.ErrTeamNotFound):
+		pkg.NotFound(w, "Team not found")
+	case errors.Is(err, service.ErrTeamExists):
+		pkg.Conflict(w, "Team already exists")
+	case errors.Is(err, service.ErrNotMember):
+		pkg.NotFound(w, "User is not a member of the team")
+	case errors.Is(err, service.ErrLastMember):
+		pkg.Conflict(w, "A team keeps at least one member, delete the team instead")
+	case errors.Is(err, service.ErrUnknownUser):
+		pkg.UnprocessableEntity(w, "User has never signed in")
+	default:
+		pkg.InternalError(w, message)
+	}
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+// TenantHandler serves tenant memberships under /admin
+type TenantHandler struct {
+	service *service.TenantService
+}
+
+// NewTenantHandler creates a new TenantHandler
+func NewTenantHandler(service *service.TenantService) *TenantHandler {
+	return &TenantHandler{service: service}
+}
+
+// Members handles GET /admin/tenants/{tenant}/members
+func (h *TenantHandler) Members(w http.ResponseWriter, r *http.Request) {
+	members, err := h.service.List(r.Context(), chi.URLParam(r, "tenant"))
+	if err != nil {
+		writeTenantError(w, r, err, "Failed to retrieve tenant members")
+		return
+	}
+
+	pkg.JSONSuccess(w, members)
+}
+
+// AddMember handles PUT /admin/tenants/{tenant}/members/{user}
+func (h *TenantHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	member, err := h.service.Add(r.Context(), chi.URLParam(r, "tenant"), chi.URLParam(r, "user"))
+	if err != nil {
+		writeTenantError(w, r, err, "Failed to add tenant member")
+		return
+	}
+
+	pkg.JSONSuccess(w, member)
+}
+
+// RemoveMember handles DELETE /admin/tenants/{tenant}/members/{user}
+func (h *TenantHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.Remove(r.Context(), chi.URLParam(r, "tenant"), chi.URLParam(r, "user")); err != nil {
+		writeTenantError(w, r, err, "Failed to remove tenant member")
+		return
+	}
+
+	pkg.NoContent(w)
+}
+
+// writeTenantError maps TenantService errors to responses
+func writeTenantError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrValidation):
+		pkg.BadRequest(w, err.Error())
+	case errors.Is(err, service.ErrUnknownUser):
+		pkg.NotFound(w, "User not found")
+	case errors.Is(err, service.ErrMemberNotFound):
+		pkg.NotFound(w, "Tenant member not found")
+	default:
+		logger.Ctx(r.Context()).Error().Err(err).Msg(message)
+		pkg.InternalError(w, message)
+	}
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/codec"
+	"github.com/moabdelazem/mutlitier_app/pkg/middleware"
+)
+
+// TokenHandler handles HTTP requests for the caller's API tokens. Routes
+// are mounted behind middleware.RequireAuth, so a principal is present.
+// Issued and revoked tokens and refused scopes are recorded as security
+// events, and another user's token is answered by the access policy.
+type TokenHandler struct {
+	service  *service.TokenService
+	security middleware.SecurityRecorder
+	access   *AccessPolicy
+}
+
+// NewTokenHandler creates a new TokenHandler
+func NewTokenHandler(service *service.TokenService, security middleware.SecurityRecorder, access *AccessPolicy) *TokenHandler {
+	return &TokenHandler{service: service, security: security, access: access}
+}
+
+// Create handles POST /me/tokens
+func (h *TokenHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req model.CreateTokenRequest
+	if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.BadRequest(w, "Invalid JSON payload")
+		return
+	}
+
+	token, err := h.service.Create(r.Context(), auth.FromContext(r.Context()), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrValidation) {
+			pkg.BadRequest(w, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrScopeDenied) {
+			h.security.Record(r.Context(), middleware.SecurityEvent(r, model.SecurityPermissionDenied, err.Error()))
+			pkg.Forbidden(w, err.Error())
+			return
+		}
+		pkg.InternalError(w, "Failed to create token")
+		return
+	}
+
+	event := middleware.SecurityEvent(r, model.SecurityTokenIssued, "scopes "+strings.Join(token.Scopes, ","))
+	event.Credential, event.TokenID = model.CredentialAPIToken, token.ID
+	h.security.Record(r.Context(), event)
+
+	pkg.Created(w, token)
+}
+
+// List handles GET /me/tokens
+func (h *TokenHandler) List(w http.ResponseWriter, r *http.Request) {
+	tokens, err := h.service.List(r.Context(), auth.FromContext(r.Context()).User)
+	if err != nil {
+		pkg.InternalError(w, "Failed to retrieve tokens")
+		return
+	}
+
+	pkg.JSONSuccess(w, tokens)
+}
+
+// Get handles GET /me/tokens/{id}
+func (h *TokenHandler) Get(w http.ResponseWriter, r *http.Request) {
+	token, err := h.service.Get(r.Context(), auth.FromContext(r.Context()).User, chi.URLParam(r, "id"))
+	if err != nil {
+		if h.access.Deny(w, r, err, "Token not found") {
+			return
+		}
+		if errors.Is(err, service.ErrTokenNotFound) {
+			pkg.NotFound(w, "Token not found")
+			return
+		}
+		pkg.InternalError(w, "Failed to retrieve token")
+		return
+	}
+
+	pkg.JSONSuccess(w, token)
+}
+
+// Delete handles DELETE /me/tokens/{id}
+func (h *TokenHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	err := h.service.Delete(r.Context(), auth.FromContext(r.Context()).User, id)
+	if err != nil {
+		if h.access.Deny(w, r, err, "Token not found") {
+			return
+		}
+		if errors.Is(err, service.ErrTokenNotFound) {
+			pkg.NotFound(w, "Token not found")
+			return
+		}
+		pkg.InternalError(w, "Failed to delete token")
+		return
+	}
+
+	event := middleware.SecurityEvent(r, model.SecurityTokenRevoked, "")
+	event.Credential, event.TokenID = model.CredentialAPIToken, id
+	h.security.Record(r.Context(), event)
+
+	pkg.NoContent(w)
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedSecurity struct {
+	mu     sync.Mutex
+	events []*model.SecurityEvent
+}
+
+func (s *recordedSecurity) Record(ctx context.Context, event *model.SecurityEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+}
+
+func (s *recordedSecurity) count(eventType model.SecurityEventType) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, event := range s.events {
+		if event.Type == eventType {
+			n++
+		}
+	}
+	return n
+}
+
+func TestTokenHandler_CrossUser(t *testing.T) {
+	for _, tt := range []struct {
+		denial string
+		status int
+	}{
+		{denial: "hide", status: http.StatusNotFound},
+		{denial: "forbid", status: http.StatusForbidden},
+	} {
+		t.Run(tt.denial, func(t *testing.T) {
+			cfg := &config.AuthConfig{TokenDefaultTTL: time.Hour, TokenMaxTTL: 24 * time.Hour, Denial: tt.denial}
+			tokens := service.NewTokenService(repository.NewMemoryTokenRepository(), cfg)
+			created, err := tokens.Create(context.Background(),
+				&auth.Principal{User: "alice", Scopes: []auth.Scope{auth.ScopeReadTasks}},
+				&model.CreateTokenRequest{Name: "ci", Scopes: []string{"read:tasks"}})
+			require.NoError(t, err)
+
+			security := &recordedSecurity{}
+			h := NewTokenHandler(tokens, security, NewAccessPolicy(cfg, security))
+			r := chi.NewRouter()
+			r.Use(func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					principal := &auth.Principal{User: r.Header.Get("X-User")}
+					next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+				})
+			})
+			r.Get("/me/tokens/{id}", h.Get)
+			r.Delete("/me/tokens/{id}", h.Delete)
+
+			do := func(method, user, id string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(method, "/me/tokens/"+id, nil)
+				req.Header.Set("X-User", user)
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+				return w
+			}
+
+			// Another user's token is answered by the policy and recorded
+			assert.Equal(t, tt.status, do(http.MethodGet, "bob", created.ID).Code)
+			assert.Equal(t, tt.status, do(http.MethodDelete, "bob", created.ID).Code)
+			assert.Equal(t, 2, security.count(model.SecurityPermissionDenied))
+
+			// A missing token is 404 under either policy
+			missing := "0190b6c4-3f4e-7a8b-9c0d-1e2f3a4b5c6d"
+			assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "bob", missing).Code)
+
+			w := do(http.MethodGet, "alice", created.ID)
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Body.String(), created.ID)
+			assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "alice", created.ID).Code)
+			assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "alice", created.ID).Code)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/service"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/codec"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+)
+
+// WatcherHandler handles HTTP requests for task watchers and the caller's
+// notifications
+type WatcherHandler struct {
+	service *service.WatcherService
+}
+
+// NewWatcherHandler creates a new WatcherHandler
+func NewWatcherHandler(service *service.WatcherService) *WatcherHandler {
+	return &WatcherHandler{service: service}
+}
+
+// Watch handles POST /tasks/{id}/watch
+func (h *WatcherHandler) Watch(w http.ResponseWriter, r *http.Request) {
+	status, err := h.service.Watch(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeWatchError(w, err, "Failed to watch task")
+		return
+	}
+
+	pkg.JSONSuccess(w, status)
+}
+
+// Unwatch handles DELETE /tasks/{id}/watch
+func (h *WatcherHandler) Unwatch(w http.ResponseWriter, r *http.Request) {
+	status, err := h.service.Unwatch(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeWatchError(w, err, "Failed to unwatch task")
+		return
+	}
+
+	pkg.JSONSuccess(w, status)
+}
+
+// List handles GET /tasks/{id}/watchers
+func (h *WatcherHandler) List(w http.ResponseWriter, r *http.Request) {
+	watchers, err := h.service.List(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeWatchError(w, err, "Failed to retrieve watchers")
+		return
+	}
+
+	pkg.JSONSuccess(w, watchers)
+}
+
+// Notifications handles GET /me/notifications
+func (h *WatcherHandler) Notifications(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var opts model.ListOptions
+	var err error
+	if opts.Params, err = listing.Parse(query); err != nil {
+		pkg.BadRequest(w, err.Error())
+		return
+	}
+	unreadOnly, err := listing.Bool(query, "unread")
+	if err != nil {
+		pkg.BadRequest(w, err.Error())
+		return
+	}
+
+	notifications, err := h.service.Notifications(r.Context(), unreadOnly, &opts)
+	if err != nil {
+		if errors.Is(err, service.ErrValidation) || errors.Is(err, service.ErrQueryTooExpensive) {
+			pkg.BadRequest(w, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrAnonymous) {
+			pkg.Unauthorized(w, err.Error())
+			return
+		}
+		pkg.InternalError(w, "Failed to retrieve notifications")
+		return
+	}
+
+	pkg.JSONList(w, notifications)
+}
+
+// MarkRead handles POST /me/notifications/read
+func (h *WatcherHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	var req model.MarkNotificationsReadRequest
+	if r.ContentLength != 0 {
+		if err := codec.NewDecoder(r.Body).Decode(&req); err != nil {
+			pkg.BadRequest(w, "Invalid JSON payload")
+			return
+		}
+	}
+
+	marked, err := h.service.MarkRead(r.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrValidation) {
+			pkg.BadRequest(w, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrAnonymous) {
+			pkg.Unauthorized(w, err.Error())
+			return
+		}
+		pkg.InternalError(w, "Failed to mark notifications read")
+		return
+	}
+
+	pkg.JSONSuccess(w, map[string]int64{"marked": marked})
+}
+
+func writeWatchError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrAnonymous):
+		pkg.Unauthorized(w, err.Error())
+	case errors.Is(err, service.ErrTaskNotFound):
+		pkg.NotFound(w, "Task not found")
+	default:
+		pkg.InternalError(w, message)
+	}
+}
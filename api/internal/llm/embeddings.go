@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// OpenAIEmbedder is an Embedder for any OpenAI-compatible embeddings API,
+// such as vLLM, Ollama or text-embeddings-inference running in the cluster
+type OpenAIEmbedder struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+	model   string
+}
+
+// NewOpenAIEmbedder creates a new OpenAI-compatible embeddings client.
+// baseURL is the API root, e.g. http://tei.ai.svc:8080/v1.
+func NewOpenAIEmbedder(client *http.Client, baseURL, apiKey, model string) *OpenAIEmbedder {
+	return &OpenAIEmbedder{
+		client:  client,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+	}
+}
+
+type embeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embed implements Embedder
+func (o *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(&embeddingRequest{Model: o.model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embedding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("model returned %d: %s", resp.StatusCode, message)
+	}
+
+	var reply embeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return nil, fmt.Errorf("failed to decode embedding response: %w", err)
+	}
+
+	// Replies name the input each vector belongs to, in any order
+	vectors := make([][]float32, len(texts))
+	for _, item := range reply.Data {
+		if item.Index < 0 || item.Index >= len(texts) {
+			return nil, fmt.Errorf("model returned an embedding for unknown input %d", item.Index)
+		}
+		vectors[item.Index] = item.Embedding
+	}
+	for i, vector := range vectors {
+		if len(vector) == 0 {
+			return nil, fmt.Errorf("model returned no embedding for input %d", i)
+		}
+	}
+	return vectors, nil
+}
+
+// Model implements Embedder
+func (o *OpenAIEmbedder) Model() string {
+	return o.model
+}
@@ -0,0 +1,74 @@
+// Package llm talks to the language model behind task summaries and triage
+// and the embedding model behind duplicate task detection
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+)
+
+// Message is one turn of a conversation with the model
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Message roles
+const (
+	RoleSystem = "system"
+	RoleUser   = "user"
+)
+
+// Provider is a language model. Implementations must be safe for
+// concurrent use.
+type Provider interface {
+	// Complete returns the model's reply to messages
+	Complete(ctx context.Context, messages []Message) (string, error)
+
+	// Model names the model replies come from
+	Model() string
+}
+
+// Embedder turns text into vectors whose cosine similarity reflects how
+// close in meaning the texts are. Implementations must be safe for
+// concurrent use.
+type Embedder interface {
+	// Embed returns one vector per text, in order
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+
+	// Model names the model vectors come from. Vectors of different
+	// models cannot be compared.
+	Model() string
+}
+
+// Supported backends
+const (
+	BackendOpenAI = "openai"
+)
+
+// New creates the Provider for the configured backend
+func New(cfg *config.AIConfig) (Provider, error) {
+	client := &http.Client{Timeout: cfg.Timeout}
+
+	switch cfg.Backend {
+	case BackendOpenAI:
+		return NewOpenAI(client, cfg.URL, cfg.APIKey, cfg.Model, cfg.MaxTokens), nil
+	default:
+		return nil, fmt.Errorf("unknown AI backend %q", cfg.Backend)
+	}
+}
+
+// NewEmbedder creates the Embedder for the configured backend
+func NewEmbedder(cfg *config.EmbeddingsConfig) (Embedder, error) {
+	client := &http.Client{Timeout: cfg.Timeout}
+
+	switch cfg.Backend {
+	case BackendOpenAI:
+		return NewOpenAIEmbedder(client, cfg.URL, cfg.APIKey, cfg.Model), nil
+	default:
+		return nil, fmt.Errorf("unknown embeddings backend %q", cfg.Backend)
+	}
+}
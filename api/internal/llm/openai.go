@@ -0,0 +1,90 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// OpenAI is a Provider for any OpenAI-compatible chat completions API,
+// such as vLLM, Ollama or LocalAI running in the cluster
+type OpenAI struct {
+	client    *http.Client
+	baseURL   string
+	apiKey    string
+	model     string
+	maxTokens int
+}
+
+// NewOpenAI creates a new OpenAI-compatible client. baseURL is the API
+// root, e.g. http://vllm.ai.svc:8000/v1.
+func NewOpenAI(client *http.Client, baseURL, apiKey, model string, maxTokens int) *OpenAI {
+	return &OpenAI{
+		client:    client,
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		apiKey:    apiKey,
+		model:     model,
+		maxTokens: maxTokens,
+	}
+}
+
+type chatRequest struct {
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	MaxTokens   int       `json:"max_tokens,omitempty"`
+	Temperature float64   `json:"temperature"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message Message `json:"message"`
+	} `json:"choices"`
+}
+
+// Complete implements Provider. Replies use temperature 0, so the same
+// input gives the same reply as far as the model allows.
+func (o *OpenAI) Complete(ctx context.Context, messages []Message) (string, error) {
+	body, err := json.Marshal(&chatRequest{Model: o.model, Messages: messages, MaxTokens: o.maxTokens})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("model request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("model returned %d: %s", resp.StatusCode, message)
+	}
+
+	var reply chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return "", fmt.Errorf("failed to decode model response: %w", err)
+	}
+	if len(reply.Choices) == 0 || strings.TrimSpace(reply.Choices[0].Message.Content) == "" {
+		return "", errors.New("model returned no reply")
+	}
+	return strings.TrimSpace(reply.Choices[0].Message.Content), nil
+}
+
+// Model implements Provider
+func (o *OpenAI) Model() string {
+	return o.model
+}
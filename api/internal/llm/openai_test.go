@@ -0,0 +1,67 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAI_Complete(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+
+		var body chatRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "llama", body.Model)
+		assert.Equal(t, 100, body.MaxTokens)
+		require.Len(t, body.Messages, 2)
+		assert.Equal(t, RoleSystem, body.Messages[0].Role)
+
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"  Ship it.\n"}}]}`))
+	}))
+	defer srv.Close()
+
+	model := NewOpenAI(srv.Client(), srv.URL+"/v1/", "key", "llama", 100)
+	reply, err := model.Complete(context.Background(), []Message{
+		{Role: RoleSystem, Content: "Summarize"},
+		{Role: RoleUser, Content: "Title: Deploy"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Ship it.", reply)
+}
+
+func TestOpenAI_CompleteError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error":"model loading"}`))
+	}))
+	defer srv.Close()
+
+	_, err := NewOpenAI(srv.Client(), srv.URL, "", "llama", 0).Complete(context.Background(), nil)
+	assert.ErrorContains(t, err, "503")
+}
+
+func TestOpenAIEmbedder_Embed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/embeddings", r.URL.Path)
+
+		var body embeddingRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "bge-small", body.Model)
+		assert.Equal(t, []string{"Deploy", "Rollback"}, body.Input)
+
+		w.Write([]byte(`{"data":[{"index":1,"embedding":[0,1]},{"index":0,"embedding":[1,0]}]}`))
+	}))
+	defer srv.Close()
+
+	vectors, err := NewOpenAIEmbedder(srv.Client(), srv.URL+"/v1", "", "bge-small").
+		Embed(context.Background(), []string{"Deploy", "Rollback"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1, 0}, {0, 1}}, vectors)
+}
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// TaskSummary is a language model's summary of a task, its description
+// and its latest comments
+type TaskSummary struct {
+	TaskID      string    `json:"task_id"`
+	Summary     string    `json:"summary"`
+	Model       string    `json:"model"`
+	Cached      bool      `json:"cached"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// TriageRequest represents the request body for triaging a task that is
+// about to be created
+type TriageRequest struct {
+	Title       string `json:"title" validate:"required,min=1,max=255"`
+	Description string `json:"description" validate:"max=1000"`
+}
+
+// TriageSuggestion is a language model's suggested priority and tags for a
+// task. Tags are limited to existing ones, so they can be attached as is.
+type TriageSuggestion struct {
+	Priority    Priority  `json:"priority"`
+	Tags        []string  `json:"tags"`
+	Reason      string    `json:"reason"`
+	Model       string    `json:"model"`
+	Cached      bool      `json:"cached"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
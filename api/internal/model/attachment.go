@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// Attachment is a file uploaded to a task. The contents are kept by the
+// storage backend under StorageKey.
+type Attachment struct {
+	ID          string    `json:"id"`
+	TaskID      string    `json:"task_id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	StorageKey  string    `json:"-"`
+	UploadedBy  string    `json:"uploaded_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AttachmentListResponse represents every attachment of a task, oldest first
+type AttachmentListResponse struct {
+	Data       []*Attachment `json:"data"`
+	Total      int           `json:"total"`
+	TotalBytes int64         `json:"total_bytes"`
+}
+
+// NewAttachmentListResponse builds the response for attachments
+func NewAttachmentListResponse(attachments []*Attachment) *AttachmentListResponse {
+	response := &AttachmentListResponse{Data: attachments, Total: len(attachments)}
+	if response.Data == nil {
+		response.Data = []*Attachment{}
+	}
+	for _, attachment := range attachments {
+		response.TotalBytes += attachment.Size
+	}
+	return response
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+)
+
+// AuditLog is one successful mutation made by an identified caller.
+// Endpoint is the route pattern, such as PATCH /tasks/{id}, and Before and
+// After summarize the entity's fields that changed, when known.
+type AuditLog struct {
+	ID        int64           `json:"id"`
+	User      string          `json:"user"`
+	TokenID   string          `json:"token_id,omitempty"`
+	Method    string          `json:"method"`
+	Endpoint  string          `json:"endpoint"`
+	Path      string          `json:"path"`
+	EntityID  string          `json:"entity_id,omitempty"`
+	Status    int             `json:"status"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	IP        string          `json:"ip"`
+	RequestID string          `json:"request_id,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AuditLogFilter narrows an audit log listing; zero values match
+// everything
+type AuditLogFilter struct {
+	User     string
+	EntityID string
+	From     *time.Time
+	To       *time.Time
+}
+
+// AuditLogListResponse represents a page of audit logs, newest first
+type AuditLogListResponse struct {
+	Data       []*AuditLog        `json:"data"`
+	Pagination listing.Pagination `json:"pagination"`
+}
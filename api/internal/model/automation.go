@@ -0,0 +1,196 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+)
+
+// AutomationTrigger is what starts an automation rule
+type AutomationTrigger string
+
+const (
+	// AutomationStatusChanged fires when a task moves to another status
+	AutomationStatusChanged AutomationTrigger = "status_changed"
+	// AutomationTagAdded fires when a task gains a tag
+	AutomationTagAdded AutomationTrigger = "tag_added"
+	// AutomationDueDatePassed fires once an open task is past its due date
+	AutomationDueDatePassed AutomationTrigger = "due_date_passed"
+)
+
+// AutomationActionType is something an automation rule does to a task
+type AutomationActionType string
+
+const (
+	AutomationAssign  AutomationActionType = "assign"
+	AutomationLabel   AutomationActionType = "label"
+	AutomationWebhook AutomationActionType = "webhook"
+	AutomationComment AutomationActionType = "comment"
+)
+
+// Condition operators
+const (
+	AutomationIs    = "is"
+	AutomationIsNot = "is_not"
+)
+
+// AutomationUnassigned stands for no assignee in assignee conditions
+const AutomationUnassigned = "none"
+
+// AutomationTriggerSpec describes when a rule fires. From and To restrict
+// status_changed to changes from and to those statuses, and Tag restricts
+// tag_added to one tag; empty matches any.
+type AutomationTriggerSpec struct {
+	Type AutomationTrigger `json:"type" validate:"required,oneof=status_changed tag_added due_date_passed"`
+	From []Status          `json:"from,omitempty" validate:"max=10,dive,task_status"`
+	To   []Status          `json:"to,omitempty" validate:"max=10,dive,task_status"`
+	Tag  string            `json:"tag,omitempty" validate:"omitempty,max=50"`
+}
+
+// MatchesStatus reports whether a change from one status to another
+// fires a status_changed trigger
+func (t *AutomationTriggerSpec) MatchesStatus(from, to Status) bool {
+	return from != to &&
+		(len(t.From) == 0 || slices.Contains(t.From, from)) &&
+		(len(t.To) == 0 || slices.Contains(t.To, to))
+}
+
+// MatchesTag reports whether adding tag fires a tag_added trigger
+func (t *AutomationTriggerSpec) MatchesTag(tag string) bool {
+	return t.Tag == "" || t.Tag == tag
+}
+
+// AutomationCondition must hold for a rule to act: the task's Field is one
+// of Values (op "is") or none of them ("is_not"). A task matches a tag
+// value when it carries that tag, and the assignee "none" when it is
+// unassigned.
+type AutomationCondition struct {
+	Field  string   `json:"field" validate:"required,oneof=status priority assignee tag"`
+	Op     string   `json:"op" validate:"required,oneof=is is_not"`
+	Values []string `json:"values" validate:"required,min=1,max=20,dive,min=1,max=255"`
+}
+
+// Holds reports whether the condition holds for task
+func (c *AutomationCondition) Holds(task *TaskResponse) bool {
+	var actual []string
+	switch c.Field {
+	case "status":
+		actual = []string{string(task.Status)}
+	case "priority":
+		actual = []string{string(task.Priority)}
+	case "assignee":
+		actual = []string{AutomationUnassigned}
+		if task.Assignee != nil {
+			actual = []string{*task.Assignee}
+		}
+	case "tag":
+		actual = task.Tags
+	}
+
+	found := slices.ContainsFunc(actual, func(value string) bool {
+		return slices.Contains(c.Values, value)
+	})
+	return found == (c.Op == AutomationIs)
+}
+
+// AutomationAction is one thing a rule does: assign the task to Assignee,
+// label it with Tag, post it to the webhook at URL, or comment Body on it
+type AutomationAction struct {
+	Type     AutomationActionType `json:"type" validate:"required,oneof=assign label webhook comment"`
+	Assignee string               `json:"assignee,omitempty" validate:"omitempty,max=255"`
+	Tag      string               `json:"tag,omitempty" validate:"omitempty,max=50"`
+	URL      string               `json:"url,omitempty" validate:"omitempty,url,max=2048"`
+	Body     string               `json:"body,omitempty" validate:"omitempty,max=5000"`
+}
+
+// AutomationRule acts on the tasks of a project when its trigger fires
+// and all of its conditions hold
+type AutomationRule struct {
+	ID         string                `json:"id"`
+	ProjectKey string                `json:"project_key"`
+	Name       string                `json:"name"`
+	Trigger    AutomationTriggerSpec `json:"trigger"`
+	Conditions []AutomationCondition `json:"conditions"`
+	Actions    []AutomationAction    `json:"actions"`
+	Enabled    bool                  `json:"enabled"`
+	CreatedBy  string                `json:"created_by"`
+	// Owner is the user the rule runs as: it only fires on, and acts on,
+	// the tasks they reach. Nil for rules created by admins, which reach
+	// every task.
+	Owner     *string   `json:"owner,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Tenant is the tenant the rule was created in and runs in
+	Tenant string `json:"-"`
+}
+
+// Applies reports whether every condition of the rule holds for task
+func (r *AutomationRule) Applies(task *TaskResponse) bool {
+	for i := range r.Conditions {
+		if !r.Conditions[i].Holds(task) {
+			return false
+		}
+	}
+	return true
+}
+
+// AutomationRuleRequest represents the request body for creating or
+// replacing an automation rule. Enabled defaults to true.
+type AutomationRuleRequest struct {
+	Name       string                `json:"name" validate:"required,min=1,max=100"`
+	Trigger    AutomationTriggerSpec `json:"trigger"`
+	Conditions []AutomationCondition `json:"conditions" validate:"max=20,dive"`
+	Actions    []AutomationAction    `json:"actions" validate:"required,min=1,max=10,dive"`
+	Enabled    *bool                 `json:"enabled"`
+}
+
+// AutomationRuleListResponse represents a project's automation rules,
+// oldest first
+type AutomationRuleListResponse struct {
+	Data []*AutomationRule `json:"data"`
+}
+
+// AutomationRunStatus is the outcome of a rule run
+type AutomationRunStatus string
+
+const (
+	// AutomationRunning is a run claimed but not finished, such as one cut
+	// short by a crash
+	AutomationRunning   AutomationRunStatus = "running"
+	AutomationSucceeded AutomationRunStatus = "succeeded"
+	AutomationFailed    AutomationRunStatus = "failed"
+	// AutomationSkipped is a run refused by loop protection
+	AutomationSkipped AutomationRunStatus = "skipped"
+)
+
+// AutomationActionResult is the outcome of one action of a run
+type AutomationActionResult struct {
+	Type   AutomationActionType `json:"type"`
+	Status AutomationRunStatus  `json:"status"`
+	Error  string               `json:"error,omitempty"`
+}
+
+// AutomationRun is the execution log entry of a rule firing for a task.
+// EventID is the event that fired it, DueDate the due date that had
+// passed for due_date_passed rules.
+type AutomationRun struct {
+	ID         int64                    `json:"id"`
+	RuleID     string                   `json:"rule_id"`
+	RuleName   string                   `json:"rule_name"`
+	ProjectKey string                   `json:"project_key"`
+	TaskID     string                   `json:"task_id"`
+	EventID    *int64                   `json:"event_id,omitempty"`
+	DueDate    *time.Time               `json:"due_date,omitempty"`
+	Status     AutomationRunStatus      `json:"status"`
+	Reason     string                   `json:"reason,omitempty"`
+	Actions    []AutomationActionResult `json:"actions"`
+	CreatedAt  time.Time                `json:"created_at"`
+}
+
+// AutomationRunListResponse represents a page of a project's rule runs,
+// newest first
+type AutomationRunListResponse struct {
+	Data       []*AutomationRun   `json:"data"`
+	Pagination listing.Pagination `json:"pagination"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import "encoding/json"
+
+// BatchRequest represents independent GET requests run in one round trip
+type BatchRequest struct {
+	Requests []BatchItem `json:"requests"`
+}
+
+// BatchItem is one sub-request of a batch. Headers are added to those of
+// the batch request, e.g. If-None-Match for a cached resource.
+type BatchItem struct {
+	ID      string            `json:"id"`
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// BatchResponse holds the sub-responses in request order
+type BatchResponse struct {
+	Responses []BatchItemResponse `json:"responses"`
+}
+
+// BatchItemResponse is the outcome of one sub-request. Body is the JSON
+// response body, or a JSON string for other content types.
+type BatchItemResponse struct {
+	ID      string            `json:"id"`
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
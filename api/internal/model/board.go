@@ -0,0 +1,123 @@
+package model
+
+import (
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// BoardOptions selects what a board shows. Every status is a column, in
+// lifecycle order.
+type BoardOptions struct {
+	Project string         // only tasks of this project, every project when empty
+	Since   string         // token of an earlier board, for a delta
+	Limit   int            // tasks shown per column
+	Limits  map[Status]int // per-column overrides of Limit
+	Sort    string
+	Order   string
+}
+
+// ColumnLimit returns how many tasks the column of status shows
+func (o *BoardOptions) ColumnLimit(status Status) int {
+	if limit, ok := o.Limits[status]; ok {
+		return limit
+	}
+	return o.Limit
+}
+
+// BoardTasks is one board column as loaded from storage: its first tasks
+// and how many tasks it holds in total
+type BoardTasks struct {
+	Status Status
+	Total  int
+	Tasks  []*Task
+}
+
+// BoardResponse is the task board, one column per status. Delta responses
+// only carry the columns that changed since the token the client sent, and
+// in those only the tasks that changed.
+type BoardResponse struct {
+	Token     string         `json:"token"`
+	Delta     bool           `json:"delta"`
+	Project   string         `json:"project,omitempty"`
+	Sort      string         `json:"sort"`
+	Order     string         `json:"order"`
+	Columns   []*BoardColumn `json:"columns"`
+	Unchanged []Status       `json:"unchanged,omitempty"` // columns left out of a delta
+}
+
+// BoardColumn holds the first tasks with one status in board order, and
+// their IDs. In a delta, Tasks holds only the tasks written, moved in or
+// newly overdue since the token, while IDs still lists every task shown,
+// so clients can drop the tasks that left and reorder the rest.
+type BoardColumn struct {
+	Status Status          `json:"status"`
+	Total  int             `json:"total"`
+	Limit  int             `json:"limit"`
+	Tasks  []*TaskResponse `json:"tasks"`
+	IDs    []string        `json:"ids"`
+}
+
+// BoardToken records, for each board column, its total and a fingerprint
+// of every task shown, in board order
+type BoardToken map[Status]*ColumnToken
+
+// ColumnToken is the part of a board token for one column
+type ColumnToken struct {
+	Total int
+	Tasks []string
+}
+
+// Has reports whether the column showed a task with the fingerprint
+func (c *ColumnToken) Has(fingerprint string) bool {
+	return slices.Contains(c.Tasks, fingerprint)
+}
+
+// Equal reports whether both tokens describe the same column content
+func (c *ColumnToken) Equal(other *ColumnToken) bool {
+	return other != nil && c.Total == other.Total && slices.Equal(c.Tasks, other.Tasks)
+}
+
+// String encodes the token as an opaque URL-safe string
+func (t BoardToken) String() string {
+	parts := make([]string, 0, len(t))
+	for _, status := range Statuses() {
+		if column, ok := t[status]; ok {
+			parts = append(parts, string(status)+"="+strconv.Itoa(column.Total)+":"+strings.Join(column.Tasks, "."))
+		}
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(strings.Join(parts, ",")))
+}
+
+// ParseBoardToken decodes a token returned by a previous board response
+func ParseBoardToken(value string) (BoardToken, error) {
+	invalid := errors.New("invalid board token")
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(raw) == 0 {
+		return nil, invalid
+	}
+
+	token := make(BoardToken)
+	for _, part := range strings.Split(string(raw), ",") {
+		name, content, ok := strings.Cut(part, "=")
+		status, err := ParseStatus(name)
+		if !ok || err != nil {
+			return nil, invalid
+		}
+		total, tasks, ok := strings.Cut(content, ":")
+		column := &ColumnToken{}
+		if column.Total, err = strconv.Atoi(total); !ok || err != nil || column.Total < 0 {
+			return nil, invalid
+		}
+		if tasks != "" {
+			column.Tasks = strings.Split(tasks, ".")
+		}
+		if slices.Contains(column.Tasks, "") {
+			return nil, invalid
+		}
+		token[status] = column
+	}
+	return token, nil
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBoardToken(t *testing.T) {
+	token := BoardToken{
+		StatusPending:   {Total: 12, Tasks: []string{"0a1b2c3d", "4e5f6a7b"}},
+		StatusCompleted: {Total: 3},
+	}
+
+	parsed, err := ParseBoardToken(token.String())
+	require.NoError(t, err)
+	assert.Equal(t, token, parsed)
+
+	for _, raw := range []string{"", "pending", "pending=x:", "pending=-1:", "pending=2:a..b", "done=1:"} {
+		_, err := ParseBoardToken(base64.RawURLEncoding.EncodeToString([]byte(raw)))
+		assert.Error(t, err, raw)
+	}
+	_, err = ParseBoardToken("not a token")
+	assert.Error(t, err)
+}
@@ -0,0 +1,54 @@
+package model
+
+import "time"
+
+// ChecklistItem is one step of a task's checklist, kept in Position order
+type ChecklistItem struct {
+	ID        string    `json:"id"`
+	TaskID    string    `json:"task_id"`
+	Text      string    `json:"text"`
+	Done      bool      `json:"done"`
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateChecklistItemRequest represents the request body for adding a
+// checklist item, which goes to the end of the list
+type CreateChecklistItemRequest struct {
+	Text string `json:"text" validate:"required,min=1,max=500"`
+	Done bool   `json:"done"`
+}
+
+// UpdateChecklistItemRequest represents the request body for changing a
+// checklist item; omitted fields are left alone
+type UpdateChecklistItemRequest struct {
+	Text *string `json:"text" validate:"omitempty,min=1,max=500"`
+	Done *bool   `json:"done"`
+}
+
+// ReorderChecklistRequest lists every item of a checklist in its new order
+type ReorderChecklistRequest struct {
+	IDs []string `json:"ids" validate:"required"`
+}
+
+// ChecklistResponse represents a task's whole checklist
+type ChecklistResponse struct {
+	Data  []*ChecklistItem `json:"data"`
+	Done  int              `json:"done"`
+	Total int              `json:"total"`
+}
+
+// NewChecklistResponse builds the response for items
+func NewChecklistResponse(items []*ChecklistItem) *ChecklistResponse {
+	response := &ChecklistResponse{Data: items, Total: len(items)}
+	if response.Data == nil {
+		response.Data = []*ChecklistItem{}
+	}
+	for _, item := range items {
+		if item.Done {
+			response.Done++
+		}
+	}
+	return response
+}
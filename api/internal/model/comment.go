@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+)
+
+// Comment is a note left on a task
+type Comment struct {
+	ID        string    `json:"id"`
+	TaskID    string    `json:"task_id"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateCommentRequest represents the request body for commenting on a
+// task. The author is whoever makes the request.
+type CreateCommentRequest struct {
+	Body string `json:"body" validate:"required,min=1,max=5000"`
+}
+
+// CommentResponse represents the response for a comment
+type CommentResponse struct {
+	ID        string    `json:"id"`
+	TaskID    string    `json:"task_id"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CommentListResponse represents a page of a task's comments
+type CommentListResponse struct {
+	Data       []*CommentResponse `json:"data"`
+	Pagination listing.Pagination `json:"pagination"`
+}
+
+// ToResponse converts a Comment to CommentResponse
+func (c *Comment) ToResponse() *CommentResponse {
+	return &CommentResponse{
+		ID:        c.ID,
+		TaskID:    c.TaskID,
+		Author:    c.Author,
+		Body:      c.Body,
+		CreatedAt: c.CreatedAt.UTC(),
+	}
+}
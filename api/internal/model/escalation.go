@@ -0,0 +1,95 @@
+package model
+
+import (
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+)
+
+// EscalationTrigger is when an escalation rule fires for a task
+type EscalationTrigger string
+
+const (
+	// EscalationDueSoon fires once a task is due within the threshold
+	EscalationDueSoon EscalationTrigger = "due_soon"
+	// EscalationOverdue fires once a task is overdue by the threshold
+	EscalationOverdue EscalationTrigger = "overdue"
+)
+
+// EscalationRule escalates the open tasks of a project that are due soon
+// or overdue: it notifies the people it names and can raise the task's
+// priority. A rule fires once per task and due date.
+type EscalationRule struct {
+	ID             string            `json:"id"`
+	ProjectKey     string            `json:"project_key"`
+	Name           string            `json:"name"`
+	Trigger        EscalationTrigger `json:"trigger"`
+	ThresholdHours int               `json:"threshold_hours"`
+	NotifyOwner    bool              `json:"notify_owner"`
+	NotifyAssignee bool              `json:"notify_assignee"`
+	NotifyUsers    []string          `json:"notify_users"`
+	BumpPriority   bool              `json:"bump_priority"`
+	Enabled        bool              `json:"enabled"`
+	CreatedBy      string            `json:"created_by"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+	// Tenant is the tenant the rule was created in and runs in
+	Tenant string `json:"-"`
+}
+
+// Threshold returns the rule's threshold as a duration
+func (r *EscalationRule) Threshold() time.Duration {
+	return time.Duration(r.ThresholdHours) * time.Hour
+}
+
+// Matches reports whether a task due at due has reached the rule at now
+func (r *EscalationRule) Matches(due, now time.Time) bool {
+	switch r.Trigger {
+	case EscalationDueSoon:
+		return due.After(now) && !due.After(now.Add(r.Threshold()))
+	case EscalationOverdue:
+		return !due.After(now.Add(-r.Threshold()))
+	}
+	return false
+}
+
+// EscalationRuleRequest represents the request body for creating or
+// replacing an escalation rule. Enabled defaults to true.
+type EscalationRuleRequest struct {
+	Name           string            `json:"name" validate:"required,min=1,max=100"`
+	Trigger        EscalationTrigger `json:"trigger" validate:"required,oneof=due_soon overdue"`
+	ThresholdHours int               `json:"threshold_hours" validate:"min=0,max=8760"`
+	NotifyOwner    bool              `json:"notify_owner"`
+	NotifyAssignee bool              `json:"notify_assignee"`
+	NotifyUsers    []string          `json:"notify_users" validate:"max=20,dive,min=1,max=64"`
+	BumpPriority   bool              `json:"bump_priority"`
+	Enabled        *bool             `json:"enabled"`
+}
+
+// EscalationRuleListResponse represents a project's escalation rules,
+// oldest first
+type EscalationRuleListResponse struct {
+	Data []*EscalationRule `json:"data"`
+}
+
+// Escalation records the actions an escalation rule took on a task
+type Escalation struct {
+	ID         int64     `json:"id"`
+	RuleID     string    `json:"rule_id"`
+	RuleName   string    `json:"rule_name"`
+	ProjectKey string    `json:"project_key"`
+	TaskID     string    `json:"task_id"`
+	DueDate    time.Time `json:"due_date"`
+	Notified   []string  `json:"notified"`
+	// PriorityFrom and PriorityTo are set when the priority was raised
+	PriorityFrom *Priority `json:"priority_from,omitempty"`
+	PriorityTo   *Priority `json:"priority_to,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// EscalationListResponse represents a page of a project's escalations,
+// newest first
+type EscalationListResponse struct {
+	Data       []*Escalation      `json:"data"`
+	Pagination listing.Pagination `json:"pagination"`
+}
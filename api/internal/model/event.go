@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+)
+
+// EventType identifies what happened to a task
+type EventType string
+
+const (
+	EventTaskCreated  EventType = "task.created"
+	EventTaskUpdated  EventType = "task.updated"
+	EventTaskDeleted  EventType = "task.deleted"
+	EventTaskRestored EventType = "task.restored"
+	// EventTaskEscalated is published when an escalation rule fires
+	EventTaskEscalated EventType = "task.escalated"
+)
+
+// TaskEvent is an entry in the task change stream. IDs increase
+// monotonically and serve as resume cursors.
+type TaskEvent struct {
+	ID        int64         `json:"id"`
+	Type      EventType     `json:"type"`
+	TaskID    string        `json:"task_id"`
+	Actor     string        `json:"actor,omitempty"`
+	Task      *TaskResponse `json:"task,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+	// Tenant is the tenant of the task, set when reading the log
+	Tenant string `json:"-"`
+}
+
+// TaskEventPage is a page of the event log for replay. NextCursor is the
+// since value for the following page.
+type TaskEventPage struct {
+	Data       []*TaskEvent `json:"data"`
+	NextCursor int64        `json:"next_cursor"`
+	HasMore    bool         `json:"has_more"`
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// TaskFields are the task response fields ?fields= can select, in
+// response order. Expansions are selected with ?expand= instead.
+var TaskFields = []string{
+	"id", "ref", "title", "description", "status", "priority", "due_date", "is_overdue", "tags",
+	"recurrence", "assignee", "owner", "team", "archived", "position", "version", "created_at", "updated_at", "next_occurrence_id",
+}
+
+// ParseTaskFields converts a comma-separated list of task response fields
+// into a selection, ignoring blanks and repeats. The id is always
+// selected, since clients need it to address the task. An empty list
+// selects every field and is returned as nil.
+func ParseTaskFields(value string) ([]string, error) {
+	var fields []string
+	for _, part := range strings.Split(value, ",") {
+		field := strings.TrimSpace(part)
+		if field == "" || slices.Contains(fields, field) {
+			continue
+		}
+		if !slices.Contains(TaskFields, field) {
+			return nil, fmt.Errorf("fields must be a comma-separated list of: %s", strings.Join(TaskFields, ", "))
+		}
+		fields = append(fields, field)
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	if !slices.Contains(fields, "id") {
+		fields = append([]string{"id"}, fields...)
+	}
+	return fields, nil
+}
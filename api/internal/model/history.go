@@ -0,0 +1,113 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+)
+
+// HistoryAction names the kind of write a history entry records
+type HistoryAction string
+
+const (
+	HistoryCreated    HistoryAction = "created"
+	HistoryUpdated    HistoryAction = "updated"
+	HistoryDeleted    HistoryAction = "deleted"
+	HistoryRestored   HistoryAction = "restored"
+	HistoryArchived   HistoryAction = "archived"
+	HistoryUnarchived HistoryAction = "unarchived"
+)
+
+// ParseHistoryActions converts a comma-separated list into history actions,
+// ignoring blanks
+func ParseHistoryActions(value string) ([]HistoryAction, error) {
+	var actions []HistoryAction
+	for _, part := range strings.Split(value, ",") {
+		action := HistoryAction(strings.TrimSpace(part))
+		switch action {
+		case "":
+			continue
+		case HistoryCreated, HistoryUpdated, HistoryDeleted, HistoryRestored, HistoryArchived, HistoryUnarchived:
+			actions = append(actions, action)
+		default:
+			return nil, fmt.Errorf("action must be one of created, updated, deleted, restored, archived, unarchived")
+		}
+	}
+	return actions, nil
+}
+
+// HistoryFields are the task fields whose changes are recorded
+var HistoryFields = []string{"title", "description", "status", "priority", "due_date", "assignee"}
+
+// FieldChange holds the JSON values of a field before and after a write
+type FieldChange struct {
+	From json.RawMessage `json:"from"`
+	To   json.RawMessage `json:"to"`
+}
+
+// TaskHistoryEntry is one recorded write of a task
+type TaskHistoryEntry struct {
+	ID        int64                  `json:"id"`
+	TaskID    string                 `json:"task_id"`
+	Action    HistoryAction          `json:"action"`
+	Actor     string                 `json:"actor"`
+	Changes   map[string]FieldChange `json:"changes"`
+	Version   int64                  `json:"version"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// TaskHistoryResponse represents a page of a task's history, newest first
+type TaskHistoryResponse struct {
+	Data       []*TaskHistoryEntry `json:"data"`
+	Pagination listing.Pagination  `json:"pagination"`
+}
+
+// ActivityFilter narrows the activity feed across all tasks. From is
+// inclusive and To exclusive; nil bounds are open.
+type ActivityFilter struct {
+	Actions []HistoryAction
+	From    *time.Time
+	To      *time.Time
+}
+
+// DiffTasks returns the HistoryFields that differ between two versions of
+// a task; before may be nil for a created task
+func DiffTasks(before, after *Task) map[string]FieldChange {
+	from, to := historyValues(before), historyValues(after)
+	changes := make(map[string]FieldChange)
+	for _, field := range HistoryFields {
+		if string(from[field]) != string(to[field]) {
+			changes[field] = FieldChange{From: from[field], To: to[field]}
+		}
+	}
+	return changes
+}
+
+func historyValues(task *Task) map[string]json.RawMessage {
+	values := make(map[string]json.RawMessage, len(HistoryFields))
+	for _, field := range HistoryFields {
+		values[field] = json.RawMessage("null")
+	}
+	if task == nil {
+		return values
+	}
+
+	marshal := func(v any) json.RawMessage {
+		data, _ := json.Marshal(v)
+		return data
+	}
+	values["title"] = marshal(task.Title)
+	values["description"] = marshal(task.Description)
+	values["status"] = marshal(task.Status)
+	values["priority"] = marshal(task.Priority)
+	if task.DueDate != nil {
+		values["due_date"] = marshal(task.DueDate.UTC())
+	}
+	if task.Assignee != nil {
+		values["assignee"] = marshal(*task.Assignee)
+	}
+	return values
+}
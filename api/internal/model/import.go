@@ -0,0 +1,35 @@
+package model
+
+// ImportFormat is the file format of a task import
+type ImportFormat string
+
+const (
+	ImportCSV    ImportFormat = "csv"
+	ImportNDJSON ImportFormat = "ndjson"
+)
+
+// ImportColumns are the CSV columns a task import understands, named like
+// the fields of CreateTaskRequest. Only title is required.
+var ImportColumns = []string{"title", "description", "project", "priority", "due_date", "recurrence"}
+
+// ImportedTask is a task created by an import
+type ImportedTask struct {
+	Line int    `json:"line"`
+	ID   string `json:"id"`
+	Ref  string `json:"ref"`
+}
+
+// ImportError explains why one line of an import was not created
+type ImportError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// ImportReport summarizes a task import. Lines are numbered from 1 and
+// count the CSV header.
+type ImportReport struct {
+	Format  ImportFormat   `json:"format"`
+	Rows    int            `json:"rows"`
+	Created []ImportedTask `json:"created"`
+	Failed  []ImportError  `json:"failed"`
+}
@@ -0,0 +1,146 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// MergePatchContentType is the media type of RFC 7386 JSON merge patches
+const MergePatchContentType = "application/merge-patch+json"
+
+var (
+	ErrInvalidPatch = errors.New("invalid merge patch")
+)
+
+// readOnlyFields are task fields a patch may not touch
+var readOnlyFields = map[string]bool{
+	"id":         true,
+	"ref":        true,
+	"created_at": true,
+	"updated_at": true,
+	"is_overdue": true,
+
+	"next_occurrence_id": true,
+}
+
+// TaskMergePatch is an RFC 7386 merge patch for a task. Unlike
+// UpdateTaskRequest, a title that is present must not be empty and a null
+// description or due_date clears it. Nil fields are left unchanged.
+type TaskMergePatch struct {
+	Title       *string   `validate:"omitnil,min=1,max=255"`
+	Description *string   `validate:"omitnil,max=1000"`
+	Status      *Status   `validate:"omitnil,task_status"`
+	Priority    *Priority `validate:"omitnil,task_priority"`
+	DueDate     *time.Time
+	Recurrence  *string `validate:"omitnil,max=100,recurrence"`
+
+	// ClearDueDate is set by a null due_date
+	ClearDueDate bool
+}
+
+// ParseMergePatch parses a merge patch document. The document must be a
+// JSON object; null removes a member, which is only allowed for description,
+// due_date and recurrence.
+// Unknown and read-only members are rejected.
+func ParseMergePatch(data []byte) (*TaskMergePatch, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil || members == nil {
+		return nil, fmt.Errorf("%w: patch must be a JSON object", ErrInvalidPatch)
+	}
+
+	// Deterministic error messages when several members are invalid
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	patch := &TaskMergePatch{}
+	for _, name := range names {
+		raw := members[name]
+		null := bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+
+		switch {
+		case name == "title":
+			if null {
+				return nil, fmt.Errorf("%w: title cannot be removed", ErrInvalidPatch)
+			}
+			if err := json.Unmarshal(raw, &patch.Title); err != nil {
+				return nil, fmt.Errorf("%w: title must be a string", ErrInvalidPatch)
+			}
+		case name == "description":
+			description := ""
+			if !null {
+				if err := json.Unmarshal(raw, &description); err != nil {
+					return nil, fmt.Errorf("%w: description must be a string", ErrInvalidPatch)
+				}
+			}
+			patch.Description = &description
+		case name == "status":
+			if null {
+				return nil, fmt.Errorf("%w: status cannot be removed", ErrInvalidPatch)
+			}
+			var status Status
+			if err := json.Unmarshal(raw, &status); err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidPatch, (&InvalidStatusError{}).Error())
+			}
+			patch.Status = &status
+		case name == "priority":
+			if null {
+				return nil, fmt.Errorf("%w: priority cannot be removed", ErrInvalidPatch)
+			}
+			var priority Priority
+			if err := json.Unmarshal(raw, &priority); err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidPatch, (&InvalidPriorityError{}).Error())
+			}
+			patch.Priority = &priority
+		case name == "due_date":
+			if null {
+				patch.ClearDueDate = true
+				continue
+			}
+			var dueDate time.Time
+			if err := json.Unmarshal(raw, &dueDate); err != nil {
+				return nil, fmt.Errorf("%w: due_date must be an RFC 3339 timestamp", ErrInvalidPatch)
+			}
+			patch.DueDate = &dueDate
+		case name == "recurrence":
+			recurrence := ""
+			if !null {
+				if err := json.Unmarshal(raw, &recurrence); err != nil {
+					return nil, fmt.Errorf("%w: recurrence must be a string", ErrInvalidPatch)
+				}
+			}
+			patch.Recurrence = &recurrence
+		case readOnlyFields[name]:
+			return nil, fmt.Errorf("%w: %s is read-only", ErrInvalidPatch, name)
+		default:
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidPatch, name)
+		}
+	}
+
+	return patch, nil
+}
+
+// Empty reports whether the patch changes nothing
+func (p *TaskMergePatch) Empty() bool {
+	return p.Title == nil && p.Description == nil && p.Status == nil && p.Priority == nil &&
+		p.DueDate == nil && !p.ClearDueDate && p.Recurrence == nil
+}
+
+// ToUpdate converts the patch into the repository's partial update
+func (p *TaskMergePatch) ToUpdate() *UpdateTaskRequest {
+	return &UpdateTaskRequest{
+		Title:        p.Title,
+		Description:  p.Description,
+		Status:       p.Status,
+		Priority:     p.Priority,
+		DueDate:      p.DueDate,
+		Recurrence:   p.Recurrence,
+		ClearDueDate: p.ClearDueDate,
+	}
+}
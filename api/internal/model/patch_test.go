@@ -0,0 +1,67 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMergePatch(t *testing.T) {
+	patch, err := ParseMergePatch([]byte(`{"title": "New", "description": null, "status": "completed"}`))
+	require.NoError(t, err)
+
+	require.NotNil(t, patch.Title)
+	assert.Equal(t, "New", *patch.Title)
+	require.NotNil(t, patch.Description)
+	assert.Equal(t, "", *patch.Description)
+	require.NotNil(t, patch.Status)
+	assert.Equal(t, StatusCompleted, *patch.Status)
+
+	patch, err = ParseMergePatch([]byte(`{"priority": "urgent", "due_date": "2030-01-02T15:04:05Z"}`))
+	require.NoError(t, err)
+	assert.Equal(t, PriorityUrgent, *patch.Priority)
+	assert.Equal(t, "2030-01-02T15:04:05Z", patch.DueDate.Format(time.RFC3339))
+
+	patch, err = ParseMergePatch([]byte(`{"due_date": null}`))
+	require.NoError(t, err)
+	assert.False(t, patch.Empty())
+	assert.True(t, patch.ToUpdate().ClearDueDate)
+
+	patch, err = ParseMergePatch([]byte(`{"recurrence": null}`))
+	require.NoError(t, err)
+	require.NotNil(t, patch.ToUpdate().Recurrence)
+	assert.Equal(t, "", *patch.ToUpdate().Recurrence)
+
+	empty, err := ParseMergePatch([]byte(`{}`))
+	require.NoError(t, err)
+	assert.True(t, empty.Empty())
+}
+
+func TestParseMergePatch_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+	}{
+		{"not an object", `["title"]`},
+		{"null document", `null`},
+		{"remove title", `{"title": null}`},
+		{"remove status", `{"status": null}`},
+		{"wrong type", `{"title": 5}`},
+		{"unknown status", `{"status": "archived"}`},
+		{"read-only field", `{"id": "abc"}`},
+		{"unknown field", `{"owner": "me"}`},
+		{"remove priority", `{"priority": null}`},
+		{"unknown priority", `{"priority": "critical"}`},
+		{"due date not a timestamp", `{"due_date": "tomorrow"}`},
+		{"read-only overdue flag", `{"is_overdue": false}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseMergePatch([]byte(tt.patch))
+			assert.ErrorIs(t, err, ErrInvalidPatch)
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// BulkAction names a bulk operation that is planned before it runs
+type BulkAction string
+
+const (
+	BulkActionUpdate BulkAction = "update"
+	BulkActionDelete BulkAction = "delete"
+)
+
+// BulkPlan previews a bulk operation without changing anything. Running
+// the operation requires ConfirmToken, which is only valid for the same
+// request and only while the affected tasks stay the same.
+type BulkPlan struct {
+	Action        BulkAction `json:"action"`
+	AffectedCount int        `json:"affected_count"`
+	Affected      []string   `json:"affected"`
+	NotFound      []string   `json:"not_found"`
+	Archived      []string   `json:"archived,omitempty"`
+	Rejected      []string   `json:"rejected,omitempty"`
+	Blocked       []string   `json:"blocked,omitempty"`
+	ConfirmToken  string     `json:"confirm_token"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+}
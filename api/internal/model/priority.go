@@ -0,0 +1,154 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Priority represents how urgent a task is
+type Priority string
+
+// Task priorities, lowest first. New priorities only need to be added here
+// and to priorityRanks, and to the priority_rank column in the database.
+const (
+	PriorityLow    Priority = "low"
+	PriorityMedium Priority = "medium"
+	PriorityHigh   Priority = "high"
+	PriorityUrgent Priority = "urgent"
+)
+
+// DefaultPriority is given to tasks created without a priority
+const DefaultPriority = PriorityMedium
+
+// priorityRanks orders priorities; sorting by priority sorts by rank
+var priorityRanks = map[Priority]int{
+	PriorityLow:    1,
+	PriorityMedium: 2,
+	PriorityHigh:   3,
+	PriorityUrgent: 4,
+}
+
+// InvalidPriorityError is returned when a value is not a known priority
+type InvalidPriorityError struct {
+	Value string
+}
+
+func (e *InvalidPriorityError) Error() string {
+	return fmt.Sprintf("priority must be one of: %s", strings.Join(priorityNames(), " "))
+}
+
+// Priorities returns all known priorities, lowest first
+func Priorities() []Priority {
+	return []Priority{PriorityLow, PriorityMedium, PriorityHigh, PriorityUrgent}
+}
+
+// ParsePriority converts a string into a Priority
+func ParsePriority(value string) (Priority, error) {
+	priority := Priority(value)
+	if !priority.Valid() {
+		return "", &InvalidPriorityError{Value: value}
+	}
+	return priority, nil
+}
+
+// ParsePriorities parses a comma-separated list of priorities, as used by
+// the priority list filter
+func ParsePriorities(value string) ([]Priority, error) {
+	var priorities []Priority
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		priority, err := ParsePriority(part)
+		if err != nil {
+			return nil, err
+		}
+		priorities = append(priorities, priority)
+	}
+	return priorities, nil
+}
+
+// Valid reports whether the priority is a known priority
+func (p Priority) Valid() bool {
+	_, ok := priorityRanks[p]
+	return ok
+}
+
+// Rank returns the priority's position, higher is more urgent
+func (p Priority) Rank() int {
+	return priorityRanks[p]
+}
+
+// String returns the priority as a string
+func (p Priority) String() string {
+	return string(p)
+}
+
+// MarshalJSON encodes the priority as a JSON string
+func (p Priority) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(p))
+}
+
+// UnmarshalJSON decodes a JSON string, rejecting unknown priorities
+func (p *Priority) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	priority, err := ParsePriority(value)
+	if err != nil {
+		return err
+	}
+	*p = priority
+	return nil
+}
+
+// Scan implements sql.Scanner
+func (p *Priority) Scan(src any) error {
+	var value string
+	switch v := src.(type) {
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Priority", src)
+	}
+	priority, err := ParsePriority(value)
+	if err != nil {
+		return err
+	}
+	*p = priority
+	return nil
+}
+
+// Value implements driver.Valuer
+func (p Priority) Value() (driver.Value, error) {
+	if !p.Valid() {
+		return nil, &InvalidPriorityError{Value: string(p)}
+	}
+	return string(p), nil
+}
+
+func priorityNames() []string {
+	names := make([]string, 0, len(priorityRanks))
+	for _, priority := range Priorities() {
+		names = append(names, string(priority))
+	}
+	return names
+}
+
+// Raised returns the priority one step more urgent than p, and false when
+// p is already the most urgent
+func (p Priority) Raised() (Priority, bool) {
+	priorities := Priorities()
+	for i, priority := range priorities[:len(priorities)-1] {
+		if priority == p {
+			return priorities[i+1], true
+		}
+	}
+	return p, false
+}
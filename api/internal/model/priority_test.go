@@ -0,0 +1,40 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePriorities(t *testing.T) {
+	priorities, err := ParsePriorities("high, urgent,")
+	assert.NoError(t, err)
+	assert.Equal(t, []Priority{PriorityHigh, PriorityUrgent}, priorities)
+
+	priorities, err = ParsePriorities("")
+	assert.NoError(t, err)
+	assert.Empty(t, priorities)
+
+	_, err = ParsePriorities("high,critical")
+	var priorityErr *InvalidPriorityError
+	assert.ErrorAs(t, err, &priorityErr)
+}
+
+func TestPriority_JSON(t *testing.T) {
+	var req CreateTaskRequest
+	assert.NoError(t, json.Unmarshal([]byte(`{"title":"Ship","priority":"urgent"}`), &req))
+	assert.Equal(t, PriorityUrgent, req.Priority)
+
+	err := json.Unmarshal([]byte(`{"priority":"critical"}`), &req)
+	var priorityErr *InvalidPriorityError
+	assert.ErrorAs(t, err, &priorityErr)
+}
+
+func TestPriority_Rank(t *testing.T) {
+	previous := 0
+	for _, priority := range Priorities() {
+		assert.Greater(t, priority.Rank(), previous)
+		previous = priority.Rank()
+	}
+}
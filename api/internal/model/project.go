@@ -0,0 +1,50 @@
+package model
+
+import "time"
+
+// Project groups tasks under a key, which prefixes their references
+// (PROJ-123) and never changes. Creating a task in a project that does
+// not exist yet creates the project, named after its key. Keys are unique
+// within a tenant.
+type Project struct {
+	Key         string    `json:"key"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// Tenant is the tenant the project was created in
+	Tenant string `json:"-"`
+}
+
+// CreateProjectRequest represents the request body for creating a project
+type CreateProjectRequest struct {
+	Key         string `json:"key" validate:"required,project_key"`
+	Name        string `json:"name" validate:"required,min=1,max=255"`
+	Description string `json:"description" validate:"max=1000"`
+}
+
+// UpdateProjectRequest represents the request body for updating a project
+type UpdateProjectRequest struct {
+	Name        *string `json:"name" validate:"omitempty,min=1,max=255"`
+	Description *string `json:"description" validate:"omitempty,max=1000"`
+}
+
+// ProjectResponse represents the response for a project
+type ProjectResponse struct {
+	Key         string    `json:"key"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ToResponse converts a Project to ProjectResponse
+func (p *Project) ToResponse() *ProjectResponse {
+	return &ProjectResponse{
+		Key:         p.Key,
+		Name:        p.Name,
+		Description: p.Description,
+		CreatedAt:   p.CreatedAt.UTC(),
+		UpdatedAt:   p.UpdatedAt.UTC(),
+	}
+}
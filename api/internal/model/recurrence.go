@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// ValidRecurrence reports whether spec is a standard five-field cron
+// expression or a descriptor such as @daily, optionally prefixed with
+// CRON_TZ=<zone>
+func ValidRecurrence(spec string) bool {
+	_, err := cron.ParseStandard(spec)
+	return err == nil
+}
+
+// NextOccurrence returns when a task recurring on spec is next due: the
+// first time the schedule fires after both the previous due date and now,
+// so occurrences missed while the task was open are skipped
+func NextOccurrence(spec string, previous *time.Time, now time.Time) (time.Time, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	after := now
+	if previous != nil && previous.After(now) {
+		after = *previous
+	}
+	return schedule.Next(after.UTC()).UTC(), nil
+}
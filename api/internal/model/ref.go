@@ -0,0 +1,81 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidRef = errors.New("invalid task reference")
+
+var (
+	// refPattern matches references such as PROJ-123
+	refPattern = regexp.MustCompile(`^([A-Z][A-Z0-9]{1,15})-([1-9][0-9]*)$`)
+
+	// embeddedRefPattern finds references inside free text such as commit messages
+	embeddedRefPattern = regexp.MustCompile(`\b([A-Z][A-Z0-9]{1,15})-([1-9][0-9]{0,17})\b`)
+
+	// projectKeyPattern matches project keys such as PROJ
+	projectKeyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]{1,15}$`)
+)
+
+// ValidProjectKey reports whether key is a valid project key
+func ValidProjectKey(key string) bool {
+	return projectKeyPattern.MatchString(key)
+}
+
+// Ref is a human-friendly task reference made of a project key and a
+// per-project sequential number, e.g. PROJ-123
+type Ref struct {
+	ProjectKey string
+	Number     int64
+}
+
+// ParseRef parses a reference such as PROJ-123 (case-insensitive)
+func ParseRef(value string) (Ref, error) {
+	matches := refPattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(value)))
+	if matches == nil {
+		return Ref{}, ErrInvalidRef
+	}
+
+	number, err := strconv.ParseInt(matches[2], 10, 64)
+	if err != nil {
+		return Ref{}, ErrInvalidRef
+	}
+
+	return Ref{ProjectKey: matches[1], Number: number}, nil
+}
+
+// String formats the reference as PROJ-123
+func (r Ref) String() string {
+	return fmt.Sprintf("%s-%d", r.ProjectKey, r.Number)
+}
+
+// ExtractRefs finds unique task references in arbitrary text, in order of
+// first appearance, returning at most limit references
+func ExtractRefs(text string, limit int) []Ref {
+	seen := make(map[Ref]bool)
+	var refs []Ref
+
+	for _, matches := range embeddedRefPattern.FindAllStringSubmatch(text, -1) {
+		number, err := strconv.ParseInt(matches[2], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		ref := Ref{ProjectKey: matches[1], Number: number}
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+
+		refs = append(refs, ref)
+		if len(refs) == limit {
+			break
+		}
+	}
+
+	return refs
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRef(t *testing.T) {
+	ref, err := ParseRef("proj-42")
+	assert.NoError(t, err)
+	assert.Equal(t, Ref{ProjectKey: "PROJ", Number: 42}, ref)
+	assert.Equal(t, "PROJ-42", ref.String())
+
+	for _, invalid := range []string{"", "PROJ", "PROJ-0", "1PROJ-3", "PROJ-x"} {
+		_, err := ParseRef(invalid)
+		assert.ErrorIs(t, err, ErrInvalidRef, invalid)
+	}
+}
+
+func TestExtractRefs(t *testing.T) {
+	text := "Fix PROJ-12 and OPS-7 (follow-up to PROJ-12), see utf-8 docs"
+
+	refs := ExtractRefs(text, 10)
+	assert.Equal(t, []Ref{{"PROJ", 12}, {"OPS", 7}}, refs)
+
+	assert.Len(t, ExtractRefs(text, 1), 1)
+}
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+)
+
+// SecurityEventType names the kind of authentication event recorded
+type SecurityEventType string
+
+const (
+	SecurityLoginSucceeded   SecurityEventType = "login_succeeded"
+	SecurityLoginFailed      SecurityEventType = "login_failed"
+	SecurityTokenIssued      SecurityEventType = "token_issued"
+	SecurityTokenRevoked     SecurityEventType = "token_revoked"
+	SecurityTokenUsed        SecurityEventType = "token_used"
+	SecurityPermissionDenied SecurityEventType = "permission_denied"
+	SecurityAbuseDetected    SecurityEventType = "abuse_detected"
+)
+
+// SecurityEventTypes lists every security event type
+var SecurityEventTypes = []SecurityEventType{
+	SecurityLoginSucceeded, SecurityLoginFailed, SecurityTokenIssued,
+	SecurityTokenRevoked, SecurityTokenUsed, SecurityPermissionDenied,
+	SecurityAbuseDetected,
+}
+
+// ParseSecurityEventTypes converts a comma-separated list into security
+// event types, ignoring blanks
+func ParseSecurityEventTypes(value string) ([]SecurityEventType, error) {
+	var types []SecurityEventType
+	for _, part := range strings.Split(value, ",") {
+		eventType := SecurityEventType(strings.TrimSpace(part))
+		switch eventType {
+		case "":
+			continue
+		case SecurityLoginSucceeded, SecurityLoginFailed, SecurityTokenIssued,
+			SecurityTokenRevoked, SecurityTokenUsed, SecurityPermissionDenied,
+			SecurityAbuseDetected:
+			types = append(types, eventType)
+		default:
+			return nil, fmt.Errorf("type must be one of login_succeeded, login_failed, token_issued, token_revoked, token_used, permission_denied, abuse_detected")
+		}
+	}
+	return types, nil
+}
+
+// Credentials a request can authenticate with
+const (
+	CredentialAPIToken   = "api_token"
+	CredentialAdminToken = "admin_token"
+	CredentialProxy      = "proxy_header"
+	CredentialPassword   = "password"
+	CredentialSession    = "session_token"
+	CredentialRefresh    = "refresh_token"
+	CredentialOIDC       = "oidc"
+)
+
+// SecurityEvent is one recorded authentication or authorization event.
+// User is empty when the caller could not be identified.
+type SecurityEvent struct {
+	ID         int64             `json:"id"`
+	Type       SecurityEventType `json:"type"`
+	User       string            `json:"user"`
+	Credential string            `json:"credential,omitempty"`
+	TokenID    string            `json:"token_id,omitempty"`
+	Reason     string            `json:"reason,omitempty"`
+	IP         string            `json:"ip"`
+	Method     string            `json:"method"`
+	Path       string            `json:"path"`
+	RequestID  string            `json:"request_id,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// SecurityEventFilter narrows a security event listing; zero values match
+// everything
+type SecurityEventFilter struct {
+	Types []SecurityEventType
+	User  string
+	From  *time.Time
+	To    *time.Time
+}
+
+// SecurityEventListResponse represents a page of security events, newest first
+type SecurityEventListResponse struct {
+	Data       []*SecurityEvent   `json:"data"`
+	Pagination listing.Pagination `json:"pagination"`
+}
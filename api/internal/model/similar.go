@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// SimilarTask is a task close in meaning to another one, by the cosine
+// similarity of the embeddings of their titles and descriptions
+type SimilarTask struct {
+	Task       *TaskResponse `json:"task"`
+	Similarity float64       `json:"similarity"`
+}
+
+// SimilarTaskListResponse represents the response body of GET
+// /tasks/{id}/similar, most similar first
+type SimilarTaskListResponse struct {
+	Data  []*SimilarTask `json:"data"`
+	Model string         `json:"model"`
+}
+
+// TaskEmbedding is the embedding of a task's title and description
+type TaskEmbedding struct {
+	TaskID        string
+	Model         string
+	Vector        []float32
+	ContentHash   string    // hash of the text embedded
+	TaskUpdatedAt time.Time // the task version it was made for
+}
+
+// EmbeddingDue is a task whose embedding is missing or older than the
+// task. ContentHash is that of the text last embedded for the task with
+// the same model, empty if none.
+type EmbeddingDue struct {
+	Task        *Task
+	ContentHash string
+}
+
+// TaskMatch is a task and how similar it is to the one compared with
+type TaskMatch struct {
+	Task       *Task
+	Similarity float64
+}
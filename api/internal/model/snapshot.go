@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+// SnapshotFormatVersion is the version of the snapshot document layout,
+// raised whenever a change would stop older code from restoring it
+const SnapshotFormatVersion = 1
+
+// Snapshot describes a point-in-time copy of a project's tasks kept in
+// object storage
+type Snapshot struct {
+	ID            string    `json:"id"` // UUIDv7, so snapshots sort by time
+	Project       string    `json:"project"`
+	FormatVersion int       `json:"format_version"`
+	TaskCount     int       `json:"task_count"`
+	Size          int64     `json:"size"`   // bytes of the snapshot document
+	SHA256        string    `json:"sha256"` // of the snapshot document
+	CreatedBy     string    `json:"created_by"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// SnapshotTask is a task as captured by a snapshot
+type SnapshotTask struct {
+	ID          string     `json:"id"`
+	Ref         string     `json:"ref"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Status      Status     `json:"status"`
+	Priority    Priority   `json:"priority"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	Recurrence  string     `json:"recurrence,omitempty"`
+	Archived    bool       `json:"archived"`
+}
+
+// SnapshotDocument is a whole snapshot: its description and the tasks of
+// the project in number order
+type SnapshotDocument struct {
+	ID            string         `json:"id"`
+	Project       string         `json:"project"`
+	FormatVersion int            `json:"format_version"`
+	CreatedBy     string         `json:"created_by"`
+	CreatedAt     time.Time      `json:"created_at"`
+	Tasks         []SnapshotTask `json:"tasks"`
+}
+
+// SnapshotListResponse represents a project's snapshots, newest first
+type SnapshotListResponse struct {
+	Data []*Snapshot `json:"data"`
+}
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// TaskStatsFilter selects the tasks summarized by GET /tasks/stats
+type TaskStatsFilter struct {
+	// Days is how many days, today included, created_per_day covers
+	Days            int
+	IncludeArchived bool
+	Project         string // project key, all projects when empty
+}
+
+// DailyCount is the number of tasks created on one UTC day
+type DailyCount struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
+
+// TaskStats summarizes tasks that are not deleted. AvgCompletionSeconds
+// is nil until a task has been completed.
+type TaskStats struct {
+	Total                int            `json:"total"`
+	ByStatus             map[Status]int `json:"by_status"`
+	Overdue              int            `json:"overdue"`
+	CreatedPerDay        []DailyCount   `json:"created_per_day"`
+	AvgCompletionSeconds *float64       `json:"avg_completion_seconds"`
+	IncludeArchived      bool           `json:"include_archived"`
+	Project              string         `json:"project,omitempty"`
+	ComputedAt           time.Time      `json:"computed_at"`
+}
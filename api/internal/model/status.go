@@ -0,0 +1,137 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Status represents the lifecycle state of a task
+type Status string
+
+// Task statuses. New statuses only need to be added here and to transitions.
+const (
+	StatusPending    Status = "pending"
+	StatusInProgress Status = "in_progress"
+	StatusCompleted  Status = "completed"
+	StatusCancelled  Status = "cancelled"
+)
+
+// transitions lists the statuses each status may move to by default:
+// work starts before it completes, open tasks can be cancelled, and
+// closed tasks can be reopened
+var transitions = map[Status][]Status{
+	StatusPending:    {StatusInProgress, StatusCancelled},
+	StatusInProgress: {StatusPending, StatusCompleted, StatusCancelled},
+	StatusCompleted:  {StatusInProgress},
+	StatusCancelled:  {StatusPending},
+}
+
+// InvalidStatusError is returned when a value is not a known status
+type InvalidStatusError struct {
+	Value string
+}
+
+func (e *InvalidStatusError) Error() string {
+	return fmt.Sprintf("status must be one of: %s", strings.Join(statusNames(), " "))
+}
+
+// Statuses returns all known statuses in lifecycle order
+func Statuses() []Status {
+	return []Status{StatusPending, StatusInProgress, StatusCompleted, StatusCancelled}
+}
+
+// ParseStatus converts a string into a Status
+func ParseStatus(value string) (Status, error) {
+	status := Status(value)
+	if !status.Valid() {
+		return "", &InvalidStatusError{Value: value}
+	}
+	return status, nil
+}
+
+// Valid reports whether the status is a known status
+func (s Status) Valid() bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+// String returns the status as a string
+func (s Status) String() string {
+	return string(s)
+}
+
+// Closed reports whether the status ends the task's lifecycle
+func (s Status) Closed() bool {
+	return s == StatusCompleted || s == StatusCancelled
+}
+
+// AllowedTransitions returns the statuses this status may move to
+func (s Status) AllowedTransitions() []Status {
+	return transitions[s]
+}
+
+// CanTransitionTo reports whether moving to next is allowed
+func (s Status) CanTransitionTo(next Status) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalJSON encodes the status as a JSON string
+func (s Status) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(s))
+}
+
+// UnmarshalJSON decodes a JSON string, rejecting unknown statuses
+func (s *Status) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	status, err := ParseStatus(value)
+	if err != nil {
+		return err
+	}
+	*s = status
+	return nil
+}
+
+// Scan implements sql.Scanner
+func (s *Status) Scan(src any) error {
+	var value string
+	switch v := src.(type) {
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Status", src)
+	}
+	status, err := ParseStatus(value)
+	if err != nil {
+		return err
+	}
+	*s = status
+	return nil
+}
+
+// Value implements driver.Valuer
+func (s Status) Value() (driver.Value, error) {
+	if !s.Valid() {
+		return nil, &InvalidStatusError{Value: string(s)}
+	}
+	return string(s), nil
+}
+
+func statusNames() []string {
+	names := make([]string, 0, len(transitions))
+	for _, status := range Statuses() {
+		names = append(names, string(status))
+	}
+	return names
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStatus(t *testing.T) {
+	for _, status := range Statuses() {
+		parsed, err := ParseStatus(string(status))
+		assert.NoError(t, err)
+		assert.Equal(t, status, parsed)
+	}
+
+	_, err := ParseStatus("archived")
+	assert.Error(t, err)
+}
+
+func TestStatus_JSON(t *testing.T) {
+	var req UpdateTaskRequest
+	assert.NoError(t, json.Unmarshal([]byte(`{"status":"in_progress"}`), &req))
+	assert.Equal(t, StatusInProgress, *req.Status)
+
+	err := json.Unmarshal([]byte(`{"status":"done"}`), &req)
+	var statusErr *InvalidStatusError
+	assert.ErrorAs(t, err, &statusErr)
+}
+
+func TestStatus_Transitions(t *testing.T) {
+	assert.True(t, StatusPending.CanTransitionTo(StatusInProgress))
+	assert.True(t, StatusCompleted.CanTransitionTo(StatusInProgress))
+	assert.False(t, StatusCompleted.CanTransitionTo(StatusPending))
+	assert.False(t, StatusPending.CanTransitionTo(StatusPending))
+	assert.False(t, StatusPending.CanTransitionTo(StatusCompleted))
+	assert.True(t, StatusInProgress.CanTransitionTo(StatusCancelled))
+	assert.True(t, StatusCancelled.CanTransitionTo(StatusPending))
+}
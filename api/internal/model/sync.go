@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// SyncTask declares one task of a project synced from a file. Tasks are
+// matched by title, so titles must be unique within a sync request.
+// Omitted fields take their defaults, except Status: an omitted status
+// leaves the task's current status alone.
+type SyncTask struct {
+	Title       string     `json:"title" validate:"required,min=1,max=255"`
+	Description string     `json:"description" validate:"max=1000"`
+	Status      Status     `json:"status" validate:"omitempty,task_status"`
+	Priority    Priority   `json:"priority" validate:"omitempty,task_priority"`
+	DueDate     *time.Time `json:"due_date"`
+	Recurrence  string     `json:"recurrence" validate:"omitempty,max=100,recurrence"`
+}
+
+// SyncRequest is the full set of tasks a project should have. An empty
+// list deletes every task of the project.
+type SyncRequest struct {
+	Tasks []SyncTask `json:"tasks" validate:"required,dive"`
+}
+
+// SyncChange is one task a sync created, updated or deleted
+type SyncChange struct {
+	ID     string   `json:"id,omitempty"` // empty for tasks a dry run would create
+	Ref    string   `json:"ref,omitempty"`
+	Title  string   `json:"title"`
+	Fields []string `json:"fields,omitempty"` // fields an update changed
+}
+
+// SyncResult is the diff a sync applied, or would apply on a dry run.
+// Archived tasks are read-only and listed under Skipped; tasks kept by
+// their comments are listed under Blocked.
+type SyncResult struct {
+	Project   string       `json:"project"`
+	DryRun    bool         `json:"dry_run"`
+	Created   []SyncChange `json:"created"`
+	Updated   []SyncChange `json:"updated"`
+	Deleted   []SyncChange `json:"deleted"`
+	Blocked   []SyncChange `json:"blocked,omitempty"`
+	Skipped   []SyncChange `json:"skipped,omitempty"`
+	Unchanged int          `json:"unchanged"`
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// tagNamePattern matches tag names such as backend or needs-review
+var tagNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// ValidTagName reports whether name is a valid tag name
+func ValidTagName(name string) bool {
+	return tagNamePattern.MatchString(name)
+}
+
+// ParseTagNames parses a comma-separated list of tag names, as used by
+// the tag list filter. Names are lowercased and duplicates dropped.
+func ParseTagNames(value string) ([]string, error) {
+	var names []string
+	for _, part := range strings.Split(value, ",") {
+		name := strings.ToLower(strings.TrimSpace(part))
+		if name == "" {
+			continue
+		}
+		if !ValidTagName(name) {
+			return nil, fmt.Errorf("tag %q is invalid, tag names must be 1-32 lowercase letters, digits, - or _, starting with a letter or digit", part)
+		}
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// Tag is a label that can be attached to any number of tasks
+type Tag struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateTagRequest represents the request body for creating a tag
+type CreateTagRequest struct {
+	Name  string `json:"name" validate:"required,tag_name"`
+	Color string `json:"color" validate:"omitempty,hexcolor"`
+}
+
+// UpdateTagRequest represents the request body for updating a tag
+type UpdateTagRequest struct {
+	Name  *string `json:"name" validate:"omitempty,tag_name"`
+	Color *string `json:"color" validate:"omitempty,hexcolor"`
+}
+
+// AttachTagsRequest represents the request body for tagging a task
+type AttachTagsRequest struct {
+	Tags []string `json:"tags" validate:"required,min=1,dive,tag_name"`
+}
+
+// TagResponse represents the response for a tag
+type TagResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ToResponse converts a Tag to TagResponse
+func (t *Tag) ToResponse() *TagResponse {
+	return &TagResponse{
+		ID:        t.ID,
+		Name:      t.Name,
+		Color:     t.Color,
+		CreatedAt: t.CreatedAt.UTC(),
+		UpdatedAt: t.UpdatedAt.UTC(),
+	}
+}
@@ -2,49 +2,224 @@ package model
 
 import (
 	"time"
+
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
 )
 
 // Task represents a task entity in the system
 type Task struct {
-	ID          string    `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string     `json:"id"`
+	ProjectKey  string     `json:"project_key"`
+	Number      int64      `json:"number"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Status      Status     `json:"status"`
+	Priority    Priority   `json:"priority"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	Tags        []string   `json:"tags"`                 // tag names, sorted
+	Recurrence  *string    `json:"recurrence,omitempty"` // cron expression, nil for one-off tasks
+	Assignee    *string    `json:"assignee,omitempty"`   // user id, nil when unassigned
+	Owner       *string    `json:"owner,omitempty"`      // user id of the creator, nil when created anonymously
+	Team        *string    `json:"team,omitempty"`       // team id the task is shared with, nil when not shared
+	Archived    bool       `json:"archived"`
+	Position    int64      `json:"position"` // manual order, lowest first
+	Version     int64      `json:"version"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"` // set while the task is soft-deleted
+
+	// NextOccurrenceID is set once a completed recurring task's next
+	// occurrence has been created
+	NextOccurrenceID *string `json:"next_occurrence_id,omitempty"`
 }
 
 // CreateTaskRequest represents the request body for creating a task
 type CreateTaskRequest struct {
-	Title       string `json:"title" validate:"required,min=1,max=255"`
-	Description string `json:"description" validate:"max=1000"`
+	Title       string     `json:"title" validate:"required,min=1,max=255"`
+	Description string     `json:"description" validate:"max=1000"`
+	Project     string     `json:"project" validate:"omitempty,project_key"`
+	Priority    Priority   `json:"priority" validate:"omitempty,task_priority"`
+	DueDate     *time.Time `json:"due_date"`
+	Recurrence  string     `json:"recurrence" validate:"omitempty,max=100,recurrence"`
 }
 
 // UpdateTaskRequest represents the request body for updating a task
 type UpdateTaskRequest struct {
-	Title       *string `json:"title" validate:"omitempty,min=1,max=255"`
-	Description *string `json:"description" validate:"omitempty,max=1000"`
-	Status      *string `json:"status" validate:"omitempty,oneof=pending in_progress completed"`
+	Title       *string    `json:"title" validate:"omitempty,min=1,max=255"`
+	Description *string    `json:"description" validate:"omitempty,max=1000"`
+	Status      *Status    `json:"status" validate:"omitempty,task_status"`
+	Priority    *Priority  `json:"priority" validate:"omitempty,task_priority"`
+	DueDate     *time.Time `json:"due_date"`
+
+	// Recurrence replaces the cron expression; an empty string stops the
+	// task recurring
+	Recurrence *string `json:"recurrence" validate:"omitnil,max=100,recurrence"`
+
+	// ClearDueDate removes the due date; only merge patches can set it
+	ClearDueDate bool `json:"-"`
+
+	// FromStatuses limits the update to tasks currently in one of these
+	// statuses; the service sets it to enforce status transitions
+	FromStatuses []Status `json:"-"`
+}
+
+// AssigneeMe stands for the authenticated caller wherever an assignee is expected
+const AssigneeMe = "me"
+
+// AssignTaskRequest represents the request body for assigning a task
+type AssignTaskRequest struct {
+	// Assignee is a user id, or "me" for the caller
+	Assignee string `json:"assignee" validate:"required,max=255"`
+}
+
+// MoveTaskRequest represents the request body for moving a task in the
+// manual order; exactly one of Before and After names the task to move
+// next to
+type MoveTaskRequest struct {
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// DuplicateOptions selects what a duplicate copies besides the title,
+// description, project and priority
+type DuplicateOptions struct {
+	Tags       bool
+	DueDate    bool
+	Recurrence bool
+	Owner      *string // owner of the copy, the caller, nil when anonymous
+}
+
+// BulkUpdateRequest represents the request body for applying the same
+// changes to several tasks
+type BulkUpdateRequest struct {
+	IDs          []string          `json:"ids" validate:"required,min=1"`
+	Changes      UpdateTaskRequest `json:"changes"`
+	ConfirmToken string            `json:"confirm_token,omitempty"`
+}
+
+// BulkDeleteRequest represents the request body for soft-deleting several tasks
+type BulkDeleteRequest struct {
+	IDs          []string `json:"ids" validate:"required,min=1"`
+	ConfirmToken string   `json:"confirm_token,omitempty"`
+}
+
+// BulkUpdateResponse reports the outcome of a bulk update
+type BulkUpdateResponse struct {
+	Updated  []*TaskResponse `json:"updated"`
+	NotFound []string        `json:"not_found"`
+	Rejected []string        `json:"rejected,omitempty"` // tasks whose status may not move to the requested one
+	Archived []string        `json:"archived,omitempty"` // archived tasks, which are read-only
+}
+
+// BulkDeleteResponse reports the outcome of a bulk delete
+type BulkDeleteResponse struct {
+	Deleted  []string `json:"deleted"`
+	NotFound []string `json:"not_found"`
+	Blocked  []string `json:"blocked,omitempty"`  // tasks kept because they have comments
+	Archived []string `json:"archived,omitempty"` // archived tasks, which are read-only
+}
+
+// ListOptions represents the query parameters accepted by list endpoints
+type ListOptions struct {
+	listing.Params
+
+	Search     string     // q: title prefix to match
+	Priorities []Priority // priority: comma-separated priorities to include, all when empty
+	Overdue    bool       // overdue: only open tasks past their due date
+	Tags       []string   // tag: comma-separated tag names a task must all carry
+	Assignee   string     // assignee: user the tasks are assigned to, "me" for the caller
+	Project    string     // project: key of the project the tasks belong to
+	Cursor     string     // cursor: opaque position from a previous page's next_cursor
+	Fields     []string   // fields: task response fields to return, all when empty
+
+	IncludeArchived bool // include_archived: also list archived tasks
+}
+
+// TaskListResponse represents a page of tasks
+type TaskListResponse struct {
+	Data       []*TaskResponse    `json:"data"`
+	Pagination listing.Pagination `json:"pagination"`
 }
 
 // TaskResponse represents the response for a task
 type TaskResponse struct {
-	ID          string    `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string     `json:"id"`
+	Ref         string     `json:"ref"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Status      Status     `json:"status"`
+	Priority    Priority   `json:"priority,omitempty"`
+	DueDate     *time.Time `json:"due_date"`
+	IsOverdue   bool       `json:"is_overdue"`
+	Tags        []string   `json:"tags"`
+	Recurrence  *string    `json:"recurrence"`
+	Assignee    *string    `json:"assignee"`
+	Owner       *string    `json:"owner"`
+	Team        *string    `json:"team"`
+	Archived    bool       `json:"archived"`
+	Position    int64      `json:"position"`
+	Version     int64      `json:"version"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	NextOccurrenceID *string `json:"next_occurrence_id"`
+
+	// Checklist, Comments, Watchers and TagDetails are only set when
+	// expanded, e.g. with ?expand=checklist
+	Checklist  []*ChecklistItem   `json:"checklist,omitempty"`
+	Comments   []*CommentResponse `json:"comments,omitempty"`
+	Watchers   []string           `json:"watchers,omitempty"`
+	TagDetails []*TagResponse     `json:"tag_details,omitempty"`
+
+	// Duplicates is only set in the response to POST /tasks, when
+	// duplicate detection is on
+	Duplicates []*SimilarTask `json:"duplicates,omitempty"`
+}
+
+// Ref returns the task's human-friendly reference
+func (t *Task) Ref() Ref {
+	return Ref{ProjectKey: t.ProjectKey, Number: t.Number}
+}
+
+// Overdue reports whether a task with this due date and status is past
+// due at now. Completed and cancelled tasks are never overdue.
+func Overdue(dueDate *time.Time, status Status, now time.Time) bool {
+	return dueDate != nil && !status.Closed() && dueDate.Before(now)
 }
 
 // ToResponse converts a Task to TaskResponse
 func (t *Task) ToResponse() *TaskResponse {
+	var dueDate *time.Time
+	if t.DueDate != nil {
+		utc := t.DueDate.UTC()
+		dueDate = &utc
+	}
+
+	tags := t.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
 	return &TaskResponse{
 		ID:          t.ID,
+		Ref:         t.Ref().String(),
 		Title:       t.Title,
 		Description: t.Description,
 		Status:      t.Status,
-		CreatedAt:   t.CreatedAt,
-		UpdatedAt:   t.UpdatedAt,
+		Priority:    t.Priority,
+		DueDate:     dueDate,
+		IsOverdue:   Overdue(t.DueDate, t.Status, time.Now()),
+		Tags:        tags,
+		Recurrence:  t.Recurrence,
+		Assignee:    t.Assignee,
+		Owner:       t.Owner,
+		Team:        t.Team,
+		Archived:    t.Archived,
+		Position:    t.Position,
+		Version:     t.Version,
+		CreatedAt:   t.CreatedAt.UTC(),
+		UpdatedAt:   t.UpdatedAt.UTC(),
+
+		NextOccurrenceID: t.NextOccurrenceID,
 	}
 }
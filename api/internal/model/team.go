@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+// Team is a group of users sharing tasks. A task assigned to a team can be
+// seen and edited by all of its members, as well as by its owner.
+type Team struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	Members   []string  `json:"members"` // user ids, sorted
+}
+
+// CreateTeamRequest represents the request body for creating a team
+type CreateTeamRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=100"`
+}
+
+// AssignTeamRequest represents the request body for sharing a task with a team
+type AssignTeamRequest struct {
+	Team string `json:"team"` // id of a team the caller belongs to
+}
+
+// TeamResponse represents the response for a team
+type TeamResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	Members   []string  `json:"members"`
+}
+
+// ToResponse converts a Team to TeamResponse
+func (t *Team) ToResponse() *TeamResponse {
+	members := t.Members
+	if members == nil {
+		members = []string{}
+	}
+	return &TeamResponse{
+		ID:        t.ID,
+		Name:      t.Name,
+		CreatedBy: t.CreatedBy,
+		CreatedAt: t.CreatedAt.UTC(),
+		Members:   members,
+	}
+}
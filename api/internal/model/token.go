@@ -0,0 +1,61 @@
+package model
+
+import "time"
+
+// APIToken is a personal access token. Only a hash of the secret is
+// stored; Prefix identifies the token in listings.
+type APIToken struct {
+	ID         string
+	User       string
+	Name       string
+	Prefix     string
+	Hash       string
+	Scopes     []string
+	ExpiresAt  time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+	Tenant     string // tenant the token was created in and is bound to
+}
+
+// CreateTokenRequest represents the request body for creating a token
+type CreateTokenRequest struct {
+	Name      string     `json:"name" validate:"required,min=1,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// TokenResponse represents a token without its secret
+type TokenResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreatedTokenResponse includes the secret, which is only ever returned
+// when the token is created
+type CreatedTokenResponse struct {
+	*TokenResponse
+	Token string `json:"token"`
+}
+
+// TokenListResponse represents a user's tokens, newest first
+type TokenListResponse struct {
+	Data []*TokenResponse `json:"data"`
+}
+
+// ToResponse converts an APIToken to TokenResponse
+func (t *APIToken) ToResponse() *TokenResponse {
+	return &TokenResponse{
+		ID:         t.ID,
+		Name:       t.Name,
+		Prefix:     t.Prefix,
+		Scopes:     t.Scopes,
+		ExpiresAt:  t.ExpiresAt.UTC(),
+		LastUsedAt: t.LastUsedAt,
+		CreatedAt:  t.CreatedAt.UTC(),
+	}
+}
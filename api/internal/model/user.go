@@ -0,0 +1,132 @@
+package model
+
+import (
+	"regexp"
+	"slices"
+	"time"
+)
+
+// usernamePattern matches usernames such as alice or j.doe
+var usernamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{2,63}$`)
+
+// reservedUsernames can never be registered: admin is the admin token's
+// user, the others have a meaning of their own in the API
+var reservedUsernames = []string{"admin", "anonymous", "me", "system"}
+
+// ValidUsername reports whether name can be registered
+func ValidUsername(name string) bool {
+	return usernamePattern.MatchString(name) && !slices.Contains(reservedUsernames, name)
+}
+
+// RegisterRequest represents the request body for POST /auth/register
+type RegisterRequest struct {
+	Username string `json:"username" validate:"required,username"`
+	// bcrypt only reads the first 72 bytes of a password
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+// LoginRequest represents the request body for POST /auth/login
+type LoginRequest struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// UserResponse represents a registered user
+type UserResponse struct {
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RefreshRequest represents the request body for POST /auth/refresh and
+// POST /auth/logout
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// SessionResponse carries the tokens issued by a login or refresh
+type SessionResponse struct {
+	AccessToken      string    `json:"access_token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresIn        int64     `json:"expires_in"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// RefreshToken is a long-lived token traded for a new session token.
+// Only a hash of the secret is stored. Each refresh uses the token up and
+// issues the next one in the same family, which starts at a login.
+type RefreshToken struct {
+	ID        string
+	User      string
+	FamilyID  string
+	Hash      string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// Session is a sign-in, from a login or an OIDC callback until it expires
+// with its refresh tokens or is revoked. Its ID is the refresh token
+// family's and is carried by every session token of the sign-in.
+type Session struct {
+	ID         string
+	User       string
+	UserAgent  string
+	IP         string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+}
+
+// SessionInfo represents one of the caller's sessions
+type SessionInfo struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// Current marks the session the request was made with
+	Current bool `json:"current"`
+}
+
+// SessionListResponse represents a user's active sessions, most recently
+// seen first
+type SessionListResponse struct {
+	Data []*SessionInfo `json:"data"`
+}
+
+// ToInfo converts a Session to SessionInfo, current when it is the
+// session named current
+func (s *Session) ToInfo(current string) *SessionInfo {
+	return &SessionInfo{
+		ID:         s.ID,
+		UserAgent:  s.UserAgent,
+		IP:         s.IP,
+		CreatedAt:  s.CreatedAt.UTC(),
+		LastSeenAt: s.LastSeenAt.UTC(),
+		ExpiresAt:  s.ExpiresAt.UTC(),
+		Current:    s.ID == current,
+	}
+}
+
+// SessionsRevokedResponse reports how many sessions DELETE /auth/sessions
+// signed out
+type SessionsRevokedResponse struct {
+	Revoked int `json:"revoked"`
+}
+
+// TenantMember represents a user allowed to sign in to a tenant
+type TenantMember struct {
+	Tenant    string    `json:"tenant"`
+	User      string    `json:"user"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TenantMemberListResponse represents the members of a tenant, by name
+type TenantMemberListResponse struct {
+	Data []*TenantMember `json:"data"`
+}
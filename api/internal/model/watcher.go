@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+)
+
+// Watcher is a user following the changes to a task
+type Watcher struct {
+	TaskID    string    `json:"task_id"`
+	User      string    `json:"user"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WatcherListResponse lists the watchers of a task
+type WatcherListResponse struct {
+	Data []*Watcher `json:"data"`
+}
+
+// WatchResponse reports whether the caller watches a task
+type WatchResponse struct {
+	TaskID   string `json:"task_id"`
+	Watching bool   `json:"watching"`
+	Watchers int    `json:"watchers"`
+}
+
+// Notification tells a watcher about a change to a task. EventID points
+// at the task event it was fanned out from.
+type Notification struct {
+	ID        int64      `json:"id"`
+	User      string     `json:"user"`
+	EventID   int64      `json:"event_id"`
+	Type      EventType  `json:"type"`
+	TaskID    string     `json:"task_id"`
+	Actor     string     `json:"actor,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	ReadAt    *time.Time `json:"read_at"`
+}
+
+// NotificationListResponse represents a page of a user's notifications,
+// newest first
+type NotificationListResponse struct {
+	Data       []*Notification    `json:"data"`
+	Unread     int                `json:"unread"`
+	Pagination listing.Pagination `json:"pagination"`
+}
+
+// MarkNotificationsReadRequest marks notifications as read, all of the
+// caller's when IDs is empty
+type MarkNotificationsReadRequest struct {
+	IDs []int64 `json:"ids" validate:"max=1000"`
+}
@@ -0,0 +1,393 @@
+// Package oidc is a minimal OpenID Connect relying party: it sends users to
+// the provider with the authorization code flow and PKCE, trades the code
+// for an ID token and verifies it against the provider's published keys
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+)
+
+// ErrInvalidIDToken is returned for ID tokens that are forged, expired,
+// meant for another client or from another sign-in
+var ErrInvalidIDToken = errors.New("invalid ID token")
+
+// maxResponseBytes caps the provider documents read
+const maxResponseBytes = 1 << 20
+
+// keyRefetchInterval limits how often tokens naming an unknown key make
+// the key set be fetched again
+const keyRefetchInterval = time.Minute
+
+// clockSkew is how far the provider's clock may be ahead of or behind ours
+const clockSkew = time.Minute
+
+// Identity is the user an ID token vouches for
+type Identity struct {
+	Issuer  string
+	Subject string
+	// Claims are all claims of the ID token, for picking a username
+	Claims map[string]any
+}
+
+// Claim returns the string claim name, empty when missing
+func (i *Identity) Claim(name string) string {
+	value, _ := i.Claims[name].(string)
+	return value
+}
+
+// discovery is the part of the provider's discovery document used
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider talks to one OpenID Connect provider. Its discovery document
+// and keys are fetched on first use; keys are fetched again when a token
+// is signed with an unknown one, as happens after the provider rotates.
+type Provider struct {
+	cfg    *config.OIDCConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	discovery *discovery
+	keys      map[string]crypto.PublicKey
+	fetched   time.Time
+}
+
+// NewProvider creates a Provider. client is used for every request to the
+// provider; when nil, one with a 10 second timeout is.
+func NewProvider(cfg *config.OIDCConfig, client *http.Client) *Provider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Provider{cfg: cfg, client: client}
+}
+
+// AuthCodeURL returns the provider URL a user signs in at. state comes
+// back with the callback, nonce inside the ID token, and verifier is the
+// PKCE secret Exchange must present.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	scopes := p.cfg.Scopes
+	if !slices.Contains(scopes, "openid") {
+		scopes = append([]string{"openid"}, scopes...)
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {strings.Join(scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return d.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange trades an authorization code for the identity of its ID token,
+// which must carry nonce
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Identity, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+
+	var token struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := p.do(req, &token); err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("%w: token response has no id_token", ErrInvalidIDToken)
+	}
+
+	return p.Verify(ctx, token.IDToken, nonce, time.Now())
+}
+
+// Verify checks an ID token's signature, issuer, audience, expiry and
+// nonce at now and returns its identity
+func (p *Provider) Verify(ctx context.Context, token, nonce string, now time.Time) (*Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidIDToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrInvalidIDToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidIDToken
+	}
+
+	key, err := p.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if !verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature) {
+		return nil, fmt.Errorf("%w: bad signature", ErrInvalidIDToken)
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidIDToken
+	}
+	identity := &Identity{Claims: claims}
+	identity.Issuer, identity.Subject = identity.Claim("iss"), identity.Claim("sub")
+
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case identity.Issuer != d.Issuer:
+		return nil, fmt.Errorf("%w: issued by %q", ErrInvalidIDToken, identity.Issuer)
+	case identity.Subject == "":
+		return nil, fmt.Errorf("%w: no subject", ErrInvalidIDToken)
+	case !audienceContains(claims["aud"], p.cfg.ClientID):
+		return nil, fmt.Errorf("%w: issued to another client", ErrInvalidIDToken)
+	case !now.Before(unixClaim(claims["exp"]).Add(clockSkew)):
+		return nil, fmt.Errorf("%w: expired", ErrInvalidIDToken)
+	case subtle.ConstantTimeCompare([]byte(identity.Claim("nonce")), []byte(nonce)) != 1:
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	return identity, nil
+}
+
+// discover fetches the provider's discovery document once
+func (p *Provider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.IssuerURL+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build discovery request: %w", err)
+	}
+	var d discovery
+	if err := p.do(req, &d); err != nil {
+		return nil, fmt.Errorf("failed to discover OIDC provider: %w", err)
+	}
+	// The issuer identifies the provider in every ID token, so a document
+	// naming another one is not this provider's
+	if strings.TrimSuffix(d.Issuer, "/") != p.cfg.IssuerURL {
+		return nil, fmt.Errorf("OIDC discovery names issuer %q, expected %q", d.Issuer, p.cfg.IssuerURL)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, errors.New("OIDC discovery document lacks an endpoint")
+	}
+
+	p.discovery = &d
+	return p.discovery, nil
+}
+
+// key returns the provider's public key kid, fetching the key set again
+// when it is unknown
+func (p *Provider) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(p.fetched) < keyRefetchInterval {
+		return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidIDToken, kid)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.JWKSURI, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build key set request: %w", err)
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.do(req, &set); err != nil {
+		return nil, fmt.Errorf("failed to fetch OIDC keys: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	p.keys, p.fetched = keys, time.Now()
+
+	key, ok := keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidIDToken, kid)
+	}
+	return key, nil
+}
+
+// do sends req and decodes its JSON response into v
+func (p *Provider) do(req *http.Request, v any) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var oauthErr struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		if json.Unmarshal(body, &oauthErr) == nil && oauthErr.Error != "" {
+			return fmt.Errorf("%s: %s %s", resp.Status, oauthErr.Error, oauthErr.Description)
+		}
+		return errors.New(resp.Status)
+	}
+	return json.Unmarshal(body, v)
+}
+
+// jsonWebKey is an RSA or EC public key of a JWK set
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+}
+
+// verifySignature checks an RS256 or ES256 signature. The algorithm must
+// match the key type, so a token cannot pick a weaker check.
+func verifySignature(alg string, key crypto.PublicKey, signed string, signature []byte) bool {
+	digest := sha256.Sum256([]byte(signed))
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		return alg == "RS256" && rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	case *ecdsa.PublicKey:
+		if alg != "ES256" || len(signature) != 64 {
+			return false
+		}
+		r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+		return ecdsa.Verify(key, digest[:], r, s)
+	}
+	return false
+}
+
+func decodeSegment(segment string, v any) error {
+	decoded, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(decoded, v)
+}
+
+// audienceContains reports whether the aud claim, a string or a list,
+// names clientID
+func audienceContains(aud any, clientID string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == clientID
+	case []any:
+		return slices.Contains(aud, any(clientID))
+	}
+	return false
+}
+
+// unixClaim reads a NumericDate claim
+func unixClaim(value any) time.Time {
+	seconds, _ := value.(float64)
+	return time.Unix(int64(seconds), 0)
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// AttachmentStore is the storage contract for attachment metadata,
+// implemented by the Postgres AttachmentRepository and the in-memory
+// MemoryAttachmentRepository. File contents are kept by a storage.Backend.
+type AttachmentStore interface {
+	Create(ctx context.Context, attachment *model.Attachment) (*model.Attachment, error)
+	// ListByTask returns a task's attachments, oldest first
+	ListByTask(ctx context.Context, taskID string) ([]*model.Attachment, error)
+	GetByID(ctx context.Context, taskID, id string) (*model.Attachment, error)
+	Delete(ctx context.Context, taskID, id string) error
+}
+
+var (
+	_ AttachmentStore = (*AttachmentRepository)(nil)
+	_ AttachmentStore = (*MemoryAttachmentRepository)(nil)
+)
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/moabdelazem/mutlitier_app/internal/database"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+var (
+	ErrAttachmentNotFound = errors.New("attachment not found")
+)
+
+// attachmentColumns is the column list shared by every attachment query, in scanAttachment order
+const attachmentColumns = `id, task_id, filename, content_type, size_bytes, sha256, storage_key, uploaded_by, created_at`
+
+// scanAttachment scans a row selected with attachmentColumns into an Attachment
+func scanAttachment(row scanner) (*model.Attachment, error) {
+	var a model.Attachment
+	if err := row.Scan(&a.ID, &a.TaskID, &a.Filename, &a.ContentType, &a.Size, &a.SHA256, &a.StorageKey, &a.UploadedBy, &a.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// AttachmentRepository handles database operations for attachment metadata
+type AttachmentRepository struct {
+	db *database.DB
+}
+
+// NewAttachmentRepository creates a new AttachmentRepository
+func NewAttachmentRepository(db *database.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+// Create implements AttachmentStore
+func (r *AttachmentRepository) Create(ctx context.Context, a *model.Attachment) (*model.Attachment, error) {
+	query := `
+		INSERT INTO task_attachments (id, task_id, filename, content_type, size_bytes, sha256, storage_key, uploaded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ` + attachmentColumns
+
+	created, err := scanAttachment(r.db.QueryRowContext(ctx, query,
+		a.ID, a.TaskID, a.Filename, a.ContentType, a.Size, a.SHA256, a.StorageKey, a.UploadedBy))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create attachment: %w", err)
+	}
+
+	return created, nil
+}
+
+// ListByTask implements AttachmentStore
+func (r *AttachmentRepository) ListByTask(ctx context.Context, taskID string) ([]*model.Attachment, error) {
+	query := `
+		SELECT ` + attachmentColumns + `
+		FROM task_attachments
+		WHERE task_id = $1
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var attachments []*model.Attachment
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate attachments: %w", err)
+	}
+
+	return attachments, nil
+}
+
+// GetByID implements AttachmentStore
+func (r *AttachmentRepository) GetByID(ctx context.Context, taskID, id string) (*model.Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM task_attachments WHERE task_id = $1 AND id = $2`
+
+	a, err := scanAttachment(r.db.QueryRowContext(ctx, query, taskID, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAttachmentNotFound
+		}
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+
+	return a, nil
+}
+
+// Delete implements AttachmentStore
+func (r *AttachmentRepository) Delete(ctx context.Context, taskID, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM task_attachments WHERE task_id = $1 AND id = $2`, taskID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrAttachmentNotFound
+	}
+
+	return nil
+}
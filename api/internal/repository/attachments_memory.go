@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// MemoryAttachmentRepository is an in-memory AttachmentStore used by demo mode
+type MemoryAttachmentRepository struct {
+	mu          sync.RWMutex
+	attachments map[string]*model.Attachment
+}
+
+// NewMemoryAttachmentRepository creates a new MemoryAttachmentRepository
+func NewMemoryAttachmentRepository() *MemoryAttachmentRepository {
+	return &MemoryAttachmentRepository{attachments: make(map[string]*model.Attachment)}
+}
+
+// Reset removes all attachments
+func (r *MemoryAttachmentRepository) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.attachments = make(map[string]*model.Attachment)
+}
+
+// Create implements AttachmentStore
+func (r *MemoryAttachmentRepository) Create(ctx context.Context, a *model.Attachment) (*model.Attachment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	created := *a
+	created.CreatedAt = time.Now().UTC()
+	r.attachments[created.ID] = &created
+
+	copied := created
+	return &copied, nil
+}
+
+// ListByTask implements AttachmentStore
+func (r *MemoryAttachmentRepository) ListByTask(ctx context.Context, taskID string) ([]*model.Attachment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var attachments []*model.Attachment
+	for _, a := range r.attachments {
+		if a.TaskID == taskID {
+			copied := *a
+			attachments = append(attachments, &copied)
+		}
+	}
+
+	slices.SortFunc(attachments, func(a, b *model.Attachment) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+	return attachments, nil
+}
+
+// GetByID implements AttachmentStore
+func (r *MemoryAttachmentRepository) GetByID(ctx context.Context, taskID, id string) (*model.Attachment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	a, ok := r.attachments[id]
+	if !ok || a.TaskID != taskID {
+		return nil, ErrAttachmentNotFound
+	}
+	copied := *a
+	return &copied, nil
+}
+
+// Delete implements AttachmentStore
+func (r *MemoryAttachmentRepository) Delete(ctx context.Context, taskID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	a, ok := r.attachments[id]
+	if !ok || a.TaskID != taskID {
+		return ErrAttachmentNotFound
+	}
+	delete(r.attachments, id)
+	return nil
+}
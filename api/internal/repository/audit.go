@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/database"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// AuditLogRepository stores audit logs in Postgres
+type AuditLogRepository struct {
+	db *database.DB
+}
+
+// NewAuditLogRepository creates a new AuditLogRepository
+func NewAuditLogRepository(db *database.DB) *AuditLogRepository {
+	return &AuditLogRepository{db: db}
+}
+
+// auditLogColumns are the columns scanned by scanAuditLog
+const auditLogColumns = `id, user_id, COALESCE(token_id::text, ''), method, endpoint, path, COALESCE(entity_id, ''),
+	status, before, after, ip, COALESCE(request_id, ''), created_at`
+
+// auditLogWhere filters audit_logs by a model.AuditLogFilter passed as the
+// first four parameters
+const auditLogWhere = `
+	WHERE ($1 = '' OR user_id = $1)
+	  AND ($2 = '' OR entity_id = $2)
+	  AND ($3::timestamptz IS NULL OR created_at >= $3)
+	  AND ($4::timestamptz IS NULL OR created_at < $4)
+`
+
+// Append implements AuditLogStore with a single multi-row insert
+func (r *AuditLogRepository) Append(ctx context.Context, logs []*model.AuditLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+
+	var query strings.Builder
+	query.WriteString(`INSERT INTO audit_logs
+		(user_id, token_id, method, endpoint, path, entity_id, status, before, after, ip, request_id, created_at) VALUES `)
+
+	const columns = 12
+	args := make([]any, 0, len(logs)*columns)
+	for i, log := range logs {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		n := i * columns
+		fmt.Fprintf(&query, "($%d, NULLIF($%d, '')::uuid, $%d, $%d, $%d, NULLIF($%d, ''), $%d, $%d, $%d, $%d, NULLIF($%d, ''), $%d)",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9, n+10, n+11, n+12)
+		args = append(args, log.User, log.TokenID, log.Method, log.Endpoint, log.Path, log.EntityID, log.Status,
+			nullJSON(log.Before), nullJSON(log.After), log.IP, log.RequestID, log.CreatedAt)
+	}
+
+	if _, err := r.db.ExecContext(ctx, query.String(), args...); err != nil {
+		return fmt.Errorf("failed to append audit logs: %w", err)
+	}
+
+	return nil
+}
+
+// List implements AuditLogStore
+func (r *AuditLogRepository) List(ctx context.Context, filter *model.AuditLogFilter, opts *model.ListOptions) ([]*model.AuditLog, error) {
+	query := `SELECT ` + auditLogColumns + ` FROM audit_logs` + auditLogWhere + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT $5 OFFSET $6
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, filter.User, filter.EntityID, filter.From, filter.To, opts.PerPage, opts.Offset())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	var logs []*model.AuditLog
+	for rows.Next() {
+		log, err := scanAuditLog(rows)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, log)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit logs: %w", err)
+	}
+
+	return logs, nil
+}
+
+// Count implements AuditLogStore
+func (r *AuditLogRepository) Count(ctx context.Context, filter *model.AuditLogFilter) (int, error) {
+	query := `SELECT COUNT(*) FROM audit_logs` + auditLogWhere
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, query, filter.User, filter.EntityID, filter.From, filter.To).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count audit logs: %w", err)
+	}
+
+	return total, nil
+}
+
+// Purge implements AuditLogStore
+func (r *AuditLogRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM audit_logs WHERE created_at < $1`
+
+	result, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge audit logs: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return purged, nil
+}
+
+func scanAuditLog(rows *sql.Rows) (*model.AuditLog, error) {
+	var log model.AuditLog
+	var before, after []byte
+	if err := rows.Scan(&log.ID, &log.User, &log.TokenID, &log.Method, &log.Endpoint, &log.Path, &log.EntityID,
+		&log.Status, &before, &after, &log.IP, &log.RequestID, &log.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to scan audit log: %w", err)
+	}
+	log.Before, log.After = before, after
+	return &log, nil
+}
+
+// nullJSON stores an empty JSON value as NULL
+func nullJSON(value []byte) any {
+	if len(value) == 0 {
+		return nil
+	}
+	return string(value)
+}
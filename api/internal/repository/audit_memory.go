@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// MemoryAuditLogRepository is an in-memory AuditLogStore used by demo
+// mode. Logs are kept oldest first.
+type MemoryAuditLogRepository struct {
+	mu     sync.RWMutex
+	logs   []*model.AuditLog
+	nextID int64
+}
+
+// NewMemoryAuditLogRepository creates a new MemoryAuditLogRepository
+func NewMemoryAuditLogRepository() *MemoryAuditLogRepository {
+	return &MemoryAuditLogRepository{nextID: 1}
+}
+
+// Append implements AuditLogStore
+func (r *MemoryAuditLogRepository) Append(ctx context.Context, logs []*model.AuditLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, log := range logs {
+		appended := *log
+		appended.ID = r.nextID
+		r.nextID++
+		r.logs = append(r.logs, &appended)
+	}
+	return nil
+}
+
+// List implements AuditLogStore
+func (r *MemoryAuditLogRepository) List(ctx context.Context, filter *model.AuditLogFilter, opts *model.ListOptions) ([]*model.AuditLog, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matches := r.matching(filter)
+	start := min(opts.Offset(), len(matches))
+	end := min(start+opts.PerPage, len(matches))
+
+	logs := make([]*model.AuditLog, 0, end-start)
+	for _, log := range matches[start:end] {
+		copied := *log
+		logs = append(logs, &copied)
+	}
+	return logs, nil
+}
+
+// Count implements AuditLogStore
+func (r *MemoryAuditLogRepository) Count(ctx context.Context, filter *model.AuditLogFilter) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.matching(filter)), nil
+}
+
+// Purge implements AuditLogStore
+func (r *MemoryAuditLogRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.logs[:0]
+	for _, log := range r.logs {
+		if !log.CreatedAt.Before(before) {
+			kept = append(kept, log)
+		}
+	}
+	purged := int64(len(r.logs) - len(kept))
+	r.logs = kept
+	return purged, nil
+}
+
+// matching returns the logs matching filter, newest first. Callers hold
+// the lock.
+func (r *MemoryAuditLogRepository) matching(filter *model.AuditLogFilter) []*model.AuditLog {
+	var matches []*model.AuditLog
+	for i := len(r.logs) - 1; i >= 0; i-- {
+		log := r.logs[i]
+		if filter.User != "" && log.User != filter.User {
+			continue
+		}
+		if filter.EntityID != "" && log.EntityID != filter.EntityID {
+			continue
+		}
+		if filter.From != nil && log.CreatedAt.Before(*filter.From) {
+			continue
+		}
+		if filter.To != nil && !log.CreatedAt.Before(*filter.To) {
+			continue
+		}
+		matches = append(matches, log)
+	}
+	return matches
+}
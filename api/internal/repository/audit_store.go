@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// AuditLogStore is the storage contract for the audit log of mutations,
+// implemented by the Postgres AuditLogRepository and the in-memory
+// MemoryAuditLogRepository
+type AuditLogStore interface {
+	// Append stores a batch of audit logs
+	Append(ctx context.Context, logs []*model.AuditLog) error
+
+	// List returns a page of audit logs matching filter, newest first
+	List(ctx context.Context, filter *model.AuditLogFilter, opts *model.ListOptions) ([]*model.AuditLog, error)
+	Count(ctx context.Context, filter *model.AuditLogFilter) (int, error)
+
+	// Purge removes audit logs created before the given time
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
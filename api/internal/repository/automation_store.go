@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// AutomationStore is the storage contract for automation rules and their
+// execution log. Both task stores implement it, since finding the tasks
+// past their due date reads the tasks. Under an owner scope rules are
+// only reached by the user who owns them, and due tasks and runs are
+// limited to the tasks the owner reaches.
+type AutomationStore interface {
+	// CreateAutomationRule returns ErrProjectNotFound when the rule's
+	// project is missing
+	CreateAutomationRule(ctx context.Context, rule *model.AutomationRule) (*model.AutomationRule, error)
+	// GetAutomationRule, UpdateAutomationRule and DeleteAutomationRule only
+	// reach the rules of project; others are ErrAutomationRuleNotFound
+	GetAutomationRule(ctx context.Context, project, id string) (*model.AutomationRule, error)
+	UpdateAutomationRule(ctx context.Context, rule *model.AutomationRule) (*model.AutomationRule, error)
+	DeleteAutomationRule(ctx context.Context, project, id string) error
+	// ListAutomationRules returns the rules of project, oldest first
+	ListAutomationRules(ctx context.Context, project string) ([]*model.AutomationRule, error)
+	// ListEnabledAutomationRules returns the enabled rules of every project
+	ListEnabledAutomationRules(ctx context.Context) ([]*model.AutomationRule, error)
+
+	// ListAutomationDue returns up to limit open, live tasks of the rule's
+	// project past their due date at now that the rule has not run for
+	// with that due date, soonest due first
+	ListAutomationDue(ctx context.Context, rule *model.AutomationRule, now time.Time, limit int) ([]*model.Task, error)
+	// ClaimAutomationRun stores a run, setting its ID and creation time, and
+	// reports whether it did; false means the rule already ran for the same
+	// event, or for the same task and due date
+	ClaimAutomationRun(ctx context.Context, run *model.AutomationRun) (bool, error)
+	// FinishAutomationRun records the status and action results of a run
+	FinishAutomationRun(ctx context.Context, run *model.AutomationRun) error
+	// CountRecentAutomationRuns counts the runs of a rule for a task since
+	// a time, skipped ones excluded
+	CountRecentAutomationRuns(ctx context.Context, ruleID, taskID string, since time.Time) (int, error)
+	// ListAutomationRuns returns a page of project's runs, newest first
+	ListAutomationRuns(ctx context.Context, project string, opts *model.ListOptions) ([]*model.AutomationRun, error)
+	CountAutomationRuns(ctx context.Context, project string) (int, error)
+}
+
+var (
+	_ AutomationStore = (*TaskRepository)(nil)
+	_ AutomationStore = (*MemoryTaskRepository)(nil)
+)
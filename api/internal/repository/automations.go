@@ -0,0 +1,344 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+var (
+	ErrAutomationRuleNotFound = errors.New("automation rule not found")
+)
+
+// automationRuleColumns is the column list shared by every rule query, in
+// scanAutomationRule order
+const automationRuleColumns = `id, project_key, name, trigger, conditions, actions, enabled, created_by, owner_id, created_at, updated_at, tenant_id`
+
+// automationRunColumns is the column list shared by every run query, in
+// scanAutomationRun order
+const automationRunColumns = `id, rule_id, rule_name, project_key, task_id, event_id, due_date, status, reason, actions, created_at`
+
+// scanAutomationRule scans a row selected with automationRuleColumns
+func scanAutomationRule(row scanner) (*model.AutomationRule, error) {
+	var rule model.AutomationRule
+	var trigger, conditions, actions []byte
+	if err := row.Scan(&rule.ID, &rule.ProjectKey, &rule.Name, &trigger, &conditions, &actions,
+		&rule.Enabled, &rule.CreatedBy, &rule.Owner, &rule.CreatedAt, &rule.UpdatedAt, &rule.Tenant); err != nil {
+		return nil, err
+	}
+	if err := errors.Join(json.Unmarshal(trigger, &rule.Trigger), json.Unmarshal(conditions, &rule.Conditions),
+		json.Unmarshal(actions, &rule.Actions)); err != nil {
+		return nil, fmt.Errorf("failed to decode automation rule %s: %w", rule.ID, err)
+	}
+	return &rule, nil
+}
+
+// scanAutomationRun scans a row selected with automationRunColumns
+func scanAutomationRun(row scanner) (*model.AutomationRun, error) {
+	var run model.AutomationRun
+	var actions []byte
+	if err := row.Scan(&run.ID, &run.RuleID, &run.RuleName, &run.ProjectKey, &run.TaskID, &run.EventID,
+		&run.DueDate, &run.Status, &run.Reason, &actions, &run.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(actions, &run.Actions); err != nil {
+		return nil, fmt.Errorf("failed to decode automation run %d: %w", run.ID, err)
+	}
+	return &run, nil
+}
+
+// encodeAutomationRule returns the JSON columns of a rule
+func encodeAutomationRule(rule *model.AutomationRule) (trigger, conditions, actions []byte, err error) {
+	if trigger, err = json.Marshal(rule.Trigger); err != nil {
+		return nil, nil, nil, err
+	}
+	if conditions, err = json.Marshal(rule.Conditions); err != nil {
+		return nil, nil, nil, err
+	}
+	if actions, err = json.Marshal(rule.Actions); err != nil {
+		return nil, nil, nil, err
+	}
+	return trigger, conditions, actions, nil
+}
+
+// CreateAutomationRule implements AutomationStore. The tenant_id column
+// defaults to the tenant of ctx's connection, which rule.Tenant already
+// names.
+func (r *TaskRepository) CreateAutomationRule(ctx context.Context, rule *model.AutomationRule) (*model.AutomationRule, error) {
+	trigger, conditions, actions, err := encodeAutomationRule(rule)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode automation rule: %w", err)
+	}
+
+	query := `
+		INSERT INTO automation_rules (id, project_key, name, trigger, conditions, actions, enabled, created_by, owner_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING ` + automationRuleColumns
+
+	created, err := scanAutomationRule(r.db.QueryRowContext(ctx, query, rule.ID, rule.ProjectKey, rule.Name,
+		trigger, conditions, actions, rule.Enabled, rule.CreatedBy, rule.Owner))
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return nil, ErrProjectNotFound
+		}
+		return nil, fmt.Errorf("failed to create automation rule: %w", err)
+	}
+
+	return created, nil
+}
+
+// GetAutomationRule implements AutomationStore
+func (r *TaskRepository) GetAutomationRule(ctx context.Context, project, id string) (*model.AutomationRule, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT ` + automationRuleColumns + ` FROM automation_rules
+		WHERE id = $1 AND project_key = $2 AND ` + ruleFilter("$3")
+
+	rule, err := scanAutomationRule(r.db.QueryRowContext(ctx, query, id, project, owner))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAutomationRuleNotFound
+		}
+		return nil, fmt.Errorf("failed to get automation rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// UpdateAutomationRule implements AutomationStore
+func (r *TaskRepository) UpdateAutomationRule(ctx context.Context, rule *model.AutomationRule) (*model.AutomationRule, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+	trigger, conditions, actions, err := encodeAutomationRule(rule)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode automation rule: %w", err)
+	}
+
+	query := `
+		UPDATE automation_rules SET name = $3, trigger = $4, conditions = $5, actions = $6, enabled = $7, updated_at = NOW()
+		WHERE id = $1 AND project_key = $2 AND ` + ruleFilter("$8") + `
+		RETURNING ` + automationRuleColumns
+
+	updated, err := scanAutomationRule(r.db.QueryRowContext(ctx, query, rule.ID, rule.ProjectKey, rule.Name,
+		trigger, conditions, actions, rule.Enabled, owner))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAutomationRuleNotFound
+		}
+		return nil, fmt.Errorf("failed to update automation rule: %w", err)
+	}
+
+	return updated, nil
+}
+
+// DeleteAutomationRule implements AutomationStore
+func (r *TaskRepository) DeleteAutomationRule(ctx context.Context, project, id string) error {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return err
+	}
+	query := `DELETE FROM automation_rules WHERE id = $1 AND project_key = $2 AND ` + ruleFilter("$3")
+	result, err := r.db.ExecContext(ctx, query, id, project, owner)
+	if err != nil {
+		return fmt.Errorf("failed to delete automation rule: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return ErrAutomationRuleNotFound
+	}
+
+	return nil
+}
+
+// ListAutomationRules implements AutomationStore
+func (r *TaskRepository) ListAutomationRules(ctx context.Context, project string) ([]*model.AutomationRule, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT ` + automationRuleColumns + ` FROM automation_rules
+		WHERE project_key = $1 AND ` + ruleFilter("$2") + ` ORDER BY created_at, id`
+	return r.listAutomationRules(ctx, query, project, owner)
+}
+
+// ListEnabledAutomationRules implements AutomationStore
+func (r *TaskRepository) ListEnabledAutomationRules(ctx context.Context) ([]*model.AutomationRule, error) {
+	query := `SELECT ` + automationRuleColumns + ` FROM automation_rules WHERE enabled ORDER BY created_at, id`
+	return r.listAutomationRules(ctx, query)
+}
+
+// ruleFilter matches the rules the user bound to the text parameter param
+// created, every rule when param is NULL and none when it is empty
+func ruleFilter(param string) string {
+	return `(` + param + `::text IS NULL OR owner_id = ` + param + `)`
+}
+
+func (r *TaskRepository) listAutomationRules(ctx context.Context, query string, args ...any) ([]*model.AutomationRule, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list automation rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []*model.AutomationRule
+	for rows.Next() {
+		rule, err := scanAutomationRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan automation rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating automation rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// ListAutomationDue implements AutomationStore. The tenant is matched
+// explicitly too, so a connection outside row level security still only
+// sees the rule's tenant.
+func (r *TaskRepository) ListAutomationDue(ctx context.Context, rule *model.AutomationRule, now time.Time, limit int) ([]*model.Task, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT ` + taskColumns + ` FROM tasks
+		WHERE project_key = $1 AND due_date <= $2
+			AND status NOT IN ('completed', 'cancelled') AND deleted_at IS NULL AND NOT archived
+			AND ` + taskFilter("tasks", "$5") + ` AND tenant_id = $6
+			AND NOT EXISTS (
+				SELECT 1 FROM automation_runs a
+				WHERE a.rule_id = $3 AND a.task_id = tasks.id AND a.due_date = tasks.due_date
+			)
+		ORDER BY due_date, id
+		LIMIT $4`
+
+	rows, err := r.db.QueryContext(ctx, query, rule.ProjectKey, now, rule.ID, limit, owner, rule.Tenant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks past their due date: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTasks(rows)
+}
+
+// ClaimAutomationRun implements AutomationStore
+func (r *TaskRepository) ClaimAutomationRun(ctx context.Context, run *model.AutomationRun) (bool, error) {
+	actions, err := json.Marshal(run.Actions)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode automation actions: %w", err)
+	}
+
+	query := `
+		INSERT INTO automation_runs (rule_id, rule_name, project_key, task_id, event_id, due_date, status, reason, actions)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT DO NOTHING
+		RETURNING id, created_at`
+
+	err = r.db.QueryRowContext(ctx, query, run.RuleID, run.RuleName, run.ProjectKey, run.TaskID, run.EventID,
+		run.DueDate, run.Status, run.Reason, actions).Scan(&run.ID, &run.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to claim automation run: %w", err)
+	}
+
+	return true, nil
+}
+
+// FinishAutomationRun implements AutomationStore
+func (r *TaskRepository) FinishAutomationRun(ctx context.Context, run *model.AutomationRun) error {
+	actions, err := json.Marshal(run.Actions)
+	if err != nil {
+		return fmt.Errorf("failed to encode automation actions: %w", err)
+	}
+
+	query := `UPDATE automation_runs SET status = $2, reason = $3, actions = $4 WHERE id = $1`
+	if _, err := r.db.ExecContext(ctx, query, run.ID, run.Status, run.Reason, actions); err != nil {
+		return fmt.Errorf("failed to finish automation run: %w", err)
+	}
+
+	return nil
+}
+
+// CountRecentAutomationRuns implements AutomationStore
+func (r *TaskRepository) CountRecentAutomationRuns(ctx context.Context, ruleID, taskID string, since time.Time) (int, error) {
+	var count int
+	query := `
+		SELECT COUNT(*) FROM automation_runs
+		WHERE rule_id = $1 AND task_id = $2 AND created_at >= $3 AND status <> 'skipped'`
+	if err := r.db.QueryRowContext(ctx, query, ruleID, taskID, since).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count automation runs: %w", err)
+	}
+	return count, nil
+}
+
+// ListAutomationRuns implements AutomationStore
+func (r *TaskRepository) ListAutomationRuns(ctx context.Context, project string, opts *model.ListOptions) ([]*model.AutomationRun, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT ` + automationRunColumns + ` FROM automation_runs
+		WHERE project_key = $1 AND ` + runFilter("$4") + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.QueryContext(ctx, query, project, opts.PerPage, opts.Offset(), owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list automation runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*model.AutomationRun
+	for rows.Next() {
+		run, err := scanAutomationRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan automation run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating automation runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+// CountAutomationRuns implements AutomationStore
+func (r *TaskRepository) CountAutomationRuns(ctx context.Context, project string) (int, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var count int
+	query := `SELECT COUNT(*) FROM automation_runs WHERE project_key = $1 AND ` + runFilter("$2")
+	if err := r.db.QueryRowContext(ctx, query, project, owner).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count automation runs: %w", err)
+	}
+	return count, nil
+}
+
+// runFilter matches the runs on tasks the user bound to the text parameter
+// param reaches, every run when param is NULL
+func runFilter(param string) string {
+	return `(` + param + `::text IS NULL OR EXISTS (
+		SELECT 1 FROM tasks WHERE tasks.id = automation_runs.task_id AND ` + taskFilter("tasks", param) + `))`
+}
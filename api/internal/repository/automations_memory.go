@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// CreateAutomationRule implements AutomationStore
+func (r *MemoryTaskRepository) CreateAutomationRule(ctx context.Context, rule *model.AutomationRule) (*model.AutomationRule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.project(ctx, rule.ProjectKey); !ok {
+		return nil, ErrProjectNotFound
+	}
+
+	created := copyAutomationRule(rule)
+	created.CreatedAt = time.Now().UTC()
+	created.UpdatedAt = created.CreatedAt
+	r.automated[created.ID] = created
+
+	return copyAutomationRule(created), nil
+}
+
+// GetAutomationRule implements AutomationStore
+func (r *MemoryTaskRepository) GetAutomationRule(ctx context.Context, project, id string) (*model.AutomationRule, error) {
+	if err := checkScope(ctx); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rule, ok := r.automated[id]
+	if !ok || rule.ProjectKey != project || !inTenant(ctx, rule.Tenant) || !inScope(ctx, rule.Owner) {
+		return nil, ErrAutomationRuleNotFound
+	}
+	return copyAutomationRule(rule), nil
+}
+
+// UpdateAutomationRule implements AutomationStore
+func (r *MemoryTaskRepository) UpdateAutomationRule(ctx context.Context, rule *model.AutomationRule) (*model.AutomationRule, error) {
+	if err := checkScope(ctx); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.automated[rule.ID]
+	if !ok || existing.ProjectKey != rule.ProjectKey || !inTenant(ctx, existing.Tenant) || !inScope(ctx, existing.Owner) {
+		return nil, ErrAutomationRuleNotFound
+	}
+
+	updated := copyAutomationRule(rule)
+	updated.CreatedBy, updated.CreatedAt = existing.CreatedBy, existing.CreatedAt
+	updated.Owner, updated.Tenant = existing.Owner, existing.Tenant
+	updated.UpdatedAt = time.Now().UTC()
+	r.automated[updated.ID] = updated
+
+	return copyAutomationRule(updated), nil
+}
+
+// DeleteAutomationRule implements AutomationStore
+func (r *MemoryTaskRepository) DeleteAutomationRule(ctx context.Context, project, id string) error {
+	if err := checkScope(ctx); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rule, ok := r.automated[id]
+	if !ok || rule.ProjectKey != project || !inTenant(ctx, rule.Tenant) || !inScope(ctx, rule.Owner) {
+		return ErrAutomationRuleNotFound
+	}
+	delete(r.automated, id)
+	return nil
+}
+
+// ListAutomationRules implements AutomationStore
+func (r *MemoryTaskRepository) ListAutomationRules(ctx context.Context, project string) ([]*model.AutomationRule, error) {
+	if err := checkScope(ctx); err != nil {
+		return nil, err
+	}
+	return r.listAutomationRules(func(rule *model.AutomationRule) bool {
+		return rule.ProjectKey == project && inTenant(ctx, rule.Tenant) && inScope(ctx, rule.Owner)
+	}), nil
+}
+
+// ListEnabledAutomationRules implements AutomationStore
+func (r *MemoryTaskRepository) ListEnabledAutomationRules(ctx context.Context) ([]*model.AutomationRule, error) {
+	return r.listAutomationRules(func(rule *model.AutomationRule) bool {
+		return rule.Enabled && inTenant(ctx, rule.Tenant)
+	}), nil
+}
+
+// listAutomationRules returns the rules matching keep, oldest first
+func (r *MemoryTaskRepository) listAutomationRules(keep func(*model.AutomationRule) bool) []*model.AutomationRule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var rules []*model.AutomationRule
+	for _, rule := range r.automated {
+		if keep(rule) {
+			rules = append(rules, copyAutomationRule(rule))
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if !rules[i].CreatedAt.Equal(rules[j].CreatedAt) {
+			return rules[i].CreatedAt.Before(rules[j].CreatedAt)
+		}
+		return rules[i].ID < rules[j].ID
+	})
+	return rules
+}
+
+// ListAutomationDue implements AutomationStore
+func (r *MemoryTaskRepository) ListAutomationDue(ctx context.Context, rule *model.AutomationRule, now time.Time, limit int) ([]*model.Task, error) {
+	if err := checkScope(ctx); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tasks []*model.Task
+	for _, task := range r.tasks {
+		if task.ProjectKey != rule.ProjectKey || task.DueDate == nil || task.DueDate.After(now) ||
+			task.Status.Closed() || task.DeletedAt != nil || task.Archived || !r.visible(ctx, task) {
+			continue
+		}
+		if slices.ContainsFunc(r.runs, func(run *model.AutomationRun) bool {
+			return run.RuleID == rule.ID && run.TaskID == task.ID && run.DueDate != nil && run.DueDate.Equal(*task.DueDate)
+		}) {
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if !tasks[i].DueDate.Equal(*tasks[j].DueDate) {
+			return tasks[i].DueDate.Before(*tasks[j].DueDate)
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+
+	due := make([]*model.Task, 0, min(limit, len(tasks)))
+	for _, task := range tasks[:min(limit, len(tasks))] {
+		due = append(due, copyTask(task))
+	}
+	return due, nil
+}
+
+// ClaimAutomationRun implements AutomationStore
+func (r *MemoryTaskRepository) ClaimAutomationRun(ctx context.Context, run *model.AutomationRun) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.runs {
+		if existing.RuleID != run.RuleID {
+			continue
+		}
+		if run.EventID != nil && existing.EventID != nil && *existing.EventID == *run.EventID {
+			return false, nil
+		}
+		if run.DueDate != nil && existing.DueDate != nil && existing.TaskID == run.TaskID && existing.DueDate.Equal(*run.DueDate) {
+			return false, nil
+		}
+	}
+
+	run.ID = int64(len(r.runs) + 1)
+	run.CreatedAt = time.Now().UTC()
+	r.runs = append(r.runs, copyAutomationRun(run))
+	return true, nil
+}
+
+// FinishAutomationRun implements AutomationStore
+func (r *MemoryTaskRepository) FinishAutomationRun(ctx context.Context, run *model.AutomationRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if run.ID < 1 || run.ID > int64(len(r.runs)) {
+		return nil
+	}
+	stored := r.runs[run.ID-1]
+	stored.Status, stored.Reason = run.Status, run.Reason
+	stored.Actions = slices.Clone(run.Actions)
+	return nil
+}
+
+// CountRecentAutomationRuns implements AutomationStore
+func (r *MemoryTaskRepository) CountRecentAutomationRuns(ctx context.Context, ruleID, taskID string, since time.Time) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := 0
+	for _, run := range r.runs {
+		if run.RuleID == ruleID && run.TaskID == taskID && !run.CreatedAt.Before(since) && run.Status != model.AutomationSkipped {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// ListAutomationRuns implements AutomationStore
+func (r *MemoryTaskRepository) ListAutomationRuns(ctx context.Context, project string, opts *model.ListOptions) ([]*model.AutomationRun, error) {
+	if err := checkScope(ctx); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matches := r.runsOf(ctx, project)
+	start := min(opts.Offset(), len(matches))
+	end := min(start+opts.PerPage, len(matches))
+
+	runs := make([]*model.AutomationRun, 0, end-start)
+	for _, run := range matches[start:end] {
+		runs = append(runs, copyAutomationRun(run))
+	}
+	return runs, nil
+}
+
+// CountAutomationRuns implements AutomationStore
+func (r *MemoryTaskRepository) CountAutomationRuns(ctx context.Context, project string) (int, error) {
+	if err := checkScope(ctx); err != nil {
+		return 0, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.runsOf(ctx, project)), nil
+}
+
+// runsOf returns project's runs on tasks visible under ctx's scope, newest
+// first; callers hold the lock
+func (r *MemoryTaskRepository) runsOf(ctx context.Context, project string) []*model.AutomationRun {
+	_, scoped := ScopeFrom(ctx)
+	var matches []*model.AutomationRun
+	for i := len(r.runs) - 1; i >= 0; i-- {
+		task, ok := r.tasks[r.runs[i].TaskID]
+		if r.runs[i].ProjectKey == project && (!scoped || ok && r.visible(ctx, task)) {
+			matches = append(matches, r.runs[i])
+		}
+	}
+	return matches
+}
+
+func copyAutomationRule(rule *model.AutomationRule) *model.AutomationRule {
+	copied := *rule
+	copied.Trigger.From = slices.Clone(rule.Trigger.From)
+	copied.Trigger.To = slices.Clone(rule.Trigger.To)
+	copied.Conditions = make([]model.AutomationCondition, len(rule.Conditions))
+	for i, condition := range rule.Conditions {
+		condition.Values = slices.Clone(condition.Values)
+		copied.Conditions[i] = condition
+	}
+	copied.Actions = slices.Clone(rule.Actions)
+	return &copied
+}
+
+func copyAutomationRun(run *model.AutomationRun) *model.AutomationRun {
+	copied := *run
+	copied.Actions = slices.Clone(run.Actions)
+	return &copied
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// Board implements TaskStore in a single query: window functions number
+// and count the tasks of each status, and only the first of each column
+// are selected, so tags are loaded for shown tasks only. A column with a
+// limit of 0 still selects one row, for its total.
+func (r *TaskRepository) Board(ctx context.Context, opts *model.BoardOptions) ([]*model.BoardTasks, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	column, ok := sortColumns[opts.Sort]
+	if !ok {
+		column = "priority_rank"
+	}
+	order, ok := sortOrders[opts.Order]
+	if !ok {
+		order = "DESC"
+	}
+
+	columns, byStatus := boardColumns()
+	statuses := make([]model.Status, len(columns))
+	limits := make([]int64, len(columns))
+	for i, col := range columns {
+		statuses[i] = col.Status
+		limits[i] = int64(max(opts.ColumnLimit(col.Status), 1))
+	}
+
+	// The subquery is aliased tasks so taskColumns resolves against it
+	query := fmt.Sprintf(`
+		SELECT %s, column_total
+		FROM (
+			SELECT *,
+				COUNT(*) OVER (PARTITION BY status) AS column_total,
+				ROW_NUMBER() OVER (PARTITION BY status ORDER BY %s %s, id %s) AS column_rank
+			FROM tasks
+			WHERE deleted_at IS NULL AND NOT archived AND ($1 = '' OR project_key = $1) AND %s
+		) AS tasks
+		WHERE column_rank <= ($3::bigint[])[array_position($2::text[], status::text)]
+		ORDER BY column_rank
+	`, taskColumns, column, order, order, taskFilter("tasks", "$4"))
+
+	rows, err := r.db.QueryContext(ctx, query, opts.Project, statusArray(statuses), pq.Array(limits), owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get board: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var total int
+		task, err := scanTask(trailingScanner{rows, []any{&total}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan board task: %w", err)
+		}
+		col, ok := byStatus[task.Status]
+		if !ok {
+			continue
+		}
+		col.Total = total
+		if len(col.Tasks) < opts.ColumnLimit(col.Status) {
+			col.Tasks = append(col.Tasks, task)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating board: %w", err)
+	}
+
+	return columns, nil
+}
+
+// boardColumns returns an empty column per status, in lifecycle order,
+// and the same columns by status
+func boardColumns() ([]*model.BoardTasks, map[model.Status]*model.BoardTasks) {
+	statuses := model.Statuses()
+	columns := make([]*model.BoardTasks, len(statuses))
+	byStatus := make(map[model.Status]*model.BoardTasks, len(statuses))
+	for i, status := range statuses {
+		columns[i] = &model.BoardTasks{Status: status, Tasks: []*model.Task{}}
+		byStatus[status] = columns[i]
+	}
+	return columns, byStatus
+}
+
+// trailingScanner scans the columns selected after taskColumns into extra
+type trailingScanner struct {
+	scanner
+	extra []any
+}
+
+func (s trailingScanner) Scan(dest ...any) error {
+	return s.scanner.Scan(append(dest, s.extra...)...)
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// Board implements TaskStore
+func (r *MemoryTaskRepository) Board(ctx context.Context, opts *model.BoardOptions) ([]*model.BoardTasks, error) {
+	if err := checkScope(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sortBy := opts.Sort
+	if sortBy == "" {
+		sortBy = "priority"
+	}
+	tasks := r.filter(ctx, &model.ListOptions{Project: opts.Project})
+	sort.Slice(tasks, func(i, j int) bool {
+		if opts.Order == "asc" {
+			return lessBy(sortBy, tasks[i], tasks[j])
+		}
+		return lessBy(sortBy, tasks[j], tasks[i])
+	})
+
+	columns, byStatus := boardColumns()
+	for _, task := range tasks {
+		col, ok := byStatus[task.Status]
+		if !ok {
+			continue
+		}
+		col.Total++
+		if len(col.Tasks) < opts.ColumnLimit(col.Status) {
+			col.Tasks = append(col.Tasks, task)
+		}
+	}
+
+	return columns, nil
+}
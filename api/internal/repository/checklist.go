@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/moabdelazem/mutlitier_app/internal/database"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+var (
+	ErrChecklistItemNotFound = errors.New("checklist item not found")
+)
+
+// checklistColumns is the column list shared by every checklist query, in scanChecklistItem order
+const checklistColumns = `id, task_id, text, done, position, created_at, updated_at`
+
+// scanChecklistItem scans a row selected with checklistColumns into a ChecklistItem
+func scanChecklistItem(row scanner) (*model.ChecklistItem, error) {
+	var item model.ChecklistItem
+	if err := row.Scan(&item.ID, &item.TaskID, &item.Text, &item.Done, &item.Position, &item.CreatedAt, &item.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// ChecklistRepository handles database operations for task checklists
+type ChecklistRepository struct {
+	db *database.DB
+}
+
+// NewChecklistRepository creates a new ChecklistRepository
+func NewChecklistRepository(db *database.DB) *ChecklistRepository {
+	return &ChecklistRepository{db: db}
+}
+
+// Create implements ChecklistStore
+func (r *ChecklistRepository) Create(ctx context.Context, item *model.ChecklistItem) (*model.ChecklistItem, error) {
+	query := `
+		INSERT INTO task_checklist_items (id, task_id, text, done, position)
+		SELECT $1, $2, $3, $4, COALESCE(MAX(position) + 1, 0) FROM task_checklist_items WHERE task_id = $2
+		RETURNING ` + checklistColumns
+
+	created, err := scanChecklistItem(r.db.QueryRowContext(ctx, query, item.ID, item.TaskID, item.Text, item.Done))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create checklist item: %w", err)
+	}
+
+	return created, nil
+}
+
+// ListByTasks implements ChecklistStore
+func (r *ChecklistRepository) ListByTasks(ctx context.Context, taskIDs []string) ([]*model.ChecklistItem, error) {
+	query := `
+		SELECT ` + checklistColumns + `
+		FROM task_checklist_items
+		WHERE task_id = ANY($1::uuid[])
+		ORDER BY task_id, position, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(taskIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checklist items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*model.ChecklistItem
+	for rows.Next() {
+		item, err := scanChecklistItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan checklist item: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate checklist items: %w", err)
+	}
+
+	return items, nil
+}
+
+// Update implements ChecklistStore
+func (r *ChecklistRepository) Update(ctx context.Context, taskID, id string, updates *model.UpdateChecklistItemRequest) (*model.ChecklistItem, error) {
+	query := `
+		UPDATE task_checklist_items
+		SET text = COALESCE($3, text), done = COALESCE($4, done), updated_at = NOW()
+		WHERE task_id = $1 AND id = $2
+		RETURNING ` + checklistColumns
+
+	item, err := scanChecklistItem(r.db.QueryRowContext(ctx, query, taskID, id, updates.Text, updates.Done))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrChecklistItemNotFound
+		}
+		return nil, fmt.Errorf("failed to update checklist item: %w", err)
+	}
+
+	return item, nil
+}
+
+// Delete implements ChecklistStore
+func (r *ChecklistRepository) Delete(ctx context.Context, taskID, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM task_checklist_items WHERE task_id = $1 AND id = $2`, taskID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete checklist item: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrChecklistItemNotFound
+	}
+
+	return nil
+}
+
+// Reorder implements ChecklistStore in one statement
+func (r *ChecklistRepository) Reorder(ctx context.Context, taskID string, ids []string) error {
+	query := `
+		UPDATE task_checklist_items
+		SET position = ordered.ord - 1, updated_at = NOW()
+		FROM unnest($2::uuid[]) WITH ORDINALITY AS ordered(id, ord)
+		WHERE task_checklist_items.task_id = $1 AND task_checklist_items.id = ordered.id
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, taskID, pq.Array(ids)); err != nil {
+		return fmt.Errorf("failed to reorder checklist: %w", err)
+	}
+
+	return nil
+}
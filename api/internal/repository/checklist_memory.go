@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// MemoryChecklistRepository is an in-memory ChecklistStore used by demo mode
+type MemoryChecklistRepository struct {
+	mu    sync.RWMutex
+	items map[string]*model.ChecklistItem
+}
+
+// NewMemoryChecklistRepository creates a new MemoryChecklistRepository
+func NewMemoryChecklistRepository() *MemoryChecklistRepository {
+	return &MemoryChecklistRepository{items: make(map[string]*model.ChecklistItem)}
+}
+
+// Reset removes all checklist items
+func (r *MemoryChecklistRepository) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.items = make(map[string]*model.ChecklistItem)
+}
+
+// Create implements ChecklistStore
+func (r *MemoryChecklistRepository) Create(ctx context.Context, item *model.ChecklistItem) (*model.ChecklistItem, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	created := *item
+	created.Position = 0
+	for _, existing := range r.items {
+		if existing.TaskID == item.TaskID && existing.Position >= created.Position {
+			created.Position = existing.Position + 1
+		}
+	}
+	created.CreatedAt = time.Now().UTC()
+	created.UpdatedAt = created.CreatedAt
+	r.items[created.ID] = &created
+
+	copied := created
+	return &copied, nil
+}
+
+// ListByTasks implements ChecklistStore
+func (r *MemoryChecklistRepository) ListByTasks(ctx context.Context, taskIDs []string) ([]*model.ChecklistItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var items []*model.ChecklistItem
+	for _, item := range r.items {
+		if slices.Contains(taskIDs, item.TaskID) {
+			copied := *item
+			items = append(items, &copied)
+		}
+	}
+
+	slices.SortFunc(items, func(a, b *model.ChecklistItem) int {
+		return cmp.Or(cmp.Compare(a.TaskID, b.TaskID), cmp.Compare(a.Position, b.Position), cmp.Compare(a.ID, b.ID))
+	})
+	return items, nil
+}
+
+// Update implements ChecklistStore
+func (r *MemoryChecklistRepository) Update(ctx context.Context, taskID, id string, updates *model.UpdateChecklistItemRequest) (*model.ChecklistItem, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	item, ok := r.items[id]
+	if !ok || item.TaskID != taskID {
+		return nil, ErrChecklistItemNotFound
+	}
+	if updates.Text != nil {
+		item.Text = *updates.Text
+	}
+	if updates.Done != nil {
+		item.Done = *updates.Done
+	}
+	item.UpdatedAt = time.Now().UTC()
+
+	copied := *item
+	return &copied, nil
+}
+
+// Delete implements ChecklistStore
+func (r *MemoryChecklistRepository) Delete(ctx context.Context, taskID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	item, ok := r.items[id]
+	if !ok || item.TaskID != taskID {
+		return ErrChecklistItemNotFound
+	}
+	delete(r.items, id)
+	return nil
+}
+
+// Reorder implements ChecklistStore
+func (r *MemoryChecklistRepository) Reorder(ctx context.Context, taskID string, ids []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	for position, id := range ids {
+		if item, ok := r.items[id]; ok && item.TaskID == taskID {
+			item.Position = position
+			item.UpdatedAt = now
+		}
+	}
+	return nil
+}
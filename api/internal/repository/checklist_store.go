@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// ChecklistStore is the storage contract for task checklist items,
+// implemented by the Postgres ChecklistRepository and the in-memory
+// MemoryChecklistRepository
+type ChecklistStore interface {
+	// Create appends an item to the end of its task's checklist
+	Create(ctx context.Context, item *model.ChecklistItem) (*model.ChecklistItem, error)
+	// ListByTasks returns the items of several tasks in one query, grouped
+	// by task and in position order
+	ListByTasks(ctx context.Context, taskIDs []string) ([]*model.ChecklistItem, error)
+	Update(ctx context.Context, taskID, id string, updates *model.UpdateChecklistItemRequest) (*model.ChecklistItem, error)
+	Delete(ctx context.Context, taskID, id string) error
+	// Reorder sets the positions of a task's items to their order in ids
+	Reorder(ctx context.Context, taskID string, ids []string) error
+}
+
+var (
+	_ ChecklistStore = (*ChecklistRepository)(nil)
+	_ ChecklistStore = (*MemoryChecklistRepository)(nil)
+)
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// CommentStore is the storage contract for task comments, implemented by
+// the Postgres CommentRepository and the in-memory MemoryCommentRepository
+type CommentStore interface {
+	Create(ctx context.Context, comment *model.Comment) (*model.Comment, error)
+	ListByTask(ctx context.Context, taskID string, opts *model.ListOptions) ([]*model.Comment, error)
+	CountByTask(ctx context.Context, taskID string) (int, error)
+
+	// ListRecentByTasks returns up to limit of the newest comments of each
+	// of taskIDs, oldest first within a task
+	ListRecentByTasks(ctx context.Context, taskIDs []string, limit int) ([]*model.Comment, error)
+	Delete(ctx context.Context, taskID, id string) error
+	DeleteByTask(ctx context.Context, taskID string) error
+	TasksWithComments(ctx context.Context, taskIDs []string) ([]string, error)
+}
+
+// ErrTaskCommented is returned by task deletes made with KeepCommented when
+// the task has comments
+var ErrTaskCommented = errors.New("task has comments")
+
+type keepCommentedKey struct{}
+
+// KeepCommented returns a context whose task deletes leave tasks with
+// comments alone. The check is part of the delete itself, so a comment
+// posted meanwhile cannot slip past it.
+func KeepCommented(ctx context.Context) context.Context {
+	return context.WithValue(ctx, keepCommentedKey{}, true)
+}
+
+// keepsCommented reports whether ctx came from KeepCommented
+func keepsCommented(ctx context.Context) bool {
+	keep, _ := ctx.Value(keepCommentedKey{}).(bool)
+	return keep
+}
+
+var (
+	_ CommentStore = (*CommentRepository)(nil)
+	_ CommentStore = (*MemoryCommentRepository)(nil)
+)
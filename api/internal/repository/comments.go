@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/moabdelazem/mutlitier_app/internal/database"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+var (
+	ErrCommentNotFound = errors.New("comment not found")
+)
+
+// commentColumns is the column list shared by every comment query, in scanComment order
+const commentColumns = `id, task_id, author, body, created_at`
+
+// scanComment scans a row selected with commentColumns into a Comment
+func scanComment(row scanner) (*model.Comment, error) {
+	var comment model.Comment
+	if err := row.Scan(&comment.ID, &comment.TaskID, &comment.Author, &comment.Body, &comment.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// CommentRepository handles database operations for task comments
+type CommentRepository struct {
+	db *database.DB
+}
+
+// NewCommentRepository creates a new CommentRepository
+func NewCommentRepository(db *database.DB) *CommentRepository {
+	return &CommentRepository{db: db}
+}
+
+// Create implements CommentStore
+func (r *CommentRepository) Create(ctx context.Context, comment *model.Comment) (*model.Comment, error) {
+	query := `INSERT INTO task_comments (id, task_id, author, body) VALUES ($1, $2, $3, $4) RETURNING ` + commentColumns
+
+	created, err := scanComment(r.db.QueryRowContext(ctx, query, comment.ID, comment.TaskID, comment.Author, comment.Body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	return created, nil
+}
+
+// ListByTask implements CommentStore, oldest first
+func (r *CommentRepository) ListByTask(ctx context.Context, taskID string, opts *model.ListOptions) ([]*model.Comment, error) {
+	query := `
+		SELECT ` + commentColumns + `
+		FROM task_comments
+		WHERE task_id = $1
+		ORDER BY created_at, id
+		LIMIT $2 OFFSET $3
+	`
+
+	offset := opts.Offset()
+
+	rows, err := r.db.QueryContext(ctx, query, taskID, opts.PerPage, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []*model.Comment
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan comment: %w", err)
+		}
+		comments = append(comments, comment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating comments: %w", err)
+	}
+
+	return comments, nil
+}
+
+// ListRecentByTasks implements CommentStore in one query
+func (r *CommentRepository) ListRecentByTasks(ctx context.Context, taskIDs []string, limit int) ([]*model.Comment, error) {
+	query := `
+		SELECT ` + commentColumns + `
+		FROM (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY task_id ORDER BY created_at DESC, id DESC) AS rank
+			FROM task_comments
+			WHERE task_id = ANY($1)
+		) ranked
+		WHERE rank <= $2
+		ORDER BY task_id, created_at, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(taskIDs), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []*model.Comment
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan comment: %w", err)
+		}
+		comments = append(comments, comment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating comments: %w", err)
+	}
+
+	return comments, nil
+}
+
+// CountByTask implements CommentStore
+func (r *CommentRepository) CountByTask(ctx context.Context, taskID string) (int, error) {
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM task_comments WHERE task_id = $1`, taskID).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count comments: %w", err)
+	}
+
+	return total, nil
+}
+
+// Delete implements CommentStore; the comment must belong to the task
+func (r *CommentRepository) Delete(ctx context.Context, taskID, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM task_comments WHERE id = $1 AND task_id = $2`, id, taskID)
+	if err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrCommentNotFound
+	}
+
+	return nil
+}
+
+// DeleteByTask implements CommentStore. It has nothing to do in Postgres,
+// where task_comments cascades on its foreign key when a task is removed.
+func (r *CommentRepository) DeleteByTask(ctx context.Context, taskID string) error {
+	return nil
+}
+
+// TasksWithComments implements CommentStore, returning the IDs among
+// taskIDs that have at least one comment
+func (r *CommentRepository) TasksWithComments(ctx context.Context, taskIDs []string) ([]string, error) {
+	query := `SELECT DISTINCT task_id FROM task_comments WHERE task_id = ANY($1::uuid[])`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(taskIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find commented tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan task id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating commented tasks: %w", err)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// MemoryCommentRepository is an in-memory CommentStore used by demo mode
+type MemoryCommentRepository struct {
+	mu       sync.RWMutex
+	comments map[string]*model.Comment
+}
+
+// NewMemoryCommentRepository creates a new MemoryCommentRepository
+func NewMemoryCommentRepository() *MemoryCommentRepository {
+	return &MemoryCommentRepository{comments: make(map[string]*model.Comment)}
+}
+
+// Reset removes all comments
+func (r *MemoryCommentRepository) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.comments = make(map[string]*model.Comment)
+}
+
+// Create implements CommentStore
+func (r *MemoryCommentRepository) Create(ctx context.Context, comment *model.Comment) (*model.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	created := *comment
+	created.CreatedAt = time.Now().UTC()
+	r.comments[created.ID] = &created
+
+	copied := created
+	return &copied, nil
+}
+
+// ListByTask implements CommentStore, oldest first
+func (r *MemoryCommentRepository) ListByTask(ctx context.Context, taskID string, opts *model.ListOptions) ([]*model.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var comments []*model.Comment
+	for _, comment := range r.comments {
+		if comment.TaskID == taskID {
+			copied := *comment
+			comments = append(comments, &copied)
+		}
+	}
+
+	sort.Slice(comments, func(i, j int) bool {
+		if cmp := comments[i].CreatedAt.Compare(comments[j].CreatedAt); cmp != 0 {
+			return cmp < 0
+		}
+		return comments[i].ID < comments[j].ID
+	})
+
+	offset := opts.Offset()
+	if offset >= len(comments) {
+		return nil, nil
+	}
+	end := offset + opts.PerPage
+	if end > len(comments) {
+		end = len(comments)
+	}
+
+	return comments[offset:end], nil
+}
+
+// ListRecentByTasks implements CommentStore
+func (r *MemoryCommentRepository) ListRecentByTasks(ctx context.Context, taskIDs []string, limit int) ([]*model.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	byTask := make(map[string][]*model.Comment)
+	for _, comment := range r.comments {
+		if slices.Contains(taskIDs, comment.TaskID) {
+			copied := *comment
+			byTask[comment.TaskID] = append(byTask[comment.TaskID], &copied)
+		}
+	}
+
+	var comments []*model.Comment
+	for _, taskID := range taskIDs {
+		recent := byTask[taskID]
+		delete(byTask, taskID)
+		sort.Slice(recent, func(i, j int) bool {
+			if cmp := recent[i].CreatedAt.Compare(recent[j].CreatedAt); cmp != 0 {
+				return cmp < 0
+			}
+			return recent[i].ID < recent[j].ID
+		})
+		if len(recent) > limit {
+			recent = recent[len(recent)-limit:]
+		}
+		comments = append(comments, recent...)
+	}
+	return comments, nil
+}
+
+// CountByTask implements CommentStore
+func (r *MemoryCommentRepository) CountByTask(ctx context.Context, taskID string) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	total := 0
+	for _, comment := range r.comments {
+		if comment.TaskID == taskID {
+			total++
+		}
+	}
+	return total, nil
+}
+
+// Delete implements CommentStore
+func (r *MemoryCommentRepository) Delete(ctx context.Context, taskID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	comment, ok := r.comments[id]
+	if !ok || comment.TaskID != taskID {
+		return ErrCommentNotFound
+	}
+	delete(r.comments, id)
+	return nil
+}
+
+// DeleteByTask implements CommentStore
+func (r *MemoryCommentRepository) DeleteByTask(ctx context.Context, taskID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, comment := range r.comments {
+		if comment.TaskID == taskID {
+			delete(r.comments, id)
+		}
+	}
+	return nil
+}
+
+// TasksWithComments implements CommentStore
+func (r *MemoryCommentRepository) TasksWithComments(ctx context.Context, taskIDs []string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var ids []string
+	for _, comment := range r.comments {
+		if slices.Contains(taskIDs, comment.TaskID) && !slices.Contains(ids, comment.TaskID) {
+			ids = append(ids, comment.TaskID)
+		}
+	}
+	return ids, nil
+}
+
+// hasComments reports whether the task has at least one comment
+func (r *MemoryCommentRepository) hasComments(taskID string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, comment := range r.comments {
+		if comment.TaskID == taskID {
+			return true
+		}
+	}
+	return false
+}
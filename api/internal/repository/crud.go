@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/moabdelazem/mutlitier_app/internal/database"
+)
+
+// CRUDStore is the storage contract of a simple resource, one that is
+// created, read, listed, updated and deleted by ID and nothing else. T is
+// the stored model and U the partial update applied by Update, whose nil
+// fields are left alone. It is implemented by the Postgres CRUDRepository
+// and the in-memory MemoryCRUDRepository.
+type CRUDStore[T, U any] interface {
+	Create(ctx context.Context, item *T) (*T, error)
+	Get(ctx context.Context, id string) (*T, error)
+	List(ctx context.Context) ([]*T, error)
+	Update(ctx context.Context, id string, changes *U) (*T, error)
+	Delete(ctx context.Context, id string) error
+}
+
+var (
+	_ CRUDStore[struct{}, struct{}] = (*CRUDRepository[struct{}, struct{}])(nil)
+	_ CRUDStore[struct{}, struct{}] = (*MemoryCRUDRepository[struct{}, struct{}])(nil)
+)
+
+// Table maps a simple resource onto its table for CRUDRepository. The
+// table's key is an id column, and it has an updated_at column that Update
+// sets.
+type Table[T, U any] struct {
+	Name string
+	// Item names one row in errors, such as "tag"
+	Item string
+	// Columns are selected in Scan order
+	Columns string
+	Scan    func(row scanner) (*T, error)
+	// Insert returns the values of InsertColumns for a new item
+	InsertColumns []string
+	Insert        func(item *T) []any
+	// Changes returns the values of UpdateColumns for an update, nil for
+	// a column that keeps its value
+	UpdateColumns []string
+	Changes       func(changes *U) []any
+	// OrderBy orders List
+	OrderBy string
+	// NotFound is returned for a missing row, Exists for a unique
+	// violation
+	NotFound error
+	Exists   error
+}
+
+// CRUDRepository handles database operations for a simple resource. Only
+// the table mapping is specific to the resource; the queries are built
+// from it once.
+type CRUDRepository[T, U any] struct {
+	db    *database.DB
+	table *Table[T, U]
+
+	insertQuery string
+	getQuery    string
+	listQuery   string
+	updateQuery string
+	deleteQuery string
+}
+
+// NewCRUDRepository creates a new CRUDRepository for table
+func NewCRUDRepository[T, U any](db *database.DB, table *Table[T, U]) *CRUDRepository[T, U] {
+	placeholders := make([]string, len(table.InsertColumns))
+	for i := range placeholders {
+		placeholders[i] = "$" + strconv.Itoa(i+1)
+	}
+	sets := make([]string, 0, len(table.UpdateColumns)+1)
+	for i, column := range table.UpdateColumns {
+		sets = append(sets, fmt.Sprintf("%s = COALESCE($%d, %s)", column, i+2, column))
+	}
+	sets = append(sets, "updated_at = NOW()")
+
+	return &CRUDRepository[T, U]{
+		db:    db,
+		table: table,
+		insertQuery: `INSERT INTO ` + table.Name + ` (` + strings.Join(table.InsertColumns, ", ") + `)
+			VALUES (` + strings.Join(placeholders, ", ") + `) RETURNING ` + table.Columns,
+		getQuery:  `SELECT ` + table.Columns + ` FROM ` + table.Name + ` WHERE id = $1`,
+		listQuery: `SELECT ` + table.Columns + ` FROM ` + table.Name + ` ORDER BY ` + table.OrderBy,
+		updateQuery: `UPDATE ` + table.Name + ` SET ` + strings.Join(sets, ", ") + `
+			WHERE id = $1 RETURNING ` + table.Columns,
+		deleteQuery: `DELETE FROM ` + table.Name + ` WHERE id = $1`,
+	}
+}
+
+// Create implements CRUDStore
+func (r *CRUDRepository[T, U]) Create(ctx context.Context, item *T) (*T, error) {
+	created, err := r.table.Scan(r.db.QueryRowContext(ctx, r.insertQuery, r.table.Insert(item)...))
+	if err != nil {
+		if r.table.Exists != nil && isUniqueViolation(err) {
+			return nil, r.table.Exists
+		}
+		return nil, fmt.Errorf("failed to create %s: %w", r.table.Item, err)
+	}
+
+	return created, nil
+}
+
+// Get implements CRUDStore
+func (r *CRUDRepository[T, U]) Get(ctx context.Context, id string) (*T, error) {
+	item, err := r.table.Scan(r.db.QueryRowContext(ctx, r.getQuery, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, r.table.NotFound
+		}
+		return nil, fmt.Errorf("failed to get %s: %w", r.table.Item, err)
+	}
+
+	return item, nil
+}
+
+// List implements CRUDStore
+func (r *CRUDRepository[T, U]) List(ctx context.Context) ([]*T, error) {
+	rows, err := r.db.QueryContext(ctx, r.listQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", r.table.Name, err)
+	}
+	defer rows.Close()
+
+	var items []*T
+	for rows.Next() {
+		item, err := r.table.Scan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", r.table.Item, err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating %s: %w", r.table.Name, err)
+	}
+
+	return items, nil
+}
+
+// Update implements CRUDStore
+func (r *CRUDRepository[T, U]) Update(ctx context.Context, id string, changes *U) (*T, error) {
+	args := append([]any{id}, r.table.Changes(changes)...)
+
+	updated, err := r.table.Scan(r.db.QueryRowContext(ctx, r.updateQuery, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, r.table.NotFound
+		}
+		if r.table.Exists != nil && isUniqueViolation(err) {
+			return nil, r.table.Exists
+		}
+		return nil, fmt.Errorf("failed to update %s: %w", r.table.Item, err)
+	}
+
+	return updated, nil
+}
+
+// Delete implements CRUDStore
+func (r *CRUDRepository[T, U]) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, r.deleteQuery, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", r.table.Item, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return r.table.NotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MemoryTable describes a simple resource to MemoryCRUDRepository. Items
+// are copied by value, so T must not share slices or maps between copies.
+type MemoryTable[T, U any] struct {
+	ID func(item *T) string
+	// Key returns the value that must be unique across items, such as a
+	// name; nil when nothing is
+	Key func(item *T) string
+	// Apply applies the non-nil fields of changes to item
+	Apply func(item *T, changes *U)
+	// Stamp sets item's timestamps: both on create, only the update time
+	// otherwise
+	Stamp func(item *T, at time.Time, created bool)
+	// Compare orders List
+	Compare func(a, b *T) int
+	// NotFound is returned for a missing item, Exists for a duplicate Key
+	NotFound error
+	Exists   error
+}
+
+// MemoryCRUDRepository is an in-memory CRUDStore used by demo mode
+type MemoryCRUDRepository[T, U any] struct {
+	mu    sync.RWMutex
+	table *MemoryTable[T, U]
+	items map[string]*T
+}
+
+// NewMemoryCRUDRepository creates a new MemoryCRUDRepository for table
+func NewMemoryCRUDRepository[T, U any](table *MemoryTable[T, U]) *MemoryCRUDRepository[T, U] {
+	return &MemoryCRUDRepository[T, U]{table: table, items: make(map[string]*T)}
+}
+
+// Create implements CRUDStore
+func (r *MemoryCRUDRepository[T, U]) Create(ctx context.Context, item *T) (*T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.taken(item, "") {
+		return nil, r.table.Exists
+	}
+
+	created := *item
+	r.table.Stamp(&created, time.Now().UTC(), true)
+	r.items[r.table.ID(&created)] = &created
+
+	copied := created
+	return &copied, nil
+}
+
+// Get implements CRUDStore
+func (r *MemoryCRUDRepository[T, U]) Get(ctx context.Context, id string) (*T, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	item, ok := r.items[id]
+	if !ok {
+		return nil, r.table.NotFound
+	}
+	copied := *item
+	return &copied, nil
+}
+
+// List implements CRUDStore
+func (r *MemoryCRUDRepository[T, U]) List(ctx context.Context) ([]*T, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	items := make([]*T, 0, len(r.items))
+	for _, item := range r.items {
+		copied := *item
+		items = append(items, &copied)
+	}
+	slices.SortFunc(items, r.table.Compare)
+	return items, nil
+}
+
+// Update implements CRUDStore
+func (r *MemoryCRUDRepository[T, U]) Update(ctx context.Context, id string, changes *U) (*T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	item, ok := r.items[id]
+	if !ok {
+		return nil, r.table.NotFound
+	}
+
+	updated := *item
+	r.table.Apply(&updated, changes)
+	if r.taken(&updated, id) {
+		return nil, r.table.Exists
+	}
+	r.table.Stamp(&updated, time.Now().UTC(), false)
+	*item = updated
+
+	return &updated, nil
+}
+
+// Delete implements CRUDStore
+func (r *MemoryCRUDRepository[T, U]) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.items[id]; !ok {
+		return r.table.NotFound
+	}
+	delete(r.items, id)
+	return nil
+}
+
+// taken reports whether another item than the one with id has item's
+// key; callers hold mu
+func (r *MemoryCRUDRepository[T, U]) taken(item *T, id string) bool {
+	if r.table.Key == nil {
+		return false
+	}
+	key := r.table.Key(item)
+	for otherID, other := range r.items {
+		if otherID != id && r.table.Key(other) == key {
+			return true
+		}
+	}
+	return false
+}
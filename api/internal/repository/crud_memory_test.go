@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryTagTable maps tags onto MemoryCRUDRepository the way a new
+// resource would be
+var memoryTagTable = &MemoryTable[model.Tag, model.UpdateTagRequest]{
+	ID:  func(tag *model.Tag) string { return tag.ID },
+	Key: func(tag *model.Tag) string { return tag.Name },
+	Apply: func(tag *model.Tag, updates *model.UpdateTagRequest) {
+		if updates.Name != nil {
+			tag.Name = *updates.Name
+		}
+		if updates.Color != nil {
+			tag.Color = *updates.Color
+		}
+	},
+	Stamp: func(tag *model.Tag, at time.Time, created bool) {
+		if created {
+			tag.CreatedAt = at
+		}
+		tag.UpdatedAt = at
+	},
+	Compare:  func(a, b *model.Tag) int { return strings.Compare(a.Name, b.Name) },
+	NotFound: ErrTagNotFound,
+	Exists:   ErrTagExists,
+}
+
+func TestMemoryCRUDRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryCRUDRepository(memoryTagTable)
+
+	backend, err := repo.Create(ctx, &model.Tag{ID: "1", Name: "backend"})
+	require.NoError(t, err)
+	assert.False(t, backend.CreatedAt.IsZero())
+	_, err = repo.Create(ctx, &model.Tag{ID: "2", Name: "bug"})
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, &model.Tag{ID: "3", Name: "bug"})
+	assert.ErrorIs(t, err, ErrTagExists)
+
+	// Returned items are copies
+	backend.Name = "changed"
+	got, err := repo.Get(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "backend", got.Name)
+
+	name, color := "api", "#00ff00"
+	updated, err := repo.Update(ctx, "1", &model.UpdateTagRequest{Name: &name, Color: &color})
+	require.NoError(t, err)
+	assert.Equal(t, "api", updated.Name)
+	assert.Equal(t, "#00ff00", updated.Color)
+	assert.Equal(t, got.CreatedAt, updated.CreatedAt)
+
+	// A clashing update leaves the item as it was
+	taken := "bug"
+	_, err = repo.Update(ctx, "1", &model.UpdateTagRequest{Name: &taken})
+	assert.ErrorIs(t, err, ErrTagExists)
+	got, err = repo.Get(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "api", got.Name)
+
+	tags, err := repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, tags, 2)
+	assert.Equal(t, "api", tags[0].Name)
+	assert.Equal(t, "bug", tags[1].Name)
+
+	require.NoError(t, repo.Delete(ctx, "1"))
+	assert.ErrorIs(t, repo.Delete(ctx, "1"), ErrTagNotFound)
+	_, err = repo.Get(ctx, "1")
+	assert.ErrorIs(t, err, ErrTagNotFound)
+	_, err = repo.Update(ctx, "1", &model.UpdateTagRequest{})
+	assert.ErrorIs(t, err, ErrTagNotFound)
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// EmbeddingStore is the storage contract for task embeddings. Both task
+// stores implement it, since comparing embeddings reads the tasks.
+type EmbeddingStore interface {
+	// ListEmbeddingsDue returns up to limit live, unarchived tasks without
+	// an embedding from embedModel or with one older than the task, least
+	// recently updated first
+	ListEmbeddingsDue(ctx context.Context, embedModel string, limit int) ([]*model.EmbeddingDue, error)
+	// GetEmbedding returns ErrEmbeddingNotFound when the task has no
+	// embedding from embedModel
+	GetEmbedding(ctx context.Context, taskID, embedModel string) (*model.TaskEmbedding, error)
+	// SaveEmbedding stores a task's embedding in place of any other one.
+	// It returns ErrTaskNotFound when the task is gone.
+	SaveEmbedding(ctx context.Context, embedding *model.TaskEmbedding) error
+	// TouchEmbedding marks the task's embedding from embedModel as made
+	// for the task version updated at taskUpdatedAt
+	TouchEmbedding(ctx context.Context, taskID, embedModel string, taskUpdatedAt time.Time) error
+	// SimilarTasks returns up to limit live, unarchived tasks other than
+	// exclude whose embedding from embedModel has a cosine similarity of
+	// at least threshold to vector, most similar first. Under a scope it
+	// only returns tasks the scope reaches.
+	SimilarTasks(ctx context.Context, embedModel string, vector []float32, exclude string, threshold float64, limit int) ([]*model.TaskMatch, error)
+}
+
+var (
+	_ EmbeddingStore = (*TaskRepository)(nil)
+	_ EmbeddingStore = (*MemoryTaskRepository)(nil)
+)
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+var (
+	ErrEmbeddingNotFound = errors.New("task embedding not found")
+)
+
+// encodeVector formats v as a pgvector literal, e.g. [0.1,0.2]
+func encodeVector(v []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, x := range v {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(x), 'g', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// decodeVector parses a pgvector literal
+func decodeVector(s string) ([]float32, error) {
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+	if s == "" {
+		return []float32{}, nil
+	}
+	parts := strings.Split(s, ",")
+	v := make([]float32, len(parts))
+	for i, part := range parts {
+		x, err := strconv.ParseFloat(strings.TrimSpace(part), 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vector: %w", err)
+		}
+		v[i] = float32(x)
+	}
+	return v, nil
+}
+
+// ListEmbeddingsDue implements EmbeddingStore
+func (r *TaskRepository) ListEmbeddingsDue(ctx context.Context, embedModel string, limit int) ([]*model.EmbeddingDue, error) {
+	query := `
+		SELECT ` + taskColumns + `, CASE WHEN e.model = $1 THEN e.content_hash ELSE '' END
+		FROM tasks
+		LEFT JOIN task_embeddings e ON e.task_id = tasks.id
+		WHERE deleted_at IS NULL AND NOT archived
+			AND (e.task_id IS NULL OR e.model <> $1 OR e.task_updated_at < tasks.updated_at)
+		ORDER BY tasks.updated_at, tasks.id
+		LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, query, embedModel, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks to embed: %w", err)
+	}
+	defer rows.Close()
+
+	var due []*model.EmbeddingDue
+	for rows.Next() {
+		var hash string
+		task, err := scanTask(trailingScanner{rows, []any{&hash}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan task to embed: %w", err)
+		}
+		due = append(due, &model.EmbeddingDue{Task: task, ContentHash: hash})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tasks to embed: %w", err)
+	}
+
+	return due, nil
+}
+
+// GetEmbedding implements EmbeddingStore
+func (r *TaskRepository) GetEmbedding(ctx context.Context, taskID, embedModel string) (*model.TaskEmbedding, error) {
+	query := `
+		SELECT task_id, model, embedding::text, content_hash, task_updated_at
+		FROM task_embeddings WHERE task_id = $1 AND model = $2`
+
+	var embedding model.TaskEmbedding
+	var vector string
+	err := r.db.QueryRowContext(ctx, query, taskID, embedModel).Scan(&embedding.TaskID, &embedding.Model,
+		&vector, &embedding.ContentHash, &embedding.TaskUpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrEmbeddingNotFound
+		}
+		return nil, fmt.Errorf("failed to get task embedding: %w", err)
+	}
+	if embedding.Vector, err = decodeVector(vector); err != nil {
+		return nil, fmt.Errorf("failed to get task embedding: %w", err)
+	}
+
+	return &embedding, nil
+}
+
+// SaveEmbedding implements EmbeddingStore
+func (r *TaskRepository) SaveEmbedding(ctx context.Context, embedding *model.TaskEmbedding) error {
+	query := `
+		INSERT INTO task_embeddings (task_id, model, embedding, content_hash, task_updated_at)
+		VALUES ($1, $2, $3::vector, $4, $5)
+		ON CONFLICT (task_id) DO UPDATE SET
+			model = EXCLUDED.model,
+			embedding = EXCLUDED.embedding,
+			content_hash = EXCLUDED.content_hash,
+			task_updated_at = EXCLUDED.task_updated_at,
+			embedded_at = NOW()`
+
+	_, err := r.db.ExecContext(ctx, query, embedding.TaskID, embedding.Model, encodeVector(embedding.Vector),
+		embedding.ContentHash, embedding.TaskUpdatedAt)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return ErrTaskNotFound
+		}
+		return fmt.Errorf("failed to save task embedding: %w", err)
+	}
+
+	return nil
+}
+
+// TouchEmbedding implements EmbeddingStore
+func (r *TaskRepository) TouchEmbedding(ctx context.Context, taskID, embedModel string, taskUpdatedAt time.Time) error {
+	query := `UPDATE task_embeddings SET task_updated_at = $3 WHERE task_id = $1 AND model = $2`
+
+	if _, err := r.db.ExecContext(ctx, query, taskID, embedModel, taskUpdatedAt); err != nil {
+		return fmt.Errorf("failed to touch task embedding: %w", err)
+	}
+	return nil
+}
+
+// SimilarTasks implements EmbeddingStore
+func (r *TaskRepository) SimilarTasks(ctx context.Context, embedModel string, vector []float32, exclude string, threshold float64, limit int) ([]*model.TaskMatch, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// <=> is pgvector's cosine distance, one minus the similarity
+	query := `
+		SELECT ` + taskColumns + `, 1 - (e.embedding <=> $2::vector)
+		FROM task_embeddings e
+		JOIN tasks ON tasks.id = e.task_id
+		WHERE e.model = $1 AND tasks.id::text <> $3 AND deleted_at IS NULL AND NOT archived
+			AND 1 - (e.embedding <=> $2::vector) >= $4 AND ` + taskFilter("tasks", "$6") + `
+		ORDER BY e.embedding <=> $2::vector, tasks.id
+		LIMIT $5`
+
+	rows, err := r.db.QueryContext(ctx, query, embedModel, encodeVector(vector), exclude, threshold, limit, owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find similar tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []*model.TaskMatch
+	for rows.Next() {
+		var similarity float64
+		task, err := scanTask(trailingScanner{rows, []any{&similarity}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan similar task: %w", err)
+		}
+		matches = append(matches, &model.TaskMatch{Task: task, Similarity: similarity})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating similar tasks: %w", err)
+	}
+
+	return matches, nil
+}
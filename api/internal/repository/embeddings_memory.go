@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// ListEmbeddingsDue implements EmbeddingStore
+func (r *MemoryTaskRepository) ListEmbeddingsDue(ctx context.Context, embedModel string, limit int) ([]*model.EmbeddingDue, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tasks []*model.Task
+	for _, task := range r.tasks {
+		if task.DeletedAt != nil || task.Archived {
+			continue
+		}
+		embedding, ok := r.embedded[task.ID]
+		if ok && embedding.Model == embedModel && !embedding.TaskUpdatedAt.Before(task.UpdatedAt) {
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if !tasks[i].UpdatedAt.Equal(tasks[j].UpdatedAt) {
+			return tasks[i].UpdatedAt.Before(tasks[j].UpdatedAt)
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+
+	due := make([]*model.EmbeddingDue, 0, min(limit, len(tasks)))
+	for _, task := range tasks[:min(limit, len(tasks))] {
+		var hash string
+		if embedding, ok := r.embedded[task.ID]; ok && embedding.Model == embedModel {
+			hash = embedding.ContentHash
+		}
+		due = append(due, &model.EmbeddingDue{Task: copyTask(task), ContentHash: hash})
+	}
+	return due, nil
+}
+
+// GetEmbedding implements EmbeddingStore
+func (r *MemoryTaskRepository) GetEmbedding(ctx context.Context, taskID, embedModel string) (*model.TaskEmbedding, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	embedding, ok := r.embedded[taskID]
+	if !ok || embedding.Model != embedModel {
+		return nil, ErrEmbeddingNotFound
+	}
+	return copyEmbedding(embedding), nil
+}
+
+// SaveEmbedding implements EmbeddingStore
+func (r *MemoryTaskRepository) SaveEmbedding(ctx context.Context, embedding *model.TaskEmbedding) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tasks[embedding.TaskID]; !ok {
+		return ErrTaskNotFound
+	}
+	r.embedded[embedding.TaskID] = copyEmbedding(embedding)
+	return nil
+}
+
+// TouchEmbedding implements EmbeddingStore
+func (r *MemoryTaskRepository) TouchEmbedding(ctx context.Context, taskID, embedModel string, taskUpdatedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if embedding, ok := r.embedded[taskID]; ok && embedding.Model == embedModel {
+		embedding.TaskUpdatedAt = taskUpdatedAt
+	}
+	return nil
+}
+
+// SimilarTasks implements EmbeddingStore
+func (r *MemoryTaskRepository) SimilarTasks(ctx context.Context, embedModel string, vector []float32, exclude string, threshold float64, limit int) ([]*model.TaskMatch, error) {
+	if err := checkScope(ctx); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matches []*model.TaskMatch
+	for id, embedding := range r.embedded {
+		task := r.tasks[id]
+		if id == exclude || embedding.Model != embedModel || task.DeletedAt != nil || task.Archived || !r.visible(ctx, task) {
+			continue
+		}
+		if similarity := cosineSimilarity(vector, embedding.Vector); similarity >= threshold {
+			matches = append(matches, &model.TaskMatch{Task: task, Similarity: similarity})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Similarity != matches[j].Similarity {
+			return matches[i].Similarity > matches[j].Similarity
+		}
+		return matches[i].Task.ID < matches[j].Task.ID
+	})
+
+	matches = matches[:min(limit, len(matches))]
+	for _, match := range matches {
+		match.Task = copyTask(match.Task)
+	}
+	return matches, nil
+}
+
+// cosineSimilarity returns the cosine similarity of a and b, or 0 when
+// their dimensions differ or either is zero
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+func copyEmbedding(embedding *model.TaskEmbedding) *model.TaskEmbedding {
+	copied := *embedding
+	copied.Vector = slices.Clone(embedding.Vector)
+	return &copied
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// EscalationStore is the storage contract for escalation rules and the
+// escalations they made. Both task stores implement it, since finding the
+// tasks a rule has reached reads the tasks. Rules belong to the tenant
+// they were created in; within a tenant only its rules are reached.
+type EscalationStore interface {
+	// CreateRule returns ErrProjectNotFound when the rule's project is
+	// missing
+	CreateRule(ctx context.Context, rule *model.EscalationRule) (*model.EscalationRule, error)
+	// GetRule, UpdateRule and DeleteRule only reach the rules of project;
+	// others are ErrEscalationRuleNotFound
+	GetRule(ctx context.Context, project, id string) (*model.EscalationRule, error)
+	UpdateRule(ctx context.Context, rule *model.EscalationRule) (*model.EscalationRule, error)
+	DeleteRule(ctx context.Context, project, id string) error
+	// ListRules returns the rules of project, oldest first
+	ListRules(ctx context.Context, project string) ([]*model.EscalationRule, error)
+	// ListEnabledRules returns the enabled rules of every project
+	ListEnabledRules(ctx context.Context) ([]*model.EscalationRule, error)
+
+	// ListEscalationDue returns up to limit open, live tasks of the rule's
+	// tenant and project that have reached it at now and it has not escalated for
+	// their current due date, soonest due first
+	ListEscalationDue(ctx context.Context, rule *model.EscalationRule, now time.Time, limit int) ([]*model.Task, error)
+	// RecordEscalation stores an escalation and reports whether it did;
+	// false means the rule already escalated the task for that due date
+	RecordEscalation(ctx context.Context, escalation *model.Escalation) (bool, error)
+	// ListEscalations returns a page of project's escalations, newest first
+	ListEscalations(ctx context.Context, project string, opts *model.ListOptions) ([]*model.Escalation, error)
+	CountEscalations(ctx context.Context, project string) (int, error)
+}
+
+var (
+	_ EscalationStore = (*TaskRepository)(nil)
+	_ EscalationStore = (*MemoryTaskRepository)(nil)
+)
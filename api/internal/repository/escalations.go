@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+var (
+	ErrEscalationRuleNotFound = errors.New("escalation rule not found")
+)
+
+// escalationRuleColumns is the column list shared by every rule query, in
+// scanEscalationRule order
+const escalationRuleColumns = `id, project_key, name, trigger, threshold_hours, notify_owner, notify_assignee,
+	notify_users, bump_priority, enabled, created_by, created_at, updated_at, tenant_id`
+
+// escalationColumns is the column list shared by every escalation query,
+// in scanEscalation order
+const escalationColumns = `id, rule_id, rule_name, project_key, task_id, due_date, notified, priority_from, priority_to, created_at`
+
+// scanEscalationRule scans a row selected with escalationRuleColumns
+func scanEscalationRule(row scanner) (*model.EscalationRule, error) {
+	var rule model.EscalationRule
+	if err := row.Scan(&rule.ID, &rule.ProjectKey, &rule.Name, &rule.Trigger, &rule.ThresholdHours,
+		&rule.NotifyOwner, &rule.NotifyAssignee, pq.Array(&rule.NotifyUsers), &rule.BumpPriority,
+		&rule.Enabled, &rule.CreatedBy, &rule.CreatedAt, &rule.UpdatedAt, &rule.Tenant); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// scanEscalation scans a row selected with escalationColumns
+func scanEscalation(row scanner) (*model.Escalation, error) {
+	var escalation model.Escalation
+	if err := row.Scan(&escalation.ID, &escalation.RuleID, &escalation.RuleName, &escalation.ProjectKey,
+		&escalation.TaskID, &escalation.DueDate, pq.Array(&escalation.Notified),
+		&escalation.PriorityFrom, &escalation.PriorityTo, &escalation.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &escalation, nil
+}
+
+// CreateRule implements EscalationStore. The tenant_id column defaults to
+// the tenant of ctx's connection, which rule.Tenant already names.
+func (r *TaskRepository) CreateRule(ctx context.Context, rule *model.EscalationRule) (*model.EscalationRule, error) {
+	query := `
+		INSERT INTO escalation_rules (id, project_key, name, trigger, threshold_hours, notify_owner,
+			notify_assignee, notify_users, bump_priority, enabled, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING ` + escalationRuleColumns
+
+	created, err := scanEscalationRule(r.db.QueryRowContext(ctx, query, rule.ID, rule.ProjectKey, rule.Name,
+		rule.Trigger, rule.ThresholdHours, rule.NotifyOwner, rule.NotifyAssignee, pq.Array(rule.NotifyUsers),
+		rule.BumpPriority, rule.Enabled, rule.CreatedBy))
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return nil, ErrProjectNotFound
+		}
+		return nil, fmt.Errorf("failed to create escalation rule: %w", err)
+	}
+
+	return created, nil
+}
+
+// GetRule implements EscalationStore
+func (r *TaskRepository) GetRule(ctx context.Context, project, id string) (*model.EscalationRule, error) {
+	query := `SELECT ` + escalationRuleColumns + ` FROM escalation_rules WHERE id = $1 AND project_key = $2`
+
+	rule, err := scanEscalationRule(r.db.QueryRowContext(ctx, query, id, project))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrEscalationRuleNotFound
+		}
+		return nil, fmt.Errorf("failed to get escalation rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// UpdateRule implements EscalationStore
+func (r *TaskRepository) UpdateRule(ctx context.Context, rule *model.EscalationRule) (*model.EscalationRule, error) {
+	query := `
+		UPDATE escalation_rules SET name = $3, trigger = $4, threshold_hours = $5, notify_owner = $6,
+			notify_assignee = $7, notify_users = $8, bump_priority = $9, enabled = $10, updated_at = NOW()
+		WHERE id = $1 AND project_key = $2
+		RETURNING ` + escalationRuleColumns
+
+	updated, err := scanEscalationRule(r.db.QueryRowContext(ctx, query, rule.ID, rule.ProjectKey, rule.Name,
+		rule.Trigger, rule.ThresholdHours, rule.NotifyOwner, rule.NotifyAssignee, pq.Array(rule.NotifyUsers),
+		rule.BumpPriority, rule.Enabled))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrEscalationRuleNotFound
+		}
+		return nil, fmt.Errorf("failed to update escalation rule: %w", err)
+	}
+
+	return updated, nil
+}
+
+// DeleteRule implements EscalationStore
+func (r *TaskRepository) DeleteRule(ctx context.Context, project, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM escalation_rules WHERE id = $1 AND project_key = $2`, id, project)
+	if err != nil {
+		return fmt.Errorf("failed to delete escalation rule: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return ErrEscalationRuleNotFound
+	}
+
+	return nil
+}
+
+// ListRules implements EscalationStore
+func (r *TaskRepository) ListRules(ctx context.Context, project string) ([]*model.EscalationRule, error) {
+	query := `SELECT ` + escalationRuleColumns + ` FROM escalation_rules WHERE project_key = $1 ORDER BY created_at, id`
+	return r.listRules(ctx, query, project)
+}
+
+// ListEnabledRules implements EscalationStore
+func (r *TaskRepository) ListEnabledRules(ctx context.Context) ([]*model.EscalationRule, error) {
+	query := `SELECT ` + escalationRuleColumns + ` FROM escalation_rules WHERE enabled ORDER BY created_at, id`
+	return r.listRules(ctx, query)
+}
+
+func (r *TaskRepository) listRules(ctx context.Context, query string, args ...any) ([]*model.EscalationRule, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list escalation rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []*model.EscalationRule
+	for rows.Next() {
+		rule, err := scanEscalationRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan escalation rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating escalation rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// ListEscalationDue implements EscalationStore. The tenant is matched
+// explicitly too, so a connection outside row level security still only
+// sees the rule's tenant.
+func (r *TaskRepository) ListEscalationDue(ctx context.Context, rule *model.EscalationRule, now time.Time, limit int) ([]*model.Task, error) {
+	var reached string
+	switch rule.Trigger {
+	case model.EscalationDueSoon:
+		reached = `due_date > $2 AND due_date <= $2 + $3 * INTERVAL '1 hour'`
+	case model.EscalationOverdue:
+		reached = `due_date <= $2 - $3 * INTERVAL '1 hour'`
+	default:
+		return nil, nil
+	}
+
+	query := `
+		SELECT ` + taskColumns + ` FROM tasks
+		WHERE project_key = $1 AND ` + reached + `
+			AND status NOT IN ('completed', 'cancelled') AND deleted_at IS NULL AND NOT archived
+			AND NOT EXISTS (
+				SELECT 1 FROM task_escalations e
+				WHERE e.rule_id = $4 AND e.task_id = tasks.id AND e.due_date = tasks.due_date
+			)
+			AND tenant_id = $6
+		ORDER BY due_date, id
+		LIMIT $5`
+
+	rows, err := r.db.QueryContext(ctx, query, rule.ProjectKey, now, rule.ThresholdHours, rule.ID, limit, rule.Tenant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks to escalate: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTasks(rows)
+}
+
+// RecordEscalation implements EscalationStore
+func (r *TaskRepository) RecordEscalation(ctx context.Context, escalation *model.Escalation) (bool, error) {
+	query := `
+		INSERT INTO task_escalations (rule_id, rule_name, project_key, task_id, due_date, notified, priority_from, priority_to)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (rule_id, task_id, due_date) DO NOTHING`
+
+	result, err := r.db.ExecContext(ctx, query, escalation.RuleID, escalation.RuleName, escalation.ProjectKey,
+		escalation.TaskID, escalation.DueDate, pq.Array(escalation.Notified), escalation.PriorityFrom, escalation.PriorityTo)
+	if err != nil {
+		return false, fmt.Errorf("failed to record escalation: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// ListEscalations implements EscalationStore
+func (r *TaskRepository) ListEscalations(ctx context.Context, project string, opts *model.ListOptions) ([]*model.Escalation, error) {
+	query := `
+		SELECT ` + escalationColumns + ` FROM task_escalations
+		WHERE project_key = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.QueryContext(ctx, query, project, opts.PerPage, opts.Offset())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list escalations: %w", err)
+	}
+	defer rows.Close()
+
+	var escalations []*model.Escalation
+	for rows.Next() {
+		escalation, err := scanEscalation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan escalation: %w", err)
+		}
+		escalations = append(escalations, escalation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating escalations: %w", err)
+	}
+
+	return escalations, nil
+}
+
+// CountEscalations implements EscalationStore
+func (r *TaskRepository) CountEscalations(ctx context.Context, project string) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM task_escalations WHERE project_key = $1`
+	if err := r.db.QueryRowContext(ctx, query, project).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count escalations: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// CreateRule implements EscalationStore
+func (r *MemoryTaskRepository) CreateRule(ctx context.Context, rule *model.EscalationRule) (*model.EscalationRule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.project(ctx, rule.ProjectKey); !ok {
+		return nil, ErrProjectNotFound
+	}
+
+	created := copyEscalationRule(rule)
+	created.CreatedAt = time.Now().UTC()
+	created.UpdatedAt = created.CreatedAt
+	r.rules[created.ID] = created
+
+	return copyEscalationRule(created), nil
+}
+
+// GetRule implements EscalationStore
+func (r *MemoryTaskRepository) GetRule(ctx context.Context, project, id string) (*model.EscalationRule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rule, ok := r.rules[id]
+	if !ok || rule.ProjectKey != project || !inTenant(ctx, rule.Tenant) {
+		return nil, ErrEscalationRuleNotFound
+	}
+	return copyEscalationRule(rule), nil
+}
+
+// UpdateRule implements EscalationStore
+func (r *MemoryTaskRepository) UpdateRule(ctx context.Context, rule *model.EscalationRule) (*model.EscalationRule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.rules[rule.ID]
+	if !ok || existing.ProjectKey != rule.ProjectKey || !inTenant(ctx, existing.Tenant) {
+		return nil, ErrEscalationRuleNotFound
+	}
+
+	updated := copyEscalationRule(rule)
+	updated.CreatedBy, updated.CreatedAt, updated.Tenant = existing.CreatedBy, existing.CreatedAt, existing.Tenant
+	updated.UpdatedAt = time.Now().UTC()
+	r.rules[updated.ID] = updated
+
+	return copyEscalationRule(updated), nil
+}
+
+// DeleteRule implements EscalationStore
+func (r *MemoryTaskRepository) DeleteRule(ctx context.Context, project, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rule, ok := r.rules[id]
+	if !ok || rule.ProjectKey != project || !inTenant(ctx, rule.Tenant) {
+		return ErrEscalationRuleNotFound
+	}
+	delete(r.rules, id)
+	return nil
+}
+
+// ListRules implements EscalationStore
+func (r *MemoryTaskRepository) ListRules(ctx context.Context, project string) ([]*model.EscalationRule, error) {
+	return r.listRules(func(rule *model.EscalationRule) bool {
+		return rule.ProjectKey == project && inTenant(ctx, rule.Tenant)
+	}), nil
+}
+
+// ListEnabledRules implements EscalationStore
+func (r *MemoryTaskRepository) ListEnabledRules(ctx context.Context) ([]*model.EscalationRule, error) {
+	return r.listRules(func(rule *model.EscalationRule) bool { return rule.Enabled && inTenant(ctx, rule.Tenant) }), nil
+}
+
+// listRules returns the rules matching keep, oldest first
+func (r *MemoryTaskRepository) listRules(keep func(*model.EscalationRule) bool) []*model.EscalationRule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var rules []*model.EscalationRule
+	for _, rule := range r.rules {
+		if keep(rule) {
+			rules = append(rules, copyEscalationRule(rule))
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if !rules[i].CreatedAt.Equal(rules[j].CreatedAt) {
+			return rules[i].CreatedAt.Before(rules[j].CreatedAt)
+		}
+		return rules[i].ID < rules[j].ID
+	})
+	return rules
+}
+
+// ListEscalationDue implements EscalationStore
+func (r *MemoryTaskRepository) ListEscalationDue(ctx context.Context, rule *model.EscalationRule, now time.Time, limit int) ([]*model.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tasks []*model.Task
+	for _, task := range r.tasks {
+		if task.ProjectKey != rule.ProjectKey || task.DueDate == nil || task.Status.Closed() ||
+			task.DeletedAt != nil || task.Archived || !rule.Matches(*task.DueDate, now) {
+			continue
+		}
+		if slices.ContainsFunc(r.escalated, func(e *model.Escalation) bool {
+			return e.RuleID == rule.ID && e.TaskID == task.ID && e.DueDate.Equal(*task.DueDate)
+		}) {
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if !tasks[i].DueDate.Equal(*tasks[j].DueDate) {
+			return tasks[i].DueDate.Before(*tasks[j].DueDate)
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+
+	due := make([]*model.Task, 0, min(limit, len(tasks)))
+	for _, task := range tasks[:min(limit, len(tasks))] {
+		due = append(due, copyTask(task))
+	}
+	return due, nil
+}
+
+// RecordEscalation implements EscalationStore
+func (r *MemoryTaskRepository) RecordEscalation(ctx context.Context, escalation *model.Escalation) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range r.escalated {
+		if e.RuleID == escalation.RuleID && e.TaskID == escalation.TaskID && e.DueDate.Equal(escalation.DueDate) {
+			return false, nil
+		}
+	}
+
+	recorded := *escalation
+	recorded.ID = int64(len(r.escalated) + 1)
+	recorded.Notified = slices.Clone(escalation.Notified)
+	recorded.CreatedAt = time.Now().UTC()
+	r.escalated = append(r.escalated, &recorded)
+	return true, nil
+}
+
+// ListEscalations implements EscalationStore
+func (r *MemoryTaskRepository) ListEscalations(ctx context.Context, project string, opts *model.ListOptions) ([]*model.Escalation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matches := r.escalationsOf(project)
+	start := min(opts.Offset(), len(matches))
+	end := min(start+opts.PerPage, len(matches))
+
+	escalations := make([]*model.Escalation, 0, end-start)
+	for _, escalation := range matches[start:end] {
+		copied := *escalation
+		copied.Notified = slices.Clone(escalation.Notified)
+		escalations = append(escalations, &copied)
+	}
+	return escalations, nil
+}
+
+// CountEscalations implements EscalationStore
+func (r *MemoryTaskRepository) CountEscalations(ctx context.Context, project string) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.escalationsOf(project)), nil
+}
+
+// escalationsOf returns project's escalations, newest first; callers hold
+// the lock
+func (r *MemoryTaskRepository) escalationsOf(project string) []*model.Escalation {
+	var matches []*model.Escalation
+	for i := len(r.escalated) - 1; i >= 0; i-- {
+		if r.escalated[i].ProjectKey == project {
+			matches = append(matches, r.escalated[i])
+		}
+	}
+	return matches
+}
+
+func copyEscalationRule(rule *model.EscalationRule) *model.EscalationRule {
+	copied := *rule
+	copied.NotifyUsers = slices.Clone(rule.NotifyUsers)
+	return &copied
+}
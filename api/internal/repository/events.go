@@ -0,0 +1,349 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/database"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+)
+
+// EventStore is the storage contract for the short-retention task event
+// log, implemented by the Postgres EventRepository and MemoryEventRepository
+type EventStore interface {
+	// InTx runs fn in a transaction that the task store and Append join
+	// through fn's context, so a task change and its events are kept or
+	// lost together
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+
+	// Append stores an event and returns it with its ID and timestamp.
+	// The timestamp is when the ID was taken, not when the write began.
+	Append(ctx context.Context, event *model.TaskEvent) (*model.TaskEvent, error)
+
+	// ListAfter returns up to limit events with an ID greater than cursor
+	// and at most until, oldest first. Under an owner scope only events of
+	// tasks the owner reaches, deleted ones included, are returned. Events
+	// carry the tenant of their task.
+	ListAfter(ctx context.Context, cursor, until int64, limit int) ([]*model.TaskEvent, error)
+
+	// Settled returns the newest event ID that readers past after can
+	// safely move their cursor to. IDs are taken when an event is written
+	// but become visible when its transaction commits, so a missing ID
+	// may still be in flight; it is waited for until the event after it
+	// is older than window, and then taken for a rolled back write. Every
+	// tenant's events count, so other tenants' events are not mistaken
+	// for missing ones.
+	Settled(ctx context.Context, after int64, window time.Duration) (int64, error)
+
+	// Previous returns the retained event of taskID preceding the event
+	// with ID before, nil when there is none
+	Previous(ctx context.Context, taskID string, before int64) (*model.TaskEvent, error)
+
+	// Bounds returns the oldest and newest retained event IDs, 0 when empty
+	Bounds(ctx context.Context) (oldest, newest int64, err error)
+
+	// Purge removes events created before the given time
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+var (
+	_ EventStore = (*EventRepository)(nil)
+	_ EventStore = (*MemoryEventRepository)(nil)
+)
+
+// EventRepository stores task events in Postgres
+type EventRepository struct {
+	db *database.DB
+}
+
+// NewEventRepository creates a new EventRepository
+func NewEventRepository(db *database.DB) *EventRepository {
+	return &EventRepository{db: db}
+}
+
+// InTx implements EventStore
+func (r *EventRepository) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return r.db.InTx(ctx, fn)
+}
+
+// Append implements EventStore
+func (r *EventRepository) Append(ctx context.Context, event *model.TaskEvent) (*model.TaskEvent, error) {
+	query := `
+		INSERT INTO task_events (type, task_id, actor, payload, created_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, clock_timestamp())
+		RETURNING id, created_at
+	`
+
+	var payload []byte
+	if event.Task != nil {
+		var err error
+		if payload, err = json.Marshal(event.Task); err != nil {
+			return nil, fmt.Errorf("failed to encode event payload: %w", err)
+		}
+	}
+
+	appended := *event
+	if err := r.db.QueryRowContext(ctx, query, event.Type, event.TaskID, event.Actor, payload).Scan(&appended.ID, &appended.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to append event: %w", err)
+	}
+
+	return &appended, nil
+}
+
+// ListAfter implements EventStore
+func (r *EventRepository) ListAfter(ctx context.Context, cursor, until int64, limit int) ([]*model.TaskEvent, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT id, type, task_id, COALESCE(actor, ''), payload, created_at, tenant_id
+		FROM task_events
+		WHERE id > $1 AND id <= $4
+		  AND ($3::text IS NULL OR EXISTS (
+			SELECT 1 FROM tasks WHERE tasks.id = task_events.task_id AND ` + taskFilter("tasks", "$3") + `
+		  ))
+		ORDER BY id
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, cursor, limit, owner, until)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*model.TaskEvent
+	for rows.Next() {
+		var event model.TaskEvent
+		var payload []byte
+		if err := rows.Scan(&event.ID, &event.Type, &event.TaskID, &event.Actor, &payload, &event.CreatedAt, &event.Tenant); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		if payload != nil {
+			if err := json.Unmarshal(payload, &event.Task); err != nil {
+				return nil, fmt.Errorf("failed to decode event payload: %w", err)
+			}
+		}
+		events = append(events, &event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating events: %w", err)
+	}
+
+	return events, nil
+}
+
+// Previous implements EventStore
+func (r *EventRepository) Previous(ctx context.Context, taskID string, before int64) (*model.TaskEvent, error) {
+	query := `
+		SELECT id, type, task_id, COALESCE(actor, ''), payload, created_at
+		FROM task_events
+		WHERE task_id = $1 AND id < $2
+		ORDER BY id DESC
+		LIMIT 1
+	`
+
+	var event model.TaskEvent
+	var payload []byte
+	err := r.db.QueryRowContext(ctx, query, taskID, before).Scan(&event.ID, &event.Type, &event.TaskID, &event.Actor, &payload, &event.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get previous event: %w", err)
+	}
+	if payload != nil {
+		if err := json.Unmarshal(payload, &event.Task); err != nil {
+			return nil, fmt.Errorf("failed to decode event payload: %w", err)
+		}
+	}
+
+	return &event, nil
+}
+
+// Bounds implements EventStore
+func (r *EventRepository) Bounds(ctx context.Context) (int64, int64, error) {
+	query := `SELECT MIN(id), MAX(id) FROM task_events`
+
+	var oldest, newest sql.NullInt64
+	if err := r.db.QueryRowContext(ctx, query).Scan(&oldest, &newest); err != nil {
+		return 0, 0, fmt.Errorf("failed to get event bounds: %w", err)
+	}
+
+	return oldest.Int64, newest.Int64, nil
+}
+
+// Settled implements EventStore. Only events younger than window can
+// follow a missing ID that is still waited for, so the check reads the
+// created_at index rather than every event past after.
+func (r *EventRepository) Settled(ctx context.Context, after int64, window time.Duration) (int64, error) {
+	query := `
+		SELECT CASE
+			WHEN waiting.id IS NULL THEN (SELECT COALESCE(MAX(id), 0) FROM task_events)
+			ELSE (SELECT COALESCE(MAX(id), $1) FROM task_events WHERE id < waiting.id)
+		END
+		FROM (
+			SELECT MIN(e.id) AS id FROM task_events e
+			WHERE e.created_at > clock_timestamp() - $2 * INTERVAL '1 microsecond'
+			  AND e.id - 1 > $1
+			  AND NOT EXISTS (SELECT 1 FROM task_events p WHERE p.id = e.id - 1)
+		) AS waiting
+	`
+
+	// Run as no tenant: row level security would hide other tenants' events
+	var settled int64
+	if err := r.db.QueryRowContext(tenant.With(ctx, ""), query, after, window.Microseconds()).Scan(&settled); err != nil {
+		return 0, fmt.Errorf("failed to get settled event: %w", err)
+	}
+
+	return max(settled, after), nil
+}
+
+// Purge implements EventStore
+func (r *EventRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM task_events WHERE created_at < $1`
+
+	result, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge events: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return purged, nil
+}
+
+// MemoryEventRepository is an in-memory EventStore used by demo mode.
+// Scoped reads need the tasks the events are about, see FollowTasks.
+type MemoryEventRepository struct {
+	mu     sync.RWMutex
+	events []*model.TaskEvent
+	nextID int64
+	tasks  *MemoryTaskRepository
+}
+
+// NewMemoryEventRepository creates a new MemoryEventRepository
+func NewMemoryEventRepository() *MemoryEventRepository {
+	return &MemoryEventRepository{nextID: 1}
+}
+
+// FollowTasks makes scoped reads return the events of tasks reaches;
+// without it they fail with ErrUnscoped
+func (r *MemoryEventRepository) FollowTasks(tasks *MemoryTaskRepository) *MemoryEventRepository {
+	r.tasks = tasks
+	return r
+}
+
+// InTx implements EventStore. Memory writes cannot be rolled back, and
+// events are written last, once nothing else can fail.
+func (r *MemoryEventRepository) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// Append implements EventStore
+func (r *MemoryEventRepository) Append(ctx context.Context, event *model.TaskEvent) (*model.TaskEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	appended := *event
+	appended.ID = r.nextID
+	appended.CreatedAt = time.Now().UTC()
+	appended.Tenant = tenant.From(ctx)
+	if appended.Tenant == "" {
+		appended.Tenant = tenant.Default
+	}
+	r.nextID++
+	r.events = append(r.events, &appended)
+
+	copied := appended
+	return &copied, nil
+}
+
+// ListAfter implements EventStore
+func (r *MemoryEventRepository) ListAfter(ctx context.Context, cursor, until int64, limit int) ([]*model.TaskEvent, error) {
+	if err := checkScope(ctx); err != nil {
+		return nil, err
+	}
+	_, scoped := ScopeFrom(ctx)
+	if scoped && r.tasks == nil {
+		return nil, ErrUnscoped
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var events []*model.TaskEvent
+	for _, event := range r.events {
+		if event.ID > until {
+			break
+		}
+		if event.ID <= cursor || (scoped && !r.tasks.reaches(ctx, event.TaskID)) {
+			continue
+		}
+		copied := *event
+		events = append(events, &copied)
+		if len(events) == limit {
+			break
+		}
+	}
+	return events, nil
+}
+
+// Previous implements EventStore
+func (r *MemoryEventRepository) Previous(ctx context.Context, taskID string, before int64) (*model.TaskEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for i := len(r.events) - 1; i >= 0; i-- {
+		if event := r.events[i]; event.ID < before && event.TaskID == taskID {
+			copied := *event
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+// Bounds implements EventStore
+func (r *MemoryEventRepository) Bounds(ctx context.Context) (int64, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.events) == 0 {
+		return 0, 0, nil
+	}
+	return r.events[0].ID, r.events[len(r.events)-1].ID, nil
+}
+
+// Settled implements EventStore. Events are appended under a lock in ID
+// order, so none is ever in flight.
+func (r *MemoryEventRepository) Settled(ctx context.Context, after int64, window time.Duration) (int64, error) {
+	_, newest, err := r.Bounds(ctx)
+	return max(newest, after), err
+}
+
+// Purge implements EventStore
+func (r *MemoryEventRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.events[:0]
+	for _, event := range r.events {
+		if !event.CreatedAt.Before(before) {
+			kept = append(kept, event)
+		}
+	}
+	purged := int64(len(r.events) - len(kept))
+	r.events = kept
+	return purged, nil
+}
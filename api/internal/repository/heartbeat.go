@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/moabdelazem/mutlitier_app/internal/database"
+)
+
+// HeartbeatRepository writes the cdc_heartbeat row change data capture
+// connectors watch to keep their replication slot moving
+type HeartbeatRepository struct {
+	db *database.DB
+}
+
+// NewHeartbeatRepository creates a new HeartbeatRepository
+func NewHeartbeatRepository(db *database.DB) *HeartbeatRepository {
+	return &HeartbeatRepository{db: db}
+}
+
+// Beat sets the heartbeat to now
+func (r *HeartbeatRepository) Beat(ctx context.Context) error {
+	query := `UPDATE cdc_heartbeat SET beat_at = NOW() WHERE id = 1`
+
+	if _, err := r.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to write cdc heartbeat: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,12 @@
+package repository
+
+import "context"
+
+// HeartbeatStore is the storage contract for the CDC heartbeat,
+// implemented by the Postgres HeartbeatRepository
+type HeartbeatStore interface {
+	// Beat sets the heartbeat to now
+	Beat(ctx context.Context) error
+}
+
+var _ HeartbeatStore = (*HeartbeatRepository)(nil)
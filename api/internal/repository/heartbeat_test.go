@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeartbeatRepository_Beat(t *testing.T) {
+	db := openTestDB(t)
+	heartbeats := NewHeartbeatRepository(db)
+
+	err := db.InTx(context.Background(), func(ctx context.Context) error {
+		_, err := db.ExecContext(ctx, `UPDATE cdc_heartbeat SET beat_at = NOW() - INTERVAL '1 hour' WHERE id = 1`)
+		require.NoError(t, err)
+
+		require.NoError(t, heartbeats.Beat(ctx))
+
+		var current bool
+		require.NoError(t, db.QueryRowContext(ctx, `SELECT beat_at = NOW() FROM cdc_heartbeat WHERE id = 1`).Scan(&current))
+		assert.True(t, current)
+
+		// The row is the only one the connector watches
+		var rows int
+		require.NoError(t, db.QueryRowContext(ctx, `SELECT COUNT(*) FROM cdc_heartbeat`).Scan(&rows))
+		assert.Equal(t, 1, rows)
+		return errRollback
+	})
+	require.ErrorIs(t, err, errRollback)
+}
+
+// touch_updated_at keeps updated_at usable as the incremental snapshot
+// watermark: it moves forward on every write, even several in one
+// transaction where NOW() stands still, and cannot be set back
+func TestTouchUpdatedAt(t *testing.T) {
+	db := openTestDB(t)
+
+	err := db.InTx(context.Background(), func(ctx context.Context) error {
+		var id string
+		var created, previous time.Time
+		require.NoError(t, db.QueryRowContext(ctx,
+			`INSERT INTO tags (name) VALUES ('cdc-watermark') RETURNING id, created_at, updated_at`,
+		).Scan(&id, &created, &previous))
+
+		for _, color := range []string{"#000000", "#ffffff"} {
+			var touched time.Time
+			require.NoError(t, db.QueryRowContext(ctx,
+				`UPDATE tags SET color = $2 WHERE id = $1 RETURNING updated_at`, id, color,
+			).Scan(&touched))
+			assert.True(t, touched.After(previous), "updated_at %s is not after %s", touched, previous)
+			previous = touched
+		}
+
+		var createdAt, touched time.Time
+		require.NoError(t, db.QueryRowContext(ctx,
+			`UPDATE tags SET created_at = '2000-01-01', updated_at = '2000-01-01' WHERE id = $1 RETURNING created_at, updated_at`, id,
+		).Scan(&createdAt, &touched))
+		assert.True(t, createdAt.Equal(created), "created_at moved to %s", createdAt)
+		assert.True(t, touched.After(previous), "updated_at %s is not after %s", touched, previous)
+		return errRollback
+	})
+	require.ErrorIs(t, err, errRollback)
+}
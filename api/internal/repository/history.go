@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// ListHistory implements HistoryStore
+func (r *TaskRepository) ListHistory(ctx context.Context, taskID string, opts *model.ListOptions) ([]*model.TaskHistoryEntry, error) {
+	query := `
+		SELECT id, task_id, action, COALESCE(actor, ''), changes, version, created_at
+		FROM task_history
+		WHERE task_id = $1
+		ORDER BY id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, taskID, opts.PerPage, opts.Offset())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task history: %w", err)
+	}
+
+	return scanHistory(rows)
+}
+
+// CountHistory implements HistoryStore
+func (r *TaskRepository) CountHistory(ctx context.Context, taskID string) (int, error) {
+	query := `SELECT COUNT(*) FROM task_history WHERE task_id = $1`
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, query, taskID).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count task history: %w", err)
+	}
+
+	return total, nil
+}
+
+// activityWhere filters task_history by a model.ActivityFilter passed as
+// the first three parameters, and to the tasks the scope owner bound to
+// the fourth reaches
+var activityWhere = `
+	WHERE (cardinality($1::text[]) = 0 OR action = ANY($1))
+	  AND ($2::timestamptz IS NULL OR created_at >= $2)
+	  AND ($3::timestamptz IS NULL OR created_at < $3)
+	  AND ($4::text IS NULL OR EXISTS (
+		SELECT 1 FROM tasks WHERE tasks.id = task_history.task_id AND ` + taskFilter("tasks", "$4") + `
+	  ))
+`
+
+// ListActivity implements HistoryStore
+func (r *TaskRepository) ListActivity(ctx context.Context, filter *model.ActivityFilter, opts *model.ListOptions) ([]*model.TaskHistoryEntry, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT id, task_id, action, COALESCE(actor, ''), changes, version, created_at
+		FROM task_history` + activityWhere + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT $5 OFFSET $6
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, actionArray(filter.Actions), filter.From, filter.To, owner,
+		opts.PerPage, opts.Offset())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list activity: %w", err)
+	}
+
+	return scanHistory(rows)
+}
+
+// CountActivity implements HistoryStore
+func (r *TaskRepository) CountActivity(ctx context.Context, filter *model.ActivityFilter) (int, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return 0, err
+	}
+	query := `SELECT COUNT(*) FROM task_history` + activityWhere
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, query, actionArray(filter.Actions), filter.From, filter.To, owner).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count activity: %w", err)
+	}
+
+	return total, nil
+}
+
+func scanHistory(rows *sql.Rows) ([]*model.TaskHistoryEntry, error) {
+	defer rows.Close()
+
+	var entries []*model.TaskHistoryEntry
+	for rows.Next() {
+		var entry model.TaskHistoryEntry
+		var changes []byte
+		if err := rows.Scan(&entry.ID, &entry.TaskID, &entry.Action, &entry.Actor, &changes, &entry.Version, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan task history: %w", err)
+		}
+		if err := json.Unmarshal(changes, &entry.Changes); err != nil {
+			return nil, fmt.Errorf("failed to decode task history changes: %w", err)
+		}
+		entries = append(entries, &entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating task history: %w", err)
+	}
+
+	return entries, nil
+}
+
+// actionArray converts history actions into a text[] parameter
+func actionArray(actions []model.HistoryAction) any {
+	values := make([]string, len(actions))
+	for i, action := range actions {
+		values[i] = string(action)
+	}
+	return pq.Array(values)
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/audit"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// ListHistory implements HistoryStore
+func (r *MemoryTaskRepository) ListHistory(ctx context.Context, taskID string, opts *model.ListOptions) ([]*model.TaskHistoryEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	history := r.history[taskID]
+	var entries []*model.TaskHistoryEntry
+	// Stored oldest first, returned newest first
+	for i := len(history) - 1 - opts.Offset(); i >= 0 && len(entries) < opts.PerPage; i-- {
+		copied := *history[i]
+		entries = append(entries, &copied)
+	}
+	return entries, nil
+}
+
+// CountHistory implements HistoryStore
+func (r *MemoryTaskRepository) CountHistory(ctx context.Context, taskID string) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.history[taskID]), nil
+}
+
+// ListActivity implements HistoryStore
+func (r *MemoryTaskRepository) ListActivity(ctx context.Context, filter *model.ActivityFilter, opts *model.ListOptions) ([]*model.TaskHistoryEntry, error) {
+	if err := checkScope(ctx); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matches := r.activity(ctx, filter)
+	sort.Slice(matches, func(i, j int) bool {
+		if !matches[i].CreatedAt.Equal(matches[j].CreatedAt) {
+			return matches[i].CreatedAt.After(matches[j].CreatedAt)
+		}
+		return matches[i].ID > matches[j].ID
+	})
+
+	start := min(opts.Offset(), len(matches))
+	end := min(start+opts.PerPage, len(matches))
+
+	entries := make([]*model.TaskHistoryEntry, 0, end-start)
+	for _, entry := range matches[start:end] {
+		copied := *entry
+		entries = append(entries, &copied)
+	}
+	return entries, nil
+}
+
+// CountActivity implements HistoryStore
+func (r *MemoryTaskRepository) CountActivity(ctx context.Context, filter *model.ActivityFilter) (int, error) {
+	if err := checkScope(ctx); err != nil {
+		return 0, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.activity(ctx, filter)), nil
+}
+
+// activity returns the history entries matching filter of the tasks ctx
+// reaches. Callers hold the lock.
+func (r *MemoryTaskRepository) activity(ctx context.Context, filter *model.ActivityFilter) []*model.TaskHistoryEntry {
+	_, scoped := ScopeFrom(ctx)
+	var matches []*model.TaskHistoryEntry
+	for id, history := range r.history {
+		if task, ok := r.tasks[id]; scoped && (!ok || !r.visible(ctx, task)) {
+			continue
+		}
+		for _, entry := range history {
+			if len(filter.Actions) > 0 && !slices.Contains(filter.Actions, entry.Action) {
+				continue
+			}
+			if filter.From != nil && entry.CreatedAt.Before(*filter.From) {
+				continue
+			}
+			if filter.To != nil && !entry.CreatedAt.Before(*filter.To) {
+				continue
+			}
+			matches = append(matches, entry)
+		}
+	}
+	return matches
+}
+
+// record appends a history entry for a write that turned before into
+// after, mirroring the Postgres trigger. Callers hold the write lock.
+func (r *MemoryTaskRepository) record(ctx context.Context, action model.HistoryAction, before, after *model.Task) {
+	changes := model.DiffTasks(before, after)
+	if action == model.HistoryUpdated && len(changes) == 0 {
+		return
+	}
+
+	r.historyID++
+	r.history[after.ID] = append(r.history[after.ID], &model.TaskHistoryEntry{
+		ID:        r.historyID,
+		TaskID:    after.ID,
+		Action:    action,
+		Actor:     audit.Actor(ctx),
+		Changes:   changes,
+		Version:   after.Version,
+		CreatedAt: time.Now().UTC(),
+	})
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// HistoryStore reads the audit trail of task writes. Both task stores
+// implement it, since the trail is written together with the task: by a
+// trigger in the same transaction in Postgres, under the lock in memory.
+type HistoryStore interface {
+	// ListHistory returns a page of a task's history, newest first
+	ListHistory(ctx context.Context, taskID string, opts *model.ListOptions) ([]*model.TaskHistoryEntry, error)
+	CountHistory(ctx context.Context, taskID string) (int, error)
+
+	// ListActivity returns a page of all tasks' history, newest first
+	ListActivity(ctx context.Context, filter *model.ActivityFilter, opts *model.ListOptions) ([]*model.TaskHistoryEntry, error)
+	CountActivity(ctx context.Context, filter *model.ActivityFilter) (int, error)
+}
+
+var (
+	_ HistoryStore = (*TaskRepository)(nil)
+	_ HistoryStore = (*MemoryTaskRepository)(nil)
+)
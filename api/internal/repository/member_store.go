@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// MemberStore is the storage contract for tenant memberships, implemented
+// by the Postgres MemberRepository and the in-memory MemoryMemberRepository
+type MemberStore interface {
+	IsMember(ctx context.Context, tenant, user string) (bool, error)
+	// Add makes user a member of tenant, or returns ErrUserNotFound when
+	// the user is unknown. Adding a member twice is fine.
+	Add(ctx context.Context, tenant, user string) (*model.TenantMember, error)
+	// Remove returns ErrMemberNotFound when user is not a member
+	Remove(ctx context.Context, tenant, user string) error
+	List(ctx context.Context, tenant string) ([]*model.TenantMember, error)
+}
+
+var (
+	_ MemberStore = (*MemberRepository)(nil)
+	_ MemberStore = (*MemoryMemberRepository)(nil)
+)
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/moabdelazem/mutlitier_app/internal/database"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// ErrMemberNotFound is returned when a user is not a member of a tenant
+var ErrMemberNotFound = errors.New("tenant member not found")
+
+// MemberRepository handles database operations for tenant memberships
+type MemberRepository struct {
+	db *database.DB
+}
+
+// NewMemberRepository creates a new MemberRepository
+func NewMemberRepository(db *database.DB) *MemberRepository {
+	return &MemberRepository{db: db}
+}
+
+// IsMember implements MemberStore
+func (r *MemberRepository) IsMember(ctx context.Context, tenant, user string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM tenant_members WHERE tenant_id = $1 AND user_id = $2)`
+
+	var member bool
+	if err := r.db.QueryRowContext(ctx, query, tenant, user).Scan(&member); err != nil {
+		return false, fmt.Errorf("failed to check tenant member: %w", err)
+	}
+
+	return member, nil
+}
+
+// Add implements MemberStore
+func (r *MemberRepository) Add(ctx context.Context, tenant, user string) (*model.TenantMember, error) {
+	query := `
+		INSERT INTO tenant_members (tenant_id, user_id) VALUES ($1, $2)
+		ON CONFLICT (tenant_id, user_id) DO UPDATE SET tenant_id = EXCLUDED.tenant_id
+		RETURNING created_at
+	`
+
+	member := &model.TenantMember{Tenant: tenant, User: user}
+	if err := r.db.QueryRowContext(ctx, query, tenant, user).Scan(&member.CreatedAt); err != nil {
+		if isForeignKeyViolation(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to add tenant member: %w", err)
+	}
+
+	return member, nil
+}
+
+// Remove implements MemberStore
+func (r *MemberRepository) Remove(ctx context.Context, tenant, user string) error {
+	query := `DELETE FROM tenant_members WHERE tenant_id = $1 AND user_id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, tenant, user)
+	if err != nil {
+		return fmt.Errorf("failed to remove tenant member: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return ErrMemberNotFound
+	}
+
+	return nil
+}
+
+// List implements MemberStore
+func (r *MemberRepository) List(ctx context.Context, tenant string) ([]*model.TenantMember, error) {
+	query := `SELECT tenant_id, user_id, created_at FROM tenant_members WHERE tenant_id = $1 ORDER BY user_id`
+
+	rows, err := r.db.QueryContext(ctx, query, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenant members: %w", err)
+	}
+	defer rows.Close()
+
+	members := []*model.TenantMember{}
+	for rows.Next() {
+		var member model.TenantMember
+		if err := rows.Scan(&member.Tenant, &member.User, &member.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan tenant member: %w", err)
+		}
+		members = append(members, &member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate tenant members: %w", err)
+	}
+
+	return members, nil
+}
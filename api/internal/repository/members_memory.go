@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// MemoryMemberRepository is an in-memory MemberStore used by demo mode.
+// Every user is known to it.
+type MemoryMemberRepository struct {
+	mu      sync.RWMutex
+	members map[[2]string]time.Time
+}
+
+// NewMemoryMemberRepository creates a new MemoryMemberRepository
+func NewMemoryMemberRepository() *MemoryMemberRepository {
+	return &MemoryMemberRepository{members: make(map[[2]string]time.Time)}
+}
+
+// IsMember implements MemberStore
+func (r *MemoryMemberRepository) IsMember(ctx context.Context, tenant, user string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.members[[2]string{tenant, user}]
+	return ok, nil
+}
+
+// Add implements MemberStore
+func (r *MemoryMemberRepository) Add(ctx context.Context, tenant, user string) (*model.TenantMember, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := [2]string{tenant, user}
+	createdAt, ok := r.members[key]
+	if !ok {
+		createdAt = time.Now().UTC()
+		r.members[key] = createdAt
+	}
+	return &model.TenantMember{Tenant: tenant, User: user, CreatedAt: createdAt}, nil
+}
+
+// Remove implements MemberStore
+func (r *MemoryMemberRepository) Remove(ctx context.Context, tenant, user string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := [2]string{tenant, user}
+	if _, ok := r.members[key]; !ok {
+		return ErrMemberNotFound
+	}
+	delete(r.members, key)
+	return nil
+}
+
+// List implements MemberStore
+func (r *MemoryMemberRepository) List(ctx context.Context, tenant string) ([]*model.TenantMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	members := []*model.TenantMember{}
+	for key, createdAt := range r.members {
+		if key[0] == tenant {
+			members = append(members, &model.TenantMember{Tenant: tenant, User: key[1], CreatedAt: createdAt})
+		}
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].User < members[j].User })
+	return members, nil
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/database"
+)
+
+// NonceRepository stores request nonces for replay protection
+type NonceRepository struct {
+	db *database.DB
+}
+
+// NewNonceRepository creates a new NonceRepository
+func NewNonceRepository(db *database.DB) *NonceRepository {
+	return &NonceRepository{db: db}
+}
+
+// Remember records a nonce until expiresAt. It returns false if the nonce
+// was already seen, meaning the request is a replay. Expired nonces are
+// purged in the same round trip.
+func (r *NonceRepository) Remember(ctx context.Context, nonce string, expiresAt time.Time) (bool, error) {
+	query := `
+		WITH purged AS (
+			DELETE FROM request_nonces WHERE expires_at < NOW()
+		)
+		INSERT INTO request_nonces (nonce, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (nonce) DO NOTHING
+	`
+
+	result, err := r.db.ExecContext(ctx, query, nonce, expiresAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to store nonce: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected == 1, nil
+}
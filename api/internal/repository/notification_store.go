@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// NotificationStore is the storage contract for the per-user notification
+// queue, implemented by the Postgres NotificationRepository and the
+// in-memory MemoryNotificationRepository
+type NotificationStore interface {
+	// CreateMany enqueues notifications, skipping any already enqueued for
+	// the same user and event, and returns how many were added
+	CreateMany(ctx context.Context, notifications []*model.Notification) (int, error)
+
+	// ListByUser returns a page of the user's notifications, newest first
+	ListByUser(ctx context.Context, owner Scope, unreadOnly bool, opts *model.ListOptions) ([]*model.Notification, error)
+	CountByUser(ctx context.Context, owner Scope, unreadOnly bool) (int, error)
+
+	// MarkRead marks the user's unread notifications among ids as read at
+	// the given time, all of them when ids is empty. Ids of other users'
+	// notifications are ignored.
+	MarkRead(ctx context.Context, owner Scope, ids []int64, at time.Time) (int64, error)
+
+	// Purge removes notifications created before the given time
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+var (
+	_ NotificationStore = (*NotificationRepository)(nil)
+	_ NotificationStore = (*MemoryNotificationRepository)(nil)
+)
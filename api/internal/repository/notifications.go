@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/moabdelazem/mutlitier_app/internal/database"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// notificationColumns is the column list shared by every notification
+// query, in scanNotification order
+const notificationColumns = `id, user_id, event_id, type, task_id, COALESCE(actor, ''), created_at, read_at`
+
+// scanNotification scans a row selected with notificationColumns into a Notification
+func scanNotification(row scanner) (*model.Notification, error) {
+	var n model.Notification
+	if err := row.Scan(&n.ID, &n.User, &n.EventID, &n.Type, &n.TaskID, &n.Actor, &n.CreatedAt, &n.ReadAt); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// NotificationRepository handles database operations for notifications
+type NotificationRepository struct {
+	db *database.DB
+}
+
+// NewNotificationRepository creates a new NotificationRepository
+func NewNotificationRepository(db *database.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// notificationInsertBatch keeps inserts well under the Postgres limit of
+// 65535 parameters per statement
+const notificationInsertBatch = 1000
+
+// CreateMany implements NotificationStore, inserting in batches
+func (r *NotificationRepository) CreateMany(ctx context.Context, notifications []*model.Notification) (int, error) {
+	created := 0
+	for batch := range slices.Chunk(notifications, notificationInsertBatch) {
+		var query strings.Builder
+		query.WriteString(`INSERT INTO notifications (user_id, event_id, type, task_id, actor) VALUES `)
+		args := make([]any, 0, len(batch)*5)
+		for i, n := range batch {
+			if i > 0 {
+				query.WriteString(", ")
+			}
+			base := i * 5
+			fmt.Fprintf(&query, "($%d, $%d, $%d, $%d, NULLIF($%d, ''))", base+1, base+2, base+3, base+4, base+5)
+			args = append(args, n.User, n.EventID, n.Type, n.TaskID, n.Actor)
+		}
+		query.WriteString(` ON CONFLICT (user_id, event_id) DO NOTHING`)
+
+		result, err := r.db.ExecContext(ctx, query.String(), args...)
+		if err != nil {
+			return created, fmt.Errorf("failed to create notifications: %w", err)
+		}
+
+		added, err := result.RowsAffected()
+		if err != nil {
+			return created, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		created += int(added)
+	}
+
+	return created, nil
+}
+
+// ListByUser implements NotificationStore
+func (r *NotificationRepository) ListByUser(ctx context.Context, owner Scope, unreadOnly bool, opts *model.ListOptions) ([]*model.Notification, error) {
+	scope, err := owner.Where(1)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT ` + notificationColumns + `
+		FROM notifications
+		WHERE ` + scope + ` AND (NOT $2 OR read_at IS NULL)
+		ORDER BY id DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	offset := opts.Offset()
+
+	rows, err := r.db.QueryContext(ctx, query, owner.Owner(), unreadOnly, opts.PerPage, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var notifications []*model.Notification
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		notifications = append(notifications, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating notifications: %w", err)
+	}
+
+	return notifications, nil
+}
+
+// CountByUser implements NotificationStore
+func (r *NotificationRepository) CountByUser(ctx context.Context, owner Scope, unreadOnly bool) (int, error) {
+	scope, err := owner.Where(1)
+	if err != nil {
+		return 0, err
+	}
+	query := `SELECT COUNT(*) FROM notifications WHERE ` + scope + ` AND (NOT $2 OR read_at IS NULL)`
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, query, owner.Owner(), unreadOnly).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count notifications: %w", err)
+	}
+
+	return total, nil
+}
+
+// MarkRead implements NotificationStore
+func (r *NotificationRepository) MarkRead(ctx context.Context, owner Scope, ids []int64, at time.Time) (int64, error) {
+	scope, err := owner.Where(1)
+	if err != nil {
+		return 0, err
+	}
+	query := `
+		UPDATE notifications SET read_at = $3
+		WHERE ` + scope + ` AND read_at IS NULL AND (cardinality($2::BIGINT[]) = 0 OR id = ANY($2))
+	`
+
+	result, err := r.db.ExecContext(ctx, query, owner.Owner(), pq.Array(ids), at)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+
+	marked, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return marked, nil
+}
+
+// Purge implements NotificationStore
+func (r *NotificationRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM notifications WHERE created_at < $1`
+
+	result, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge notifications: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return purged, nil
+}
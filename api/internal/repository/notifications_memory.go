@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// MemoryNotificationRepository is an in-memory NotificationStore used by demo mode
+type MemoryNotificationRepository struct {
+	mu            sync.RWMutex
+	notifications []*model.Notification
+	nextID        int64
+}
+
+// NewMemoryNotificationRepository creates a new MemoryNotificationRepository
+func NewMemoryNotificationRepository() *MemoryNotificationRepository {
+	return &MemoryNotificationRepository{nextID: 1}
+}
+
+// Reset removes all notifications
+func (r *MemoryNotificationRepository) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.notifications = nil
+}
+
+// CreateMany implements NotificationStore
+func (r *MemoryNotificationRepository) CreateMany(ctx context.Context, notifications []*model.Notification) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	created := 0
+	for _, n := range notifications {
+		duplicate := slices.ContainsFunc(r.notifications, func(existing *model.Notification) bool {
+			return existing.User == n.User && existing.EventID == n.EventID
+		})
+		if duplicate {
+			continue
+		}
+
+		added := *n
+		added.ID = r.nextID
+		added.CreatedAt = time.Now().UTC()
+		added.ReadAt = nil
+		r.nextID++
+		r.notifications = append(r.notifications, &added)
+		created++
+	}
+	return created, nil
+}
+
+// ListByUser implements NotificationStore
+func (r *MemoryNotificationRepository) ListByUser(ctx context.Context, owner Scope, unreadOnly bool, opts *model.ListOptions) ([]*model.Notification, error) {
+	if err := owner.Check(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched []*model.Notification
+	for i := len(r.notifications) - 1; i >= 0; i-- {
+		n := r.notifications[i]
+		if owner.Owns(n.User) && (!unreadOnly || n.ReadAt == nil) {
+			copied := *n
+			matched = append(matched, &copied)
+		}
+	}
+
+	offset := opts.Offset()
+	if offset >= len(matched) {
+		return nil, nil
+	}
+	end := offset + opts.PerPage
+	if end > len(matched) {
+		end = len(matched)
+	}
+
+	return matched[offset:end], nil
+}
+
+// CountByUser implements NotificationStore
+func (r *MemoryNotificationRepository) CountByUser(ctx context.Context, owner Scope, unreadOnly bool) (int, error) {
+	if err := owner.Check(); err != nil {
+		return 0, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	total := 0
+	for _, n := range r.notifications {
+		if owner.Owns(n.User) && (!unreadOnly || n.ReadAt == nil) {
+			total++
+		}
+	}
+	return total, nil
+}
+
+// MarkRead implements NotificationStore
+func (r *MemoryNotificationRepository) MarkRead(ctx context.Context, owner Scope, ids []int64, at time.Time) (int64, error) {
+	if err := owner.Check(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var marked int64
+	for _, n := range r.notifications {
+		if !owner.Owns(n.User) || n.ReadAt != nil || (len(ids) > 0 && !slices.Contains(ids, n.ID)) {
+			continue
+		}
+		readAt := at
+		n.ReadAt = &readAt
+		marked++
+	}
+	return marked, nil
+}
+
+// Purge implements NotificationStore
+func (r *MemoryNotificationRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.notifications[:0]
+	for _, n := range r.notifications {
+		if !n.CreatedAt.Before(before) {
+			kept = append(kept, n)
+		}
+	}
+	purged := int64(len(r.notifications) - len(kept))
+	r.notifications = kept
+	return purged, nil
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/moabdelazem/mutlitier_app/internal/database"
+)
+
+// ProbeRepository runs canary writes against the health_probes table so
+// health checks exercise the full write path, not just connectivity
+type ProbeRepository struct {
+	db *database.DB
+}
+
+// NewProbeRepository creates a new ProbeRepository
+func NewProbeRepository(db *database.DB) *ProbeRepository {
+	return &ProbeRepository{db: db}
+}
+
+// Write inserts a probe row
+func (r *ProbeRepository) Write(ctx context.Context, id, payload string) error {
+	query := `INSERT INTO health_probes (id, payload) VALUES ($1, $2)`
+
+	if _, err := r.db.ExecContext(ctx, query, id, payload); err != nil {
+		return fmt.Errorf("failed to write probe: %w", err)
+	}
+
+	return nil
+}
+
+// Read returns the payload of a probe row
+func (r *ProbeRepository) Read(ctx context.Context, id string) (string, error) {
+	query := `SELECT payload FROM health_probes WHERE id = $1`
+
+	var payload string
+	if err := r.db.QueryRowContext(ctx, query, id).Scan(&payload); err != nil {
+		return "", fmt.Errorf("failed to read probe: %w", err)
+	}
+
+	return payload, nil
+}
+
+// Delete removes a probe row
+func (r *ProbeRepository) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM health_probes WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to delete probe: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// ProjectStore is the storage contract for projects. Both task stores
+// implement it, since creating a task creates its project when missing
+// and a project cannot be deleted while it has tasks.
+type ProjectStore interface {
+	CreateProject(ctx context.Context, project *model.Project) (*model.Project, error)
+	GetProject(ctx context.Context, key string) (*model.Project, error)
+	// ListProjects returns every project, ordered by key
+	ListProjects(ctx context.Context) ([]*model.Project, error)
+	UpdateProject(ctx context.Context, key string, updates *model.UpdateProjectRequest) (*model.Project, error)
+	// DeleteProject returns ErrProjectHasTasks while any task, deleted or
+	// not, belongs to the project
+	DeleteProject(ctx context.Context, key string) error
+}
+
+var (
+	_ ProjectStore = (*TaskRepository)(nil)
+	_ ProjectStore = (*MemoryTaskRepository)(nil)
+)
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+var (
+	ErrProjectNotFound = errors.New("project not found")
+	ErrProjectExists   = errors.New("project already exists")
+	ErrProjectHasTasks = errors.New("project has tasks")
+)
+
+// projectColumns is the column list shared by every project query, in
+// scanProject order
+const projectColumns = `key, name, description, created_at, updated_at`
+
+// scanProject scans a row selected with projectColumns into a Project
+func scanProject(row scanner) (*model.Project, error) {
+	var project model.Project
+	if err := row.Scan(&project.Key, &project.Name, &project.Description, &project.CreatedAt, &project.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// CreateProject implements ProjectStore
+func (r *TaskRepository) CreateProject(ctx context.Context, project *model.Project) (*model.Project, error) {
+	query := `INSERT INTO projects (key, name, description) VALUES ($1, $2, $3) RETURNING ` + projectColumns
+
+	created, err := scanProject(r.db.QueryRowContext(ctx, query, project.Key, project.Name, project.Description))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrProjectExists
+		}
+		return nil, fmt.Errorf("failed to create project: %w", err)
+	}
+
+	return created, nil
+}
+
+// GetProject implements ProjectStore
+func (r *TaskRepository) GetProject(ctx context.Context, key string) (*model.Project, error) {
+	query := `SELECT ` + projectColumns + ` FROM projects WHERE key = $1`
+
+	project, err := scanProject(r.db.QueryRowContext(ctx, query, key))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrProjectNotFound
+		}
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+
+	return project, nil
+}
+
+// ListProjects implements ProjectStore
+func (r *TaskRepository) ListProjects(ctx context.Context) ([]*model.Project, error) {
+	query := `SELECT ` + projectColumns + ` FROM projects ORDER BY key`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+	defer rows.Close()
+
+	var projects []*model.Project
+	for rows.Next() {
+		project, err := scanProject(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan project: %w", err)
+		}
+		projects = append(projects, project)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating projects: %w", err)
+	}
+
+	return projects, nil
+}
+
+// UpdateProject implements ProjectStore
+func (r *TaskRepository) UpdateProject(ctx context.Context, key string, updates *model.UpdateProjectRequest) (*model.Project, error) {
+	query := `
+		UPDATE projects
+		SET name = COALESCE($2, name),
+			description = COALESCE($3, description)
+		WHERE key = $1
+		RETURNING ` + projectColumns
+
+	updated, err := scanProject(r.db.QueryRowContext(ctx, query, key, updates.Name, updates.Description))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrProjectNotFound
+		}
+		return nil, fmt.Errorf("failed to update project: %w", err)
+	}
+
+	return updated, nil
+}
+
+// DeleteProject implements ProjectStore, relying on the tasks foreign key
+// to refuse projects that still have tasks. The project's numbering is
+// kept, so a project created again under the same key does not reuse the
+// references of its predecessor's tasks.
+func (r *TaskRepository) DeleteProject(ctx context.Context, key string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM projects WHERE key = $1`, key)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return ErrProjectHasTasks
+		}
+		return fmt.Errorf("failed to delete project: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrProjectNotFound
+	}
+
+	return nil
+}
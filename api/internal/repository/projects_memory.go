@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+)
+
+// projectID keys a project and its numbering by tenant, since each tenant
+// may use any key
+func projectID(tenantID, key string) string {
+	return tenantID + "/" + key
+}
+
+// projectTenant returns the tenant projects written under ctx belong to,
+// the default one when ctx is unscoped as in Postgres
+func projectTenant(ctx context.Context) string {
+	if id := tenant.From(ctx); id != "" {
+		return id
+	}
+	return tenant.Default
+}
+
+// project returns the project of key reachable under ctx. Unscoped
+// contexts reach every tenant's, the default tenant's first. Callers hold
+// the lock.
+func (r *MemoryTaskRepository) project(ctx context.Context, key string) (*model.Project, bool) {
+	if project, ok := r.projects[projectID(projectTenant(ctx), key)]; ok || tenant.From(ctx) != "" {
+		return project, ok
+	}
+	for _, project := range r.projects {
+		if project.Key == key {
+			return project, true
+		}
+	}
+	return nil, false
+}
+
+// CreateProject implements ProjectStore
+func (r *MemoryTaskRepository) CreateProject(ctx context.Context, project *model.Project) (*model.Project, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	created := *project
+	created.Tenant = projectTenant(ctx)
+	id := projectID(created.Tenant, created.Key)
+	if _, ok := r.projects[id]; ok {
+		return nil, ErrProjectExists
+	}
+
+	now := time.Now().UTC()
+	created.CreatedAt = now
+	created.UpdatedAt = now
+	r.projects[id] = &created
+
+	copied := created
+	return &copied, nil
+}
+
+// GetProject implements ProjectStore
+func (r *MemoryTaskRepository) GetProject(ctx context.Context, key string) (*model.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	project, ok := r.project(ctx, key)
+	if !ok {
+		return nil, ErrProjectNotFound
+	}
+	copied := *project
+	return &copied, nil
+}
+
+// ListProjects implements ProjectStore
+func (r *MemoryTaskRepository) ListProjects(ctx context.Context) ([]*model.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var projects []*model.Project
+	for _, project := range r.projects {
+		if !inTenant(ctx, project.Tenant) {
+			continue
+		}
+		copied := *project
+		projects = append(projects, &copied)
+	}
+	slices.SortFunc(projects, func(a, b *model.Project) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return projects, nil
+}
+
+// UpdateProject implements ProjectStore
+func (r *MemoryTaskRepository) UpdateProject(ctx context.Context, key string, updates *model.UpdateProjectRequest) (*model.Project, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	project, ok := r.project(ctx, key)
+	if !ok {
+		return nil, ErrProjectNotFound
+	}
+	if updates.Name != nil {
+		project.Name = *updates.Name
+	}
+	if updates.Description != nil {
+		project.Description = *updates.Description
+	}
+	project.UpdatedAt = time.Now().UTC()
+
+	copied := *project
+	return &copied, nil
+}
+
+// DeleteProject implements ProjectStore; numbering is kept as in Postgres.
+// Tasks do not record their tenant here, so any task under the key keeps
+// the project.
+func (r *MemoryTaskRepository) DeleteProject(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	project, ok := r.project(ctx, key)
+	if !ok {
+		return ErrProjectNotFound
+	}
+	for _, task := range r.tasks {
+		if task.ProjectKey == key {
+			return ErrProjectHasTasks
+		}
+	}
+
+	delete(r.projects, projectID(project.Tenant, key))
+	for id, rule := range r.rules {
+		if rule.ProjectKey == key && rule.Tenant == project.Tenant {
+			delete(r.rules, id)
+		}
+	}
+	for id, rule := range r.automated {
+		if rule.ProjectKey == key && rule.Tenant == project.Tenant {
+			delete(r.automated, id)
+		}
+	}
+	return nil
+}
+
+// ensureProject creates a project named after its key in ctx's tenant
+// unless it exists, and returns the projectID its tasks are numbered
+// under. Callers hold the lock.
+func (r *MemoryTaskRepository) ensureProject(ctx context.Context, key string) string {
+	id := projectID(projectTenant(ctx), key)
+	if _, ok := r.projects[id]; ok {
+		return id
+	}
+	now := time.Now().UTC()
+	r.projects[id] = &model.Project{Key: key, Name: key, Tenant: projectTenant(ctx), CreatedAt: now, UpdatedAt: now}
+	return id
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/moabdelazem/mutlitier_app/internal/audit"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// pendingRecurrence matches completed recurring tasks waiting for their
+// next occurrence, using idx_tasks_recurrence_pending
+const pendingRecurrence = `recurrence IS NOT NULL AND next_occurrence_id IS NULL AND status = 'completed'
+	AND deleted_at IS NULL AND NOT archived`
+
+// ListPendingRecurrences implements RecurrenceStore
+func (r *TaskRepository) ListPendingRecurrences(ctx context.Context, limit int) ([]*model.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE ` + pendingRecurrence + ` ORDER BY updated_at LIMIT $1`
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending recurrences: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTasks(rows)
+}
+
+// CreateOccurrence implements RecurrenceStore in a single statement. The
+// source row is locked, so a concurrent call waits and then finds it
+// already linked. Tags copied in the statement are not visible to its own
+// snapshot, so they are read from the source task instead. The job runs
+// outside any tenant, so the occurrence and its tags take the tenant of
+// the source task explicitly.
+func (r *TaskRepository) CreateOccurrence(ctx context.Context, fromID string, next *model.Task) (*model.Task, error) {
+	query := `
+		WITH source AS (
+			SELECT id, tenant_id FROM tasks WHERE id = $1 AND ` + pendingRecurrence + `
+			FOR UPDATE
+		), seq AS (
+			INSERT INTO task_sequences (project_key, last_number, tenant_id)
+			SELECT $3, 1, source.tenant_id FROM source
+			ON CONFLICT (tenant_id, project_key)
+			DO UPDATE SET last_number = task_sequences.last_number + 1
+			RETURNING last_number
+		), created AS (
+			INSERT INTO tasks (id, project_key, number, title, description, status, priority, due_date, updated_by, recurrence, assignee, owner_id, team_id, tenant_id)
+			SELECT $2, $3, seq.last_number, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, source.tenant_id FROM seq, source
+			RETURNING *
+		), tagged AS (
+			INSERT INTO task_tags (task_id, tag_id, tenant_id)
+			SELECT created.id, task_tags.tag_id, created.tenant_id FROM created, task_tags WHERE task_tags.task_id = $1
+		), linked AS (
+			UPDATE tasks SET next_occurrence_id = created.id, updated_by = $9
+			FROM created WHERE tasks.id = $1
+		)
+		SELECT id, project_key, number, title, description, status, priority, due_date, recurrence, next_occurrence_id::text,
+			assignee, owner_id, team_id::text, archived, position, version, created_at, updated_at, deleted_at,
+			ARRAY(SELECT tags.name FROM task_tags JOIN tags ON tags.id = task_tags.tag_id WHERE task_tags.task_id = $1 ORDER BY tags.name)
+		FROM created
+	`
+
+	created, err := scanTask(r.db.QueryRowContext(ctx, query,
+		fromID,
+		next.ID,
+		next.ProjectKey,
+		next.Title,
+		next.Description,
+		model.StatusPending,
+		next.Priority,
+		next.DueDate,
+		audit.Actor(ctx),
+		next.Recurrence,
+		next.Assignee,
+		next.Owner,
+		next.Team,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotRecurring
+		}
+		return nil, fmt.Errorf("failed to create occurrence: %w", err)
+	}
+
+	return created, nil
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// ListPendingRecurrences implements RecurrenceStore
+func (r *MemoryTaskRepository) ListPendingRecurrences(ctx context.Context, limit int) ([]*model.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tasks []*model.Task
+	for _, task := range r.tasks {
+		if isPendingRecurrence(task) {
+			tasks = append(tasks, task)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].UpdatedAt.Before(tasks[j].UpdatedAt) })
+
+	pending := make([]*model.Task, 0, min(limit, len(tasks)))
+	for _, task := range tasks[:min(limit, len(tasks))] {
+		pending = append(pending, copyTask(task))
+	}
+	return pending, nil
+}
+
+// CreateOccurrence implements RecurrenceStore
+func (r *MemoryTaskRepository) CreateOccurrence(ctx context.Context, fromID string, next *model.Task) (*model.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	source, ok := r.tasks[fromID]
+	if !ok || !isPendingRecurrence(source) {
+		return nil, ErrNotRecurring
+	}
+
+	created, err := r.create(ctx, next)
+	if err != nil {
+		return nil, err
+	}
+	created.Tags = slices.Clone(source.Tags)
+
+	// Linking changes no recorded field, so it is not in the history
+	id := created.ID
+	source.NextOccurrenceID = &id
+	touch(source)
+
+	return copyTask(created), nil
+}
+
+// isPendingRecurrence reports whether a completed recurring task is waiting
+// for its next occurrence
+func isPendingRecurrence(task *model.Task) bool {
+	return task.Recurrence != nil && task.NextOccurrenceID == nil && task.Status == model.StatusCompleted &&
+		task.DeletedAt == nil && !task.Archived
+}
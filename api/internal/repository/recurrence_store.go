@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// RecurrenceStore creates the next occurrences of completed recurring
+// tasks. Both task stores implement it, since the occurrence is created and
+// linked to its predecessor in one write.
+type RecurrenceStore interface {
+	// ListPendingRecurrences returns up to limit completed, live recurring
+	// tasks whose next occurrence has not been created, oldest first
+	ListPendingRecurrences(ctx context.Context, limit int) ([]*model.Task, error)
+
+	// CreateOccurrence creates next with the tags of the task fromID and
+	// links it as that task's next occurrence. It returns ErrNotRecurring
+	// when fromID is no longer pending, for example because another
+	// replica created the occurrence first or the task was reopened.
+	CreateOccurrence(ctx context.Context, fromID string, next *model.Task) (*model.Task, error)
+}
+
+var (
+	_ RecurrenceStore = (*TaskRepository)(nil)
+	_ RecurrenceStore = (*MemoryTaskRepository)(nil)
+)
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// RefreshTokenStore is the storage contract for refresh tokens,
+// implemented by the Postgres RefreshTokenRepository and the in-memory
+// MemoryRefreshTokenRepository
+type RefreshTokenStore interface {
+	Create(ctx context.Context, token *model.RefreshToken) error
+	// GetByHash returns the token with the given secret hash, whatever its
+	// state, or ErrRefreshTokenNotFound
+	GetByHash(ctx context.Context, hash string) (*model.RefreshToken, error)
+	// Rotate marks token id used and stores next, or returns
+	// ErrRefreshTokenUsed when id is already used or revoked, so only one
+	// of two refreshes racing with the same token wins
+	Rotate(ctx context.Context, id string, next *model.RefreshToken) error
+	// RevokeFamily revokes every token of a family not revoked yet
+	RevokeFamily(ctx context.Context, family string, at time.Time) error
+	// RevokeUser revokes every token of a user not revoked yet and returns
+	// how many families that touched
+	RevokeUser(ctx context.Context, owner Scope, at time.Time) (int, error)
+}
+
+var (
+	_ RefreshTokenStore = (*RefreshTokenRepository)(nil)
+	_ RefreshTokenStore = (*MemoryRefreshTokenRepository)(nil)
+)
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/database"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+var (
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	ErrRefreshTokenUsed     = errors.New("refresh token already used")
+)
+
+// refreshTokenColumns is the column list shared by every refresh token
+// query, in scanRefreshToken order
+const refreshTokenColumns = `id, user_id, family_id, token_hash, expires_at, used_at, revoked_at, created_at`
+
+// insertRefreshToken stores a new token, by Create and by Rotate
+const insertRefreshToken = `
+	INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, expires_at)
+	VALUES ($1, $2, $3, $4, $5)`
+
+// scanRefreshToken scans a row selected with refreshTokenColumns
+func scanRefreshToken(row scanner) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	if err := row.Scan(&token.ID, &token.User, &token.FamilyID, &token.Hash,
+		&token.ExpiresAt, &token.UsedAt, &token.RevokedAt, &token.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RefreshTokenRepository handles database operations for refresh tokens
+type RefreshTokenRepository struct {
+	db *database.DB
+}
+
+// NewRefreshTokenRepository creates a new RefreshTokenRepository
+func NewRefreshTokenRepository(db *database.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: db}
+}
+
+// Create implements RefreshTokenStore
+func (r *RefreshTokenRepository) Create(ctx context.Context, token *model.RefreshToken) error {
+	_, err := r.db.ExecContext(ctx, insertRefreshToken, token.ID, token.User, token.FamilyID, token.Hash, token.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+	return nil
+}
+
+// GetByHash implements RefreshTokenStore
+func (r *RefreshTokenRepository) GetByHash(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	query := `SELECT ` + refreshTokenColumns + ` FROM refresh_tokens WHERE token_hash = $1`
+
+	token, err := scanRefreshToken(r.db.QueryRowContext(ctx, query, hash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRefreshTokenNotFound
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	return token, nil
+}
+
+// Rotate implements RefreshTokenStore. The conditional update takes the
+// row lock, so a racing rotation waits and then matches no row.
+func (r *RefreshTokenRepository) Rotate(ctx context.Context, id string, next *model.RefreshToken) error {
+	tx, err := r.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE refresh_tokens SET used_at = NOW()
+		WHERE id = $1 AND used_at IS NULL AND revoked_at IS NULL`
+
+	result, err := tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to use refresh token: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to use refresh token: %w", err)
+	}
+	if rows == 0 {
+		return ErrRefreshTokenUsed
+	}
+
+	_, err = tx.ExecContext(ctx, insertRefreshToken, next.ID, next.User, next.FamilyID, next.Hash, next.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeFamily implements RefreshTokenStore
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, family string, at time.Time) error {
+	query := `UPDATE refresh_tokens SET revoked_at = $2 WHERE family_id = $1 AND revoked_at IS NULL`
+
+	if _, err := r.db.ExecContext(ctx, query, family, at); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeUser implements RefreshTokenStore
+func (r *RefreshTokenRepository) RevokeUser(ctx context.Context, owner Scope, at time.Time) (int, error) {
+	scope, err := owner.Where(1)
+	if err != nil {
+		return 0, err
+	}
+	query := `
+		WITH revoked AS (
+			UPDATE refresh_tokens SET revoked_at = $2
+			WHERE ` + scope + ` AND revoked_at IS NULL
+			RETURNING family_id
+		)
+		SELECT COUNT(DISTINCT family_id) FROM revoked`
+
+	var families int
+	if err := r.db.QueryRowContext(ctx, query, owner.Owner(), at).Scan(&families); err != nil {
+		return 0, fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	return families, nil
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// MemoryRefreshTokenRepository is an in-memory RefreshTokenStore used by
+// demo mode
+type MemoryRefreshTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[string]*model.RefreshToken
+}
+
+// NewMemoryRefreshTokenRepository creates a new MemoryRefreshTokenRepository
+func NewMemoryRefreshTokenRepository() *MemoryRefreshTokenRepository {
+	return &MemoryRefreshTokenRepository{tokens: make(map[string]*model.RefreshToken)}
+}
+
+// Create implements RefreshTokenStore
+func (r *MemoryRefreshTokenRepository) Create(ctx context.Context, token *model.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.create(token)
+	return nil
+}
+
+// GetByHash implements RefreshTokenStore
+func (r *MemoryRefreshTokenRepository) GetByHash(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.Hash == hash {
+			return copyRefreshToken(token), nil
+		}
+	}
+	return nil, ErrRefreshTokenNotFound
+}
+
+// Rotate implements RefreshTokenStore
+func (r *MemoryRefreshTokenRepository) Rotate(ctx context.Context, id string, next *model.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok {
+		return ErrRefreshTokenNotFound
+	}
+	if token.UsedAt != nil || token.RevokedAt != nil {
+		return ErrRefreshTokenUsed
+	}
+
+	now := time.Now().UTC()
+	token.UsedAt = &now
+	r.create(next)
+	return nil
+}
+
+// RevokeFamily implements RefreshTokenStore
+func (r *MemoryRefreshTokenRepository) RevokeFamily(ctx context.Context, family string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.FamilyID == family && token.RevokedAt == nil {
+			token.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+// RevokeUser implements RefreshTokenStore
+func (r *MemoryRefreshTokenRepository) RevokeUser(ctx context.Context, owner Scope, at time.Time) (int, error) {
+	if err := owner.Check(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	families := make(map[string]bool)
+	for _, token := range r.tokens {
+		if owner.Owns(token.User) && token.RevokedAt == nil {
+			token.RevokedAt = &at
+			families[token.FamilyID] = true
+		}
+	}
+	return len(families), nil
+}
+
+// create stores a copy of token; callers hold mu
+func (r *MemoryRefreshTokenRepository) create(token *model.RefreshToken) {
+	created := *token
+	created.CreatedAt = time.Now().UTC()
+	r.tokens[created.ID] = &created
+}
+
+func copyRefreshToken(token *model.RefreshToken) *model.RefreshToken {
+	copied := *token
+	if token.UsedAt != nil {
+		at := *token.UsedAt
+		copied.UsedAt = &at
+	}
+	if token.RevokedAt != nil {
+		at := *token.RevokedAt
+		copied.RevokedAt = &at
+	}
+	return &copied
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+)
+
+var (
+	// ErrUnscoped is returned by an owner-scoped store method given an empty
+	// Scope, so a missing principal fails closed instead of reaching every
+	// owner's rows
+	ErrUnscoped = errors.New("query has no owner scope")
+
+	// ErrNotOwned is returned instead of a store's not found error when the
+	// row exists but is outside the scope, for the caller's access policy
+	// to answer as either missing or forbidden
+	ErrNotOwned = errors.New("row belongs to another owner")
+)
+
+// Scope restricts a store query to the rows one owner may reach. Stores of
+// per-user resources take a Scope and push its predicate into every query
+// rather than leaving services to filter what comes back. A row owned by
+// someone else is never returned or changed; lookups by ID report it as
+// ErrNotOwned so the denial is explicit rather than a side effect.
+type Scope struct {
+	column    string
+	owner     string
+	anonymous bool
+}
+
+// OwnedBy scopes queries to user's rows, keyed by the user_id column
+func OwnedBy(user string) Scope {
+	return Scope{column: "user_id", owner: user}
+}
+
+// Unowned scopes task queries to the tasks of no owner and no team, the
+// ones an anonymous caller reaches. Per-user stores reach nothing under it.
+func Unowned() Scope {
+	return Scope{anonymous: true}
+}
+
+// Owner returns the owner the scope is bound to, empty for Unowned
+func (s Scope) Owner() string {
+	return s.owner
+}
+
+// Anonymous reports whether the scope is Unowned
+func (s Scope) Anonymous() bool {
+	return s.anonymous
+}
+
+// Where returns the scope's predicate with the owner bound to placeholder n,
+// or ErrUnscoped when the scope has no owner
+func (s Scope) Where(n int) (string, error) {
+	if s.owner == "" || s.column == "" {
+		return "", ErrUnscoped
+	}
+	return fmt.Sprintf("%s = $%d", s.column, n), nil
+}
+
+// Owns reports whether a row owned by owner is in scope, the counterpart of
+// Where for the in-memory stores. An empty scope owns nothing.
+func (s Scope) Owns(owner string) bool {
+	return s.owner != "" && s.owner == owner
+}
+
+// Check returns ErrUnscoped when the scope has no owner and is not
+// Unowned, for memory stores that must fail the same way as their SQL
+// counterparts
+func (s Scope) Check() error {
+	if s.owner == "" && !s.anonymous {
+		return ErrUnscoped
+	}
+	return nil
+}
+
+type scopeKey struct{}
+
+// WithScope returns a context under which stores that take their scope
+// from the context rather than an argument, such as the task stores, only
+// reach s's rows
+func WithScope(ctx context.Context, s Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, s)
+}
+
+// ScopeFrom returns the scope ctx carries. Without one, stores that take
+// their scope from the context are unscoped, as for admins and background
+// jobs.
+func ScopeFrom(ctx context.Context) (Scope, bool) {
+	s, ok := ctx.Value(scopeKey{}).(Scope)
+	return s, ok
+}
+
+// scopeParam returns what to bind to a taskFilter parameter: the owner
+// of ctx's scope, an empty string under Unowned, or nil when ctx is
+// unscoped
+func scopeParam(ctx context.Context) (any, error) {
+	s, ok := ScopeFrom(ctx)
+	if !ok {
+		return nil, nil
+	}
+	if err := s.Check(); err != nil {
+		return nil, err
+	}
+	return s.owner, nil
+}
+
+// checkScope returns ErrUnscoped when ctx carries a scope without an
+// owner, for the in-memory stores to fail as scopeParam does
+func checkScope(ctx context.Context) error {
+	_, err := scopeParam(ctx)
+	return err
+}
+
+// taskFilter matches the rows of table, tasks or an alias of it, that the
+// user bound to the text parameter param reaches: the tasks they own and
+// those assigned to a team they belong to. Every task matches when param
+// is NULL, and only tasks of no owner and no team when it is empty.
+func taskFilter(table, param string) string {
+	return `(` + param + `::text IS NULL OR ` + table + `.owner_id = ` + param +
+		` OR ` + table + `.team_id IN (SELECT team_id FROM team_members WHERE user_id = ` + param + `)` +
+		` OR (` + param + ` = '' AND ` + table + `.owner_id IS NULL AND ` + table + `.team_id IS NULL))`
+}
+
+// inTenant reports whether a row of tenant id is reachable from ctx's
+// tenant, for the in-memory stores to match what row level security lets
+// through. Outside any tenant every row is.
+func inTenant(ctx context.Context, id string) bool {
+	current := tenant.From(ctx)
+	return current == "" || current == id
+}
+
+// inScope reports whether a row owned by owner is reachable under ctx's
+// scope by ownership alone; MemoryTaskRepository.visible adds teams
+func inScope(ctx context.Context, owner *string) bool {
+	s, ok := ScopeFrom(ctx)
+	if !ok {
+		return true
+	}
+	return owner != nil && s.Owns(*owner)
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScope(t *testing.T) {
+	where, err := OwnedBy("alice").Where(2)
+	require.NoError(t, err)
+	assert.Equal(t, "user_id = $2", where)
+	assert.True(t, OwnedBy("alice").Owns("alice"))
+	assert.False(t, OwnedBy("alice").Owns("bob"))
+
+	// An empty scope fails closed rather than matching every owner
+	_, err = OwnedBy("").Where(1)
+	assert.ErrorIs(t, err, ErrUnscoped)
+	_, err = Scope{}.Where(1)
+	assert.ErrorIs(t, err, ErrUnscoped)
+	assert.False(t, OwnedBy("").Owns(""))
+
+	// Unowned passes the task stores' check but owns no per-user rows
+	assert.NoError(t, Unowned().Check())
+	_, err = Unowned().Where(1)
+	assert.ErrorIs(t, err, ErrUnscoped)
+	assert.False(t, Unowned().Owns(""))
+}
+
+func TestMemoryTokenRepository_Scope(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryTokenRepository()
+	_, err := repo.Create(ctx, &model.APIToken{ID: "t1", User: "alice", Hash: "h1", ExpiresAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+
+	token, err := repo.Get(ctx, OwnedBy("alice"), "t1")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", token.User)
+
+	// Another user's token is never returned or deleted, and is told
+	// apart from a missing one for the access policy
+	_, err = repo.Get(ctx, OwnedBy("bob"), "t1")
+	assert.ErrorIs(t, err, ErrNotOwned)
+	assert.ErrorIs(t, repo.Delete(ctx, OwnedBy("bob"), "t1"), ErrNotOwned)
+	_, err = repo.Get(ctx, OwnedBy("bob"), "t2")
+	assert.ErrorIs(t, err, ErrTokenNotFound)
+	tokens, err := repo.ListByUser(ctx, OwnedBy("bob"))
+	require.NoError(t, err)
+	assert.Empty(t, tokens)
+
+	_, err = repo.ListByUser(ctx, OwnedBy(""))
+	assert.ErrorIs(t, err, ErrUnscoped)
+	assert.ErrorIs(t, repo.Delete(ctx, OwnedBy(""), "t1"), ErrUnscoped)
+
+	require.NoError(t, repo.Delete(ctx, OwnedBy("alice"), "t1"))
+}
+
+func TestMemoryNotificationRepository_Scope(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryNotificationRepository()
+	_, err := repo.CreateMany(ctx, []*model.Notification{
+		{User: "alice", EventID: 1, Type: model.EventTaskUpdated, TaskID: "task"},
+		{User: "bob", EventID: 1, Type: model.EventTaskUpdated, TaskID: "task"},
+	})
+	require.NoError(t, err)
+
+	list, err := repo.ListByUser(ctx, OwnedBy("alice"), false, &model.ListOptions{Params: listing.Params{Page: 1, PerPage: 10}})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "alice", list[0].User)
+
+	// Naming bob's notification does not let alice mark it read
+	bobs, err := repo.ListByUser(ctx, OwnedBy("bob"), false, &model.ListOptions{Params: listing.Params{Page: 1, PerPage: 10}})
+	require.NoError(t, err)
+	require.Len(t, bobs, 1)
+	marked, err := repo.MarkRead(ctx, OwnedBy("alice"), []int64{bobs[0].ID}, time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, marked)
+	unread, err := repo.CountByUser(ctx, OwnedBy("bob"), true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, unread)
+
+	_, err = repo.MarkRead(ctx, OwnedBy(""), nil, time.Now())
+	assert.ErrorIs(t, err, ErrUnscoped)
+}
+
+func TestMemoryActivityAndEvents_Scope(t *testing.T) {
+	ctx := context.Background()
+	tasks := NewMemoryTaskRepository(0)
+	events := NewMemoryEventRepository().FollowTasks(tasks)
+	alice, bob := "alice", "bob"
+
+	for _, task := range []*model.Task{
+		{ID: "a", ProjectKey: "TASK", Title: "Alice's", Owner: &alice},
+		{ID: "b", ProjectKey: "TASK", Title: "Bob's", Owner: &bob},
+	} {
+		_, err := tasks.Create(ctx, task)
+		require.NoError(t, err)
+		_, err = events.Append(ctx, &model.TaskEvent{Type: model.EventTaskCreated, TaskID: task.ID})
+		require.NoError(t, err)
+	}
+	// Deleted tasks keep their events
+	require.NoError(t, tasks.Delete(ctx, "b", AnyVersion))
+	_, err := events.Append(ctx, &model.TaskEvent{Type: model.EventTaskDeleted, TaskID: "b"})
+	require.NoError(t, err)
+
+	scoped := WithScope(ctx, OwnedBy(bob))
+	activity, err := tasks.ListActivity(scoped, &model.ActivityFilter{}, &model.ListOptions{Params: listing.Params{Page: 1, PerPage: 10}})
+	require.NoError(t, err)
+	require.NotEmpty(t, activity)
+	for _, entry := range activity {
+		assert.Equal(t, "b", entry.TaskID)
+	}
+	total, err := tasks.CountActivity(scoped, &model.ActivityFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, len(activity), total)
+
+	listed, err := events.ListAfter(scoped, 0, math.MaxInt64, 10)
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+	assert.Equal(t, model.EventTaskCreated, listed[0].Type)
+	assert.Equal(t, model.EventTaskDeleted, listed[1].Type)
+
+	all, err := events.ListAfter(ctx, 0, math.MaxInt64, 10)
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+
+	// Without tasks to check against, scoped reads fail closed
+	_, err = NewMemoryEventRepository().ListAfter(scoped, 0, math.MaxInt64, 10)
+	assert.ErrorIs(t, err, ErrUnscoped)
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+
+	_ "github.com/lib/pq"
+	"github.com/moabdelazem/mutlitier_app/internal/database"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// errRollback ends a test transaction without keeping its writes
+var errRollback = errors.New("rollback")
+
+// openTestDB connects to the migrated database at TEST_DATABASE_URL,
+// skipping the test without one
+func openTestDB(t *testing.T) *database.DB {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := sql.Open("postgres", url)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Ping())
+	return &database.DB{DB: db}
+}
+
+func TestMemoryTaskRepository_Search(t *testing.T) {
+	testSearch(t, context.Background(), NewMemoryTaskRepository(0))
+}
+
+func TestTaskRepository_Search(t *testing.T) {
+	db := openTestDB(t)
+
+	err := db.InTx(context.Background(), func(ctx context.Context) error {
+		testSearch(t, ctx, NewTaskRepository(db))
+		return errRollback
+	})
+	require.ErrorIs(t, err, errRollback)
+}
+
+// testSearch runs the same queries against each TaskStore, so the
+// in-memory store keeps answering like websearch_to_tsquery in Postgres
+func testSearch(t *testing.T, ctx context.Context, repo TaskStore) {
+	for _, task := range []*model.Task{
+		{ID: "00000000-0000-4000-8000-00000000000a", Title: "Quokka migration", Description: "Move the wombat cluster"},
+		{ID: "00000000-0000-4000-8000-00000000000b", Title: "Wombat upgrade", Description: "Quokka nodes first"},
+		{ID: "00000000-0000-4000-8000-00000000000c", Title: "Numbat cleanup", Description: "Nothing to do with quokka migration"},
+	} {
+		task.ProjectKey = "SRCH"
+		task.Status = model.StatusPending
+		task.Priority = model.PriorityMedium
+		_, err := repo.Create(ctx, task)
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		// Title matches rank above description ones, ties go to the newest
+		{"ranking", "quokka", []string{"a", "c", "b"}},
+		// Closer words rank higher in Postgres, newer tasks in memory
+		{"every word", "quokka wombat", []string{"b", "a"}},
+		{"phrase", `"quokka migration"`, []string{"a", "c"}},
+		{"unterminated phrase", `"quokka`, []string{"a", "c", "b"}},
+		{"negation", "quokka -wombat", []string{"c"}},
+		{"alternatives", "numbat or wombat", []string{"c", "b", "a"}},
+		{"case and punctuation", "QUOKKA!!", []string{"a", "c", "b"}},
+		{"no match", "platypus", nil},
+		{"empty", "", nil},
+		{"punctuation only", "!!! ??? --", nil},
+		{"dangling or", "or", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &model.ListOptions{Params: listing.Params{Page: 1, PerPage: 10}, Search: tt.query}
+
+			tasks, err := repo.Search(ctx, opts)
+			require.NoError(t, err)
+			var got []string
+			for _, task := range tasks {
+				got = append(got, task.ID[len(task.ID)-1:])
+			}
+			assert.Equal(t, tt.want, got)
+
+			total, err := repo.CountSearch(ctx, opts)
+			require.NoError(t, err)
+			assert.Equal(t, len(tt.want), total)
+		})
+	}
+
+	t.Run("pages", func(t *testing.T) {
+		opts := &model.ListOptions{Params: listing.Params{Page: 2, PerPage: 2}, Search: "quokka"}
+		tasks, err := repo.Search(ctx, opts)
+		require.NoError(t, err)
+		require.Len(t, tasks, 1)
+		assert.Equal(t, "00000000-0000-4000-8000-00000000000b", tasks[0].ID)
+	})
+}
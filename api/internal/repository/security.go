@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/moabdelazem/mutlitier_app/internal/database"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// SecurityEventRepository stores security events in Postgres
+type SecurityEventRepository struct {
+	db *database.DB
+}
+
+// NewSecurityEventRepository creates a new SecurityEventRepository
+func NewSecurityEventRepository(db *database.DB) *SecurityEventRepository {
+	return &SecurityEventRepository{db: db}
+}
+
+// securityEventColumns are the columns scanned by scanSecurityEvent
+const securityEventColumns = `id, type, COALESCE(user_id, ''), COALESCE(credential, ''), COALESCE(token_id::text, ''),
+	COALESCE(reason, ''), ip, method, path, COALESCE(request_id, ''), created_at`
+
+// securityEventWhere filters security_events by a model.SecurityEventFilter
+// passed as the first four parameters
+const securityEventWhere = `
+	WHERE (cardinality($1::text[]) = 0 OR type = ANY($1))
+	  AND ($2 = '' OR user_id = $2)
+	  AND ($3::timestamptz IS NULL OR created_at >= $3)
+	  AND ($4::timestamptz IS NULL OR created_at < $4)
+`
+
+// Append implements SecurityEventStore with a single multi-row insert
+func (r *SecurityEventRepository) Append(ctx context.Context, events []*model.SecurityEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	var query strings.Builder
+	query.WriteString(`INSERT INTO security_events
+		(type, user_id, credential, token_id, reason, ip, method, path, request_id, created_at) VALUES `)
+
+	const columns = 10
+	args := make([]any, 0, len(events)*columns)
+	for i, event := range events {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		n := i * columns
+		fmt.Fprintf(&query, "($%d, NULLIF($%d, ''), NULLIF($%d, ''), NULLIF($%d, '')::uuid, NULLIF($%d, ''), $%d, $%d, $%d, NULLIF($%d, ''), $%d)",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9, n+10)
+		args = append(args, event.Type, event.User, event.Credential, event.TokenID, event.Reason,
+			event.IP, event.Method, event.Path, event.RequestID, event.CreatedAt)
+	}
+
+	if _, err := r.db.ExecContext(ctx, query.String(), args...); err != nil {
+		return fmt.Errorf("failed to append security events: %w", err)
+	}
+
+	return nil
+}
+
+// List implements SecurityEventStore
+func (r *SecurityEventRepository) List(ctx context.Context, filter *model.SecurityEventFilter, opts *model.ListOptions) ([]*model.SecurityEvent, error) {
+	query := `SELECT ` + securityEventColumns + ` FROM security_events` + securityEventWhere + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT $5 OFFSET $6
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, securityTypeArray(filter.Types), filter.User, filter.From, filter.To,
+		opts.PerPage, opts.Offset())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list security events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*model.SecurityEvent
+	for rows.Next() {
+		event, err := scanSecurityEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating security events: %w", err)
+	}
+
+	return events, nil
+}
+
+// Count implements SecurityEventStore
+func (r *SecurityEventRepository) Count(ctx context.Context, filter *model.SecurityEventFilter) (int, error) {
+	query := `SELECT COUNT(*) FROM security_events` + securityEventWhere
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, query, securityTypeArray(filter.Types), filter.User, filter.From, filter.To).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count security events: %w", err)
+	}
+
+	return total, nil
+}
+
+// Export implements SecurityEventStore, streaming rows as they are read
+func (r *SecurityEventRepository) Export(ctx context.Context, filter *model.SecurityEventFilter, fn func(*model.SecurityEvent) error) error {
+	query := `SELECT ` + securityEventColumns + ` FROM security_events` + securityEventWhere + `
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, securityTypeArray(filter.Types), filter.User, filter.From, filter.To)
+	if err != nil {
+		return fmt.Errorf("failed to export security events: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		event, err := scanSecurityEvent(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating security events: %w", err)
+	}
+
+	return nil
+}
+
+// Purge implements SecurityEventStore
+func (r *SecurityEventRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM security_events WHERE created_at < $1`
+
+	result, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge security events: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return purged, nil
+}
+
+func scanSecurityEvent(rows *sql.Rows) (*model.SecurityEvent, error) {
+	var event model.SecurityEvent
+	if err := rows.Scan(&event.ID, &event.Type, &event.User, &event.Credential, &event.TokenID,
+		&event.Reason, &event.IP, &event.Method, &event.Path, &event.RequestID, &event.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to scan security event: %w", err)
+	}
+	return &event, nil
+}
+
+// securityTypeArray converts security event types into a text[] parameter
+func securityTypeArray(types []model.SecurityEventType) any {
+	values := make([]string, len(types))
+	for i, eventType := range types {
+		values[i] = string(eventType)
+	}
+	return pq.Array(values)
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// MemorySecurityEventRepository is an in-memory SecurityEventStore used by
+// demo mode. Events are kept oldest first.
+type MemorySecurityEventRepository struct {
+	mu     sync.RWMutex
+	events []*model.SecurityEvent
+	nextID int64
+}
+
+// NewMemorySecurityEventRepository creates a new MemorySecurityEventRepository
+func NewMemorySecurityEventRepository() *MemorySecurityEventRepository {
+	return &MemorySecurityEventRepository{nextID: 1}
+}
+
+// Append implements SecurityEventStore
+func (r *MemorySecurityEventRepository) Append(ctx context.Context, events []*model.SecurityEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, event := range events {
+		appended := *event
+		appended.ID = r.nextID
+		r.nextID++
+		r.events = append(r.events, &appended)
+	}
+	return nil
+}
+
+// List implements SecurityEventStore
+func (r *MemorySecurityEventRepository) List(ctx context.Context, filter *model.SecurityEventFilter, opts *model.ListOptions) ([]*model.SecurityEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matches := r.matching(filter)
+	start := min(opts.Offset(), len(matches))
+	end := min(start+opts.PerPage, len(matches))
+
+	events := make([]*model.SecurityEvent, 0, end-start)
+	for _, event := range matches[start:end] {
+		copied := *event
+		events = append(events, &copied)
+	}
+	return events, nil
+}
+
+// Count implements SecurityEventStore
+func (r *MemorySecurityEventRepository) Count(ctx context.Context, filter *model.SecurityEventFilter) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.matching(filter)), nil
+}
+
+// Export implements SecurityEventStore
+func (r *MemorySecurityEventRepository) Export(ctx context.Context, filter *model.SecurityEventFilter, fn func(*model.SecurityEvent) error) error {
+	r.mu.RLock()
+	matches := r.matching(filter)
+	events := make([]model.SecurityEvent, len(matches))
+	for i, event := range matches {
+		events[i] = *event
+	}
+	r.mu.RUnlock()
+
+	for i := range events {
+		if err := fn(&events[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Purge implements SecurityEventStore
+func (r *MemorySecurityEventRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.events[:0]
+	for _, event := range r.events {
+		if !event.CreatedAt.Before(before) {
+			kept = append(kept, event)
+		}
+	}
+	purged := int64(len(r.events) - len(kept))
+	r.events = kept
+	return purged, nil
+}
+
+// matching returns the events matching filter, newest first. Callers hold
+// the lock.
+func (r *MemorySecurityEventRepository) matching(filter *model.SecurityEventFilter) []*model.SecurityEvent {
+	var matches []*model.SecurityEvent
+	for i := len(r.events) - 1; i >= 0; i-- {
+		event := r.events[i]
+		if len(filter.Types) > 0 && !slices.Contains(filter.Types, event.Type) {
+			continue
+		}
+		if filter.User != "" && event.User != filter.User {
+			continue
+		}
+		if filter.From != nil && event.CreatedAt.Before(*filter.From) {
+			continue
+		}
+		if filter.To != nil && !event.CreatedAt.Before(*filter.To) {
+			continue
+		}
+		matches = append(matches, event)
+	}
+	return matches
+}
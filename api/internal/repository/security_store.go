@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// SecurityEventStore is the storage contract for the authentication audit
+// trail, implemented by the Postgres SecurityEventRepository and the
+// in-memory MemorySecurityEventRepository
+type SecurityEventStore interface {
+	// Append stores a batch of events
+	Append(ctx context.Context, events []*model.SecurityEvent) error
+
+	// List returns a page of events matching filter, newest first
+	List(ctx context.Context, filter *model.SecurityEventFilter, opts *model.ListOptions) ([]*model.SecurityEvent, error)
+	Count(ctx context.Context, filter *model.SecurityEventFilter) (int, error)
+
+	// Export calls fn for every event matching filter, newest first,
+	// stopping at the first error
+	Export(ctx context.Context, filter *model.SecurityEventFilter, fn func(*model.SecurityEvent) error) error
+
+	// Purge removes events created before the given time
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+var (
+	_ SecurityEventStore = (*SecurityEventRepository)(nil)
+	_ SecurityEventStore = (*MemorySecurityEventRepository)(nil)
+)
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// SessionStore is the storage contract for sign-in sessions, implemented
+// by the Postgres SessionRepository and the in-memory
+// MemorySessionRepository
+type SessionStore interface {
+	Create(ctx context.Context, session *model.Session) error
+	// Get returns a session whatever its state, or ErrSessionNotFound
+	Get(ctx context.Context, id string) (*model.Session, error)
+	// List returns the owner's sessions neither revoked nor expired, most
+	// recently seen first
+	List(ctx context.Context, owner Scope) ([]*model.Session, error)
+	// Touch records that session id was seen at at, from ip unless empty
+	Touch(ctx context.Context, id, ip string, at time.Time) error
+	// Revoke revokes one of the owner's active sessions. An unknown,
+	// revoked or expired session is ErrSessionNotFound and another user's
+	// is ErrNotOwned.
+	Revoke(ctx context.Context, owner Scope, id string, at time.Time) error
+	// RevokeUser revokes every active session of the owner but keep, which
+	// may be empty, and returns the IDs it revoked
+	RevokeUser(ctx context.Context, owner Scope, keep string, at time.Time) ([]string, error)
+}
+
+var (
+	_ SessionStore = (*SessionRepository)(nil)
+	_ SessionStore = (*MemorySessionRepository)(nil)
+)
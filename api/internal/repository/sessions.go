@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/moabdelazem/mutlitier_app/internal/database"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// sessionColumns is the column list shared by every session query, in
+// scanSession order
+const sessionColumns = `id, user_id, user_agent, ip, created_at, last_seen_at, expires_at, revoked_at`
+
+// scanSession scans a row selected with sessionColumns
+func scanSession(row scanner) (*model.Session, error) {
+	var session model.Session
+	if err := row.Scan(&session.ID, &session.User, &session.UserAgent, &session.IP,
+		&session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt, &session.RevokedAt); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// SessionRepository handles database operations for sign-in sessions
+type SessionRepository struct {
+	db *database.DB
+}
+
+// NewSessionRepository creates a new SessionRepository
+func NewSessionRepository(db *database.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// Create implements SessionStore
+func (r *SessionRepository) Create(ctx context.Context, session *model.Session) error {
+	query := `
+		INSERT INTO sessions (id, user_id, user_agent, ip, expires_at)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := r.db.ExecContext(ctx, query, session.ID, session.User, session.UserAgent, session.IP, session.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return nil
+}
+
+// Get implements SessionStore
+func (r *SessionRepository) Get(ctx context.Context, id string) (*model.Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE id = $1`
+
+	session, err := scanSession(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return session, nil
+}
+
+// List implements SessionStore
+func (r *SessionRepository) List(ctx context.Context, owner Scope) ([]*model.Session, error) {
+	scope, err := owner.Where(1)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT ` + sessionColumns + ` FROM sessions
+		WHERE ` + scope + ` AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_seen_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, owner.Owner())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*model.Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// Touch implements SessionStore
+func (r *SessionRepository) Touch(ctx context.Context, id, ip string, at time.Time) error {
+	query := `UPDATE sessions SET last_seen_at = $2, ip = COALESCE(NULLIF($3, ''), ip) WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, id, at, ip); err != nil {
+		return fmt.Errorf("failed to touch session: %w", err)
+	}
+	return nil
+}
+
+// Revoke implements SessionStore
+func (r *SessionRepository) Revoke(ctx context.Context, owner Scope, id string, at time.Time) error {
+	scope, err := owner.Where(2)
+	if err != nil {
+		return err
+	}
+	query := `
+		UPDATE sessions SET revoked_at = $3
+		WHERE id = $1 AND ` + scope + ` AND revoked_at IS NULL AND expires_at > NOW()`
+
+	result, err := r.db.ExecContext(ctx, query, id, owner.Owner(), at)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return r.missing(ctx, id)
+	}
+
+	return nil
+}
+
+// missing tells why a scoped revocation of session id matched nothing:
+// ErrNotOwned when it is active outside the scope, ErrSessionNotFound
+// otherwise
+func (r *SessionRepository) missing(ctx context.Context, id string) error {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM sessions WHERE id = $1 AND revoked_at IS NULL AND expires_at > NOW())`
+	if err := r.db.QueryRowContext(ctx, query, id).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check session: %w", err)
+	}
+	if exists {
+		return ErrNotOwned
+	}
+	return ErrSessionNotFound
+}
+
+// RevokeUser implements SessionStore
+func (r *SessionRepository) RevokeUser(ctx context.Context, owner Scope, keep string, at time.Time) ([]string, error) {
+	scope, err := owner.Where(1)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		WITH revoked AS (
+			UPDATE sessions SET revoked_at = $2
+			WHERE ` + scope + ` AND id::text <> $3 AND revoked_at IS NULL AND expires_at > NOW()
+			RETURNING id
+		)
+		SELECT COALESCE(array_agg(id::text), '{}') FROM revoked`
+
+	var ids []string
+	if err := r.db.QueryRowContext(ctx, query, owner.Owner(), at, keep).Scan(pq.Array(&ids)); err != nil {
+		return nil, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// MemorySessionRepository is an in-memory SessionStore used by demo mode
+type MemorySessionRepository struct {
+	mu       sync.Mutex
+	sessions map[string]*model.Session
+}
+
+// NewMemorySessionRepository creates a new MemorySessionRepository
+func NewMemorySessionRepository() *MemorySessionRepository {
+	return &MemorySessionRepository{sessions: make(map[string]*model.Session)}
+}
+
+// Create implements SessionStore
+func (r *MemorySessionRepository) Create(ctx context.Context, session *model.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	created := *session
+	created.CreatedAt = time.Now().UTC()
+	created.LastSeenAt = created.CreatedAt
+	r.sessions[created.ID] = &created
+	return nil
+}
+
+// Get implements SessionStore
+func (r *MemorySessionRepository) Get(ctx context.Context, id string) (*model.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	return copySession(session), nil
+}
+
+// List implements SessionStore
+func (r *MemorySessionRepository) List(ctx context.Context, owner Scope) ([]*model.Session, error) {
+	if err := owner.Check(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var sessions []*model.Session
+	for _, session := range r.sessions {
+		if owner.Owns(session.User) && sessionActive(session, now) {
+			sessions = append(sessions, copySession(session))
+		}
+	}
+	slices.SortFunc(sessions, func(a, b *model.Session) int {
+		return cmp.Compare(b.LastSeenAt.UnixNano(), a.LastSeenAt.UnixNano())
+	})
+	return sessions, nil
+}
+
+// Touch implements SessionStore
+func (r *MemorySessionRepository) Touch(ctx context.Context, id, ip string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if session, ok := r.sessions[id]; ok {
+		session.LastSeenAt = at
+		if ip != "" {
+			session.IP = ip
+		}
+	}
+	return nil
+}
+
+// Revoke implements SessionStore
+func (r *MemorySessionRepository) Revoke(ctx context.Context, owner Scope, id string, at time.Time) error {
+	if err := owner.Check(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok || !sessionActive(session, time.Now()) {
+		return ErrSessionNotFound
+	}
+	if !owner.Owns(session.User) {
+		return ErrNotOwned
+	}
+	session.RevokedAt = &at
+	return nil
+}
+
+// RevokeUser implements SessionStore
+func (r *MemorySessionRepository) RevokeUser(ctx context.Context, owner Scope, keep string, at time.Time) ([]string, error) {
+	if err := owner.Check(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var ids []string
+	for _, session := range r.sessions {
+		if owner.Owns(session.User) && session.ID != keep && sessionActive(session, now) {
+			session.RevokedAt = &at
+			ids = append(ids, session.ID)
+		}
+	}
+	return ids, nil
+}
+
+// sessionActive reports whether session is neither revoked nor expired at now
+func sessionActive(session *model.Session, now time.Time) bool {
+	return session.RevokedAt == nil && session.ExpiresAt.After(now)
+}
+
+func copySession(session *model.Session) *model.Session {
+	copied := *session
+	if session.RevokedAt != nil {
+		at := *session.RevokedAt
+		copied.RevokedAt = &at
+	}
+	return &copied
+}
@@ -0,0 +1,504 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+	"github.com/moabdelazem/mutlitier_app/pkg/metrics"
+)
+
+// Shadow comparison results reported in repository_shadow_comparisons_total
+const (
+	shadowMatch    = "match"
+	shadowDiverged = "diverged"
+	shadowError    = "error"
+	shadowSkipped  = "skipped"
+)
+
+// ShadowTaskStore decorates a primary TaskStore with a shadow implementation
+// during a repository migration. Callers only ever see the primary's results.
+// Reads are repeated against the shadow in the background and compared;
+// with dualWrite, writes are also applied to the shadow after the primary
+// succeeds. Divergences are logged and counted, never returned.
+//
+// The shadow is expected to start as a copy of the primary, unless the
+// store is limited with OnlyWritten.
+type ShadowTaskStore struct {
+	primary   TaskStore
+	shadow    TaskStore
+	dualWrite bool
+	timeout   time.Duration
+	inFlight  chan struct{}
+	written   *writtenTasks
+}
+
+// NewShadowTaskStore creates a ShadowTaskStore. At most maxInFlight shadow
+// reads run at once; comparisons beyond that are skipped rather than queued.
+func NewShadowTaskStore(primary, shadow TaskStore, dualWrite bool, timeout time.Duration, maxInFlight int) *ShadowTaskStore {
+	return &ShadowTaskStore{
+		primary:   primary,
+		shadow:    shadow,
+		dualWrite: dualWrite,
+		timeout:   timeout,
+		inFlight:  make(chan struct{}, maxInFlight),
+	}
+}
+
+// OnlyWritten limits the store to tasks written through it, for a shadow
+// that starts empty rather than as a copy of the primary. Only reads of
+// those tasks are compared and only their writes repeated; lists and
+// counts cannot be compared and are not. A shadow that is full and
+// refuses new tasks with ErrStoreFull leaves them out.
+func (s *ShadowTaskStore) OnlyWritten() *ShadowTaskStore {
+	s.written = &writtenTasks{ids: make(map[string]bool)}
+	return s
+}
+
+// Create implements TaskStore
+func (s *ShadowTaskStore) Create(ctx context.Context, task *model.Task) (*model.Task, error) {
+	created, err := s.primary.Create(ctx, task)
+	if err == nil && s.dualWrite {
+		// The primary's task, so a shadow that started empty keeps its number
+		_, shadowErr := s.shadow.Create(ctx, created)
+		s.reportCreate("Create", shadowErr, created)
+	}
+	return created, err
+}
+
+// CreateMany implements TaskStore
+func (s *ShadowTaskStore) CreateMany(ctx context.Context, tasks []*model.Task) ([]*model.Task, error) {
+	created, err := s.primary.CreateMany(ctx, tasks)
+	if err == nil && s.dualWrite {
+		_, shadowErr := s.shadow.CreateMany(ctx, created)
+		s.reportCreate("CreateMany", shadowErr, created...)
+	}
+	return created, err
+}
+
+// GetByID implements TaskStore
+func (s *ShadowTaskStore) GetByID(ctx context.Context, id string) (*model.Task, error) {
+	task, err := s.primary.GetByID(ctx, id)
+	if !s.written.holds(id) {
+		return task, err
+	}
+	s.compare(ctx, "GetByID", task, err, func(ctx context.Context) (any, error) {
+		return s.shadow.GetByID(ctx, id)
+	})
+	return task, err
+}
+
+// GetByRef implements TaskStore
+func (s *ShadowTaskStore) GetByRef(ctx context.Context, ref model.Ref) (*model.Task, error) {
+	task, err := s.primary.GetByRef(ctx, ref)
+	if s.written != nil && (task == nil || !s.written.holds(task.ID)) {
+		return task, err
+	}
+	s.compare(ctx, "GetByRef", task, err, func(ctx context.Context) (any, error) {
+		return s.shadow.GetByRef(ctx, ref)
+	})
+	return task, err
+}
+
+// GetByRefs implements TaskStore
+func (s *ShadowTaskStore) GetByRefs(ctx context.Context, refs []model.Ref) ([]*model.Task, error) {
+	tasks, err := s.primary.GetByRefs(ctx, refs)
+	held, heldRefs := tasks, refs
+	if s.written != nil {
+		held, heldRefs = s.written.tasks(tasks), nil
+		for _, task := range held {
+			heldRefs = append(heldRefs, task.Ref())
+		}
+	}
+	s.compare(ctx, "GetByRefs", held, err, func(ctx context.Context) (any, error) {
+		return s.shadow.GetByRefs(ctx, heldRefs)
+	})
+	return tasks, err
+}
+
+// GetByIDs implements TaskStore
+func (s *ShadowTaskStore) GetByIDs(ctx context.Context, ids []string) ([]*model.Task, error) {
+	tasks, err := s.primary.GetByIDs(ctx, ids)
+	heldIDs := s.written.filter(ids)
+	s.compare(ctx, "GetByIDs", s.written.tasks(tasks), err, func(ctx context.Context) (any, error) {
+		return s.shadow.GetByIDs(ctx, heldIDs)
+	})
+	return tasks, err
+}
+
+// GetByProject implements TaskStore
+func (s *ShadowTaskStore) GetByProject(ctx context.Context, projectKey string) ([]*model.Task, error) {
+	tasks, err := s.primary.GetByProject(ctx, projectKey)
+	s.compareAll(ctx, "GetByProject", tasks, err, func(ctx context.Context) (any, error) {
+		return s.shadow.GetByProject(ctx, projectKey)
+	})
+	return tasks, err
+}
+
+// GetAll implements TaskStore
+func (s *ShadowTaskStore) GetAll(ctx context.Context, opts *model.ListOptions) ([]*model.Task, error) {
+	tasks, err := s.primary.GetAll(ctx, opts)
+	shadowOpts := *opts
+	s.compareAll(ctx, "GetAll", tasks, err, func(ctx context.Context) (any, error) {
+		return s.shadow.GetAll(ctx, &shadowOpts)
+	})
+	return tasks, err
+}
+
+// Count implements TaskStore
+func (s *ShadowTaskStore) Count(ctx context.Context, opts *model.ListOptions) (int, error) {
+	total, err := s.primary.Count(ctx, opts)
+	shadowOpts := *opts
+	s.compareAll(ctx, "Count", total, err, func(ctx context.Context) (any, error) {
+		return s.shadow.Count(ctx, &shadowOpts)
+	})
+	return total, err
+}
+
+// Board implements TaskStore
+func (s *ShadowTaskStore) Board(ctx context.Context, opts *model.BoardOptions) ([]*model.BoardTasks, error) {
+	columns, err := s.primary.Board(ctx, opts)
+	shadowOpts := *opts
+	s.compareAll(ctx, "Board", columns, err, func(ctx context.Context) (any, error) {
+		return s.shadow.Board(ctx, &shadowOpts)
+	})
+	return columns, err
+}
+
+// Search implements TaskStore
+func (s *ShadowTaskStore) Search(ctx context.Context, opts *model.ListOptions) ([]*model.Task, error) {
+	tasks, err := s.primary.Search(ctx, opts)
+	shadowOpts := *opts
+	s.compareAll(ctx, "Search", tasks, err, func(ctx context.Context) (any, error) {
+		return s.shadow.Search(ctx, &shadowOpts)
+	})
+	return tasks, err
+}
+
+// CountSearch implements TaskStore
+func (s *ShadowTaskStore) CountSearch(ctx context.Context, opts *model.ListOptions) (int, error) {
+	total, err := s.primary.CountSearch(ctx, opts)
+	shadowOpts := *opts
+	s.compareAll(ctx, "CountSearch", total, err, func(ctx context.Context) (any, error) {
+		return s.shadow.CountSearch(ctx, &shadowOpts)
+	})
+	return total, err
+}
+
+// Update implements TaskStore
+func (s *ShadowTaskStore) Update(ctx context.Context, id string, updates *model.UpdateTaskRequest, expectedVersion int64) (*model.Task, error) {
+	updated, err := s.primary.Update(ctx, id, updates, expectedVersion)
+	if err == nil && s.dualWrite && s.written.holds(id) {
+		_, shadowErr := s.shadow.Update(ctx, id, updates, expectedVersion)
+		s.reportWrite("Update", shadowErr)
+	}
+	return updated, err
+}
+
+// BulkUpdate implements TaskStore
+func (s *ShadowTaskStore) BulkUpdate(ctx context.Context, ids []string, updates *model.UpdateTaskRequest) ([]*model.Task, error) {
+	updated, err := s.primary.BulkUpdate(ctx, ids, updates)
+	if held := s.written.filter(ids); err == nil && s.dualWrite && len(held) > 0 {
+		_, shadowErr := s.shadow.BulkUpdate(ctx, held, updates)
+		s.reportWrite("BulkUpdate", shadowErr)
+	}
+	return updated, err
+}
+
+// Delete implements TaskStore
+func (s *ShadowTaskStore) Delete(ctx context.Context, id string, expectedVersion int64) error {
+	err := s.primary.Delete(ctx, id, expectedVersion)
+	if err == nil && s.dualWrite && s.written.holds(id) {
+		s.reportWrite("Delete", s.shadow.Delete(ctx, id, expectedVersion))
+	}
+	return err
+}
+
+// BulkDelete implements TaskStore
+func (s *ShadowTaskStore) BulkDelete(ctx context.Context, ids []string) ([]string, error) {
+	deleted, err := s.primary.BulkDelete(ctx, ids)
+	if held := s.written.filter(ids); err == nil && s.dualWrite && len(held) > 0 {
+		_, shadowErr := s.shadow.BulkDelete(ctx, held)
+		s.reportWrite("BulkDelete", shadowErr)
+	}
+	return deleted, err
+}
+
+// HardDelete implements TaskStore
+func (s *ShadowTaskStore) HardDelete(ctx context.Context, id string, expectedVersion int64) error {
+	err := s.primary.HardDelete(ctx, id, expectedVersion)
+	if err == nil && s.dualWrite && s.written.holds(id) {
+		s.reportWrite("HardDelete", s.shadow.HardDelete(ctx, id, expectedVersion))
+		s.written.forget(id)
+	}
+	return err
+}
+
+// Restore implements TaskStore
+func (s *ShadowTaskStore) Restore(ctx context.Context, id string) (*model.Task, error) {
+	restored, err := s.primary.Restore(ctx, id)
+	if err == nil && s.dualWrite && s.written.holds(id) {
+		_, shadowErr := s.shadow.Restore(ctx, id)
+		s.reportWrite("Restore", shadowErr)
+	}
+	return restored, err
+}
+
+// SetArchived implements TaskStore
+func (s *ShadowTaskStore) SetArchived(ctx context.Context, id string, archived bool) (*model.Task, error) {
+	task, err := s.primary.SetArchived(ctx, id, archived)
+	if err == nil && s.dualWrite && s.written.holds(id) {
+		_, shadowErr := s.shadow.SetArchived(ctx, id, archived)
+		s.reportWrite("SetArchived", shadowErr)
+	}
+	return task, err
+}
+
+// SetAssignee implements TaskStore
+func (s *ShadowTaskStore) SetAssignee(ctx context.Context, id string, assignee *string) (*model.Task, error) {
+	task, err := s.primary.SetAssignee(ctx, id, assignee)
+	if err == nil && s.dualWrite && s.written.holds(id) {
+		_, shadowErr := s.shadow.SetAssignee(ctx, id, assignee)
+		s.reportWrite("SetAssignee", shadowErr)
+	}
+	return task, err
+}
+
+// SetTeam implements TaskStore
+func (s *ShadowTaskStore) SetTeam(ctx context.Context, id string, team *string) (*model.Task, error) {
+	task, err := s.primary.SetTeam(ctx, id, team)
+	if err == nil && s.dualWrite && s.written.holds(id) {
+		_, shadowErr := s.shadow.SetTeam(ctx, id, team)
+		s.reportWrite("SetTeam", shadowErr)
+	}
+	return task, err
+}
+
+// Move implements TaskStore
+func (s *ShadowTaskStore) Move(ctx context.Context, id, anchorID string, after bool) (*model.Task, error) {
+	task, err := s.primary.Move(ctx, id, anchorID, after)
+	if err == nil && s.dualWrite && s.written.holds(id) {
+		if !s.written.holds(anchorID) {
+			// The shadow cannot repeat the move, so the task drifts from here
+			s.written.forget(id)
+			return task, err
+		}
+		_, shadowErr := s.shadow.Move(ctx, id, anchorID, after)
+		s.reportWrite("Move", shadowErr)
+	}
+	return task, err
+}
+
+// Duplicate implements TaskStore
+func (s *ShadowTaskStore) Duplicate(ctx context.Context, id, newID string, opts *model.DuplicateOptions) (*model.Task, error) {
+	task, err := s.primary.Duplicate(ctx, id, newID, opts)
+	if err == nil && s.dualWrite && s.written != nil {
+		// The copy is a new task to a shadow holding only some
+		_, shadowErr := s.shadow.Create(ctx, task)
+		s.reportCreate("Duplicate", shadowErr, task)
+	} else if err == nil && s.dualWrite {
+		_, shadowErr := s.shadow.Duplicate(ctx, id, newID, opts)
+		s.reportWrite("Duplicate", shadowErr)
+	}
+	return task, err
+}
+
+// compare runs read against the shadow in the background and reports
+// whether it agrees with the primary's result
+func (s *ShadowTaskStore) compare(ctx context.Context, method string, primary any, primaryErr error, read func(ctx context.Context) (any, error)) {
+	// A failed primary read leaves nothing meaningful to compare against
+	if primaryErr != nil && !errors.Is(primaryErr, ErrTaskNotFound) {
+		return
+	}
+
+	select {
+	case s.inFlight <- struct{}{}:
+	default:
+		metrics.RepositoryShadowComparisons.WithLabelValues(method, shadowSkipped).Inc()
+		return
+	}
+
+	// Snapshot now: callers may modify the returned tasks
+	want := fingerprint(primary)
+
+	go func() {
+		defer func() { <-s.inFlight }()
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.timeout)
+		defer cancel()
+
+		got, err := read(ctx)
+
+		result := shadowMatch
+		switch {
+		case err != nil && !errors.Is(err, ErrTaskNotFound):
+			result = shadowError
+		case errors.Is(primaryErr, ErrTaskNotFound) != errors.Is(err, ErrTaskNotFound):
+			result = shadowDiverged
+		case !reflect.DeepEqual(want, fingerprint(got)):
+			result = shadowDiverged
+		}
+
+		metrics.RepositoryShadowComparisons.WithLabelValues(method, result).Inc()
+		if result != shadowMatch {
+			logger.Ctx(ctx).Warn().
+				AnErr("shadow_error", err).
+				Str("method", method).
+				Str("result", result).
+				Interface("primary", want).
+				Interface("shadow", fingerprint(got)).
+				Msg("Shadow repository diverged")
+		}
+	}()
+}
+
+// compareAll compares a list or count read, which a shadow holding only
+// the tasks written through the store cannot answer
+func (s *ShadowTaskStore) compareAll(ctx context.Context, method string, primary any, primaryErr error, read func(ctx context.Context) (any, error)) {
+	if s.written != nil {
+		return
+	}
+	s.compare(ctx, method, primary, primaryErr, read)
+}
+
+// reportCreate records the outcome of creating tasks in the shadow, which
+// holds them from then on. A full shadow skips them.
+func (s *ShadowTaskStore) reportCreate(method string, err error, tasks ...*model.Task) {
+	if errors.Is(err, ErrStoreFull) {
+		metrics.RepositoryShadowComparisons.WithLabelValues(method, shadowSkipped).Inc()
+		return
+	}
+	s.reportWrite(method, err)
+	if err == nil {
+		for _, task := range tasks {
+			s.written.add(task.ID)
+		}
+	}
+}
+
+// reportWrite records the outcome of a dual write to the shadow
+func (s *ShadowTaskStore) reportWrite(method string, err error) {
+	result := shadowMatch
+	if err != nil {
+		result = shadowError
+		logger.Get().Warn().Err(err).Str("method", method).Msg("Shadow repository write failed")
+	}
+	metrics.RepositoryShadowComparisons.WithLabelValues(method, result).Inc()
+}
+
+// writtenTasks are the IDs of the tasks a shadow started empty holds. A
+// nil set stands for a shadow that holds every task.
+type writtenTasks struct {
+	mu  sync.RWMutex
+	ids map[string]bool
+}
+
+// holds reports whether the shadow holds the task id
+func (w *writtenTasks) holds(id string) bool {
+	if w == nil {
+		return true
+	}
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.ids[id]
+}
+
+// filter returns the ids the shadow holds
+func (w *writtenTasks) filter(ids []string) []string {
+	if w == nil {
+		return ids
+	}
+	held := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if w.holds(id) {
+			held = append(held, id)
+		}
+	}
+	return held
+}
+
+// tasks returns the tasks the shadow holds
+func (w *writtenTasks) tasks(tasks []*model.Task) []*model.Task {
+	if w == nil {
+		return tasks
+	}
+	held := make([]*model.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if w.holds(task.ID) {
+			held = append(held, task)
+		}
+	}
+	return held
+}
+
+func (w *writtenTasks) add(id string) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.ids[id] = true
+}
+
+func (w *writtenTasks) forget(id string) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.ids, id)
+}
+
+// taskFingerprint is the part of a task both stores must agree on.
+// Timestamps are excluded because each store stamps its own.
+type taskFingerprint struct {
+	ID          string
+	Ref         string
+	Title       string
+	Description string
+	Status      model.Status
+	Priority    model.Priority
+	DueDate     string
+	Version     int64
+}
+
+// fingerprint reduces a read result to a comparable value
+func fingerprint(v any) any {
+	switch v := v.(type) {
+	case *model.Task:
+		if v == nil {
+			return nil
+		}
+		return taskFingerprint{
+			ID:          v.ID,
+			Ref:         v.Ref().String(),
+			Title:       v.Title,
+			Description: v.Description,
+			Status:      v.Status,
+			Priority:    v.Priority,
+			DueDate:     formatDueDate(v.DueDate),
+			Version:     v.Version,
+		}
+	case []*model.Task:
+		fingerprints := make([]any, len(v))
+		for i, task := range v {
+			fingerprints[i] = fingerprint(task)
+		}
+		return fingerprints
+	default:
+		return v
+	}
+}
+
+// formatDueDate makes due dates comparable across stores, which may
+// return them in different time zones
+func formatDueDate(dueDate *time.Time) string {
+	if dueDate == nil {
+		return ""
+	}
+	return dueDate.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShadowTaskStore(t *testing.T) {
+	ctx := context.Background()
+	primary := NewMemoryTaskRepository(0)
+	shadow := NewMemoryTaskRepository(0)
+	store := NewShadowTaskStore(primary, shadow, true, time.Second, 4)
+
+	counter := func(method, result string) float64 {
+		return testutil.ToFloat64(metrics.RepositoryShadowComparisons.WithLabelValues(method, result))
+	}
+	waitFor := func(method, result string, want float64) {
+		assert.Eventually(t, func() bool { return counter(method, result) == want }, time.Second, time.Millisecond)
+	}
+
+	// Dual writes keep both stores in step
+	task, err := store.Create(ctx, &model.Task{ID: "a", ProjectKey: "TASK", Title: "Ship it"})
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), counter("Create", shadowMatch))
+
+	_, err = store.GetByID(ctx, task.ID)
+	require.NoError(t, err)
+	waitFor("GetByID", shadowMatch, 1)
+
+	// A task only the primary has is reported as a divergence
+	_, err = primary.Create(ctx, &model.Task{ID: "b", ProjectKey: "TASK", Title: "Primary only"})
+	require.NoError(t, err)
+
+	got, err := store.GetByID(ctx, "b")
+	require.NoError(t, err)
+	assert.Equal(t, "Primary only", got.Title)
+	waitFor("GetByID", shadowDiverged, 1)
+}
+
+func TestShadowTaskStore_OnlyWritten(t *testing.T) {
+	ctx := context.Background()
+	primary := NewMemoryTaskRepository(0)
+	shadow := NewMemoryTaskRepository(2)
+	store := NewShadowTaskStore(primary, shadow, true, time.Second, 4).OnlyWritten()
+
+	counter := func(method, result string) float64 {
+		return testutil.ToFloat64(metrics.RepositoryShadowComparisons.WithLabelValues(method, result))
+	}
+	waitFor := func(method, result string, want float64) {
+		assert.Eventually(t, func() bool { return counter(method, result) == want }, time.Second, time.Millisecond)
+	}
+
+	// Tasks from before shadowing started are neither compared nor written
+	_, err := primary.Create(ctx, &model.Task{ID: "old", ProjectKey: "TASK", Title: "Before"})
+	require.NoError(t, err)
+	before := counter("GetByID", shadowDiverged)
+	_, err = store.GetByID(ctx, "old")
+	require.NoError(t, err)
+	_, err = store.SetArchived(ctx, "old", true)
+	require.NoError(t, err)
+	_, err = shadow.GetByID(ctx, "old")
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+
+	// New tasks are copied with the primary's number and compared
+	task, err := store.Create(ctx, &model.Task{ID: "new", ProjectKey: "TASK", Title: "After"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), task.Number)
+	matched := counter("GetByRef", shadowMatch)
+	_, err = store.GetByRef(ctx, task.Ref())
+	require.NoError(t, err)
+	waitFor("GetByRef", shadowMatch, matched+1)
+
+	listed := counter("GetByIDs", shadowMatch)
+	tasks, err := store.GetByIDs(ctx, []string{"old", "new"})
+	require.NoError(t, err)
+	assert.Len(t, tasks, 2)
+	waitFor("GetByIDs", shadowMatch, listed+1)
+	assert.Equal(t, before, counter("GetByID", shadowDiverged))
+
+	// Past its size the shadow skips new tasks
+	_, err = store.Create(ctx, &model.Task{ID: "second", ProjectKey: "TASK", Title: "Fits"})
+	require.NoError(t, err)
+	skipped := counter("Create", shadowSkipped)
+	_, err = store.Create(ctx, &model.Task{ID: "third", ProjectKey: "TASK", Title: "Full"})
+	require.NoError(t, err)
+	assert.Equal(t, skipped+1, counter("Create", shadowSkipped))
+	assert.False(t, store.written.holds("third"))
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// statsTasks matches the tasks a TaskStatsFilter summarizes, within the
+// owner scope bound to $3
+var statsTasks = `deleted_at IS NULL AND ($1 OR NOT archived) AND ($2 = '' OR project_key = $2) AND ` + taskFilter("tasks", "$3")
+
+// TaskStats implements StatsStore with three aggregate queries. A task's
+// completion time is its last change to completed in task_history.
+func (r *TaskRepository) TaskStats(ctx context.Context, filter *model.TaskStatsFilter, now time.Time) (*model.TaskStats, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats := &model.TaskStats{ByStatus: make(map[model.Status]int)}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT status, COUNT(*) FROM tasks WHERE `+statsTasks+` GROUP BY status`,
+		filter.IncludeArchived, filter.Project, owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count tasks by status: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status model.Status
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan status count: %w", err)
+		}
+		stats.ByStatus[status] = count
+		stats.Total += count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count tasks by status: %w", err)
+	}
+
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM tasks
+			 WHERE ` + statsTasks + ` AND due_date < $4 AND status NOT IN ('completed', 'cancelled')),
+			(SELECT AVG(EXTRACT(EPOCH FROM done.completed_at - tasks.created_at)) FROM tasks
+			 CROSS JOIN LATERAL (
+				SELECT MAX(created_at) AS completed_at FROM task_history
+				WHERE task_history.task_id = tasks.id AND changes -> 'status' ->> 'to' = 'completed'
+			 ) done
+			 WHERE ` + statsTasks + ` AND status = 'completed' AND done.completed_at IS NOT NULL)
+	`
+	var avg sql.NullFloat64
+	if err := r.db.QueryRowContext(ctx, query, filter.IncludeArchived, filter.Project, owner, now).Scan(&stats.Overdue, &avg); err != nil {
+		return nil, fmt.Errorf("failed to aggregate task stats: %w", err)
+	}
+	if avg.Valid {
+		stats.AvgCompletionSeconds = &avg.Float64
+	}
+
+	since := statsSince(filter, now)
+	rows, err = r.db.QueryContext(ctx, `
+		SELECT to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS day, COUNT(*) FROM tasks
+		WHERE `+statsTasks+` AND created_at >= $4
+		GROUP BY day ORDER BY day`,
+		filter.IncludeArchived, filter.Project, owner, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count tasks created per day: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var day model.DailyCount
+		if err := rows.Scan(&day.Date, &day.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan daily count: %w", err)
+		}
+		stats.CreatedPerDay = append(stats.CreatedPerDay, day)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count tasks created per day: %w", err)
+	}
+
+	return stats, nil
+}
+
+// statsSince is the start of the first UTC day created_per_day covers
+func statsSince(filter *model.TaskStatsFilter, now time.Time) time.Time {
+	today := now.UTC().Truncate(24 * time.Hour)
+	return today.AddDate(0, 0, 1-filter.Days)
+}
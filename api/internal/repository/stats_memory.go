@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// completedJSON is how a change to completed is recorded in the history
+var completedJSON = []byte(`"` + model.StatusCompleted + `"`)
+
+// TaskStats implements StatsStore
+func (r *MemoryTaskRepository) TaskStats(ctx context.Context, filter *model.TaskStatsFilter, now time.Time) (*model.TaskStats, error) {
+	if err := checkScope(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := &model.TaskStats{ByStatus: make(map[model.Status]int)}
+	since := statsSince(filter, now)
+	perDay := make(map[string]int)
+	var completionTotal float64
+	completed := 0
+
+	for _, task := range r.tasks {
+		if task.DeletedAt != nil || (task.Archived && !filter.IncludeArchived) || !r.visible(ctx, task) {
+			continue
+		}
+		if filter.Project != "" && task.ProjectKey != filter.Project {
+			continue
+		}
+
+		stats.Total++
+		stats.ByStatus[task.Status]++
+		if model.Overdue(task.DueDate, task.Status, now) {
+			stats.Overdue++
+		}
+		if !task.CreatedAt.Before(since) {
+			perDay[task.CreatedAt.UTC().Format(time.DateOnly)]++
+		}
+
+		if task.Status == model.StatusCompleted {
+			if at, ok := r.completedAt(task.ID); ok {
+				completionTotal += at.Sub(task.CreatedAt).Seconds()
+				completed++
+			}
+		}
+	}
+
+	if completed > 0 {
+		avg := completionTotal / float64(completed)
+		stats.AvgCompletionSeconds = &avg
+	}
+
+	for date, count := range perDay {
+		stats.CreatedPerDay = append(stats.CreatedPerDay, model.DailyCount{Date: date, Count: count})
+	}
+	sort.Slice(stats.CreatedPerDay, func(i, j int) bool { return stats.CreatedPerDay[i].Date < stats.CreatedPerDay[j].Date })
+
+	return stats, nil
+}
+
+// completedAt returns when a task last changed to completed
+func (r *MemoryTaskRepository) completedAt(taskID string) (time.Time, bool) {
+	history := r.history[taskID]
+	for i := len(history) - 1; i >= 0; i-- {
+		if change, ok := history[i].Changes["status"]; ok && bytes.Equal(change.To, completedJSON) {
+			return history[i].CreatedAt, true
+		}
+	}
+	return time.Time{}, false
+}
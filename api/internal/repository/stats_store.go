@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// StatsStore aggregates tasks without loading them. Time to completion is
+// read from the task history, which both task stores keep.
+type StatsStore interface {
+	// TaskStats fills in every TaskStats count as of now. CreatedPerDay
+	// only lists days on which tasks were created.
+	TaskStats(ctx context.Context, filter *model.TaskStatsFilter, now time.Time) (*model.TaskStats, error)
+}
+
+var (
+	_ StatsStore = (*TaskRepository)(nil)
+	_ StatsStore = (*MemoryTaskRepository)(nil)
+)
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// TagStore is the storage contract for tags and their links to tasks. Both
+// task stores implement it, since tag filters and task reads join the two.
+type TagStore interface {
+	CreateTag(ctx context.Context, tag *model.Tag) (*model.Tag, error)
+	GetTag(ctx context.Context, id string) (*model.Tag, error)
+	ListTags(ctx context.Context) ([]*model.Tag, error)
+	TagsByName(ctx context.Context, names []string) ([]*model.Tag, error)
+	UpdateTag(ctx context.Context, id string, updates *model.UpdateTagRequest) (*model.Tag, error)
+	DeleteTag(ctx context.Context, id string) error
+	AttachTags(ctx context.Context, taskID string, names []string) (*model.Task, error)
+	DetachTag(ctx context.Context, taskID, name string) (*model.Task, error)
+}
+
+var (
+	_ TagStore = (*TaskRepository)(nil)
+	_ TagStore = (*MemoryTaskRepository)(nil)
+)
+
+// TagCRUD serves the tag CRUD methods of store as a CRUDStore. The task
+// stores name them CreateTag and so on, since Create is a task's.
+func TagCRUD(store TagStore) CRUDStore[model.Tag, model.UpdateTagRequest] {
+	return tagCRUD{store}
+}
+
+type tagCRUD struct{ store TagStore }
+
+func (t tagCRUD) Create(ctx context.Context, tag *model.Tag) (*model.Tag, error) {
+	return t.store.CreateTag(ctx, tag)
+}
+
+func (t tagCRUD) Get(ctx context.Context, id string) (*model.Tag, error) {
+	return t.store.GetTag(ctx, id)
+}
+
+func (t tagCRUD) List(ctx context.Context) ([]*model.Tag, error) {
+	return t.store.ListTags(ctx)
+}
+
+func (t tagCRUD) Update(ctx context.Context, id string, updates *model.UpdateTagRequest) (*model.Tag, error) {
+	return t.store.UpdateTag(ctx, id, updates)
+}
+
+func (t tagCRUD) Delete(ctx context.Context, id string) error {
+	return t.store.DeleteTag(ctx, id)
+}
@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/moabdelazem/mutlitier_app/internal/audit"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// tagColumns is the column list shared by every tag query, in scanTag order
+const tagColumns = `id, name, color, created_at, updated_at`
+
+// uniqueViolation is the Postgres error code for a duplicate key
+const uniqueViolation = "23505"
+
+// scanTag scans a row selected with tagColumns into a Tag
+func scanTag(row scanner) (*model.Tag, error) {
+	var tag model.Tag
+	if err := row.Scan(&tag.ID, &tag.Name, &tag.Color, &tag.CreatedAt, &tag.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// tagTable maps tags for CRUDRepository, which serves the tag CRUD
+// methods of TaskRepository
+var tagTable = &Table[model.Tag, model.UpdateTagRequest]{
+	Name:          "tags",
+	Item:          "tag",
+	Columns:       tagColumns,
+	Scan:          scanTag,
+	InsertColumns: []string{"id", "name", "color"},
+	Insert: func(tag *model.Tag) []any {
+		return []any{tag.ID, tag.Name, tag.Color}
+	},
+	UpdateColumns: []string{"name", "color"},
+	Changes: func(updates *model.UpdateTagRequest) []any {
+		return []any{updates.Name, updates.Color}
+	},
+	OrderBy:  "name",
+	NotFound: ErrTagNotFound,
+	Exists:   ErrTagExists,
+}
+
+// CreateTag implements TagStore
+func (r *TaskRepository) CreateTag(ctx context.Context, tag *model.Tag) (*model.Tag, error) {
+	return r.tags.Create(ctx, tag)
+}
+
+// GetTag implements TagStore
+func (r *TaskRepository) GetTag(ctx context.Context, id string) (*model.Tag, error) {
+	return r.tags.Get(ctx, id)
+}
+
+// ListTags implements TagStore, ordered by name
+func (r *TaskRepository) ListTags(ctx context.Context) ([]*model.Tag, error) {
+	return r.tags.List(ctx)
+}
+
+// TagsByName implements TagStore. Names without a tag are skipped.
+func (r *TaskRepository) TagsByName(ctx context.Context, names []string) ([]*model.Tag, error) {
+	query := `SELECT ` + tagColumns + ` FROM tags WHERE name = ANY($1) ORDER BY name`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(names))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags by name: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []*model.Tag
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// UpdateTag implements TagStore. Tasks refer to tags by ID, so a renamed
+// tag is renamed on every task that carries it, without touching those
+// tasks' versions.
+func (r *TaskRepository) UpdateTag(ctx context.Context, id string, updates *model.UpdateTagRequest) (*model.Tag, error) {
+	return r.tags.Update(ctx, id, updates)
+}
+
+// DeleteTag implements TagStore; the tag's links to tasks are removed with it
+func (r *TaskRepository) DeleteTag(ctx context.Context, id string) error {
+	return r.tags.Delete(ctx, id)
+}
+
+// AttachTags implements TagStore. Every name must be an existing tag; tags
+// the task already carries are left alone. The task's version only
+// changes when a tag is actually added.
+func (r *TaskRepository) AttachTags(ctx context.Context, taskID string, names []string) (*model.Task, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		INSERT INTO task_tags (task_id, tag_id)
+		SELECT tasks.id, tags.id FROM tasks, tags
+		WHERE tasks.id = $1 AND tasks.deleted_at IS NULL AND NOT tasks.archived AND tags.name = ANY($2)
+			AND ` + taskFilter("tasks", "$3") + `
+		ON CONFLICT DO NOTHING
+	`
+
+	// The tags stay locked until the links are in, so a tag deleted
+	// meanwhile cannot be skipped silently
+	var task *model.Task
+	err = r.db.InTx(ctx, func(ctx context.Context) error {
+		if err := r.checkTagsExist(ctx, names); err != nil {
+			return err
+		}
+		result, err := r.db.ExecContext(ctx, query, taskID, pq.Array(names), owner)
+		if err != nil {
+			return fmt.Errorf("failed to attach tags: %w", err)
+		}
+		task, err = r.touchIfChanged(ctx, taskID, result)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// DetachTag implements TagStore. Detaching a tag the task does not carry
+// is not an error.
+func (r *TaskRepository) DetachTag(ctx context.Context, taskID, name string) (*model.Task, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		DELETE FROM task_tags
+		USING tags, tasks
+		WHERE task_tags.tag_id = tags.id AND task_tags.task_id = tasks.id
+			AND tasks.id = $1 AND tasks.deleted_at IS NULL AND NOT tasks.archived AND tags.name = $2
+			AND ` + taskFilter("tasks", "$3") + `
+	`
+
+	var task *model.Task
+	err = r.db.InTx(ctx, func(ctx context.Context) error {
+		result, err := r.db.ExecContext(ctx, query, taskID, name, owner)
+		if err != nil {
+			return fmt.Errorf("failed to detach tag: %w", err)
+		}
+		task, err = r.touchIfChanged(ctx, taskID, result)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// checkTagsExist returns ErrTagNotFound naming every unknown tag. The
+// tags found are locked against deletion for the rest of the transaction.
+func (r *TaskRepository) checkTagsExist(ctx context.Context, names []string) error {
+	rows, err := r.db.QueryContext(ctx, `SELECT name FROM tags WHERE name = ANY($1) FOR SHARE`, pq.Array(names))
+	if err != nil {
+		return fmt.Errorf("failed to look up tags: %w", err)
+	}
+	defer rows.Close()
+
+	found := make(map[string]bool, len(names))
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return fmt.Errorf("failed to scan tag name: %w", err)
+		}
+		found[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating tag names: %w", err)
+	}
+
+	var missing []string
+	for _, name := range names {
+		if !found[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrTagNotFound, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// touchIfChanged returns the task after a tag link write, bumping its
+// version through the updated_at trigger when the write changed a link.
+// Archived tasks match no links, so they surface as ErrTaskArchived.
+func (r *TaskRepository) touchIfChanged(ctx context.Context, taskID string, result sql.Result) (*model.Task, error) {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		task, err := r.GetByID(ctx, taskID)
+		if err == nil && task.Archived {
+			return nil, ErrTaskArchived
+		}
+		return task, err
+	}
+
+	query := `UPDATE tasks SET updated_at = NOW(), updated_by = $2 WHERE id = $1 AND deleted_at IS NULL RETURNING ` + taskColumns
+
+	task, err := scanTask(r.db.QueryRowContext(ctx, query, taskID, audit.Actor(ctx)))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, fmt.Errorf("failed to touch task: %w", err)
+	}
+
+	return task, nil
+}
+
+// isUniqueViolation reports whether err is a Postgres duplicate key error
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
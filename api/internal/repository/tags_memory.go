@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// CreateTag implements TagStore
+func (r *MemoryTaskRepository) CreateTag(ctx context.Context, tag *model.Tag) (*model.Tag, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.tagByName(tag.Name) != nil {
+		return nil, ErrTagExists
+	}
+
+	now := time.Now().UTC()
+	created := *tag
+	created.CreatedAt = now
+	created.UpdatedAt = now
+	r.tags[created.ID] = &created
+
+	copied := created
+	return &copied, nil
+}
+
+// GetTag implements TagStore
+func (r *MemoryTaskRepository) GetTag(ctx context.Context, id string) (*model.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tag, ok := r.tags[id]
+	if !ok {
+		return nil, ErrTagNotFound
+	}
+	copied := *tag
+	return &copied, nil
+}
+
+// ListTags implements TagStore, ordered by name
+func (r *MemoryTaskRepository) ListTags(ctx context.Context) ([]*model.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tags []*model.Tag
+	for _, tag := range r.tags {
+		copied := *tag
+		tags = append(tags, &copied)
+	}
+	slices.SortFunc(tags, func(a, b *model.Tag) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return tags, nil
+}
+
+// TagsByName implements TagStore, ordered by name
+func (r *MemoryTaskRepository) TagsByName(ctx context.Context, names []string) ([]*model.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tags []*model.Tag
+	for _, tag := range r.tags {
+		if slices.Contains(names, tag.Name) {
+			copied := *tag
+			tags = append(tags, &copied)
+		}
+	}
+	slices.SortFunc(tags, func(a, b *model.Tag) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return tags, nil
+}
+
+// UpdateTag implements TagStore
+func (r *MemoryTaskRepository) UpdateTag(ctx context.Context, id string, updates *model.UpdateTagRequest) (*model.Tag, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tag, ok := r.tags[id]
+	if !ok {
+		return nil, ErrTagNotFound
+	}
+
+	if updates.Name != nil && *updates.Name != tag.Name {
+		if r.tagByName(*updates.Name) != nil {
+			return nil, ErrTagExists
+		}
+		for _, task := range r.tasks {
+			if i := slices.Index(task.Tags, tag.Name); i >= 0 {
+				task.Tags[i] = *updates.Name
+				slices.Sort(task.Tags)
+			}
+		}
+		tag.Name = *updates.Name
+	}
+	if updates.Color != nil {
+		tag.Color = *updates.Color
+	}
+	tag.UpdatedAt = time.Now().UTC()
+
+	copied := *tag
+	return &copied, nil
+}
+
+// DeleteTag implements TagStore
+func (r *MemoryTaskRepository) DeleteTag(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tag, ok := r.tags[id]
+	if !ok {
+		return ErrTagNotFound
+	}
+
+	for _, task := range r.tasks {
+		task.Tags = slices.DeleteFunc(task.Tags, func(name string) bool { return name == tag.Name })
+	}
+	delete(r.tags, id)
+	return nil
+}
+
+// AttachTags implements TagStore
+func (r *MemoryTaskRepository) AttachTags(ctx context.Context, taskID string, names []string) (*model.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var missing []string
+	for _, name := range names {
+		if r.tagByName(name) == nil {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrTagNotFound, strings.Join(missing, ", "))
+	}
+
+	task, err := r.owned(ctx, taskID, false)
+	if err != nil {
+		return nil, err
+	}
+	if task.Archived {
+		return nil, ErrTaskArchived
+	}
+
+	changed := false
+	for _, name := range names {
+		if !slices.Contains(task.Tags, name) {
+			task.Tags = append(task.Tags, name)
+			changed = true
+		}
+	}
+	if changed {
+		slices.Sort(task.Tags)
+		touch(task)
+	}
+
+	return copyTask(task), nil
+}
+
+// DetachTag implements TagStore
+func (r *MemoryTaskRepository) DetachTag(ctx context.Context, taskID, name string) (*model.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, err := r.owned(ctx, taskID, false)
+	if err != nil {
+		return nil, err
+	}
+	if task.Archived {
+		return nil, ErrTaskArchived
+	}
+
+	if i := slices.Index(task.Tags, name); i >= 0 {
+		task.Tags = slices.Delete(task.Tags, i, i+1)
+		touch(task)
+	}
+
+	return copyTask(task), nil
+}
+
+// tagByName returns the tag with this name, or nil
+func (r *MemoryTaskRepository) tagByName(name string) *model.Tag {
+	for _, tag := range r.tags {
+		if tag.Name == name {
+			return tag
+		}
+	}
+	return nil
+}
+
+// hasAllTags reports whether task carries every one of names
+func hasAllTags(task *model.Task, names []string) bool {
+	for _, name := range names {
+		if !slices.Contains(task.Tags, name) {
+			return false
+		}
+	}
+	return true
+}
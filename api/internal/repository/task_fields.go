@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// taskColumn is one entry of taskColumns: its SQL expression and the
+// Task field it scans into
+type taskColumn struct {
+	name  string
+	expr  string
+	field func(task *model.Task) any // pointer to the Task field
+}
+
+// taskColumnList is taskColumns broken into its columns, in order
+var taskColumnList = []taskColumn{
+	{"id", "id", func(t *model.Task) any { return &t.ID }},
+	{"project_key", "project_key", func(t *model.Task) any { return &t.ProjectKey }},
+	{"number", "number", func(t *model.Task) any { return &t.Number }},
+	{"title", "title", func(t *model.Task) any { return &t.Title }},
+	{"description", "description", func(t *model.Task) any { return &t.Description }},
+	{"status", "status", func(t *model.Task) any { return &t.Status }},
+	{"priority", "priority", func(t *model.Task) any { return &t.Priority }},
+	{"due_date", "due_date", func(t *model.Task) any { return &t.DueDate }},
+	{"recurrence", "recurrence", func(t *model.Task) any { return &t.Recurrence }},
+	{"next_occurrence_id", "next_occurrence_id::text", func(t *model.Task) any { return &t.NextOccurrenceID }},
+	{"assignee", "assignee", func(t *model.Task) any { return &t.Assignee }},
+	{"owner_id", "owner_id", func(t *model.Task) any { return &t.Owner }},
+	{"team_id", "team_id::text", func(t *model.Task) any { return &t.Team }},
+	{"archived", "archived", func(t *model.Task) any { return &t.Archived }},
+	{"position", "position", func(t *model.Task) any { return &t.Position }},
+	{"version", "version", func(t *model.Task) any { return &t.Version }},
+	{"created_at", "created_at", func(t *model.Task) any { return &t.CreatedAt }},
+	{"updated_at", "updated_at", func(t *model.Task) any { return &t.UpdatedAt }},
+	{"deleted_at", "deleted_at", func(t *model.Task) any { return &t.DeletedAt }},
+	{"tags", taskTagsColumn, func(t *model.Task) any { return &t.Tags }},
+}
+
+// taskFieldColumns maps each of model.TaskFields to the columns its
+// response value is built from
+var taskFieldColumns = map[string][]string{
+	"id":                 {"id"},
+	"ref":                {"project_key", "number"},
+	"title":              {"title"},
+	"description":        {"description"},
+	"status":             {"status"},
+	"priority":           {"priority"},
+	"due_date":           {"due_date"},
+	"is_overdue":         {"due_date", "status"},
+	"tags":               {"tags"},
+	"recurrence":         {"recurrence"},
+	"assignee":           {"assignee"},
+	"owner":              {"owner_id"},
+	"team":               {"team_id"},
+	"archived":           {"archived"},
+	"position":           {"position"},
+	"version":            {"version"},
+	"created_at":         {"created_at"},
+	"updated_at":         {"updated_at"},
+	"next_occurrence_id": {"next_occurrence_id"},
+}
+
+// selectedColumns returns the columns the response fields are built from,
+// in taskColumns order, or nil for every column when fields is empty.
+// Fields outside the whitelist select nothing.
+func selectedColumns(fields []string) []taskColumn {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	var names []string
+	for _, field := range fields {
+		names = append(names, taskFieldColumns[field]...)
+	}
+
+	columns := []taskColumn{taskColumnList[0]}
+	for _, column := range taskColumnList[1:] {
+		if slices.Contains(names, column.name) {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// selectList returns the SELECT list for columns, taskColumns when nil
+func selectList(columns []taskColumn) string {
+	if columns == nil {
+		return taskColumns
+	}
+	exprs := make([]string, len(columns))
+	for i, column := range columns {
+		exprs[i] = column.expr
+	}
+	return strings.Join(exprs, ", ")
+}
+
+// scanTaskColumns scans a row selected with selectList(columns)
+func scanTaskColumns(row scanner, columns []taskColumn) (*model.Task, error) {
+	if columns == nil {
+		return scanTask(row)
+	}
+
+	var task model.Task
+	dest := make([]any, len(columns))
+	for i, column := range columns {
+		dest[i] = column.field(&task)
+		if column.name == "tags" {
+			dest[i] = pq.Array(dest[i])
+		}
+	}
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// projectTask returns task with only columns set, the way a query
+// selecting them returns it; task itself when columns is nil
+func projectTask(task *model.Task, columns []taskColumn) *model.Task {
+	if columns == nil {
+		return task
+	}
+
+	var projected model.Task
+	for _, column := range columns {
+		reflect.ValueOf(column.field(&projected)).Elem().Set(reflect.ValueOf(column.field(task)).Elem())
+	}
+	return &projected
+}
+
+// projectTasks replaces each task with its projection onto the columns of
+// fields, as the SQL store reads them
+func projectTasks(tasks []*model.Task, fields []string) []*model.Task {
+	columns := selectedColumns(fields)
+	for i, task := range tasks {
+		tasks[i] = projectTask(task, columns)
+	}
+	return tasks
+}
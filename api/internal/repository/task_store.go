@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// TaskStore is the storage contract for tasks, implemented by the Postgres
+// TaskRepository and the in-memory MemoryTaskRepository used in demo mode
+type TaskStore interface {
+	Create(ctx context.Context, task *model.Task) (*model.Task, error)
+	// CreateMany inserts tasks in one transaction: all of them or none
+	CreateMany(ctx context.Context, tasks []*model.Task) ([]*model.Task, error)
+	GetByID(ctx context.Context, id string) (*model.Task, error)
+	GetByRef(ctx context.Context, ref model.Ref) (*model.Task, error)
+	GetByRefs(ctx context.Context, refs []model.Ref) ([]*model.Task, error)
+	GetByIDs(ctx context.Context, ids []string) ([]*model.Task, error)
+	// GetByProject returns every task of a project that is not deleted,
+	// archived ones included, in number order
+	GetByProject(ctx context.Context, projectKey string) ([]*model.Task, error)
+	GetAll(ctx context.Context, opts *model.ListOptions) ([]*model.Task, error)
+	Count(ctx context.Context, opts *model.ListOptions) (int, error)
+	// Board returns one column per status, in lifecycle order, each with
+	// its first live, unarchived tasks in board order and its total
+	Board(ctx context.Context, opts *model.BoardOptions) ([]*model.BoardTasks, error)
+	Search(ctx context.Context, opts *model.ListOptions) ([]*model.Task, error)
+	CountSearch(ctx context.Context, opts *model.ListOptions) (int, error)
+	Update(ctx context.Context, id string, updates *model.UpdateTaskRequest, expectedVersion int64) (*model.Task, error)
+	BulkUpdate(ctx context.Context, ids []string, updates *model.UpdateTaskRequest) ([]*model.Task, error)
+	Delete(ctx context.Context, id string, expectedVersion int64) error
+	BulkDelete(ctx context.Context, ids []string) ([]string, error)
+	HardDelete(ctx context.Context, id string, expectedVersion int64) error
+	Restore(ctx context.Context, id string) (*model.Task, error)
+	// SetArchived returns ErrTaskArchived or ErrTaskNotArchived when the
+	// task is already in the requested state
+	SetArchived(ctx context.Context, id string, archived bool) (*model.Task, error)
+	// SetAssignee returns ErrUserNotFound when the store knows the user is
+	// missing; a nil assignee unassigns the task
+	SetAssignee(ctx context.Context, id string, assignee *string) (*model.Task, error)
+	// SetTeam shares a live, unarchived task with a team, or stops sharing
+	// it when team is nil. A missing team gives ErrTeamNotFound.
+	SetTeam(ctx context.Context, id string, team *string) (*model.Task, error)
+	// Move places a live, unarchived task right before or, when after is
+	// set, right after the anchor task in position order. A missing anchor
+	// gives ErrAnchorNotFound.
+	Move(ctx context.Context, id, anchorID string, after bool) (*model.Task, error)
+	// Duplicate creates a pending copy of a task with ID newID. A due date
+	// already in the past is not copied.
+	Duplicate(ctx context.Context, id, newID string, opts *model.DuplicateOptions) (*model.Task, error)
+}
+
+var (
+	_ TaskStore = (*TaskRepository)(nil)
+	_ TaskStore = (*MemoryTaskRepository)(nil)
+)
@@ -5,176 +5,863 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"time"
+	"strings"
 
+	"github.com/lib/pq"
+	"github.com/moabdelazem/mutlitier_app/internal/audit"
 	"github.com/moabdelazem/mutlitier_app/internal/database"
 	"github.com/moabdelazem/mutlitier_app/internal/model"
 )
 
 var (
-	ErrTaskNotFound = errors.New("task not found")
+	ErrTaskNotFound    = errors.New("task not found")
+	ErrTagNotFound     = errors.New("tag not found")
+	ErrTagExists       = errors.New("tag already exists")
+	ErrVersionConflict = errors.New("task version conflict")
+	ErrTaskNotDeleted  = errors.New("task is not deleted")
+	ErrTaskArchived    = errors.New("task is archived")
+	ErrTaskNotArchived = errors.New("task is not archived")
+	ErrNotRecurring    = errors.New("task has no pending recurrence")
+	ErrAnchorNotFound  = errors.New("task to move next to not found")
 )
 
+// AnyVersion skips the optimistic concurrency check on Update and Delete
+const AnyVersion int64 = 0
+
+// taskColumns is the column list shared by every task query, in scanTask
+// order. Tag names come from a correlated subquery so loading a page of
+// tasks stays a single statement.
+const taskColumns = `id, project_key, number, title, description, status, priority, due_date, recurrence, next_occurrence_id::text,
+	assignee, owner_id, team_id::text, archived, position, version, created_at, updated_at, deleted_at,
+	` + taskTagsColumn
+
+// taskTagsColumn selects a task's tag names, sorted
+const taskTagsColumn = `ARRAY(SELECT tags.name FROM task_tags JOIN tags ON tags.id = task_tags.tag_id WHERE task_tags.task_id = tasks.id ORDER BY tags.name)`
+
+// scanner is implemented by *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTask scans a row selected with taskColumns into a Task
+func scanTask(row scanner) (*model.Task, error) {
+	var task model.Task
+	err := row.Scan(
+		&task.ID,
+		&task.ProjectKey,
+		&task.Number,
+		&task.Title,
+		&task.Description,
+		&task.Status,
+		&task.Priority,
+		&task.DueDate,
+		&task.Recurrence,
+		&task.NextOccurrenceID,
+		&task.Assignee,
+		&task.Owner,
+		&task.Team,
+		&task.Archived,
+		&task.Position,
+		&task.Version,
+		&task.CreatedAt,
+		&task.UpdatedAt,
+		&task.DeletedAt,
+		pq.Array(&task.Tags),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
 // TaskRepository handles database operations for tasks
 type TaskRepository struct {
-	db *database.DB
+	db   *database.DB
+	tags *CRUDRepository[model.Tag, model.UpdateTagRequest]
 }
 
 // NewTaskRepository creates a new TaskRepository
 func NewTaskRepository(db *database.DB) *TaskRepository {
-	return &TaskRepository{db: db}
+	return &TaskRepository{db: db, tags: NewCRUDRepository(db, tagTable)}
 }
 
-// Create inserts a new task into the database
-func (r *TaskRepository) Create(ctx context.Context, task *model.Task) (*model.Task, error) {
-	query := `
-		INSERT INTO tasks (title, description, status)
-		VALUES ($1, $2, $3)
-		RETURNING id, title, description, status, created_at, updated_at
-	`
+// createTaskQuery inserts a task, assigning the next sequential number
+// for its project and creating the project if missing in the same statement
+const createTaskQuery = `
+	WITH project AS (
+		INSERT INTO projects (key, name)
+		VALUES ($2, $2)
+		ON CONFLICT (tenant_id, key) DO NOTHING
+	), seq AS (
+		INSERT INTO task_sequences (project_key, last_number)
+		VALUES ($2, 1)
+		ON CONFLICT (tenant_id, project_key)
+		DO UPDATE SET last_number = task_sequences.last_number + 1
+		RETURNING last_number
+	)
+	INSERT INTO tasks (id, project_key, number, title, description, status, priority, due_date, updated_by, recurrence, assignee, owner_id, team_id)
+	SELECT $1, $2, seq.last_number, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12 FROM seq
+	RETURNING ` + taskColumns
 
-	var createdTask model.Task
-	err := r.db.QueryRowContext(ctx, query,
+// createTaskArgs returns the arguments of createTaskQuery for task
+func createTaskArgs(ctx context.Context, task *model.Task) []any {
+	return []any{
+		task.ID,
+		task.ProjectKey,
 		task.Title,
 		task.Description,
-		"pending",
-	).Scan(
-		&createdTask.ID,
-		&createdTask.Title,
-		&createdTask.Description,
-		&createdTask.Status,
-		&createdTask.CreatedAt,
-		&createdTask.UpdatedAt,
-	)
+		model.StatusPending,
+		task.Priority,
+		task.DueDate,
+		audit.Actor(ctx),
+		task.Recurrence,
+		task.Assignee,
+		task.Owner,
+		task.Team,
+	}
+}
 
+// Create inserts a new task into the database, assigning the next
+// sequential number for its project in the same statement
+func (r *TaskRepository) Create(ctx context.Context, task *model.Task) (*model.Task, error) {
+	createdTask, err := scanTask(r.db.QueryRowContext(ctx, createTaskQuery, createTaskArgs(ctx, task)...))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create task: %w", err)
 	}
 
-	return &createdTask, nil
+	return createdTask, nil
+}
+
+// CreateMany inserts tasks in one transaction, so either all of them are
+// created or, on the first failure, none
+func (r *TaskRepository) CreateMany(ctx context.Context, tasks []*model.Task) ([]*model.Task, error) {
+	created := make([]*model.Task, 0, len(tasks))
+	err := r.db.InTx(ctx, func(ctx context.Context) error {
+		for _, task := range tasks {
+			createdTask, err := scanTask(r.db.QueryRowContext(ctx, createTaskQuery, createTaskArgs(ctx, task)...))
+			if err != nil {
+				return fmt.Errorf("failed to create task: %w", err)
+			}
+			created = append(created, createdTask)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
 }
 
 // GetByID retrieves a task by its ID
 func (r *TaskRepository) GetByID(ctx context.Context, id string) (*model.Task, error) {
-	query := `
-		SELECT id, title, description, status, created_at, updated_at
-		FROM tasks
-		WHERE id = $1
-	`
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE id = $1 AND deleted_at IS NULL AND ` + taskFilter("tasks", "$2")
 
-	var task model.Task
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&task.ID,
-		&task.Title,
-		&task.Description,
-		&task.Status,
-		&task.CreatedAt,
-		&task.UpdatedAt,
-	)
+	task, err := scanTask(r.db.QueryRowContext(ctx, query, id, owner))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, r.missing(ctx, id, false)
+		}
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+
+	return task, nil
+}
 
+// GetByRef retrieves a task by its project key and sequential number
+func (r *TaskRepository) GetByRef(ctx context.Context, ref model.Ref) (*model.Task, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE project_key = $1 AND number = $2 AND deleted_at IS NULL AND ` + taskFilter("tasks", "$3")
+
+	task, err := scanTask(r.db.QueryRowContext(ctx, query, ref.ProjectKey, ref.Number, owner))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrTaskNotFound
 		}
-		return nil, fmt.Errorf("failed to get task: %w", err)
+		return nil, fmt.Errorf("failed to get task by ref: %w", err)
 	}
 
-	return &task, nil
+	return task, nil
 }
 
-// GetAll retrieves all tasks from the database
-func (r *TaskRepository) GetAll(ctx context.Context) ([]*model.Task, error) {
+// GetByRefs retrieves all tasks matching any of the given references
+func (r *TaskRepository) GetByRefs(ctx context.Context, refs []model.Ref) ([]*model.Task, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, len(refs))
+	numbers := make([]int64, len(refs))
+	for i, ref := range refs {
+		keys[i] = ref.ProjectKey
+		numbers[i] = ref.Number
+	}
+
 	query := `
-		SELECT id, title, description, status, created_at, updated_at
+		SELECT ` + taskColumns + `
 		FROM tasks
-		ORDER BY created_at DESC
+		WHERE (project_key, number) IN (
+			SELECT * FROM unnest($1::text[], $2::bigint[])
+		)
+		AND deleted_at IS NULL AND ` + taskFilter("tasks", "$3") + `
+		ORDER BY project_key, number
 	`
 
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(keys), pq.Array(numbers), owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tasks by refs: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTasks(rows)
+}
+
+// GetByIDs retrieves the tasks with any of the given IDs, in ID order
+func (r *TaskRepository) GetByIDs(ctx context.Context, ids []string) ([]*model.Task, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL AND ` + taskFilter("tasks", "$2") + ` ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids), owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tasks by ids: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTasks(rows)
+}
+
+// GetByProject retrieves every task of a project that is not deleted, in number order
+func (r *TaskRepository) GetByProject(ctx context.Context, projectKey string) ([]*model.Task, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE project_key = $1 AND deleted_at IS NULL AND ` + taskFilter("tasks", "$2") + ` ORDER BY number`
+
+	rows, err := r.db.QueryContext(ctx, query, projectKey, owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tasks by project: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTasks(rows)
+}
+
+// sortColumns maps accepted sort keys to SQL columns
+var sortColumns = map[string]string{
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"title":      "title",
+	"status":     "status",
+	"priority":   "priority_rank",
+	"position":   "position",
+}
+
+// sortOrders maps accepted sort orders to SQL directions
+var sortOrders = map[string]string{
+	"asc":  "ASC",
+	"desc": "DESC",
+}
+
+// GetAll retrieves tasks from the database matching the list options
+func (r *TaskRepository) GetAll(ctx context.Context, opts *model.ListOptions) ([]*model.Task, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	column, ok := sortColumns[opts.Sort]
+	if !ok {
+		column = "created_at"
+	}
+	order, ok := sortOrders[opts.Order]
+	if !ok {
+		order = "DESC"
+	}
+
+	// Only the columns of the requested fields are read; id breaks ties so
+	// pages stay stable when sort values repeat
+	columns := selectedColumns(opts.Fields)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM tasks
+		WHERE deleted_at IS NULL AND ($1 = '' OR lower(title) LIKE lower($1) || '%%')
+			AND (cardinality($4::text[]) = 0 OR priority = ANY($4))
+			AND (NOT $5 OR (due_date < NOW() AND status NOT IN ('completed', 'cancelled')))
+			AND %s
+			AND ($7 OR NOT archived)
+			AND ($8 = '' OR assignee = $8)
+			AND ($9 = '' OR project_key = $9)
+			AND %s
+		ORDER BY %s %s, id %s
+		LIMIT $2 OFFSET $3
+	`, selectList(columns), taskTagsFilter("$6"), taskFilter("tasks", "$10"), column, order, order)
+
+	offset := opts.Offset()
+
+	rows, err := r.db.QueryContext(ctx, query, escapeLike(opts.Search), opts.PerPage, offset, priorityArray(opts.Priorities), opts.Overdue, tagArray(opts.Tags), opts.IncludeArchived, opts.Assignee, opts.Project, owner)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tasks: %w", err)
 	}
 	defer rows.Close()
 
-	var tasks []*model.Task
-	for rows.Next() {
-		var task model.Task
-		if err := rows.Scan(
-			&task.ID,
-			&task.Title,
-			&task.Description,
-			&task.Status,
-			&task.CreatedAt,
-			&task.UpdatedAt,
-		); err != nil {
-			return nil, fmt.Errorf("failed to scan task: %w", err)
+	return scanTaskColumnRows(rows, columns)
+}
+
+// Count returns the number of tasks matching the list options' filters
+func (r *TaskRepository) Count(ctx context.Context, opts *model.ListOptions) (int, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return 0, err
+	}
+	query := `
+		SELECT COUNT(*) FROM tasks
+		WHERE deleted_at IS NULL AND ($1 = '' OR lower(title) LIKE lower($1) || '%')
+			AND (cardinality($2::text[]) = 0 OR priority = ANY($2))
+			AND (NOT $3 OR (due_date < NOW() AND status NOT IN ('completed', 'cancelled')))
+			AND ` + taskTagsFilter("$4") + `
+			AND ($5 OR NOT archived)
+			AND ($6 = '' OR assignee = $6)
+			AND ($7 = '' OR project_key = $7)
+			AND ` + taskFilter("tasks", "$8") + `
+	`
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, query, escapeLike(opts.Search), priorityArray(opts.Priorities), opts.Overdue, tagArray(opts.Tags), opts.IncludeArchived, opts.Assignee, opts.Project, owner).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count tasks: %w", err)
+	}
+
+	return total, nil
+}
+
+// Search retrieves tasks matching a full-text query over title and
+// description, best matches first. Title matches rank above description ones.
+func (r *TaskRepository) Search(ctx context.Context, opts *model.ListOptions) ([]*model.Task, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := selectedColumns(opts.Fields)
+	query := `
+		SELECT ` + selectList(columns) + `
+		FROM tasks, websearch_to_tsquery('english', $1) AS q
+		WHERE search_vector @@ q AND deleted_at IS NULL AND ` + taskFilter("tasks", "$4") + `
+		ORDER BY ts_rank_cd(search_vector, q) DESC, created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	offset := opts.Offset()
+
+	rows, err := r.db.QueryContext(ctx, query, opts.Search, opts.PerPage, offset, owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search tasks: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTaskColumnRows(rows, columns)
+}
+
+// CountSearch returns the number of tasks matching a full-text query
+func (r *TaskRepository) CountSearch(ctx context.Context, opts *model.ListOptions) (int, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return 0, err
+	}
+	query := `SELECT COUNT(*) FROM tasks WHERE search_vector @@ websearch_to_tsquery('english', $1) AND deleted_at IS NULL AND ` + taskFilter("tasks", "$2")
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, query, opts.Search, owner).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count search results: %w", err)
+	}
+
+	return total, nil
+}
+
+// Update applies the non-nil fields of updates in a single statement, so
+// concurrent updates to different fields do not overwrite each other. Unless
+// expectedVersion is AnyVersion, the task must still be at that version.
+func (r *TaskRepository) Update(ctx context.Context, id string, updates *model.UpdateTaskRequest, expectedVersion int64) (*model.Task, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		UPDATE tasks
+		SET title = COALESCE($1, title),
+			description = COALESCE($2, description),
+			status = COALESCE($3, status),
+			priority = COALESCE($6, priority),
+			due_date = CASE WHEN $8 THEN NULL ELSE COALESCE($7, due_date) END,
+			recurrence = CASE WHEN $11::text IS NULL THEN recurrence ELSE NULLIF($11, '') END,
+			updated_by = $10
+		WHERE id = $4 AND deleted_at IS NULL AND NOT archived AND ($5 = 0 OR version = $5)
+			AND (cardinality($9::text[]) = 0 OR status = ANY($9))
+			AND ` + taskFilter("tasks", "$12") + `
+		RETURNING ` + taskColumns
+
+	updatedTask, err := scanTask(r.db.QueryRowContext(ctx, query,
+		updates.Title,
+		updates.Description,
+		updates.Status,
+		id,
+		expectedVersion,
+		updates.Priority,
+		updates.DueDate,
+		updates.ClearDueDate,
+		statusArray(updates.FromStatuses),
+		audit.Actor(ctx),
+		updates.Recurrence,
+		owner,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, r.missingOrConflict(ctx, id, false)
 		}
-		tasks = append(tasks, &task)
+		return nil, fmt.Errorf("failed to update task: %w", err)
+	}
+
+	return updatedTask, nil
+}
+
+// BulkUpdate applies the non-nil fields of updates to every live task in
+// ids in a single statement and returns the tasks it changed; IDs that
+// are missing, soft-deleted or archived are skipped
+func (r *TaskRepository) BulkUpdate(ctx context.Context, ids []string, updates *model.UpdateTaskRequest) ([]*model.Task, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		UPDATE tasks
+		SET title = COALESCE($1, title),
+			description = COALESCE($2, description),
+			status = COALESCE($3, status),
+			priority = COALESCE($5, priority),
+			due_date = CASE WHEN $7 THEN NULL ELSE COALESCE($6, due_date) END,
+			recurrence = CASE WHEN $10::text IS NULL THEN recurrence ELSE NULLIF($10, '') END,
+			updated_by = $9
+		WHERE id = ANY($4::uuid[]) AND deleted_at IS NULL AND NOT archived
+			AND (cardinality($8::text[]) = 0 OR status = ANY($8))
+			AND ` + taskFilter("tasks", "$11") + `
+		RETURNING ` + taskColumns
+
+	rows, err := r.db.QueryContext(ctx, query,
+		updates.Title,
+		updates.Description,
+		updates.Status,
+		pq.Array(ids),
+		updates.Priority,
+		updates.DueDate,
+		updates.ClearDueDate,
+		statusArray(updates.FromStatuses),
+		audit.Actor(ctx),
+		updates.Recurrence,
+		owner,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to bulk update tasks: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTasks(rows)
+}
+
+// Delete soft-deletes a task, hiding it from reads until it is restored.
+// Unless expectedVersion is AnyVersion, the task must still be at that version.
+func (r *TaskRepository) Delete(ctx context.Context, id string, expectedVersion int64) error {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return err
+	}
+	query := `
+		UPDATE tasks
+		SET deleted_at = NOW(), updated_by = $3
+		WHERE id = $1 AND deleted_at IS NULL AND NOT archived AND ($2 = 0 OR version = $2)
+			AND ` + taskFilter("tasks", "$4") + commentFilter(ctx) + `
+	`
+
+	return r.execVersioned(ctx, "delete task", query, id, expectedVersion, false, audit.Actor(ctx), owner)
+}
+
+// BulkDelete soft-deletes every live, unarchived task in ids in a single
+// statement and returns the IDs it deleted
+func (r *TaskRepository) BulkDelete(ctx context.Context, ids []string) ([]string, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		UPDATE tasks
+		SET deleted_at = NOW(), updated_by = $2
+		WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL AND NOT archived
+			AND ` + taskFilter("tasks", "$3") + commentFilter(ctx) + `
+		RETURNING id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids), audit.Actor(ctx), owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to bulk delete tasks: %w", err)
 	}
+	defer rows.Close()
 
+	var deleted []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan deleted task id: %w", err)
+		}
+		deleted = append(deleted, id)
+	}
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating tasks: %w", err)
+		return nil, fmt.Errorf("error iterating deleted tasks: %w", err)
 	}
 
-	return tasks, nil
+	return deleted, nil
+}
+
+// HardDelete permanently removes a task, whether or not it is soft-deleted,
+// unless it is archived. Unless expectedVersion is AnyVersion, the task
+// must still be at that version.
+func (r *TaskRepository) HardDelete(ctx context.Context, id string, expectedVersion int64) error {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return err
+	}
+	query := `DELETE FROM tasks WHERE id = $1 AND NOT archived AND ($2 = 0 OR version = $2) AND ` + taskFilter("tasks", "$3") + commentFilter(ctx)
+
+	return r.execVersioned(ctx, "hard delete task", query, id, expectedVersion, true, owner)
 }
 
-// Update updates a task in the database
-func (r *TaskRepository) Update(ctx context.Context, id string, updates *model.UpdateTaskRequest) (*model.Task, error) {
-	// First, get the current task
-	currentTask, err := r.GetByID(ctx, id)
+// Restore undoes a soft delete
+func (r *TaskRepository) Restore(ctx context.Context, id string) (*model.Task, error) {
+	owner, err := scopeParam(ctx)
 	if err != nil {
 		return nil, err
 	}
+	query := `
+		UPDATE tasks
+		SET deleted_at = NULL, updated_by = $2
+		WHERE id = $1 AND deleted_at IS NOT NULL AND ` + taskFilter("tasks", "$3") + `
+		RETURNING ` + taskColumns
 
-	// Apply updates
-	if updates.Title != nil {
-		currentTask.Title = *updates.Title
+	restoredTask, err := scanTask(r.db.QueryRowContext(ctx, query, id, audit.Actor(ctx), owner))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// A live task matches as a "conflict", anything else is missing
+			err = r.missingOrConflict(ctx, id, false)
+			if errors.Is(err, ErrTaskNotFound) {
+				err = r.missing(ctx, id, true)
+			}
+			if errors.Is(err, ErrVersionConflict) || errors.Is(err, ErrTaskArchived) {
+				return nil, ErrTaskNotDeleted
+			}
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to restore task: %w", err)
 	}
-	if updates.Description != nil {
-		currentTask.Description = *updates.Description
+
+	return restoredTask, nil
+}
+
+// SetArchived archives or unarchives a live task
+func (r *TaskRepository) SetArchived(ctx context.Context, id string, archived bool) (*model.Task, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return nil, err
 	}
-	if updates.Status != nil {
-		currentTask.Status = *updates.Status
+	query := `
+		UPDATE tasks
+		SET archived = $2, updated_by = $3
+		WHERE id = $1 AND deleted_at IS NULL AND archived <> $2 AND ` + taskFilter("tasks", "$4") + `
+		RETURNING ` + taskColumns
+
+	task, err := scanTask(r.db.QueryRowContext(ctx, query, id, archived, audit.Actor(ctx), owner))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The task is missing or already in the requested state
+			err = r.missingOrConflict(ctx, id, false)
+			if errors.Is(err, ErrTaskArchived) || errors.Is(err, ErrVersionConflict) {
+				return nil, archivedConflict(archived)
+			}
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to archive task: %w", err)
 	}
 
+	return task, nil
+}
+
+// SetAssignee assigns a live, unarchived task to a user, or unassigns it
+// when assignee is nil. Unknown users give ErrUserNotFound.
+func (r *TaskRepository) SetAssignee(ctx context.Context, id string, assignee *string) (*model.Task, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return nil, err
+	}
 	query := `
 		UPDATE tasks
-		SET title = $1, description = $2, status = $3, updated_at = $4
-		WHERE id = $5
-		RETURNING id, title, description, status, created_at, updated_at
+		SET assignee = $2, updated_by = $3
+		WHERE id = $1 AND deleted_at IS NULL AND NOT archived AND ` + taskFilter("tasks", "$4") + `
+		RETURNING ` + taskColumns
+
+	task, err := scanTask(r.db.QueryRowContext(ctx, query, id, assignee, audit.Actor(ctx), owner))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, r.missingOrConflict(ctx, id, false)
+		}
+		if isForeignKeyViolation(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to assign task: %w", err)
+	}
+
+	return task, nil
+}
+
+// SetTeam shares a live, unarchived task with a team, or stops sharing it
+// when team is nil. Missing teams give ErrTeamNotFound.
+func (r *TaskRepository) SetTeam(ctx context.Context, id string, team *string) (*model.Task, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		UPDATE tasks
+		SET team_id = $2, updated_by = $3
+		WHERE id = $1 AND deleted_at IS NULL AND NOT archived AND ` + taskFilter("tasks", "$4") + `
+		RETURNING ` + taskColumns
+
+	task, err := scanTask(r.db.QueryRowContext(ctx, query, id, team, audit.Actor(ctx), owner))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, r.missingOrConflict(ctx, id, false)
+		}
+		if isForeignKeyViolation(err) {
+			return nil, ErrTeamNotFound
+		}
+		return nil, fmt.Errorf("failed to share task: %w", err)
+	}
+
+	return task, nil
+}
+
+// positionGap is the spacing of task positions when they are assigned
+// or renumbered, leaving room for moves in between
+const positionGap = 1024
+
+// errNoGap means no free position is left where a task is being moved
+var errNoGap = errors.New("no free position between tasks")
+
+// Move implements TaskStore. Moves are serialized with an advisory lock
+// so two of them never claim the same gap. A move normally takes the
+// midpoint between the anchor and its neighbour; when they are adjacent,
+// every task is renumbered positionGap apart first, bumping their versions.
+func (r *TaskRepository) Move(ctx context.Context, id, anchorID string, after bool) (*model.Task, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var task *model.Task
+	err = r.db.InTx(ctx, func(ctx context.Context) error {
+		tx := r.db.TxFrom(ctx)
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('tasks.position'))`); err != nil {
+			return fmt.Errorf("failed to lock task positions: %w", err)
+		}
+
+		var archived bool
+		query := `SELECT archived FROM tasks WHERE id = $1 AND deleted_at IS NULL AND ` + taskFilter("tasks", "$2") + ` FOR UPDATE`
+		err := tx.QueryRowContext(ctx, query, id, owner).Scan(&archived)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return r.missing(ctx, id, false)
+			}
+			return fmt.Errorf("failed to get task: %w", err)
+		}
+		if archived {
+			return ErrTaskArchived
+		}
+
+		position, err := freePosition(ctx, tx, id, anchorID, after, owner)
+		if errors.Is(err, errNoGap) {
+			if err := renumberPositions(ctx, tx); err != nil {
+				return err
+			}
+			position, err = freePosition(ctx, tx, id, anchorID, after, owner)
+		}
+		if err != nil {
+			return err
+		}
+
+		query = `UPDATE tasks SET position = $2, updated_by = $3 WHERE id = $1 RETURNING ` + taskColumns
+		if task, err = scanTask(tx.QueryRowContext(ctx, query, id, position, audit.Actor(ctx))); err != nil {
+			return fmt.Errorf("failed to move task: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// freePosition returns the position between the anchor and the live task
+// next to it on the side the task id moves to, ignoring id itself. Past
+// the last task it takes the next value of the sequence new tasks use, so
+// they keep being created at the end. An anchor outside the owner scope is
+// missing, but its neighbours are found among every task, since positions
+// are shared by all of them.
+func freePosition(ctx context.Context, tx *sql.Tx, id, anchorID string, after bool, owner any) (int64, error) {
+	compare, order := "<", "DESC"
+	if after {
+		compare, order = ">", "ASC"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT anchor.position, (
+			SELECT t.position FROM tasks t
+			WHERE t.deleted_at IS NULL AND t.id <> $1 AND (t.position, t.id) %s (anchor.position, anchor.id)
+			ORDER BY t.position %s, t.id %s
+			LIMIT 1
+		)
+		FROM tasks anchor
+		WHERE anchor.id = $2 AND anchor.deleted_at IS NULL AND %s
+	`, compare, order, order, taskFilter("anchor", "$3"))
+
+	var anchor int64
+	var neighbour sql.NullInt64
+	if err := tx.QueryRowContext(ctx, query, id, anchorID, owner).Scan(&anchor, &neighbour); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrAnchorNotFound
+		}
+		return 0, fmt.Errorf("failed to find a free position: %w", err)
+	}
+
+	if !neighbour.Valid {
+		if !after {
+			return anchor - positionGap, nil
+		}
+		var next int64
+		if err := tx.QueryRowContext(ctx, `SELECT nextval('task_positions') * $1`, positionGap).Scan(&next); err != nil {
+			return 0, fmt.Errorf("failed to allocate a position: %w", err)
+		}
+		return next, nil
+	}
+
+	return midpoint(anchor, neighbour.Int64)
+}
+
+// midpoint returns the position halfway between a and b, or errNoGap
+// when there is no integer strictly between them
+func midpoint(a, b int64) (int64, error) {
+	low, high := min(a, b), max(a, b)
+	mid := low + (high-low)/2
+	if mid == low {
+		return 0, errNoGap
+	}
+	return mid, nil
+}
+
+// renumberPositions spaces every task positionGap apart in its current
+// order, touching only the rows whose position changes
+func renumberPositions(ctx context.Context, tx *sql.Tx) error {
+	query := `
+		UPDATE tasks SET position = ranked.rank * $1
+		FROM (SELECT id, ROW_NUMBER() OVER (ORDER BY position, id) AS rank FROM tasks) AS ranked
+		WHERE tasks.id = ranked.id AND tasks.position <> ranked.rank * $1
 	`
+	if _, err := tx.ExecContext(ctx, query, positionGap); err != nil {
+		return fmt.Errorf("failed to renumber task positions: %w", err)
+	}
+	return nil
+}
 
-	var updatedTask model.Task
-	err = r.db.QueryRowContext(ctx, query,
-		currentTask.Title,
-		currentTask.Description,
-		currentTask.Status,
-		time.Now(),
-		id,
-	).Scan(
-		&updatedTask.ID,
-		&updatedTask.Title,
-		&updatedTask.Description,
-		&updatedTask.Status,
-		&updatedTask.CreatedAt,
-		&updatedTask.UpdatedAt,
-	)
+// Duplicate implements TaskStore in a single statement, so the copy and
+// its tags are created together or not at all. As in CreateOccurrence,
+// the copied tags are read from the source task.
+func (r *TaskRepository) Duplicate(ctx context.Context, id, newID string, opts *model.DuplicateOptions) (*model.Task, error) {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		WITH source AS (
+			SELECT * FROM tasks WHERE id = $1 AND deleted_at IS NULL AND ` + taskFilter("tasks", "$9") + `
+		), seq AS (
+			INSERT INTO task_sequences (project_key, last_number)
+			SELECT project_key, 1 FROM source
+			ON CONFLICT (tenant_id, project_key)
+			DO UPDATE SET last_number = task_sequences.last_number + 1
+			RETURNING last_number
+		), created AS (
+			INSERT INTO tasks (id, project_key, number, title, description, status, priority, due_date, updated_by, recurrence, owner_id, team_id)
+			SELECT $2, source.project_key, seq.last_number, source.title, source.description, $3, source.priority,
+				CASE WHEN $4 AND source.due_date > NOW() THEN source.due_date END,
+				$5,
+				CASE WHEN $6 THEN source.recurrence END,
+				$8, source.team_id
+			FROM source, seq
+			RETURNING *
+		), tagged AS (
+			INSERT INTO task_tags (task_id, tag_id)
+			SELECT created.id, task_tags.tag_id FROM created, task_tags WHERE task_tags.task_id = $1 AND $7
+		)
+		SELECT id, project_key, number, title, description, status, priority, due_date, recurrence, next_occurrence_id::text,
+			assignee, owner_id, team_id::text, archived, position, version, created_at, updated_at, deleted_at,
+			CASE WHEN $7 THEN ARRAY(SELECT tags.name FROM task_tags JOIN tags ON tags.id = task_tags.tag_id WHERE task_tags.task_id = $1 ORDER BY tags.name)
+			ELSE '{}' END
+		FROM created
+	`
 
+	task, err := scanTask(r.db.QueryRowContext(ctx, query,
+		id,
+		newID,
+		model.StatusPending,
+		opts.DueDate,
+		audit.Actor(ctx),
+		opts.Recurrence,
+		opts.Tags,
+		opts.Owner,
+		owner,
+	))
 	if err != nil {
-		return nil, fmt.Errorf("failed to update task: %w", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, r.missing(ctx, id, false)
+		}
+		return nil, fmt.Errorf("failed to duplicate task: %w", err)
 	}
 
-	return &updatedTask, nil
+	return task, nil
 }
 
-// Delete removes a task from the database
-func (r *TaskRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM tasks WHERE id = $1`
+// archivedConflict is the error for archiving an archived task or
+// unarchiving one that is not
+func archivedConflict(archived bool) error {
+	if archived {
+		return ErrTaskArchived
+	}
+	return ErrTaskNotArchived
+}
 
-	result, err := r.db.ExecContext(ctx, query, id)
+// execVersioned runs a versioned write and explains a miss. The query
+// takes id and expectedVersion as $1 and $2, followed by args.
+func (r *TaskRepository) execVersioned(ctx context.Context, action, query, id string, expectedVersion int64, includeDeleted bool, args ...any) error {
+	result, err := r.db.ExecContext(ctx, query, append([]any{id, expectedVersion}, args...)...)
 	if err != nil {
-		return fmt.Errorf("failed to delete task: %w", err)
+		return fmt.Errorf("failed to %s: %w", action, err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -183,8 +870,127 @@ func (r *TaskRepository) Delete(ctx context.Context, id string) error {
 	}
 
 	if rowsAffected == 0 {
-		return ErrTaskNotFound
+		return r.missingOrConflict(ctx, id, includeDeleted)
 	}
 
 	return nil
 }
+
+// missingOrConflict explains why a versioned write matched no rows.
+// Soft-deleted tasks count as missing unless includeDeleted is set, tasks
+// outside the context's owner scope are ErrNotOwned, and under
+// KeepCommented tasks with comments are ErrTaskCommented.
+func (r *TaskRepository) missingOrConflict(ctx context.Context, id string, includeDeleted bool) error {
+	owner, err := scopeParam(ctx)
+	if err != nil {
+		return err
+	}
+	query := `
+		SELECT archived, ` + taskFilter("tasks", "$3") + `,
+			EXISTS (SELECT 1 FROM task_comments WHERE task_comments.task_id = tasks.id)
+		FROM tasks WHERE id = $1 AND ($2 OR deleted_at IS NULL)
+	`
+
+	var archived, reachable, commented bool
+	if err := r.db.QueryRowContext(ctx, query, id, includeDeleted, owner).Scan(&archived, &reachable, &commented); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTaskNotFound
+		}
+		return fmt.Errorf("failed to check task: %w", err)
+	}
+
+	if !reachable {
+		return ErrNotOwned
+	}
+	if archived {
+		return ErrTaskArchived
+	}
+	if commented && keepsCommented(ctx) {
+		return ErrTaskCommented
+	}
+	return ErrVersionConflict
+}
+
+// commentFilter is the predicate KeepCommented adds to task deletes
+func commentFilter(ctx context.Context) string {
+	if !keepsCommented(ctx) {
+		return ""
+	}
+	return ` AND NOT EXISTS (SELECT 1 FROM task_comments WHERE task_comments.task_id = tasks.id)`
+}
+
+// missing explains why a scoped read matched no rows: ErrNotOwned when
+// the task exists outside the context's owner scope, ErrTaskNotFound
+// otherwise
+func (r *TaskRepository) missing(ctx context.Context, id string, includeDeleted bool) error {
+	if _, ok := ScopeFrom(ctx); !ok {
+		return ErrTaskNotFound
+	}
+	err := r.missingOrConflict(ctx, id, includeDeleted)
+	if errors.Is(err, ErrTaskArchived) || errors.Is(err, ErrVersionConflict) {
+		// In scope after all, so it changed since the read
+		return ErrTaskNotFound
+	}
+	return err
+}
+
+// scanTasks scans all rows selected with taskColumns
+func scanTasks(rows *sql.Rows) ([]*model.Task, error) {
+	return scanTaskColumnRows(rows, nil)
+}
+
+// scanTaskColumnRows scans rows selected with selectList(columns)
+func scanTaskColumnRows(rows *sql.Rows, columns []taskColumn) ([]*model.Task, error) {
+	var tasks []*model.Task
+	for rows.Next() {
+		task, err := scanTaskColumns(rows, columns)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan task: %w", err)
+		}
+		tasks = append(tasks, task)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tasks: %w", err)
+	}
+
+	return tasks, nil
+}
+
+// priorityArray converts priorities into a text[] parameter
+func priorityArray(priorities []model.Priority) any {
+	values := make([]string, len(priorities))
+	for i, priority := range priorities {
+		values[i] = string(priority)
+	}
+	return pq.Array(values)
+}
+
+// statusArray converts statuses into a text[] parameter
+func statusArray(statuses []model.Status) any {
+	values := make([]string, len(statuses))
+	for i, status := range statuses {
+		values[i] = string(status)
+	}
+	return pq.Array(values)
+}
+
+// tagArray converts tag names into a text[] parameter, empty rather than
+// NULL when there are none
+func tagArray(names []string) any {
+	if names == nil {
+		names = []string{}
+	}
+	return pq.Array(names)
+}
+
+// taskTagsFilter matches tasks carrying every tag name in the text[]
+// parameter param; an empty array matches every task
+func taskTagsFilter(param string) string {
+	return `ARRAY(SELECT tags.name FROM task_tags JOIN tags ON tags.id = task_tags.tag_id WHERE task_tags.task_id = tasks.id) @> ` + param + `::text[]`
+}
+
+// escapeLike escapes LIKE wildcards so user input is matched literally
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
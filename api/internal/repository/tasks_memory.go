@@ -0,0 +1,1003 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+var (
+	ErrStoreFull = errors.New("task store is full")
+)
+
+// MemoryTaskRepository is an in-memory TaskStore used by demo mode.
+// It mirrors the Postgres repository's ordering and numbering semantics.
+type MemoryTaskRepository struct {
+	mu        sync.RWMutex
+	tasks     map[string]*model.Task
+	sequences map[string]int64 // by projectID
+	tags      map[string]*model.Tag
+	projects  map[string]*model.Project // by projectID
+	teams     map[string]*model.Team
+	members   map[string]map[string]bool // team id to its members
+	history   map[string][]*model.TaskHistoryEntry
+	historyID int64
+	rules     map[string]*model.EscalationRule
+	escalated []*model.Escalation
+	automated map[string]*model.AutomationRule
+	runs      []*model.AutomationRun
+	embedded  map[string]*model.TaskEmbedding
+	position  int64 // last position given to a task appended at the end
+	maxTasks  int
+	comments  *MemoryCommentRepository
+}
+
+// NewMemoryTaskRepository creates a new MemoryTaskRepository holding at most
+// maxTasks tasks (0 means unlimited)
+func NewMemoryTaskRepository(maxTasks int) *MemoryTaskRepository {
+	return &MemoryTaskRepository{
+		tasks:     make(map[string]*model.Task),
+		sequences: make(map[string]int64),
+		tags:      make(map[string]*model.Tag),
+		projects:  make(map[string]*model.Project),
+		teams:     make(map[string]*model.Team),
+		members:   make(map[string]map[string]bool),
+		history:   make(map[string][]*model.TaskHistoryEntry),
+		rules:     make(map[string]*model.EscalationRule),
+		automated: make(map[string]*model.AutomationRule),
+		embedded:  make(map[string]*model.TaskEmbedding),
+		maxTasks:  maxTasks,
+	}
+}
+
+// FollowComments makes deletes under KeepCommented see the comments kept
+// in comments; without it tasks have none
+func (r *MemoryTaskRepository) FollowComments(comments *MemoryCommentRepository) *MemoryTaskRepository {
+	r.comments = comments
+	return r
+}
+
+// commented reports whether a delete under ctx must leave the task alone
+// for its comments. Callers hold the lock.
+func (r *MemoryTaskRepository) commented(ctx context.Context, id string) bool {
+	return keepsCommented(ctx) && r.comments != nil && r.comments.hasComments(id)
+}
+
+// Reset removes all tasks, tags, projects, teams, history, escalations,
+// automations and embeddings and restarts numbering
+func (r *MemoryTaskRepository) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tasks = make(map[string]*model.Task)
+	r.sequences = make(map[string]int64)
+	r.tags = make(map[string]*model.Tag)
+	r.projects = make(map[string]*model.Project)
+	r.teams = make(map[string]*model.Team)
+	r.members = make(map[string]map[string]bool)
+	r.history = make(map[string][]*model.TaskHistoryEntry)
+	r.rules = make(map[string]*model.EscalationRule)
+	r.escalated = nil
+	r.automated = make(map[string]*model.AutomationRule)
+	r.runs = nil
+	r.embedded = make(map[string]*model.TaskEmbedding)
+	r.position = 0
+}
+
+// Create implements TaskStore
+func (r *MemoryTaskRepository) Create(ctx context.Context, task *model.Task) (*model.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	created, err := r.create(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	return copyTask(created), nil
+}
+
+// CreateMany stores tasks all at once, or none of them when they do not
+// all fit
+func (r *MemoryTaskRepository) CreateMany(ctx context.Context, tasks []*model.Task) ([]*model.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.maxTasks > 0 && len(r.tasks)+len(tasks) > r.maxTasks {
+		return nil, ErrStoreFull
+	}
+
+	created := make([]*model.Task, 0, len(tasks))
+	for _, task := range tasks {
+		stored, err := r.create(ctx, task)
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, copyTask(stored))
+	}
+	return created, nil
+}
+
+// create stores a new pending task and returns the stored task. Callers
+// hold the lock.
+func (r *MemoryTaskRepository) create(ctx context.Context, task *model.Task) (*model.Task, error) {
+	if r.maxTasks > 0 && len(r.tasks) >= r.maxTasks {
+		return nil, ErrStoreFull
+	}
+
+	sequence := r.ensureProject(ctx, task.ProjectKey)
+	now := time.Now().UTC()
+
+	// A task copied from another store, as by a shadow, keeps its number
+	created := *task
+	if created.Number == 0 {
+		r.sequences[sequence]++
+		created.Number = r.sequences[sequence]
+	} else {
+		r.sequences[sequence] = max(r.sequences[sequence], created.Number)
+	}
+	created.Status = model.StatusPending
+	if created.Priority == "" {
+		created.Priority = model.DefaultPriority
+	}
+	if task.DueDate != nil {
+		dueDate := task.DueDate.UTC()
+		created.DueDate = &dueDate
+	}
+	if task.Recurrence != nil {
+		recurrence := *task.Recurrence
+		created.Recurrence = &recurrence
+	}
+	if task.Assignee != nil {
+		assignee := *task.Assignee
+		created.Assignee = &assignee
+	}
+	if task.Owner != nil {
+		owner := *task.Owner
+		created.Owner = &owner
+	}
+	if task.Team != nil {
+		team := *task.Team
+		created.Team = &team
+	}
+	created.NextOccurrenceID = nil
+	created.Tags = nil
+	r.position += positionGap
+	created.Position = r.position
+	created.Version = 1
+	created.CreatedAt = now
+	created.UpdatedAt = now
+	r.tasks[created.ID] = &created
+	r.record(ctx, model.HistoryCreated, nil, &created)
+
+	return &created, nil
+}
+
+// GetByID implements TaskStore
+func (r *MemoryTaskRepository) GetByID(ctx context.Context, id string) (*model.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	task, err := r.owned(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	return copyTask(task), nil
+}
+
+// GetByRef implements TaskStore
+func (r *MemoryTaskRepository) GetByRef(ctx context.Context, ref model.Ref) (*model.Task, error) {
+	if err := checkScope(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, task := range r.tasks {
+		if task.DeletedAt == nil && task.Ref() == ref && r.visible(ctx, task) {
+			return copyTask(task), nil
+		}
+	}
+	return nil, ErrTaskNotFound
+}
+
+// GetByIDs implements TaskStore
+func (r *MemoryTaskRepository) GetByIDs(ctx context.Context, ids []string) ([]*model.Task, error) {
+	if err := checkScope(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tasks []*model.Task
+	for _, id := range ids {
+		if task, ok := r.live(id); ok && r.visible(ctx, task) && !slices.ContainsFunc(tasks, func(t *model.Task) bool { return t.ID == id }) {
+			tasks = append(tasks, copyTask(task))
+		}
+	}
+
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks, nil
+}
+
+// GetByProject implements TaskStore
+func (r *MemoryTaskRepository) GetByProject(ctx context.Context, projectKey string) ([]*model.Task, error) {
+	if err := checkScope(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tasks []*model.Task
+	for _, task := range r.tasks {
+		if task.ProjectKey == projectKey && task.DeletedAt == nil && r.visible(ctx, task) {
+			tasks = append(tasks, copyTask(task))
+		}
+	}
+
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Number < tasks[j].Number })
+	return tasks, nil
+}
+
+// GetByRefs implements TaskStore
+func (r *MemoryTaskRepository) GetByRefs(ctx context.Context, refs []model.Ref) ([]*model.Task, error) {
+	if err := checkScope(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	wanted := make(map[model.Ref]bool, len(refs))
+	for _, ref := range refs {
+		wanted[ref] = true
+	}
+
+	var tasks []*model.Task
+	for _, task := range r.tasks {
+		if task.DeletedAt == nil && wanted[task.Ref()] && r.visible(ctx, task) {
+			tasks = append(tasks, copyTask(task))
+		}
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].ProjectKey == tasks[j].ProjectKey {
+			return tasks[i].Number < tasks[j].Number
+		}
+		return tasks[i].ProjectKey < tasks[j].ProjectKey
+	})
+	return tasks, nil
+}
+
+// GetAll implements TaskStore
+func (r *MemoryTaskRepository) GetAll(ctx context.Context, opts *model.ListOptions) ([]*model.Task, error) {
+	if err := checkScope(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tasks := r.filter(ctx, opts)
+
+	sort.Slice(tasks, func(i, j int) bool {
+		less := lessBy(opts.Sort, tasks[i], tasks[j])
+		if opts.Order == "asc" {
+			return less
+		}
+		return lessBy(opts.Sort, tasks[j], tasks[i])
+	})
+
+	offset := opts.Offset()
+	if offset >= len(tasks) {
+		return nil, nil
+	}
+	end := offset + opts.PerPage
+	if end > len(tasks) {
+		end = len(tasks)
+	}
+
+	return projectTasks(tasks[offset:end], opts.Fields), nil
+}
+
+// Count implements TaskStore
+func (r *MemoryTaskRepository) Count(ctx context.Context, opts *model.ListOptions) (int, error) {
+	if err := checkScope(ctx); err != nil {
+		return 0, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.filter(ctx, opts)), nil
+}
+
+// Search implements TaskStore with a simple term match following the
+// websearch_to_tsquery syntax: every term must appear in the title or
+// description, and title hits rank higher
+func (r *MemoryTaskRepository) Search(ctx context.Context, opts *model.ListOptions) ([]*model.Task, error) {
+	if err := checkScope(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tasks, ranks := r.search(ctx, opts.Search)
+
+	sort.Slice(tasks, func(i, j int) bool {
+		a, b := tasks[i], tasks[j]
+		if ranks[a.ID] != ranks[b.ID] {
+			return ranks[a.ID] > ranks[b.ID]
+		}
+		return lessBy("created_at", b, a)
+	})
+
+	offset := opts.Offset()
+	if offset >= len(tasks) {
+		return nil, nil
+	}
+	end := offset + opts.PerPage
+	if end > len(tasks) {
+		end = len(tasks)
+	}
+
+	return projectTasks(tasks[offset:end], opts.Fields), nil
+}
+
+// CountSearch implements TaskStore
+func (r *MemoryTaskRepository) CountSearch(ctx context.Context, opts *model.ListOptions) (int, error) {
+	if err := checkScope(ctx); err != nil {
+		return 0, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tasks, _ := r.search(ctx, opts.Search)
+	return len(tasks), nil
+}
+
+// Update implements TaskStore
+func (r *MemoryTaskRepository) Update(ctx context.Context, id string, updates *model.UpdateTaskRequest, expectedVersion int64) (*model.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, err := r.owned(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	if task.Archived {
+		return nil, ErrTaskArchived
+	}
+	if expectedVersion != AnyVersion && task.Version != expectedVersion {
+		return nil, ErrVersionConflict
+	}
+	if !fromStatus(task, updates) {
+		return nil, ErrVersionConflict
+	}
+
+	before := copyTask(task)
+	applyUpdate(task, updates)
+	touch(task)
+	r.record(ctx, model.HistoryUpdated, before, task)
+
+	return copyTask(task), nil
+}
+
+// BulkUpdate implements TaskStore
+func (r *MemoryTaskRepository) BulkUpdate(ctx context.Context, ids []string, updates *model.UpdateTaskRequest) ([]*model.Task, error) {
+	if err := checkScope(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var updated []*model.Task
+	for _, id := range ids {
+		task, ok := r.live(id)
+		if !ok || !r.visible(ctx, task) || task.Archived || !fromStatus(task, updates) {
+			continue
+		}
+
+		before := copyTask(task)
+		applyUpdate(task, updates)
+		touch(task)
+		r.record(ctx, model.HistoryUpdated, before, task)
+		updated = append(updated, copyTask(task))
+	}
+
+	return updated, nil
+}
+
+// Delete implements TaskStore with a soft delete
+func (r *MemoryTaskRepository) Delete(ctx context.Context, id string, expectedVersion int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, err := r.owned(ctx, id, false)
+	if err != nil {
+		return err
+	}
+	if task.Archived {
+		return ErrTaskArchived
+	}
+	if r.commented(ctx, id) {
+		return ErrTaskCommented
+	}
+	if expectedVersion != AnyVersion && task.Version != expectedVersion {
+		return ErrVersionConflict
+	}
+
+	now := time.Now().UTC()
+	task.DeletedAt = &now
+	touch(task)
+	r.record(ctx, model.HistoryDeleted, task, task)
+	return nil
+}
+
+// BulkDelete implements TaskStore with a soft delete
+func (r *MemoryTaskRepository) BulkDelete(ctx context.Context, ids []string) ([]string, error) {
+	if err := checkScope(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	var deleted []string
+	for _, id := range ids {
+		task, ok := r.live(id)
+		if !ok || !r.visible(ctx, task) || task.Archived || r.commented(ctx, id) {
+			continue
+		}
+
+		task.DeletedAt = &now
+		touch(task)
+		r.record(ctx, model.HistoryDeleted, task, task)
+		deleted = append(deleted, id)
+	}
+
+	return deleted, nil
+}
+
+// HardDelete implements TaskStore
+func (r *MemoryTaskRepository) HardDelete(ctx context.Context, id string, expectedVersion int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, err := r.owned(ctx, id, true)
+	if err != nil {
+		return err
+	}
+	if task.Archived {
+		return ErrTaskArchived
+	}
+	if r.commented(ctx, id) {
+		return ErrTaskCommented
+	}
+	if expectedVersion != AnyVersion && task.Version != expectedVersion {
+		return ErrVersionConflict
+	}
+	delete(r.tasks, id)
+	delete(r.history, id)
+	delete(r.embedded, id)
+
+	// Like ON DELETE SET NULL on next_occurrence_id
+	for _, other := range r.tasks {
+		if other.NextOccurrenceID != nil && *other.NextOccurrenceID == id {
+			other.NextOccurrenceID = nil
+		}
+	}
+	return nil
+}
+
+// Restore implements TaskStore
+func (r *MemoryTaskRepository) Restore(ctx context.Context, id string) (*model.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, err := r.owned(ctx, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if task.DeletedAt == nil {
+		return nil, ErrTaskNotDeleted
+	}
+
+	task.DeletedAt = nil
+	touch(task)
+	r.record(ctx, model.HistoryRestored, task, task)
+	return copyTask(task), nil
+}
+
+// SetArchived implements TaskStore
+func (r *MemoryTaskRepository) SetArchived(ctx context.Context, id string, archived bool) (*model.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, err := r.owned(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	if task.Archived == archived {
+		return nil, archivedConflict(archived)
+	}
+
+	task.Archived = archived
+	touch(task)
+	action := model.HistoryArchived
+	if !archived {
+		action = model.HistoryUnarchived
+	}
+	r.record(ctx, action, task, task)
+	return copyTask(task), nil
+}
+
+// SetAssignee implements TaskStore. Users are not checked, demo mode
+// relies on the service for that.
+func (r *MemoryTaskRepository) SetAssignee(ctx context.Context, id string, assignee *string) (*model.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, err := r.owned(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	if task.Archived {
+		return nil, ErrTaskArchived
+	}
+
+	before := copyTask(task)
+	task.Assignee = nil
+	if assignee != nil {
+		value := *assignee
+		task.Assignee = &value
+	}
+	touch(task)
+	r.record(ctx, model.HistoryUpdated, before, task)
+	return copyTask(task), nil
+}
+
+// SetTeam implements TaskStore
+func (r *MemoryTaskRepository) SetTeam(ctx context.Context, id string, team *string) (*model.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, err := r.owned(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	if task.Archived {
+		return nil, ErrTaskArchived
+	}
+	if team != nil {
+		if _, ok := r.teams[*team]; !ok {
+			return nil, ErrTeamNotFound
+		}
+	}
+
+	task.Team = nil
+	if team != nil {
+		value := *team
+		task.Team = &value
+	}
+	touch(task)
+	return copyTask(task), nil
+}
+
+// Move implements TaskStore, renumbering every task when the anchor and
+// its neighbour are adjacent as the Postgres repository does
+func (r *MemoryTaskRepository) Move(ctx context.Context, id, anchorID string, after bool) (*model.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, err := r.owned(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	if task.Archived {
+		return nil, ErrTaskArchived
+	}
+	if anchor, ok := r.live(anchorID); !ok || !r.visible(ctx, anchor) {
+		return nil, ErrAnchorNotFound
+	}
+
+	position, err := r.freePosition(id, anchorID, after)
+	if errors.Is(err, errNoGap) {
+		r.renumberPositions()
+		position, err = r.freePosition(id, anchorID, after)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	task.Position = position
+	touch(task)
+	return copyTask(task), nil
+}
+
+// freePosition returns the position between the anchor and the live task
+// next to it, ignoring id itself. Callers hold the lock.
+func (r *MemoryTaskRepository) freePosition(id, anchorID string, after bool) (int64, error) {
+	var others []*model.Task
+	for _, task := range r.tasks {
+		if task.DeletedAt == nil && task.ID != id {
+			others = append(others, task)
+		}
+	}
+	sort.Slice(others, func(i, j int) bool { return lessBy("position", others[i], others[j]) })
+
+	i := slices.IndexFunc(others, func(task *model.Task) bool { return task.ID == anchorID })
+	anchor := others[i].Position
+	switch {
+	case after && i+1 < len(others):
+		return midpoint(anchor, others[i+1].Position)
+	case after:
+		r.position += positionGap
+		return r.position, nil
+	case i > 0:
+		return midpoint(anchor, others[i-1].Position)
+	default:
+		return anchor - positionGap, nil
+	}
+}
+
+// renumberPositions spaces every task positionGap apart in its current
+// order. Callers hold the lock.
+func (r *MemoryTaskRepository) renumberPositions() {
+	all := make([]*model.Task, 0, len(r.tasks))
+	for _, task := range r.tasks {
+		all = append(all, task)
+	}
+	sort.Slice(all, func(i, j int) bool { return lessBy("position", all[i], all[j]) })
+
+	for i, task := range all {
+		if position := int64(i+1) * positionGap; task.Position != position {
+			task.Position = position
+			touch(task)
+		}
+	}
+}
+
+// Duplicate implements TaskStore
+func (r *MemoryTaskRepository) Duplicate(ctx context.Context, id, newID string, opts *model.DuplicateOptions) (*model.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	source, err := r.owned(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	task := &model.Task{
+		ID:          newID,
+		ProjectKey:  source.ProjectKey,
+		Title:       source.Title,
+		Description: source.Description,
+		Priority:    source.Priority,
+		Owner:       opts.Owner,
+		Team:        source.Team,
+	}
+	if opts.DueDate && source.DueDate != nil && source.DueDate.After(time.Now()) {
+		task.DueDate = source.DueDate
+	}
+	if opts.Recurrence {
+		task.Recurrence = source.Recurrence
+	}
+
+	created, err := r.create(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Tags {
+		created.Tags = slices.Clone(source.Tags)
+	}
+
+	return copyTask(created), nil
+}
+
+// live returns a task unless it is missing or soft-deleted
+func (r *MemoryTaskRepository) live(id string) (*model.Task, bool) {
+	task, ok := r.tasks[id]
+	if !ok || task.DeletedAt != nil {
+		return nil, false
+	}
+	return task, true
+}
+
+// owned returns task id unless it is missing, soft-deleted and
+// includeDeleted is unset, or outside ctx's owner scope, which gives
+// ErrNotOwned. Callers hold the lock.
+func (r *MemoryTaskRepository) owned(ctx context.Context, id string, includeDeleted bool) (*model.Task, error) {
+	if _, err := scopeParam(ctx); err != nil {
+		return nil, err
+	}
+	task, ok := r.tasks[id]
+	if !ok || (task.DeletedAt != nil && !includeDeleted) {
+		return nil, ErrTaskNotFound
+	}
+	if !r.visible(ctx, task) {
+		return nil, ErrNotOwned
+	}
+	return task, nil
+}
+
+// filter returns copies of the tasks within ctx's owner scope matching the
+// list options' filters
+func (r *MemoryTaskRepository) filter(ctx context.Context, opts *model.ListOptions) []*model.Task {
+	search := strings.ToLower(opts.Search)
+	now := time.Now()
+
+	var tasks []*model.Task
+	for _, task := range r.tasks {
+		if task.DeletedAt != nil || (task.Archived && !opts.IncludeArchived) || !r.visible(ctx, task) {
+			continue
+		}
+		if search != "" && !strings.HasPrefix(strings.ToLower(task.Title), search) {
+			continue
+		}
+		if len(opts.Priorities) > 0 && !slices.Contains(opts.Priorities, task.Priority) {
+			continue
+		}
+		if opts.Overdue && !model.Overdue(task.DueDate, task.Status, now) {
+			continue
+		}
+		if !hasAllTags(task, opts.Tags) {
+			continue
+		}
+		if opts.Assignee != "" && (task.Assignee == nil || *task.Assignee != opts.Assignee) {
+			continue
+		}
+		if opts.Project != "" && task.ProjectKey != opts.Project {
+			continue
+		}
+		tasks = append(tasks, copyTask(task))
+	}
+	return tasks
+}
+
+// search returns copies of the tasks within ctx's owner scope matching
+// the query and their ranks
+func (r *MemoryTaskRepository) search(ctx context.Context, text string) ([]*model.Task, map[string]int) {
+	clauses := parseSearch(text)
+	ranks := make(map[string]int)
+
+	var tasks []*model.Task
+	for _, task := range r.tasks {
+		if task.DeletedAt != nil || !r.visible(ctx, task) {
+			continue
+		}
+
+		title := searchWords(task.Title)
+		description := searchWords(task.Description)
+
+		rank, matched := 0, false
+		for _, clause := range clauses {
+			if clauseRank, ok := clause.rank(title, description); ok {
+				rank, matched = max(rank, clauseRank), true
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		tasks = append(tasks, copyTask(task))
+		ranks[task.ID] = rank
+	}
+	return tasks, ranks
+}
+
+// searchTerm is a word or quoted phrase of a search query, possibly
+// negated
+type searchTerm struct {
+	words []string
+	not   bool
+}
+
+// searchClause is one alternative of a search query; every term must match
+type searchClause []searchTerm
+
+// parseSearch reads a query the way websearch_to_tsquery does: words must
+// all match, "quoted phrases" match consecutive words, -term excludes
+// tasks containing the term and or separates alternatives. Punctuation is
+// ignored, so a query of punctuation alone matches nothing.
+func parseSearch(text string) []searchClause {
+	var clauses []searchClause
+	var clause searchClause
+
+	text = strings.TrimLeftFunc(strings.ToLower(text), unicode.IsSpace)
+	for text != "" {
+		not := strings.HasPrefix(text, "-")
+		text = strings.TrimPrefix(text, "-")
+
+		var words []string
+		if phrase, ok := strings.CutPrefix(text, `"`); ok {
+			phrase, text, _ = strings.Cut(phrase, `"`)
+			words = searchWords(phrase)
+		} else {
+			end := strings.IndexFunc(text, unicode.IsSpace)
+			if end < 0 {
+				end = len(text)
+			}
+			word := text[:end]
+			text = text[end:]
+			if word == "or" && !not {
+				if len(clause) > 0 {
+					clauses = append(clauses, clause)
+					clause = nil
+				}
+				text = strings.TrimLeftFunc(text, unicode.IsSpace)
+				continue
+			}
+			words = searchWords(word)
+		}
+		if len(words) > 0 {
+			clause = append(clause, searchTerm{words: words, not: not})
+		}
+		text = strings.TrimLeftFunc(text, unicode.IsSpace)
+	}
+
+	if len(clause) > 0 {
+		clauses = append(clauses, clause)
+	}
+	return clauses
+}
+
+// searchWords splits text into lowercase words, dropping punctuation
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// rank reports whether the clause matches a task with the given title and
+// description words, and how well: a term found in the title counts twice
+// one found in the description, as title matches rank higher in Postgres
+func (c searchClause) rank(title, description []string) (int, bool) {
+	rank := 0
+	for _, term := range c {
+		inTitle, inDescription := term.in(title), term.in(description)
+		switch {
+		case term.not:
+			if inTitle || inDescription {
+				return 0, false
+			}
+		case inTitle:
+			rank += 2
+		case inDescription:
+			rank++
+		default:
+			return 0, false
+		}
+	}
+	return rank, true
+}
+
+// in reports whether the term's words appear consecutively in words. A
+// query word matches the words it prefixes, standing in for stemming.
+func (t searchTerm) in(words []string) bool {
+	for i := 0; i+len(t.words) <= len(words); i++ {
+		matched := true
+		for j, word := range t.words {
+			if !strings.HasPrefix(words[i+j], word) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// lessBy orders two tasks by a sort column, breaking ties by id like the SQL query
+func lessBy(column string, a, b *model.Task) bool {
+	var cmp int
+	switch column {
+	case "updated_at":
+		cmp = a.UpdatedAt.Compare(b.UpdatedAt)
+	case "title":
+		cmp = strings.Compare(a.Title, b.Title)
+	case "status":
+		cmp = strings.Compare(string(a.Status), string(b.Status))
+	case "priority":
+		cmp = a.Priority.Rank() - b.Priority.Rank()
+	case "position":
+		switch {
+		case a.Position < b.Position:
+			cmp = -1
+		case a.Position > b.Position:
+			cmp = 1
+		}
+	default:
+		cmp = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if cmp == 0 {
+		return a.ID < b.ID
+	}
+	return cmp < 0
+}
+
+// fromStatus reports whether task is in one of the statuses the update
+// is limited to, if any
+func fromStatus(task *model.Task, updates *model.UpdateTaskRequest) bool {
+	return len(updates.FromStatuses) == 0 || slices.Contains(updates.FromStatuses, task.Status)
+}
+
+// applyUpdate copies the non-nil fields of updates onto task
+func applyUpdate(task *model.Task, updates *model.UpdateTaskRequest) {
+	if updates.Title != nil {
+		task.Title = *updates.Title
+	}
+	if updates.Description != nil {
+		task.Description = *updates.Description
+	}
+	if updates.Status != nil {
+		task.Status = *updates.Status
+	}
+	if updates.Priority != nil {
+		task.Priority = *updates.Priority
+	}
+	if updates.DueDate != nil {
+		dueDate := updates.DueDate.UTC()
+		task.DueDate = &dueDate
+	}
+	if updates.ClearDueDate {
+		task.DueDate = nil
+	}
+	if updates.Recurrence != nil {
+		task.Recurrence = nil
+		if *updates.Recurrence != "" {
+			recurrence := *updates.Recurrence
+			task.Recurrence = &recurrence
+		}
+	}
+}
+
+// touch bumps updated_at and the version, with the same monotonic
+// guarantee as the Postgres trigger
+func touch(task *model.Task) {
+	now := time.Now().UTC()
+	if !now.After(task.UpdatedAt) {
+		now = task.UpdatedAt.Add(time.Microsecond)
+	}
+	task.UpdatedAt = now
+	task.Version++
+}
+
+func copyTask(task *model.Task) *model.Task {
+	copied := *task
+	if task.DeletedAt != nil {
+		deletedAt := *task.DeletedAt
+		copied.DeletedAt = &deletedAt
+	}
+	if task.DueDate != nil {
+		dueDate := *task.DueDate
+		copied.DueDate = &dueDate
+	}
+	if task.Recurrence != nil {
+		recurrence := *task.Recurrence
+		copied.Recurrence = &recurrence
+	}
+	if task.NextOccurrenceID != nil {
+		next := *task.NextOccurrenceID
+		copied.NextOccurrenceID = &next
+	}
+	if task.Assignee != nil {
+		assignee := *task.Assignee
+		copied.Assignee = &assignee
+	}
+	if task.Owner != nil {
+		owner := *task.Owner
+		copied.Owner = &owner
+	}
+	if task.Team != nil {
+		team := *task.Team
+		copied.Team = &team
+	}
+	copied.Tags = slices.Clone(task.Tags)
+	return &copied
+}
@@ -0,0 +1,348 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryTaskRepository_SoftDelete(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryTaskRepository(0)
+	opts := &model.ListOptions{Params: listing.Params{Page: 1, PerPage: 10}}
+
+	task, err := repo.Create(ctx, &model.Task{ID: "a", ProjectKey: "TASK", Title: "Ship it"})
+	require.NoError(t, err)
+
+	// A live task cannot be restored
+	_, err = repo.Restore(ctx, task.ID)
+	assert.ErrorIs(t, err, ErrTaskNotDeleted)
+
+	// Soft-deleted tasks disappear from every read
+	require.NoError(t, repo.Delete(ctx, task.ID, task.Version))
+
+	_, err = repo.GetByID(ctx, task.ID)
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+	_, err = repo.GetByRef(ctx, task.Ref())
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+	total, err := repo.Count(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+	_, err = repo.Update(ctx, task.ID, &model.UpdateTaskRequest{}, AnyVersion)
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+	assert.ErrorIs(t, repo.Delete(ctx, task.ID, AnyVersion), ErrTaskNotFound)
+
+	// Restoring brings it back at a new version
+	restored, err := repo.Restore(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Nil(t, restored.DeletedAt)
+	assert.Equal(t, task.Version+2, restored.Version)
+
+	total, err = repo.Count(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+
+	// Hard deletes check the version and also remove soft-deleted tasks
+	assert.ErrorIs(t, repo.HardDelete(ctx, task.ID, task.Version), ErrVersionConflict)
+	require.NoError(t, repo.Delete(ctx, task.ID, AnyVersion))
+	require.NoError(t, repo.HardDelete(ctx, task.ID, AnyVersion))
+
+	_, err = repo.Restore(ctx, task.ID)
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+}
+
+func TestMemoryTaskRepository_Priority(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryTaskRepository(0)
+
+	for id, priority := range map[string]model.Priority{"a": model.PriorityLow, "b": model.PriorityUrgent, "c": model.PriorityHigh, "d": ""} {
+		_, err := repo.Create(ctx, &model.Task{ID: id, ProjectKey: "TASK", Title: id, Priority: priority})
+		require.NoError(t, err)
+	}
+
+	ids := func(tasks []*model.Task) []string {
+		var ids []string
+		for _, task := range tasks {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+
+	// Sorting follows urgency rather than the alphabet, and the default is medium
+	tasks, err := repo.GetAll(ctx, &model.ListOptions{Params: listing.Params{Page: 1, PerPage: 10, Sort: "priority", Order: "desc"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "c", "d", "a"}, ids(tasks))
+
+	opts := &model.ListOptions{Params: listing.Params{Page: 1, PerPage: 10, Sort: "priority", Order: "asc"}, Priorities: []model.Priority{model.PriorityHigh, model.PriorityUrgent}}
+	tasks, err = repo.GetAll(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "b"}, ids(tasks))
+	total, err := repo.Count(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+}
+
+func TestMemoryTaskRepository_Tags(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryTaskRepository(0)
+
+	backend, err := repo.CreateTag(ctx, &model.Tag{ID: "t1", Name: "backend"})
+	require.NoError(t, err)
+	_, err = repo.CreateTag(ctx, &model.Tag{ID: "t2", Name: "backend"})
+	assert.ErrorIs(t, err, ErrTagExists)
+	_, err = repo.CreateTag(ctx, &model.Tag{ID: "t3", Name: "bug"})
+	require.NoError(t, err)
+
+	task, err := repo.Create(ctx, &model.Task{ID: "a", ProjectKey: "TASK", Title: "Fix login"})
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, &model.Task{ID: "b", ProjectKey: "TASK", Title: "Write docs"})
+	require.NoError(t, err)
+
+	// Unknown tags are rejected by name, before anything is attached
+	_, err = repo.AttachTags(ctx, task.ID, []string{"bug", "frontend"})
+	assert.ErrorIs(t, err, ErrTagNotFound)
+	assert.ErrorContains(t, err, "frontend")
+
+	tagged, err := repo.AttachTags(ctx, task.ID, []string{"bug", "backend"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"backend", "bug"}, tagged.Tags)
+	assert.Equal(t, task.Version+1, tagged.Version)
+
+	// Re-attaching is a no-op and keeps the version
+	again, err := repo.AttachTags(ctx, task.ID, []string{"bug"})
+	require.NoError(t, err)
+	assert.Equal(t, tagged.Version, again.Version)
+
+	// The filter requires every listed tag
+	opts := &model.ListOptions{Params: listing.Params{Page: 1, PerPage: 10}, Tags: []string{"backend", "bug"}}
+	tasks, err := repo.GetAll(ctx, opts)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, task.ID, tasks[0].ID)
+
+	// Renames and deletes follow through to tagged tasks
+	name := "api"
+	_, err = repo.UpdateTag(ctx, backend.ID, &model.UpdateTagRequest{Name: &name})
+	require.NoError(t, err)
+	got, err := repo.GetByID(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api", "bug"}, got.Tags)
+
+	require.NoError(t, repo.DeleteTag(ctx, backend.ID))
+	got, err = repo.DetachTag(ctx, task.ID, "bug")
+	require.NoError(t, err)
+	assert.Empty(t, got.Tags)
+}
+
+func TestMemoryTaskRepository_Move(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryTaskRepository(0)
+	opts := &model.ListOptions{Params: listing.Params{Page: 1, PerPage: 10, Sort: "position", Order: "asc"}}
+
+	for _, id := range []string{"a", "b", "c"} {
+		_, err := repo.Create(ctx, &model.Task{ID: id, ProjectKey: "TASK", Title: id})
+		require.NoError(t, err)
+	}
+	order := func() []string {
+		tasks, err := repo.GetAll(ctx, opts)
+		require.NoError(t, err)
+		ids := make([]string, len(tasks))
+		for i, task := range tasks {
+			ids[i] = task.ID
+		}
+		return ids
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, order())
+
+	moved, err := repo.Move(ctx, "c", "a", false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), moved.Version)
+	assert.Equal(t, []string{"c", "a", "b"}, order())
+
+	_, err = repo.Move(ctx, "c", "b", true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, order())
+
+	// Moving back and forth between the same two tasks exhausts the gap,
+	// which renumbers the order rather than failing
+	for range 20 {
+		_, err = repo.Move(ctx, "c", "b", false)
+		require.NoError(t, err)
+		_, err = repo.Move(ctx, "b", "c", false)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, order())
+
+	// New tasks still go at the end
+	_, err = repo.Create(ctx, &model.Task{ID: "d", ProjectKey: "TASK", Title: "d"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, order())
+
+	_, err = repo.Move(ctx, "a", "missing", true)
+	assert.ErrorIs(t, err, ErrAnchorNotFound)
+	_, err = repo.Move(ctx, "missing", "a", true)
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+}
+
+func TestMemoryTaskRepository_Projects(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryTaskRepository(0)
+
+	// Creating a task creates its project
+	task, err := repo.Create(ctx, &model.Task{ID: "a", ProjectKey: "OPS", Title: "Rotate certs"})
+	require.NoError(t, err)
+	project, err := repo.GetProject(ctx, "OPS")
+	require.NoError(t, err)
+	assert.Equal(t, "OPS", project.Name)
+
+	_, err = repo.CreateProject(ctx, &model.Project{Key: "OPS", Name: "Operations"})
+	assert.ErrorIs(t, err, ErrProjectExists)
+
+	// Soft-deleted tasks still hold on to their project
+	require.NoError(t, repo.Delete(ctx, task.ID, AnyVersion))
+	assert.ErrorIs(t, repo.DeleteProject(ctx, "OPS"), ErrProjectHasTasks)
+	require.NoError(t, repo.HardDelete(ctx, task.ID, AnyVersion))
+	require.NoError(t, repo.DeleteProject(ctx, "OPS"))
+	assert.ErrorIs(t, repo.DeleteProject(ctx, "OPS"), ErrProjectNotFound)
+
+	// Numbering survives the project, so references are not reused
+	again, err := repo.Create(ctx, &model.Task{ID: "b", ProjectKey: "OPS", Title: "Renew certs"})
+	require.NoError(t, err)
+	assert.Equal(t, "OPS-2", again.Ref().String())
+}
+
+func TestMemoryTaskRepository_Board(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryTaskRepository(0)
+
+	for _, task := range []*model.Task{
+		{ID: "a", ProjectKey: "OPS", Title: "Low", Status: model.StatusPending, Priority: model.PriorityLow},
+		{ID: "b", ProjectKey: "OPS", Title: "High", Status: model.StatusPending, Priority: model.PriorityHigh},
+		{ID: "c", ProjectKey: "OPS", Title: "Medium", Status: model.StatusPending, Priority: model.PriorityMedium},
+		{ID: "d", ProjectKey: "OPS", Title: "Done", Priority: model.PriorityMedium},
+		{ID: "e", ProjectKey: "WEB", Title: "Elsewhere", Status: model.StatusPending, Priority: model.PriorityHigh},
+	} {
+		_, err := repo.Create(ctx, task)
+		require.NoError(t, err)
+	}
+	completed := model.StatusCompleted
+	_, err := repo.Update(ctx, "d", &model.UpdateTaskRequest{Status: &completed}, AnyVersion)
+	require.NoError(t, err)
+
+	columns, err := repo.Board(ctx, &model.BoardOptions{
+		Project: "OPS",
+		Limit:   2,
+		Limits:  map[model.Status]int{model.StatusCompleted: 0},
+		Sort:    "priority",
+		Order:   "desc",
+	})
+	require.NoError(t, err)
+	require.Len(t, columns, len(model.Statuses()))
+
+	// Most urgent first, cut at the column limit, totals still counted
+	pending := columns[0]
+	assert.Equal(t, model.StatusPending, pending.Status)
+	assert.Equal(t, 3, pending.Total)
+	require.Len(t, pending.Tasks, 2)
+	assert.Equal(t, "b", pending.Tasks[0].ID)
+	assert.Equal(t, "c", pending.Tasks[1].ID)
+
+	assert.Equal(t, 0, columns[1].Total)
+	assert.Empty(t, columns[1].Tasks)
+	assert.Equal(t, 1, columns[2].Total)
+	assert.Empty(t, columns[2].Tasks)
+}
+
+func TestMemoryTaskRepository_Fields(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryTaskRepository(0)
+
+	_, err := repo.Create(ctx, &model.Task{ID: "a", ProjectKey: "OPS", Title: "Rotate certs", Description: "Yearly", Priority: model.PriorityHigh})
+	require.NoError(t, err)
+
+	tasks, err := repo.GetAll(ctx, &model.ListOptions{Params: listing.Params{Page: 1, PerPage: 10}, Fields: []string{"id", "ref", "title"}})
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "OPS-1", tasks[0].Ref().String())
+	assert.Equal(t, "Rotate certs", tasks[0].Title)
+	assert.Empty(t, tasks[0].Description, "unselected columns are not read")
+	assert.Empty(t, tasks[0].Priority)
+
+	// The column table is taskColumns taken apart, and covers every field
+	exprs := make([]string, len(taskColumnList))
+	for i, column := range taskColumnList {
+		exprs[i] = column.expr
+	}
+	assert.Equal(t, strings.Join(strings.Fields(taskColumns), " "), strings.Join(strings.Fields(strings.Join(exprs, ", ")), " "))
+	for _, field := range model.TaskFields {
+		assert.NotEmpty(t, taskFieldColumns[field], field)
+	}
+}
+
+func TestMemoryTaskRepository_Owner(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryTaskRepository(0)
+	opts := &model.ListOptions{Params: listing.Params{Page: 1, PerPage: 10}}
+	alice, bob := "alice", "bob"
+
+	task, err := repo.Create(ctx, &model.Task{ID: "a", ProjectKey: "TASK", Title: "Ship it", Owner: &alice})
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, &model.Task{ID: "b", ProjectKey: "TASK", Title: "Test it", Owner: &bob})
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, &model.Task{ID: "c", ProjectKey: "TASK", Title: "Anonymous"})
+	require.NoError(t, err)
+
+	// A scoped context only reaches its owner's tasks
+	scoped := WithScope(ctx, OwnedBy(bob))
+	_, err = repo.GetByID(scoped, task.ID)
+	assert.ErrorIs(t, err, ErrNotOwned)
+	_, err = repo.Update(scoped, task.ID, &model.UpdateTaskRequest{}, AnyVersion)
+	assert.ErrorIs(t, err, ErrNotOwned)
+	assert.ErrorIs(t, repo.Delete(scoped, task.ID, AnyVersion), ErrNotOwned)
+	_, err = repo.GetByID(scoped, "missing")
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+	tasks, err := repo.GetAll(scoped, opts)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "b", tasks[0].ID)
+	total, err := repo.Count(scoped, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+
+	// An empty scope fails closed
+	_, err = repo.GetAll(WithScope(ctx, OwnedBy("")), opts)
+	assert.ErrorIs(t, err, ErrUnscoped)
+
+	// Without a scope every task is reachable
+	total, err = repo.Count(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+}
+
+func TestMemoryTaskRepository_KeepCommented(t *testing.T) {
+	ctx := context.Background()
+	comments := NewMemoryCommentRepository()
+	repo := NewMemoryTaskRepository(0).FollowComments(comments)
+
+	for _, id := range []string{"a", "b"} {
+		_, err := repo.Create(ctx, &model.Task{ID: id, ProjectKey: "TASK", Title: "Task " + id})
+		require.NoError(t, err)
+	}
+	_, err := comments.Create(ctx, &model.Comment{ID: "c", TaskID: "a", Body: "Not yet"})
+	require.NoError(t, err)
+
+	keep := KeepCommented(ctx)
+	assert.ErrorIs(t, repo.Delete(keep, "a", AnyVersion), ErrTaskCommented)
+	assert.ErrorIs(t, repo.HardDelete(keep, "a", AnyVersion), ErrTaskCommented)
+	deleted, err := repo.BulkDelete(keep, []string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, deleted)
+
+	// Without the marker comments do not hold a delete back
+	require.NoError(t, repo.Delete(ctx, "a", AnyVersion))
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// TeamStore is the storage contract for teams and their members. Both task
+// stores implement it, since the tasks a user can reach depend on the
+// teams they belong to.
+type TeamStore interface {
+	// CreateTeam stores a team with its creator as the first member
+	CreateTeam(ctx context.Context, team *model.Team) (*model.Team, error)
+	// GetTeam returns a team with its members
+	GetTeam(ctx context.Context, id string) (*model.Team, error)
+	// ListTeams returns the teams user belongs to, or every team when user
+	// is empty, ordered by name, without their members
+	ListTeams(ctx context.Context, user string) ([]*model.Team, error)
+	// DeleteTeam deletes a team; its tasks go back to their owners alone
+	DeleteTeam(ctx context.Context, id string) error
+	// AddMember returns ErrUserNotFound when the store knows the user is
+	// missing. Adding a member twice is not an error.
+	AddMember(ctx context.Context, teamID, user string) error
+	// RemoveMember returns ErrNotMember when user is not a member
+	RemoveMember(ctx context.Context, teamID, user string) error
+	IsMember(ctx context.Context, teamID, user string) (bool, error)
+}
+
+var (
+	_ TeamStore = (*TaskRepository)(nil)
+	_ TeamStore = (*MemoryTaskRepository)(nil)
+)
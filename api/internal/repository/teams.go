@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+var (
+	ErrTeamNotFound = errors.New("team not found")
+	ErrTeamExists   = errors.New("team already exists")
+	ErrNotMember    = errors.New("user is not a member of the team")
+)
+
+// teamColumns is the column list shared by every team query, in scanTeam
+// order
+const teamColumns = `id, name, created_by, created_at`
+
+// scanTeam scans a row selected with teamColumns into a Team
+func scanTeam(row scanner) (*model.Team, error) {
+	var team model.Team
+	if err := row.Scan(&team.ID, &team.Name, &team.CreatedBy, &team.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// CreateTeam implements TeamStore in a single statement
+func (r *TaskRepository) CreateTeam(ctx context.Context, team *model.Team) (*model.Team, error) {
+	query := `
+		WITH team AS (
+			INSERT INTO teams (id, name, created_by) VALUES ($1, $2, $3)
+			RETURNING ` + teamColumns + `
+		), member AS (
+			INSERT INTO team_members (team_id, user_id) SELECT id, created_by FROM team
+		)
+		SELECT ` + teamColumns + ` FROM team`
+
+	created, err := scanTeam(r.db.QueryRowContext(ctx, query, team.ID, team.Name, team.CreatedBy))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrTeamExists
+		}
+		if isForeignKeyViolation(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to create team: %w", err)
+	}
+
+	created.Members = []string{team.CreatedBy}
+	return created, nil
+}
+
+// GetTeam implements TeamStore
+func (r *TaskRepository) GetTeam(ctx context.Context, id string) (*model.Team, error) {
+	query := `
+		SELECT ` + teamColumns + `,
+			ARRAY(SELECT user_id FROM team_members WHERE team_id = teams.id ORDER BY user_id)
+		FROM teams WHERE id = $1`
+
+	var team model.Team
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&team.ID, &team.Name, &team.CreatedBy, &team.CreatedAt, pq.Array(&team.Members))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTeamNotFound
+		}
+		return nil, fmt.Errorf("failed to get team: %w", err)
+	}
+
+	return &team, nil
+}
+
+// ListTeams implements TeamStore
+func (r *TaskRepository) ListTeams(ctx context.Context, user string) ([]*model.Team, error) {
+	query := `
+		SELECT ` + teamColumns + ` FROM teams
+		WHERE $1 = '' OR id IN (SELECT team_id FROM team_members WHERE user_id = $1)
+		ORDER BY name`
+
+	rows, err := r.db.QueryContext(ctx, query, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list teams: %w", err)
+	}
+	defer rows.Close()
+
+	var teams []*model.Team
+	for rows.Next() {
+		team, err := scanTeam(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan team: %w", err)
+		}
+		teams = append(teams, team)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating teams: %w", err)
+	}
+
+	return teams, nil
+}
+
+// DeleteTeam implements TeamStore, relying on the foreign keys to drop
+// the members and unassign the team's tasks
+func (r *TaskRepository) DeleteTeam(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM teams WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete team: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrTeamNotFound
+	}
+
+	return nil
+}
+
+// AddMember implements TeamStore
+func (r *TaskRepository) AddMember(ctx context.Context, teamID, user string) error {
+	query := `INSERT INTO team_members (team_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+
+	if _, err := r.db.ExecContext(ctx, query, teamID, user); err != nil {
+		if isForeignKeyViolation(err) {
+			// Either the team or the user is missing
+			if _, err := r.GetTeam(ctx, teamID); err != nil {
+				return err
+			}
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to add team member: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveMember implements TeamStore
+func (r *TaskRepository) RemoveMember(ctx context.Context, teamID, user string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM team_members WHERE team_id = $1 AND user_id = $2`, teamID, user)
+	if err != nil {
+		return fmt.Errorf("failed to remove team member: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		if _, err := r.GetTeam(ctx, teamID); err != nil {
+			return err
+		}
+		return ErrNotMember
+	}
+
+	return nil
+}
+
+// IsMember implements TeamStore
+func (r *TaskRepository) IsMember(ctx context.Context, teamID, user string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM team_members WHERE team_id = $1 AND user_id = $2)`
+
+	var member bool
+	if err := r.db.QueryRowContext(ctx, query, teamID, user).Scan(&member); err != nil {
+		return false, fmt.Errorf("failed to check team member: %w", err)
+	}
+
+	return member, nil
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// CreateTeam implements TeamStore
+func (r *MemoryTaskRepository) CreateTeam(ctx context.Context, team *model.Team) (*model.Team, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.teams {
+		if existing.Name == team.Name {
+			return nil, ErrTeamExists
+		}
+	}
+
+	created := *team
+	created.CreatedAt = time.Now().UTC()
+	created.Members = nil
+	r.teams[created.ID] = &created
+	r.members[created.ID] = map[string]bool{created.CreatedBy: true}
+
+	return r.copyTeam(&created, true), nil
+}
+
+// GetTeam implements TeamStore
+func (r *MemoryTaskRepository) GetTeam(ctx context.Context, id string) (*model.Team, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	team, ok := r.teams[id]
+	if !ok {
+		return nil, ErrTeamNotFound
+	}
+	return r.copyTeam(team, true), nil
+}
+
+// ListTeams implements TeamStore
+func (r *MemoryTaskRepository) ListTeams(ctx context.Context, user string) ([]*model.Team, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var teams []*model.Team
+	for id, team := range r.teams {
+		if user == "" || r.members[id][user] {
+			teams = append(teams, r.copyTeam(team, false))
+		}
+	}
+	sort.Slice(teams, func(i, j int) bool { return teams[i].Name < teams[j].Name })
+	return teams, nil
+}
+
+// DeleteTeam implements TeamStore, unassigning the team's tasks as the
+// foreign key does in Postgres
+func (r *MemoryTaskRepository) DeleteTeam(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.teams[id]; !ok {
+		return ErrTeamNotFound
+	}
+	for _, task := range r.tasks {
+		if task.Team != nil && *task.Team == id {
+			task.Team = nil
+		}
+	}
+	delete(r.teams, id)
+	delete(r.members, id)
+	return nil
+}
+
+// AddMember implements TeamStore. The memory store does not know users,
+// so it never returns ErrUserNotFound.
+func (r *MemoryTaskRepository) AddMember(ctx context.Context, teamID, user string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.teams[teamID]; !ok {
+		return ErrTeamNotFound
+	}
+	r.members[teamID][user] = true
+	return nil
+}
+
+// RemoveMember implements TeamStore
+func (r *MemoryTaskRepository) RemoveMember(ctx context.Context, teamID, user string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.teams[teamID]; !ok {
+		return ErrTeamNotFound
+	}
+	if !r.members[teamID][user] {
+		return ErrNotMember
+	}
+	delete(r.members[teamID], user)
+	return nil
+}
+
+// IsMember implements TeamStore
+func (r *MemoryTaskRepository) IsMember(ctx context.Context, teamID, user string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.members[teamID][user], nil
+}
+
+// copyTeam returns a copy of team, with its sorted members when
+// withMembers is set. Callers hold the lock.
+func (r *MemoryTaskRepository) copyTeam(team *model.Team, withMembers bool) *model.Team {
+	copied := *team
+	copied.Members = nil
+	if withMembers {
+		for user := range r.members[team.ID] {
+			copied.Members = append(copied.Members, user)
+		}
+		slices.Sort(copied.Members)
+	}
+	return &copied
+}
+
+// visible reports whether task is reachable under ctx's scope: owned by
+// the scope's user or assigned to a team they belong to, the counterpart
+// of taskFilter. Callers hold the lock.
+func (r *MemoryTaskRepository) visible(ctx context.Context, task *model.Task) bool {
+	s, _ := ScopeFrom(ctx)
+	if s.Anonymous() {
+		return task.Owner == nil && task.Team == nil
+	}
+	if inScope(ctx, task.Owner) {
+		return true
+	}
+	return task.Team != nil && s.Owner() != "" && r.members[*task.Team][s.Owner()]
+}
+
+// reaches reports whether task id, deleted or not, is visible under ctx's
+// scope, for stores that filter rows hanging off tasks
+func (r *MemoryTaskRepository) reaches(ctx context.Context, id string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	task, ok := r.tasks[id]
+	return ok && r.visible(ctx, task)
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// TokenStore is the storage contract for API tokens, implemented by the
+// Postgres TokenRepository and the in-memory MemoryTokenRepository
+type TokenStore interface {
+	Create(ctx context.Context, token *model.APIToken) (*model.APIToken, error)
+	// GetByHash returns the token with the given secret hash, expired or not
+	GetByHash(ctx context.Context, hash string) (*model.APIToken, error)
+	// Get, ListByUser and Delete only reach the owner's tokens; another
+	// user's token is ErrNotOwned
+	Get(ctx context.Context, owner Scope, id string) (*model.APIToken, error)
+	ListByUser(ctx context.Context, owner Scope) ([]*model.APIToken, error)
+	Delete(ctx context.Context, owner Scope, id string) error
+	// DeleteUser deletes every token of the owner in the request's tenant
+	// and returns how many there were
+	DeleteUser(ctx context.Context, owner Scope) (int, error)
+	Touch(ctx context.Context, id string, at time.Time) error
+}
+
+var (
+	_ TokenStore = (*TokenRepository)(nil)
+	_ TokenStore = (*MemoryTokenRepository)(nil)
+)
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/moabdelazem/mutlitier_app/internal/database"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+var (
+	ErrTokenNotFound = errors.New("token not found")
+)
+
+// tokenColumns is the column list shared by every token query, in scanToken order
+const tokenColumns = `id, user_id, name, prefix, token_hash, scopes, expires_at, last_used_at, created_at, tenant_id`
+
+// scanToken scans a row selected with tokenColumns into an APIToken
+func scanToken(row scanner) (*model.APIToken, error) {
+	var token model.APIToken
+	if err := row.Scan(&token.ID, &token.User, &token.Name, &token.Prefix, &token.Hash,
+		pq.Array(&token.Scopes), &token.ExpiresAt, &token.LastUsedAt, &token.CreatedAt, &token.Tenant); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// TokenRepository handles database operations for API tokens
+type TokenRepository struct {
+	db *database.DB
+}
+
+// NewTokenRepository creates a new TokenRepository
+func NewTokenRepository(db *database.DB) *TokenRepository {
+	return &TokenRepository{db: db}
+}
+
+// Create implements TokenStore. The tenant_id column defaults to the
+// tenant of ctx's connection, which token.Tenant already names.
+func (r *TokenRepository) Create(ctx context.Context, token *model.APIToken) (*model.APIToken, error) {
+	query := `
+		INSERT INTO api_tokens (id, user_id, name, prefix, token_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + tokenColumns
+
+	created, err := scanToken(r.db.QueryRowContext(ctx, query, token.ID, token.User, token.Name,
+		token.Prefix, token.Hash, pq.Array(token.Scopes), token.ExpiresAt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token: %w", err)
+	}
+
+	return created, nil
+}
+
+// GetByHash implements TokenStore
+func (r *TokenRepository) GetByHash(ctx context.Context, hash string) (*model.APIToken, error) {
+	query := `SELECT ` + tokenColumns + ` FROM api_tokens WHERE token_hash = $1`
+
+	token, err := scanToken(r.db.QueryRowContext(ctx, query, hash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTokenNotFound
+		}
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+
+	return token, nil
+}
+
+// Get implements TokenStore
+func (r *TokenRepository) Get(ctx context.Context, owner Scope, id string) (*model.APIToken, error) {
+	scope, err := owner.Where(2)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT ` + tokenColumns + ` FROM api_tokens WHERE id = $1 AND ` + scope
+
+	token, err := scanToken(r.db.QueryRowContext(ctx, query, id, owner.Owner()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, r.missing(ctx, id)
+		}
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+
+	return token, nil
+}
+
+// ListByUser implements TokenStore, newest first
+func (r *TokenRepository) ListByUser(ctx context.Context, owner Scope) ([]*model.APIToken, error) {
+	scope, err := owner.Where(1)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT ` + tokenColumns + ` FROM api_tokens WHERE ` + scope + ` ORDER BY created_at DESC, id`
+
+	rows, err := r.db.QueryContext(ctx, query, owner.Owner())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []*model.APIToken
+	for rows.Next() {
+		token, err := scanToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// Delete implements TokenStore
+func (r *TokenRepository) Delete(ctx context.Context, owner Scope, id string) error {
+	scope, err := owner.Where(2)
+	if err != nil {
+		return err
+	}
+	query := `DELETE FROM api_tokens WHERE id = $1 AND ` + scope
+
+	result, err := r.db.ExecContext(ctx, query, id, owner.Owner())
+	if err != nil {
+		return fmt.Errorf("failed to delete token: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return r.missing(ctx, id)
+	}
+
+	return nil
+}
+
+// DeleteUser implements TokenStore
+func (r *TokenRepository) DeleteUser(ctx context.Context, owner Scope) (int, error) {
+	scope, err := owner.Where(1)
+	if err != nil {
+		return 0, err
+	}
+	query := `DELETE FROM api_tokens WHERE ` + scope
+
+	result, err := r.db.ExecContext(ctx, query, owner.Owner())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete tokens: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return int(rows), nil
+}
+
+// missing tells why a scoped lookup of token id matched nothing:
+// ErrNotOwned when it exists outside the scope, ErrTokenNotFound otherwise
+func (r *TokenRepository) missing(ctx context.Context, id string) error {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM api_tokens WHERE id = $1)`
+	if err := r.db.QueryRowContext(ctx, query, id).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check token: %w", err)
+	}
+	if exists {
+		return ErrNotOwned
+	}
+	return ErrTokenNotFound
+}
+
+// Touch implements TokenStore
+func (r *TokenRepository) Touch(ctx context.Context, id string, at time.Time) error {
+	query := `UPDATE api_tokens SET last_used_at = $2 WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, id, at); err != nil {
+		return fmt.Errorf("failed to touch token: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+)
+
+// MemoryTokenRepository is an in-memory TokenStore used by demo mode
+type MemoryTokenRepository struct {
+	mu     sync.RWMutex
+	tokens map[string]*model.APIToken
+}
+
+// NewMemoryTokenRepository creates a new MemoryTokenRepository
+func NewMemoryTokenRepository() *MemoryTokenRepository {
+	return &MemoryTokenRepository{tokens: make(map[string]*model.APIToken)}
+}
+
+// Create implements TokenStore
+func (r *MemoryTokenRepository) Create(ctx context.Context, token *model.APIToken) (*model.APIToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	created := *token
+	created.Scopes = slices.Clone(token.Scopes)
+	created.CreatedAt = time.Now().UTC()
+	r.tokens[created.ID] = &created
+
+	return copyToken(&created), nil
+}
+
+// GetByHash implements TokenStore
+func (r *MemoryTokenRepository) GetByHash(ctx context.Context, hash string) (*model.APIToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, token := range r.tokens {
+		if token.Hash == hash {
+			return copyToken(token), nil
+		}
+	}
+	return nil, ErrTokenNotFound
+}
+
+// Get implements TokenStore
+func (r *MemoryTokenRepository) Get(ctx context.Context, owner Scope, id string) (*model.APIToken, error) {
+	if err := owner.Check(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	token, ok := r.tokens[id]
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+	if !owner.Owns(token.User) {
+		return nil, ErrNotOwned
+	}
+	return copyToken(token), nil
+}
+
+// ListByUser implements TokenStore, newest first
+func (r *MemoryTokenRepository) ListByUser(ctx context.Context, owner Scope) ([]*model.APIToken, error) {
+	if err := owner.Check(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tokens []*model.APIToken
+	for _, token := range r.tokens {
+		if owner.Owns(token.User) {
+			tokens = append(tokens, copyToken(token))
+		}
+	}
+
+	sort.Slice(tokens, func(i, j int) bool {
+		if cmp := tokens[i].CreatedAt.Compare(tokens[j].CreatedAt); cmp != 0 {
+			return cmp > 0
+		}
+		return tokens[i].ID < tokens[j].ID
+	})
+	return tokens, nil
+}
+
+// Delete implements TokenStore
+func (r *MemoryTokenRepository) Delete(ctx context.Context, owner Scope, id string) error {
+	if err := owner.Check(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok {
+		return ErrTokenNotFound
+	}
+	if !owner.Owns(token.User) {
+		return ErrNotOwned
+	}
+	delete(r.tokens, id)
+	return nil
+}
+
+// DeleteUser implements TokenStore
+func (r *MemoryTokenRepository) DeleteUser(ctx context.Context, owner Scope) (int, error) {
+	if err := owner.Check(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := tenant.From(ctx)
+	deleted := 0
+	for key, token := range r.tokens {
+		if owner.Owns(token.User) && (id == "" || token.Tenant == id) {
+			delete(r.tokens, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Touch implements TokenStore
+func (r *MemoryTokenRepository) Touch(ctx context.Context, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if token, ok := r.tokens[id]; ok {
+		token.LastUsedAt = &at
+	}
+	return nil
+}
+
+func copyToken(token *model.APIToken) *model.APIToken {
+	copied := *token
+	copied.Scopes = slices.Clone(token.Scopes)
+	if token.LastUsedAt != nil {
+		at := *token.LastUsedAt
+		copied.LastUsedAt = &at
+	}
+	return &copied
+}
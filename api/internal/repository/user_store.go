@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// UserStore is the storage contract for the directory of users who have
+// authenticated, implemented by the Postgres UserRepository and the
+// in-memory MemoryUserRepository
+type UserStore interface {
+	// Touch records that user was seen at, adding them when new
+	Touch(ctx context.Context, user string, at time.Time) error
+	Exists(ctx context.Context, user string) (bool, error)
+	// Register adds a new user signing in with a password, or returns
+	// ErrUserExists when the name is taken, with or without a password
+	Register(ctx context.Context, user, passwordHash string) (time.Time, error)
+	// PasswordHash returns the user's password hash, or ErrUserNotFound
+	// when the user does not sign in with a password
+	PasswordHash(ctx context.Context, user string) (string, error)
+	// Identity returns the user an OIDC provider's subject is linked to,
+	// or ErrUserNotFound when it is not linked yet
+	Identity(ctx context.Context, issuer, subject string) (string, error)
+	// Link adds a new user signing in through an OIDC provider's subject,
+	// or returns ErrUserExists when the name is taken
+	Link(ctx context.Context, issuer, subject, user string) error
+}
+
+var (
+	_ UserStore = (*UserRepository)(nil)
+	_ UserStore = (*MemoryUserRepository)(nil)
+)
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/moabdelazem/mutlitier_app/internal/database"
+)
+
+var (
+	// ErrUserNotFound is returned when a task is assigned to an unknown user
+	ErrUserNotFound = errors.New("user not found")
+	ErrUserExists   = errors.New("user already exists")
+)
+
+const foreignKeyViolation = "23503"
+
+// UserRepository handles database operations for users
+type UserRepository struct {
+	db *database.DB
+}
+
+// NewUserRepository creates a new UserRepository
+func NewUserRepository(db *database.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// Touch implements UserStore
+func (r *UserRepository) Touch(ctx context.Context, user string, at time.Time) error {
+	query := `
+		INSERT INTO users (id, created_at, last_seen_at) VALUES ($1, $2, $2)
+		ON CONFLICT (id) DO UPDATE SET last_seen_at = GREATEST(users.last_seen_at, EXCLUDED.last_seen_at)
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, user, at); err != nil {
+		return fmt.Errorf("failed to touch user: %w", err)
+	}
+
+	return nil
+}
+
+// Exists implements UserStore
+func (r *UserRepository) Exists(ctx context.Context, user string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`
+
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, query, user).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check user: %w", err)
+	}
+
+	return exists, nil
+}
+
+// Register implements UserStore
+func (r *UserRepository) Register(ctx context.Context, user, passwordHash string) (time.Time, error) {
+	tx, err := r.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var createdAt time.Time
+	err = tx.QueryRowContext(ctx, `INSERT INTO users (id) VALUES ($1) RETURNING created_at`, user).Scan(&createdAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return time.Time{}, ErrUserExists
+		}
+		return time.Time{}, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	query := `INSERT INTO user_credentials (user_id, password_hash) VALUES ($1, $2)`
+	if _, err := tx.ExecContext(ctx, query, user, passwordHash); err != nil {
+		return time.Time{}, fmt.Errorf("failed to store password: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return time.Time{}, fmt.Errorf("failed to commit user: %w", err)
+	}
+
+	return createdAt, nil
+}
+
+// PasswordHash implements UserStore
+func (r *UserRepository) PasswordHash(ctx context.Context, user string) (string, error) {
+	query := `SELECT password_hash FROM user_credentials WHERE user_id = $1`
+
+	var hash string
+	if err := r.db.QueryRowContext(ctx, query, user).Scan(&hash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrUserNotFound
+		}
+		return "", fmt.Errorf("failed to get password: %w", err)
+	}
+
+	return hash, nil
+}
+
+// Identity implements UserStore
+func (r *UserRepository) Identity(ctx context.Context, issuer, subject string) (string, error) {
+	query := `SELECT user_id FROM user_identities WHERE issuer = $1 AND subject = $2`
+
+	var user string
+	if err := r.db.QueryRowContext(ctx, query, issuer, subject).Scan(&user); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrUserNotFound
+		}
+		return "", fmt.Errorf("failed to get identity: %w", err)
+	}
+
+	return user, nil
+}
+
+// Link implements UserStore
+func (r *UserRepository) Link(ctx context.Context, issuer, subject, user string) error {
+	tx, err := r.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO users (id) VALUES ($1)`, user); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return ErrUserExists
+		}
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	query := `INSERT INTO user_identities (issuer, subject, user_id) VALUES ($1, $2, $3)`
+	if _, err := tx.ExecContext(ctx, query, issuer, subject, user); err != nil {
+		return fmt.Errorf("failed to link identity: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit identity: %w", err)
+	}
+
+	return nil
+}
+
+// isForeignKeyViolation reports whether err is a Postgres foreign key error
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation
+}
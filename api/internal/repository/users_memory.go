@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryUserRepository is an in-memory UserStore used by demo mode
+type MemoryUserRepository struct {
+	mu         sync.RWMutex
+	users      map[string]time.Time
+	passwords  map[string]string
+	identities map[[2]string]string
+}
+
+// NewMemoryUserRepository creates a new MemoryUserRepository
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{
+		users:      make(map[string]time.Time),
+		passwords:  make(map[string]string),
+		identities: make(map[[2]string]string),
+	}
+}
+
+// Touch implements UserStore
+func (r *MemoryUserRepository) Touch(ctx context.Context, user string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if at.After(r.users[user]) {
+		r.users[user] = at
+	}
+	return nil
+}
+
+// Exists implements UserStore
+func (r *MemoryUserRepository) Exists(ctx context.Context, user string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.users[user]
+	return ok, nil
+}
+
+// Register implements UserStore
+func (r *MemoryUserRepository) Register(ctx context.Context, user, passwordHash string) (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[user]; ok {
+		return time.Time{}, ErrUserExists
+	}
+	now := time.Now().UTC()
+	r.users[user] = now
+	r.passwords[user] = passwordHash
+	return now, nil
+}
+
+// PasswordHash implements UserStore
+func (r *MemoryUserRepository) PasswordHash(ctx context.Context, user string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	hash, ok := r.passwords[user]
+	if !ok {
+		return "", ErrUserNotFound
+	}
+	return hash, nil
+}
+
+// Identity implements UserStore
+func (r *MemoryUserRepository) Identity(ctx context.Context, issuer, subject string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.identities[[2]string{issuer, subject}]
+	if !ok {
+		return "", ErrUserNotFound
+	}
+	return user, nil
+}
+
+// Link implements UserStore
+func (r *MemoryUserRepository) Link(ctx context.Context, issuer, subject, user string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[user]; ok {
+		return ErrUserExists
+	}
+	r.users[user] = time.Now().UTC()
+	r.identities[[2]string{issuer, subject}] = user
+	return nil
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// WatcherStore is the storage contract for task watchers, implemented by
+// the Postgres WatcherRepository and the in-memory MemoryWatcherRepository
+type WatcherStore interface {
+	// Watch adds user to the task's watchers, a no-op when already watching
+	Watch(ctx context.Context, taskID, user string) error
+
+	// Unwatch removes user from the task's watchers, a no-op when not watching
+	Unwatch(ctx context.Context, taskID, user string) error
+
+	// ListByTask returns the task's watchers, earliest first
+	ListByTask(ctx context.Context, taskID string) ([]*model.Watcher, error)
+
+	// WatchersOf returns the users watching each of taskIDs, leaving out
+	// tasks nobody watches
+	WatchersOf(ctx context.Context, taskIDs []string) (map[string][]string, error)
+}
+
+var (
+	_ WatcherStore = (*WatcherRepository)(nil)
+	_ WatcherStore = (*MemoryWatcherRepository)(nil)
+)
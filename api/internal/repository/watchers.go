@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/moabdelazem/mutlitier_app/internal/database"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// WatcherRepository handles database operations for task watchers
+type WatcherRepository struct {
+	db *database.DB
+}
+
+// NewWatcherRepository creates a new WatcherRepository
+func NewWatcherRepository(db *database.DB) *WatcherRepository {
+	return &WatcherRepository{db: db}
+}
+
+// Watch implements WatcherStore. A task removed meanwhile is ErrTaskNotFound.
+func (r *WatcherRepository) Watch(ctx context.Context, taskID, user string) error {
+	query := `
+		INSERT INTO task_watchers (task_id, user_id) VALUES ($1, $2)
+		ON CONFLICT (task_id, user_id) DO NOTHING
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, taskID, user); err != nil {
+		if isForeignKeyViolation(err) {
+			return ErrTaskNotFound
+		}
+		return fmt.Errorf("failed to watch task: %w", err)
+	}
+
+	return nil
+}
+
+// Unwatch implements WatcherStore
+func (r *WatcherRepository) Unwatch(ctx context.Context, taskID, user string) error {
+	query := `DELETE FROM task_watchers WHERE task_id = $1 AND user_id = $2`
+
+	if _, err := r.db.ExecContext(ctx, query, taskID, user); err != nil {
+		return fmt.Errorf("failed to unwatch task: %w", err)
+	}
+
+	return nil
+}
+
+// ListByTask implements WatcherStore
+func (r *WatcherRepository) ListByTask(ctx context.Context, taskID string) ([]*model.Watcher, error) {
+	query := `
+		SELECT task_id, user_id, created_at
+		FROM task_watchers
+		WHERE task_id = $1
+		ORDER BY created_at, user_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list watchers: %w", err)
+	}
+	defer rows.Close()
+
+	var watchers []*model.Watcher
+	for rows.Next() {
+		var watcher model.Watcher
+		if err := rows.Scan(&watcher.TaskID, &watcher.User, &watcher.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan watcher: %w", err)
+		}
+		watchers = append(watchers, &watcher)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating watchers: %w", err)
+	}
+
+	return watchers, nil
+}
+
+// WatchersOf implements WatcherStore
+func (r *WatcherRepository) WatchersOf(ctx context.Context, taskIDs []string) (map[string][]string, error) {
+	query := `
+		SELECT task_id, user_id
+		FROM task_watchers
+		WHERE task_id = ANY($1)
+		ORDER BY task_id, user_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(taskIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list watchers: %w", err)
+	}
+	defer rows.Close()
+
+	watchers := make(map[string][]string)
+	for rows.Next() {
+		var taskID, user string
+		if err := rows.Scan(&taskID, &user); err != nil {
+			return nil, fmt.Errorf("failed to scan watcher: %w", err)
+		}
+		watchers[taskID] = append(watchers[taskID], user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating watchers: %w", err)
+	}
+
+	return watchers, nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// MemoryWatcherRepository is an in-memory WatcherStore used by demo mode
+type MemoryWatcherRepository struct {
+	mu       sync.RWMutex
+	watchers map[string]map[string]time.Time
+}
+
+// NewMemoryWatcherRepository creates a new MemoryWatcherRepository
+func NewMemoryWatcherRepository() *MemoryWatcherRepository {
+	return &MemoryWatcherRepository{watchers: make(map[string]map[string]time.Time)}
+}
+
+// Reset removes all watchers
+func (r *MemoryWatcherRepository) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.watchers = make(map[string]map[string]time.Time)
+}
+
+// Watch implements WatcherStore
+func (r *MemoryWatcherRepository) Watch(ctx context.Context, taskID, user string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.watchers[taskID] == nil {
+		r.watchers[taskID] = make(map[string]time.Time)
+	}
+	if _, ok := r.watchers[taskID][user]; !ok {
+		r.watchers[taskID][user] = time.Now().UTC()
+	}
+	return nil
+}
+
+// Unwatch implements WatcherStore
+func (r *MemoryWatcherRepository) Unwatch(ctx context.Context, taskID, user string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.watchers[taskID], user)
+	if len(r.watchers[taskID]) == 0 {
+		delete(r.watchers, taskID)
+	}
+	return nil
+}
+
+// ListByTask implements WatcherStore
+func (r *MemoryWatcherRepository) ListByTask(ctx context.Context, taskID string) ([]*model.Watcher, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var watchers []*model.Watcher
+	for user, at := range r.watchers[taskID] {
+		watchers = append(watchers, &model.Watcher{TaskID: taskID, User: user, CreatedAt: at})
+	}
+
+	sort.Slice(watchers, func(i, j int) bool {
+		if cmp := watchers[i].CreatedAt.Compare(watchers[j].CreatedAt); cmp != 0 {
+			return cmp < 0
+		}
+		return watchers[i].User < watchers[j].User
+	})
+	return watchers, nil
+}
+
+// WatchersOf implements WatcherStore
+func (r *MemoryWatcherRepository) WatchersOf(ctx context.Context, taskIDs []string) (map[string][]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	watchers := make(map[string][]string)
+	for _, taskID := range taskIDs {
+		if _, done := watchers[taskID]; done {
+			continue
+		}
+		for user := range r.watchers[taskID] {
+			watchers[taskID] = append(watchers[taskID], user)
+		}
+		sort.Strings(watchers[taskID])
+	}
+	return watchers, nil
+}
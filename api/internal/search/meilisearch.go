@@ -0,0 +1,123 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// Meilisearch is an Index backed by Meilisearch. Writes are queued by
+// Meilisearch and applied asynchronously, in order, per index.
+type Meilisearch struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+	index   string
+}
+
+// NewMeilisearch creates a new Meilisearch index client
+func NewMeilisearch(client *http.Client, baseURL, apiKey, index string) *Meilisearch {
+	return &Meilisearch{
+		client:  client,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		index:   index,
+	}
+}
+
+// Setup implements Index
+func (m *Meilisearch) Setup(ctx context.Context) error {
+	// Creating an existing index fails inside Meilisearch's task queue, not here
+	err := m.do(ctx, http.MethodPost, "/indexes", map[string]string{"uid": m.index, "primaryKey": "id"}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create index: %w", err)
+	}
+
+	// Listed in ranking order, so title matches rank above description ones
+	err = m.do(ctx, http.MethodPut, m.path("/settings/searchable-attributes"), []string{"title", "description"}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to configure index: %w", err)
+	}
+	return nil
+}
+
+// Upsert implements Index
+func (m *Meilisearch) Upsert(ctx context.Context, tasks []*model.TaskResponse) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	if err := m.do(ctx, http.MethodPost, m.path("/documents"), tasks, nil); err != nil {
+		return fmt.Errorf("failed to index tasks: %w", err)
+	}
+	return nil
+}
+
+// Delete implements Index
+func (m *Meilisearch) Delete(ctx context.Context, id string) error {
+	if err := m.do(ctx, http.MethodDelete, m.path("/documents/"+url.PathEscape(id)), nil, nil); err != nil {
+		return fmt.Errorf("failed to delete task document: %w", err)
+	}
+	return nil
+}
+
+// Clear implements Index
+func (m *Meilisearch) Clear(ctx context.Context) error {
+	if err := m.do(ctx, http.MethodDelete, m.path("/documents"), nil, nil); err != nil {
+		return fmt.Errorf("failed to clear index: %w", err)
+	}
+	return nil
+}
+
+// Search implements Index
+func (m *Meilisearch) Search(ctx context.Context, query string, offset, limit int) ([]*model.TaskResponse, int, error) {
+	body := map[string]any{"q": query, "offset": offset, "limit": limit}
+
+	var result struct {
+		Hits               []*model.TaskResponse `json:"hits"`
+		EstimatedTotalHits int                   `json:"estimatedTotalHits"`
+	}
+	if err := m.do(ctx, http.MethodPost, m.path("/search"), body, &result); err != nil {
+		return nil, 0, fmt.Errorf("failed to search index: %w", err)
+	}
+	return result.Hits, result.EstimatedTotalHits, nil
+}
+
+// Get implements Index
+func (m *Meilisearch) Get(ctx context.Context, id string) (*model.TaskResponse, bool, error) {
+	var task model.TaskResponse
+	err := m.do(ctx, http.MethodGet, m.path("/documents/"+url.PathEscape(id)), nil, &task)
+	if hasStatus(err, http.StatusNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get task document: %w", err)
+	}
+	return &task, true, nil
+}
+
+// Count implements Index
+func (m *Meilisearch) Count(ctx context.Context) (int, error) {
+	var stats struct {
+		NumberOfDocuments int `json:"numberOfDocuments"`
+	}
+	if err := m.do(ctx, http.MethodGet, m.path("/stats"), nil, &stats); err != nil {
+		return 0, fmt.Errorf("failed to get index stats: %w", err)
+	}
+	return stats.NumberOfDocuments, nil
+}
+
+func (m *Meilisearch) path(suffix string) string {
+	return "/indexes/" + url.PathEscape(m.index) + suffix
+}
+
+func (m *Meilisearch) do(ctx context.Context, method, path string, body, out any) error {
+	header := http.Header{}
+	if m.apiKey != "" {
+		header.Set("Authorization", "Bearer "+m.apiKey)
+	}
+	return do(ctx, m.client, method, m.baseURL+path, header, body, out)
+}
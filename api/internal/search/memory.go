@@ -0,0 +1,140 @@
+package search
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// Memory is an in-process Index for demo mode and tests. Like the memory
+// task repository, every term must appear and title hits rank higher.
+type Memory struct {
+	mu    sync.RWMutex
+	tasks map[string]*model.TaskResponse
+}
+
+// NewMemory creates a new empty Memory index
+func NewMemory() *Memory {
+	return &Memory{tasks: make(map[string]*model.TaskResponse)}
+}
+
+// Setup implements Index
+func (m *Memory) Setup(ctx context.Context) error {
+	return nil
+}
+
+// Upsert implements Index
+func (m *Memory) Upsert(ctx context.Context, tasks []*model.TaskResponse) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, task := range tasks {
+		copied := *task
+		m.tasks[task.ID] = &copied
+	}
+	return nil
+}
+
+// Delete implements Index
+func (m *Memory) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.tasks, id)
+	return nil
+}
+
+// Clear implements Index
+func (m *Memory) Clear(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tasks = make(map[string]*model.TaskResponse)
+	return nil
+}
+
+// Search implements Index
+func (m *Memory) Search(ctx context.Context, query string, offset, limit int) ([]*model.TaskResponse, int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	terms := strings.Fields(strings.ToLower(query))
+	ranks := make(map[string]int)
+
+	var tasks []*model.TaskResponse
+	for _, task := range m.tasks {
+		if rank := rankTask(task, terms); rank > 0 {
+			copied := *task
+			tasks = append(tasks, &copied)
+			ranks[task.ID] = rank
+		}
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		a, b := tasks[i], tasks[j]
+		if ranks[a.ID] != ranks[b.ID] {
+			return ranks[a.ID] > ranks[b.ID]
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID > b.ID
+	})
+
+	total := len(tasks)
+	if offset >= total {
+		return nil, total, nil
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return tasks[offset:end], total, nil
+}
+
+// Get implements Index
+func (m *Memory) Get(ctx context.Context, id string) (*model.TaskResponse, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	task, ok := m.tasks[id]
+	if !ok {
+		return nil, false, nil
+	}
+	copied := *task
+	return &copied, true, nil
+}
+
+// Count implements Index
+func (m *Memory) Count(ctx context.Context) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.tasks), nil
+}
+
+// rankTask scores a task against search terms, 0 meaning no match
+func rankTask(task *model.TaskResponse, terms []string) int {
+	if len(terms) == 0 {
+		return 0
+	}
+
+	title := strings.ToLower(task.Title)
+	description := strings.ToLower(task.Description)
+
+	rank := 0
+	for _, term := range terms {
+		switch {
+		case strings.Contains(title, term):
+			rank += 2
+		case strings.Contains(description, term):
+			rank++
+		default:
+			return 0
+		}
+	}
+	return rank
+}
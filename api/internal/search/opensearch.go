@@ -0,0 +1,183 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// OpenSearch is an Index backed by OpenSearch (or Elasticsearch). Basic
+// auth credentials are taken from the base URL.
+type OpenSearch struct {
+	client  *http.Client
+	baseURL string
+	index   string
+}
+
+// NewOpenSearch creates a new OpenSearch index client
+func NewOpenSearch(client *http.Client, baseURL, index string) *OpenSearch {
+	return &OpenSearch{
+		client:  client,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		index:   index,
+	}
+}
+
+// Setup implements Index
+func (o *OpenSearch) Setup(ctx context.Context) error {
+	err := o.do(ctx, http.MethodHead, o.path(""), nil, nil)
+	if err == nil {
+		return nil
+	}
+	if !hasStatus(err, http.StatusNotFound) {
+		return fmt.Errorf("failed to check index: %w", err)
+	}
+
+	mappings := map[string]any{
+		"mappings": map[string]any{
+			"properties": map[string]any{
+				"id":          map[string]string{"type": "keyword"},
+				"ref":         map[string]string{"type": "keyword"},
+				"title":       map[string]string{"type": "text"},
+				"description": map[string]string{"type": "text"},
+				"status":      map[string]string{"type": "keyword"},
+				"priority":    map[string]string{"type": "keyword"},
+				"due_date":    map[string]string{"type": "date"},
+				"tags":        map[string]string{"type": "keyword"},
+				"version":     map[string]string{"type": "long"},
+				"created_at":  map[string]string{"type": "date"},
+				"updated_at":  map[string]string{"type": "date"},
+			},
+		},
+	}
+	if err := o.do(ctx, http.MethodPut, o.path(""), mappings, nil); err != nil {
+		return fmt.Errorf("failed to create index: %w", err)
+	}
+	return nil
+}
+
+// Upsert implements Index using the bulk API
+func (o *OpenSearch) Upsert(ctx context.Context, tasks []*model.TaskResponse) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, task := range tasks {
+		action := map[string]any{"index": map[string]string{"_index": o.index, "_id": task.ID}}
+		if err := encoder.Encode(action); err != nil {
+			return fmt.Errorf("failed to encode bulk action: %w", err)
+		}
+		if err := encoder.Encode(task); err != nil {
+			return fmt.Errorf("failed to encode task document: %w", err)
+		}
+	}
+
+	header := http.Header{"Content-Type": {"application/x-ndjson"}}
+
+	var result struct {
+		Errors bool `json:"errors"`
+	}
+	if err := do(ctx, o.client, http.MethodPost, o.baseURL+"/_bulk", header, body.Bytes(), &result); err != nil {
+		return fmt.Errorf("failed to index tasks: %w", err)
+	}
+	if result.Errors {
+		return fmt.Errorf("failed to index tasks: bulk request reported item errors")
+	}
+	return nil
+}
+
+// Delete implements Index
+func (o *OpenSearch) Delete(ctx context.Context, id string) error {
+	err := o.do(ctx, http.MethodDelete, o.path("/_doc/"+url.PathEscape(id)), nil, nil)
+	if err != nil && !hasStatus(err, http.StatusNotFound) {
+		return fmt.Errorf("failed to delete task document: %w", err)
+	}
+	return nil
+}
+
+// Clear implements Index
+func (o *OpenSearch) Clear(ctx context.Context) error {
+	body := map[string]any{"query": map[string]any{"match_all": map[string]any{}}}
+	if err := o.do(ctx, http.MethodPost, o.path("/_delete_by_query?refresh=true"), body, nil); err != nil {
+		return fmt.Errorf("failed to clear index: %w", err)
+	}
+	return nil
+}
+
+// Search implements Index. Every term must match, title matches rank higher.
+func (o *OpenSearch) Search(ctx context.Context, query string, offset, limit int) ([]*model.TaskResponse, int, error) {
+	body := map[string]any{
+		"query": map[string]any{
+			"multi_match": map[string]any{
+				"query":    query,
+				"fields":   []string{"title^2", "description"},
+				"operator": "and",
+			},
+		},
+		"from":             offset,
+		"size":             limit,
+		"track_total_hits": true,
+	}
+
+	var result struct {
+		Hits struct {
+			Total struct {
+				Value int `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				Source *model.TaskResponse `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := o.do(ctx, http.MethodPost, o.path("/_search"), body, &result); err != nil {
+		return nil, 0, fmt.Errorf("failed to search index: %w", err)
+	}
+
+	tasks := make([]*model.TaskResponse, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		tasks = append(tasks, hit.Source)
+	}
+	return tasks, result.Hits.Total.Value, nil
+}
+
+// Get implements Index
+func (o *OpenSearch) Get(ctx context.Context, id string) (*model.TaskResponse, bool, error) {
+	var result struct {
+		Source *model.TaskResponse `json:"_source"`
+	}
+	err := o.do(ctx, http.MethodGet, o.path("/_doc/"+url.PathEscape(id)), nil, &result)
+	if hasStatus(err, http.StatusNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get task document: %w", err)
+	}
+	return result.Source, true, nil
+}
+
+// Count implements Index
+func (o *OpenSearch) Count(ctx context.Context) (int, error) {
+	var result struct {
+		Count int `json:"count"`
+	}
+	if err := o.do(ctx, http.MethodGet, o.path("/_count"), nil, &result); err != nil {
+		return 0, fmt.Errorf("failed to count documents: %w", err)
+	}
+	return result.Count, nil
+}
+
+func (o *OpenSearch) path(suffix string) string {
+	return "/" + url.PathEscape(o.index) + suffix
+}
+
+func (o *OpenSearch) do(ctx context.Context, method, path string, body, out any) error {
+	return do(ctx, o.client, method, o.baseURL+path, nil, body, out)
+}
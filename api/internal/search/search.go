@@ -0,0 +1,59 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// Index mirrors tasks into a search engine. Documents are task responses
+// keyed by task ID, so search results can be served without touching
+// Postgres. Implementations must be safe for concurrent use.
+type Index interface {
+	// Setup creates the index and its settings if they do not exist yet
+	Setup(ctx context.Context) error
+
+	// Upsert adds or replaces task documents
+	Upsert(ctx context.Context, tasks []*model.TaskResponse) error
+
+	// Delete removes a task document; missing documents are not an error
+	Delete(ctx context.Context, id string) error
+
+	// Clear removes every document, keeping the index and its settings
+	Clear(ctx context.Context) error
+
+	// Search returns a page of matching tasks, best matches first, and the total number of matches
+	Search(ctx context.Context, query string, offset, limit int) ([]*model.TaskResponse, int, error)
+
+	// Get returns a single task document, or false if it is not indexed
+	Get(ctx context.Context, id string) (*model.TaskResponse, bool, error)
+
+	// Count returns the number of indexed documents
+	Count(ctx context.Context) (int, error)
+}
+
+// Supported backends
+const (
+	BackendMeilisearch = "meilisearch"
+	BackendOpenSearch  = "opensearch"
+	BackendMemory      = "memory"
+)
+
+// New creates the Index for the configured backend
+func New(cfg *config.SearchConfig) (Index, error) {
+	client := &http.Client{Timeout: cfg.Timeout}
+
+	switch cfg.Backend {
+	case BackendMeilisearch:
+		return NewMeilisearch(client, cfg.URL, cfg.APIKey, cfg.Index), nil
+	case BackendOpenSearch:
+		return NewOpenSearch(client, cfg.URL, cfg.Index), nil
+	case BackendMemory:
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown search backend %q", cfg.Backend)
+	}
+}
@@ -0,0 +1,47 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeilisearch_Search(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/indexes/tasks/search", r.URL.Path)
+		assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "docs", body["q"])
+		assert.Equal(t, float64(10), body["offset"])
+
+		w.Write([]byte(`{"hits":[{"id":"a","title":"Write docs"}],"estimatedTotalHits":11}`))
+	}))
+	defer srv.Close()
+
+	tasks, total, err := NewMeilisearch(srv.Client(), srv.URL+"/", "key", "tasks").Search(context.Background(), "docs", 10, 10)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "Write docs", tasks[0].Title)
+	assert.Equal(t, 11, total)
+}
+
+func TestOpenSearch_GetMissing(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/tasks/_doc/a", r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"found":false}`))
+	}))
+	defer srv.Close()
+
+	task, ok, err := NewOpenSearch(srv.Client(), srv.URL, "tasks").Get(context.Background(), "a")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Nil(t, task)
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/llm"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+var (
+	ErrAIUnavailable = errors.New("language model unavailable")
+)
+
+// aiTagLimit bounds the existing tags offered to the model for triage
+const aiTagLimit = 200
+
+const summarizePrompt = `You summarize tasks from a task tracker for busy engineers.
+Reply with a plain-text summary of at most three sentences: what the task is about, where it stands, and any open question or blocker raised in the comments.
+Do not invent details that are not in the task.`
+
+const triagePrompt = `You triage new tasks for a task tracker.
+Reply with only a JSON object of the form {"priority": "...", "tags": ["..."], "reason": "..."}.
+priority is one of low, medium, high or urgent. tags are at most three of the existing tags listed, or none. reason is one sentence explaining the priority.`
+
+// AIService summarizes and triages tasks with a language model. Only task
+// text the caller can read is sent, and only when a model is configured;
+// replies are cached by a hash of everything sent, so asking again about
+// an unchanged task does not call the model.
+type AIService struct {
+	provider llm.Provider
+	tasks    *TaskService
+	comments *CommentService
+	tags     repository.TagStore
+	cache    kvstore.Store
+	cfg      *config.AIConfig
+	validate *validator.Validate
+}
+
+// NewAIService creates a new AIService
+func NewAIService(provider llm.Provider, tasks *TaskService, comments *CommentService, tags repository.TagStore, cache kvstore.Store, cfg *config.AIConfig) *AIService {
+	return &AIService{
+		provider: provider,
+		tasks:    tasks,
+		comments: comments,
+		tags:     tags,
+		cache:    cache,
+		cfg:      cfg,
+		validate: validator.New(),
+	}
+}
+
+// Summarize returns a summary of a task, its description and its latest
+// comments
+func (s *AIService) Summarize(ctx context.Context, id string) (*model.TaskSummary, error) {
+	task, err := s.tasks.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := s.comments.List(ctx, id, &model.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "Title: %s\nStatus: %s\nPriority: %s\n", task.Title, task.Status, task.Priority)
+	if len(task.Tags) > 0 {
+		fmt.Fprintf(&text, "Tags: %s\n", strings.Join(task.Tags, ", "))
+	}
+	fmt.Fprintf(&text, "Description:\n%s\n", task.Description)
+	if len(comments.Data) > 0 {
+		text.WriteString("Comments:\n")
+		for _, comment := range comments.Data {
+			fmt.Fprintf(&text, "- %s: %s\n", comment.Author, comment.Body)
+		}
+	}
+
+	reply, err := s.complete(ctx, "summary", summarizePrompt, text.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.TaskSummary{
+		TaskID:      task.ID,
+		Summary:     reply.Text,
+		Model:       s.provider.Model(),
+		Cached:      reply.cached,
+		GeneratedAt: reply.GeneratedAt,
+	}, nil
+}
+
+// Triage suggests a priority and existing tags for a task about to be
+// created
+func (s *AIService) Triage(ctx context.Context, req *model.TriageRequest) (*model.TriageSuggestion, error) {
+	req.Title = strings.TrimSpace(req.Title)
+	req.Description = strings.TrimSpace(req.Description)
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, formatValidationErrors(err))
+	}
+
+	tags, err := s.tags.ListTags(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	names := make([]string, 0, min(len(tags), aiTagLimit))
+	for _, tag := range tags[:min(len(tags), aiTagLimit)] {
+		names = append(names, tag.Name)
+	}
+	slices.Sort(names)
+
+	text := fmt.Sprintf("Existing tags: %s\nTitle: %s\nDescription:\n%s\n", strings.Join(names, ", "), req.Title, req.Description)
+	reply, err := s.complete(ctx, "triage", triagePrompt, text)
+	if err != nil {
+		return nil, err
+	}
+
+	var suggestion struct {
+		Priority string   `json:"priority"`
+		Tags     []string `json:"tags"`
+		Reason   string   `json:"reason"`
+	}
+	// Models often wrap JSON in prose or code fences
+	start, end := strings.Index(reply.Text, "{"), strings.LastIndex(reply.Text, "}")
+	if start < 0 || end < start || json.Unmarshal([]byte(reply.Text[start:end+1]), &suggestion) != nil {
+		return nil, fmt.Errorf("%w: the model's reply was not the JSON asked for", ErrAIUnavailable)
+	}
+	priority, err := model.ParsePriority(strings.ToLower(strings.TrimSpace(suggestion.Priority)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: the model suggested an unknown priority", ErrAIUnavailable)
+	}
+
+	suggested := []string{}
+	for _, name := range suggestion.Tags {
+		name = normalizeTagName(name)
+		if slices.Contains(names, name) && !slices.Contains(suggested, name) {
+			suggested = append(suggested, name)
+		}
+	}
+
+	return &model.TriageSuggestion{
+		Priority:    priority,
+		Tags:        suggested,
+		Reason:      strings.TrimSpace(suggestion.Reason),
+		Model:       s.provider.Model(),
+		Cached:      reply.cached,
+		GeneratedAt: reply.GeneratedAt,
+	}, nil
+}
+
+// aiReply is a model reply as cached
+type aiReply struct {
+	Text        string    `json:"text"`
+	GeneratedAt time.Time `json:"generated_at"`
+
+	cached bool
+}
+
+// complete asks the model about text, cut to AI_MAX_INPUT, with the
+// instructions in prompt, reusing a cached reply to the same question.
+// Model failures are ErrAIUnavailable.
+func (s *AIService) complete(ctx context.Context, kind, prompt, text string) (*aiReply, error) {
+	if len(text) > s.cfg.MaxInput {
+		text = strings.ToValidUTF8(text[:s.cfg.MaxInput], "")
+	}
+
+	sum := sha256.Sum256([]byte(s.provider.Model() + "\x00" + prompt + "\x00" + text))
+	key := "ai:" + kind + ":" + hex.EncodeToString(sum[:])
+
+	// The cache is an optimization; a broken one only costs a model call
+	if value, ok, err := s.cache.Get(ctx, key); err == nil && ok {
+		var reply aiReply
+		if json.Unmarshal(value, &reply) == nil {
+			reply.cached = true
+			return &reply, nil
+		}
+	}
+
+	call, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
+	answer, err := s.provider.Complete(call, []llm.Message{
+		{Role: llm.RoleSystem, Content: prompt},
+		{Role: llm.RoleUser, Content: text},
+	})
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Str("kind", kind).Msg("Language model request failed")
+		return nil, ErrAIUnavailable
+	}
+
+	reply := &aiReply{Text: answer, GeneratedAt: time.Now().UTC()}
+	if value, err := json.Marshal(reply); err == nil {
+		_ = s.cache.Set(ctx, key, value, s.cfg.CacheTTL)
+	}
+	return reply, nil
+}
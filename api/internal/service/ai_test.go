@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/mutlitier_app/internal/audit"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/llm"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeModel replies with reply, or fails with err, counting its calls
+type fakeModel struct {
+	reply string
+	err   error
+	calls int
+	last  []llm.Message
+}
+
+func (m *fakeModel) Complete(ctx context.Context, messages []llm.Message) (string, error) {
+	m.calls++
+	m.last = messages
+	return m.reply, m.err
+}
+
+func (m *fakeModel) Model() string { return "fake" }
+
+func TestAIService(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryTaskRepository(0)
+	guard := NewQueryGuard(&config.QueryGuardConfig{DefaultPerPage: 10, MaxPerPage: 10})
+	events := NewEventService(repository.NewMemoryEventRepository(), kvstore.NewMemory(), &config.EventsConfig{})
+	tasks := NewTaskService(repo, guard, nil, events, nil, nil, nil, &config.TaskConfig{DefaultProject: "TASK"})
+	comments := NewCommentService(repository.NewMemoryCommentRepository(), repo, guard, &config.CommentConfig{})
+	provider := &fakeModel{reply: "Deploy the API; blocked on the certificate renewal."}
+	svc := NewAIService(provider, tasks, comments, repo, kvstore.NewMemory(), &config.AIConfig{
+		Timeout: time.Second, MaxInput: 4000, CacheTTL: time.Hour,
+	})
+
+	task, err := tasks.Create(ctx, &model.CreateTaskRequest{Title: "Deploy", Description: "Roll out v2 to production"})
+	require.NoError(t, err)
+	_, err = comments.Create(audit.WithActor(ctx, "bob"), task.ID, &model.CreateCommentRequest{Body: "Waiting on the new certificate"})
+	require.NoError(t, err)
+
+	summary, err := svc.Summarize(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, provider.reply, summary.Summary)
+	assert.False(t, summary.Cached)
+	assert.Contains(t, provider.last[1].Content, "Waiting on the new certificate")
+
+	// An unchanged task is answered from the cache
+	summary, err = svc.Summarize(ctx, task.ID)
+	require.NoError(t, err)
+	assert.True(t, summary.Cached)
+	assert.Equal(t, 1, provider.calls)
+
+	_, err = svc.Summarize(ctx, uuid.NewString())
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+
+	// Triage only suggests tags that exist
+	for _, name := range []string{"backend", "ops"} {
+		_, err = repo.CreateTag(ctx, &model.Tag{ID: uuid.NewString(), Name: name})
+		require.NoError(t, err)
+	}
+	provider.reply = "Sure!\n```json\n{\"priority\": \"High\", \"tags\": [\"ops\", \"made-up\"], \"reason\": \"Production is affected.\"}\n```"
+	suggestion, err := svc.Triage(ctx, &model.TriageRequest{Title: "API returns 500s"})
+	require.NoError(t, err)
+	assert.Equal(t, model.PriorityHigh, suggestion.Priority)
+	assert.Equal(t, []string{"ops"}, suggestion.Tags)
+	assert.Contains(t, provider.last[1].Content, "backend, ops")
+
+	_, err = svc.Triage(ctx, &model.TriageRequest{})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	provider.reply = "I think it is important"
+	_, err = svc.Triage(ctx, &model.TriageRequest{Title: "Flaky test"})
+	assert.ErrorIs(t, err, ErrAIUnavailable)
+
+	provider.err = errors.New("connection refused")
+	_, err = svc.Triage(ctx, &model.TriageRequest{Title: "Slow board"})
+	assert.ErrorIs(t, err, ErrAIUnavailable)
+}
@@ -0,0 +1,362 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/analytics"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+	"github.com/moabdelazem/mutlitier_app/pkg/worker"
+)
+
+const (
+	analyticsCursorKey       = "analytics:events:cursor"
+	analyticsLockPrefix      = "analytics:export:"
+	analyticsWatermarkPrefix = "analytics:watermark:"
+
+	// analyticsLag keeps the newest audit logs and security events, which
+	// are written in batches shortly after they happen, for the next run
+	analyticsLag = time.Minute
+
+	// analyticsStateTTL outlives any sensible export schedule
+	analyticsStateTTL = 30 * 24 * time.Hour
+)
+
+var (
+	ErrExportClaimed = errors.New("analytics export for this date already ran or is running")
+)
+
+// ExportResult describes a finished analytics export
+type ExportResult struct {
+	Date           string   `json:"date"`
+	Tasks          int      `json:"tasks"`
+	Events         int      `json:"events"`
+	AuditLogs      int      `json:"audit_logs"`
+	SecurityEvents int      `json:"security_events"`
+	Incomplete     bool     `json:"incomplete"`
+	Files          []string `json:"files"`
+}
+
+// AnalyticsExporter writes task, audit and security facts to object
+// storage for analytics. Each run stores a snapshot of all tasks plus the
+// task events, audit logs and security events written since the previous
+// run, partitioned by export date.
+type AnalyticsExporter struct {
+	repo     repository.TaskStore
+	events   *EventService
+	audit    repository.AuditLogStore
+	security repository.SecurityEventStore
+	sink     analytics.Sink
+	state    kvstore.Store
+	cfg      *config.AnalyticsConfig
+}
+
+// NewAnalyticsExporter creates a new AnalyticsExporter
+func NewAnalyticsExporter(repo repository.TaskStore, events *EventService, audit repository.AuditLogStore, security repository.SecurityEventStore,
+	sink analytics.Sink, state kvstore.Store, cfg *config.AnalyticsConfig) *AnalyticsExporter {
+	return &AnalyticsExporter{repo: repo, events: events, audit: audit, security: security, sink: sink, state: state, cfg: cfg}
+}
+
+// Schedule runs Export in workers every day at ANALYTICS_EXPORT_AT (UTC).
+// On shutdown no new export starts and a running one may finish until the
+// drain deadline; one cut short releases its claim so another replica
+// can export that date.
+func (e *AnalyticsExporter) Schedule(workers *worker.Group) error {
+	at, err := e.cfg.TimeOfDay()
+	if err != nil {
+		return err
+	}
+
+	workers.Go("analytics-export", func(stop, work context.Context) {
+		log := logger.Get().WithComponent("analytics")
+
+		for {
+			timer := time.NewTimer(time.Until(nextExport(time.Now(), at)))
+			select {
+			case <-stop.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			_, err := e.Export(work, time.Now(), false)
+			if err != nil && !errors.Is(err, ErrExportClaimed) {
+				log.Error().Err(err).Msg("Analytics export failed")
+			}
+		}
+	})
+	return nil
+}
+
+// nextExport returns the first time after now that is at past midnight UTC
+func nextExport(now time.Time, at time.Duration) time.Time {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).Add(at)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// Export writes the export for now's date. Replicas claim each date in the
+// kv store so only one of them exports; force skips the claim for manual
+// re-runs. A re-run replaces the tasks snapshot and adds the events, audit
+// logs and security events written since the previous run in separate
+// files. An export missing task events that were purged before it ran is
+// marked incomplete.
+func (e *AnalyticsExporter) Export(ctx context.Context, now time.Time, force bool) (result *ExportResult, err error) {
+	now = now.UTC()
+	date := now.Format(time.DateOnly)
+
+	if !force {
+		lock := analyticsLockPrefix + date
+		claimed, err := e.state.SetNX(ctx, lock, []byte(now.Format(time.RFC3339)), analyticsStateTTL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim analytics export: %w", err)
+		}
+		if !claimed {
+			return nil, ErrExportClaimed
+		}
+
+		// Release the claim on failure so the export can be retried
+		defer func() {
+			if err != nil {
+				_ = e.state.Delete(context.WithoutCancel(ctx), lock)
+			}
+		}()
+	}
+
+	result = &ExportResult{Date: date, Files: []string{}}
+
+	if err := e.exportTasks(ctx, now, result); err != nil {
+		return nil, err
+	}
+	if err := e.exportEvents(ctx, result); err != nil {
+		return nil, err
+	}
+	until := now.Add(-analyticsLag)
+	if err := e.exportAudit(ctx, until, result); err != nil {
+		return nil, err
+	}
+	if err := e.exportSecurity(ctx, until, result); err != nil {
+		return nil, err
+	}
+
+	logger.Get().Info().
+		Str("date", date).
+		Int("tasks", result.Tasks).
+		Int("events", result.Events).
+		Int("audit_logs", result.AuditLogs).
+		Int("security_events", result.SecurityEvents).
+		Bool("incomplete", result.Incomplete).
+		Strs("files", result.Files).
+		Msg("Analytics export finished")
+
+	return result, nil
+}
+
+func (e *AnalyticsExporter) exportTasks(ctx context.Context, now time.Time, result *ExportResult) error {
+	var facts []analytics.TaskFact
+
+	opts := &model.ListOptions{Params: listing.Params{Page: 1, PerPage: e.cfg.PageSize, Sort: "created_at", Order: "asc"}}
+	for {
+		tasks, err := e.repo.GetAll(ctx, opts)
+		if err != nil {
+			return fmt.Errorf("failed to list tasks: %w", err)
+		}
+		for _, task := range tasks {
+			facts = append(facts, analytics.NewTaskFact(task, now))
+		}
+		if len(tasks) < opts.PerPage {
+			break
+		}
+		opts.Page++
+	}
+
+	key := fmt.Sprintf("tasks/date=%s/tasks.%s", result.Date, e.cfg.Format)
+	if err := putFacts(ctx, e, key, facts); err != nil {
+		return err
+	}
+
+	result.Tasks = len(facts)
+	result.Files = append(result.Files, e.sink.Location(key))
+	return nil
+}
+
+func (e *AnalyticsExporter) exportEvents(ctx context.Context, result *ExportResult) error {
+	start, err := e.loadCursor(ctx)
+	if err != nil {
+		return err
+	}
+
+	var facts []analytics.EventFact
+	cursor := start
+	for {
+		events, gap, err := e.events.After(ctx, cursor, e.cfg.PageSize)
+		if err != nil {
+			return err
+		}
+		if gap {
+			if err := e.markIncomplete(ctx, cursor, result); err != nil {
+				return err
+			}
+		}
+		for _, event := range events {
+			facts = append(facts, analytics.NewEventFact(event))
+		}
+		if len(events) == 0 {
+			break
+		}
+		cursor = events[len(events)-1].ID
+	}
+
+	if len(facts) == 0 {
+		return nil
+	}
+
+	// Named by event range so re-runs on the same date add files instead of replacing them
+	key := fmt.Sprintf("task_events/date=%s/task_events-%d-%d.%s", result.Date, start+1, cursor, e.cfg.Format)
+	if err := putFacts(ctx, e, key, facts); err != nil {
+		return err
+	}
+	if err := e.state.Set(ctx, analyticsCursorKey, []byte(strconv.FormatInt(cursor, 10)), analyticsStateTTL); err != nil {
+		return fmt.Errorf("failed to save analytics cursor: %w", err)
+	}
+
+	result.Events = len(facts)
+	result.Files = append(result.Files, e.sink.Location(key))
+	return nil
+}
+
+func (e *AnalyticsExporter) loadCursor(ctx context.Context) (int64, error) {
+	value, ok, err := e.state.Get(ctx, analyticsCursorKey)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load analytics cursor: %w", err)
+	}
+	if !ok {
+		return 0, nil
+	}
+	cursor, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return 0, nil
+	}
+	return cursor, nil
+}
+
+// markIncomplete records that task events after cursor were purged before
+// they were exported. The marker sits next to the partition's event files;
+// its leading underscore keeps Hive-style readers from loading it.
+func (e *AnalyticsExporter) markIncomplete(ctx context.Context, cursor int64, result *ExportResult) error {
+	logger.Get().Error().Int64("cursor", cursor).
+		Msg("Task events were purged before they were exported, keep EVENTS_RETENTION above a day")
+
+	key := fmt.Sprintf("task_events/date=%s/_incomplete-after-%d.txt", result.Date, cursor)
+	note := fmt.Sprintf("Task events after %d were purged before they were exported.\n", cursor)
+	if err := e.sink.Put(ctx, key, []byte(note), "text/plain"); err != nil {
+		return err
+	}
+
+	result.Incomplete = true
+	result.Files = append(result.Files, e.sink.Location(key))
+	return nil
+}
+
+func (e *AnalyticsExporter) exportAudit(ctx context.Context, until time.Time, result *ExportResult) error {
+	from, err := e.loadWatermark(ctx, "audit_logs")
+	if err != nil || (from != nil && !from.Before(until)) {
+		return err
+	}
+
+	var facts []analytics.AuditFact
+	filter := &model.AuditLogFilter{From: from, To: &until}
+	opts := &model.ListOptions{Params: listing.Params{Page: 1, PerPage: e.cfg.PageSize}}
+	for {
+		logs, err := e.audit.List(ctx, filter, opts)
+		if err != nil {
+			return fmt.Errorf("failed to list audit logs: %w", err)
+		}
+		for _, log := range logs {
+			facts = append(facts, analytics.NewAuditFact(log))
+		}
+		if len(logs) < opts.PerPage {
+			break
+		}
+		opts.Page++
+	}
+
+	result.AuditLogs = len(facts)
+	return putWindow(ctx, e, "audit_logs", until, facts, result)
+}
+
+func (e *AnalyticsExporter) exportSecurity(ctx context.Context, until time.Time, result *ExportResult) error {
+	from, err := e.loadWatermark(ctx, "security_events")
+	if err != nil || (from != nil && !from.Before(until)) {
+		return err
+	}
+
+	var facts []analytics.SecurityFact
+	filter := &model.SecurityEventFilter{From: from, To: &until}
+	err = e.security.Export(ctx, filter, func(event *model.SecurityEvent) error {
+		facts = append(facts, analytics.NewSecurityFact(event))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	result.SecurityEvents = len(facts)
+	return putWindow(ctx, e, "security_events", until, facts, result)
+}
+
+// loadWatermark returns the time up to which table was exported, nil
+// before its first export
+func (e *AnalyticsExporter) loadWatermark(ctx context.Context, table string) (*time.Time, error) {
+	value, ok, err := e.state.Get(ctx, analyticsWatermarkPrefix+table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load analytics watermark: %w", err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	watermark, err := time.Parse(time.RFC3339Nano, string(value))
+	if err != nil {
+		return nil, nil
+	}
+	return &watermark, nil
+}
+
+// putWindow stores the facts of table created before until, named by
+// until so re-runs on the same date add files, and moves the table's
+// watermark to until
+func putWindow[T any](ctx context.Context, e *AnalyticsExporter, table string, until time.Time, facts []T, result *ExportResult) error {
+	if len(facts) > 0 {
+		key := fmt.Sprintf("%s/date=%s/%s-%s.%s", table, result.Date, table, until.Format("20060102T150405Z"), e.cfg.Format)
+		if err := putFacts(ctx, e, key, facts); err != nil {
+			return err
+		}
+		result.Files = append(result.Files, e.sink.Location(key))
+	}
+
+	if err := e.state.Set(ctx, analyticsWatermarkPrefix+table, []byte(until.Format(time.RFC3339Nano)), analyticsStateTTL); err != nil {
+		return fmt.Errorf("failed to save analytics watermark: %w", err)
+	}
+	return nil
+}
+
+// putFacts encodes facts in the configured format and stores them under key
+func putFacts[T any](ctx context.Context, e *AnalyticsExporter, key string, facts []T) error {
+	var buf bytes.Buffer
+	if err := analytics.Encode(&buf, e.cfg.Format, facts); err != nil {
+		return err
+	}
+	return e.sink.Put(ctx, key, buf.Bytes(), analytics.ContentType(e.cfg.Format))
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/analytics"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyticsExporter_Export(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	repo := repository.NewMemoryTaskRepository(0)
+	state := kvstore.NewMemory()
+	eventStore := repository.NewMemoryEventRepository()
+	events := NewEventService(eventStore, state, &config.EventsConfig{})
+	audit := repository.NewMemoryAuditLogRepository()
+	security := repository.NewMemorySecurityEventRepository()
+	cfg := &config.AnalyticsConfig{Format: analytics.FormatCSV, URL: "file://" + dir, PageSize: 2}
+	sink, err := analytics.NewSink(cfg)
+	require.NoError(t, err)
+	exporter := NewAnalyticsExporter(repo, events, audit, security, sink, state, cfg)
+
+	for _, id := range []string{"a", "b", "c"} {
+		task, err := repo.Create(ctx, &model.Task{ID: id, ProjectKey: "TASK", Title: "Task " + id})
+		require.NoError(t, err)
+		record(t, events, ctx, model.EventTaskCreated, task.ID, task.ToResponse())
+	}
+
+	now := time.Date(2026, 3, 4, 2, 0, 0, 0, time.UTC)
+	require.NoError(t, audit.Append(ctx, []*model.AuditLog{
+		{User: "alice", Method: "DELETE", Endpoint: "/tasks/{id}", EntityID: "a", Status: 204, CreatedAt: now.Add(-time.Hour)},
+		// Still within the lag, left for the next run
+		{User: "bob", Method: "POST", Endpoint: "/tasks", Status: 201, CreatedAt: now.Add(-time.Second)},
+	}))
+	require.NoError(t, security.Append(ctx, []*model.SecurityEvent{
+		{Type: model.SecurityLoginFailed, User: "mallory", Credential: model.CredentialPassword, Reason: "invalid password", CreatedAt: now.Add(-time.Hour)},
+	}))
+
+	result, err := exporter.Export(ctx, now, false)
+	require.NoError(t, err)
+	assert.Equal(t, "2026-03-04", result.Date)
+	assert.Equal(t, 3, result.Tasks)
+	assert.Equal(t, 3, result.Events)
+	assert.Equal(t, 1, result.AuditLogs)
+	assert.Equal(t, 1, result.SecurityEvents)
+	assert.False(t, result.Incomplete)
+	assert.FileExists(t, filepath.Join(dir, "tasks/date=2026-03-04/tasks.csv"))
+	assert.FileExists(t, filepath.Join(dir, "task_events/date=2026-03-04/task_events-1-3.csv"))
+	assert.FileExists(t, filepath.Join(dir, "audit_logs/date=2026-03-04/audit_logs-20260304T015900Z.csv"))
+
+	data, err := os.ReadFile(filepath.Join(dir, "security_events/date=2026-03-04/security_events-20260304T015900Z.csv"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "login_failed,mallory,password,,invalid password")
+
+	// The date is claimed, a second scheduled run is skipped
+	_, err = exporter.Export(ctx, now, false)
+	assert.ErrorIs(t, err, ErrExportClaimed)
+
+	// Forced re-runs only export what was written since the last run
+	require.NoError(t, repo.Delete(ctx, "a", repository.AnyVersion))
+	record(t, events, ctx, model.EventTaskDeleted, "a", nil)
+	result, err = exporter.Export(ctx, now.Add(time.Hour), true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Tasks)
+	assert.Equal(t, 1, result.Events)
+
+	// The audit log held back by the lag is exported once, by the next run
+	assert.Equal(t, 1, result.AuditLogs)
+	assert.Equal(t, 0, result.SecurityEvents)
+
+	data, err = os.ReadFile(filepath.Join(dir, "task_events/date=2026-03-04/task_events-4-4.csv"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "4,task.deleted,a,,,")
+
+	// Events purged before they were exported mark the export incomplete
+	record(t, events, ctx, model.EventTaskUpdated, "b", nil)
+	_, err = eventStore.Purge(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	record(t, events, ctx, model.EventTaskUpdated, "c", nil)
+
+	result, err = exporter.Export(ctx, now.Add(2*time.Hour), true)
+	require.NoError(t, err)
+	assert.True(t, result.Incomplete)
+	assert.Equal(t, 1, result.Events)
+	assert.FileExists(t, filepath.Join(dir, "task_events/date=2026-03-04/_incomplete-after-4.txt"))
+	assert.FileExists(t, filepath.Join(dir, "task_events/date=2026-03-04/task_events-5-6.csv"))
+}
+
+func TestNextExport(t *testing.T) {
+	at := 2 * time.Hour
+	assert.Equal(t, time.Date(2026, 3, 4, 2, 0, 0, 0, time.UTC),
+		nextExport(time.Date(2026, 3, 4, 1, 0, 0, 0, time.UTC), at))
+	assert.Equal(t, time.Date(2026, 3, 5, 2, 0, 0, 0, time.UTC),
+		nextExport(time.Date(2026, 3, 4, 2, 0, 0, 0, time.UTC), at))
+}
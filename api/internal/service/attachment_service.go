@@ -0,0 +1,254 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/mutlitier_app/internal/audit"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/internal/storage"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+var (
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrAttachmentTooLarge = errors.New("attachment is too large")
+	ErrAttachmentType     = errors.New("attachment type is not allowed")
+)
+
+// AttachmentService handles business logic for files uploaded to tasks.
+// Metadata goes to the attachment store and contents to the storage
+// backend; like the rest of the task, attachments of an archived task are
+// read-only.
+type AttachmentService struct {
+	repo    repository.AttachmentStore
+	tasks   repository.TaskStore
+	backend storage.Backend
+	cfg     *config.AttachmentConfig
+}
+
+// NewAttachmentService creates a new AttachmentService
+func NewAttachmentService(repo repository.AttachmentStore, tasks repository.TaskStore, backend storage.Backend, cfg *config.AttachmentConfig) *AttachmentService {
+	return &AttachmentService{repo: repo, tasks: tasks, backend: backend, cfg: cfg}
+}
+
+// List returns the attachments of a live task, oldest first
+func (s *AttachmentService) List(ctx context.Context, taskID string) (*model.AttachmentListResponse, error) {
+	if err := s.checkTask(ctx, taskID, false); err != nil {
+		return nil, err
+	}
+
+	attachments, err := s.repo.ListByTask(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+
+	return model.NewAttachmentListResponse(attachments), nil
+}
+
+// Upload stores the file read from r as an attachment of a task. The
+// media type is sniffed from the content; declaredType is only used when
+// sniffing cannot tell, so a file cannot pass the allow list by claiming
+// to be something else.
+func (s *AttachmentService) Upload(ctx context.Context, taskID, filename, declaredType string, r io.Reader) (*model.Attachment, error) {
+	if err := s.checkTask(ctx, taskID, true); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.ListByTask(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+	if len(existing) >= s.cfg.MaxPerTask {
+		return nil, fmt.Errorf("%w: a task holds at most %d attachments", ErrValidation, s.cfg.MaxPerTask)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, s.cfg.MaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	if int64(len(data)) > s.cfg.MaxBytes {
+		return nil, fmt.Errorf("%w: files must be at most %d bytes", ErrAttachmentTooLarge, s.cfg.MaxBytes)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: file is empty", ErrValidation)
+	}
+
+	contentType := detectContentType(data, declaredType)
+	if !slices.Contains(s.cfg.AllowedTypes, contentType) {
+		return nil, fmt.Errorf("%w: %s, expected one of %s", ErrAttachmentType, contentType, strings.Join(s.cfg.AllowedTypes, ", "))
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate attachment id: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	attachment := &model.Attachment{
+		ID:          id.String(),
+		TaskID:      taskID,
+		Filename:    cleanFilename(filename),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		SHA256:      hex.EncodeToString(sum[:]),
+		StorageKey:  "tasks/" + taskID + "/" + id.String(),
+		UploadedBy:  audit.Actor(ctx),
+	}
+
+	// Contents first, so a stored row always has a file behind it
+	if err := s.backend.Put(ctx, attachment.StorageKey, bytes.NewReader(data), attachment.Size, contentType); err != nil {
+		return nil, fmt.Errorf("failed to store attachment: %w", err)
+	}
+	created, err := s.repo.Create(ctx, attachment)
+	if err != nil {
+		s.discard(ctx, attachment.StorageKey)
+		return nil, fmt.Errorf("failed to create attachment: %w", err)
+	}
+
+	return created, nil
+}
+
+// Open returns an attachment of a live task and its contents, which the
+// caller must close
+func (s *AttachmentService) Open(ctx context.Context, taskID, id string) (*model.Attachment, io.ReadCloser, error) {
+	attachment, err := s.get(ctx, taskID, id, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	contents, err := s.backend.Open(ctx, attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			logger.Ctx(ctx).Error().Str("attachment_id", id).Str("key", attachment.StorageKey).Msg("Attachment contents missing from storage")
+			return nil, nil, ErrAttachmentNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to open attachment: %w", err)
+	}
+
+	return attachment, contents, nil
+}
+
+// Delete removes an attachment and its contents
+func (s *AttachmentService) Delete(ctx context.Context, taskID, id string) error {
+	attachment, err := s.get(ctx, taskID, id, true)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, taskID, id); err != nil {
+		if errors.Is(err, repository.ErrAttachmentNotFound) {
+			return ErrAttachmentNotFound
+		}
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+	s.discard(ctx, attachment.StorageKey)
+
+	return nil
+}
+
+// get returns an attachment after checking its task like checkTask
+func (s *AttachmentService) get(ctx context.Context, taskID, id string, write bool) (*model.Attachment, error) {
+	if err := s.checkTask(ctx, taskID, write); err != nil {
+		return nil, err
+	}
+	if !isValidID(id) {
+		return nil, ErrAttachmentNotFound
+	}
+
+	attachment, err := s.repo.GetByID(ctx, taskID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrAttachmentNotFound) {
+			return nil, ErrAttachmentNotFound
+		}
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+
+	return attachment, nil
+}
+
+// discard deletes contents that no row points to any more. Failures only
+// leave an orphaned file behind, so they are logged rather than returned.
+func (s *AttachmentService) discard(ctx context.Context, key string) {
+	if err := s.backend.Delete(context.WithoutCancel(ctx), key); err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Str("key", key).Msg("Failed to delete attachment contents, leaving an orphaned file")
+	}
+}
+
+// checkTask returns ErrTaskNotFound unless taskID is a live task and, for
+// changes, ErrArchived when it is archived
+func (s *AttachmentService) checkTask(ctx context.Context, taskID string, write bool) error {
+	if !isValidID(taskID) {
+		return ErrTaskNotFound
+	}
+
+	task, err := s.tasks.GetByID(ctx, taskID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotOwned) {
+			return denied(ErrTaskNotFound)
+		}
+		if errors.Is(err, repository.ErrTaskNotFound) {
+			return ErrTaskNotFound
+		}
+		return fmt.Errorf("failed to get task: %w", err)
+	}
+	if write && task.Archived {
+		return ErrArchived
+	}
+
+	return nil
+}
+
+// detectContentType sniffs the media type of data, without parameters.
+// The declared type is trusted only where sniffing is inconclusive: for
+// unrecognised binary data, and for text that may be a more specific
+// text type such as text/csv.
+func detectContentType(data []byte, declaredType string) string {
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	declared, _, err := mime.ParseMediaType(declaredType)
+	if err != nil || declared == "" {
+		return sniffed
+	}
+
+	switch {
+	case sniffed == "application/octet-stream":
+		return declared
+	case sniffed == "text/plain" && strings.HasPrefix(declared, "text/"):
+		return declared
+	}
+	return sniffed
+}
+
+// cleanFilename keeps the base name of an uploaded file without control
+// characters, at most 255 bytes long
+func cleanFilename(filename string) string {
+	filename = path.Base(strings.ReplaceAll(filename, "\\", "/"))
+	filename = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' {
+			return -1
+		}
+		return r
+	}, filename))
+	for len(filename) > 255 {
+		_, size := utf8.DecodeLastRuneInString(filename)
+		filename = filename[:len(filename)-size]
+	}
+	if filename == "" || filename == "." || filename == "/" {
+		return "attachment"
+	}
+	return filename
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/moabdelazem/mutlitier_app/internal/audit"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/internal/storage"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachmentService(t *testing.T) {
+	ctx := audit.WithActor(context.Background(), "alice")
+	repo := repository.NewMemoryTaskRepository(0)
+	events := NewEventService(repository.NewMemoryEventRepository(), kvstore.NewMemory(), &config.EventsConfig{})
+	tasks := NewTaskService(repo, nil, nil, events, nil, nil, nil, &config.TaskConfig{DefaultProject: "TASK"})
+	backend := storage.NewMemoryBackend()
+	cfg := &config.AttachmentConfig{MaxBytes: 64, MaxPerTask: 2, AllowedTypes: []string{"text/plain", "text/csv", "image/png"}}
+	svc := NewAttachmentService(repository.NewMemoryAttachmentRepository(), repo, backend, cfg)
+
+	task, err := tasks.Create(ctx, &model.CreateTaskRequest{Title: "Quarterly report"})
+	require.NoError(t, err)
+
+	// Plain text may be declared as a more specific text type
+	report, err := svc.Upload(ctx, task.ID, `C:\Users\alice\report.csv`, "text/csv", strings.NewReader("a,b\n1,2\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "report.csv", report.Filename)
+	assert.Equal(t, "text/csv", report.ContentType)
+	assert.Equal(t, int64(8), report.Size)
+	assert.Equal(t, "alice", report.UploadedBy)
+	assert.Len(t, report.SHA256, 64)
+
+	// Sniffed content wins over a declared type
+	_, err = svc.Upload(ctx, task.ID, "cat.png", "image/png", strings.NewReader("<html><script></script></html>"))
+	assert.ErrorIs(t, err, ErrAttachmentType)
+	_, err = svc.Upload(ctx, task.ID, "big.txt", "text/plain", strings.NewReader(strings.Repeat("x", 65)))
+	assert.ErrorIs(t, err, ErrAttachmentTooLarge)
+	_, err = svc.Upload(ctx, task.ID, "empty.txt", "text/plain", strings.NewReader(""))
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = svc.Upload(ctx, task.ID, "notes.txt", "", strings.NewReader("remember"))
+	require.NoError(t, err)
+	_, err = svc.Upload(ctx, task.ID, "third.txt", "", strings.NewReader("one too many"))
+	assert.ErrorIs(t, err, ErrValidation)
+
+	list, err := svc.List(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, list.Total)
+	assert.Equal(t, int64(16), list.TotalBytes)
+
+	attachment, contents, err := svc.Open(ctx, task.ID, report.ID)
+	require.NoError(t, err)
+	data, err := io.ReadAll(contents)
+	require.NoError(t, err)
+	contents.Close()
+	assert.Equal(t, "a,b\n1,2\n", string(data))
+	assert.Equal(t, report.ID, attachment.ID)
+
+	// Attachments of archived tasks are read-only
+	_, err = tasks.Archive(ctx, task.ID)
+	require.NoError(t, err)
+	assert.ErrorIs(t, svc.Delete(ctx, task.ID, report.ID), ErrArchived)
+	_, err = tasks.Unarchive(ctx, task.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.Delete(ctx, task.ID, report.ID))
+	_, _, err = svc.Open(ctx, task.ID, report.ID)
+	assert.ErrorIs(t, err, ErrAttachmentNotFound)
+	_, err = backend.Open(ctx, report.StorageKey)
+	assert.ErrorIs(t, err, storage.ErrNotFound, "contents are deleted with the row")
+
+	_, err = svc.List(ctx, "not-a-uuid")
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+}
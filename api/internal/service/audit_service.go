@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+	"github.com/moabdelazem/mutlitier_app/pkg/metrics"
+)
+
+// auditValueLimit is the longest string kept in an audit summary
+const auditValueLimit = 200
+
+// auditRedacted are substrings of field names whose values never reach
+// the audit log, such as issued tokens and passwords
+var auditRedacted = []string{"password", "secret", "token"}
+
+// AuditService keeps the audit log of mutations in the audit_logs table
+// and serves it to admins. Like security events, logs are queued by Record
+// and summarized and written in batches by Run, so auditing never holds a
+// request up; when the queue is full new logs are dropped.
+type AuditService struct {
+	store repository.AuditLogStore
+	guard *QueryGuard
+	cfg   *config.AuditConfig
+	queue chan *model.AuditLog
+}
+
+// NewAuditService creates a new AuditService
+func NewAuditService(store repository.AuditLogStore, guard *QueryGuard, cfg *config.AuditConfig) *AuditService {
+	return &AuditService{
+		store: store,
+		guard: guard,
+		cfg:   cfg,
+		queue: make(chan *model.AuditLog, max(cfg.BufferSize, 1)),
+	}
+}
+
+// Record queues an audit log for writing. Before and After may hold the
+// whole entity; they are cut down to a summary when written.
+func (s *AuditService) Record(ctx context.Context, log *model.AuditLog) {
+	if log.CreatedAt.IsZero() {
+		log.CreatedAt = time.Now().UTC()
+	}
+
+	select {
+	case s.queue <- log:
+		metrics.AuditLogs.WithLabelValues("recorded").Inc()
+	default:
+		metrics.AuditLogs.WithLabelValues("dropped").Inc()
+		logger.Ctx(ctx).WithComponent("audit").Warn().
+			Str("user", log.User).Str("endpoint", log.Endpoint).Str("request_id", log.RequestID).
+			Msg("Audit queue full, dropped audit log")
+	}
+}
+
+// Run writes queued logs in batches of up to AUDIT_BATCH_SIZE until stop is
+// cancelled, then flushes what is left using work
+func (s *AuditService) Run(stop, work context.Context) {
+	log := logger.Get().WithComponent("audit")
+	batch := make([]*model.AuditLog, 0, max(s.cfg.BatchSize, 1))
+
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.store.Append(ctx, batch); err != nil {
+			log.Error().Err(err).Int("logs", len(batch)).Msg("Failed to write audit logs")
+		}
+		batch = batch[:0]
+	}
+	add := func(entry *model.AuditLog) {
+		entry.Before, entry.After = summarizeChange(entry.Before, entry.After)
+		batch = append(batch, entry)
+	}
+
+	for {
+		select {
+		case <-stop.Done():
+			for {
+				select {
+				case entry := <-s.queue:
+					add(entry)
+					if len(batch) == cap(batch) {
+						flush(work)
+					}
+				default:
+					flush(work)
+					return
+				}
+			}
+		case entry := <-s.queue:
+			add(entry)
+			// Take whatever else is already queued before writing
+			for len(batch) < cap(batch) && len(s.queue) > 0 {
+				add(<-s.queue)
+			}
+			flush(work)
+		}
+	}
+}
+
+// PurgeEvery removes logs older than AUDIT_RETENTION on every interval
+// until ctx is done
+func (s *AuditService) PurgeEvery(ctx context.Context, interval time.Duration) {
+	log := logger.Get().WithComponent("audit")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.store.Purge(ctx, time.Now().Add(-s.cfg.Retention))
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to purge audit logs")
+				continue
+			}
+			if purged > 0 {
+				log.Debug().Int64("purged", purged).Msg("Purged expired audit logs")
+			}
+		}
+	}
+}
+
+// List returns a page of audit logs, newest first
+func (s *AuditService) List(ctx context.Context, filter *model.AuditLogFilter, opts *model.ListOptions) (*model.AuditLogListResponse, error) {
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrValidation)
+	}
+
+	if err := s.guard.CheckPage(opts); err != nil {
+		return nil, err
+	}
+
+	logs, err := s.store.List(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+	if logs == nil {
+		logs = []*model.AuditLog{}
+	}
+
+	total, err := s.store.Count(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count audit logs: %w", err)
+	}
+
+	return &model.AuditLogListResponse{
+		Data:       logs,
+		Pagination: listing.NewPagination(opts.Page, opts.PerPage, total),
+	}, nil
+}
+
+// summarizeChange cuts the states before and after a change down to their
+// top-level fields, leaving out nested objects and lists, long strings and
+// secrets. With both states only the fields that changed are kept. Values
+// that are not JSON objects are dropped.
+func summarizeChange(before, after json.RawMessage) (json.RawMessage, json.RawMessage) {
+	b, a := auditFields(before), auditFields(after)
+	if b != nil && a != nil {
+		for name, value := range maps.Clone(b) {
+			if other, ok := a[name]; ok && reflect.DeepEqual(value, other) {
+				delete(b, name)
+				delete(a, name)
+			}
+		}
+	}
+	return auditJSON(b), auditJSON(a)
+}
+
+// auditFields decodes the summarized fields of a JSON object, nil for
+// anything else
+func auditFields(raw json.RawMessage) map[string]any {
+	var fields map[string]any
+	if len(raw) == 0 || json.Unmarshal(raw, &fields) != nil || fields == nil {
+		return nil
+	}
+
+	for name, value := range fields {
+		switch v := value.(type) {
+		case map[string]any, []any:
+			delete(fields, name)
+		case string:
+			if len(v) > auditValueLimit {
+				fields[name] = v[:auditValueLimit] + "..."
+			}
+		}
+		for _, marker := range auditRedacted {
+			if strings.Contains(strings.ToLower(name), marker) {
+				fields[name] = "[redacted]"
+			}
+		}
+	}
+	return fields
+}
+
+func auditJSON(fields map[string]any) json.RawMessage {
+	if len(fields) == 0 {
+		return nil
+	}
+	encoded, err := json.Marshal(fields)
+	if err != nil {
+		return nil
+	}
+	return encoded
+}
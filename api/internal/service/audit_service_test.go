@@ -0,0 +1,31 @@
+package service
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarizeChange(t *testing.T) {
+	before := json.RawMessage(`{"id":"t-1","title":"Docs","status":"todo","tags":["a"],"version":1}`)
+	after := json.RawMessage(`{"id":"t-1","title":"Docs","status":"done","tags":["a","b"],"version":2}`)
+
+	// Only changed top-level fields are kept
+	b, a := summarizeChange(before, after)
+	assert.JSONEq(t, `{"status":"todo","version":1}`, string(b))
+	assert.JSONEq(t, `{"status":"done","version":2}`, string(a))
+
+	// Secrets are redacted and long values cut short
+	_, a = summarizeChange(nil, json.RawMessage(`{"id":"k-1","token":"tok_abc","name":"`+strings.Repeat("x", 300)+`"}`))
+	var fields map[string]string
+	assert.NoError(t, json.Unmarshal(a, &fields))
+	assert.Equal(t, "[redacted]", fields["token"])
+	assert.Len(t, fields["name"], auditValueLimit+3)
+
+	// Anything but an object is dropped
+	b, a = summarizeChange(json.RawMessage(`[1,2]`), json.RawMessage(`"ok"`))
+	assert.Nil(t, b)
+	assert.Nil(t, a)
+}
@@ -0,0 +1,457 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrUserExists          = errors.New("username is taken")
+	ErrInvalidCredentials  = errors.New("invalid username or password")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
+	ErrSessionNotFound     = errors.New("session not found")
+)
+
+// RefreshTokenPrefix starts every refresh token, like TokenPrefix does
+// API tokens
+const RefreshTokenPrefix = "mtr_"
+
+// sessionTouchInterval limits how often last_seen_at is written for a
+// session
+const sessionTouchInterval = time.Minute
+
+// SessionScopes are granted to users signed in with a password, the same
+// as users of the sign-in proxy
+var SessionScopes = []auth.Scope{auth.ScopeReadTasks, auth.ScopeWriteTasks, auth.ScopeReadStats}
+
+// AuthService registers users with a password, signs them in and verifies
+// the session tokens it issues. Session tokens are HS256 JWTs signed with
+// JWT_SECRET. They are short-lived and renewed with refresh tokens, which
+// are stored and so can be revoked. Each sign-in is also stored as a
+// session, which its tokens name and Verify looks up, so revoking a
+// session signs its device out on the next request. With tenants, users
+// only sign in and refresh in tenants they are members of.
+type AuthService struct {
+	users    repository.UserStore
+	refresh  repository.RefreshTokenStore
+	sessions repository.SessionStore
+	tenants  *TenantService
+	cfg      *config.JWTConfig
+	validate *validator.Validate
+
+	// dummyHash is compared against when the user does not exist, so an
+	// unknown username takes as long to reject as a wrong password
+	dummyHash []byte
+}
+
+// NewAuthService creates a new AuthService. tenants may be nil when every
+// user may sign in to every tenant, as without tenancy.
+func NewAuthService(users repository.UserStore, refresh repository.RefreshTokenStore, sessions repository.SessionStore, tenants *TenantService, cfg *config.JWTConfig) *AuthService {
+	validate := validator.New()
+	validate.RegisterValidation("username", func(fl validator.FieldLevel) bool {
+		return model.ValidUsername(fl.Field().String())
+	})
+
+	dummyHash, _ := bcrypt.GenerateFromPassword([]byte(rand.Text()), bcrypt.DefaultCost)
+
+	return &AuthService{
+		users:     users,
+		refresh:   refresh,
+		sessions:  sessions,
+		tenants:   tenants,
+		cfg:       cfg,
+		validate:  validate,
+		dummyHash: dummyHash,
+	}
+}
+
+// Register creates a user who signs in with a password. Names already
+// known, from API tokens or the sign-in proxy, cannot be registered, so
+// nobody can take over another user's tasks.
+func (s *AuthService) Register(ctx context.Context, req *model.RegisterRequest) (*model.UserResponse, error) {
+	req.Username = normalizeUsername(req.Username)
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, formatValidationErrors(err))
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	createdAt, err := s.users.Register(ctx, req.Username, string(hash))
+	if err != nil {
+		if errors.Is(err, repository.ErrUserExists) {
+			return nil, ErrUserExists
+		}
+		return nil, fmt.Errorf("failed to register user: %w", err)
+	}
+
+	return &model.UserResponse{Username: req.Username, CreatedAt: createdAt.UTC()}, nil
+}
+
+// Login checks a username and password and issues a session token and the
+// first refresh token of a new family. Any mismatch is
+// ErrInvalidCredentials, without saying which part was wrong.
+func (s *AuthService) Login(ctx context.Context, req *model.LoginRequest) (*model.SessionResponse, error) {
+	req.Username = normalizeUsername(req.Username)
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, formatValidationErrors(err))
+	}
+
+	hash, err := s.users.PasswordHash(ctx, req.Username)
+	if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+		return nil, fmt.Errorf("failed to get password: %w", err)
+	}
+	if err != nil {
+		bcrypt.CompareHashAndPassword(s.dummyHash, []byte(req.Password))
+		return nil, ErrInvalidCredentials
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(req.Password)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	return s.SignIn(ctx, req.Username)
+}
+
+// SignIn starts a session for user, whose credentials were checked by the
+// caller, from the client in ctx. It issues a session token and the first
+// refresh token of a new family, or returns ErrNotTenantMember when user
+// is not a member of the request's tenant.
+func (s *AuthService) SignIn(ctx context.Context, user string) (*model.SessionResponse, error) {
+	if err := s.member(ctx, user); err != nil {
+		return nil, err
+	}
+
+	family := uuid.NewString()
+	expiresAt := time.Now().UTC().Add(s.cfg.RefreshTTL).Truncate(time.Second)
+
+	client := auth.ClientFrom(ctx)
+	if err := s.sessions.Create(ctx, &model.Session{
+		ID:        family,
+		User:      user,
+		UserAgent: client.UserAgent,
+		IP:        client.IP,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	session, err := s.issue(ctx, user, family)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, token, err := newRefreshToken(user, family, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refresh.Create(ctx, token); err != nil {
+		return nil, fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	session.RefreshToken, session.RefreshExpiresAt = raw, token.ExpiresAt
+	return session, nil
+}
+
+// Refresh trades a refresh token for a new session token and the next
+// refresh token of its family, using the one given up. Every token of a
+// family expires when the first did, so a sign-in lasts JWT_REFRESH_TTL at
+// most. It returns the token's user, when known, along with the error.
+//
+// A used token coming back means it was copied: either the thief or the
+// user already refreshed with it. Which one cannot be told, so the user's
+// every family is revoked with ErrRefreshTokenReused and they sign in
+// again. An unknown, revoked or expired token is ErrInvalidRefreshToken,
+// and one of a user no longer in the tenant is ErrNotTenantMember.
+func (s *AuthService) Refresh(ctx context.Context, raw string) (*model.SessionResponse, string, error) {
+	if !strings.HasPrefix(raw, RefreshTokenPrefix) {
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	token, err := s.refresh.GetByHash(ctx, hashToken(raw))
+	if err != nil {
+		if errors.Is(err, repository.ErrRefreshTokenNotFound) {
+			return nil, "", ErrInvalidRefreshToken
+		}
+		return nil, "", fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	if token.UsedAt != nil {
+		return nil, token.User, s.reused(ctx, token)
+	}
+	if token.RevokedAt != nil || !token.ExpiresAt.After(time.Now()) {
+		return nil, token.User, ErrInvalidRefreshToken
+	}
+	if err := s.member(ctx, token.User); err != nil {
+		return nil, token.User, err
+	}
+	if err := s.resume(ctx, token); err != nil {
+		return nil, token.User, err
+	}
+
+	session, err := s.issue(ctx, token.User, token.FamilyID)
+	if err != nil {
+		return nil, token.User, err
+	}
+
+	next, nextToken, err := newRefreshToken(token.User, token.FamilyID, token.ExpiresAt)
+	if err != nil {
+		return nil, token.User, err
+	}
+	if err := s.refresh.Rotate(ctx, token.ID, nextToken); err != nil {
+		// Another refresh with the same token got there first, which
+		// is reuse as much as a later one would be
+		if errors.Is(err, repository.ErrRefreshTokenUsed) {
+			return nil, token.User, s.reused(ctx, token)
+		}
+		return nil, token.User, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	session.RefreshToken, session.RefreshExpiresAt = next, nextToken.ExpiresAt.UTC()
+	return session, token.User, nil
+}
+
+// member returns ErrNotTenantMember unless user may sign in to the
+// request's tenant
+func (s *AuthService) member(ctx context.Context, user string) error {
+	if s.tenants == nil {
+		return nil
+	}
+	return s.tenants.Check(ctx, user)
+}
+
+// resume records a refresh on the session of token's family, from the
+// client in ctx. Sign-ins from before sessions were stored get one now.
+func (s *AuthService) resume(ctx context.Context, token *model.RefreshToken) error {
+	client := auth.ClientFrom(ctx)
+	now := time.Now().UTC()
+
+	session, err := s.sessions.Get(ctx, token.FamilyID)
+	if errors.Is(err, repository.ErrSessionNotFound) {
+		err = s.sessions.Create(ctx, &model.Session{
+			ID:        token.FamilyID,
+			User:      token.User,
+			UserAgent: client.UserAgent,
+			IP:        client.IP,
+			ExpiresAt: token.ExpiresAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create session: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+	if session.RevokedAt != nil {
+		return ErrInvalidRefreshToken
+	}
+
+	if err := s.sessions.Touch(ctx, session.ID, client.IP, now); err != nil {
+		return fmt.Errorf("failed to touch session: %w", err)
+	}
+	return nil
+}
+
+// reused revokes every session and refresh token of the user token belongs
+// to and returns ErrRefreshTokenReused
+func (s *AuthService) reused(ctx context.Context, token *model.RefreshToken) error {
+	now := time.Now().UTC()
+	if _, err := s.sessions.RevokeUser(ctx, repository.OwnedBy(token.User), "", now); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	families, err := s.refresh.RevokeUser(ctx, repository.OwnedBy(token.User), now)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	logger.Ctx(ctx).Warn().
+		Str("user", token.User).
+		Str("family_id", token.FamilyID).
+		Int("families_revoked", families).
+		Msg("Used refresh token presented again, revoked all of the user's sessions")
+	return ErrRefreshTokenReused
+}
+
+// Logout revokes the session of a refresh token and its family, ending
+// the sign-in it came from along with the session tokens issued for it.
+// Unknown tokens are ignored, so logging out twice is fine. It returns the
+// token's user, empty when unknown.
+func (s *AuthService) Logout(ctx context.Context, raw string) (string, error) {
+	if !strings.HasPrefix(raw, RefreshTokenPrefix) {
+		return "", nil
+	}
+
+	token, err := s.refresh.GetByHash(ctx, hashToken(raw))
+	if err != nil {
+		if errors.Is(err, repository.ErrRefreshTokenNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	if err := s.end(ctx, token.User, token.FamilyID); err != nil && !errors.Is(err, ErrSessionNotFound) {
+		return "", err
+	}
+
+	return token.User, nil
+}
+
+// Sessions returns the active sessions of principal's user, marking the
+// one principal signed in with as current
+func (s *AuthService) Sessions(ctx context.Context, principal *auth.Principal) (*model.SessionListResponse, error) {
+	sessions, err := s.sessions.List(ctx, repository.OwnedBy(principal.User))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	infos := make([]*model.SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		infos = append(infos, session.ToInfo(principal.SessionID))
+	}
+
+	return &model.SessionListResponse{Data: infos}, nil
+}
+
+// RevokeSession signs one of the user's sessions out. Another user's
+// session is ErrForbidden, which also matches ErrSessionNotFound.
+func (s *AuthService) RevokeSession(ctx context.Context, user, id string) error {
+	if !isValidID(id) {
+		return ErrSessionNotFound
+	}
+	return s.end(ctx, user, id)
+}
+
+// RevokeSessions signs every session of the user out but keep, which may
+// be empty, and returns how many it ended
+func (s *AuthService) RevokeSessions(ctx context.Context, user, keep string) (int, error) {
+	now := time.Now().UTC()
+	ids, err := s.sessions.RevokeUser(ctx, repository.OwnedBy(user), keep, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	for _, id := range ids {
+		if err := s.refresh.RevokeFamily(ctx, id, now); err != nil {
+			return 0, fmt.Errorf("failed to revoke refresh tokens: %w", err)
+		}
+	}
+	return len(ids), nil
+}
+
+// end revokes session id of user and its refresh token family
+func (s *AuthService) end(ctx context.Context, user, id string) error {
+	now := time.Now().UTC()
+	if err := s.sessions.Revoke(ctx, repository.OwnedBy(user), id, now); err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return ErrSessionNotFound
+		}
+		if errors.Is(err, repository.ErrNotOwned) {
+			return denied(ErrSessionNotFound)
+		}
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if err := s.refresh.RevokeFamily(ctx, id, now); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// issue signs a session token for user's session, bound to the request's
+// tenant
+func (s *AuthService) issue(ctx context.Context, user, session string) (*model.SessionResponse, error) {
+	now := time.Now().UTC()
+	expiresAt := now.Add(s.cfg.TTL)
+
+	token, err := auth.SignJWT(&auth.Claims{
+		ID:        rand.Text(),
+		Issuer:    s.cfg.Issuer,
+		Subject:   user,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+		Tenant:    tenant.From(ctx),
+		Session:   session,
+	}, []byte(s.cfg.Secret))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign session token: %w", err)
+	}
+
+	return &model.SessionResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.cfg.TTL.Seconds()),
+		ExpiresAt:   time.Unix(expiresAt.Unix(), 0).UTC(),
+	}, nil
+}
+
+// newRefreshToken returns a new raw refresh token for user in family and
+// the record to store for it
+func newRefreshToken(user, family string, expiresAt time.Time) (string, *model.RefreshToken, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	raw := RefreshTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	return raw, &model.RefreshToken{
+		ID:        uuid.NewString(),
+		User:      user,
+		FamilyID:  family,
+		Hash:      hashToken(raw),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// Verify returns the principal a session token authenticates, or
+// auth.ErrInvalidToken when it is forged, expired, from another issuer or
+// its session was revoked. Tokens issued before sessions were stored name
+// none and are refused too; refreshing them issues one that does.
+func (s *AuthService) Verify(ctx context.Context, token string) (*auth.Principal, error) {
+	now := time.Now()
+	claims, err := auth.ParseJWT(token, []byte(s.cfg.Secret), s.cfg.Issuer, now)
+	if err != nil {
+		return nil, err
+	}
+	if !isValidID(claims.Session) {
+		return nil, auth.ErrInvalidToken
+	}
+
+	session, err := s.sessions.Get(ctx, claims.Session)
+	if err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return nil, auth.ErrInvalidToken
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if session.User != claims.Subject || session.RevokedAt != nil || !session.ExpiresAt.After(now) {
+		return nil, auth.ErrInvalidToken
+	}
+
+	// Best effort, a missed update only makes last_seen_at less precise
+	if now.Sub(session.LastSeenAt) >= sessionTouchInterval {
+		_ = s.sessions.Touch(ctx, session.ID, "", now.UTC())
+	}
+
+	return &auth.Principal{User: claims.Subject, SessionID: session.ID, Scopes: SessionScopes, Tenant: claims.Tenant}, nil
+}
+
+// normalizeUsername makes usernames case-insensitive
+func normalizeUsername(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
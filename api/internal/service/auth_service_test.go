@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthService(t *testing.T) {
+	ctx := context.Background()
+	users := repository.NewMemoryUserRepository()
+	cfg := &config.JWTConfig{Secret: strings.Repeat("s", 32), Issuer: "tasks-api", TTL: time.Minute, RefreshTTL: time.Hour}
+	svc := NewAuthService(users, repository.NewMemoryRefreshTokenRepository(), repository.NewMemorySessionRepository(), nil, cfg)
+
+	user, err := svc.Register(ctx, &model.RegisterRequest{Username: " Alice ", Password: "correct horse"})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user.Username)
+
+	// Taken names, including users only known from tokens or the proxy
+	_, err = svc.Register(ctx, &model.RegisterRequest{Username: "alice", Password: "another one"})
+	assert.ErrorIs(t, err, ErrUserExists)
+	require.NoError(t, users.Touch(ctx, "bob", time.Now()))
+	_, err = svc.Register(ctx, &model.RegisterRequest{Username: "bob", Password: "correct horse"})
+	assert.ErrorIs(t, err, ErrUserExists)
+	_, err = svc.Register(ctx, &model.RegisterRequest{Username: "admin", Password: "correct horse"})
+	assert.ErrorIs(t, err, ErrValidation)
+	_, err = svc.Register(ctx, &model.RegisterRequest{Username: "carol", Password: "short"})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = svc.Login(ctx, &model.LoginRequest{Username: "alice", Password: "wrong horse"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = svc.Login(ctx, &model.LoginRequest{Username: "bob", Password: "correct horse"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	session, err := svc.Login(ctx, &model.LoginRequest{Username: "ALICE", Password: "correct horse"})
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer", session.TokenType)
+	assert.EqualValues(t, 60, session.ExpiresIn)
+
+	principal, err := svc.Verify(ctx, session.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", principal.User)
+	assert.True(t, principal.Has(auth.ScopeWriteTasks))
+	assert.False(t, principal.Has(auth.ScopeAdmin))
+
+	// Tampered, foreign and expired tokens are all just invalid
+	header, rest, _ := strings.Cut(session.AccessToken, ".")
+	_, signature, _ := strings.Cut(rest, ".")
+	forged, _ := auth.SignJWT(&auth.Claims{Issuer: "tasks-api", Subject: "admin", ExpiresAt: time.Now().Add(time.Hour).Unix()}, []byte("guessed"))
+	payload := strings.Split(forged, ".")[1]
+	for _, token := range []string{
+		header + "." + payload + "." + signature,
+		forged,
+		`eyJhbGciOiJub25lIiwidHlwIjoiSldUIn0.` + payload + `.`,
+	} {
+		_, err = svc.Verify(ctx, token)
+		assert.ErrorIs(t, err, auth.ErrInvalidToken)
+	}
+
+	claims, err := auth.ParseJWT(session.AccessToken, []byte(cfg.Secret), "tasks-api", time.Now())
+	require.NoError(t, err)
+	_, err = auth.ParseJWT(session.AccessToken, []byte(cfg.Secret), "tasks-api", time.Unix(claims.ExpiresAt, 0))
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+	_, err = auth.ParseJWT(session.AccessToken, []byte(cfg.Secret), "other-api", time.Now())
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+}
+
+func TestAuthServiceRefresh(t *testing.T) {
+	ctx := context.Background()
+	refresh := repository.NewMemoryRefreshTokenRepository()
+	cfg := &config.JWTConfig{Secret: strings.Repeat("s", 32), Issuer: "tasks-api", TTL: time.Minute, RefreshTTL: time.Hour}
+	svc := NewAuthService(repository.NewMemoryUserRepository(), refresh, repository.NewMemorySessionRepository(), nil, cfg)
+
+	_, err := svc.Register(ctx, &model.RegisterRequest{Username: "alice", Password: "correct horse"})
+	require.NoError(t, err)
+	login := func() *model.SessionResponse {
+		session, err := svc.Login(ctx, &model.LoginRequest{Username: "alice", Password: "correct horse"})
+		require.NoError(t, err)
+		return session
+	}
+
+	first := login()
+	assert.True(t, strings.HasPrefix(first.RefreshToken, RefreshTokenPrefix))
+
+	// Each refresh gives up the token for the next of the same family,
+	// expiring when the first one does
+	second, user, err := svc.Refresh(ctx, first.RefreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+	assert.True(t, first.RefreshExpiresAt.Equal(second.RefreshExpiresAt))
+	principal, err := svc.Verify(ctx, second.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", principal.User)
+
+	// Logging out ends the family and its session tokens, twice is fine
+	user, err = svc.Logout(ctx, second.RefreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user)
+	_, _, err = svc.Refresh(ctx, second.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	_, err = svc.Verify(ctx, second.AccessToken)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+	user, err = svc.Logout(ctx, second.RefreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user)
+	user, err = svc.Logout(ctx, RefreshTokenPrefix+"unknown")
+	require.NoError(t, err)
+	assert.Empty(t, user)
+
+	for _, raw := range []string{"", "mtp_abc", RefreshTokenPrefix + "unknown"} {
+		_, _, err = svc.Refresh(ctx, raw)
+		assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	}
+
+	// Reusing a used token revokes every session of the user, the thief's
+	// and the user's own
+	stolen, other := login(), login()
+	rotated, _, err := svc.Refresh(ctx, stolen.RefreshToken)
+	require.NoError(t, err)
+	_, user, err = svc.Refresh(ctx, stolen.RefreshToken)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+	assert.Equal(t, "alice", user)
+	for _, raw := range []string{rotated.RefreshToken, other.RefreshToken} {
+		_, _, err = svc.Refresh(ctx, raw)
+		assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	}
+
+	// Expired tokens cannot be refreshed
+	cfg.RefreshTTL = -time.Second
+	_, _, err = svc.Refresh(ctx, login().RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestAuthServiceSessions(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.JWTConfig{Secret: strings.Repeat("s", 32), Issuer: "tasks-api", TTL: time.Minute, RefreshTTL: time.Hour}
+	svc := NewAuthService(repository.NewMemoryUserRepository(), repository.NewMemoryRefreshTokenRepository(), repository.NewMemorySessionRepository(), nil, cfg)
+
+	signIn := func(user, agent string) (*model.SessionResponse, *auth.Principal) {
+		session, err := svc.SignIn(auth.WithClient(ctx, auth.Client{IP: "192.0.2.1", UserAgent: agent}), user)
+		require.NoError(t, err)
+		principal, err := svc.Verify(ctx, session.AccessToken)
+		require.NoError(t, err)
+		return session, principal
+	}
+	laptop, current := signIn("alice", "laptop")
+	phone, phoneSession := signIn("alice", "phone")
+	tablet, _ := signIn("alice", "tablet")
+	_, bob := signIn("bob", "laptop")
+
+	list, err := svc.Sessions(ctx, current)
+	require.NoError(t, err)
+	require.Len(t, list.Data, 3)
+	for _, session := range list.Data {
+		assert.Equal(t, "192.0.2.1", session.IP)
+		assert.Equal(t, session.UserAgent == "laptop", session.Current, session.UserAgent)
+	}
+
+	// Revoking a session refuses its session tokens at once and ends its
+	// refresh tokens; another user's session is forbidden
+	require.NoError(t, svc.RevokeSession(ctx, "alice", phoneSession.SessionID))
+	_, err = svc.Verify(ctx, phone.AccessToken)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+	_, _, err = svc.Refresh(ctx, phone.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	assert.ErrorIs(t, svc.RevokeSession(ctx, "alice", bob.SessionID), ErrForbidden)
+	assert.ErrorIs(t, svc.RevokeSession(ctx, "alice", phoneSession.SessionID), ErrSessionNotFound)
+	assert.ErrorIs(t, svc.RevokeSession(ctx, "alice", "not-a-uuid"), ErrSessionNotFound)
+
+	// Signing out everywhere else keeps the current session
+	revoked, err := svc.RevokeSessions(ctx, "alice", current.SessionID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, revoked)
+	_, err = svc.Verify(ctx, tablet.AccessToken)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+	_, err = svc.Verify(ctx, laptop.AccessToken)
+	assert.NoError(t, err)
+
+	revoked, err = svc.RevokeSessions(ctx, "alice", "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, revoked)
+	list, err = svc.Sessions(ctx, current)
+	require.NoError(t, err)
+	assert.Empty(t, list.Data)
+}
+
+func TestAuthServiceRefreshRace(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.JWTConfig{Secret: strings.Repeat("s", 32), Issuer: "tasks-api", TTL: time.Minute, RefreshTTL: time.Hour}
+	svc := NewAuthService(repository.NewMemoryUserRepository(), repository.NewMemoryRefreshTokenRepository(), repository.NewMemorySessionRepository(), nil, cfg)
+
+	_, err := svc.Register(ctx, &model.RegisterRequest{Username: "alice", Password: "correct horse"})
+	require.NoError(t, err)
+	session, err := svc.Login(ctx, &model.LoginRequest{Username: "alice", Password: "correct horse"})
+	require.NoError(t, err)
+
+	// Of refreshes racing with the same token, at most one wins
+	var wg sync.WaitGroup
+	var wins atomic.Int32
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := svc.Refresh(ctx, session.RefreshToken); err == nil {
+				wins.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, wins.Load(), int32(1))
+}
+
+func TestAuthServiceTenantMembers(t *testing.T) {
+	ctx := context.Background()
+	members := repository.NewMemoryMemberRepository()
+	tokens := repository.NewMemoryTokenRepository()
+	refresh := repository.NewMemoryRefreshTokenRepository()
+	sessions := repository.NewMemorySessionRepository()
+	tenants := NewTenantService(members, tokens, refresh, sessions, &config.TenancyConfig{Enabled: true, Default: "default"})
+	cfg := &config.JWTConfig{Secret: strings.Repeat("s", 32), Issuer: "tasks-api", TTL: time.Minute, RefreshTTL: time.Hour}
+	svc := NewAuthService(repository.NewMemoryUserRepository(), refresh, sessions, tenants, cfg)
+
+	_, err := svc.Register(ctx, &model.RegisterRequest{Username: "alice", Password: "correct horse"})
+	require.NoError(t, err)
+	login := &model.LoginRequest{Username: "alice", Password: "correct horse"}
+
+	// Everyone may sign in to the default tenant, only members to others
+	_, err = svc.Login(tenant.With(ctx, "default"), login)
+	require.NoError(t, err)
+	acme := tenant.With(ctx, "acme")
+	_, err = svc.Login(acme, login)
+	assert.ErrorIs(t, err, ErrNotTenantMember)
+
+	_, err = tenants.Add(ctx, "acme", "Alice")
+	require.NoError(t, err)
+	session, err := svc.Login(acme, login)
+	require.NoError(t, err)
+	_, err = tokens.Create(acme, &model.APIToken{ID: "t1", User: "alice", Tenant: "acme"})
+	require.NoError(t, err)
+
+	// Removal signs the member out of the tenant
+	require.NoError(t, tenants.Remove(ctx, "acme", "alice"))
+	assert.ErrorIs(t, tenants.Remove(ctx, "acme", "alice"), ErrMemberNotFound)
+	_, err = svc.Verify(acme, session.AccessToken)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+	_, _, err = svc.Refresh(acme, session.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	_, err = tokens.Get(acme, repository.OwnedBy("alice"), "t1")
+	assert.ErrorIs(t, err, repository.ErrTokenNotFound)
+
+	_, err = tenants.Add(ctx, "Not_A_Tenant", "alice")
+	assert.ErrorIs(t, err, ErrValidation)
+}
@@ -0,0 +1,667 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/mutlitier_app/internal/audit"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+// AutomationActor is who the changes automation rules make are attributed
+// to. Events by this actor never fire rules, which keeps rules from
+// triggering each other in a loop.
+const AutomationActor = "automation"
+
+const (
+	automationCursorKey = "automation:cursor"
+	automationLeaseKey  = "automation:lease"
+
+	// automationLeaseTTL bounds how long rules stall when the replica
+	// holding the lease dies without releasing it
+	automationLeaseTTL = 30 * time.Second
+
+	// automationCursorTTL outlives any realistic outage; a lost cursor
+	// restarts the engine from the newest event
+	automationCursorTTL = 7 * 24 * time.Hour
+)
+
+var (
+	ErrAutomationRuleNotFound = errors.New("automation rule not found")
+)
+
+// AutomationService manages the automation rules of projects and runs
+// them. Like NotificationFanout it follows the task event log, one replica
+// at a time holding a lease; each run is claimed in the execution log
+// first, so an event handled again after a crash does not run a rule
+// twice.
+type AutomationService struct {
+	store    repository.AutomationStore
+	tasks    *TaskService
+	tags     *TagService
+	comments *CommentService
+	events   *EventService
+	state    kvstore.Store
+	guard    *QueryGuard
+	cfg      *config.AutomationConfig
+	validate *validator.Validate
+	client   *http.Client
+	lease    *lease
+}
+
+// NewAutomationService creates a new AutomationService
+func NewAutomationService(store repository.AutomationStore, tasks *TaskService, tags *TagService, comments *CommentService, events *EventService, state kvstore.Store, guard *QueryGuard, cfg *config.AutomationConfig) *AutomationService {
+	validate := validator.New()
+	validate.RegisterValidation("task_status", func(fl validator.FieldLevel) bool {
+		return model.Status(fl.Field().String()).Valid()
+	})
+
+	return &AutomationService{
+		store:    store,
+		tasks:    tasks,
+		tags:     tags,
+		comments: comments,
+		events:   events,
+		state:    state,
+		guard:    guard,
+		cfg:      cfg,
+		validate: validate,
+		client:   &http.Client{Timeout: cfg.WebhookTimeout},
+		lease:    newLease(state, automationLeaseKey, automationLeaseTTL),
+	}
+}
+
+// CreateRule adds an automation rule to a project
+func (s *AutomationService) CreateRule(ctx context.Context, project string, req *model.AutomationRuleRequest) (*model.AutomationRule, error) {
+	if !model.ValidProjectKey(project) {
+		return nil, ErrProjectNotFound
+	}
+	rule, err := s.rule(req)
+	if err != nil {
+		return nil, err
+	}
+	rule.ID = uuid.NewString()
+	rule.ProjectKey = project
+	rule.CreatedBy = audit.Actor(ctx)
+	rule.Tenant = tenant.From(ctx)
+	if rule.Tenant == "" {
+		rule.Tenant = tenant.Default
+	}
+	if scope, ok := repository.ScopeFrom(ctx); ok {
+		// The rule runs as its creator, on the tasks they reach
+		if scope.Anonymous() {
+			return nil, fmt.Errorf("%w: sign in to create automation rules", ErrAnonymous)
+		}
+		owner := scope.Owner()
+		rule.Owner = &owner
+	}
+
+	created, err := s.store.CreateAutomationRule(ctx, rule)
+	if err != nil {
+		if errors.Is(err, repository.ErrProjectNotFound) {
+			return nil, ErrProjectNotFound
+		}
+		return nil, fmt.Errorf("failed to create automation rule: %w", err)
+	}
+
+	return created, nil
+}
+
+// ListRules returns the automation rules of a project, oldest first
+func (s *AutomationService) ListRules(ctx context.Context, project string) (*model.AutomationRuleListResponse, error) {
+	rules, err := s.store.ListAutomationRules(ctx, project)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list automation rules: %w", err)
+	}
+	if rules == nil {
+		rules = []*model.AutomationRule{}
+	}
+
+	return &model.AutomationRuleListResponse{Data: rules}, nil
+}
+
+// GetRule returns one of a project's automation rules
+func (s *AutomationService) GetRule(ctx context.Context, project, id string) (*model.AutomationRule, error) {
+	if !isValidID(id) {
+		return nil, ErrAutomationRuleNotFound
+	}
+
+	rule, err := s.store.GetAutomationRule(ctx, project, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrAutomationRuleNotFound) {
+			return nil, ErrAutomationRuleNotFound
+		}
+		return nil, fmt.Errorf("failed to get automation rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// UpdateRule replaces one of a project's automation rules
+func (s *AutomationService) UpdateRule(ctx context.Context, project, id string, req *model.AutomationRuleRequest) (*model.AutomationRule, error) {
+	if !isValidID(id) {
+		return nil, ErrAutomationRuleNotFound
+	}
+	rule, err := s.rule(req)
+	if err != nil {
+		return nil, err
+	}
+	rule.ID = id
+	rule.ProjectKey = project
+
+	updated, err := s.store.UpdateAutomationRule(ctx, rule)
+	if err != nil {
+		if errors.Is(err, repository.ErrAutomationRuleNotFound) {
+			return nil, ErrAutomationRuleNotFound
+		}
+		return nil, fmt.Errorf("failed to update automation rule: %w", err)
+	}
+
+	return updated, nil
+}
+
+// DeleteRule removes one of a project's automation rules. Its runs stay
+// in the execution log.
+func (s *AutomationService) DeleteRule(ctx context.Context, project, id string) error {
+	if !isValidID(id) {
+		return ErrAutomationRuleNotFound
+	}
+
+	if err := s.store.DeleteAutomationRule(ctx, project, id); err != nil {
+		if errors.Is(err, repository.ErrAutomationRuleNotFound) {
+			return ErrAutomationRuleNotFound
+		}
+		return fmt.Errorf("failed to delete automation rule: %w", err)
+	}
+
+	return nil
+}
+
+// ListRuns returns a page of the execution log of a project's rules,
+// newest first
+func (s *AutomationService) ListRuns(ctx context.Context, project string, opts *model.ListOptions) (*model.AutomationRunListResponse, error) {
+	if err := s.guard.CheckPage(opts); err != nil {
+		return nil, err
+	}
+
+	runs, err := s.store.ListAutomationRuns(ctx, project, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list automation runs: %w", err)
+	}
+	if runs == nil {
+		runs = []*model.AutomationRun{}
+	}
+
+	total, err := s.store.CountAutomationRuns(ctx, project)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count automation runs: %w", err)
+	}
+
+	return &model.AutomationRunListResponse{
+		Data:       runs,
+		Pagination: listing.NewPagination(opts.Page, opts.PerPage, total),
+	}, nil
+}
+
+// rule validates a request and returns the rule it describes
+func (s *AutomationService) rule(req *model.AutomationRuleRequest) (*model.AutomationRule, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, formatValidationErrors(err))
+	}
+
+	trigger := req.Trigger
+	trigger.Tag = normalizeTagName(trigger.Tag)
+	if trigger.Tag != "" && (trigger.Type != model.AutomationTagAdded || !model.ValidTagName(trigger.Tag)) {
+		return nil, fmt.Errorf("%w: trigger.tag must be a valid tag name of a tag_added trigger", ErrValidation)
+	}
+	if (len(trigger.From) > 0 || len(trigger.To) > 0) && trigger.Type != model.AutomationStatusChanged {
+		return nil, fmt.Errorf("%w: trigger.from and trigger.to only apply to status_changed", ErrValidation)
+	}
+
+	conditions := slices.Clone(req.Conditions)
+	if conditions == nil {
+		conditions = []model.AutomationCondition{}
+	}
+	for i := range conditions {
+		if conditions[i].Field == "tag" {
+			conditions[i].Values = slices.Clone(conditions[i].Values)
+			for j, value := range conditions[i].Values {
+				conditions[i].Values[j] = normalizeTagName(value)
+			}
+		}
+	}
+
+	actions := slices.Clone(req.Actions)
+	for i := range actions {
+		if err := s.checkAction(&actions[i]); err != nil {
+			return nil, fmt.Errorf("%w: actions[%d]: %s", ErrValidation, i, err)
+		}
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	return &model.AutomationRule{
+		Name:       strings.TrimSpace(req.Name),
+		Trigger:    trigger,
+		Conditions: conditions,
+		Actions:    actions,
+		Enabled:    enabled,
+	}, nil
+}
+
+// checkAction checks that an action has what its type needs and nothing
+// else, normalizing its values
+func (s *AutomationService) checkAction(action *model.AutomationAction) error {
+	var set int
+	for _, value := range []string{action.Assignee, action.Tag, action.URL, action.Body} {
+		if value != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return errors.New("an action sets exactly the one field its type uses")
+	}
+
+	switch action.Type {
+	case model.AutomationAssign:
+		action.Assignee = normalizeUsername(action.Assignee)
+		if !model.ValidUsername(action.Assignee) {
+			return errors.New("assign needs a valid assignee username")
+		}
+	case model.AutomationLabel:
+		action.Tag = normalizeTagName(action.Tag)
+		if !model.ValidTagName(action.Tag) {
+			return errors.New("label needs a valid tag name")
+		}
+	case model.AutomationComment:
+		action.Body = strings.TrimSpace(action.Body)
+		if action.Body == "" {
+			return errors.New("comment needs a body")
+		}
+	case model.AutomationWebhook:
+		target, err := url.Parse(action.URL)
+		if err != nil || (target.Scheme != "https" && target.Scheme != "http") || target.Host == "" {
+			return errors.New("webhook needs an http or https url")
+		}
+		if !slices.Contains(s.cfg.WebhookHosts, "*") && !slices.Contains(s.cfg.WebhookHosts, target.Hostname()) {
+			return fmt.Errorf("webhook host %s is not in AUTOMATION_WEBHOOK_HOSTS", target.Hostname())
+		}
+	}
+	return nil
+}
+
+// Run runs the rules on new events and passed due dates until stop is
+// done, waking on local events and every AUTOMATION_POLL_INTERVAL. Work
+// in progress when stop fires finishes using work.
+func (s *AutomationService) Run(stop, work context.Context) {
+	log := logger.Get().WithComponent("automation")
+
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+	defer s.lease.release()
+
+	for {
+		changed := s.events.Changed()
+
+		if err := s.Automate(work); err != nil && work.Err() == nil {
+			log.Error().Err(err).Msg("Failed to run automation rules")
+		}
+
+		select {
+		case <-stop.Done():
+			return
+		case <-changed:
+		case <-ticker.C:
+		}
+	}
+}
+
+// Automate runs the rules fired by the events after the cursor and by the
+// due dates passed since the last check, if this replica holds the lease.
+// Without a cursor it starts from the newest event, so old changes do not
+// fire rules.
+func (s *AutomationService) Automate(ctx context.Context) error {
+	leader, err := s.lease.acquire(ctx)
+	if err != nil || !leader {
+		return err
+	}
+
+	rules, err := s.store.ListEnabledAutomationRules(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list automation rules: %w", err)
+	}
+
+	ctx = audit.WithActor(ctx, AutomationActor)
+	if err := s.followEvents(ctx, rules); err != nil {
+		return err
+	}
+	return s.passDueDates(ctx, rules)
+}
+
+// followEvents runs the event-triggered rules on the events after the
+// cursor. A rule only fires on the events of its tenant's tasks that its
+// owner reaches.
+func (s *AutomationService) followEvents(ctx context.Context, rules []*model.AutomationRule) error {
+	cursor, ok, err := s.loadCursor(ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		latest, err := s.events.Latest(ctx)
+		if err != nil {
+			return err
+		}
+		return s.saveCursor(ctx, latest)
+	}
+
+	byProject := map[string][]*model.AutomationRule{}
+	for _, rule := range rules {
+		if rule.Trigger.Type != model.AutomationDueDatePassed {
+			byProject[rule.ProjectKey] = append(byProject[rule.ProjectKey], rule)
+		}
+	}
+
+	for {
+		reached := map[string]bool{}
+		events, gap, err := s.events.After(ctx, cursor, s.cfg.BatchSize)
+		if err != nil {
+			return err
+		}
+		if gap {
+			logger.Get().Warn().Int64("cursor", cursor).Msg("Automation fell behind the event log, some events did not run rules")
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		// The state of each task before an event is its previous event
+		previous := map[string]*model.TaskResponse{}
+		for _, event := range events {
+			before, seen := previous[event.TaskID]
+			if !seen {
+				prior, err := s.events.Previous(ctx, event.TaskID, event.ID)
+				if err != nil {
+					return err
+				}
+				if prior != nil {
+					before = prior.Task
+				}
+			}
+			previous[event.TaskID] = event.Task
+
+			if event.Task == nil || event.Actor == AutomationActor {
+				continue
+			}
+			ref, err := model.ParseRef(event.Task.Ref)
+			if err != nil {
+				continue
+			}
+			for _, rule := range byProject[ref.ProjectKey] {
+				if rule.Tenant != event.Tenant || !fires(rule, event, before) || !rule.Applies(event.Task) {
+					continue
+				}
+				ruleCtx := ruleContext(ctx, rule)
+				ok, err := s.reaches(ruleCtx, rule, event.TaskID, reached)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					continue
+				}
+				if err := s.execute(ruleCtx, rule, event.Task, &model.AutomationRun{EventID: &event.ID}); err != nil {
+					return err
+				}
+			}
+		}
+
+		cursor = events[len(events)-1].ID
+		if err := s.saveCursor(ctx, cursor); err != nil {
+			return err
+		}
+		if len(events) < s.cfg.BatchSize {
+			return nil
+		}
+	}
+}
+
+// ruleContext returns the context rule runs in: its tenant, and the scope
+// of its owner when it has one, so the stores and the actions only reach
+// what the rule's creator could
+func ruleContext(ctx context.Context, rule *model.AutomationRule) context.Context {
+	ctx = tenant.With(ctx, rule.Tenant)
+	if rule.Owner != nil {
+		ctx = repository.WithScope(ctx, repository.OwnedBy(*rule.Owner))
+	}
+	return ctx
+}
+
+// reaches reports whether the owner of rule reaches task id, remembering
+// the answer in reached for the rest of the batch. Rules without an owner
+// reach every task of their tenant.
+func (s *AutomationService) reaches(ctx context.Context, rule *model.AutomationRule, id string, reached map[string]bool) (bool, error) {
+	if rule.Owner == nil {
+		return true, nil
+	}
+	key := rule.Tenant + "/" + *rule.Owner + "/" + id
+	if ok, seen := reached[key]; seen {
+		return ok, nil
+	}
+
+	_, err := s.tasks.GetByID(ctx, id)
+	if err != nil && !errors.Is(err, ErrTaskNotFound) {
+		return false, err
+	}
+	reached[key] = err == nil
+	return err == nil, nil
+}
+
+// fires reports whether event fires rule, given the task before it
+func fires(rule *model.AutomationRule, event *model.TaskEvent, before *model.TaskResponse) bool {
+	switch rule.Trigger.Type {
+	case model.AutomationStatusChanged:
+		return before != nil && rule.Trigger.MatchesStatus(before.Status, event.Task.Status)
+	case model.AutomationTagAdded:
+		if before == nil && event.Type != model.EventTaskCreated {
+			// Without the earlier state the tags that were added are unknown
+			return false
+		}
+		for _, tag := range event.Task.Tags {
+			if (before == nil || !slices.Contains(before.Tags, tag)) && rule.Trigger.MatchesTag(tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// passDueDates runs the due_date_passed rules on the tasks of their tenant
+// past their due date they have not run for, and their owner reaches. A task whose conditions do not hold is
+// logged as skipped, so it is not checked again for the same due date.
+func (s *AutomationService) passDueDates(ctx context.Context, rules []*model.AutomationRule) error {
+	now := time.Now()
+	for _, rule := range rules {
+		if rule.Trigger.Type != model.AutomationDueDatePassed {
+			continue
+		}
+
+		ruleCtx := ruleContext(ctx, rule)
+		tasks, err := s.store.ListAutomationDue(ruleCtx, rule, now, s.cfg.BatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to list tasks past their due date: %w", err)
+		}
+		for _, task := range tasks {
+			run := &model.AutomationRun{DueDate: task.DueDate}
+			response := task.ToResponse()
+			if !rule.Applies(response) {
+				run.Status, run.Reason = model.AutomationSkipped, "conditions not met"
+			}
+			if err := s.execute(ruleCtx, rule, response, run); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// execute claims run of rule for task and takes the rule's actions, one
+// after another, logging the outcome of each. Runs beyond
+// AUTOMATION_LOOP_LIMIT are logged as skipped instead.
+func (s *AutomationService) execute(ctx context.Context, rule *model.AutomationRule, task *model.TaskResponse, run *model.AutomationRun) error {
+	run.RuleID, run.RuleName, run.ProjectKey, run.TaskID = rule.ID, rule.Name, rule.ProjectKey, task.ID
+	run.Actions = []model.AutomationActionResult{}
+	if run.Status == "" {
+		recent, err := s.store.CountRecentAutomationRuns(ctx, rule.ID, task.ID, time.Now().Add(-s.cfg.LoopWindow))
+		if err != nil {
+			return err
+		}
+		run.Status = model.AutomationRunning
+		if recent >= s.cfg.LoopLimit {
+			run.Status = model.AutomationSkipped
+			run.Reason = fmt.Sprintf("loop protection: ran %d times for this task within %s", recent, s.cfg.LoopWindow)
+		}
+	}
+
+	claimed, err := s.store.ClaimAutomationRun(ctx, run)
+	if err != nil {
+		return err
+	}
+	if !claimed || run.Status == model.AutomationSkipped {
+		return nil
+	}
+
+	run.Status = model.AutomationSucceeded
+	current := task
+	for _, action := range rule.Actions {
+		result := model.AutomationActionResult{Type: action.Type, Status: model.AutomationSucceeded}
+		updated, err := s.act(ctx, rule, action, current, run)
+		if err != nil {
+			result.Status, result.Error = model.AutomationFailed, err.Error()
+			run.Status = model.AutomationFailed
+		} else if updated != nil {
+			current = updated
+		}
+		run.Actions = append(run.Actions, result)
+	}
+
+	if run.Status == model.AutomationFailed {
+		logger.Get().Warn().Str("rule_id", rule.ID).Str("task_id", task.ID).Int64("run_id", run.ID).
+			Msg("Automation rule action failed")
+	}
+	return s.store.FinishAutomationRun(ctx, run)
+}
+
+// act takes one action on task, returning the task after it when the
+// action changed it
+func (s *AutomationService) act(ctx context.Context, rule *model.AutomationRule, action model.AutomationAction, task *model.TaskResponse, run *model.AutomationRun) (*model.TaskResponse, error) {
+	switch action.Type {
+	case model.AutomationAssign:
+		return s.tasks.Assign(ctx, task.ID, &model.AssignTaskRequest{Assignee: action.Assignee})
+	case model.AutomationLabel:
+		if slices.Contains(task.Tags, action.Tag) {
+			return nil, nil
+		}
+		return s.tags.AttachTags(ctx, task.ID, &model.AttachTagsRequest{Tags: []string{action.Tag}})
+	case model.AutomationComment:
+		_, err := s.comments.Create(ctx, task.ID, &model.CreateCommentRequest{Body: action.Body})
+		return nil, err
+	case model.AutomationWebhook:
+		return nil, s.webhook(ctx, rule, action.URL, task, run)
+	}
+	return nil, fmt.Errorf("unknown action %q", action.Type)
+}
+
+// automationWebhook is the body POSTed by webhook actions
+type automationWebhook struct {
+	RuleID     string                  `json:"rule_id"`
+	RuleName   string                  `json:"rule_name"`
+	ProjectKey string                  `json:"project_key"`
+	Trigger    model.AutomationTrigger `json:"trigger"`
+	RunID      int64                   `json:"run_id"`
+	EventID    *int64                  `json:"event_id,omitempty"`
+	Task       *model.TaskResponse     `json:"task"`
+}
+
+// webhook POSTs the task to target, signing the body with
+// AUTOMATION_WEBHOOK_SECRET when set. Any answer but 2xx is a failure.
+func (s *AutomationService) webhook(ctx context.Context, rule *model.AutomationRule, target string, task *model.TaskResponse, run *model.AutomationRun) error {
+	body, err := json.Marshal(&automationWebhook{
+		RuleID:     rule.ID,
+		RuleName:   rule.Name,
+		ProjectKey: rule.ProjectKey,
+		Trigger:    rule.Trigger.Type,
+		RunID:      run.ID,
+		EventID:    run.EventID,
+		Task:       task,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Automation-Run", strconv.FormatInt(run.ID, 10))
+	if s.cfg.WebhookSecret != "" {
+		mac := hmac.New(sha256.New, []byte(s.cfg.WebhookSecret))
+		mac.Write(body)
+		req.Header.Set("X-Automation-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook call failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+func (s *AutomationService) loadCursor(ctx context.Context) (int64, bool, error) {
+	value, ok, err := s.state.Get(ctx, automationCursorKey)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to load automation cursor: %w", err)
+	}
+	if !ok {
+		return 0, false, nil
+	}
+
+	cursor, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return 0, false, nil
+	}
+	return cursor, true, nil
+}
+
+func (s *AutomationService) saveCursor(ctx context.Context, cursor int64) error {
+	if err := s.state.Set(ctx, automationCursorKey, []byte(strconv.FormatInt(cursor, 10)), automationCursorTTL); err != nil {
+		return fmt.Errorf("failed to save automation cursor: %w", err)
+	}
+	return nil
+}
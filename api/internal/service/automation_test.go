@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutomationService_Automate(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryTaskRepository(0)
+	state := kvstore.NewMemory()
+	events := NewEventService(repository.NewMemoryEventRepository(), state, &config.EventsConfig{})
+	guard := NewQueryGuard(&config.QueryGuardConfig{DefaultPerPage: 10, MaxPerPage: 10})
+	tasks := NewTaskService(repo, guard, nil, events, nil, nil, nil, &config.TaskConfig{DefaultProject: "TASK"})
+	comments := NewCommentService(repository.NewMemoryCommentRepository(), repo, guard, &config.CommentConfig{})
+	svc := NewAutomationService(repo, tasks, NewTagService(repo, events), comments, events, state, guard,
+		&config.AutomationConfig{BatchSize: 10, LoopLimit: 2, LoopWindow: time.Hour, WebhookHosts: []string{"hooks.example.com"}})
+	page := &model.ListOptions{Params: listing.Params{Page: 1, PerPage: 10}}
+
+	_, err := repo.CreateProject(ctx, &model.Project{Key: "OPS", Name: "Operations"})
+	require.NoError(t, err)
+	for _, name := range []string{"flapping", "overdue"} {
+		_, err = repo.CreateTag(ctx, &model.Tag{ID: uuid.NewString(), Name: name})
+		require.NoError(t, err)
+	}
+
+	// Webhooks only reach the allowed hosts, and actions carry what they use
+	_, err = svc.CreateRule(ctx, "OPS", &model.AutomationRuleRequest{Name: "Leak",
+		Trigger: model.AutomationTriggerSpec{Type: model.AutomationTagAdded},
+		Actions: []model.AutomationAction{{Type: model.AutomationWebhook, URL: "https://evil.example.org/hook"}}})
+	assert.ErrorIs(t, err, ErrValidation)
+	_, err = svc.CreateRule(ctx, "OPS", &model.AutomationRuleRequest{Name: "Empty",
+		Trigger: model.AutomationTriggerSpec{Type: model.AutomationTagAdded},
+		Actions: []model.AutomationAction{{Type: model.AutomationAssign}}})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	started, err := svc.CreateRule(ctx, "OPS", &model.AutomationRuleRequest{
+		Name:       "Pick up",
+		Trigger:    model.AutomationTriggerSpec{Type: model.AutomationStatusChanged, To: []model.Status{model.StatusInProgress}},
+		Conditions: []model.AutomationCondition{{Field: "assignee", Op: model.AutomationIs, Values: []string{model.AutomationUnassigned}}},
+		Actions: []model.AutomationAction{
+			{Type: model.AutomationAssign, Assignee: "Bob"},
+			{Type: model.AutomationComment, Body: "Picked up"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "bob", started.Actions[0].Assignee)
+
+	// The first check only places the cursor
+	require.NoError(t, svc.Automate(ctx))
+
+	task, err := tasks.Create(ctx, &model.CreateTaskRequest{Title: "Deploy", Project: "OPS"})
+	require.NoError(t, err)
+	inProgress := model.StatusInProgress
+	_, err = tasks.Update(ctx, task.ID, &model.UpdateTaskRequest{Status: &inProgress}, 0)
+	require.NoError(t, err)
+	require.NoError(t, svc.Automate(ctx))
+
+	got, err := tasks.GetByID(ctx, task.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.Assignee)
+	assert.Equal(t, "bob", *got.Assignee)
+	posted, err := comments.List(ctx, task.ID, page)
+	require.NoError(t, err)
+	require.Len(t, posted.Data, 1)
+	assert.Equal(t, AutomationActor, posted.Data[0].Author)
+
+	// The rule's own changes do not fire rules again
+	require.NoError(t, svc.Automate(ctx))
+	runs, err := svc.ListRuns(ctx, "OPS", page)
+	require.NoError(t, err)
+	require.Len(t, runs.Data, 1)
+	assert.Equal(t, model.AutomationSucceeded, runs.Data[0].Status)
+	assert.Len(t, runs.Data[0].Actions, 2)
+
+	// Loop protection skips runs beyond AUTOMATION_LOOP_LIMIT
+	_, err = svc.CreateRule(ctx, "OPS", &model.AutomationRuleRequest{
+		Name:    "Flapping",
+		Trigger: model.AutomationTriggerSpec{Type: model.AutomationStatusChanged},
+		Actions: []model.AutomationAction{{Type: model.AutomationLabel, Tag: "flapping"}},
+	})
+	require.NoError(t, err)
+	pending := model.StatusPending
+	for _, status := range []*model.Status{&pending, &inProgress, &pending} {
+		_, err = tasks.Update(ctx, task.ID, &model.UpdateTaskRequest{Status: status}, 0)
+		require.NoError(t, err)
+	}
+	require.NoError(t, svc.Automate(ctx))
+
+	runs, err = svc.ListRuns(ctx, "OPS", page)
+	require.NoError(t, err)
+	require.Len(t, runs.Data, 4)
+	assert.Equal(t, model.AutomationSkipped, runs.Data[0].Status)
+	assert.Contains(t, runs.Data[0].Reason, "loop protection")
+
+	// Due date rules run once per task and due date
+	_, err = svc.CreateRule(ctx, "OPS", &model.AutomationRuleRequest{
+		Name:    "Overdue",
+		Trigger: model.AutomationTriggerSpec{Type: model.AutomationDueDatePassed},
+		Actions: []model.AutomationAction{{Type: model.AutomationLabel, Tag: "overdue"}},
+	})
+	require.NoError(t, err)
+	late := time.Now().Add(-time.Hour)
+	overdue, err := repo.Create(ctx, &model.Task{ID: uuid.NewString(), ProjectKey: "OPS", Title: "Renew certificates",
+		Status: model.StatusPending, Priority: model.PriorityMedium, DueDate: &late})
+	require.NoError(t, err)
+	require.NoError(t, svc.Automate(ctx))
+	require.NoError(t, svc.Automate(ctx))
+
+	got, err = tasks.GetByID(ctx, overdue.ID)
+	require.NoError(t, err)
+	assert.Contains(t, got.Tags, "overdue")
+	total, err := repo.CountAutomationRuns(ctx, "OPS")
+	require.NoError(t, err)
+	assert.Equal(t, 5, total)
+}
+
+func TestAutomationService_RuleScope(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryTaskRepository(0)
+	state := kvstore.NewMemory()
+	events := NewEventService(repository.NewMemoryEventRepository(), state, &config.EventsConfig{})
+	guard := NewQueryGuard(&config.QueryGuardConfig{DefaultPerPage: 10, MaxPerPage: 10})
+	tasks := NewTaskService(repo, guard, nil, events, nil, nil, nil, &config.TaskConfig{DefaultProject: "TASK"})
+	comments := NewCommentService(repository.NewMemoryCommentRepository(), repo, guard, &config.CommentConfig{})
+	svc := NewAutomationService(repo, tasks, NewTagService(repo, events), comments, events, state, guard,
+		&config.AutomationConfig{BatchSize: 10, LoopLimit: 2, LoopWindow: time.Hour})
+	alice := tasks.Scope(auth.WithPrincipal(ctx, &auth.Principal{User: "alice"}))
+	bob := tasks.Scope(auth.WithPrincipal(ctx, &auth.Principal{User: "bob"}))
+
+	// Projects belong to a tenant, so acme has its own OPS
+	for _, tenantCtx := range []context.Context{ctx, tenant.With(ctx, "acme")} {
+		_, err := repo.CreateProject(tenantCtx, &model.Project{Key: "OPS", Name: "Operations"})
+		require.NoError(t, err)
+	}
+	var err error
+	for _, name := range []string{"alice", "acme", "overdue"} {
+		_, err = repo.CreateTag(ctx, &model.Tag{ID: uuid.NewString(), Name: name})
+		require.NoError(t, err)
+	}
+
+	started := model.AutomationTriggerSpec{Type: model.AutomationStatusChanged, To: []model.Status{model.StatusInProgress}}
+	owned, err := svc.CreateRule(alice, "OPS", &model.AutomationRuleRequest{Name: "Alice's", Trigger: started,
+		Actions: []model.AutomationAction{{Type: model.AutomationLabel, Tag: "alice"}}})
+	require.NoError(t, err)
+	require.NotNil(t, owned.Owner)
+	assert.Equal(t, "alice", *owned.Owner)
+	_, err = svc.CreateRule(tenant.With(ctx, "acme"), "OPS", &model.AutomationRuleRequest{Name: "Acme's", Trigger: started,
+		Actions: []model.AutomationAction{{Type: model.AutomationLabel, Tag: "acme"}}})
+	require.NoError(t, err)
+	_, err = svc.CreateRule(alice, "OPS", &model.AutomationRuleRequest{Name: "Alice's overdue",
+		Trigger: model.AutomationTriggerSpec{Type: model.AutomationDueDatePassed},
+		Actions: []model.AutomationAction{{Type: model.AutomationLabel, Tag: "overdue"}}})
+	require.NoError(t, err)
+
+	// Only the owner reaches a rule
+	_, err = svc.GetRule(bob, "OPS", owned.ID)
+	assert.ErrorIs(t, err, ErrAutomationRuleNotFound)
+	_, err = svc.UpdateRule(bob, "OPS", owned.ID, &model.AutomationRuleRequest{Name: "Hijacked", Trigger: started,
+		Actions: []model.AutomationAction{{Type: model.AutomationLabel, Tag: "alice"}}})
+	assert.ErrorIs(t, err, ErrAutomationRuleNotFound)
+	listed, err := svc.ListRules(bob, "OPS")
+	require.NoError(t, err)
+	assert.Empty(t, listed.Data)
+	listed, err = svc.ListRules(tenant.With(ctx, "globex"), "OPS")
+	require.NoError(t, err)
+	assert.Empty(t, listed.Data)
+
+	require.NoError(t, svc.Automate(ctx))
+
+	inProgress := model.StatusInProgress
+	late := time.Now().Add(-time.Hour)
+	created := map[string]*model.Task{}
+	for user, userCtx := range map[string]context.Context{"alice": alice, "bob": bob} {
+		task, err := repo.Create(ctx, &model.Task{ID: uuid.NewString(), ProjectKey: "OPS", Title: "Deploy",
+			Status: model.StatusPending, Priority: model.PriorityMedium, DueDate: &late, Owner: &user})
+		require.NoError(t, err)
+		// The first change records the state the status changes from
+		title := "Deploy " + user
+		_, err = tasks.Update(userCtx, task.ID, &model.UpdateTaskRequest{Title: &title}, 0)
+		require.NoError(t, err)
+		_, err = tasks.Update(userCtx, task.ID, &model.UpdateTaskRequest{Status: &inProgress}, 0)
+		require.NoError(t, err)
+		created[user] = task
+	}
+	require.NoError(t, svc.Automate(ctx))
+
+	// Alice's rules fire on her task only, and the other tenant's on neither
+	got, err := tasks.GetByID(ctx, created["alice"].ID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"alice", "overdue"}, got.Tags)
+	got, err = tasks.GetByID(ctx, created["bob"].ID)
+	require.NoError(t, err)
+	assert.Empty(t, got.Tags)
+
+	runs, err := svc.ListRuns(bob, "OPS", &model.ListOptions{Params: listing.Params{Page: 1, PerPage: 10}})
+	require.NoError(t, err)
+	assert.Empty(t, runs.Data)
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+)
+
+// Board returns the first tasks of every status, grouped into columns,
+// most urgent first unless opts sorts otherwise. With the token of an
+// earlier board, only columns whose content changed since are returned,
+// each with only the tasks that changed.
+func (s *TaskService) Board(ctx context.Context, opts *model.BoardOptions) (*model.BoardResponse, error) {
+	var previous model.BoardToken
+	if opts.Since != "" {
+		var err error
+		if previous, err = model.ParseBoardToken(opts.Since); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrValidation, err)
+		}
+	}
+	if err := s.checkBoard(opts); err != nil {
+		return nil, err
+	}
+
+	columns, err := s.repo.Board(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get board: %w", err)
+	}
+
+	board := &model.BoardResponse{
+		Delta:   previous != nil,
+		Project: opts.Project,
+		Sort:    opts.Sort,
+		Order:   opts.Order,
+		Columns: []*model.BoardColumn{},
+	}
+	token := make(model.BoardToken)
+	for _, tasks := range columns {
+		column := &model.BoardColumn{
+			Status: tasks.Status,
+			Total:  tasks.Total,
+			Limit:  opts.ColumnLimit(tasks.Status),
+			Tasks:  []*model.TaskResponse{},
+			IDs:    make([]string, 0, len(tasks.Tasks)),
+		}
+		responses := make([]*model.TaskResponse, 0, len(tasks.Tasks))
+		current := &model.ColumnToken{Total: tasks.Total, Tasks: make([]string, 0, len(tasks.Tasks))}
+		for _, task := range tasks.Tasks {
+			response := task.ToResponse()
+			responses = append(responses, response)
+			column.IDs = append(column.IDs, task.ID)
+			current.Tasks = append(current.Tasks, taskFingerprint(response))
+		}
+		token[column.Status] = current
+
+		if previous == nil {
+			column.Tasks = responses
+			board.Columns = append(board.Columns, column)
+			continue
+		}
+
+		// A delta column only carries the tasks the client has not seen as
+		// they are now
+		shown, ok := previous[column.Status]
+		if ok && shown.Equal(current) {
+			board.Unchanged = append(board.Unchanged, column.Status)
+			continue
+		}
+		for i, response := range responses {
+			if !ok || !shown.Has(current.Tasks[i]) {
+				column.Tasks = append(column.Tasks, response)
+			}
+		}
+		board.Columns = append(board.Columns, column)
+	}
+
+	board.Token = token.String()
+	return board, nil
+}
+
+// checkBoard validates and normalizes board options in place. Columns
+// show TASK_BOARD_COLUMN_LIMIT tasks unless asked for fewer.
+func (s *TaskService) checkBoard(opts *model.BoardOptions) error {
+	if opts.Project != "" && !model.ValidProjectKey(opts.Project) {
+		return fmt.Errorf("%w: project must be a project key such as PROJ", ErrValidation)
+	}
+
+	maxLimit := s.cfg.BoardColumnLimit
+	if opts.Limit == 0 {
+		opts.Limit = maxLimit
+	}
+	if opts.Limit < 0 || opts.Limit > maxLimit {
+		return fmt.Errorf("%w: limit must be between 0 and %d", ErrValidation, maxLimit)
+	}
+	for status, limit := range opts.Limits {
+		if limit < 0 || limit > maxLimit {
+			return fmt.Errorf("%w: limit_%s must be between 0 and %d", ErrValidation, status, maxLimit)
+		}
+	}
+
+	// Boards sort by priority unless asked otherwise
+	params := listing.Params{Sort: opts.Sort, Order: opts.Order}
+	if strings.TrimSpace(params.Sort) == "" {
+		params.Sort = "priority"
+	}
+	if err := params.NormalizeSort(indexedSortColumns); err != nil {
+		return listingError(err)
+	}
+	opts.Sort, opts.Order = params.Sort, params.Order
+
+	return nil
+}
+
+// taskFingerprint changes whenever the task is written or becomes overdue
+func taskFingerprint(task *model.TaskResponse) string {
+	hash := sha256.New()
+	hash.Write([]byte(task.ID))
+	hash.Write(binary.BigEndian.AppendUint64(nil, uint64(task.Version)))
+	if task.IsOverdue {
+		hash.Write([]byte{1})
+	} else {
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:4])
+}
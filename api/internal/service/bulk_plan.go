@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/audit"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+)
+
+var (
+	ErrPlanRequired = errors.New("bulk change needs a confirm token from a plan")
+	ErrPlanInvalid  = errors.New("confirm token is unknown, expired, used or for a different request")
+	ErrPlanStale    = errors.New("affected tasks changed since the plan, plan again")
+)
+
+// storedPlan is what a confirm token stands for
+type storedPlan struct {
+	Fingerprint string   `json:"fingerprint"`
+	Affected    []string `json:"affected"`
+}
+
+// BulkPlanner guards bulk updates and deletes behind a dry run. A plan
+// lists the tasks a request would touch and issues a single-use confirm
+// token; running the request with the token only succeeds while the same
+// caller sends the same request and the affected tasks have not changed.
+// Tokens live in the shared kv store, so any replica can redeem them.
+type BulkPlanner struct {
+	tasks *TaskService
+	store kvstore.Store
+	cfg   *config.TaskConfig
+}
+
+// NewBulkPlanner creates a new BulkPlanner
+func NewBulkPlanner(tasks *TaskService, store kvstore.Store, cfg *config.TaskConfig) *BulkPlanner {
+	return &BulkPlanner{tasks: tasks, store: store, cfg: cfg}
+}
+
+// PlanUpdate previews a bulk update and issues its confirm token
+func (p *BulkPlanner) PlanUpdate(ctx context.Context, req *model.BulkUpdateRequest) (*model.BulkPlan, error) {
+	plan, err := p.tasks.PlanBulkUpdate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return p.issue(ctx, plan, req.IDs, req.Changes)
+}
+
+// PlanDelete previews a bulk delete and issues its confirm token
+func (p *BulkPlanner) PlanDelete(ctx context.Context, req *model.BulkDeleteRequest) (*model.BulkPlan, error) {
+	plan, err := p.tasks.PlanBulkDelete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return p.issue(ctx, plan, req.IDs, nil)
+}
+
+// Update runs a bulk update once its confirm token checks out
+func (p *BulkPlanner) Update(ctx context.Context, req *model.BulkUpdateRequest) (*model.BulkUpdateResponse, error) {
+	if req.ConfirmToken == "" && !p.cfg.BulkPlanRequired {
+		return p.tasks.BulkUpdate(ctx, req)
+	}
+	if err := p.redeem(ctx, req.ConfirmToken, model.BulkActionUpdate, req.IDs, req.Changes, func() (*model.BulkPlan, error) {
+		return p.tasks.PlanBulkUpdate(ctx, req)
+	}); err != nil {
+		return nil, err
+	}
+	return p.tasks.BulkUpdate(ctx, req)
+}
+
+// Delete runs a bulk delete once its confirm token checks out
+func (p *BulkPlanner) Delete(ctx context.Context, req *model.BulkDeleteRequest) (*model.BulkDeleteResponse, error) {
+	if req.ConfirmToken == "" && !p.cfg.BulkPlanRequired {
+		return p.tasks.BulkDelete(ctx, req)
+	}
+	if err := p.redeem(ctx, req.ConfirmToken, model.BulkActionDelete, req.IDs, nil, func() (*model.BulkPlan, error) {
+		return p.tasks.PlanBulkDelete(ctx, req)
+	}); err != nil {
+		return nil, err
+	}
+	return p.tasks.BulkDelete(ctx, req)
+}
+
+// issue stores plan under a fresh confirm token
+func (p *BulkPlanner) issue(ctx context.Context, plan *model.BulkPlan, ids []string, changes any) (*model.BulkPlan, error) {
+	fingerprint, err := planFingerprint(ctx, plan.Action, ids, changes)
+	if err != nil {
+		return nil, err
+	}
+
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, fmt.Errorf("failed to generate confirm token: %w", err)
+	}
+	plan.ConfirmToken = hex.EncodeToString(token)
+	plan.ExpiresAt = time.Now().UTC().Add(p.cfg.BulkPlanTTL)
+
+	value, err := json.Marshal(storedPlan{Fingerprint: fingerprint, Affected: sortedIDs(plan.Affected)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode plan: %w", err)
+	}
+	if err := p.store.Set(ctx, "bulkplan:"+plan.ConfirmToken, value, p.cfg.BulkPlanTTL); err != nil {
+		return nil, fmt.Errorf("failed to store plan: %w", err)
+	}
+
+	return plan, nil
+}
+
+// redeem uses up token, checking it was issued to this caller for this
+// request and that replan still affects the same tasks
+func (p *BulkPlanner) redeem(ctx context.Context, token string, action model.BulkAction, ids []string, changes any, replan func() (*model.BulkPlan, error)) error {
+	if token == "" {
+		return ErrPlanRequired
+	}
+
+	value, ok, err := p.store.Get(ctx, "bulkplan:"+token)
+	if err != nil {
+		return fmt.Errorf("failed to load plan: %w", err)
+	}
+	if !ok {
+		return ErrPlanInvalid
+	}
+	var stored storedPlan
+	if err := json.Unmarshal(value, &stored); err != nil {
+		return fmt.Errorf("failed to decode plan: %w", err)
+	}
+
+	fingerprint, err := planFingerprint(ctx, action, ids, changes)
+	if err != nil {
+		return err
+	}
+	if fingerprint != stored.Fingerprint {
+		return ErrPlanInvalid
+	}
+
+	// Claim the token before acting on it, so concurrent requests cannot both use it
+	claimed, err := p.store.SetNX(ctx, "bulkplan:used:"+token, []byte("1"), p.cfg.BulkPlanTTL)
+	if err != nil {
+		return fmt.Errorf("failed to claim plan: %w", err)
+	}
+	if !claimed {
+		return ErrPlanInvalid
+	}
+	if err := p.store.Delete(ctx, "bulkplan:"+token); err != nil {
+		return fmt.Errorf("failed to use up plan: %w", err)
+	}
+
+	current, err := replan()
+	if err != nil {
+		return err
+	}
+	if !slices.Equal(sortedIDs(current.Affected), stored.Affected) {
+		return ErrPlanStale
+	}
+	return nil
+}
+
+// planFingerprint identifies a bulk request and who sent it. IDs are
+// compared as a set, so reordering them does not invalidate a plan.
+func planFingerprint(ctx context.Context, action model.BulkAction, ids []string, changes any) (string, error) {
+	normalized := make([]string, 0, len(ids))
+	for _, id := range ids {
+		normalized = append(normalized, strings.ToLower(strings.TrimSpace(id)))
+	}
+	normalized = sortedIDs(normalized)
+
+	encodedChanges, err := json.Marshal(changes)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode changes: %w", err)
+	}
+
+	sum := sha256.New()
+	for _, part := range []string{string(action), audit.Actor(ctx), strings.Join(normalized, ","), string(encodedChanges)} {
+		sum.Write([]byte(part))
+		sum.Write([]byte{0})
+	}
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+// sortedIDs returns a sorted, deduplicated copy of ids
+func sortedIDs(ids []string) []string {
+	sorted := slices.Clone(ids)
+	slices.Sort(sorted)
+	return slices.Compact(sorted)
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/mutlitier_app/internal/audit"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkPlanner(t *testing.T) {
+	ctx := audit.WithActor(context.Background(), "alice")
+	repo := repository.NewMemoryTaskRepository(0)
+	events := NewEventService(repository.NewMemoryEventRepository(), kvstore.NewMemory(), &config.EventsConfig{})
+	cfg := &config.TaskConfig{DefaultProject: "TASK", BulkMaxIDs: 5, BulkPlanRequired: true, BulkPlanTTL: time.Minute}
+	svc := NewTaskService(repo, nil, nil, events, nil, nil, nil, cfg)
+	planner := NewBulkPlanner(svc, kvstore.NewMemory(), cfg)
+
+	var ids []string
+	for _, title := range []string{"One", "Two", "Three"} {
+		task, err := svc.Create(ctx, &model.CreateTaskRequest{Title: title})
+		require.NoError(t, err)
+		ids = append(ids, task.ID)
+	}
+	missing := uuid.NewString()
+
+	// Without a token nothing is deleted
+	_, err := planner.Delete(ctx, &model.BulkDeleteRequest{IDs: ids})
+	assert.ErrorIs(t, err, ErrPlanRequired)
+
+	plan, err := planner.PlanDelete(ctx, &model.BulkDeleteRequest{IDs: append(ids, missing)})
+	require.NoError(t, err)
+	assert.Equal(t, 3, plan.AffectedCount)
+	assert.Equal(t, ids, plan.Affected)
+	assert.Equal(t, []string{missing}, plan.NotFound)
+	assert.NotEmpty(t, plan.ConfirmToken)
+	live, err := repo.GetByIDs(ctx, ids)
+	require.NoError(t, err)
+	assert.Len(t, live, 3, "planning changes nothing")
+
+	// The token only fits the planned request and caller
+	_, err = planner.Delete(ctx, &model.BulkDeleteRequest{IDs: ids[:2], ConfirmToken: plan.ConfirmToken})
+	assert.ErrorIs(t, err, ErrPlanInvalid)
+	_, err = planner.Delete(audit.WithActor(context.Background(), "bob"),
+		&model.BulkDeleteRequest{IDs: append(ids, missing), ConfirmToken: plan.ConfirmToken})
+	assert.ErrorIs(t, err, ErrPlanInvalid)
+
+	// A task archived since the plan makes it stale
+	_, err = svc.Archive(ctx, ids[0])
+	require.NoError(t, err)
+	_, err = planner.Delete(ctx, &model.BulkDeleteRequest{IDs: append(ids, missing), ConfirmToken: plan.ConfirmToken})
+	assert.ErrorIs(t, err, ErrPlanStale)
+
+	// Tokens are single use, a fresh plan goes through once
+	_, err = planner.Delete(ctx, &model.BulkDeleteRequest{IDs: append(ids, missing), ConfirmToken: plan.ConfirmToken})
+	assert.ErrorIs(t, err, ErrPlanInvalid)
+	plan, err = planner.PlanDelete(ctx, &model.BulkDeleteRequest{IDs: ids})
+	require.NoError(t, err)
+	assert.Equal(t, []string{ids[0]}, plan.Archived)
+	result, err := planner.Delete(ctx, &model.BulkDeleteRequest{IDs: ids, ConfirmToken: plan.ConfirmToken})
+	require.NoError(t, err)
+	assert.Equal(t, ids[1:], result.Deleted)
+	_, err = planner.Delete(ctx, &model.BulkDeleteRequest{IDs: ids, ConfirmToken: plan.ConfirmToken})
+	assert.ErrorIs(t, err, ErrPlanInvalid)
+
+	// Update plans list the tasks whose status may not move
+	status := model.StatusCompleted
+	update := &model.BulkUpdateRequest{IDs: ids[:1], Changes: model.UpdateTaskRequest{Status: &status}}
+	_, err = svc.Unarchive(ctx, ids[0])
+	require.NoError(t, err)
+	plan, err = planner.PlanUpdate(ctx, update)
+	require.NoError(t, err)
+	assert.Equal(t, []string{ids[0]}, plan.Rejected, "pending tasks cannot complete directly")
+	assert.Zero(t, plan.AffectedCount)
+
+	// Plans are optional when not required
+	cfg.BulkPlanRequired = false
+	title := "Renamed"
+	_, err = planner.Update(ctx, &model.BulkUpdateRequest{IDs: ids[:1], Changes: model.UpdateTaskRequest{Title: &title}})
+	require.NoError(t, err)
+}
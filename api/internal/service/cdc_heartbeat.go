@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+// BeatEvery writes the CDC heartbeat every interval until ctx is
+// cancelled. Every replica may run it; concurrent beats only overwrite
+// the same row.
+func BeatEvery(ctx context.Context, heartbeats repository.HeartbeatStore, interval time.Duration) {
+	log := logger.Get().WithComponent("cdc")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := heartbeats.Beat(ctx); err != nil {
+				log.Error().Err(err).Msg("Failed to write CDC heartbeat")
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingHeartbeats counts beats, failing the first fail of them
+type countingHeartbeats struct {
+	beats atomic.Int64
+	fail  int64
+}
+
+func (h *countingHeartbeats) Beat(ctx context.Context) error {
+	if h.beats.Add(1) <= h.fail {
+		return errors.New("database unavailable")
+	}
+	return nil
+}
+
+func TestBeatEvery(t *testing.T) {
+	heartbeats := &countingHeartbeats{fail: 2}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		BeatEvery(ctx, heartbeats, time.Millisecond)
+		close(done)
+	}()
+
+	// Failed beats are logged and the ticker keeps going
+	assert.Eventually(t, func() bool { return heartbeats.beats.Load() >= 5 }, time.Second, time.Millisecond)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("BeatEvery did not return after cancel")
+	}
+
+	// No beat happens once it returned
+	stopped := heartbeats.beats.Load()
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, stopped, heartbeats.beats.Load())
+}
+
+func TestBeatEvery_FirstBeatAfterInterval(t *testing.T) {
+	heartbeats := &countingHeartbeats{}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	BeatEvery(ctx, heartbeats, time.Hour)
+	assert.Zero(t, heartbeats.beats.Load())
+}
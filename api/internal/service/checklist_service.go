@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+)
+
+var (
+	ErrChecklistItemNotFound = errors.New("checklist item not found")
+)
+
+// ChecklistService handles business logic for task checklists. Like the
+// task itself, the checklist of an archived task is read-only.
+type ChecklistService struct {
+	repo        repository.ChecklistStore
+	tasks       repository.TaskStore
+	degradation *Degradation
+	cfg         *config.TaskConfig
+	validate    *validator.Validate
+}
+
+// NewChecklistService creates a new ChecklistService
+func NewChecklistService(repo repository.ChecklistStore, tasks repository.TaskStore, degradation *Degradation, cfg *config.TaskConfig) *ChecklistService {
+	return &ChecklistService{
+		repo:        repo,
+		tasks:       tasks,
+		degradation: degradation,
+		cfg:         cfg,
+		validate:    validator.New(),
+	}
+}
+
+// List returns a live task's whole checklist in order
+func (s *ChecklistService) List(ctx context.Context, taskID string) (*model.ChecklistResponse, error) {
+	if err := s.checkTask(ctx, taskID, false); err != nil {
+		return nil, err
+	}
+
+	items, err := s.repo.ListByTasks(ctx, []string{taskID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checklist: %w", err)
+	}
+
+	return model.NewChecklistResponse(items), nil
+}
+
+// Create appends an item to a task's checklist
+func (s *ChecklistService) Create(ctx context.Context, taskID string, req *model.CreateChecklistItemRequest) (*model.ChecklistItem, error) {
+	req.Text = strings.TrimSpace(req.Text)
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, formatValidationErrors(err))
+	}
+
+	if err := s.checkTask(ctx, taskID, true); err != nil {
+		return nil, err
+	}
+
+	items, err := s.repo.ListByTasks(ctx, []string{taskID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checklist: %w", err)
+	}
+	if len(items) >= s.cfg.ChecklistMaxItems {
+		return nil, fmt.Errorf("%w: a checklist holds at most %d items", ErrValidation, s.cfg.ChecklistMaxItems)
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate checklist item id: %w", err)
+	}
+
+	created, err := s.repo.Create(ctx, &model.ChecklistItem{ID: id.String(), TaskID: taskID, Text: req.Text, Done: req.Done})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create checklist item: %w", err)
+	}
+
+	return created, nil
+}
+
+// Update changes the text or done flag of a checklist item
+func (s *ChecklistService) Update(ctx context.Context, taskID, id string, req *model.UpdateChecklistItemRequest) (*model.ChecklistItem, error) {
+	if req.Text != nil {
+		text := strings.TrimSpace(*req.Text)
+		req.Text = &text
+	}
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, formatValidationErrors(err))
+	}
+	if req.Text == nil && req.Done == nil {
+		return nil, fmt.Errorf("%w: set text or done", ErrValidation)
+	}
+
+	if err := s.checkTask(ctx, taskID, true); err != nil {
+		return nil, err
+	}
+	if !isValidID(id) {
+		return nil, ErrChecklistItemNotFound
+	}
+
+	item, err := s.repo.Update(ctx, taskID, id, req)
+	if err != nil {
+		if errors.Is(err, repository.ErrChecklistItemNotFound) {
+			return nil, ErrChecklistItemNotFound
+		}
+		return nil, fmt.Errorf("failed to update checklist item: %w", err)
+	}
+
+	return item, nil
+}
+
+// Delete removes an item from a task's checklist
+func (s *ChecklistService) Delete(ctx context.Context, taskID, id string) error {
+	if err := s.checkTask(ctx, taskID, true); err != nil {
+		return err
+	}
+	if !isValidID(id) {
+		return ErrChecklistItemNotFound
+	}
+
+	if err := s.repo.Delete(ctx, taskID, id); err != nil {
+		if errors.Is(err, repository.ErrChecklistItemNotFound) {
+			return ErrChecklistItemNotFound
+		}
+		return fmt.Errorf("failed to delete checklist item: %w", err)
+	}
+
+	return nil
+}
+
+// Reorder puts a task's checklist in the order of req.IDs, which must list
+// every item exactly once so a stale client cannot lose track of items
+func (s *ChecklistService) Reorder(ctx context.Context, taskID string, req *model.ReorderChecklistRequest) (*model.ChecklistResponse, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, formatValidationErrors(err))
+	}
+
+	if err := s.checkTask(ctx, taskID, true); err != nil {
+		return nil, err
+	}
+
+	items, err := s.repo.ListByTasks(ctx, []string{taskID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checklist: %w", err)
+	}
+
+	ids := make([]string, len(req.IDs))
+	for i, raw := range req.IDs {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: ids must list every checklist item exactly once", ErrValidation)
+		}
+		ids[i] = parsed.String()
+	}
+	current := make([]string, len(items))
+	for i, item := range items {
+		current[i] = item.ID
+	}
+	if !slices.Equal(sortedIDs(ids), sortedIDs(current)) || len(ids) != len(current) {
+		return nil, fmt.Errorf("%w: ids must list every checklist item exactly once", ErrValidation)
+	}
+
+	if err := s.repo.Reorder(ctx, taskID, ids); err != nil {
+		return nil, fmt.Errorf("failed to reorder checklist: %w", err)
+	}
+
+	return s.List(ctx, taskID)
+}
+
+// Expand sets the checklist of every task, loading them in one query.
+// With the disable_expansions degradation switch on it does nothing.
+func (s *ChecklistService) Expand(ctx context.Context, tasks ...*model.TaskResponse) error {
+	if len(tasks) == 0 || s.degradation.ExpansionsDisabled() {
+		return nil
+	}
+
+	ids := make([]string, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	items, err := s.repo.ListByTasks(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to list checklists: %w", err)
+	}
+
+	byTask := make(map[string][]*model.ChecklistItem, len(tasks))
+	for _, item := range items {
+		byTask[item.TaskID] = append(byTask[item.TaskID], item)
+	}
+	for _, task := range tasks {
+		task.Checklist = byTask[task.ID]
+	}
+
+	return nil
+}
+
+// checkTask returns ErrTaskNotFound unless taskID is a live task and, for
+// changes, ErrArchived when it is archived
+func (s *ChecklistService) checkTask(ctx context.Context, taskID string, write bool) error {
+	if !isValidID(taskID) {
+		return ErrTaskNotFound
+	}
+
+	task, err := s.tasks.GetByID(ctx, taskID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotOwned) {
+			return denied(ErrTaskNotFound)
+		}
+		if errors.Is(err, repository.ErrTaskNotFound) {
+			return ErrTaskNotFound
+		}
+		return fmt.Errorf("failed to get task: %w", err)
+	}
+	if write && task.Archived {
+		return ErrArchived
+	}
+
+	return nil
+}
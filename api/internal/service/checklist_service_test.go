@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecklistService(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryTaskRepository(0)
+	events := NewEventService(repository.NewMemoryEventRepository(), kvstore.NewMemory(), &config.EventsConfig{})
+	cfg := &config.TaskConfig{DefaultProject: "TASK", ChecklistMaxItems: 3}
+	tasks := NewTaskService(repo, nil, nil, events, nil, nil, nil, cfg)
+	degradation := NewDegradation(&config.DegradationConfig{})
+	svc := NewChecklistService(repository.NewMemoryChecklistRepository(), repo, degradation, cfg)
+
+	task, err := tasks.Create(ctx, &model.CreateTaskRequest{Title: "Release"})
+	require.NoError(t, err)
+
+	var ids []string
+	for _, text := range []string{"Tag", "Build", "Announce"} {
+		item, err := svc.Create(ctx, task.ID, &model.CreateChecklistItemRequest{Text: text})
+		require.NoError(t, err)
+		assert.Equal(t, len(ids), item.Position)
+		ids = append(ids, item.ID)
+	}
+	_, err = svc.Create(ctx, task.ID, &model.CreateChecklistItemRequest{Text: "One too many"})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	done := true
+	_, err = svc.Update(ctx, task.ID, ids[1], &model.UpdateChecklistItemRequest{Done: &done})
+	require.NoError(t, err)
+
+	// Reordering needs every item exactly once
+	_, err = svc.Reorder(ctx, task.ID, &model.ReorderChecklistRequest{IDs: []string{ids[2], ids[0]}})
+	assert.ErrorIs(t, err, ErrValidation)
+	_, err = svc.Reorder(ctx, task.ID, &model.ReorderChecklistRequest{IDs: []string{ids[2], ids[0], ids[0]}})
+	assert.ErrorIs(t, err, ErrValidation)
+	checklist, err := svc.Reorder(ctx, task.ID, &model.ReorderChecklistRequest{IDs: []string{ids[2], ids[0], ids[1]}})
+	require.NoError(t, err)
+	assert.Equal(t, 3, checklist.Total)
+	assert.Equal(t, 1, checklist.Done)
+	assert.Equal(t, []string{"Announce", "Tag", "Build"}, []string{checklist.Data[0].Text, checklist.Data[1].Text, checklist.Data[2].Text})
+
+	// Expanded inline in one query, unless expansions are degraded
+	other, err := tasks.Create(ctx, &model.CreateTaskRequest{Title: "Empty"})
+	require.NoError(t, err)
+	require.NoError(t, svc.Expand(ctx, task, other))
+	assert.Len(t, task.Checklist, 3)
+	assert.Empty(t, other.Checklist)
+	disabled := true
+	degradation.Update(DegradationUpdate{DisableExpansions: &disabled})
+	fresh, err := tasks.GetByID(ctx, task.ID)
+	require.NoError(t, err)
+	require.NoError(t, svc.Expand(ctx, fresh))
+	assert.Nil(t, fresh.Checklist)
+
+	// Archived tasks are read-only
+	_, err = tasks.Archive(ctx, task.ID)
+	require.NoError(t, err)
+	assert.ErrorIs(t, svc.Delete(ctx, task.ID, ids[0]), ErrArchived)
+	_, err = svc.List(ctx, task.ID)
+	require.NoError(t, err)
+	assert.ErrorIs(t, svc.Delete(ctx, other.ID, ids[0]), ErrChecklistItemNotFound)
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/mutlitier_app/internal/audit"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+)
+
+var (
+	ErrCommentNotFound = errors.New("comment not found")
+	ErrTaskHasComments = errors.New("task has comments")
+)
+
+// CommentService handles business logic for task comments, including the
+// COMMENTS_ON_TASK_DELETE policy that TaskService consults before deletes
+type CommentService struct {
+	repo     repository.CommentStore
+	tasks    repository.TaskStore
+	guard    *QueryGuard
+	cfg      *config.CommentConfig
+	validate *validator.Validate
+}
+
+// NewCommentService creates a new CommentService
+func NewCommentService(repo repository.CommentStore, tasks repository.TaskStore, guard *QueryGuard, cfg *config.CommentConfig) *CommentService {
+	return &CommentService{
+		repo:     repo,
+		tasks:    tasks,
+		guard:    guard,
+		cfg:      cfg,
+		validate: validator.New(),
+	}
+}
+
+// Create adds a comment to a live task, written by the caller the
+// context attributes writes to
+func (s *CommentService) Create(ctx context.Context, taskID string, req *model.CreateCommentRequest) (*model.CommentResponse, error) {
+	req.Body = strings.TrimSpace(req.Body)
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, formatValidationErrors(err))
+	}
+
+	if err := s.checkTask(ctx, taskID); err != nil {
+		return nil, err
+	}
+
+	// UUIDv7 IDs keep comments with equal timestamps in posting order
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate comment id: %w", err)
+	}
+
+	created, err := s.repo.Create(ctx, &model.Comment{
+		ID:     id.String(),
+		TaskID: taskID,
+		Author: audit.Actor(ctx),
+		Body:   req.Body,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	return created.ToResponse(), nil
+}
+
+// List returns a page of a live task's comments, oldest first
+func (s *CommentService) List(ctx context.Context, taskID string, opts *model.ListOptions) (*model.CommentListResponse, error) {
+	if err := s.guard.CheckPage(opts); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkTask(ctx, taskID); err != nil {
+		return nil, err
+	}
+
+	comments, err := s.repo.ListByTask(ctx, taskID, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+
+	total, err := s.repo.CountByTask(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count comments: %w", err)
+	}
+
+	responses := make([]*model.CommentResponse, 0, len(comments))
+	for _, comment := range comments {
+		responses = append(responses, comment.ToResponse())
+	}
+
+	return &model.CommentListResponse{
+		Data:       responses,
+		Pagination: listing.NewPagination(opts.Page, opts.PerPage, total),
+	}, nil
+}
+
+// Delete removes a comment from a live task
+func (s *CommentService) Delete(ctx context.Context, taskID, id string) error {
+	if err := s.checkTask(ctx, taskID); err != nil {
+		return err
+	}
+
+	if !isValidID(id) {
+		return ErrCommentNotFound
+	}
+
+	if err := s.repo.Delete(ctx, taskID, id); err != nil {
+		if errors.Is(err, repository.ErrCommentNotFound) {
+			return ErrCommentNotFound
+		}
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+
+	return nil
+}
+
+// Expand sets the most recent comments of every task, up to limit per
+// task, loading them in one query
+func (s *CommentService) Expand(ctx context.Context, limit int, tasks ...*model.TaskResponse) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	comments, err := s.repo.ListRecentByTasks(ctx, ids, limit)
+	if err != nil {
+		return fmt.Errorf("failed to list comments: %w", err)
+	}
+
+	byTask := make(map[string][]*model.CommentResponse, len(tasks))
+	for _, comment := range comments {
+		byTask[comment.TaskID] = append(byTask[comment.TaskID], comment.ToResponse())
+	}
+	for _, task := range tasks {
+		task.Comments = byTask[task.ID]
+	}
+
+	return nil
+}
+
+// Protect returns the context to delete tasks with. Under the block
+// policy those deletes leave tasks with comments alone, checking for them
+// in the delete itself, and report them as repository.ErrTaskCommented.
+func (s *CommentService) Protect(ctx context.Context) context.Context {
+	if !s.cfg.Blocking() {
+		return ctx
+	}
+	return repository.KeepCommented(ctx)
+}
+
+// Blocked returns the IDs among taskIDs that the block policy keeps from
+// being deleted
+func (s *CommentService) Blocked(ctx context.Context, taskIDs []string) ([]string, error) {
+	if !s.cfg.Blocking() {
+		return nil, nil
+	}
+
+	ids, err := s.repo.TasksWithComments(ctx, taskIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find commented tasks: %w", err)
+	}
+
+	return ids, nil
+}
+
+// TaskRemoved deletes the comments of a permanently deleted task. Soft
+// deletes keep comments so a restored task gets them back.
+func (s *CommentService) TaskRemoved(ctx context.Context, taskID string) error {
+	if err := s.repo.DeleteByTask(ctx, taskID); err != nil {
+		return fmt.Errorf("failed to delete task comments: %w", err)
+	}
+
+	return nil
+}
+
+// checkTask returns ErrTaskNotFound unless taskID is a live task, so
+// comments of soft-deleted tasks are hidden until the task is restored
+func (s *CommentService) checkTask(ctx context.Context, taskID string) error {
+	if !isValidID(taskID) {
+		return ErrTaskNotFound
+	}
+
+	if _, err := s.tasks.GetByID(ctx, taskID); err != nil {
+		if errors.Is(err, repository.ErrNotOwned) {
+			return denied(ErrTaskNotFound)
+		}
+		if errors.Is(err, repository.ErrTaskNotFound) {
+			return ErrTaskNotFound
+		}
+		return fmt.Errorf("failed to get task: %w", err)
+	}
+
+	return nil
+}
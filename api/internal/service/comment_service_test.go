@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/mutlitier_app/internal/audit"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommentService_TaskDeletePolicy(t *testing.T) {
+	for _, policy := range []string{"cascade", "block"} {
+		t.Run(policy, func(t *testing.T) {
+			ctx := context.Background()
+			commentRepo := repository.NewMemoryCommentRepository()
+			repo := repository.NewMemoryTaskRepository(0).FollowComments(commentRepo)
+			events := NewEventService(repository.NewMemoryEventRepository(), kvstore.NewMemory(), &config.EventsConfig{})
+			guard := NewQueryGuard(&config.QueryGuardConfig{DefaultPerPage: 10, MaxPerPage: 10})
+			comments := NewCommentService(commentRepo, repo, guard, &config.CommentConfig{OnTaskDelete: policy})
+			svc := NewTaskService(repo, nil, nil, events, nil, comments, nil, &config.TaskConfig{DefaultProject: "TASK", BulkMaxIDs: 5})
+
+			commented, err := svc.Create(ctx, &model.CreateTaskRequest{Title: "Discussed"})
+			require.NoError(t, err)
+			quiet, err := svc.Create(ctx, &model.CreateTaskRequest{Title: "Quiet"})
+			require.NoError(t, err)
+
+			_, err = comments.Create(audit.WithActor(ctx, "sara"), commented.ID, &model.CreateCommentRequest{Body: "  Looks good  "})
+			require.NoError(t, err)
+
+			page, err := comments.List(ctx, commented.ID, &model.ListOptions{})
+			require.NoError(t, err)
+			require.Len(t, page.Data, 1)
+			assert.Equal(t, "Looks good", page.Data[0].Body)
+			assert.Equal(t, "sara", page.Data[0].Author)
+
+			bulk, err := svc.BulkDelete(ctx, &model.BulkDeleteRequest{IDs: []string{commented.ID, quiet.ID}})
+			require.NoError(t, err)
+
+			if policy == "block" {
+				assert.Equal(t, []string{quiet.ID}, bulk.Deleted)
+				assert.Equal(t, []string{commented.ID}, bulk.Blocked)
+				assert.ErrorIs(t, svc.Delete(ctx, commented.ID, repository.AnyVersion), ErrTaskHasComments)
+				assert.ErrorIs(t, svc.HardDelete(ctx, commented.ID, repository.AnyVersion), ErrTaskHasComments)
+				return
+			}
+
+			assert.Equal(t, []string{commented.ID, quiet.ID}, bulk.Deleted)
+
+			// Soft-deleted tasks hide their comments until restored
+			_, err = comments.List(ctx, commented.ID, &model.ListOptions{})
+			assert.ErrorIs(t, err, ErrTaskNotFound)
+
+			require.NoError(t, svc.HardDelete(ctx, commented.ID, repository.AnyVersion))
+			total, err := commentRepo.CountByTask(ctx, commented.ID)
+			require.NoError(t, err)
+			assert.Zero(t, total)
+		})
+	}
+}
+
+func TestCommentService_AnonymousAuthor(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryTaskRepository(0)
+	guard := NewQueryGuard(&config.QueryGuardConfig{DefaultPerPage: 10, MaxPerPage: 10})
+	comments := NewCommentService(repository.NewMemoryCommentRepository(), repo, guard, &config.CommentConfig{})
+	task, err := repo.Create(ctx, &model.Task{ID: uuid.NewString(), Title: "Unsigned", Status: model.StatusPending})
+	require.NoError(t, err)
+
+	created, err := comments.Create(ctx, task.ID, &model.CreateCommentRequest{Body: "Who wrote this?"})
+	require.NoError(t, err)
+	assert.Equal(t, audit.Anonymous, created.Author)
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+)
+
+// Resource describes a simple resource to CRUDService. T is the stored
+// model, C and U the create and update requests, R the response.
+type Resource[T, C, U, R any] struct {
+	// Name names the resource in errors, such as "tag"
+	Name string
+	// New builds the item to store under id from a valid create request
+	New func(id string, req *C) *T
+	// Response converts a stored item to its response
+	Response func(item *T) *R
+	// NormalizeCreate and NormalizeUpdate, when set, tidy a request
+	// before it is validated, such as lowercasing names
+	NormalizeCreate func(req *C)
+	NormalizeUpdate func(req *U)
+	// StoreNotFound and StoreExists are the store's errors for a missing
+	// or duplicate item, returned as NotFound and Exists
+	StoreNotFound error
+	StoreExists   error
+	NotFound      error
+	Exists        error
+}
+
+// CRUDService handles the business logic of a simple resource: requests
+// are validated with the resource's validate tags, IDs are UUIDv7 and
+// store errors become the resource's own. Services for such resources
+// embed it and add only what is specific to them.
+type CRUDService[T, C, U, R any] struct {
+	store    repository.CRUDStore[T, U]
+	resource *Resource[T, C, U, R]
+	validate *validator.Validate
+}
+
+// NewCRUDService creates a new CRUDService. validate carries the custom
+// validations the resource's requests use.
+func NewCRUDService[T, C, U, R any](store repository.CRUDStore[T, U], resource *Resource[T, C, U, R], validate *validator.Validate) *CRUDService[T, C, U, R] {
+	return &CRUDService[T, C, U, R]{store: store, resource: resource, validate: validate}
+}
+
+// Create creates a new item
+func (s *CRUDService[T, C, U, R]) Create(ctx context.Context, req *C) (*R, error) {
+	if s.resource.NormalizeCreate != nil {
+		s.resource.NormalizeCreate(req)
+	}
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, formatValidationErrors(err))
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate %s id: %w", s.resource.Name, err)
+	}
+
+	created, err := s.store.Create(ctx, s.resource.New(id.String(), req))
+	if err != nil {
+		return nil, s.storeError("create", err)
+	}
+
+	return s.resource.Response(created), nil
+}
+
+// GetByID retrieves an item by its ID
+func (s *CRUDService[T, C, U, R]) GetByID(ctx context.Context, id string) (*R, error) {
+	if !isValidID(id) {
+		return nil, s.resource.NotFound
+	}
+
+	item, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, s.storeError("get", err)
+	}
+
+	return s.resource.Response(item), nil
+}
+
+// List returns every item in the store's order
+func (s *CRUDService[T, C, U, R]) List(ctx context.Context) ([]*R, error) {
+	items, err := s.store.List(ctx)
+	if err != nil {
+		return nil, s.storeError("list", err)
+	}
+
+	responses := make([]*R, 0, len(items))
+	for _, item := range items {
+		responses = append(responses, s.resource.Response(item))
+	}
+
+	return responses, nil
+}
+
+// Update applies the fields set in req to an item
+func (s *CRUDService[T, C, U, R]) Update(ctx context.Context, id string, req *U) (*R, error) {
+	if s.resource.NormalizeUpdate != nil {
+		s.resource.NormalizeUpdate(req)
+	}
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, formatValidationErrors(err))
+	}
+
+	if !isValidID(id) {
+		return nil, s.resource.NotFound
+	}
+
+	updated, err := s.store.Update(ctx, id, req)
+	if err != nil {
+		return nil, s.storeError("update", err)
+	}
+
+	return s.resource.Response(updated), nil
+}
+
+// Delete deletes an item
+func (s *CRUDService[T, C, U, R]) Delete(ctx context.Context, id string) error {
+	if !isValidID(id) {
+		return s.resource.NotFound
+	}
+
+	if err := s.store.Delete(ctx, id); err != nil {
+		return s.storeError("delete", err)
+	}
+
+	return nil
+}
+
+// storeError maps the store's not found and exists errors to the
+// resource's and wraps any other
+func (s *CRUDService[T, C, U, R]) storeError(op string, err error) error {
+	switch {
+	case s.resource.StoreNotFound != nil && errors.Is(err, s.resource.StoreNotFound):
+		return s.resource.NotFound
+	case s.resource.StoreExists != nil && errors.Is(err, s.resource.StoreExists):
+		return s.resource.Exists
+	default:
+		return fmt.Errorf("failed to %s %s: %w", op, s.resource.Name, err)
+	}
+}
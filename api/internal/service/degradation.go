@@ -0,0 +1,92 @@
+package service
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/pkg/metrics"
+)
+
+var (
+	ErrDegraded = errors.New("feature temporarily disabled")
+)
+
+// DegradationModes is a snapshot of the degradation switches
+type DegradationModes struct {
+	DisableSearch     bool `json:"disable_search"`
+	DisableExpansions bool `json:"disable_expansions"`
+	CachedStatsOnly   bool `json:"cached_stats_only"`
+}
+
+// DegradationUpdate changes the switches that are set and leaves the rest alone
+type DegradationUpdate struct {
+	DisableSearch     *bool `json:"disable_search"`
+	DisableExpansions *bool `json:"disable_expansions"`
+	CachedStatsOnly   *bool `json:"cached_stats_only"`
+}
+
+// Degradation holds switches that shed optional, expensive features while
+// dependencies are unhealthy so core CRUD stays available. Switches start
+// from config and can be flipped at runtime.
+type Degradation struct {
+	disableSearch     atomic.Bool
+	disableExpansions atomic.Bool
+	cachedStatsOnly   atomic.Bool
+}
+
+// NewDegradation creates the switches from their configured defaults
+func NewDegradation(cfg *config.DegradationConfig) *Degradation {
+	d := &Degradation{}
+	d.Update(DegradationUpdate{
+		DisableSearch:     &cfg.DisableSearch,
+		DisableExpansions: &cfg.DisableExpansions,
+		CachedStatsOnly:   &cfg.CachedStatsOnly,
+	})
+	return d
+}
+
+// Modes returns the current switch positions
+func (d *Degradation) Modes() DegradationModes {
+	return DegradationModes{
+		DisableSearch:     d.disableSearch.Load(),
+		DisableExpansions: d.disableExpansions.Load(),
+		CachedStatsOnly:   d.cachedStatsOnly.Load(),
+	}
+}
+
+// Update flips the switches present in the update and returns the new modes
+func (d *Degradation) Update(update DegradationUpdate) DegradationModes {
+	setMode(&d.disableSearch, "disable_search", update.DisableSearch)
+	setMode(&d.disableExpansions, "disable_expansions", update.DisableExpansions)
+	setMode(&d.cachedStatsOnly, "cached_stats_only", update.CachedStatsOnly)
+	return d.Modes()
+}
+
+// SearchDisabled reports whether list searches are rejected
+func (d *Degradation) SearchDisabled() bool {
+	return d.disableSearch.Load()
+}
+
+// ExpansionsDisabled reports whether related resources are left unexpanded
+func (d *Degradation) ExpansionsDisabled() bool {
+	return d.disableExpansions.Load()
+}
+
+// CachedStatsOnly reports whether stats are served from cache without recomputing
+func (d *Degradation) CachedStatsOnly() bool {
+	return d.cachedStatsOnly.Load()
+}
+
+func setMode(flag *atomic.Bool, mode string, value *bool) {
+	if value == nil {
+		return
+	}
+	flag.Store(*value)
+
+	gauge := 0.0
+	if *value {
+		gauge = 1
+	}
+	metrics.DegradationMode.WithLabelValues(mode).Set(gauge)
+}
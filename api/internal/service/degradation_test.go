@@ -0,0 +1,20 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDegradation_Update(t *testing.T) {
+	d := NewDegradation(&config.DegradationConfig{CachedStatsOnly: true})
+	assert.Equal(t, DegradationModes{CachedStatsOnly: true}, d.Modes())
+
+	on := true
+	modes := d.Update(DegradationUpdate{DisableSearch: &on})
+
+	assert.Equal(t, DegradationModes{DisableSearch: true, CachedStatsOnly: true}, modes)
+	assert.True(t, d.SearchDisabled())
+	assert.False(t, d.ExpansionsDisabled())
+}
@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/mutlitier_app/internal/audit"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+// EscalationActor is who escalations are attributed to in task history,
+// events and notifications
+const EscalationActor = "escalation"
+
+var (
+	ErrEscalationRuleNotFound = errors.New("escalation rule not found")
+)
+
+// EscalationService manages the escalation rules of projects and applies
+// them. Every replica may run the job: recording an escalation claims it,
+// so only the replica that recorded it first notifies and raises the
+// priority.
+type EscalationService struct {
+	store         repository.EscalationStore
+	tasks         *TaskService
+	events        *EventService
+	notifications repository.NotificationStore
+	guard         *QueryGuard
+	cfg           *config.EscalationConfig
+	validate      *validator.Validate
+}
+
+// NewEscalationService creates a new EscalationService
+func NewEscalationService(store repository.EscalationStore, tasks *TaskService, events *EventService, notifications repository.NotificationStore, guard *QueryGuard, cfg *config.EscalationConfig) *EscalationService {
+	return &EscalationService{
+		store:         store,
+		tasks:         tasks,
+		events:        events,
+		notifications: notifications,
+		guard:         guard,
+		cfg:           cfg,
+		validate:      validator.New(),
+	}
+}
+
+// CreateRule adds an escalation rule to a project
+func (s *EscalationService) CreateRule(ctx context.Context, project string, req *model.EscalationRuleRequest) (*model.EscalationRule, error) {
+	if !model.ValidProjectKey(project) {
+		return nil, ErrProjectNotFound
+	}
+	rule, err := s.rule(req)
+	if err != nil {
+		return nil, err
+	}
+	rule.ID = uuid.NewString()
+	rule.ProjectKey = project
+	rule.CreatedBy = audit.Actor(ctx)
+	rule.Tenant = tenant.From(ctx)
+	if rule.Tenant == "" {
+		rule.Tenant = tenant.Default
+	}
+
+	created, err := s.store.CreateRule(ctx, rule)
+	if err != nil {
+		if errors.Is(err, repository.ErrProjectNotFound) {
+			return nil, ErrProjectNotFound
+		}
+		return nil, fmt.Errorf("failed to create escalation rule: %w", err)
+	}
+
+	return created, nil
+}
+
+// ListRules returns the escalation rules of a project, oldest first
+func (s *EscalationService) ListRules(ctx context.Context, project string) (*model.EscalationRuleListResponse, error) {
+	rules, err := s.store.ListRules(ctx, project)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list escalation rules: %w", err)
+	}
+	if rules == nil {
+		rules = []*model.EscalationRule{}
+	}
+
+	return &model.EscalationRuleListResponse{Data: rules}, nil
+}
+
+// GetRule returns one of a project's escalation rules
+func (s *EscalationService) GetRule(ctx context.Context, project, id string) (*model.EscalationRule, error) {
+	if !isValidID(id) {
+		return nil, ErrEscalationRuleNotFound
+	}
+
+	rule, err := s.store.GetRule(ctx, project, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrEscalationRuleNotFound) {
+			return nil, ErrEscalationRuleNotFound
+		}
+		return nil, fmt.Errorf("failed to get escalation rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// UpdateRule replaces one of a project's escalation rules. Tasks it
+// already escalated are not escalated again for the same due date.
+func (s *EscalationService) UpdateRule(ctx context.Context, project, id string, req *model.EscalationRuleRequest) (*model.EscalationRule, error) {
+	if !isValidID(id) {
+		return nil, ErrEscalationRuleNotFound
+	}
+	rule, err := s.rule(req)
+	if err != nil {
+		return nil, err
+	}
+	rule.ID = id
+	rule.ProjectKey = project
+
+	updated, err := s.store.UpdateRule(ctx, rule)
+	if err != nil {
+		if errors.Is(err, repository.ErrEscalationRuleNotFound) {
+			return nil, ErrEscalationRuleNotFound
+		}
+		return nil, fmt.Errorf("failed to update escalation rule: %w", err)
+	}
+
+	return updated, nil
+}
+
+// DeleteRule removes one of a project's escalation rules. The escalations
+// it made stay recorded.
+func (s *EscalationService) DeleteRule(ctx context.Context, project, id string) error {
+	if !isValidID(id) {
+		return ErrEscalationRuleNotFound
+	}
+
+	if err := s.store.DeleteRule(ctx, project, id); err != nil {
+		if errors.Is(err, repository.ErrEscalationRuleNotFound) {
+			return ErrEscalationRuleNotFound
+		}
+		return fmt.Errorf("failed to delete escalation rule: %w", err)
+	}
+
+	return nil
+}
+
+// ListEscalations returns a page of the actions a project's rules took,
+// newest first
+func (s *EscalationService) ListEscalations(ctx context.Context, project string, opts *model.ListOptions) (*model.EscalationListResponse, error) {
+	if err := s.guard.CheckPage(opts); err != nil {
+		return nil, err
+	}
+
+	escalations, err := s.store.ListEscalations(ctx, project, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list escalations: %w", err)
+	}
+	if escalations == nil {
+		escalations = []*model.Escalation{}
+	}
+
+	total, err := s.store.CountEscalations(ctx, project)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count escalations: %w", err)
+	}
+
+	return &model.EscalationListResponse{
+		Data:       escalations,
+		Pagination: listing.NewPagination(opts.Page, opts.PerPage, total),
+	}, nil
+}
+
+// rule validates a request and returns the rule it describes
+func (s *EscalationService) rule(req *model.EscalationRuleRequest) (*model.EscalationRule, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, formatValidationErrors(err))
+	}
+
+	users := make([]string, 0, len(req.NotifyUsers))
+	for _, user := range req.NotifyUsers {
+		user = normalizeUsername(user)
+		if !model.ValidUsername(user) {
+			return nil, fmt.Errorf("%w: notify_users: %q is not a valid username", ErrValidation, user)
+		}
+		if !slices.Contains(users, user) {
+			users = append(users, user)
+		}
+	}
+	if !req.NotifyOwner && !req.NotifyAssignee && len(users) == 0 && !req.BumpPriority {
+		return nil, fmt.Errorf("%w: a rule must notify someone or bump the priority", ErrValidation)
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	return &model.EscalationRule{
+		Name:           strings.TrimSpace(req.Name),
+		Trigger:        req.Trigger,
+		ThresholdHours: req.ThresholdHours,
+		NotifyOwner:    req.NotifyOwner,
+		NotifyAssignee: req.NotifyAssignee,
+		NotifyUsers:    users,
+		BumpPriority:   req.BumpPriority,
+		Enabled:        enabled,
+	}, nil
+}
+
+// Run applies the escalation rules every ESCALATION_POLL_INTERVAL until
+// stop is cancelled. A check in progress finishes using work.
+func (s *EscalationService) Run(stop, work context.Context) {
+	log := logger.Get().WithComponent("escalation")
+
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if escalated, err := s.Escalate(work); err != nil {
+			log.Error().Err(err).Msg("Failed to escalate tasks")
+		} else if escalated > 0 {
+			log.Info().Int("escalated", escalated).Msg("Escalated tasks")
+		}
+
+		select {
+		case <-stop.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Escalate applies every enabled rule to up to ESCALATION_BATCH_SIZE of
+// the tasks it has reached and returns how many escalations it made
+func (s *EscalationService) Escalate(ctx context.Context) (int, error) {
+	rules, err := s.store.ListEnabledRules(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list escalation rules: %w", err)
+	}
+
+	ctx = audit.WithActor(ctx, EscalationActor)
+	now := time.Now()
+	escalated := 0
+	for _, rule := range rules {
+		// Each rule reads and changes the tasks of its own tenant only
+		ruleCtx := tenant.With(ctx, rule.Tenant)
+		tasks, err := s.store.ListEscalationDue(ruleCtx, rule, now, s.cfg.BatchSize)
+		if err != nil {
+			return escalated, fmt.Errorf("failed to list tasks to escalate: %w", err)
+		}
+
+		for _, task := range tasks {
+			done, err := s.escalate(ruleCtx, rule, task)
+			if err != nil {
+				return escalated, err
+			}
+			if done {
+				escalated++
+			}
+		}
+	}
+
+	return escalated, nil
+}
+
+// escalate records what rule does to task, then does it unless another
+// replica recorded it first. It reports whether it escalated the task.
+func (s *EscalationService) escalate(ctx context.Context, rule *model.EscalationRule, task *model.Task) (bool, error) {
+	escalation := &model.Escalation{
+		RuleID:     rule.ID,
+		RuleName:   rule.Name,
+		ProjectKey: rule.ProjectKey,
+		TaskID:     task.ID,
+		DueDate:    *task.DueDate,
+		Notified:   recipients(rule, task),
+	}
+	if rule.BumpPriority {
+		if raised, ok := task.Priority.Raised(); ok {
+			from := task.Priority
+			escalation.PriorityFrom, escalation.PriorityTo = &from, &raised
+		}
+	}
+
+	// The escalation, the raised priority and the escalated event are
+	// kept together, so a failure leaves the task to escalate next time
+	var recorded bool
+	var event *model.TaskEvent
+	err := s.events.Atomically(ctx, func(ctx context.Context) error {
+		var err error
+		if recorded, err = s.store.RecordEscalation(ctx, escalation); err != nil {
+			return fmt.Errorf("failed to record escalation: %w", err)
+		}
+		if !recorded {
+			return nil
+		}
+
+		response := task.ToResponse()
+		if escalation.PriorityTo != nil {
+			patch, _ := json.Marshal(map[string]model.Priority{"priority": *escalation.PriorityTo})
+			updated, err := s.tasks.Patch(ctx, task.ID, patch, repository.AnyVersion)
+			if err != nil {
+				// The task changed since it was listed, the rest still applies
+				logger.Get().Warn().Err(err).Str("task_id", task.ID).Str("rule_id", rule.ID).
+					Msg("Failed to raise the priority of an escalated task")
+			} else {
+				response = updated
+			}
+		}
+
+		event, err = s.events.Record(ctx, model.EventTaskEscalated, task.ID, response)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	if !recorded || len(escalation.Notified) == 0 {
+		return recorded, nil
+	}
+
+	notifications := make([]*model.Notification, 0, len(escalation.Notified))
+	for _, user := range escalation.Notified {
+		notifications = append(notifications, &model.Notification{
+			User:    user,
+			EventID: event.ID,
+			Type:    event.Type,
+			TaskID:  task.ID,
+			Actor:   EscalationActor,
+		})
+	}
+	if _, err := s.notifications.CreateMany(ctx, notifications); err != nil {
+		return true, fmt.Errorf("failed to notify escalation of task %s: %w", task.ID, err)
+	}
+
+	return true, nil
+}
+
+// recipients returns who rule notifies about task, without duplicates
+func recipients(rule *model.EscalationRule, task *model.Task) []string {
+	users := []string{}
+	add := func(user string) {
+		if user != "" && !slices.Contains(users, user) {
+			users = append(users, user)
+		}
+	}
+	if rule.NotifyOwner && task.Owner != nil {
+		add(*task.Owner)
+	}
+	if rule.NotifyAssignee && task.Assignee != nil {
+		add(*task.Assignee)
+	}
+	for _, user := range rule.NotifyUsers {
+		add(user)
+	}
+	return users
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEscalationService_Escalate(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryTaskRepository(0)
+	notifications := repository.NewMemoryNotificationRepository()
+	events := NewEventService(repository.NewMemoryEventRepository(), kvstore.NewMemory(), &config.EventsConfig{})
+	guard := NewQueryGuard(&config.QueryGuardConfig{DefaultPerPage: 10, MaxPerPage: 10})
+	tasks := NewTaskService(repo, guard, nil, events, nil, nil, nil, &config.TaskConfig{DefaultProject: "TASK"})
+	svc := NewEscalationService(repo, tasks, events, notifications, guard, &config.EscalationConfig{BatchSize: 10})
+
+	_, err := repo.CreateProject(ctx, &model.Project{Key: "OPS", Name: "Operations"})
+	require.NoError(t, err)
+
+	// A rule must do something
+	_, err = svc.CreateRule(ctx, "OPS", &model.EscalationRuleRequest{Name: "Idle", Trigger: model.EscalationOverdue})
+	assert.ErrorIs(t, err, ErrValidation)
+	_, err = svc.CreateRule(ctx, "NOPE", &model.EscalationRuleRequest{Name: "Late", Trigger: model.EscalationOverdue, BumpPriority: true})
+	assert.ErrorIs(t, err, ErrProjectNotFound)
+
+	rule, err := svc.CreateRule(ctx, "OPS", &model.EscalationRuleRequest{
+		Name:           "Overdue 3 days",
+		Trigger:        model.EscalationOverdue,
+		ThresholdHours: 72,
+		NotifyOwner:    true,
+		NotifyUsers:    []string{"lead"},
+		BumpPriority:   true,
+	})
+	require.NoError(t, err)
+	assert.True(t, rule.Enabled)
+
+	// Due dates in the past are only reachable by storing the tasks directly
+	owner := "alice"
+	late := time.Now().Add(-4 * 24 * time.Hour)
+	recent := time.Now().Add(-time.Hour)
+	overdue, err := repo.Create(ctx, &model.Task{ID: uuid.NewString(), ProjectKey: "OPS", Title: "Renew certificates",
+		Status: model.StatusPending, Priority: model.PriorityMedium, Owner: &owner, DueDate: &late})
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, &model.Task{ID: uuid.NewString(), ProjectKey: "OPS", Title: "Rotate keys",
+		Status: model.StatusPending, Priority: model.PriorityMedium, Owner: &owner, DueDate: &recent})
+	require.NoError(t, err)
+
+	escalated, err := svc.Escalate(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, escalated)
+
+	// A rule fires once per task and due date
+	escalated, err = svc.Escalate(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, escalated)
+
+	task, err := tasks.GetByID(ctx, overdue.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.PriorityHigh, task.Priority)
+
+	list, err := svc.ListEscalations(ctx, "OPS", &model.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, overdue.ID, list.Data[0].TaskID)
+	assert.Equal(t, []string{"alice", "lead"}, list.Data[0].Notified)
+	require.NotNil(t, list.Data[0].PriorityTo)
+	assert.Equal(t, model.PriorityHigh, *list.Data[0].PriorityTo)
+
+	for _, user := range []string{"alice", "lead"} {
+		notified, err := notifications.ListByUser(ctx, repository.OwnedBy(user), false, &model.ListOptions{Params: listing.Params{Page: 1, PerPage: 10}})
+		require.NoError(t, err)
+		require.Len(t, notified, 1)
+		assert.Equal(t, model.EventTaskEscalated, notified[0].Type)
+	}
+
+	// Moving the due date lets the rule fire again
+	later := late.Add(time.Hour)
+	_, err = repo.Update(ctx, overdue.ID, &model.UpdateTaskRequest{DueDate: &later}, repository.AnyVersion)
+	require.NoError(t, err)
+	escalated, err = svc.Escalate(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, escalated)
+}
+
+func TestEscalationService_RuleTenant(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryTaskRepository(0)
+	events := NewEventService(repository.NewMemoryEventRepository(), kvstore.NewMemory(), &config.EventsConfig{})
+	guard := NewQueryGuard(&config.QueryGuardConfig{DefaultPerPage: 10, MaxPerPage: 10})
+	tasks := NewTaskService(repo, guard, nil, events, nil, nil, nil, &config.TaskConfig{DefaultProject: "TASK"})
+	svc := NewEscalationService(repo, tasks, events, repository.NewMemoryNotificationRepository(), guard, &config.EscalationConfig{BatchSize: 10})
+	acme, globex := tenant.With(ctx, "acme"), tenant.With(ctx, "globex")
+
+	// Projects belong to a tenant, so each has its own OPS
+	for _, tenantCtx := range []context.Context{acme, globex} {
+		_, err := repo.CreateProject(tenantCtx, &model.Project{Key: "OPS", Name: "Operations"})
+		require.NoError(t, err)
+	}
+	rule, err := svc.CreateRule(acme, "OPS", &model.EscalationRuleRequest{Name: "Late", Trigger: model.EscalationOverdue, BumpPriority: true})
+	require.NoError(t, err)
+	assert.Equal(t, "acme", rule.Tenant)
+
+	// Another tenant does not reach the rule
+	_, err = svc.GetRule(globex, "OPS", rule.ID)
+	assert.ErrorIs(t, err, ErrEscalationRuleNotFound)
+	assert.ErrorIs(t, svc.DeleteRule(globex, "OPS", rule.ID), ErrEscalationRuleNotFound)
+	listed, err := svc.ListRules(globex, "OPS")
+	require.NoError(t, err)
+	assert.Empty(t, listed.Data)
+
+	listed, err = svc.ListRules(acme, "OPS")
+	require.NoError(t, err)
+	require.Len(t, listed.Data, 1)
+	got, err := svc.GetRule(acme, "OPS", rule.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", got.Tenant)
+}
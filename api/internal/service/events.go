@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/audit"
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+var (
+	ErrEventsPurged = errors.New("events after the cursor were purged")
+)
+
+// EventService records task changes in a short-retention log and serves
+// them to streaming clients, who resume from a cursor after reconnecting
+type EventService struct {
+	store   repository.EventStore
+	cursors kvstore.Store
+	cfg     *config.EventsConfig
+
+	mu      sync.Mutex
+	changed chan struct{}
+}
+
+// NewEventService creates a new EventService. Named stream cursors are
+// persisted in cursors so they survive reconnects to another replica.
+func NewEventService(store repository.EventStore, cursors kvstore.Store, cfg *config.EventsConfig) *EventService {
+	return &EventService{
+		store:   store,
+		cursors: cursors,
+		cfg:     cfg,
+		changed: make(chan struct{}),
+	}
+}
+
+// Atomically runs write in one transaction. Writes that change tasks
+// record their events in it with Record, so an event is kept exactly when
+// its change is, and becomes visible with it. Streams waiting on this
+// replica are woken once the transaction commits.
+func (s *EventService) Atomically(ctx context.Context, write func(ctx context.Context) error) error {
+	if err := s.store.InTx(ctx, write); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	close(s.changed)
+	s.changed = make(chan struct{})
+	s.mu.Unlock()
+	return nil
+}
+
+// Record appends an event attributed to the request's actor, in the
+// transaction of ctx when it comes from Atomically
+func (s *EventService) Record(ctx context.Context, eventType model.EventType, taskID string, task *model.TaskResponse) (*model.TaskEvent, error) {
+	event, err := s.store.Append(ctx, &model.TaskEvent{Type: eventType, TaskID: taskID, Actor: audit.Actor(ctx), Task: task})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record task event: %w", err)
+	}
+	return event, nil
+}
+
+// Write runs change and records an event of eventType for the task it
+// returns, in one transaction
+func (s *EventService) Write(ctx context.Context, eventType model.EventType, change func(ctx context.Context) (*model.Task, error)) (*model.TaskResponse, error) {
+	var response *model.TaskResponse
+	err := s.Atomically(ctx, func(ctx context.Context) error {
+		task, err := change(ctx)
+		if err != nil {
+			return err
+		}
+		response = task.ToResponse()
+		_, err = s.Record(ctx, eventType, response.ID, response)
+		return err
+	})
+	return response, err
+}
+
+// Changed returns a channel that is closed when the next local write
+// recording events commits.
+// Events written by other replicas are picked up by polling.
+func (s *EventService) Changed() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.changed
+}
+
+// After returns up to limit events following cursor, in the order their
+// writes committed: an event is held back while an earlier one may still
+// be committing, for up to EVENTS_SETTLE_WINDOW. gap is true when events
+// after cursor were already purged, meaning the client missed changes and
+// must refetch state before continuing. Cursor 0 reads from the oldest
+// retained event and never reports a gap.
+func (s *EventService) After(ctx context.Context, cursor int64, limit int) (events []*model.TaskEvent, gap bool, err error) {
+	oldest, _, err := s.store.Bounds(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get event bounds: %w", err)
+	}
+	gap = cursor > 0 && cursor < oldest-1
+
+	settled, err := s.store.Settled(ctx, cursor, s.cfg.SettleWindow)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get settled event: %w", err)
+	}
+
+	events, err = s.store.ListAfter(ctx, cursor, settled, limit)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to list events: %w", err)
+	}
+
+	return events, gap, nil
+}
+
+// Replay returns a page of up to limit events following since, for
+// consumers backfilling history. since 0 starts at the oldest retained
+// event; any other cursor older than the retained log is ErrEventsPurged.
+func (s *EventService) Replay(ctx context.Context, since int64, limit int) (*model.TaskEventPage, error) {
+	if since < 0 {
+		return nil, fmt.Errorf("%w: since must not be negative", ErrValidation)
+	}
+	if limit < 0 {
+		return nil, fmt.Errorf("%w: limit must be positive", ErrValidation)
+	}
+	if limit == 0 {
+		limit = s.cfg.ReplayLimit
+	}
+	if limit > s.cfg.ReplayMaxLimit {
+		return nil, fmt.Errorf("%w: limit must be at most %d", ErrValidation, s.cfg.ReplayMaxLimit)
+	}
+
+	events, gap, err := s.After(ctx, since, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	if gap {
+		return nil, ErrEventsPurged
+	}
+
+	page := &model.TaskEventPage{Data: events, NextCursor: since}
+	if len(events) > limit {
+		page.Data = events[:limit]
+		page.HasMore = true
+	}
+	if len(page.Data) > 0 {
+		page.NextCursor = page.Data[len(page.Data)-1].ID
+	} else {
+		page.Data = []*model.TaskEvent{}
+	}
+
+	return page, nil
+}
+
+// Previous returns the retained event of a task preceding the event with
+// ID before, nil when it was purged or there was none
+func (s *EventService) Previous(ctx context.Context, taskID string, before int64) (*model.TaskEvent, error) {
+	event, err := s.store.Previous(ctx, taskID, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get previous event: %w", err)
+	}
+	return event, nil
+}
+
+// Latest returns the newest settled event ID, the cursor for clients that
+// only want changes from now on. Events still committing come after it,
+// so they are not skipped.
+func (s *EventService) Latest(ctx context.Context) (int64, error) {
+	oldest, _, err := s.store.Bounds(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get event bounds: %w", err)
+	}
+	settled, err := s.store.Settled(ctx, max(oldest-1, 0), s.cfg.SettleWindow)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get settled event: %w", err)
+	}
+	return settled, nil
+}
+
+// SaveCursor persists the last cursor delivered to a named stream of the
+// caller
+func (s *EventService) SaveCursor(ctx context.Context, stream string, cursor int64) error {
+	return s.cursors.Set(ctx, cursorKey(ctx, stream), []byte(strconv.FormatInt(cursor, 10)), s.cfg.Retention)
+}
+
+// LoadCursor returns the last cursor delivered to a named stream of the
+// caller
+func (s *EventService) LoadCursor(ctx context.Context, stream string) (int64, bool, error) {
+	value, ok, err := s.cursors.Get(ctx, cursorKey(ctx, stream))
+	if err != nil || !ok {
+		return 0, false, err
+	}
+
+	cursor, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return 0, false, nil
+	}
+	return cursor, true, nil
+}
+
+// PurgeEvery removes events older than the retention period on every
+// interval until ctx is done
+func (s *EventService) PurgeEvery(ctx context.Context, interval time.Duration) {
+	log := logger.Get().WithComponent("events")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.store.Purge(ctx, time.Now().Add(-s.cfg.Retention))
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to purge task events")
+				continue
+			}
+			if purged > 0 {
+				log.Debug().Int64("purged", purged).Msg("Purged expired task events")
+			}
+		}
+	}
+}
+
+// cursorKey returns the store key of a named stream: a hash of its name
+// with the tenant and principal it belongs to, so one caller can neither
+// read nor move another's cursor by naming the same stream
+func cursorKey(ctx context.Context, stream string) string {
+	user := ""
+	if principal := auth.FromContext(ctx); principal != nil {
+		user = principal.User
+	}
+	scope := sha256.Sum256([]byte(strings.Join([]string{tenant.From(ctx), user, stream}, "\x00")))
+	return "events:cursor:" + hex.EncodeToString(scope[:])
+}
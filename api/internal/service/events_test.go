@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventService_Replay(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryEventRepository()
+	events := NewEventService(store, kvstore.NewMemory(), &config.EventsConfig{ReplayLimit: 2, ReplayMaxLimit: 10})
+
+	for _, id := range []string{"a", "b", "c"} {
+		record(t, events, ctx, model.EventTaskCreated, id, nil)
+	}
+
+	page, err := events.Replay(ctx, 0, 0)
+	require.NoError(t, err)
+	assert.Len(t, page.Data, 2)
+	assert.True(t, page.HasMore)
+
+	page, err = events.Replay(ctx, page.NextCursor, 0)
+	require.NoError(t, err)
+	require.Len(t, page.Data, 1)
+	assert.Equal(t, "c", page.Data[0].TaskID)
+	assert.False(t, page.HasMore)
+
+	// Caught up: an empty page keeps the cursor
+	page, err = events.Replay(ctx, page.NextCursor, 0)
+	require.NoError(t, err)
+	assert.Empty(t, page.Data)
+	assert.Equal(t, int64(3), page.NextCursor)
+
+	_, err = events.Replay(ctx, 0, 11)
+	assert.ErrorIs(t, err, ErrValidation)
+
+	// Cursors older than the retained log cannot be replayed, since=0 still can
+	_, err = store.Purge(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	record(t, events, ctx, model.EventTaskUpdated, "c", nil)
+
+	_, err = events.Replay(ctx, 1, 0)
+	assert.ErrorIs(t, err, ErrEventsPurged)
+
+	page, err = events.Replay(ctx, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, page.Data, 1)
+	assert.Equal(t, int64(4), page.Data[0].ID)
+}
+
+// record appends an event the way writes do, in its own transaction
+func record(t *testing.T, events *EventService, ctx context.Context, eventType model.EventType, taskID string, task *model.TaskResponse) {
+	t.Helper()
+	require.NoError(t, events.Atomically(ctx, func(ctx context.Context) error {
+		_, err := events.Record(ctx, eventType, taskID, task)
+		return err
+	}))
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+	"github.com/moabdelazem/mutlitier_app/pkg/metrics"
+	"golang.org/x/sync/errgroup"
+)
+
+// Expansion names a related collection that can be embedded in task responses
+type Expansion string
+
+const (
+	ExpandChecklist Expansion = "checklist"
+	ExpandComments  Expansion = "comments"
+	ExpandWatchers  Expansion = "watchers"
+	ExpandTags      Expansion = "tags"
+)
+
+// Expansions returns all known expansions
+func Expansions() []Expansion {
+	return []Expansion{ExpandChecklist, ExpandComments, ExpandWatchers, ExpandTags}
+}
+
+// Field is the task response key an expansion fills in. Tags are
+// embedded as tag_details, next to the tag names tasks always carry.
+func (e Expansion) Field() string {
+	if e == ExpandTags {
+		return "tag_details"
+	}
+	return string(e)
+}
+
+// ParseExpansions parses a comma-separated expand value, ignoring repeats
+func ParseExpansions(value string) ([]Expansion, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var expansions []Expansion
+	for _, name := range strings.Split(value, ",") {
+		expansion := Expansion(strings.TrimSpace(name))
+		if !slices.Contains(Expansions(), expansion) {
+			return nil, fmt.Errorf("%w: expand must be one of checklist, comments, watchers, tags, got %q", ErrValidation, name)
+		}
+		if !slices.Contains(expansions, expansion) {
+			expansions = append(expansions, expansion)
+		}
+	}
+	return expansions, nil
+}
+
+// ExpansionService loads the expansions of task responses. Each expansion
+// is one batched query for all tasks, and the expansions of a request run
+// concurrently, at most EXPAND_MAX_CONCURRENCY at a time and each bounded
+// by EXPAND_TIMEOUT, so a request costs about as much as its slowest
+// expansion rather than the sum of them.
+type ExpansionService struct {
+	checklist   *ChecklistService
+	comments    *CommentService
+	watchers    *WatcherService
+	tags        *TagService
+	degradation *Degradation
+	cfg         *config.ExpansionConfig
+}
+
+// NewExpansionService creates a new ExpansionService
+func NewExpansionService(checklist *ChecklistService, comments *CommentService, watchers *WatcherService, tags *TagService, degradation *Degradation, cfg *config.ExpansionConfig) *ExpansionService {
+	return &ExpansionService{
+		checklist:   checklist,
+		comments:    comments,
+		watchers:    watchers,
+		tags:        tags,
+		degradation: degradation,
+		cfg:         cfg,
+	}
+}
+
+// Expand loads the requested expansions into tasks. With EXPAND_ON_ERROR
+// set to partial, an expansion that fails or times out is left out and
+// returned in incomplete; otherwise the first failure cancels the others
+// and is returned. With the disable_expansions degradation switch on it
+// does nothing.
+//
+// Each expansion writes only its own field of the tasks, and only once its
+// query succeeded, so the tasks are complete and safe to encode as soon as
+// Expand returns.
+func (s *ExpansionService) Expand(ctx context.Context, expansions []Expansion, tasks ...*model.TaskResponse) (incomplete []Expansion, err error) {
+	if len(expansions) == 0 || len(tasks) == 0 || s.degradation.ExpansionsDisabled() {
+		return nil, nil
+	}
+
+	// Under the fail policy the first error cancels the other expansions
+	group, groupCtx := errgroup.WithContext(ctx)
+	if s.cfg.Partial() {
+		group = &errgroup.Group{}
+		groupCtx = ctx
+	}
+	if s.cfg.MaxConcurrency > 0 {
+		group.SetLimit(s.cfg.MaxConcurrency)
+	}
+
+	var mu sync.Mutex
+	for _, expansion := range expansions {
+		group.Go(func() error {
+			err := s.load(groupCtx, expansion, tasks)
+			if err == nil {
+				return nil
+			}
+			if !s.cfg.Partial() {
+				return fmt.Errorf("failed to expand %s: %w", expansion, err)
+			}
+
+			logger.Ctx(ctx).Warn().Err(err).Str("expansion", string(expansion)).Msg("Leaving out failed expansion")
+			mu.Lock()
+			incomplete = append(incomplete, expansion)
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	// Report in request order regardless of which expansion failed first
+	slices.SortFunc(incomplete, func(a, b Expansion) int {
+		return slices.Index(expansions, a) - slices.Index(expansions, b)
+	})
+	return incomplete, nil
+}
+
+// load runs one expansion under its own timeout and records how it went
+func (s *ExpansionService) load(ctx context.Context, expansion Expansion, tasks []*model.TaskResponse) error {
+	if s.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	var err error
+	switch expansion {
+	case ExpandChecklist:
+		err = s.checklist.Expand(ctx, tasks...)
+	case ExpandComments:
+		err = s.comments.Expand(ctx, s.cfg.CommentsLimit, tasks...)
+	case ExpandWatchers:
+		err = s.watchers.Expand(ctx, tasks...)
+	case ExpandTags:
+		err = s.tags.Expand(ctx, tasks...)
+	default:
+		err = fmt.Errorf("unknown expansion %q", expansion)
+	}
+
+	result := "ok"
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		result = "timeout"
+	case err != nil:
+		result = "error"
+	}
+	metrics.ExpansionDuration.WithLabelValues(string(expansion), result).Observe(time.Since(start).Seconds())
+
+	return err
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stalledComments is a comment store whose batch reads hang until cancelled
+type stalledComments struct {
+	*repository.MemoryCommentRepository
+}
+
+func (stalledComments) ListRecentByTasks(ctx context.Context, taskIDs []string, limit int) ([]*model.Comment, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestExpansionService(t *testing.T) {
+	ctx := context.Background()
+	tasks := repository.NewMemoryTaskRepository(0)
+	degradation := NewDegradation(&config.DegradationConfig{})
+	guard := NewQueryGuard(&config.QueryGuardConfig{DefaultPerPage: 10, MaxPerPage: 10})
+	checklist := NewChecklistService(repository.NewMemoryChecklistRepository(), tasks, degradation, &config.TaskConfig{})
+	watcherRepo := repository.NewMemoryWatcherRepository()
+	watchers := NewWatcherService(watcherRepo, repository.NewMemoryNotificationRepository(), tasks, guard, &config.NotificationConfig{})
+	require.NoError(t, watcherRepo.Watch(ctx, "a", "alice"))
+	tags := NewTagService(tasks, nil)
+	_, err := tasks.CreateTag(ctx, &model.Tag{ID: "t1", Name: "backend", Color: "#00ff00"})
+	require.NoError(t, err)
+
+	expand := func(cfg *config.ExpansionConfig, comments repository.CommentStore) (*model.TaskResponse, []Expansion, error) {
+		svc := NewExpansionService(checklist, NewCommentService(comments, tasks, guard, &config.CommentConfig{}), watchers, tags, degradation, cfg)
+		task := &model.TaskResponse{ID: "a", Tags: []string{"backend", "gone"}}
+		incomplete, err := svc.Expand(ctx, []Expansion{ExpandComments, ExpandWatchers, ExpandTags}, task)
+		return task, incomplete, err
+	}
+	stalled := stalledComments{repository.NewMemoryCommentRepository()}
+
+	// Expansions that finish are kept when another times out
+	task, incomplete, err := expand(&config.ExpansionConfig{MaxConcurrency: 1, Timeout: 20 * time.Millisecond, OnError: "partial"}, stalled)
+	require.NoError(t, err)
+	assert.Equal(t, []Expansion{ExpandComments}, incomplete)
+	assert.Equal(t, []string{"alice"}, task.Watchers)
+
+	// Or the request fails
+	_, _, err = expand(&config.ExpansionConfig{MaxConcurrency: 2, Timeout: 20 * time.Millisecond, OnError: "fail"}, stalled)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	task, incomplete, err = expand(&config.ExpansionConfig{MaxConcurrency: 2, Timeout: time.Second, OnError: "fail"}, repository.NewMemoryCommentRepository())
+	require.NoError(t, err)
+	assert.Empty(t, incomplete)
+	assert.Equal(t, []string{"alice"}, task.Watchers)
+	// Names without a tag, deleted since, are left out
+	require.Len(t, task.TagDetails, 1)
+	assert.Equal(t, "#00ff00", task.TagDetails[0].Color)
+
+	parsed, err := ParseExpansions("watchers, checklist,watchers")
+	require.NoError(t, err)
+	assert.Equal(t, []Expansion{ExpandWatchers, ExpandChecklist}, parsed)
+	_, err = ParseExpansions("subtasks")
+	assert.ErrorIs(t, err, ErrValidation)
+}
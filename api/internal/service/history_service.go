@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+)
+
+// HistoryService serves the recorded audit trail of task writes, per task
+// and as an activity feed across tasks
+type HistoryService struct {
+	repo  repository.HistoryStore
+	tasks repository.TaskStore
+	guard *QueryGuard
+}
+
+// NewHistoryService creates a new HistoryService
+func NewHistoryService(repo repository.HistoryStore, tasks repository.TaskStore, guard *QueryGuard) *HistoryService {
+	return &HistoryService{
+		repo:  repo,
+		tasks: tasks,
+		guard: guard,
+	}
+}
+
+// History returns a page of a task's history, newest first. Soft-deleted
+// tasks keep their history; a task with none is only found if it is live.
+// Under an owner scope the task is always looked up, so only the caller's
+// own live tasks have history.
+func (s *HistoryService) History(ctx context.Context, taskID string, opts *model.ListOptions) (*model.TaskHistoryResponse, error) {
+	if err := s.guard.CheckPage(opts); err != nil {
+		return nil, err
+	}
+
+	if !isValidID(taskID) {
+		return nil, ErrTaskNotFound
+	}
+
+	total, err := s.repo.CountHistory(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count task history: %w", err)
+	}
+
+	if _, scoped := repository.ScopeFrom(ctx); total == 0 || scoped {
+		if _, err := s.tasks.GetByID(ctx, taskID); err != nil {
+			if errors.Is(err, repository.ErrNotOwned) {
+				return nil, denied(ErrTaskNotFound)
+			}
+			if errors.Is(err, repository.ErrTaskNotFound) {
+				return nil, ErrTaskNotFound
+			}
+			return nil, fmt.Errorf("failed to get task: %w", err)
+		}
+	}
+
+	entries, err := s.repo.ListHistory(ctx, taskID, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task history: %w", err)
+	}
+	if entries == nil {
+		entries = []*model.TaskHistoryEntry{}
+	}
+
+	return &model.TaskHistoryResponse{
+		Data:       entries,
+		Pagination: listing.NewPagination(opts.Page, opts.PerPage, total),
+	}, nil
+}
+
+// Activity returns a page of the history of all tasks, newest first
+func (s *HistoryService) Activity(ctx context.Context, filter *model.ActivityFilter, opts *model.ListOptions) (*model.TaskHistoryResponse, error) {
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrValidation)
+	}
+
+	if err := s.guard.CheckPage(opts); err != nil {
+		return nil, err
+	}
+
+	entries, err := s.repo.ListActivity(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list activity: %w", err)
+	}
+	if entries == nil {
+		entries = []*model.TaskHistoryEntry{}
+	}
+
+	total, err := s.repo.CountActivity(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count activity: %w", err)
+	}
+
+	return &model.TaskHistoryResponse{
+		Data:       entries,
+		Pagination: listing.NewPagination(opts.Page, opts.PerPage, total),
+	}, nil
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/audit"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryService_History(t *testing.T) {
+	ctx := audit.WithActor(context.Background(), "admin")
+	repo := repository.NewMemoryTaskRepository(0)
+	events := NewEventService(repository.NewMemoryEventRepository(), kvstore.NewMemory(), &config.EventsConfig{})
+	guard := NewQueryGuard(&config.QueryGuardConfig{DefaultPerPage: 2, MaxPerPage: 10})
+	svc := NewTaskService(repo, nil, nil, events, nil, nil, nil, &config.TaskConfig{DefaultProject: "TASK", BulkMaxIDs: 5})
+	history := NewHistoryService(repo, repo, guard)
+
+	task, err := svc.Create(ctx, &model.CreateTaskRequest{Title: "Audit me"})
+	require.NoError(t, err)
+
+	title := "Audited"
+	_, err = svc.Update(context.Background(), task.ID, &model.UpdateTaskRequest{Title: &title}, repository.AnyVersion)
+	require.NoError(t, err)
+
+	// Rewriting the same values is not recorded
+	_, err = svc.Update(ctx, task.ID, &model.UpdateTaskRequest{Title: &title}, repository.AnyVersion)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.Delete(ctx, task.ID, repository.AnyVersion))
+
+	// History outlives soft deletes, newest first
+	page, err := history.History(ctx, task.ID, &model.ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, page.Pagination.Total)
+	require.Len(t, page.Data, 2)
+	assert.Equal(t, model.HistoryDeleted, page.Data[0].Action)
+	assert.Equal(t, model.HistoryUpdated, page.Data[1].Action)
+	assert.Equal(t, audit.Anonymous, page.Data[1].Actor)
+	assert.JSONEq(t, `"Audit me"`, string(page.Data[1].Changes["title"].From))
+	assert.JSONEq(t, `"Audited"`, string(page.Data[1].Changes["title"].To))
+
+	page, err = history.History(ctx, task.ID, &model.ListOptions{Params: listing.Params{Page: 2}})
+	require.NoError(t, err)
+	require.Len(t, page.Data, 1)
+	assert.Equal(t, model.HistoryCreated, page.Data[0].Action)
+	assert.Equal(t, "admin", page.Data[0].Actor)
+
+	require.NoError(t, svc.HardDelete(ctx, task.ID, repository.AnyVersion))
+	_, err = history.History(ctx, task.ID, &model.ListOptions{})
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+}
+
+func TestHistoryService_Activity(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryTaskRepository(0)
+	events := NewEventService(repository.NewMemoryEventRepository(), kvstore.NewMemory(), &config.EventsConfig{})
+	guard := NewQueryGuard(&config.QueryGuardConfig{DefaultPerPage: 10, MaxPerPage: 10})
+	svc := NewTaskService(repo, nil, nil, events, nil, nil, nil, &config.TaskConfig{DefaultProject: "TASK", BulkMaxIDs: 5})
+	history := NewHistoryService(repo, repo, guard)
+
+	first, err := svc.Create(ctx, &model.CreateTaskRequest{Title: "First"})
+	require.NoError(t, err)
+	second, err := svc.Create(ctx, &model.CreateTaskRequest{Title: "Second"})
+	require.NoError(t, err)
+	require.NoError(t, svc.Delete(ctx, first.ID, repository.AnyVersion))
+
+	feed, err := history.Activity(ctx, &model.ActivityFilter{}, &model.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, feed.Data, 3)
+	assert.Equal(t, model.HistoryDeleted, feed.Data[0].Action)
+	assert.Equal(t, second.ID, feed.Data[1].TaskID)
+
+	feed, err = history.Activity(ctx, &model.ActivityFilter{Actions: []model.HistoryAction{model.HistoryCreated}}, &model.ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, feed.Pagination.Total)
+
+	future := time.Now().Add(time.Hour)
+	feed, err = history.Activity(ctx, &model.ActivityFilter{From: &future}, &model.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, feed.Data)
+
+	past := time.Now().Add(-time.Hour)
+	_, err = history.Activity(ctx, &model.ActivityFilter{From: &future, To: &past}, &model.ListOptions{})
+	assert.ErrorIs(t, err, ErrValidation)
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+// importRow is one line of an import, parsed into a task or an error
+type importRow struct {
+	line int
+	task *model.Task
+	err  string
+}
+
+// Import creates a task for every row of a CSV or NDJSON file. Rows are
+// validated like POST /tasks, and valid ones are inserted
+// TASK_IMPORT_BATCH_SIZE at a time, each batch in its own transaction.
+// Invalid rows, and every row of a batch that failed to insert, are
+// reported with their line instead of failing the import.
+func (s *TaskService) Import(ctx context.Context, format model.ImportFormat, r io.Reader) (*model.ImportReport, error) {
+	data, err := io.ReadAll(io.LimitReader(r, s.cfg.ImportMaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read import: %w", err)
+	}
+	if int64(len(data)) > s.cfg.ImportMaxBytes {
+		return nil, fmt.Errorf("%w: import must be at most %d bytes", ErrValidation, s.cfg.ImportMaxBytes)
+	}
+
+	var rows []importRow
+	switch format {
+	case model.ImportCSV:
+		rows, err = s.csvRows(data)
+	case model.ImportNDJSON:
+		rows, err = s.ndjsonRows(data)
+	default:
+		return nil, fmt.Errorf("%w: format must be csv or ndjson", ErrValidation)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > s.cfg.ImportMaxRows {
+		return nil, fmt.Errorf("%w: import must have at most %d rows", ErrValidation, s.cfg.ImportMaxRows)
+	}
+
+	report := &model.ImportReport{Format: format, Rows: len(rows), Created: []model.ImportedTask{}, Failed: []model.ImportError{}}
+	var batch []importRow
+	for _, row := range rows {
+		if row.err != "" {
+			report.Failed = append(report.Failed, model.ImportError{Line: row.line, Error: row.err})
+			continue
+		}
+		batch = append(batch, row)
+		if len(batch) == s.cfg.ImportBatchSize {
+			s.importBatch(ctx, batch, report)
+			batch = nil
+		}
+	}
+	if len(batch) > 0 {
+		s.importBatch(ctx, batch, report)
+	}
+
+	// Batches finish out of line order when rows before them failed validation
+	slices.SortFunc(report.Failed, func(a, b model.ImportError) int { return a.Line - b.Line })
+
+	return report, nil
+}
+
+// importBatch inserts one batch of rows and records the outcome in report
+func (s *TaskService) importBatch(ctx context.Context, batch []importRow, report *model.ImportReport) {
+	tasks := make([]*model.Task, len(batch))
+	for i, row := range batch {
+		tasks[i] = row.task
+		tasks[i].Owner = taskOwner(ctx)
+	}
+
+	var created []*model.Task
+	err := s.events.Atomically(ctx, func(ctx context.Context) error {
+		var err error
+		if created, err = s.repo.CreateMany(ctx, tasks); err != nil {
+			return err
+		}
+		return s.recordCreated(ctx, created)
+	})
+	if err != nil {
+		message := "failed to create task, its batch was rolled back"
+		if errors.Is(err, repository.ErrStoreFull) {
+			message = ErrLimitReached.Error()
+		} else {
+			logger.Ctx(ctx).Error().Err(err).Int("first_line", batch[0].line).Int("rows", len(batch)).Msg("Failed to import batch")
+		}
+		for _, row := range batch {
+			report.Failed = append(report.Failed, model.ImportError{Line: row.line, Error: message})
+		}
+		return
+	}
+
+	for i, task := range created {
+		report.Created = append(report.Created, model.ImportedTask{Line: batch[i].line, ID: task.ID, Ref: task.Ref().String()})
+	}
+}
+
+// recordCreated records the created events of tasks
+func (s *TaskService) recordCreated(ctx context.Context, tasks []*model.Task) error {
+	for _, task := range tasks {
+		response := task.ToResponse()
+		if _, err := s.events.Record(ctx, model.EventTaskCreated, response.ID, response); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// row validates req as the task of line
+func (s *TaskService) row(line int, req *model.CreateTaskRequest) importRow {
+	task, err := s.newTask(req)
+	if err != nil {
+		return importRow{line: line, err: strings.TrimPrefix(err.Error(), ErrValidation.Error()+": ")}
+	}
+	return importRow{line: line, task: task}
+}
+
+// csvRows parses a CSV file whose header names the columns, in any order
+func (s *TaskService) csvRows(data []byte) ([]importRow, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: CSV import needs a header row", ErrValidation)
+		}
+		return nil, fmt.Errorf("%w: invalid CSV header: %s", ErrValidation, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !slices.Contains(model.ImportColumns, name) {
+			return nil, fmt.Errorf("%w: unknown CSV column %q, expected %s", ErrValidation, name, strings.Join(model.ImportColumns, ", "))
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, fmt.Errorf("%w: CSV import needs a title column", ErrValidation)
+	}
+
+	var rows []importRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("failed to read CSV: %w", err)
+			}
+			rows = append(rows, importRow{line: parseErr.StartLine, err: parseErr.Err.Error()})
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		req := &model.CreateTaskRequest{
+			Title:       field("title"),
+			Description: field("description"),
+			Project:     field("project"),
+			Priority:    model.Priority(field("priority")),
+			Recurrence:  field("recurrence"),
+		}
+		if value := field("due_date"); value != "" {
+			dueDate, err := parseImportDate(value)
+			if err != nil {
+				rows = append(rows, importRow{line: line, err: err.Error()})
+				continue
+			}
+			req.DueDate = &dueDate
+		}
+		rows = append(rows, s.row(line, req))
+	}
+	return rows, nil
+}
+
+// ndjsonRows parses one JSON task per line, skipping blank lines
+func (s *TaskService) ndjsonRows(data []byte) ([]importRow, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+
+	var rows []importRow
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var req model.CreateTaskRequest
+		if err := json.Unmarshal(text, &req); err != nil {
+			rows = append(rows, importRow{line: line, err: "invalid JSON: " + err.Error()})
+			continue
+		}
+		rows = append(rows, s.row(line, &req))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read NDJSON: %w", err)
+	}
+	return rows, nil
+}
+
+// parseImportDate accepts an RFC 3339 timestamp or, for spreadsheets, a
+// plain date meaning the end of that day in UTC
+func parseImportDate(value string) (time.Time, error) {
+	if date, err := time.Parse(time.DateOnly, value); err == nil {
+		return date.Add(24*time.Hour - time.Second), nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.New("due_date must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+	}
+	return date, nil
+}
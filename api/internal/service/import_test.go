@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskService_Import(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryTaskRepository(4)
+	events := NewEventService(repository.NewMemoryEventRepository(), kvstore.NewMemory(), &config.EventsConfig{})
+	cfg := &config.TaskConfig{DefaultProject: "TASK", ImportMaxBytes: 1 << 10, ImportMaxRows: 10, ImportBatchSize: 2}
+	svc := NewTaskService(repo, nil, nil, events, nil, nil, nil, cfg)
+
+	csv := "Title,priority,due_date\n" +
+		"One,high,2999-01-01\n" +
+		",low,\n" +
+		"\"Two, quoted\",,\n" +
+		"Three,critical,\n" +
+		"Four,,yesterday\n"
+	report, err := svc.Import(ctx, model.ImportCSV, strings.NewReader(csv))
+	require.NoError(t, err)
+	assert.Equal(t, 5, report.Rows)
+	require.Len(t, report.Created, 2)
+	assert.Equal(t, 2, report.Created[0].Line)
+	assert.Equal(t, 4, report.Created[1].Line)
+	assert.Equal(t, "TASK-2", report.Created[1].Ref)
+	var lines []int
+	for _, failed := range report.Failed {
+		lines = append(lines, failed.Line)
+	}
+	assert.Equal(t, []int{3, 5, 6}, lines)
+	assert.Equal(t, "Title is required", report.Failed[0].Error)
+
+	task, err := svc.GetByID(ctx, report.Created[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.Priority("high"), task.Priority)
+	assert.Equal(t, 2999, task.DueDate.Year())
+
+	// A batch that does not fit is rolled back as a whole
+	ndjson := "{\"title\":\"A\"}\n\n{\"title\":\"B\"}\n{\"title\":\"C\"}\n{\"title\":\n"
+	report, err = svc.Import(ctx, model.ImportNDJSON, strings.NewReader(ndjson))
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Rows)
+	require.Len(t, report.Created, 2)
+	assert.Equal(t, []int{1, 3}, []int{report.Created[0].Line, report.Created[1].Line})
+	require.Len(t, report.Failed, 2)
+	assert.Equal(t, ErrLimitReached.Error(), report.Failed[0].Error)
+	assert.Equal(t, 5, report.Failed[1].Line)
+
+	_, err = svc.Import(ctx, model.ImportCSV, strings.NewReader("title,owner\nX,me\n"))
+	assert.ErrorIs(t, err, ErrValidation)
+	_, err = svc.Import(ctx, model.ImportCSV, strings.NewReader("title\n"+strings.Repeat("X\n", 11)))
+	assert.ErrorIs(t, err, ErrValidation)
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+)
+
+// lease elects one replica to run a background worker, by holding a key in
+// the shared kv store that expires when the holder stops renewing it
+type lease struct {
+	state kvstore.Store
+	key   string
+	ttl   time.Duration
+	owner string
+}
+
+func newLease(state kvstore.Store, key string, ttl time.Duration) *lease {
+	return &lease{state: state, key: key, ttl: ttl, owner: uuid.NewString()}
+}
+
+// acquire takes the lease when it is free and renews it when held, and
+// reports whether this replica holds it
+func (l *lease) acquire(ctx context.Context) (bool, error) {
+	acquired, err := l.state.SetNX(ctx, l.key, []byte(l.owner), l.ttl)
+	if err != nil || acquired {
+		return acquired, err
+	}
+
+	holder, ok, err := l.state.Get(ctx, l.key)
+	if err != nil || !ok || string(holder) != l.owner {
+		return false, err
+	}
+	return true, l.state.Set(ctx, l.key, []byte(l.owner), l.ttl)
+}
+
+// release hands the lease over early so another replica takes over
+// without waiting for it to expire, e.g. during a rolling deploy
+func (l *lease) release() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	holder, ok, err := l.state.Get(ctx, l.key)
+	if err == nil && ok && string(holder) == l.owner {
+		_ = l.state.Delete(ctx, l.key)
+	}
+}
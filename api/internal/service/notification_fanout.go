@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+const (
+	notificationCursorKey = "notifications:cursor"
+	notificationLeaseKey  = "notifications:lease"
+
+	// notificationLeaseTTL bounds how long fan-out stalls when the replica
+	// holding the lease dies without releasing it
+	notificationLeaseTTL = 30 * time.Second
+
+	// notificationCursorTTL outlives any realistic outage; a lost cursor
+	// restarts fan-out from the newest event
+	notificationCursorTTL = 7 * 24 * time.Hour
+)
+
+// NotificationFanout follows the task event log and enqueues a
+// notification for every watcher of a changed task, except the user who
+// made the change. Like SearchIndexer, one replica at a time holds a lease
+// and does the work. Delivery channels such as email or webhooks drain the
+// notification queue rather than the event log.
+type NotificationFanout struct {
+	events        *EventService
+	watchers      repository.WatcherStore
+	notifications repository.NotificationStore
+	state         kvstore.Store
+	cfg           *config.NotificationConfig
+	lease         *lease
+}
+
+// NewNotificationFanout creates a new NotificationFanout
+func NewNotificationFanout(events *EventService, watchers repository.WatcherStore, notifications repository.NotificationStore, state kvstore.Store, cfg *config.NotificationConfig) *NotificationFanout {
+	return &NotificationFanout{
+		events:        events,
+		watchers:      watchers,
+		notifications: notifications,
+		state:         state,
+		cfg:           cfg,
+		lease:         newLease(state, notificationLeaseKey, notificationLeaseTTL),
+	}
+}
+
+// Run fans out new events until stop is done. A batch in progress when
+// stop fires runs to completion unless work is cancelled too.
+func (f *NotificationFanout) Run(stop, work context.Context) {
+	log := logger.Get().WithComponent("notifications")
+
+	ticker := time.NewTicker(f.cfg.PollInterval)
+	defer ticker.Stop()
+	defer f.lease.release()
+
+	for {
+		changed := f.events.Changed()
+
+		if err := f.sync(work); err != nil && work.Err() == nil {
+			log.Error().Err(err).Msg("Failed to fan out notifications")
+		}
+
+		select {
+		case <-stop.Done():
+			return
+		case <-changed:
+		case <-ticker.C:
+		}
+	}
+}
+
+// sync fans out the events after the cursor. Without a cursor it starts
+// from the newest event, so watchers are not flooded with old changes.
+func (f *NotificationFanout) sync(ctx context.Context) error {
+	leader, err := f.lease.acquire(ctx)
+	if err != nil || !leader {
+		return err
+	}
+
+	cursor, ok, err := f.loadCursor(ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		latest, err := f.events.Latest(ctx)
+		if err != nil {
+			return err
+		}
+		return f.saveCursor(ctx, latest)
+	}
+
+	for {
+		events, gap, err := f.events.After(ctx, cursor, f.cfg.BatchSize)
+		if err != nil {
+			return err
+		}
+		if gap {
+			logger.Get().Warn().Int64("cursor", cursor).Msg("Notification fan-out fell behind the event log, some notifications were lost")
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		if err := f.fanout(ctx, events); err != nil {
+			return err
+		}
+		cursor = events[len(events)-1].ID
+		if err := f.saveCursor(ctx, cursor); err != nil {
+			return err
+		}
+
+		if len(events) < f.cfg.BatchSize {
+			return nil
+		}
+	}
+}
+
+// fanout enqueues the notifications for a batch of events. Enqueueing is
+// idempotent, so a batch repeated after a crash does not notify twice.
+func (f *NotificationFanout) fanout(ctx context.Context, events []*model.TaskEvent) error {
+	taskIDs := make([]string, 0, len(events))
+	for _, event := range events {
+		taskIDs = append(taskIDs, event.TaskID)
+	}
+
+	watchers, err := f.watchers.WatchersOf(ctx, taskIDs)
+	if err != nil {
+		return err
+	}
+
+	var notifications []*model.Notification
+	for _, event := range events {
+		for _, user := range watchers[event.TaskID] {
+			if user == event.Actor {
+				continue
+			}
+			notifications = append(notifications, &model.Notification{
+				User:    user,
+				EventID: event.ID,
+				Type:    event.Type,
+				TaskID:  event.TaskID,
+				Actor:   event.Actor,
+			})
+		}
+	}
+
+	if _, err := f.notifications.CreateMany(ctx, notifications); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (f *NotificationFanout) loadCursor(ctx context.Context) (int64, bool, error) {
+	value, ok, err := f.state.Get(ctx, notificationCursorKey)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to load notification cursor: %w", err)
+	}
+	if !ok {
+		return 0, false, nil
+	}
+
+	cursor, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return 0, false, nil
+	}
+	return cursor, true, nil
+}
+
+func (f *NotificationFanout) saveCursor(ctx context.Context, cursor int64) error {
+	if err := f.state.Set(ctx, notificationCursorKey, []byte(strconv.FormatInt(cursor, 10)), notificationCursorTTL); err != nil {
+		return fmt.Errorf("failed to save notification cursor: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/moabdelazem/mutlitier_app/internal/audit"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationFanout(t *testing.T) {
+	ctx := context.Background()
+	state := kvstore.NewMemory()
+	events := NewEventService(repository.NewMemoryEventRepository(), state, &config.EventsConfig{})
+	watchers := repository.NewMemoryWatcherRepository()
+	notifications := repository.NewMemoryNotificationRepository()
+	fanout := NewNotificationFanout(events, watchers, notifications, state, &config.NotificationConfig{BatchSize: 2})
+
+	inbox := func(user string) []*model.Notification {
+		list, err := notifications.ListByUser(ctx, repository.OwnedBy(user), false, &model.ListOptions{Params: listing.Params{Page: 1, PerPage: 10}})
+		require.NoError(t, err)
+		return list
+	}
+
+	// Events before the first sync are not fanned out
+	require.NoError(t, watchers.Watch(ctx, "a", "alice"))
+	record(t, events, ctx, model.EventTaskCreated, "a", nil)
+	require.NoError(t, fanout.sync(ctx))
+	assert.Empty(t, inbox("alice"))
+
+	// Watchers hear about changes made by others, not their own
+	require.NoError(t, watchers.Watch(ctx, "a", "bob"))
+	record(t, events, audit.WithActor(ctx, "alice"), model.EventTaskUpdated, "a", nil)
+	record(t, events, audit.WithActor(ctx, "carol"), model.EventTaskUpdated, "b", nil)
+	record(t, events, audit.WithActor(ctx, "carol"), model.EventTaskDeleted, "a", nil)
+	require.NoError(t, fanout.sync(ctx))
+
+	require.Len(t, inbox("alice"), 1)
+	assert.Equal(t, model.EventTaskDeleted, inbox("alice")[0].Type)
+	assert.Equal(t, "carol", inbox("alice")[0].Actor)
+	assert.Len(t, inbox("bob"), 2)
+	assert.Empty(t, inbox("carol"))
+
+	// Replaying a batch after a crash does not notify twice
+	replayed, _, err := events.After(ctx, 1, 10)
+	require.NoError(t, err)
+	require.NoError(t, fanout.fanout(ctx, replayed))
+	assert.Len(t, inbox("bob"), 2)
+}
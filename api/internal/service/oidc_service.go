@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/oidc"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+// ErrOIDCState is returned for a callback whose sign-in was not started by
+// this browser, was tampered with or took longer than OIDC_LOGIN_TIMEOUT
+var ErrOIDCState = errors.New("sign-in expired or was not started here")
+
+// usernameInvalid matches characters usernames cannot contain
+var usernameInvalid = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// OIDCLogin is a started sign-in: the provider URL to send the user to,
+// and the state to keep in their browser until the callback
+type OIDCLogin struct {
+	URL       string
+	State     string
+	ExpiresAt time.Time
+}
+
+// oidcState is what a sign-in must remember between the redirect to the
+// provider and the callback. It is kept by the browser, signed so it
+// cannot be forged.
+type oidcState struct {
+	State     string `json:"s"`
+	Nonce     string `json:"n"`
+	Verifier  string `json:"v"`
+	ExpiresAt int64  `json:"e"`
+}
+
+// OIDCService signs users in through an OpenID Connect provider. The
+// provider's issuer and subject identify a user; the first sign-in creates
+// the user, named after OIDC_USERNAME_CLAIM when that name is free.
+// Sessions are the same as after a password sign-in.
+type OIDCService struct {
+	provider *oidc.Provider
+	users    repository.UserStore
+	auth     *AuthService
+	cfg      *config.OIDCConfig
+	secret   []byte
+}
+
+// NewOIDCService creates a new OIDCService signing sign-in state with secret
+func NewOIDCService(provider *oidc.Provider, users repository.UserStore, auth *AuthService, cfg *config.OIDCConfig, secret []byte) *OIDCService {
+	return &OIDCService{provider: provider, users: users, auth: auth, cfg: cfg, secret: secret}
+}
+
+// Begin starts a sign-in
+func (s *OIDCService) Begin(ctx context.Context) (*OIDCLogin, error) {
+	expiresAt := time.Now().Add(s.cfg.LoginTimeout).Truncate(time.Second)
+	state := oidcState{State: rand.Text(), Nonce: rand.Text(), Verifier: rand.Text() + rand.Text(), ExpiresAt: expiresAt.Unix()}
+
+	url, err := s.provider.AuthCodeURL(ctx, state.State, state.Nonce, state.Verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode sign-in state: %w", err)
+	}
+	payload := base64.RawURLEncoding.EncodeToString(encoded)
+	return &OIDCLogin{URL: url, State: payload + "." + s.sign(payload), ExpiresAt: expiresAt}, nil
+}
+
+// Complete finishes the sign-in kept in stored, the state Begin returned,
+// with the state and code the provider called back with. It returns the
+// session and the user signed in, or the provider's subject when the
+// sign-in failed after the ID token was verified.
+func (s *OIDCService) Complete(ctx context.Context, stored, state, code string) (*model.SessionResponse, string, error) {
+	saved, err := s.open(stored)
+	if err != nil || !hmac.Equal([]byte(saved.State), []byte(state)) || code == "" {
+		return nil, "", ErrOIDCState
+	}
+
+	identity, err := s.provider.Exchange(ctx, code, saved.Verifier, saved.Nonce)
+	if err != nil {
+		return nil, "", err
+	}
+
+	user, err := s.user(ctx, identity)
+	if err != nil {
+		return nil, identity.Subject, err
+	}
+
+	session, err := s.auth.SignIn(ctx, user)
+	if err != nil {
+		return nil, user, err
+	}
+	return session, user, nil
+}
+
+// user returns the user identity is linked to, creating one on first
+// sign-in
+func (s *OIDCService) user(ctx context.Context, identity *oidc.Identity) (string, error) {
+	user, err := s.users.Identity(ctx, identity.Issuer, identity.Subject)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, repository.ErrUserNotFound) {
+		return "", fmt.Errorf("failed to get identity: %w", err)
+	}
+
+	// A taken name is never linked, as that would hand the existing
+	// user's tasks to whoever controls the name at the provider
+	for _, name := range s.usernames(identity) {
+		err := s.users.Link(ctx, identity.Issuer, identity.Subject, name)
+		if err == nil {
+			logger.Ctx(ctx).Info().Str("user", name).Str("issuer", identity.Issuer).Msg("Created user on first OIDC sign-in")
+			return name, nil
+		}
+		if !errors.Is(err, repository.ErrUserExists) {
+			return "", fmt.Errorf("failed to link identity: %w", err)
+		}
+	}
+
+	// A concurrent first sign-in may have linked it meanwhile
+	user, err = s.users.Identity(ctx, identity.Issuer, identity.Subject)
+	if err != nil {
+		return "", fmt.Errorf("failed to link identity: %w", err)
+	}
+	return user, nil
+}
+
+// usernames returns the names a new user may get, in order of preference:
+// the username claim (or the local part of the email), then that name
+// made unique with a hash of the identity
+func (s *OIDCService) usernames(identity *oidc.Identity) []string {
+	sum := sha256.Sum256([]byte(identity.Issuer + "\x00" + identity.Subject))
+	suffix := hex.EncodeToString(sum[:])
+
+	claimed := identity.Claim(s.cfg.UsernameClaim)
+	if claimed == "" {
+		claimed, _, _ = strings.Cut(identity.Claim("email"), "@")
+	}
+	claimed = strings.Trim(usernameInvalid.ReplaceAllString(normalizeUsername(claimed), "-"), "-._")
+	if len(claimed) > 48 {
+		claimed = claimed[:48]
+	}
+
+	var names []string
+	for _, name := range []string{claimed, claimed + "-" + suffix[:6]} {
+		if model.ValidUsername(name) {
+			names = append(names, name)
+		}
+	}
+	return append(names, "oidc-"+suffix[:12])
+}
+
+// open verifies stored sign-in state and decodes it
+func (s *OIDCService) open(stored string) (*oidcState, error) {
+	payload, signature, ok := strings.Cut(stored, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.sign(payload))) {
+		return nil, ErrOIDCState
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrOIDCState
+	}
+	var state oidcState
+	if err := json.Unmarshal(decoded, &state); err != nil || time.Now().Unix() >= state.ExpiresAt {
+		return nil, ErrOIDCState
+	}
+	return &state, nil
+}
+
+// sign returns the signature of sign-in state, keyed apart from session
+// tokens signed with the same secret
+func (s *OIDCService) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("oidc-state."))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/oidc"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProvider is an OpenID Connect provider issuing ID tokens for the
+// claims of the next code exchanged
+type fakeProvider struct {
+	*httptest.Server
+	key    *rsa.PrivateKey
+	claims map[string]any
+	// challenges maps the codes handed out to their PKCE challenge
+	challenges map[string]string
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	p := &fakeProvider{key: key, challenges: make(map[string]string)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.URL,
+			"authorization_endpoint": p.URL + "/authorize",
+			"token_endpoint":         p.URL + "/token",
+			"jwks_uri":               p.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("GET /keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA", "kid": "k1", "use": "sig",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		challenge := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if id != "tasks" || secret != "shh" || p.challenges[r.FormValue("code")] != base64.RawURLEncoding.EncodeToString(challenge[:]) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": p.sign(t, p.claims)})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+// authorize signs in at the provider URL a sign-in was sent to, returning
+// the state and code of the callback
+func (p *fakeProvider) authorize(t *testing.T, login *OIDCLogin, claims map[string]any) (string, string) {
+	target, err := url.Parse(login.URL)
+	require.NoError(t, err)
+	query := target.Query()
+
+	code := rand.Text()
+	p.challenges[code] = query.Get("code_challenge")
+	p.claims = map[string]any{"iss": p.URL, "aud": "tasks", "exp": time.Now().Add(time.Minute).Unix(), "nonce": query.Get("nonce")}
+	for name, value := range claims {
+		p.claims[name] = value
+	}
+	return query.Get("state"), code
+}
+
+func (p *fakeProvider) sign(t *testing.T, claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestOIDCService(t *testing.T) {
+	ctx := context.Background()
+	provider := newFakeProvider(t)
+	users := repository.NewMemoryUserRepository()
+	secret := []byte(strings.Repeat("s", 32))
+	authService := NewAuthService(users, repository.NewMemoryRefreshTokenRepository(), repository.NewMemorySessionRepository(), nil, &config.JWTConfig{Secret: string(secret), Issuer: "tasks-api", TTL: time.Minute, RefreshTTL: time.Hour})
+	cfg := &config.OIDCConfig{
+		IssuerURL: provider.URL, ClientID: "tasks", ClientSecret: "shh", RedirectURL: "https://tasks.example.com/auth/oidc/callback",
+		Scopes: []string{"profile"}, UsernameClaim: "preferred_username", LoginTimeout: time.Minute,
+	}
+	svc := NewOIDCService(oidc.NewProvider(cfg, provider.Client()), users, authService, cfg, secret)
+
+	signIn := func(claims map[string]any) (string, error) {
+		login, err := svc.Begin(ctx)
+		require.NoError(t, err)
+		state, code := provider.authorize(t, login, claims)
+		session, user, err := svc.Complete(ctx, login.State, state, code)
+		if err != nil {
+			return user, err
+		}
+		principal, err := authService.Verify(ctx, session.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, user, principal.User)
+		assert.NotEmpty(t, session.RefreshToken)
+		return user, nil
+	}
+
+	login, err := svc.Begin(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, login.URL, provider.URL+"/authorize?")
+	assert.Contains(t, login.URL, "scope=openid+profile")
+	assert.Contains(t, login.URL, "code_challenge_method=S256")
+
+	// The first sign-in creates the user, later ones find it by subject
+	// whatever the provider now calls them
+	user, err := signIn(map[string]any{"sub": "u-1", "preferred_username": "Alice"})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user)
+	user, err = signIn(map[string]any{"sub": "u-1", "preferred_username": "alice.renamed"})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user)
+
+	// Taken names are never linked to another identity
+	user, err = signIn(map[string]any{"sub": "u-2", "preferred_username": "alice"})
+	require.NoError(t, err)
+	assert.Regexp(t, `^alice-[0-9a-f]{6}$`, user)
+	user, err = signIn(map[string]any{"sub": "u-3", "email": "Bob@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "bob", user)
+	user, err = signIn(map[string]any{"sub": "u-4", "preferred_username": "admin"})
+	require.NoError(t, err)
+	assert.Regexp(t, `^admin-[0-9a-f]{6}$`, user)
+
+	// Tokens for another client, with another nonce or expired fail
+	for _, claims := range []map[string]any{
+		{"sub": "u-5", "aud": "other"},
+		{"sub": "u-5", "nonce": "replayed"},
+		{"sub": "u-5", "exp": time.Now().Add(-time.Hour).Unix()},
+		{"sub": "u-5", "iss": "https://evil.example.com"},
+	} {
+		_, err := signIn(claims)
+		assert.ErrorIs(t, err, oidc.ErrInvalidIDToken, claims)
+	}
+
+	// Callbacks of another sign-in, forged or expired state are refused
+	login, err = svc.Begin(ctx)
+	require.NoError(t, err)
+	state, code := provider.authorize(t, login, map[string]any{"sub": "u-6"})
+	other, err := svc.Begin(ctx)
+	require.NoError(t, err)
+	_, _, err = svc.Complete(ctx, other.State, state, code)
+	assert.ErrorIs(t, err, ErrOIDCState)
+	_, _, err = svc.Complete(ctx, login.State+"x", state, code)
+	assert.ErrorIs(t, err, ErrOIDCState)
+	_, _, err = svc.Complete(ctx, "", state, code)
+	assert.ErrorIs(t, err, ErrOIDCState)
+
+	cfg.LoginTimeout = -time.Minute
+	login, err = svc.Begin(ctx)
+	require.NoError(t, err)
+	state, code = provider.authorize(t, login, map[string]any{"sub": "u-6"})
+	_, _, err = svc.Complete(ctx, login.State, state, code)
+	assert.ErrorIs(t, err, ErrOIDCState)
+}
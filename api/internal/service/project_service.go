@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+)
+
+var (
+	ErrProjectNotFound = errors.New("project not found")
+	ErrProjectExists   = errors.New("project already exists")
+	ErrProjectHasTasks = errors.New("project has tasks")
+)
+
+// ProjectService handles business logic for projects. Projects are
+// shared by everyone in their tenant, so any signed-in user may create
+// one but only admins may rename or delete it.
+type ProjectService struct {
+	repo     repository.ProjectStore
+	validate *validator.Validate
+}
+
+// NewProjectService creates a new ProjectService
+func NewProjectService(repo repository.ProjectStore) *ProjectService {
+	validate := validator.New()
+	validate.RegisterValidation("project_key", func(fl validator.FieldLevel) bool {
+		return model.ValidProjectKey(fl.Field().String())
+	})
+
+	return &ProjectService{repo: repo, validate: validate}
+}
+
+// Create creates a new, empty project
+func (s *ProjectService) Create(ctx context.Context, req *model.CreateProjectRequest) (*model.ProjectResponse, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, formatValidationErrors(err))
+	}
+	if _, err := caller(ctx); err != nil {
+		return nil, fmt.Errorf("%w: sign in to create projects", err)
+	}
+
+	created, err := s.repo.CreateProject(ctx, &model.Project{Key: req.Key, Name: req.Name, Description: req.Description})
+	if err != nil {
+		if errors.Is(err, repository.ErrProjectExists) {
+			return nil, ErrProjectExists
+		}
+		return nil, fmt.Errorf("failed to create project: %w", err)
+	}
+
+	return created.ToResponse(), nil
+}
+
+// Get retrieves a project by its key
+func (s *ProjectService) Get(ctx context.Context, key string) (*model.ProjectResponse, error) {
+	if !model.ValidProjectKey(key) {
+		return nil, ErrProjectNotFound
+	}
+
+	project, err := s.repo.GetProject(ctx, key)
+	if err != nil {
+		if errors.Is(err, repository.ErrProjectNotFound) {
+			return nil, ErrProjectNotFound
+		}
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+
+	return project.ToResponse(), nil
+}
+
+// List returns every project ordered by key
+func (s *ProjectService) List(ctx context.Context) ([]*model.ProjectResponse, error) {
+	projects, err := s.repo.ListProjects(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+
+	responses := make([]*model.ProjectResponse, 0, len(projects))
+	for _, project := range projects {
+		responses = append(responses, project.ToResponse())
+	}
+
+	return responses, nil
+}
+
+// Update renames a project or changes its description; the key stays
+func (s *ProjectService) Update(ctx context.Context, key string, req *model.UpdateProjectRequest) (*model.ProjectResponse, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, formatValidationErrors(err))
+	}
+
+	if err := s.authorize(ctx, key); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.repo.UpdateProject(ctx, key, req)
+	if err != nil {
+		if errors.Is(err, repository.ErrProjectNotFound) {
+			return nil, ErrProjectNotFound
+		}
+		return nil, fmt.Errorf("failed to update project: %w", err)
+	}
+
+	return updated.ToResponse(), nil
+}
+
+// Delete deletes a project that has no tasks left, deleted ones included
+func (s *ProjectService) Delete(ctx context.Context, key string) error {
+	if err := s.authorize(ctx, key); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteProject(ctx, key); err != nil {
+		if errors.Is(err, repository.ErrProjectNotFound) {
+			return ErrProjectNotFound
+		}
+		if errors.Is(err, repository.ErrProjectHasTasks) {
+			return ErrProjectHasTasks
+		}
+		return fmt.Errorf("failed to delete project: %w", err)
+	}
+
+	return nil
+}
+
+// authorize returns nil when the caller may change project key: an
+// ErrAnonymous error for anonymous callers, ErrProjectNotFound when the
+// project does not exist and ErrForbidden for callers who are not admins
+func (s *ProjectService) authorize(ctx context.Context, key string) error {
+	if _, err := caller(ctx); err != nil {
+		return fmt.Errorf("%w: sign in to change projects", err)
+	}
+	if _, err := s.Get(ctx, key); err != nil {
+		return err
+	}
+	if !auth.FromContext(ctx).Has(auth.ScopeAdmin) {
+		return denied(ErrProjectNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectService_Authorization(t *testing.T) {
+	ctx := context.Background()
+	svc := NewProjectService(repository.NewMemoryTaskRepository(0))
+	alice := auth.WithPrincipal(ctx, &auth.Principal{User: "alice"})
+	admin := auth.WithPrincipal(ctx, &auth.Principal{User: "admin", Scopes: auth.Scopes()})
+	name := "Platform"
+	rename := &model.UpdateProjectRequest{Name: &name}
+
+	_, err := svc.Create(ctx, &model.CreateProjectRequest{Key: "OPS", Name: "Operations"})
+	assert.ErrorIs(t, err, ErrAnonymous)
+	_, err = svc.Create(alice, &model.CreateProjectRequest{Key: "OPS", Name: "Operations"})
+	require.NoError(t, err)
+
+	// Only admins change a project, and a missing one is missing for all
+	_, err = svc.Update(ctx, "OPS", rename)
+	assert.ErrorIs(t, err, ErrAnonymous)
+	assert.ErrorIs(t, svc.Delete(ctx, "OPS"), ErrAnonymous)
+	_, err = svc.Update(alice, "OPS", rename)
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.ErrorIs(t, err, ErrProjectNotFound)
+	assert.ErrorIs(t, svc.Delete(alice, "OPS"), ErrForbidden)
+	_, err = svc.Update(alice, "NOPE", rename)
+	assert.ErrorIs(t, err, ErrProjectNotFound)
+	assert.NotErrorIs(t, err, ErrForbidden)
+
+	got, err := svc.Get(ctx, "OPS")
+	require.NoError(t, err)
+	assert.Equal(t, "Operations", got.Name)
+
+	updated, err := svc.Update(admin, "OPS", rename)
+	require.NoError(t, err)
+	assert.Equal(t, "Platform", updated.Name)
+	require.NoError(t, svc.Delete(admin, "OPS"))
+	_, err = svc.Get(ctx, "OPS")
+	assert.ErrorIs(t, err, ErrProjectNotFound)
+}
+
+func TestProjectService_Tenant(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryTaskRepository(0)
+	events := NewEventService(repository.NewMemoryEventRepository(), kvstore.NewMemory(), &config.EventsConfig{})
+	guard := NewQueryGuard(&config.QueryGuardConfig{DefaultPerPage: 10, MaxPerPage: 10})
+	tasks := NewTaskService(repo, guard, nil, events, nil, nil, nil, &config.TaskConfig{DefaultProject: "TASK"})
+	svc := NewProjectService(repo)
+	admin := &auth.Principal{User: "admin", Scopes: auth.Scopes()}
+	acme := auth.WithPrincipal(tenant.With(ctx, "acme"), admin)
+	globex := auth.WithPrincipal(tenant.With(ctx, "globex"), admin)
+
+	_, err := svc.Create(acme, &model.CreateProjectRequest{Key: "OPS", Name: "Acme operations"})
+	require.NoError(t, err)
+	_, err = repo.Create(acme, &model.Task{ID: uuid.NewString(), ProjectKey: "WEB", Title: "Launch",
+		Status: model.StatusPending, Priority: model.PriorityMedium})
+	require.NoError(t, err)
+
+	// Another tenant neither sees nor changes them
+	listed, err := svc.List(globex)
+	require.NoError(t, err)
+	assert.Empty(t, listed)
+	for _, key := range []string{"OPS", "WEB"} {
+		_, err = svc.Get(globex, key)
+		assert.ErrorIs(t, err, ErrProjectNotFound)
+		name := "Hijacked"
+		_, err = svc.Update(globex, key, &model.UpdateProjectRequest{Name: &name})
+		assert.ErrorIs(t, err, ErrProjectNotFound)
+		assert.ErrorIs(t, svc.Delete(globex, key), ErrProjectNotFound)
+	}
+
+	// and may use the same key, with its own numbering
+	created, err := svc.Create(globex, &model.CreateProjectRequest{Key: "OPS", Name: "Globex operations"})
+	require.NoError(t, err)
+	assert.Equal(t, "Globex operations", created.Name)
+	task, err := tasks.Create(globex, &model.CreateTaskRequest{Title: "Rotate keys", Project: "WEB"})
+	require.NoError(t, err)
+	assert.Equal(t, "WEB-1", task.Ref)
+
+	listed, err = svc.List(acme)
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+	assert.Equal(t, "Acme operations", listed[0].Name)
+	assert.Equal(t, "WEB", listed[1].Key)
+	listed, err = svc.List(globex)
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+	assert.Equal(t, "Globex operations", listed[0].Name)
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+	"github.com/moabdelazem/mutlitier_app/pkg/cursor"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+var (
+	ErrQueryTooExpensive = errors.New("query too expensive")
+)
+
+// indexedSortColumns are the task columns backed by an index
+var indexedSortColumns = []string{"created_at", "updated_at", "title", "status", "priority", "position"}
+
+// QueryGuard rejects or downgrades list requests that would force
+// full-table scans, such as huge pages, unindexed sorts or unanchored searches
+type QueryGuard struct {
+	cfg     *config.QueryGuardConfig
+	cursors *cursor.Codec
+}
+
+// NewQueryGuard creates a new QueryGuard. Without a configured cursor
+// secret a random one is used, so cursors only work on this process.
+func NewQueryGuard(cfg *config.QueryGuardConfig) *QueryGuard {
+	secret := []byte(cfg.CursorSecret)
+	if len(secret) == 0 {
+		logger.Get().Warn().Msg("LIST_CURSOR_SECRET is not set, pagination cursors only work on the replica that issued them")
+		secret = make([]byte, 32)
+		_, _ = rand.Read(secret)
+	}
+	return &QueryGuard{cfg: cfg, cursors: cursor.NewCodec(secret, cfg.CursorTTL)}
+}
+
+// Check validates and normalizes list options in place, resolving an
+// assignee of "me" to the caller
+func (g *QueryGuard) Check(ctx context.Context, opts *model.ListOptions) error {
+	if err := g.CheckPage(opts); err != nil {
+		return err
+	}
+
+	if err := opts.NormalizeSort(indexedSortColumns); err != nil {
+		var invalid *listing.Error
+		if errors.As(err, &invalid) && invalid.Param == "sort" {
+			return fmt.Errorf("%w: sorting by %q is not supported because it is not indexed, use one of: %s",
+				ErrQueryTooExpensive, opts.Sort, strings.Join(indexedSortColumns, ", "))
+		}
+		return listingError(err)
+	}
+
+	opts.Search = strings.TrimSpace(opts.Search)
+	if strings.HasPrefix(opts.Search, "%") || strings.HasPrefix(opts.Search, "*") || strings.HasPrefix(opts.Search, "_") {
+		return fmt.Errorf("%w: search must not start with a wildcard, searches match title prefixes", ErrQueryTooExpensive)
+	}
+
+	if opts.Assignee != "" {
+		// Resolved before the cursor hash, so a cursor only pages one user's list
+		assignee, err := resolveAssignee(ctx, opts.Assignee)
+		if err != nil {
+			return err
+		}
+		opts.Assignee = assignee
+	}
+
+	return g.resolveCursor(ctx, opts)
+}
+
+// resolveCursor replaces a cursor with the page it points at. Filters
+// are normalized by now, so any change since the cursor was issued shows
+// up as a different filter hash.
+func (g *QueryGuard) resolveCursor(ctx context.Context, opts *model.ListOptions) error {
+	if opts.Cursor == "" {
+		return nil
+	}
+	if opts.Page > 1 {
+		return fmt.Errorf("%w: use either page or cursor, not both", ErrValidation)
+	}
+
+	offset, err := g.cursors.Decode(opts.Cursor, filterHash(ctx, opts))
+	switch {
+	case errors.Is(err, cursor.ErrFilterMismatch):
+		return fmt.Errorf("%w: cursor was issued for different filters, restart pagination without cursor", ErrValidation)
+	case errors.Is(err, cursor.ErrExpired):
+		return fmt.Errorf("%w: cursor expired, restart pagination without cursor", ErrValidation)
+	case err != nil:
+		return fmt.Errorf("%w: invalid cursor", ErrValidation)
+	}
+
+	opts.Page = offset/opts.PerPage + 1
+	return nil
+}
+
+// SetNextCursor adds a cursor for the next page to pagination metadata
+// of a list checked with Check
+func (g *QueryGuard) SetNextCursor(ctx context.Context, opts *model.ListOptions, p *listing.Pagination) {
+	if p.NextPage == nil {
+		return
+	}
+	p.NextCursor = g.cursors.Encode(opts.Page*opts.PerPage, filterHash(ctx, opts))
+}
+
+// filterHash identifies everything but the position of a list request,
+// including whose list it is: the tenant and caller, who may see other
+// tasks under the same filters
+func filterHash(ctx context.Context, opts *model.ListOptions) string {
+	priorities := make([]string, len(opts.Priorities))
+	for i, priority := range opts.Priorities {
+		priorities[i] = string(priority)
+	}
+	slices.Sort(priorities)
+	tags := slices.Sorted(slices.Values(opts.Tags))
+
+	user := ""
+	if principal := auth.FromContext(ctx); principal != nil {
+		user = principal.User
+	}
+
+	return cursor.FilterHash(
+		tenant.From(ctx), user,
+		opts.Sort, opts.Order, opts.Search,
+		strings.Join(priorities, ","), strconv.FormatBool(opts.Overdue), strings.Join(tags, ","),
+		strconv.Itoa(opts.PerPage), strconv.FormatBool(opts.IncludeArchived), opts.Assignee, opts.Project,
+	)
+}
+
+// CheckPage validates and normalizes only the page and page size, for
+// endpoints such as full-text search that order results themselves
+func (g *QueryGuard) CheckPage(opts *model.ListOptions) error {
+	return listingError(opts.Normalize(listing.Limits{
+		DefaultPerPage: g.cfg.DefaultPerPage,
+		MaxPerPage:     g.cfg.MaxPerPage,
+		Clamp:          g.cfg.Mode == "downgrade",
+	}))
+}
+
+// listingError maps a listing error to ErrQueryTooExpensive for pages
+// that are too large, ErrValidation otherwise
+func listingError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, listing.ErrTooLarge):
+		return fmt.Errorf("%w: %s", ErrQueryTooExpensive, err)
+	default:
+		return fmt.Errorf("%w: %s", ErrValidation, err)
+	}
+}
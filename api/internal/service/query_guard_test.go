@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryGuard_Check(t *testing.T) {
+	cfg := &config.QueryGuardConfig{DefaultPerPage: 20, MaxPerPage: 100, Mode: "reject"}
+
+	tests := []struct {
+		name        string
+		opts        model.ListOptions
+		expectedErr error
+		expected    model.ListOptions
+	}{
+		{"defaults", model.ListOptions{}, nil, model.ListOptions{Params: listing.Params{Page: 1, PerPage: 20, Sort: "created_at", Order: "desc"}}},
+		{"indexed sort", model.ListOptions{Params: listing.Params{Sort: "status"}}, nil, model.ListOptions{Params: listing.Params{Page: 1, PerPage: 20, Sort: "status", Order: "desc"}}},
+		{"normalized sort", model.ListOptions{Params: listing.Params{Sort: " Title ", Order: "ASC"}}, nil, model.ListOptions{Params: listing.Params{Page: 1, PerPage: 20, Sort: "title", Order: "asc"}}},
+		{"invalid order", model.ListOptions{Params: listing.Params{Order: "sideways"}}, ErrValidation, model.ListOptions{}},
+		{"huge page", model.ListOptions{Params: listing.Params{PerPage: 5000}}, ErrQueryTooExpensive, model.ListOptions{}},
+		{"unindexed sort", model.ListOptions{Params: listing.Params{Sort: "description"}}, ErrQueryTooExpensive, model.ListOptions{}},
+		{"unanchored search", model.ListOptions{Search: "%report"}, ErrQueryTooExpensive, model.ListOptions{}},
+		{"explicit page", model.ListOptions{Params: listing.Params{Page: 3}}, nil, model.ListOptions{Params: listing.Params{Page: 3, PerPage: 20, Sort: "created_at", Order: "desc"}}},
+		{"negative page", model.ListOptions{Params: listing.Params{Page: -1}}, ErrValidation, model.ListOptions{}},
+		{"negative per_page", model.ListOptions{Params: listing.Params{PerPage: -1}}, ErrValidation, model.ListOptions{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			err := NewQueryGuard(cfg).Check(context.Background(), &opts)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, opts)
+		})
+	}
+}
+
+func TestQueryGuard_Downgrade(t *testing.T) {
+	guard := NewQueryGuard(&config.QueryGuardConfig{DefaultPerPage: 20, MaxPerPage: 100, Mode: "downgrade"})
+
+	opts := model.ListOptions{Params: listing.Params{PerPage: 5000}}
+	assert.NoError(t, guard.Check(context.Background(), &opts))
+	assert.Equal(t, 100, opts.PerPage)
+}
+
+func TestQueryGuard_Cursor(t *testing.T) {
+	guard := NewQueryGuard(&config.QueryGuardConfig{DefaultPerPage: 20, MaxPerPage: 100, CursorSecret: "secret", CursorTTL: time.Hour})
+
+	first := model.ListOptions{Params: listing.Params{Page: 2, Sort: "title"}, Tags: []string{"b", "a"}}
+	assert.NoError(t, guard.Check(context.Background(), &first))
+	pagination := listing.NewPagination(first.Page, first.PerPage, 100)
+	guard.SetNextCursor(context.Background(), &first, &pagination)
+	assert.NotEmpty(t, pagination.NextCursor)
+
+	// Same filters, tag order aside: the cursor resolves to the next page
+	next := model.ListOptions{Params: listing.Params{Sort: "title"}, Tags: []string{"a", "b"}, Cursor: pagination.NextCursor}
+	assert.NoError(t, guard.Check(context.Background(), &next))
+	assert.Equal(t, 3, next.Page)
+
+	changed := model.ListOptions{Params: listing.Params{Sort: "status"}, Tags: []string{"a", "b"}, Cursor: pagination.NextCursor}
+	err := guard.Check(context.Background(), &changed)
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.Contains(t, err.Error(), "different filters")
+
+	forged := model.ListOptions{Params: listing.Params{Sort: "title"}, Tags: []string{"a", "b"}, Cursor: "eyJvIjo1MDAwfQ." + pagination.NextCursor[len(pagination.NextCursor)-10:]}
+	assert.ErrorIs(t, guard.Check(context.Background(), &forged), ErrValidation)
+}
+
+func TestQueryGuard_CursorCaller(t *testing.T) {
+	guard := NewQueryGuard(&config.QueryGuardConfig{DefaultPerPage: 20, MaxPerPage: 100, CursorSecret: "secret", CursorTTL: time.Hour})
+	caller := func(tenantID, user string) context.Context {
+		return auth.WithPrincipal(tenant.With(context.Background(), tenantID), &auth.Principal{User: user})
+	}
+	alice := caller("acme", "alice")
+
+	first := model.ListOptions{Assignee: model.AssigneeMe}
+	assert.NoError(t, guard.Check(alice, &first))
+	assert.Equal(t, "alice", first.Assignee)
+	pagination := listing.NewPagination(first.Page, first.PerPage, 100)
+	guard.SetNextCursor(alice, &first, &pagination)
+
+	next := func(ctx context.Context) error {
+		opts := model.ListOptions{Assignee: model.AssigneeMe, Cursor: pagination.NextCursor}
+		return guard.Check(ctx, &opts)
+	}
+	assert.NoError(t, next(alice))
+	// Another caller's "me", or alice in another tenant, is another list
+	assert.ErrorIs(t, next(caller("acme", "bob")), ErrValidation)
+	assert.ErrorIs(t, next(caller("globex", "alice")), ErrValidation)
+	assert.ErrorIs(t, next(context.Background()), ErrAnonymous)
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/mutlitier_app/internal/audit"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+// RecurrenceActor is who occurrences are attributed to in task history
+const RecurrenceActor = "recurrence"
+
+// RecurrenceScheduler creates the next occurrence of recurring tasks once
+// they are completed. Every replica may run it: the store links each
+// occurrence to its predecessor atomically, so only one is ever created.
+type RecurrenceScheduler struct {
+	store  repository.RecurrenceStore
+	events *EventService
+	cfg    *config.RecurrenceConfig
+}
+
+// NewRecurrenceScheduler creates a new RecurrenceScheduler
+func NewRecurrenceScheduler(store repository.RecurrenceStore, events *EventService, cfg *config.RecurrenceConfig) *RecurrenceScheduler {
+	return &RecurrenceScheduler{store: store, events: events, cfg: cfg}
+}
+
+// Run creates pending occurrences every RECURRENCE_POLL_INTERVAL and after
+// every task change on this replica, until stop is cancelled. A batch in
+// progress finishes using work.
+func (s *RecurrenceScheduler) Run(stop, work context.Context) {
+	log := logger.Get().WithComponent("recurrence")
+
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Subscribe before checking so a completion during the check is not missed
+		changed := s.events.Changed()
+
+		if created, err := s.Materialize(work); err != nil {
+			log.Error().Err(err).Msg("Failed to create recurring task occurrences")
+		} else if created > 0 {
+			log.Debug().Int("created", created).Msg("Created recurring task occurrences")
+		}
+
+		select {
+		case <-stop.Done():
+			return
+		case <-ticker.C:
+		case <-changed:
+		}
+	}
+}
+
+// Materialize creates the next occurrence of up to RECURRENCE_BATCH_SIZE
+// completed recurring tasks and returns how many it created
+func (s *RecurrenceScheduler) Materialize(ctx context.Context) (int, error) {
+	tasks, err := s.store.ListPendingRecurrences(ctx, s.cfg.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list pending recurrences: %w", err)
+	}
+
+	ctx = audit.WithActor(ctx, RecurrenceActor)
+	created := 0
+	for _, task := range tasks {
+		_, err := s.events.Write(ctx, model.EventTaskCreated, func(ctx context.Context) (*model.Task, error) {
+			return s.next(ctx, task)
+		})
+		if err != nil {
+			if errors.Is(err, repository.ErrNotRecurring) {
+				continue
+			}
+			return created, err
+		}
+		created++
+	}
+
+	return created, nil
+}
+
+// next creates the occurrence following a completed task
+func (s *RecurrenceScheduler) next(ctx context.Context, task *model.Task) (*model.Task, error) {
+	dueDate, err := model.NextOccurrence(*task.Recurrence, task.DueDate, time.Now())
+	if err != nil {
+		// Validated on write, so only a stored expression this version cannot parse gets here
+		logger.Get().Warn().Err(err).Str("task_id", task.ID).Str("recurrence", *task.Recurrence).
+			Msg("Skipping task with an invalid recurrence")
+		return nil, repository.ErrNotRecurring
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate task id: %w", err)
+	}
+
+	next, err := s.store.CreateOccurrence(ctx, task.ID, &model.Task{
+		ID:          id.String(),
+		ProjectKey:  task.ProjectKey,
+		Title:       task.Title,
+		Description: task.Description,
+		Priority:    task.Priority,
+		DueDate:     &dueDate,
+		Recurrence:  task.Recurrence,
+		Assignee:    task.Assignee,
+		Owner:       task.Owner,
+		Team:        task.Team,
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotRecurring) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to create occurrence of task %s: %w", task.ID, err)
+	}
+
+	return next, nil
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecurrenceScheduler_Materialize(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryTaskRepository(0)
+	events := NewEventService(repository.NewMemoryEventRepository(), kvstore.NewMemory(), &config.EventsConfig{})
+	svc := NewTaskService(repo, nil, nil, events, nil, nil, nil, &config.TaskConfig{DefaultProject: "TASK", BulkMaxIDs: 5})
+	scheduler := NewRecurrenceScheduler(repo, events, &config.RecurrenceConfig{BatchSize: 10})
+
+	_, err := svc.Create(ctx, &model.CreateTaskRequest{Title: "Bad", Recurrence: "every monday"})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	task, err := svc.Create(ctx, &model.CreateTaskRequest{Title: "Water plants", Priority: model.PriorityHigh, Recurrence: "0 9 * * *"})
+	require.NoError(t, err)
+	_, err = repo.CreateTag(ctx, &model.Tag{ID: uuid.NewString(), Name: "home"})
+	require.NoError(t, err)
+	_, err = repo.AttachTags(ctx, task.ID, []string{"home"})
+	require.NoError(t, err)
+
+	// Nothing to do until the task is completed
+	created, err := scheduler.Materialize(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, created)
+
+	for _, status := range []model.Status{model.StatusInProgress, model.StatusCompleted} {
+		_, err = svc.Update(ctx, task.ID, &model.UpdateTaskRequest{Status: &status}, repository.AnyVersion)
+		require.NoError(t, err)
+	}
+
+	created, err = scheduler.Materialize(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, created)
+
+	// Only one occurrence per completion
+	created, err = scheduler.Materialize(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, created)
+
+	done, err := svc.GetByID(ctx, task.ID)
+	require.NoError(t, err)
+	require.NotNil(t, done.NextOccurrenceID)
+
+	next, err := svc.GetByID(ctx, *done.NextOccurrenceID)
+	require.NoError(t, err)
+	assert.Equal(t, "Water plants", next.Title)
+	assert.Equal(t, model.StatusPending, next.Status)
+	assert.Equal(t, model.PriorityHigh, next.Priority)
+	assert.Equal(t, []string{"home"}, next.Tags)
+	assert.Equal(t, "0 9 * * *", *next.Recurrence)
+	require.NotNil(t, next.DueDate)
+	assert.True(t, next.DueDate.After(time.Now()))
+	assert.Equal(t, 9, next.DueDate.Hour())
+
+	// Clearing the recurrence ends the series
+	empty := ""
+	stopped, err := svc.Update(ctx, next.ID, &model.UpdateTaskRequest{Recurrence: &empty}, repository.AnyVersion)
+	require.NoError(t, err)
+	assert.Nil(t, stopped.Recurrence)
+}
@@ -0,0 +1,397 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/internal/search"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+const (
+	searchCursorKey  = "search:cursor"
+	searchLeaseKey   = "search:lease"
+	searchReindexKey = "search:reindex"
+
+	// searchLeaseTTL bounds how long indexing stalls when the replica
+	// holding the lease dies without releasing it
+	searchLeaseTTL = 30 * time.Second
+
+	// searchStateTTL keeps the cursor and reindex status around between
+	// bursts of writes; a lost cursor only costs a rebuild
+	searchStateTTL = 7 * 24 * time.Hour
+)
+
+// ReindexStatus describes the latest rebuild of the search index
+type ReindexStatus struct {
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Indexed    int        `json:"indexed"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// SearchIndexStatus describes how far the search index has caught up
+type SearchIndexStatus struct {
+	Backend     string         `json:"backend"`
+	Cursor      int64          `json:"cursor"`
+	Lag         int64          `json:"lag"`
+	LastReindex *ReindexStatus `json:"last_reindex,omitempty"`
+}
+
+// SearchConsistency compares the search index against Postgres
+type SearchConsistency struct {
+	Consistent    bool     `json:"consistent"`
+	DatabaseCount int      `json:"database_count"`
+	IndexCount    int      `json:"index_count"`
+	Sampled       int      `json:"sampled"`
+	Missing       []string `json:"missing"`
+	Stale         []string `json:"stale"`
+	Lag           int64    `json:"lag"`
+}
+
+// SearchIndexer mirrors tasks into a search index by following the event
+// log. One replica at a time holds a lease in the kv store and applies
+// events; the others stand by. Without a cursor, or when events it has not
+// applied were purged, the indexer rebuilds the index from Postgres.
+type SearchIndexer struct {
+	index  search.Index
+	events *EventService
+	repo   repository.TaskStore
+	state  kvstore.Store
+	cfg    *config.SearchConfig
+	lease  *lease
+
+	// mu serializes applying events and rebuilding on this replica
+	mu    sync.Mutex
+	ready bool
+	kick  chan struct{}
+}
+
+// NewSearchIndexer creates a new SearchIndexer
+func NewSearchIndexer(index search.Index, events *EventService, repo repository.TaskStore, state kvstore.Store, cfg *config.SearchConfig) *SearchIndexer {
+	return &SearchIndexer{
+		index:  index,
+		events: events,
+		repo:   repo,
+		state:  state,
+		cfg:    cfg,
+		lease:  newLease(state, searchLeaseKey, searchLeaseTTL),
+		kick:   make(chan struct{}, 1),
+	}
+}
+
+// Run applies new events to the index until stop is done. A sync or
+// rebuild in progress when stop fires runs to completion unless work is
+// cancelled too; the lease is released on the way out either way.
+func (ix *SearchIndexer) Run(stop, work context.Context) {
+	log := logger.Get().WithComponent("search")
+
+	ticker := time.NewTicker(ix.cfg.PollInterval)
+	defer ticker.Stop()
+	defer ix.lease.release()
+
+	for {
+		changed := ix.events.Changed()
+
+		if err := ix.sync(work); err != nil && work.Err() == nil {
+			log.Error().Err(err).Msg("Failed to sync search index")
+		}
+
+		select {
+		case <-stop.Done():
+			return
+		case <-changed:
+		case <-ix.kick:
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reindex schedules a rebuild from Postgres by dropping the cursor, so
+// whichever replica holds the lease rebuilds on its next sync
+func (ix *SearchIndexer) Reindex(ctx context.Context) error {
+	if err := ix.state.Delete(ctx, searchCursorKey); err != nil {
+		return fmt.Errorf("failed to reset search cursor: %w", err)
+	}
+
+	select {
+	case ix.kick <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Status reports the indexer position and the latest rebuild
+func (ix *SearchIndexer) Status(ctx context.Context) (*SearchIndexStatus, error) {
+	status := &SearchIndexStatus{Backend: ix.cfg.Backend}
+
+	cursor, _, err := ix.loadCursor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status.Cursor = cursor
+
+	if status.Lag, err = ix.lag(ctx, cursor); err != nil {
+		return nil, err
+	}
+
+	value, ok, err := ix.state.Get(ctx, searchReindexKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load reindex status: %w", err)
+	}
+	if ok {
+		var reindex ReindexStatus
+		if json.Unmarshal(value, &reindex) == nil {
+			status.LastReindex = &reindex
+		}
+	}
+
+	return status, nil
+}
+
+// Consistency compares document counts and checks that the most recently
+// updated tasks are indexed at their current version. Differences are
+// expected while the indexer lags behind.
+func (ix *SearchIndexer) Consistency(ctx context.Context) (*SearchConsistency, error) {
+	result := &SearchConsistency{Missing: []string{}, Stale: []string{}}
+
+	var err error
+	if result.DatabaseCount, err = ix.repo.Count(ctx, &model.ListOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to count tasks: %w", err)
+	}
+	if result.IndexCount, err = ix.index.Count(ctx); err != nil {
+		return nil, err
+	}
+
+	recent, err := ix.repo.GetAll(ctx, &model.ListOptions{Params: listing.Params{
+		Page:    1,
+		PerPage: ix.cfg.ConsistencySample,
+		Sort:    "updated_at",
+		Order:   listing.Desc,
+	}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	for _, task := range recent {
+		indexed, ok, err := ix.index.Get(ctx, task.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			result.Missing = append(result.Missing, task.ID)
+		} else if indexed.Version != task.Version {
+			result.Stale = append(result.Stale, task.ID)
+		}
+	}
+	result.Sampled = len(recent)
+
+	cursor, _, err := ix.loadCursor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if result.Lag, err = ix.lag(ctx, cursor); err != nil {
+		return nil, err
+	}
+
+	result.Consistent = result.DatabaseCount == result.IndexCount && len(result.Missing) == 0 && len(result.Stale) == 0
+	return result, nil
+}
+
+// sync applies pending events, or rebuilds the index when it cannot
+func (ix *SearchIndexer) sync(ctx context.Context) error {
+	leader, err := ix.lease.acquire(ctx)
+	if err != nil || !leader {
+		return err
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	if !ix.ready {
+		if err := ix.index.Setup(ctx); err != nil {
+			return err
+		}
+		ix.ready = true
+	}
+
+	cursor, ok, err := ix.loadCursor(ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ix.rebuild(ctx)
+	}
+
+	for {
+		events, gap, err := ix.events.After(ctx, cursor, ix.cfg.BatchSize)
+		if err != nil {
+			return err
+		}
+		if gap {
+			logger.Get().Warn().Int64("cursor", cursor).Msg("Search index fell behind the event log, rebuilding")
+			return ix.rebuild(ctx)
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		if err := ix.apply(ctx, events); err != nil {
+			return err
+		}
+		cursor = events[len(events)-1].ID
+		if err := ix.saveCursor(ctx, cursor); err != nil {
+			return err
+		}
+
+		if len(events) < ix.cfg.BatchSize {
+			return nil
+		}
+	}
+}
+
+// apply writes the final state of each task touched by a batch of events
+func (ix *SearchIndexer) apply(ctx context.Context, events []*model.TaskEvent) error {
+	latest := make(map[string]*model.TaskEvent)
+	for _, event := range events {
+		latest[event.TaskID] = event
+	}
+
+	var upserts []*model.TaskResponse
+	for id, event := range latest {
+		if event.Type == model.EventTaskDeleted || event.Task == nil {
+			if err := ix.index.Delete(ctx, id); err != nil {
+				return err
+			}
+			continue
+		}
+		upserts = append(upserts, event.Task)
+	}
+
+	return ix.index.Upsert(ctx, upserts)
+}
+
+// rebuild clears the index and loads every task from Postgres. Events
+// written meanwhile are applied afterwards, starting from the cursor taken
+// before the first page was read.
+func (ix *SearchIndexer) rebuild(ctx context.Context) error {
+	log := logger.Get().WithComponent("search")
+	status := &ReindexStatus{StartedAt: time.Now().UTC()}
+	ix.saveReindexStatus(ctx, status)
+
+	indexed, err := ix.load(ctx)
+	finished := time.Now().UTC()
+	status.FinishedAt = &finished
+	status.Indexed = indexed
+	if err != nil {
+		status.Error = err.Error()
+	}
+	ix.saveReindexStatus(ctx, status)
+
+	if err != nil {
+		return fmt.Errorf("failed to rebuild search index: %w", err)
+	}
+	log.Info().Int("indexed", indexed).Dur("duration", finished.Sub(status.StartedAt)).Msg("Rebuilt search index")
+	return nil
+}
+
+func (ix *SearchIndexer) load(ctx context.Context) (int, error) {
+	cursor, err := ix.events.Latest(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := ix.index.Clear(ctx); err != nil {
+		return 0, err
+	}
+
+	// Offset paging may skip a task when an earlier one is deleted
+	// mid-rebuild; the consistency check reports such gaps
+	indexed := 0
+	opts := &model.ListOptions{Params: listing.Params{Page: 1, PerPage: ix.cfg.BatchSize, Sort: "created_at", Order: "asc"}}
+	for {
+		// Keep the lease for rebuilds that outlast it
+		leader, err := ix.lease.acquire(ctx)
+		if err != nil {
+			return indexed, err
+		}
+		if !leader {
+			return indexed, errors.New("lost search indexer lease")
+		}
+
+		tasks, err := ix.repo.GetAll(ctx, opts)
+		if err != nil {
+			return indexed, fmt.Errorf("failed to list tasks: %w", err)
+		}
+
+		docs := make([]*model.TaskResponse, 0, len(tasks))
+		for _, task := range tasks {
+			docs = append(docs, task.ToResponse())
+		}
+		if err := ix.index.Upsert(ctx, docs); err != nil {
+			return indexed, err
+		}
+		indexed += len(docs)
+
+		if len(tasks) < opts.PerPage {
+			break
+		}
+		opts.Page++
+	}
+
+	return indexed, ix.saveCursor(ctx, cursor)
+}
+
+func (ix *SearchIndexer) lag(ctx context.Context, cursor int64) (int64, error) {
+	latest, err := ix.events.Latest(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if latest < cursor {
+		return 0, nil
+	}
+	return latest - cursor, nil
+}
+
+func (ix *SearchIndexer) loadCursor(ctx context.Context) (int64, bool, error) {
+	value, ok, err := ix.state.Get(ctx, searchCursorKey)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to load search cursor: %w", err)
+	}
+	if !ok {
+		return 0, false, nil
+	}
+
+	cursor, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return 0, false, nil
+	}
+	return cursor, true, nil
+}
+
+func (ix *SearchIndexer) saveCursor(ctx context.Context, cursor int64) error {
+	if err := ix.state.Set(ctx, searchCursorKey, []byte(strconv.FormatInt(cursor, 10)), searchStateTTL); err != nil {
+		return fmt.Errorf("failed to save search cursor: %w", err)
+	}
+	return nil
+}
+
+func (ix *SearchIndexer) saveReindexStatus(ctx context.Context, status *ReindexStatus) {
+	value, err := json.Marshal(status)
+	if err == nil {
+		err = ix.state.Set(ctx, searchReindexKey, value, searchStateTTL)
+	}
+	if err != nil {
+		logger.Get().Error().Err(err).Msg("Failed to save reindex status")
+	}
+}
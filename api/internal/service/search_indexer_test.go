@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/internal/search"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchIndexer(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryTaskRepository(0)
+	state := kvstore.NewMemory()
+	events := NewEventService(repository.NewMemoryEventRepository(), state, &config.EventsConfig{})
+	index := search.NewMemory()
+	cfg := &config.SearchConfig{Backend: search.BackendMemory, BatchSize: 2, ConsistencySample: 10}
+	ix := NewSearchIndexer(index, events, repo, state, cfg)
+
+	create := func(id, title string) *model.Task {
+		task, err := repo.Create(ctx, &model.Task{ID: id, ProjectKey: "TASK", Title: title})
+		require.NoError(t, err)
+		return task
+	}
+	indexed := func(id string) *model.TaskResponse {
+		task, _, err := index.Get(ctx, id)
+		require.NoError(t, err)
+		return task
+	}
+
+	// Without a cursor the first sync rebuilds from the repository
+	a := create("a", "Write docs")
+	require.NoError(t, ix.sync(ctx))
+	assert.NotNil(t, indexed("a"))
+
+	// Afterwards events are applied
+	b := create("b", "Fix bug")
+	record(t, events, ctx, model.EventTaskCreated, b.ID, b.ToResponse())
+	title := "Write better docs"
+	a, err := repo.Update(ctx, a.ID, &model.UpdateTaskRequest{Title: &title}, repository.AnyVersion)
+	require.NoError(t, err)
+	record(t, events, ctx, model.EventTaskUpdated, a.ID, a.ToResponse())
+	require.NoError(t, repo.Delete(ctx, b.ID, repository.AnyVersion))
+	record(t, events, ctx, model.EventTaskDeleted, b.ID, nil)
+
+	require.NoError(t, ix.sync(ctx))
+	assert.Equal(t, title, indexed("a").Title)
+	assert.Nil(t, indexed("b"))
+
+	consistency, err := ix.Consistency(ctx)
+	require.NoError(t, err)
+	assert.True(t, consistency.Consistent)
+	assert.Zero(t, consistency.Lag)
+
+	// Lost documents are reported and fixed by a reindex
+	require.NoError(t, index.Delete(ctx, a.ID))
+	consistency, err = ix.Consistency(ctx)
+	require.NoError(t, err)
+	assert.False(t, consistency.Consistent)
+	assert.Equal(t, []string{a.ID}, consistency.Missing)
+
+	require.NoError(t, ix.Reindex(ctx))
+	require.NoError(t, ix.sync(ctx))
+	assert.NotNil(t, indexed("a"))
+
+	status, err := ix.Status(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, status.LastReindex)
+	assert.Equal(t, 1, status.LastReindex.Indexed)
+
+	// Only the lease holder indexes
+	standby := NewSearchIndexer(index, events, repo, state, cfg)
+	leader, err := standby.lease.acquire(ctx)
+	require.NoError(t, err)
+	assert.False(t, leader)
+}
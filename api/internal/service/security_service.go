@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+	"github.com/moabdelazem/mutlitier_app/pkg/metrics"
+	"github.com/rs/zerolog"
+)
+
+// SecurityService records authentication events as structured logs and in
+// the security_events table, and serves them to admins. Events are written
+// in batches by Run so a flood of failed sign-ins cannot hold requests up
+// on the database; when the queue is full new events are only logged.
+type SecurityService struct {
+	store repository.SecurityEventStore
+	guard *QueryGuard
+	cfg   *config.SecurityConfig
+	queue chan *model.SecurityEvent
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// NewSecurityService creates a new SecurityService
+func NewSecurityService(store repository.SecurityEventStore, guard *QueryGuard, cfg *config.SecurityConfig) *SecurityService {
+	return &SecurityService{
+		store: store,
+		guard: guard,
+		cfg:   cfg,
+		queue: make(chan *model.SecurityEvent, max(cfg.BufferSize, 1)),
+		seen:  make(map[string]time.Time),
+	}
+}
+
+// Record logs an event and queues it for writing. Successful sign-ins and
+// token uses repeat on every request, so they are recorded once per
+// SECURITY_EVENTS_DEDUP_WINDOW for the same credential and address.
+func (s *SecurityService) Record(ctx context.Context, event *model.SecurityEvent) {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+
+	if event.Type == model.SecurityLoginSucceeded || event.Type == model.SecurityTokenUsed {
+		if s.duplicate(event) {
+			metrics.SecurityEvents.WithLabelValues(string(event.Type), "deduplicated").Inc()
+			return
+		}
+	}
+
+	log := logger.Ctx(ctx).WithComponent("security")
+	var entry *zerolog.Event
+	switch event.Type {
+	case model.SecurityLoginFailed, model.SecurityPermissionDenied, model.SecurityAbuseDetected:
+		entry = log.Warn()
+	default:
+		entry = log.Info()
+	}
+	entry.Str("security_event", string(event.Type)).
+		Str("user", event.User).
+		Str("credential", event.Credential).
+		Str("token_id", event.TokenID).
+		Str("reason", event.Reason).
+		Str("ip", event.IP).
+		Str("method", event.Method).
+		Str("path", event.Path).
+		Str("request_id", event.RequestID).
+		Msg("Security event")
+
+	select {
+	case s.queue <- event:
+		metrics.SecurityEvents.WithLabelValues(string(event.Type), "recorded").Inc()
+	default:
+		metrics.SecurityEvents.WithLabelValues(string(event.Type), "dropped").Inc()
+	}
+}
+
+// duplicate reports whether an equivalent event was recorded within the
+// dedup window, and remembers this one otherwise
+func (s *SecurityService) duplicate(event *model.SecurityEvent) bool {
+	key := string(event.Type) + "\x00" + event.User + "\x00" + event.Credential + "\x00" + event.TokenID + "\x00" + event.IP
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if last, ok := s.seen[key]; ok && event.CreatedAt.Sub(last) < s.cfg.DedupWindow {
+		return true
+	}
+	s.seen[key] = event.CreatedAt
+	return false
+}
+
+// Run writes queued events in batches of up to SECURITY_EVENTS_BATCH_SIZE
+// until stop is cancelled, then flushes what is left using work
+func (s *SecurityService) Run(stop, work context.Context) {
+	log := logger.Get().WithComponent("security")
+	batch := make([]*model.SecurityEvent, 0, max(s.cfg.BatchSize, 1))
+
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.store.Append(ctx, batch); err != nil {
+			log.Error().Err(err).Int("events", len(batch)).Msg("Failed to write security events")
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case <-stop.Done():
+			for {
+				select {
+				case event := <-s.queue:
+					batch = append(batch, event)
+					if len(batch) == cap(batch) {
+						flush(work)
+					}
+				default:
+					flush(work)
+					return
+				}
+			}
+		case event := <-s.queue:
+			batch = append(batch, event)
+			// Take whatever else is already queued before writing
+			for len(batch) < cap(batch) && len(s.queue) > 0 {
+				batch = append(batch, <-s.queue)
+			}
+			flush(work)
+		}
+	}
+}
+
+// PurgeEvery removes events older than SECURITY_EVENTS_RETENTION, and
+// forgets expired dedup entries, on every interval until ctx is done
+func (s *SecurityService) PurgeEvery(ctx context.Context, interval time.Duration) {
+	log := logger.Get().WithComponent("security")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now()
+			s.mu.Lock()
+			for key, last := range s.seen {
+				if now.Sub(last) >= s.cfg.DedupWindow {
+					delete(s.seen, key)
+				}
+			}
+			s.mu.Unlock()
+
+			purged, err := s.store.Purge(ctx, now.Add(-s.cfg.Retention))
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to purge security events")
+				continue
+			}
+			if purged > 0 {
+				log.Debug().Int64("purged", purged).Msg("Purged expired security events")
+			}
+		}
+	}
+}
+
+// List returns a page of recorded events, newest first
+func (s *SecurityService) List(ctx context.Context, filter *model.SecurityEventFilter, opts *model.ListOptions) (*model.SecurityEventListResponse, error) {
+	if err := validateSecurityFilter(filter); err != nil {
+		return nil, err
+	}
+
+	if err := s.guard.CheckPage(opts); err != nil {
+		return nil, err
+	}
+
+	events, err := s.store.List(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list security events: %w", err)
+	}
+	if events == nil {
+		events = []*model.SecurityEvent{}
+	}
+
+	total, err := s.store.Count(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count security events: %w", err)
+	}
+
+	return &model.SecurityEventListResponse{
+		Data:       events,
+		Pagination: listing.NewPagination(opts.Page, opts.PerPage, total),
+	}, nil
+}
+
+// Export calls fn for every recorded event matching filter, newest first
+func (s *SecurityService) Export(ctx context.Context, filter *model.SecurityEventFilter, fn func(*model.SecurityEvent) error) error {
+	if err := validateSecurityFilter(filter); err != nil {
+		return err
+	}
+
+	return s.store.Export(ctx, filter, fn)
+}
+
+func validateSecurityFilter(filter *model.SecurityEventFilter) error {
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return fmt.Errorf("%w: from must be before to", ErrValidation)
+	}
+	return nil
+}
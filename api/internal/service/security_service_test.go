@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecurityService_Record(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemorySecurityEventRepository()
+	guard := NewQueryGuard(&config.QueryGuardConfig{DefaultPerPage: 10, MaxPerPage: 10})
+	security := NewSecurityService(repo, guard, &config.SecurityConfig{DedupWindow: time.Hour, BufferSize: 3, BatchSize: 2})
+
+	used := func() *model.SecurityEvent {
+		return &model.SecurityEvent{Type: model.SecurityTokenUsed, User: "alice", TokenID: "t1", IP: "10.0.0.1"}
+	}
+
+	// Repeated token uses are recorded once per window, failures every time
+	security.Record(ctx, used())
+	security.Record(ctx, used())
+	security.Record(ctx, &model.SecurityEvent{Type: model.SecurityLoginFailed, IP: "10.0.0.2"})
+	security.Record(ctx, &model.SecurityEvent{Type: model.SecurityPermissionDenied, User: "alice", IP: "10.0.0.1"})
+
+	// The queue is full, so this one is dropped rather than blocking
+	security.Record(ctx, &model.SecurityEvent{Type: model.SecurityLoginFailed, IP: "10.0.0.3"})
+
+	// Stopping flushes the queue
+	stop, cancel := context.WithCancel(ctx)
+	cancel()
+	security.Run(stop, ctx)
+
+	page, err := security.List(ctx, &model.SecurityEventFilter{}, &model.ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, page.Pagination.Total)
+	assert.Equal(t, model.SecurityPermissionDenied, page.Data[0].Type)
+	assert.Equal(t, model.SecurityTokenUsed, page.Data[2].Type)
+
+	page, err = security.List(ctx, &model.SecurityEventFilter{Types: []model.SecurityEventType{model.SecurityLoginFailed}}, &model.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, page.Data, 1)
+	assert.Equal(t, "10.0.0.2", page.Data[0].IP)
+
+	var exported []string
+	err = security.Export(ctx, &model.SecurityEventFilter{User: "alice"}, func(event *model.SecurityEvent) error {
+		exported = append(exported, string(event.Type))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"permission_denied", "token_used"}, exported)
+}
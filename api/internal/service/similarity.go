@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/llm"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+var (
+	ErrEmbeddingUnavailable = errors.New("embedding model unavailable")
+)
+
+const (
+	embeddingLeaseKey = "embeddings:lease"
+
+	// embeddingLeaseTTL bounds how long embedding stalls when the replica
+	// holding the lease dies without releasing it
+	embeddingLeaseTTL = time.Minute
+)
+
+// SimilarityService finds tasks close in meaning to a task by the
+// embeddings of their titles and descriptions. A background pass, on one
+// replica at a time, embeds tasks that have no embedding or changed since
+// theirs was made; the task asked about is embedded on the spot when its
+// own is missing or stale.
+type SimilarityService struct {
+	embedder llm.Embedder
+	store    repository.EmbeddingStore
+	tasks    *TaskService
+	cfg      *config.EmbeddingsConfig
+	lease    *lease
+}
+
+// NewSimilarityService creates a new SimilarityService
+func NewSimilarityService(embedder llm.Embedder, store repository.EmbeddingStore, tasks *TaskService, state kvstore.Store, cfg *config.EmbeddingsConfig) *SimilarityService {
+	return &SimilarityService{
+		embedder: embedder,
+		store:    store,
+		tasks:    tasks,
+		cfg:      cfg,
+		lease:    newLease(state, embeddingLeaseKey, embeddingLeaseTTL),
+	}
+}
+
+// Similar returns the tasks most similar to a task, at least
+// EMBEDDINGS_THRESHOLD similar and at most EMBEDDINGS_LIMIT of them
+func (s *SimilarityService) Similar(ctx context.Context, id string) (*model.SimilarTaskListResponse, error) {
+	task, err := s.tasks.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	similar, err := s.similar(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	return &model.SimilarTaskListResponse{Data: similar, Model: s.embedder.Model()}, nil
+}
+
+// Duplicates embeds a task just created and returns its likely
+// duplicates. Failures are only logged and leave the task to the
+// background pass, since they must not fail the create.
+func (s *SimilarityService) Duplicates(ctx context.Context, task *model.TaskResponse) []*model.SimilarTask {
+	similar, err := s.similar(ctx, task)
+	if err != nil {
+		if !errors.Is(err, ErrEmbeddingUnavailable) {
+			logger.Ctx(ctx).Warn().Err(err).Str("task_id", task.ID).Msg("Failed to look for duplicate tasks")
+		}
+		return nil
+	}
+	return similar
+}
+
+// similar returns the tasks most similar to task
+func (s *SimilarityService) similar(ctx context.Context, task *model.TaskResponse) ([]*model.SimilarTask, error) {
+	vector, err := s.vector(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := s.store.SimilarTasks(ctx, s.embedder.Model(), vector, task.ID, s.cfg.Threshold, s.cfg.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find similar tasks: %w", err)
+	}
+
+	similar := make([]*model.SimilarTask, 0, len(matches))
+	for _, match := range matches {
+		similar = append(similar, &model.SimilarTask{Task: match.Task.ToResponse(), Similarity: match.Similarity})
+	}
+	return similar, nil
+}
+
+// vector returns the embedding of task, the stored one when it was made
+// for the same text
+func (s *SimilarityService) vector(ctx context.Context, task *model.TaskResponse) ([]float32, error) {
+	text, hash := s.embeddingText(task.Title, task.Description)
+
+	stored, err := s.store.GetEmbedding(ctx, task.ID, s.embedder.Model())
+	if err == nil && stored.ContentHash == hash {
+		return stored.Vector, nil
+	}
+	if err != nil && !errors.Is(err, repository.ErrEmbeddingNotFound) {
+		return nil, fmt.Errorf("failed to get task embedding: %w", err)
+	}
+
+	vectors, err := s.embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.SaveEmbedding(ctx, &model.TaskEmbedding{TaskID: task.ID, Model: s.embedder.Model(),
+		Vector: vectors[0], ContentHash: hash, TaskUpdatedAt: task.UpdatedAt}); err != nil {
+		return nil, fmt.Errorf("failed to save task embedding: %w", err)
+	}
+	return vectors[0], nil
+}
+
+// Run embeds new and changed tasks until stop is done, every
+// EMBEDDINGS_POLL_INTERVAL or right away while a backlog remains. Work in
+// progress when stop fires finishes using work.
+func (s *SimilarityService) Run(stop, work context.Context) {
+	log := logger.Get().WithComponent("embeddings")
+
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+	defer s.lease.release()
+
+	for {
+		embedded, err := s.Embed(work)
+		if err != nil && work.Err() == nil {
+			log.Error().Err(err).Msg("Failed to embed tasks")
+		}
+
+		if err == nil && embedded == s.cfg.BatchSize {
+			if stop.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-stop.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Embed brings up to EMBEDDINGS_BATCH_SIZE task embeddings up to date, if
+// this replica holds the lease, and returns how many it looked at. Tasks
+// whose title and description did not change are only marked current.
+func (s *SimilarityService) Embed(ctx context.Context) (int, error) {
+	leader, err := s.lease.acquire(ctx)
+	if err != nil || !leader {
+		return 0, err
+	}
+
+	due, err := s.store.ListEmbeddingsDue(ctx, s.embedder.Model(), s.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var changed []*model.Task
+	var texts, hashes []string
+	for _, item := range due {
+		text, hash := s.embeddingText(item.Task.Title, item.Task.Description)
+		if hash == item.ContentHash {
+			if err := s.store.TouchEmbedding(ctx, item.Task.ID, s.embedder.Model(), item.Task.UpdatedAt); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		changed = append(changed, item.Task)
+		texts = append(texts, text)
+		hashes = append(hashes, hash)
+	}
+	if len(changed) == 0 {
+		return len(due), nil
+	}
+
+	vectors, err := s.embed(ctx, texts)
+	if err != nil {
+		return 0, err
+	}
+	for i, task := range changed {
+		err := s.store.SaveEmbedding(ctx, &model.TaskEmbedding{TaskID: task.ID, Model: s.embedder.Model(),
+			Vector: vectors[i], ContentHash: hashes[i], TaskUpdatedAt: task.UpdatedAt})
+		// A task deleted since it was listed needs no embedding
+		if err != nil && !errors.Is(err, repository.ErrTaskNotFound) {
+			return 0, err
+		}
+	}
+	return len(due), nil
+}
+
+// embeddingText returns the text embedded for a task, cut to
+// EMBEDDINGS_MAX_INPUT, and its hash
+func (s *SimilarityService) embeddingText(title, description string) (string, string) {
+	text := strings.TrimSpace(title + "\n\n" + description)
+	if len(text) > s.cfg.MaxInput {
+		text = strings.ToValidUTF8(text[:s.cfg.MaxInput], "")
+	}
+	sum := sha256.Sum256([]byte(text))
+	return text, hex.EncodeToString(sum[:])
+}
+
+// embed asks the model for the embeddings of texts. Model failures are
+// ErrEmbeddingUnavailable.
+func (s *SimilarityService) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	call, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
+	vectors, err := s.embedder.Embed(call, texts)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Int("texts", len(texts)).Msg("Embedding request failed")
+		return nil, ErrEmbeddingUnavailable
+	}
+	if len(vectors) != len(texts) {
+		logger.Ctx(ctx).Warn().Int("texts", len(texts)).Int("vectors", len(vectors)).Msg("Embedding model returned the wrong number of vectors")
+		return nil, ErrEmbeddingUnavailable
+	}
+	return vectors, nil
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEmbedder embeds a text as how often it mentions each of a few
+// words, counting the texts it was asked about
+type fakeEmbedder struct {
+	err   error
+	texts int
+}
+
+func (e *fakeEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	e.texts += len(texts)
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		for _, word := range []string{"certificate", "database", "backup", "deploy"} {
+			vectors[i] = append(vectors[i], float32(strings.Count(strings.ToLower(text), word)))
+		}
+	}
+	return vectors, nil
+}
+
+func (e *fakeEmbedder) Model() string { return "fake-embeddings" }
+
+func TestSimilarityService(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryTaskRepository(0)
+	state := kvstore.NewMemory()
+	guard := NewQueryGuard(&config.QueryGuardConfig{DefaultPerPage: 10, MaxPerPage: 10})
+	events := NewEventService(repository.NewMemoryEventRepository(), state, &config.EventsConfig{})
+	tasks := NewTaskService(repo, guard, nil, events, nil, nil, nil, &config.TaskConfig{DefaultProject: "TASK"})
+	embedder := &fakeEmbedder{}
+	svc := NewSimilarityService(embedder, repo, tasks, state, &config.EmbeddingsConfig{
+		Timeout: time.Second, MaxInput: 4000, Threshold: 0.8, Limit: 5, BatchSize: 10,
+	})
+
+	renew, err := tasks.Create(ctx, &model.CreateTaskRequest{Title: "Renew the certificate", Description: "The API certificate expires soon"})
+	require.NoError(t, err)
+	backup, err := tasks.Create(ctx, &model.CreateTaskRequest{Title: "Database backup", Description: "Nightly database backup"})
+	require.NoError(t, err)
+
+	// The background pass embeds existing tasks once
+	embedded, err := svc.Embed(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, embedded)
+	assert.Equal(t, 2, embedder.texts)
+	embedded, err = svc.Embed(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, embedded)
+
+	// A new task is compared with them as it is created
+	rotate, err := tasks.Create(ctx, &model.CreateTaskRequest{Title: "Rotate certificate", Description: "New certificate for the API"})
+	require.NoError(t, err)
+	duplicates := svc.Duplicates(ctx, rotate)
+	require.Len(t, duplicates, 1)
+	assert.Equal(t, renew.ID, duplicates[0].Task.ID)
+	assert.InDelta(t, 1, duplicates[0].Similarity, 0.001)
+
+	similar, err := svc.Similar(ctx, renew.ID)
+	require.NoError(t, err)
+	require.Len(t, similar.Data, 1)
+	assert.Equal(t, rotate.ID, similar.Data[0].Task.ID)
+	assert.Equal(t, "fake-embeddings", similar.Model)
+
+	// Changes that leave the text alone do not call the model again
+	inProgress := model.StatusInProgress
+	_, err = tasks.Update(ctx, backup.ID, &model.UpdateTaskRequest{Status: &inProgress}, 0)
+	require.NoError(t, err)
+	asked := embedder.texts
+	embedded, err = svc.Embed(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, embedded)
+	assert.Equal(t, asked, embedder.texts)
+
+	// A failing model does not fail the create, only the lookup
+	embedder.err = errors.New("model loading")
+	deploy, err := tasks.Create(ctx, &model.CreateTaskRequest{Title: "Deploy"})
+	require.NoError(t, err)
+	assert.Nil(t, svc.Duplicates(ctx, deploy))
+	_, err = svc.Similar(ctx, deploy.ID)
+	assert.ErrorIs(t, err, ErrEmbeddingUnavailable)
+}
@@ -0,0 +1,318 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/mutlitier_app/internal/audit"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/internal/storage"
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+var (
+	ErrSnapshotNotFound = errors.New("snapshot not found")
+	ErrSnapshotCorrupt  = errors.New("snapshot does not match its checksum")
+)
+
+// SnapshotService copies the tasks of a project to object storage and
+// rolls the project back to such a copy. Snapshots live only in storage,
+// each as a document with the tasks and a small manifest describing it,
+// so they survive a database restore and can be read without the API.
+type SnapshotService struct {
+	tasks   *TaskService
+	backend storage.Backend
+	cfg     *config.SnapshotConfig
+}
+
+// NewSnapshotService creates a new SnapshotService
+func NewSnapshotService(tasks *TaskService, backend storage.Backend, cfg *config.SnapshotConfig) *SnapshotService {
+	return &SnapshotService{tasks: tasks, backend: backend, cfg: cfg}
+}
+
+// Create snapshots every task of project, archived ones included, then
+// prunes snapshots beyond SNAPSHOTS_KEEP
+func (s *SnapshotService) Create(ctx context.Context, project string) (*model.Snapshot, error) {
+	if err := s.checkProject(project); err != nil {
+		return nil, err
+	}
+
+	tasks, err := s.tasks.repo.GetByProject(ctx, project)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project tasks: %w", err)
+	}
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("%w: project %s has no tasks", ErrValidation, project)
+	}
+	if len(tasks) > s.tasks.cfg.SyncMaxTasks {
+		return nil, fmt.Errorf("%w: projects with more than %d tasks cannot be restored, so they are not snapshotted", ErrValidation, s.tasks.cfg.SyncMaxTasks)
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate snapshot id: %w", err)
+	}
+	document := &model.SnapshotDocument{
+		ID:            id.String(),
+		Project:       project,
+		FormatVersion: model.SnapshotFormatVersion,
+		CreatedBy:     audit.Actor(ctx),
+		CreatedAt:     time.Now().UTC(),
+		Tasks:         make([]model.SnapshotTask, len(tasks)),
+	}
+	for i, task := range tasks {
+		document.Tasks[i] = model.SnapshotTask{
+			ID:          task.ID,
+			Ref:         task.Ref().String(),
+			Title:       task.Title,
+			Description: task.Description,
+			Status:      task.Status,
+			Priority:    task.Priority,
+			DueDate:     task.DueDate,
+			Archived:    task.Archived,
+		}
+		if task.Recurrence != nil {
+			document.Tasks[i].Recurrence = *task.Recurrence
+		}
+	}
+
+	data, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	snapshot := &model.Snapshot{
+		ID:            document.ID,
+		Project:       project,
+		FormatVersion: document.FormatVersion,
+		TaskCount:     len(document.Tasks),
+		Size:          int64(len(data)),
+		SHA256:        hex.EncodeToString(sum[:]),
+		CreatedBy:     document.CreatedBy,
+		CreatedAt:     document.CreatedAt,
+	}
+	manifest, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot manifest: %w", err)
+	}
+
+	// The manifest goes last, so a listed snapshot is always complete
+	if err := s.backend.Put(ctx, snapshotKey(snapshotFolder(ctx, project), snapshot.ID, "tasks.json"), bytes.NewReader(data), snapshot.Size, "application/json"); err != nil {
+		return nil, fmt.Errorf("failed to store snapshot: %w", err)
+	}
+	if err := s.backend.Put(ctx, snapshotKey(snapshotFolder(ctx, project), snapshot.ID, "manifest.json"), bytes.NewReader(manifest), int64(len(manifest)), "application/json"); err != nil {
+		return nil, fmt.Errorf("failed to store snapshot manifest: %w", err)
+	}
+
+	s.prune(ctx, project)
+	return snapshot, nil
+}
+
+// List returns the snapshots of project, newest first
+func (s *SnapshotService) List(ctx context.Context, project string) (*model.SnapshotListResponse, error) {
+	if err := s.checkProject(project); err != nil {
+		return nil, err
+	}
+
+	ids, err := s.ids(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &model.SnapshotListResponse{Data: make([]*model.Snapshot, 0, len(ids))}
+	for _, id := range slices.Backward(ids) {
+		snapshot, err := s.manifest(ctx, project, id)
+		if err != nil {
+			return nil, err
+		}
+		response.Data = append(response.Data, snapshot)
+	}
+	return response, nil
+}
+
+// Get returns a whole snapshot, checked against its manifest
+func (s *SnapshotService) Get(ctx context.Context, project, id string) (*model.SnapshotDocument, error) {
+	if err := s.checkProject(project); err != nil {
+		return nil, err
+	}
+	if !isValidID(id) {
+		return nil, ErrSnapshotNotFound
+	}
+
+	snapshot, err := s.manifest(ctx, project, id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.read(ctx, snapshotKey(snapshotFolder(ctx, project), id, "tasks.json"))
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != snapshot.SHA256 {
+		return nil, ErrSnapshotCorrupt
+	}
+
+	var document model.SnapshotDocument
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	if document.FormatVersion > model.SnapshotFormatVersion {
+		return nil, fmt.Errorf("%w: snapshot format %d is newer than this server supports", ErrValidation, document.FormatVersion)
+	}
+	return &document, nil
+}
+
+// Restore rolls project back to a snapshot by syncing the project to the
+// snapshot's tasks. Tasks archived in the snapshot are left out, since
+// archived tasks are read-only to a sync; of tasks sharing a title only
+// the oldest is restored, as a sync matches tasks by title.
+func (s *SnapshotService) Restore(ctx context.Context, project, id string, dryRun bool) (*model.SyncResult, error) {
+	document, err := s.Get(ctx, project, id)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &model.SyncRequest{Tasks: []model.SyncTask{}}
+	seen := make(map[string]bool, len(document.Tasks))
+	for _, task := range document.Tasks {
+		if task.Archived || seen[task.Title] {
+			continue
+		}
+		seen[task.Title] = true
+		req.Tasks = append(req.Tasks, model.SyncTask{
+			Title:       task.Title,
+			Description: task.Description,
+			Status:      task.Status,
+			Priority:    task.Priority,
+			DueDate:     task.DueDate,
+			Recurrence:  task.Recurrence,
+		})
+	}
+
+	return s.tasks.sync(ctx, project, req, dryRun, true)
+}
+
+// prune deletes the oldest snapshots of project beyond SNAPSHOTS_KEEP.
+// Failures leave extra snapshots behind, so they are only logged.
+func (s *SnapshotService) prune(ctx context.Context, project string) {
+	if s.cfg.Keep <= 0 {
+		return
+	}
+
+	log := logger.Ctx(ctx)
+	folder := snapshotFolder(ctx, project)
+	ids, err := s.ids(ctx, project)
+	if err != nil {
+		log.Warn().Err(err).Str("project", project).Msg("Failed to list snapshots to prune")
+		return
+	}
+	for len(ids) > s.cfg.Keep {
+		// Manifest first, so a half-deleted snapshot is no longer listed
+		for _, name := range []string{"manifest.json", "tasks.json"} {
+			if err := s.backend.Delete(ctx, snapshotKey(folder, ids[0], name)); err != nil {
+				log.Warn().Err(err).Str("project", project).Str("snapshot_id", ids[0]).Msg("Failed to prune snapshot")
+				return
+			}
+		}
+		ids = ids[1:]
+	}
+}
+
+// ids returns the IDs of project's complete snapshots, oldest first
+func (s *SnapshotService) ids(ctx context.Context, project string) ([]string, error) {
+	folder := snapshotFolder(ctx, project)
+	keys, err := s.backend.List(ctx, folder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	var ids []string
+	for _, key := range keys {
+		if id, ok := strings.CutSuffix(strings.TrimPrefix(key, folder), "/manifest.json"); ok && isValidID(id) {
+			ids = append(ids, id)
+		}
+	}
+	// UUIDv7 IDs sort by creation time
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// manifest reads the manifest of a snapshot
+func (s *SnapshotService) manifest(ctx context.Context, project, id string) (*model.Snapshot, error) {
+	data, err := s.read(ctx, snapshotKey(snapshotFolder(ctx, project), id, "manifest.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot model.Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot manifest: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// read returns the contents of key, or ErrSnapshotNotFound
+func (s *SnapshotService) read(ctx context.Context, key string) ([]byte, error) {
+	file, err := s.backend.Open(ctx, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrSnapshotNotFound
+		}
+		return nil, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	return data, nil
+}
+
+// checkProject validates a project key, which also makes it safe to use
+// in storage keys
+func (s *SnapshotService) checkProject(project string) error {
+	if err := s.tasks.validate.Var(project, "project_key"); err != nil {
+		return fmt.Errorf("%w: project must be 2-16 uppercase letters or digits, starting with a letter", ErrValidation)
+	}
+	return nil
+}
+
+// snapshotFolder is where the snapshots of project taken under ctx are
+// stored. A snapshot only holds the tasks its creator reached, so each
+// tenant and owner scope gets a folder of its own, anonymous requests
+// included; unscoped requests, as of admins, share the "shared" folder of
+// their tenant.
+func snapshotFolder(ctx context.Context, project string) string {
+	id := tenant.From(ctx)
+	if id == "" {
+		id = tenant.Default
+	}
+	owner := "shared"
+	if scope, ok := repository.ScopeFrom(ctx); ok {
+		owner = "users/" + url.PathEscape(scope.Owner())
+		if scope.Anonymous() {
+			owner = "anonymous"
+		}
+	}
+	return "snapshots/" + id + "/" + owner + "/" + project + "/"
+}
+
+// snapshotKey is where a file of a snapshot in folder is stored
+func snapshotKey(folder, id, name string) string {
+	return folder + id + "/" + name
+}
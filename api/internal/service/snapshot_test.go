@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/internal/storage"
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotService(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryTaskRepository(0)
+	events := NewEventService(repository.NewMemoryEventRepository(), kvstore.NewMemory(), &config.EventsConfig{})
+	tasks := NewTaskService(repo, nil, nil, events, nil, nil, nil, &config.TaskConfig{DefaultProject: "TASK", SyncMaxTasks: 10})
+	backend := storage.NewMemoryBackend()
+	svc := NewSnapshotService(tasks, backend, &config.SnapshotConfig{Keep: 2})
+
+	_, err := svc.Create(ctx, "OPS")
+	assert.ErrorIs(t, err, ErrValidation, "empty projects are not snapshotted")
+
+	keys, err := tasks.Create(ctx, &model.CreateTaskRequest{Title: "Rotate keys", Project: "OPS"})
+	require.NoError(t, err)
+	backups, err := tasks.Create(ctx, &model.CreateTaskRequest{Title: "Check backups", Project: "OPS", Priority: model.PriorityHigh})
+	require.NoError(t, err)
+	for _, status := range []model.Status{model.StatusInProgress, model.StatusCompleted} {
+		_, err = tasks.Update(ctx, keys.ID, &model.UpdateTaskRequest{Status: &status}, repository.AnyVersion)
+		require.NoError(t, err)
+	}
+
+	snapshot, err := svc.Create(ctx, "OPS")
+	require.NoError(t, err)
+	assert.Equal(t, 2, snapshot.TaskCount)
+	assert.Equal(t, model.SnapshotFormatVersion, snapshot.FormatVersion)
+
+	// Change the project: delete a completed task, edit one and add one
+	require.NoError(t, tasks.Delete(ctx, keys.ID, repository.AnyVersion))
+	low := model.PriorityLow
+	_, err = tasks.Update(ctx, backups.ID, &model.UpdateTaskRequest{Priority: &low}, repository.AnyVersion)
+	require.NoError(t, err)
+	added, err := tasks.Create(ctx, &model.CreateTaskRequest{Title: "Added later", Project: "OPS"})
+	require.NoError(t, err)
+
+	result, err := svc.Restore(ctx, "OPS", snapshot.ID, true)
+	require.NoError(t, err)
+	assert.Equal(t, []model.SyncChange{{Title: "Rotate keys"}}, result.Created)
+	require.Len(t, result.Updated, 1)
+	assert.Equal(t, []string{"priority"}, result.Updated[0].Fields)
+	require.Len(t, result.Deleted, 1)
+	assert.Equal(t, added.ID, result.Deleted[0].ID)
+
+	// A sync could not complete a new task directly, a restore walks the state machine
+	result, err = svc.Restore(ctx, "OPS", snapshot.ID, false)
+	require.NoError(t, err)
+	restored, err := tasks.GetByID(ctx, result.Created[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusCompleted, restored.Status)
+	edited, err := tasks.GetByID(ctx, backups.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.PriorityHigh, edited.Priority)
+	_, err = tasks.GetByID(ctx, added.ID)
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+
+	// Only the newest SNAPSHOTS_KEEP snapshots are kept
+	second, err := svc.Create(ctx, "OPS")
+	require.NoError(t, err)
+	third, err := svc.Create(ctx, "OPS")
+	require.NoError(t, err)
+	list, err := svc.List(ctx, "OPS")
+	require.NoError(t, err)
+	require.Len(t, list.Data, 2)
+	assert.Equal(t, third.ID, list.Data[0].ID)
+	assert.Equal(t, second.ID, list.Data[1].ID)
+	_, err = svc.Get(ctx, "OPS", snapshot.ID)
+	assert.ErrorIs(t, err, ErrSnapshotNotFound)
+	_, err = svc.Get(ctx, "OPS", uuid.NewString())
+	assert.ErrorIs(t, err, ErrSnapshotNotFound)
+	_, err = svc.List(ctx, "../etc")
+	assert.ErrorIs(t, err, ErrValidation)
+
+	// Other owners and tenants neither see nor restore the snapshots
+	for _, other := range []context.Context{
+		repository.WithScope(ctx, repository.OwnedBy("alice")),
+		tenant.With(ctx, "acme"),
+	} {
+		list, err = svc.List(other, "OPS")
+		require.NoError(t, err)
+		assert.Empty(t, list.Data)
+		_, err = svc.Get(other, "OPS", third.ID)
+		assert.ErrorIs(t, err, ErrSnapshotNotFound)
+		_, err = svc.Restore(other, "OPS", third.ID, true)
+		assert.ErrorIs(t, err, ErrSnapshotNotFound)
+	}
+}
+
+func TestStatusMachine_Path(t *testing.T) {
+	machine, err := NewStatusMachine(nil)
+	require.NoError(t, err)
+
+	path, err := machine.Path(model.StatusPending, model.StatusCompleted)
+	require.NoError(t, err)
+	assert.Equal(t, []model.Status{model.StatusInProgress, model.StatusCompleted}, path)
+
+	path, err = machine.Path(model.StatusCompleted, model.StatusCompleted)
+	require.NoError(t, err)
+	assert.Empty(t, path)
+
+	machine, err = NewStatusMachine(map[string][]string{"pending": {"completed"}})
+	require.NoError(t, err)
+	_, err = machine.Path(model.StatusCompleted, model.StatusPending)
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+// DefaultStatsDays is how many days created_per_day covers unless asked
+const DefaultStatsDays = 30
+
+// statsCacheRetention is how long computed stats stay in the cache after
+// going stale, to be served when only cached stats may be used
+const statsCacheRetention = 24 * time.Hour
+
+// StatsService computes task statistics and caches them in the shared
+// kv store for TASK_STATS_CACHE_TTL, so dashboards polling every replica
+// run the aggregates once per TTL
+type StatsService struct {
+	store       repository.StatsStore
+	cache       kvstore.Store
+	degradation *Degradation
+	cfg         *config.TaskConfig
+}
+
+// NewStatsService creates a new StatsService
+func NewStatsService(store repository.StatsStore, cache kvstore.Store, degradation *Degradation, cfg *config.TaskConfig) *StatsService {
+	return &StatsService{store: store, cache: cache, degradation: degradation, cfg: cfg}
+}
+
+// Stats returns task statistics, from the cache while fresh. With the
+// cached_stats_only degradation switch on, cached stats of any age are
+// returned and nothing is recomputed.
+func (s *StatsService) Stats(ctx context.Context, filter *model.TaskStatsFilter) (*model.TaskStats, error) {
+	if filter.Days < 1 || filter.Days > s.cfg.StatsMaxDays {
+		return nil, fmt.Errorf("%w: days must be between 1 and %d", ErrValidation, s.cfg.StatsMaxDays)
+	}
+
+	// Stats are cached per tenant and per owner scope, as they are computed
+	key := "stats:tasks:" + strconv.Itoa(filter.Days) + ":" + strconv.FormatBool(filter.IncludeArchived) + ":" + filter.Project
+	if id := tenant.From(ctx); id != "" {
+		key += ":tenant:" + id
+	}
+	if scope, ok := repository.ScopeFrom(ctx); ok {
+		key += ":owner:" + scope.Owner()
+		if scope.Anonymous() {
+			key += ":anonymous"
+		}
+	}
+	cached := s.cached(ctx, key)
+	if s.degradation != nil && s.degradation.CachedStatsOnly() {
+		if cached == nil {
+			return nil, fmt.Errorf("%w: stats are served from cache only and none are cached", ErrDegraded)
+		}
+		return cached, nil
+	}
+	if cached != nil && time.Since(cached.ComputedAt) < s.cfg.StatsCacheTTL {
+		return cached, nil
+	}
+
+	now := time.Now().UTC()
+	stats, err := s.store.TaskStats(ctx, filter, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute task stats: %w", err)
+	}
+	stats.IncludeArchived = filter.IncludeArchived
+	stats.Project = filter.Project
+	stats.ComputedAt = now
+
+	// Every status is listed, and every day, even without tasks
+	for _, status := range model.Statuses() {
+		stats.ByStatus[status] += 0
+	}
+	stats.CreatedPerDay = fillDays(stats.CreatedPerDay, filter.Days, now)
+
+	if value, err := json.Marshal(stats); err == nil {
+		if err := s.cache.Set(ctx, key, value, statsCacheRetention); err != nil {
+			logger.Ctx(ctx).Warn().Err(err).Msg("Failed to cache task stats")
+		}
+	}
+
+	return stats, nil
+}
+
+// cached returns the stats cached under key, or nil
+func (s *StatsService) cached(ctx context.Context, key string) *model.TaskStats {
+	value, ok, err := s.cache.Get(ctx, key)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Msg("Failed to read cached task stats")
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+	var stats model.TaskStats
+	if err := json.Unmarshal(value, &stats); err != nil {
+		return nil
+	}
+	return &stats
+}
+
+// fillDays returns one count per UTC day of the last days days, oldest
+// first, taking counts from the days that had any
+func fillDays(counts []model.DailyCount, days int, now time.Time) []model.DailyCount {
+	byDate := make(map[string]int, len(counts))
+	for _, count := range counts {
+		byDate[count.Date] = count.Count
+	}
+
+	filled := make([]model.DailyCount, 0, days)
+	today := now.UTC().Truncate(24 * time.Hour)
+	for i := days - 1; i >= 0; i-- {
+		date := today.AddDate(0, 0, -i).Format(time.DateOnly)
+		filled = append(filled, model.DailyCount{Date: date, Count: byDate[date]})
+	}
+	return filled
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsService_Stats(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryTaskRepository(0)
+	degradation := NewDegradation(&config.DegradationConfig{})
+	cfg := &config.TaskConfig{StatsCacheTTL: time.Hour, StatsMaxDays: 90}
+	svc := NewStatsService(repo, kvstore.NewMemory(), degradation, cfg)
+
+	create := func(title string, due *time.Time) *model.Task {
+		task, err := repo.Create(ctx, &model.Task{ID: uuid.NewString(), ProjectKey: "TASK", Title: title, DueDate: due})
+		require.NoError(t, err)
+		return task
+	}
+	past := time.Now().Add(-time.Hour)
+	create("Overdue", &past)
+	create("Open", nil)
+	done := create("Done", &past)
+	for _, status := range []model.Status{model.StatusInProgress, model.StatusCompleted} {
+		_, err := repo.Update(ctx, done.ID, &model.UpdateTaskRequest{Status: &status}, 0)
+		require.NoError(t, err)
+	}
+
+	stats, err := svc.Stats(ctx, &model.TaskStatsFilter{Days: 7})
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.Total)
+	assert.Equal(t, map[model.Status]int{
+		model.StatusPending: 2, model.StatusInProgress: 0, model.StatusCompleted: 1, model.StatusCancelled: 0,
+	}, stats.ByStatus)
+	assert.Equal(t, 1, stats.Overdue, "completed tasks are not overdue")
+	require.Len(t, stats.CreatedPerDay, 7)
+	assert.Equal(t, model.DailyCount{Date: time.Now().UTC().Format(time.DateOnly), Count: 3}, stats.CreatedPerDay[6])
+	assert.Equal(t, 0, stats.CreatedPerDay[0].Count)
+	require.NotNil(t, stats.AvgCompletionSeconds)
+	assert.GreaterOrEqual(t, *stats.AvgCompletionSeconds, 0.0)
+
+	// Served from the cache until the TTL passes
+	create("Later", nil)
+	cached, err := svc.Stats(ctx, &model.TaskStatsFilter{Days: 7})
+	require.NoError(t, err)
+	assert.Equal(t, 3, cached.Total)
+
+	// Only cached stats while degraded, and none for uncached parameters
+	cfg.StatsCacheTTL = 0
+	degraded := true
+	degradation.Update(DegradationUpdate{CachedStatsOnly: &degraded})
+	cached, err = svc.Stats(ctx, &model.TaskStatsFilter{Days: 7})
+	require.NoError(t, err)
+	assert.Equal(t, 3, cached.Total)
+	_, err = svc.Stats(ctx, &model.TaskStatsFilter{Days: 8})
+	assert.ErrorIs(t, err, ErrDegraded)
+
+	_, err = svc.Stats(ctx, &model.TaskStatsFilter{Days: 91})
+	assert.ErrorIs(t, err, ErrValidation)
+}
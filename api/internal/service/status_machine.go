@@ -0,0 +1,123 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+var (
+	ErrInvalidTransition = errors.New("invalid status transition")
+)
+
+// TransitionError is returned when the state machine does not allow a
+// task to move from its current status to the requested one
+type TransitionError struct {
+	From    model.Status
+	To      model.Status
+	Allowed []model.Status
+}
+
+func (e *TransitionError) Error() string {
+	if len(e.Allowed) == 0 {
+		return fmt.Sprintf("a %s task cannot change status", e.From)
+	}
+	allowed := make([]string, len(e.Allowed))
+	for i, status := range e.Allowed {
+		allowed[i] = string(status)
+	}
+	return fmt.Sprintf("a %s task cannot move to %s, allowed: %s", e.From, e.To, strings.Join(allowed, ", "))
+}
+
+// Unwrap lets errors.Is match ErrInvalidTransition
+func (e *TransitionError) Unwrap() error {
+	return ErrInvalidTransition
+}
+
+// StatusMachine decides which status changes tasks may make
+type StatusMachine struct {
+	transitions map[model.Status][]model.Status
+}
+
+// NewStatusMachine builds a state machine from from -> to lists. An empty
+// spec uses the default transitions of the model package.
+func NewStatusMachine(spec map[string][]string) (*StatusMachine, error) {
+	transitions := make(map[model.Status][]model.Status, len(model.Statuses()))
+	if len(spec) == 0 {
+		for _, status := range model.Statuses() {
+			transitions[status] = status.AllowedTransitions()
+		}
+		return &StatusMachine{transitions: transitions}, nil
+	}
+
+	for from, targets := range spec {
+		status, err := model.ParseStatus(from)
+		if err != nil {
+			return nil, fmt.Errorf("unknown status %q: %w", from, err)
+		}
+		for _, to := range targets {
+			next, err := model.ParseStatus(to)
+			if err != nil {
+				return nil, fmt.Errorf("unknown status %q: %w", to, err)
+			}
+			transitions[status] = append(transitions[status], next)
+		}
+	}
+	return &StatusMachine{transitions: transitions}, nil
+}
+
+// Check returns a TransitionError unless a task may move from one status
+// to the other. Keeping the current status is always allowed.
+func (m *StatusMachine) Check(from, to model.Status) error {
+	if from == to || slices.Contains(m.transitions[from], to) {
+		return nil
+	}
+	return &TransitionError{From: from, To: to, Allowed: m.transitions[from]}
+}
+
+// Sources returns the statuses a task may be in to move to status,
+// including status itself
+func (m *StatusMachine) Sources(status model.Status) []model.Status {
+	sources := []model.Status{status}
+	for _, from := range model.Statuses() {
+		if from != status && slices.Contains(m.transitions[from], status) {
+			sources = append(sources, from)
+		}
+	}
+	return sources
+}
+
+// Path returns the shortest series of statuses a task moves through, one
+// allowed transition at a time, to get from one status to another. It
+// ends with to and is empty when the two are equal.
+func (m *StatusMachine) Path(from, to model.Status) ([]model.Status, error) {
+	if from == to {
+		return nil, nil
+	}
+
+	previous := map[model.Status]model.Status{from: from}
+	queue := []model.Status{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range m.transitions[current] {
+			if _, seen := previous[next]; seen {
+				continue
+			}
+			previous[next] = current
+			if next == to {
+				var path []model.Status
+				for status := to; status != from; status = previous[status] {
+					path = append(path, status)
+				}
+				slices.Reverse(path)
+				return path, nil
+			}
+			queue = append(queue, next)
+		}
+	}
+	return nil, &TransitionError{From: from, To: to, Allowed: m.transitions[from]}
+}
@@ -0,0 +1,326 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+)
+
+// syncUpdate is a change a sync makes to an existing task
+type syncUpdate struct {
+	task    *model.Task
+	changes *model.UpdateTaskRequest
+	fields  []string
+}
+
+// Sync reconciles the tasks of project with the declared set: declared
+// tasks missing from the project are created, differing ones updated and
+// tasks that are not declared deleted. Everything is validated, status
+// transitions included, before the first write, and a dry run stops there.
+// Applying is not one transaction, but it is idempotent: after a conflict
+// the same request can simply be sent again.
+func (s *TaskService) Sync(ctx context.Context, project string, req *model.SyncRequest, dryRun bool) (*model.SyncResult, error) {
+	return s.sync(ctx, project, req, dryRun, false)
+}
+
+// sync implements Sync. A restore puts back tasks as they were, so it
+// recreates tasks whose due date has passed and moves statuses through as
+// many transitions as it takes, rather than only allowing direct ones.
+func (s *TaskService) sync(ctx context.Context, project string, req *model.SyncRequest, dryRun, restore bool) (*model.SyncResult, error) {
+	if err := s.validate.Var(project, "project_key"); err != nil {
+		return nil, fmt.Errorf("%w: project must be 2-16 uppercase letters or digits, starting with a letter", ErrValidation)
+	}
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, formatValidationErrors(err))
+	}
+	if len(req.Tasks) > s.cfg.SyncMaxTasks {
+		return nil, fmt.Errorf("%w: at most %d tasks can be synced per project", ErrValidation, s.cfg.SyncMaxTasks)
+	}
+
+	declared := make(map[string]bool, len(req.Tasks))
+	for _, task := range req.Tasks {
+		if declared[task.Title] {
+			return nil, fmt.Errorf("%w: title %q is declared more than once", ErrValidation, task.Title)
+		}
+		declared[task.Title] = true
+	}
+
+	existing, err := s.repo.GetByProject(ctx, project)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project tasks: %w", err)
+	}
+	// Tasks come in number order, so the oldest of same-titled tasks is kept
+	byTitle := make(map[string]*model.Task, len(existing))
+	for _, task := range existing {
+		if _, ok := byTitle[task.Title]; !ok {
+			byTitle[task.Title] = task
+		}
+	}
+
+	result := &model.SyncResult{
+		Project: project,
+		DryRun:  dryRun,
+		Created: []model.SyncChange{},
+		Updated: []model.SyncChange{},
+		Deleted: []model.SyncChange{},
+	}
+
+	var creates []*model.SyncTask
+	var updates []syncUpdate
+	matched := make(map[string]bool, len(req.Tasks))
+	for i := range req.Tasks {
+		want := &req.Tasks[i]
+		task, ok := byTitle[want.Title]
+		if !ok {
+			if want.Status != "" {
+				if err := s.checkSyncStatus(model.StatusPending, want.Status, restore); err != nil {
+					return nil, fmt.Errorf("task %q: %w", want.Title, err)
+				}
+			}
+			if !restore && want.DueDate != nil && want.DueDate.Before(time.Now()) {
+				return nil, fmt.Errorf("%w: task %q: due_date must not be in the past", ErrValidation, want.Title)
+			}
+			creates = append(creates, want)
+			continue
+		}
+
+		matched[task.ID] = true
+		if task.Archived {
+			result.Skipped = append(result.Skipped, syncChange(task, nil))
+			continue
+		}
+		changes, fields := syncChanges(task, want)
+		if len(fields) == 0 {
+			result.Unchanged++
+			continue
+		}
+		if changes.Status != nil {
+			if err := s.checkSyncStatus(task.Status, *changes.Status, restore); err != nil {
+				return nil, fmt.Errorf("task %q: %w", want.Title, err)
+			}
+		}
+		updates = append(updates, syncUpdate{task: task, changes: changes, fields: fields})
+	}
+
+	var deletes []string
+	deleting := make(map[string]*model.Task)
+	for _, task := range existing {
+		if matched[task.ID] {
+			continue
+		}
+		if task.Archived {
+			result.Skipped = append(result.Skipped, syncChange(task, nil))
+			continue
+		}
+		deletes = append(deletes, task.ID)
+		deleting[task.ID] = task
+	}
+
+	if dryRun {
+		for _, want := range creates {
+			result.Created = append(result.Created, model.SyncChange{Title: want.Title})
+		}
+		for _, update := range updates {
+			result.Updated = append(result.Updated, syncChange(update.task, update.fields))
+		}
+		blocked := map[string]bool{}
+		if len(deletes) > 0 && s.comments != nil {
+			ids, err := s.comments.Blocked(ctx, deletes)
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range ids {
+				blocked[id] = true
+			}
+		}
+		for _, id := range deletes {
+			if blocked[id] {
+				result.Blocked = append(result.Blocked, syncChange(deleting[id], nil))
+				continue
+			}
+			result.Deleted = append(result.Deleted, syncChange(deleting[id], nil))
+		}
+		return result, nil
+	}
+
+	if err := s.syncCreate(ctx, project, creates, restore, result); err != nil {
+		return nil, err
+	}
+
+	for _, update := range updates {
+		task, err := s.applySyncUpdate(ctx, update, restore)
+		if err != nil {
+			return nil, fmt.Errorf("task %q: %w", update.task.Title, err)
+		}
+		result.Updated = append(result.Updated, model.SyncChange{ID: task.ID, Ref: task.Ref, Title: task.Title, Fields: update.fields})
+	}
+
+	deleted, err := s.deleteTasks(ctx, deletes)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range deleted.Deleted {
+		result.Deleted = append(result.Deleted, syncChange(deleting[id], nil))
+	}
+	for _, id := range deleted.Blocked {
+		result.Blocked = append(result.Blocked, syncChange(deleting[id], nil))
+	}
+
+	return result, nil
+}
+
+// syncCreate creates the declared tasks in one transaction, then moves
+// those declared with another status out of pending
+func (s *TaskService) syncCreate(ctx context.Context, project string, creates []*model.SyncTask, restore bool, result *model.SyncResult) error {
+	if len(creates) == 0 {
+		return nil
+	}
+
+	tasks := make([]*model.Task, len(creates))
+	for i, want := range creates {
+		req := &model.CreateTaskRequest{
+			Title:       want.Title,
+			Description: want.Description,
+			Project:     project,
+			Priority:    want.Priority,
+			DueDate:     want.DueDate,
+			Recurrence:  want.Recurrence,
+		}
+		if restore {
+			// Restored due dates may have passed, which newTask rejects
+			req.DueDate = nil
+		}
+		task, err := s.newTask(req)
+		if err != nil {
+			return fmt.Errorf("task %q: %w", want.Title, err)
+		}
+		task.DueDate = want.DueDate
+		task.Owner = taskOwner(ctx)
+		tasks[i] = task
+	}
+
+	var created []*model.Task
+	err := s.events.Atomically(ctx, func(ctx context.Context) error {
+		var err error
+		if created, err = s.repo.CreateMany(ctx, tasks); err != nil {
+			return err
+		}
+		return s.recordCreated(ctx, created)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrStoreFull) {
+			return ErrLimitReached
+		}
+		return fmt.Errorf("failed to create tasks: %w", err)
+	}
+
+	for i, task := range created {
+		if status := creates[i].Status; status != "" && status != task.Status {
+			if _, err := s.walkStatus(ctx, task.ID, task.Status, status, task.Version); err != nil {
+				return fmt.Errorf("task %q: %w", task.Title, err)
+			}
+		}
+		result.Created = append(result.Created, syncChange(task, nil))
+	}
+	return nil
+}
+
+// applySyncUpdate applies one update. Outside a restore the status
+// change was checked to be a direct transition and goes in the same
+// update as the other fields; a restore walks it one transition at a time.
+func (s *TaskService) applySyncUpdate(ctx context.Context, update syncUpdate, restore bool) (*model.TaskResponse, error) {
+	if !restore || update.changes.Status == nil {
+		return s.Update(ctx, update.task.ID, update.changes, update.task.Version)
+	}
+
+	target := *update.changes.Status
+	update.changes.Status = nil
+	version := update.task.Version
+	if len(update.fields) > 1 {
+		task, err := s.Update(ctx, update.task.ID, update.changes, version)
+		if err != nil {
+			return nil, err
+		}
+		version = task.Version
+	}
+	return s.walkStatus(ctx, update.task.ID, update.task.Status, target, version)
+}
+
+// walkStatus moves a task at version from one status to another along
+// the shortest path the state machine allows
+func (s *TaskService) walkStatus(ctx context.Context, id string, from, to model.Status, version int64) (*model.TaskResponse, error) {
+	path, err := s.statuses.Path(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var task *model.TaskResponse
+	for _, status := range path {
+		if task, err = s.Update(ctx, id, &model.UpdateTaskRequest{Status: &status}, version); err != nil {
+			return nil, err
+		}
+		version = task.Version
+	}
+	return task, nil
+}
+
+// checkSyncStatus checks a status change a sync makes: a direct
+// transition, or for a restore any path through the state machine
+func (s *TaskService) checkSyncStatus(from, to model.Status, restore bool) error {
+	if !restore {
+		return s.statuses.Check(from, to)
+	}
+	_, err := s.statuses.Path(from, to)
+	return err
+}
+
+// syncChanges returns the update that makes task match want, and the
+// names of the fields it changes
+func syncChanges(task *model.Task, want *model.SyncTask) (*model.UpdateTaskRequest, []string) {
+	changes := &model.UpdateTaskRequest{}
+	var fields []string
+
+	if want.Description != task.Description {
+		changes.Description = &want.Description
+		fields = append(fields, "description")
+	}
+	if want.Status != "" && want.Status != task.Status {
+		changes.Status = &want.Status
+		fields = append(fields, "status")
+	}
+	priority := want.Priority
+	if priority == "" {
+		priority = model.DefaultPriority
+	}
+	if priority != task.Priority {
+		changes.Priority = &priority
+		fields = append(fields, "priority")
+	}
+	switch {
+	case want.DueDate == nil && task.DueDate != nil:
+		changes.ClearDueDate = true
+		fields = append(fields, "due_date")
+	case want.DueDate != nil && (task.DueDate == nil || !want.DueDate.Equal(*task.DueDate)):
+		changes.DueDate = want.DueDate
+		fields = append(fields, "due_date")
+	}
+	current := ""
+	if task.Recurrence != nil {
+		current = *task.Recurrence
+	}
+	if want.Recurrence != current {
+		changes.Recurrence = &want.Recurrence
+		fields = append(fields, "recurrence")
+	}
+
+	return changes, fields
+}
+
+// syncChange describes task in a sync result
+func syncChange(task *model.Task, fields []string) model.SyncChange {
+	return model.SyncChange{ID: task.ID, Ref: task.Ref().String(), Title: task.Title, Fields: fields}
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskService_Sync(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryTaskRepository(0)
+	events := NewEventService(repository.NewMemoryEventRepository(), kvstore.NewMemory(), &config.EventsConfig{})
+	svc := NewTaskService(repo, nil, nil, events, nil, nil, nil, &config.TaskConfig{DefaultProject: "TASK", SyncMaxTasks: 10})
+
+	other, err := svc.Create(ctx, &model.CreateTaskRequest{Title: "Elsewhere"})
+	require.NoError(t, err)
+	stray, err := svc.Create(ctx, &model.CreateTaskRequest{Title: "Stray", Project: "OPS"})
+	require.NoError(t, err)
+
+	declared := &model.SyncRequest{Tasks: []model.SyncTask{
+		{Title: "Rotate keys", Priority: model.PriorityHigh},
+		{Title: "Check backups", Status: model.StatusInProgress, Recurrence: "0 9 * * 1"},
+	}}
+	result, err := svc.Sync(ctx, "OPS", declared, true)
+	require.NoError(t, err)
+	assert.Len(t, result.Created, 2)
+	assert.Equal(t, stray.ID, result.Deleted[0].ID)
+	_, err = svc.GetByID(ctx, stray.ID)
+	require.NoError(t, err, "dry runs change nothing")
+
+	result, err = svc.Sync(ctx, "OPS", declared, false)
+	require.NoError(t, err)
+	require.Len(t, result.Created, 2)
+	assert.Equal(t, "OPS-2", result.Created[0].Ref)
+	require.Len(t, result.Deleted, 1)
+	_, err = svc.GetByID(ctx, stray.ID)
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+	_, err = svc.GetByID(ctx, other.ID)
+	require.NoError(t, err, "other projects are left alone")
+	backups, err := svc.GetByID(ctx, result.Created[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusInProgress, backups.Status)
+
+	// Syncing again changes nothing, omitted statuses are left alone
+	declared.Tasks[1].Status = ""
+	result, err = svc.Sync(ctx, "OPS", declared, false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Unchanged)
+	assert.Empty(t, result.Created)
+	assert.Empty(t, result.Updated)
+
+	declared.Tasks[0].Description = "Monthly"
+	declared.Tasks[1].Recurrence = ""
+	result, err = svc.Sync(ctx, "OPS", declared, false)
+	require.NoError(t, err)
+	require.Len(t, result.Updated, 2)
+	assert.Equal(t, []string{"description"}, result.Updated[0].Fields)
+	assert.Equal(t, []string{"recurrence"}, result.Updated[1].Fields)
+
+	// Nothing is written when any transition is not allowed
+	declared.Tasks = append(declared.Tasks, model.SyncTask{Title: "Later"})
+	declared.Tasks[0].Status = model.StatusCompleted
+	_, err = svc.Sync(ctx, "OPS", declared, false)
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	tasks, err := repo.GetByProject(ctx, "OPS")
+	require.NoError(t, err)
+	assert.Len(t, tasks, 2)
+
+	_, err = svc.Sync(ctx, "OPS", &model.SyncRequest{Tasks: []model.SyncTask{{Title: "A"}, {Title: "A"}}}, false)
+	assert.ErrorIs(t, err, ErrValidation)
+	_, err = svc.Sync(ctx, "ops", &model.SyncRequest{Tasks: []model.SyncTask{}}, false)
+	assert.ErrorIs(t, err, ErrValidation)
+}
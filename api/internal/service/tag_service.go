@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+)
+
+var (
+	ErrTagNotFound = errors.New("tag not found")
+	ErrTagExists   = errors.New("tag already exists")
+)
+
+// tagResource describes tags to CRUDService. Names are case-insensitive
+// and stored lowercase.
+var tagResource = &Resource[model.Tag, model.CreateTagRequest, model.UpdateTagRequest, model.TagResponse]{
+	Name: "tag",
+	New: func(id string, req *model.CreateTagRequest) *model.Tag {
+		return &model.Tag{ID: id, Name: req.Name, Color: req.Color}
+	},
+	Response: (*model.Tag).ToResponse,
+	NormalizeCreate: func(req *model.CreateTagRequest) {
+		req.Name = normalizeTagName(req.Name)
+	},
+	NormalizeUpdate: func(req *model.UpdateTagRequest) {
+		if req.Name != nil {
+			name := normalizeTagName(*req.Name)
+			req.Name = &name
+		}
+	},
+	StoreNotFound: repository.ErrTagNotFound,
+	StoreExists:   repository.ErrTagExists,
+	NotFound:      ErrTagNotFound,
+	Exists:        ErrTagExists,
+}
+
+// TagService handles business logic for tags and tagging tasks. Creating,
+// reading, renaming and deleting tags is CRUDService's; tags list by name.
+type TagService struct {
+	*CRUDService[model.Tag, model.CreateTagRequest, model.UpdateTagRequest, model.TagResponse]
+	repo     repository.TagStore
+	events   *EventService
+	validate *validator.Validate
+}
+
+// NewTagService creates a new TagService
+func NewTagService(repo repository.TagStore, events *EventService) *TagService {
+	validate := validator.New()
+	validate.RegisterValidation("tag_name", func(fl validator.FieldLevel) bool {
+		return model.ValidTagName(fl.Field().String())
+	})
+
+	return &TagService{
+		CRUDService: NewCRUDService(repository.TagCRUD(repo), tagResource, validate),
+		repo:        repo,
+		events:      events,
+		validate:    validate,
+	}
+}
+
+// AttachTags adds existing tags to a task by name
+func (s *TagService) AttachTags(ctx context.Context, taskID string, req *model.AttachTagsRequest) (*model.TaskResponse, error) {
+	for i, name := range req.Tags {
+		req.Tags[i] = normalizeTagName(name)
+	}
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, formatValidationErrors(err))
+	}
+
+	if !isValidID(taskID) {
+		return nil, ErrTaskNotFound
+	}
+
+	slices.Sort(req.Tags)
+	response, err := s.events.Write(ctx, model.EventTaskUpdated, func(ctx context.Context) (*model.Task, error) {
+		return s.repo.AttachTags(ctx, taskID, slices.Compact(req.Tags))
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrTagNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrValidation, err)
+		}
+		if errors.Is(err, repository.ErrNotOwned) {
+			return nil, denied(ErrTaskNotFound)
+		}
+		if errors.Is(err, repository.ErrTaskNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		if errors.Is(err, repository.ErrTaskArchived) {
+			return nil, ErrArchived
+		}
+		return nil, fmt.Errorf("failed to attach tags: %w", err)
+	}
+
+	return response, nil
+}
+
+// DetachTag removes a tag from a task by name
+func (s *TagService) DetachTag(ctx context.Context, taskID, name string) (*model.TaskResponse, error) {
+	if !isValidID(taskID) {
+		return nil, ErrTaskNotFound
+	}
+
+	response, err := s.events.Write(ctx, model.EventTaskUpdated, func(ctx context.Context) (*model.Task, error) {
+		return s.repo.DetachTag(ctx, taskID, normalizeTagName(name))
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotOwned) {
+			return nil, denied(ErrTaskNotFound)
+		}
+		if errors.Is(err, repository.ErrTaskNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		if errors.Is(err, repository.ErrTaskArchived) {
+			return nil, ErrArchived
+		}
+		return nil, fmt.Errorf("failed to detach tag: %w", err)
+	}
+
+	return response, nil
+}
+
+// Expand sets the tag details of every task, loading every tag the tasks
+// carry in one query. Details follow the order of the task's tag names.
+func (s *TagService) Expand(ctx context.Context, tasks ...*model.TaskResponse) error {
+	var names []string
+	for _, task := range tasks {
+		for _, name := range task.Tags {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	tags, err := s.repo.TagsByName(ctx, names)
+	if err != nil {
+		return fmt.Errorf("failed to get tags: %w", err)
+	}
+	byName := make(map[string]*model.TagResponse, len(tags))
+	for _, tag := range tags {
+		byName[tag.Name] = tag.ToResponse()
+	}
+
+	for _, task := range tasks {
+		details := make([]*model.TagResponse, 0, len(task.Tags))
+		for _, name := range task.Tags {
+			if tag, ok := byName[name]; ok {
+				details = append(details, tag)
+			}
+		}
+		task.TagDetails = details
+	}
+
+	return nil
+}
+
+// normalizeTagName makes tag names case-insensitive
+func normalizeTagName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagService_AttachDetach(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryTaskRepository(0)
+	store := repository.NewMemoryEventRepository()
+	events := NewEventService(store, kvstore.NewMemory(), &config.EventsConfig{})
+	tasks := NewTaskService(repo, nil, nil, events, nil, nil, nil, &config.TaskConfig{DefaultProject: "TASK"})
+	tags := NewTagService(repo, events)
+
+	task, err := tasks.Create(ctx, &model.CreateTaskRequest{Title: "Release"})
+	require.NoError(t, err)
+	for _, name := range []string{"ops", "urgent"} {
+		_, err := tags.Create(ctx, &model.CreateTagRequest{Name: name})
+		require.NoError(t, err)
+	}
+	changes := func() []*model.TaskEvent {
+		listed, err := store.ListAfter(ctx, 0, math.MaxInt64, 100)
+		require.NoError(t, err)
+		return listed
+	}
+
+	// Names are normalized and repeated ones attached once
+	tagged, err := tags.AttachTags(ctx, task.ID, &model.AttachTagsRequest{Tags: []string{"Ops", " urgent", "ops"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ops", "urgent"}, tagged.Tags)
+	assert.Greater(t, tagged.Version, task.Version)
+	require.Len(t, changes(), 2)
+	assert.Equal(t, model.EventTaskUpdated, changes()[1].Type)
+
+	// An unknown tag fails the whole request
+	_, err = tags.AttachTags(ctx, task.ID, &model.AttachTagsRequest{Tags: []string{"ops", "missing"}})
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.ErrorContains(t, err, "missing")
+	assert.Len(t, changes(), 2)
+
+	untagged, err := tags.DetachTag(ctx, task.ID, "URGENT")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ops"}, untagged.Tags)
+
+	// Detaching a tag the task does not carry changes nothing
+	same, err := tags.DetachTag(ctx, task.ID, "urgent")
+	require.NoError(t, err)
+	assert.Equal(t, untagged.Version, same.Version)
+
+	_, err = tags.AttachTags(ctx, "00000000-0000-0000-0000-000000000000", &model.AttachTagsRequest{Tags: []string{"ops"}})
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+	_, err = tags.AttachTags(ctx, task.ID, &model.AttachTagsRequest{})
+	assert.ErrorIs(t, err, ErrValidation)
+}
@@ -4,15 +4,37 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/mutlitier_app/internal/audit"
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/features"
 	"github.com/moabdelazem/mutlitier_app/internal/model"
 	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/internal/search"
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
 )
 
 var (
-	ErrValidation   = errors.New("validation error")
-	ErrTaskNotFound = errors.New("task not found")
+	ErrValidation    = errors.New("validation error")
+	ErrForbidden     = errors.New("resource belongs to another user")
+	ErrTaskNotFound  = errors.New("task not found")
+	ErrLimitReached  = errors.New("task limit reached")
+	ErrConflict      = errors.New("task was modified concurrently")
+	ErrNotDeleted    = errors.New("task is not deleted")
+	ErrArchived      = errors.New("task is archived")
+	ErrNotArchived   = errors.New("task is not archived")
+	ErrUnknownUser   = errors.New("user not found")
+	ErrAnonymous     = errors.New("authentication required")
+	ErrUnknownAnchor = errors.New("task to move next to not found")
 )
 
 // ValidationError represents a validation error with field details
@@ -23,42 +45,131 @@ type ValidationError struct {
 
 // TaskService handles business logic for tasks
 type TaskService struct {
-	repo     *repository.TaskRepository
-	validate *validator.Validate
+	repo        repository.TaskStore
+	guard       *QueryGuard
+	degradation *Degradation
+	events      *EventService
+	index       search.Index
+	comments    *CommentService
+	users       *UserService
+	statuses    *StatusMachine
+	cfg         *config.TaskConfig
+	validate    *validator.Validate
 }
 
-// NewTaskService creates a new TaskService
-func NewTaskService(repo *repository.TaskRepository) *TaskService {
+// NewTaskService creates a new TaskService. index may be nil, in which
+// case searches run against the repository, comments may be nil, in
+// which case deletes ignore comments, and users may be nil, in which case
+// assignees are not checked.
+func NewTaskService(repo repository.TaskStore, guard *QueryGuard, degradation *Degradation, events *EventService, index search.Index, comments *CommentService, users *UserService, cfg *config.TaskConfig) *TaskService {
+	validate := validator.New()
+	validate.RegisterValidation("task_status", func(fl validator.FieldLevel) bool {
+		return model.Status(fl.Field().String()).Valid()
+	})
+	validate.RegisterValidation("task_priority", func(fl validator.FieldLevel) bool {
+		return model.Priority(fl.Field().String()).Valid()
+	})
+	validate.RegisterValidation("project_key", func(fl validator.FieldLevel) bool {
+		return model.ValidProjectKey(fl.Field().String())
+	})
+	validate.RegisterValidation("recurrence", func(fl validator.FieldLevel) bool {
+		// Empty clears the recurrence of an update
+		return fl.Field().String() == "" || model.ValidRecurrence(fl.Field().String())
+	})
+
+	statuses, err := NewStatusMachine(cfg.StatusTransitions)
+	if err != nil {
+		logger.Get().Warn().Err(err).Msg("Invalid TASK_STATUS_TRANSITIONS, using the default status transitions")
+		statuses, _ = NewStatusMachine(nil)
+	}
+
 	return &TaskService{
-		repo:     repo,
-		validate: validator.New(),
+		repo:        repo,
+		guard:       guard,
+		degradation: degradation,
+		events:      events,
+		index:       index,
+		comments:    comments,
+		users:       users,
+		statuses:    statuses,
+		cfg:         cfg,
+		validate:    validate,
 	}
 }
 
 // Create creates a new task
 func (s *TaskService) Create(ctx context.Context, req *model.CreateTaskRequest) (*model.TaskResponse, error) {
+	task, err := s.newTask(req)
+	if err != nil {
+		return nil, err
+	}
+	task.Owner = taskOwner(ctx)
+
+	response, err := s.events.Write(ctx, model.EventTaskCreated, func(ctx context.Context) (*model.Task, error) {
+		return s.repo.Create(ctx, task)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrStoreFull) {
+			return nil, ErrLimitReached
+		}
+		return nil, fmt.Errorf("failed to create task: %w", err)
+	}
+
+	return response, nil
+}
+
+// newTask validates req and builds the task it creates
+func (s *TaskService) newTask(req *model.CreateTaskRequest) (*model.Task, error) {
 	// Validate request
 	if err := s.validate.Struct(req); err != nil {
 		return nil, fmt.Errorf("%w: %s", ErrValidation, formatValidationErrors(err))
 	}
 
+	// UUIDv7 IDs are time-ordered and known before the INSERT
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate task id: %w", err)
+	}
+
+	project := req.Project
+	if project == "" {
+		project = s.cfg.DefaultProject
+	}
+
+	priority := req.Priority
+	if priority == "" {
+		priority = model.DefaultPriority
+	}
+
+	if req.DueDate != nil && req.DueDate.Before(time.Now()) {
+		return nil, fmt.Errorf("%w: due_date must not be in the past", ErrValidation)
+	}
+
 	task := &model.Task{
+		ID:          id.String(),
+		ProjectKey:  project,
 		Title:       req.Title,
 		Description: req.Description,
+		Priority:    priority,
+		DueDate:     req.DueDate,
 	}
-
-	createdTask, err := s.repo.Create(ctx, task)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create task: %w", err)
+	if req.Recurrence != "" {
+		task.Recurrence = &req.Recurrence
 	}
-
-	return createdTask.ToResponse(), nil
+	return task, nil
 }
 
 // GetByID retrieves a task by its ID
 func (s *TaskService) GetByID(ctx context.Context, id string) (*model.TaskResponse, error) {
+	if !isValidID(id) {
+		return nil, ErrTaskNotFound
+	}
+
 	task, err := s.repo.GetByID(ctx, id)
 	if err != nil {
+		if errors.Is(err, repository.ErrNotOwned) {
+			return nil, denied(ErrTaskNotFound)
+		}
 		if errors.Is(err, repository.ErrTaskNotFound) {
 			return nil, ErrTaskNotFound
 		}
@@ -68,57 +179,905 @@ func (s *TaskService) GetByID(ctx context.Context, id string) (*model.TaskRespon
 	return task.ToResponse(), nil
 }
 
-// GetAll retrieves all tasks
-func (s *TaskService) GetAll(ctx context.Context) ([]*model.TaskResponse, error) {
-	tasks, err := s.repo.GetAll(ctx)
+// GetByRef retrieves a task by its reference, e.g. PROJ-123
+func (s *TaskService) GetByRef(ctx context.Context, ref string) (*model.TaskResponse, error) {
+	parsed, err := model.ParseRef(ref)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get tasks: %w", err)
+		return nil, fmt.Errorf("%w: reference must look like PROJ-123", ErrValidation)
+	}
+
+	task, err := s.repo.GetByRef(ctx, parsed)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotOwned) {
+			return nil, denied(ErrTaskNotFound)
+		}
+		if errors.Is(err, repository.ErrTaskNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, fmt.Errorf("failed to get task by ref: %w", err)
+	}
+
+	return task.ToResponse(), nil
+}
+
+// maxResolvedRefs caps how many references are resolved from one text
+const maxResolvedRefs = 50
+
+// Resolve extracts task references (PROJ-123) from free text and returns
+// the matching tasks; unknown references are ignored
+func (s *TaskService) Resolve(ctx context.Context, text string) ([]*model.TaskResponse, error) {
+	responses := []*model.TaskResponse{}
+
+	refs := model.ExtractRefs(text, maxResolvedRefs)
+	if len(refs) == 0 {
+		return responses, nil
+	}
+
+	tasks, err := s.repo.GetByRefs(ctx, refs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve task refs: %w", err)
 	}
 
-	var responses []*model.TaskResponse
 	for _, task := range tasks {
 		responses = append(responses, task.ToResponse())
 	}
 
+	return responses, nil
+}
+
+// GetAll retrieves tasks matching the list options
+func (s *TaskService) GetAll(ctx context.Context, opts *model.ListOptions) (*model.TaskListResponse, error) {
+	if err := s.guard.Check(ctx, opts); err != nil {
+		return nil, err
+	}
+
+	if opts.Search != "" && s.degradation.SearchDisabled() {
+		return nil, fmt.Errorf("%w: search is unavailable, list without q", ErrDegraded)
+	}
+
+	if opts.Search != "" && features.Enabled(ctx, features.FullTextListSearch) {
+		result, err := s.Search(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		s.guard.SetNextCursor(ctx, opts, &result.Pagination)
+		return result, nil
+	}
+
+	tasks, err := s.repo.GetAll(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tasks: %w", err)
+	}
+
+	total, err := s.repo.Count(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count tasks: %w", err)
+	}
+
 	// Return empty slice instead of nil
-	if responses == nil {
-		responses = []*model.TaskResponse{}
+	responses := make([]*model.TaskResponse, 0, len(tasks))
+	for _, task := range tasks {
+		responses = append(responses, task.ToResponse())
 	}
 
-	return responses, nil
+	pagination := listing.NewPagination(opts.Page, opts.PerPage, total)
+	s.guard.SetNextCursor(ctx, opts, &pagination)
+
+	return &model.TaskListResponse{
+		Data:       responses,
+		Pagination: pagination,
+	}, nil
+}
+
+// Search runs a ranked full-text search over task titles and descriptions
+func (s *TaskService) Search(ctx context.Context, opts *model.ListOptions) (*model.TaskListResponse, error) {
+	opts.Search = strings.TrimSpace(opts.Search)
+	if opts.Search == "" {
+		return nil, fmt.Errorf("%w: q is required", ErrValidation)
+	}
+
+	if err := s.guard.CheckPage(opts); err != nil {
+		return nil, err
+	}
+
+	if s.degradation.SearchDisabled() {
+		return nil, fmt.Errorf("%w: search is unavailable", ErrDegraded)
+	}
+
+	// Indexed documents span every tenant and owner, so scoped searches
+	// go to Postgres, where both are enforced
+	_, owned := repository.ScopeFrom(ctx)
+	if s.index != nil && !owned && tenant.From(ctx) == "" {
+		responses, total, err := s.index.Search(ctx, opts.Search, opts.Offset(), opts.PerPage)
+		if err == nil {
+			if responses == nil {
+				responses = []*model.TaskResponse{}
+			}
+			// Indexed documents keep is_overdue from when they were written
+			now := time.Now()
+			for _, response := range responses {
+				response.IsOverdue = model.Overdue(response.DueDate, response.Status, now)
+			}
+			return &model.TaskListResponse{
+				Data:       responses,
+				Pagination: listing.NewPagination(opts.Page, opts.PerPage, total),
+			}, nil
+		}
+		logger.Ctx(ctx).Warn().Err(err).Msg("Search index unavailable, falling back to Postgres")
+	}
+
+	tasks, err := s.repo.Search(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search tasks: %w", err)
+	}
+
+	total, err := s.repo.CountSearch(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count search results: %w", err)
+	}
+
+	responses := make([]*model.TaskResponse, 0, len(tasks))
+	for _, task := range tasks {
+		responses = append(responses, task.ToResponse())
+	}
+
+	return &model.TaskListResponse{
+		Data:       responses,
+		Pagination: listing.NewPagination(opts.Page, opts.PerPage, total),
+	}, nil
 }
 
-// Update updates a task
-func (s *TaskService) Update(ctx context.Context, id string, req *model.UpdateTaskRequest) (*model.TaskResponse, error) {
+// Update updates a task that is still at expectedVersion
+// (repository.AnyVersion skips the check)
+func (s *TaskService) Update(ctx context.Context, id string, req *model.UpdateTaskRequest, expectedVersion int64) (*model.TaskResponse, error) {
 	// Validate request
 	if err := s.validate.Struct(req); err != nil {
 		return nil, fmt.Errorf("%w: %s", ErrValidation, formatValidationErrors(err))
 	}
 
-	updatedTask, err := s.repo.Update(ctx, id, req)
+	if !isValidID(id) {
+		return nil, ErrTaskNotFound
+	}
+
+	if err := s.checkTransition(ctx, id, req, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	s.auditBefore(ctx, id)
+	response, err := s.events.Write(ctx, model.EventTaskUpdated, func(ctx context.Context) (*model.Task, error) {
+		return s.repo.Update(ctx, id, req, expectedVersion)
+	})
 	if err != nil {
+		if errors.Is(err, repository.ErrNotOwned) {
+			return nil, denied(ErrTaskNotFound)
+		}
 		if errors.Is(err, repository.ErrTaskNotFound) {
 			return nil, ErrTaskNotFound
 		}
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return nil, ErrConflict
+		}
+		if errors.Is(err, repository.ErrTaskArchived) {
+			return nil, ErrArchived
+		}
 		return nil, fmt.Errorf("failed to update task: %w", err)
 	}
 
-	return updatedTask.ToResponse(), nil
+	return response, nil
+}
+
+// Patch applies an RFC 7386 merge patch to a task that is still at
+// expectedVersion (repository.AnyVersion skips the check)
+func (s *TaskService) Patch(ctx context.Context, id string, data []byte, expectedVersion int64) (*model.TaskResponse, error) {
+	patch, err := model.ParseMergePatch(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, err)
+	}
+
+	if err := s.validate.Struct(patch); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, formatValidationErrors(err))
+	}
+
+	if patch.Empty() {
+		task, err := s.GetByID(ctx, id)
+		if err == nil && expectedVersion != repository.AnyVersion && task.Version != expectedVersion {
+			return nil, ErrConflict
+		}
+		return task, err
+	}
+
+	if !isValidID(id) {
+		return nil, ErrTaskNotFound
+	}
+
+	updates := patch.ToUpdate()
+	if err := s.checkTransition(ctx, id, updates, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	s.auditBefore(ctx, id)
+	response, err := s.events.Write(ctx, model.EventTaskUpdated, func(ctx context.Context) (*model.Task, error) {
+		return s.repo.Update(ctx, id, updates, expectedVersion)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotOwned) {
+			return nil, denied(ErrTaskNotFound)
+		}
+		if errors.Is(err, repository.ErrTaskNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return nil, ErrConflict
+		}
+		if errors.Is(err, repository.ErrTaskArchived) {
+			return nil, ErrArchived
+		}
+		return nil, fmt.Errorf("failed to patch task: %w", err)
+	}
+
+	return response, nil
+}
+
+// BulkUpdate applies the same changes to every task in req.IDs in one
+// statement. Missing, soft-deleted and malformed IDs are reported as not
+// found and archived tasks as archived instead of failing the request.
+func (s *TaskService) BulkUpdate(ctx context.Context, req *model.BulkUpdateRequest) (*model.BulkUpdateResponse, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, formatValidationErrors(err))
+	}
+
+	changes := req.Changes
+	if err := checkBulkChanges(&changes); err != nil {
+		return nil, err
+	}
+
+	ids, notFound, err := s.bulkIDs(req.IDs)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &model.BulkUpdateResponse{Updated: []*model.TaskResponse{}, NotFound: notFound}
+	if len(ids) == 0 {
+		return response, nil
+	}
+
+	// Only tasks whose status may move to the new one are updated
+	if changes.Status != nil {
+		changes.FromStatuses = s.statuses.Sources(*changes.Status)
+	}
+
+	updated := make(map[string]*model.TaskResponse)
+	err = s.events.Atomically(ctx, func(ctx context.Context) error {
+		tasks, err := s.repo.BulkUpdate(ctx, ids, &changes)
+		if err != nil {
+			return fmt.Errorf("failed to bulk update tasks: %w", err)
+		}
+		for _, task := range tasks {
+			taskResponse := task.ToResponse()
+			if _, err := s.events.Record(ctx, model.EventTaskUpdated, taskResponse.ID, taskResponse); err != nil {
+				return err
+			}
+			updated[task.ID] = taskResponse
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Report in request order, RETURNING order is unspecified
+	for _, id := range ids {
+		taskResponse, ok := updated[id]
+		if !ok {
+			// Tell archived tasks and rejected transitions apart from missing tasks
+			if current, err := s.repo.GetByID(ctx, id); err == nil {
+				if current.Archived {
+					response.Archived = append(response.Archived, id)
+					continue
+				}
+				if changes.Status != nil {
+					response.Rejected = append(response.Rejected, id)
+					continue
+				}
+			}
+			response.NotFound = append(response.NotFound, id)
+			continue
+		}
+		response.Updated = append(response.Updated, taskResponse)
+	}
+
+	return response, nil
+}
+
+// checkBulkChanges rejects bulk updates that change nothing
+func checkBulkChanges(changes *model.UpdateTaskRequest) error {
+	if changes.Title == nil && changes.Description == nil && changes.Status == nil && changes.Priority == nil && changes.DueDate == nil &&
+		changes.Recurrence == nil {
+		return fmt.Errorf("%w: changes must set at least one of title, description, status, priority, due_date or recurrence", ErrValidation)
+	}
+	return nil
 }
 
-// Delete deletes a task
-func (s *TaskService) Delete(ctx context.Context, id string) error {
-	err := s.repo.Delete(ctx, id)
+// checkTransition rejects status changes the state machine does not
+// allow. The update is then limited to the status that was checked, so a
+// concurrent status change surfaces as a conflict instead of slipping past.
+func (s *TaskService) checkTransition(ctx context.Context, id string, updates *model.UpdateTaskRequest, expectedVersion int64) error {
+	if updates.Status == nil {
+		return nil
+	}
+
+	task, err := s.repo.GetByID(ctx, id)
 	if err != nil {
+		if errors.Is(err, repository.ErrNotOwned) {
+			return denied(ErrTaskNotFound)
+		}
 		if errors.Is(err, repository.ErrTaskNotFound) {
 			return ErrTaskNotFound
 		}
+		return fmt.Errorf("failed to get task: %w", err)
+	}
+	if task.Archived {
+		return ErrArchived
+	}
+	if expectedVersion != repository.AnyVersion && task.Version != expectedVersion {
+		return ErrConflict
+	}
+	if err := s.statuses.Check(task.Status, *updates.Status); err != nil {
+		return err
+	}
+
+	updates.FromStatuses = []model.Status{task.Status}
+	return nil
+}
+
+// BulkDelete soft-deletes every task in req.IDs in one statement.
+// Missing, already deleted and malformed IDs are reported as not found.
+func (s *TaskService) BulkDelete(ctx context.Context, req *model.BulkDeleteRequest) (*model.BulkDeleteResponse, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, formatValidationErrors(err))
+	}
+
+	ids, notFound, err := s.bulkIDs(req.IDs)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.deleteTasks(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	response.NotFound = append(notFound, response.NotFound...)
+	return response, nil
+}
+
+// deleteTasks soft-deletes the tasks with the given normalized IDs,
+// leaving those blocked by comments alone, and reports the outcome in
+// request order
+func (s *TaskService) deleteTasks(ctx context.Context, ids []string) (*model.BulkDeleteResponse, error) {
+	response := &model.BulkDeleteResponse{Deleted: []string{}, NotFound: []string{}}
+	if len(ids) == 0 {
+		return response, nil
+	}
+
+	deleted := make(map[string]bool)
+	err := s.events.Atomically(ctx, func(ctx context.Context) error {
+		deleteCtx := ctx
+		if s.comments != nil {
+			deleteCtx = s.comments.Protect(ctx)
+		}
+		deletedIDs, err := s.repo.BulkDelete(deleteCtx, ids)
+		if err != nil {
+			return fmt.Errorf("failed to bulk delete tasks: %w", err)
+		}
+		for _, id := range deletedIDs {
+			if _, err := s.events.Record(ctx, model.EventTaskDeleted, id, nil); err != nil {
+				return err
+			}
+			deleted[id] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The delete already left commented tasks alone; this only tells
+	// them apart from the other misses
+	var blocked []string
+	if missed := slices.DeleteFunc(slices.Clone(ids), func(id string) bool { return deleted[id] }); len(missed) > 0 && s.comments != nil {
+		if blocked, err = s.comments.Blocked(ctx, missed); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, id := range ids {
+		if !deleted[id] {
+			if slices.Contains(blocked, id) {
+				response.Blocked = append(response.Blocked, id)
+				continue
+			}
+			if current, err := s.repo.GetByID(ctx, id); err == nil && current.Archived {
+				response.Archived = append(response.Archived, id)
+				continue
+			}
+			response.NotFound = append(response.NotFound, id)
+			continue
+		}
+		response.Deleted = append(response.Deleted, id)
+	}
+
+	return response, nil
+}
+
+// PlanBulkUpdate reports which tasks BulkUpdate would change, without
+// changing them
+func (s *TaskService) PlanBulkUpdate(ctx context.Context, req *model.BulkUpdateRequest) (*model.BulkPlan, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, formatValidationErrors(err))
+	}
+
+	if err := checkBulkChanges(&req.Changes); err != nil {
+		return nil, err
+	}
+
+	var sources []model.Status
+	if req.Changes.Status != nil {
+		sources = s.statuses.Sources(*req.Changes.Status)
+	}
+
+	return s.plan(ctx, model.BulkActionUpdate, req.IDs, func(plan *model.BulkPlan, task *model.Task) {
+		if sources != nil && !slices.Contains(sources, task.Status) {
+			plan.Rejected = append(plan.Rejected, task.ID)
+			return
+		}
+		plan.Affected = append(plan.Affected, task.ID)
+	})
+}
+
+// PlanBulkDelete reports which tasks BulkDelete would delete, without
+// deleting them
+func (s *TaskService) PlanBulkDelete(ctx context.Context, req *model.BulkDeleteRequest) (*model.BulkPlan, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, formatValidationErrors(err))
+	}
+
+	var blocked []string
+	if s.comments != nil {
+		ids, _, err := s.bulkIDs(req.IDs)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) > 0 {
+			if blocked, err = s.comments.Blocked(ctx, ids); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return s.plan(ctx, model.BulkActionDelete, req.IDs, func(plan *model.BulkPlan, task *model.Task) {
+		if slices.Contains(blocked, task.ID) {
+			plan.Blocked = append(plan.Blocked, task.ID)
+			return
+		}
+		plan.Affected = append(plan.Affected, task.ID)
+	})
+}
+
+// plan sorts the tasks of a bulk request into the lists of a plan, in
+// request order. classify places tasks that are neither missing nor archived.
+func (s *TaskService) plan(ctx context.Context, action model.BulkAction, raw []string, classify func(*model.BulkPlan, *model.Task)) (*model.BulkPlan, error) {
+	ids, notFound, err := s.bulkIDs(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &model.BulkPlan{Action: action, Affected: []string{}, NotFound: notFound}
+	if len(ids) == 0 {
+		return plan, nil
+	}
+
+	tasks, err := s.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tasks: %w", err)
+	}
+	found := make(map[string]*model.Task, len(tasks))
+	for _, task := range tasks {
+		found[task.ID] = task
+	}
+
+	for _, id := range ids {
+		task, ok := found[id]
+		switch {
+		case !ok:
+			plan.NotFound = append(plan.NotFound, id)
+		case task.Archived:
+			plan.Archived = append(plan.Archived, id)
+		default:
+			classify(plan, task)
+		}
+	}
+	plan.AffectedCount = len(plan.Affected)
+
+	return plan, nil
+}
+
+// bulkIDs deduplicates and normalizes the IDs of a bulk request. Malformed
+// IDs cannot match a task, so they are returned as not found right away.
+func (s *TaskService) bulkIDs(raw []string) (ids, notFound []string, err error) {
+	if len(raw) > s.cfg.BulkMaxIDs {
+		return nil, nil, fmt.Errorf("%w: at most %d ids are accepted per request", ErrValidation, s.cfg.BulkMaxIDs)
+	}
+
+	notFound = []string{}
+	seen := make(map[string]bool, len(raw))
+	for _, value := range raw {
+		parsed, err := uuid.Parse(value)
+		if err != nil {
+			notFound = append(notFound, value)
+			continue
+		}
+		id := parsed.String()
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+
+	return ids, notFound, nil
+}
+
+// Delete soft-deletes a task that is still at expectedVersion
+// (repository.AnyVersion skips the check); it can be restored later
+func (s *TaskService) Delete(ctx context.Context, id string, expectedVersion int64) error {
+	return s.delete(ctx, id, expectedVersion, s.repo.Delete)
+}
+
+// HardDelete permanently removes a task, including a soft-deleted one,
+// that is still at expectedVersion (repository.AnyVersion skips the check)
+func (s *TaskService) HardDelete(ctx context.Context, id string, expectedVersion int64) error {
+	if err := s.delete(ctx, id, expectedVersion, s.repo.HardDelete); err != nil {
+		return err
+	}
+
+	if s.comments != nil {
+		if err := s.comments.TaskRemoved(ctx, id); err != nil {
+			logger.Ctx(ctx).Warn().Err(err).Str("task_id", id).Msg("Failed to delete comments of removed task")
+		}
+	}
+
+	return nil
+}
+
+// Restore brings back a soft-deleted task
+func (s *TaskService) Restore(ctx context.Context, id string) (*model.TaskResponse, error) {
+	if !isValidID(id) {
+		return nil, ErrTaskNotFound
+	}
+
+	response, err := s.events.Write(ctx, model.EventTaskRestored, func(ctx context.Context) (*model.Task, error) {
+		return s.repo.Restore(ctx, id)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotOwned) {
+			return nil, denied(ErrTaskNotFound)
+		}
+		if errors.Is(err, repository.ErrTaskNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		if errors.Is(err, repository.ErrTaskNotDeleted) {
+			return nil, ErrNotDeleted
+		}
+		return nil, fmt.Errorf("failed to restore task: %w", err)
+	}
+
+	return response, nil
+}
+
+// Duplicate creates a pending copy of a task with a new ID and reference.
+// Archived tasks can be duplicated; the copy is not archived.
+func (s *TaskService) Duplicate(ctx context.Context, id string, opts *model.DuplicateOptions) (*model.TaskResponse, error) {
+	if !isValidID(id) {
+		return nil, ErrTaskNotFound
+	}
+
+	newID, err := uuid.NewV7()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate task id: %w", err)
+	}
+
+	opts.Owner = taskOwner(ctx)
+	response, err := s.events.Write(ctx, model.EventTaskCreated, func(ctx context.Context) (*model.Task, error) {
+		return s.repo.Duplicate(ctx, id, newID.String(), opts)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotOwned) {
+			return nil, denied(ErrTaskNotFound)
+		}
+		if errors.Is(err, repository.ErrTaskNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		if errors.Is(err, repository.ErrStoreFull) {
+			return nil, ErrLimitReached
+		}
+		return nil, fmt.Errorf("failed to duplicate task: %w", err)
+	}
+
+	return response, nil
+}
+
+// Archive makes a task read-only and hides it from the default list
+func (s *TaskService) Archive(ctx context.Context, id string) (*model.TaskResponse, error) {
+	return s.setArchived(ctx, id, true)
+}
+
+// Unarchive makes an archived task writable and listed again
+func (s *TaskService) Unarchive(ctx context.Context, id string) (*model.TaskResponse, error) {
+	return s.setArchived(ctx, id, false)
+}
+
+func (s *TaskService) setArchived(ctx context.Context, id string, archived bool) (*model.TaskResponse, error) {
+	if !isValidID(id) {
+		return nil, ErrTaskNotFound
+	}
+
+	s.auditBefore(ctx, id)
+	response, err := s.events.Write(ctx, model.EventTaskUpdated, func(ctx context.Context) (*model.Task, error) {
+		return s.repo.SetArchived(ctx, id, archived)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotOwned) {
+			return nil, denied(ErrTaskNotFound)
+		}
+		if errors.Is(err, repository.ErrTaskNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		if errors.Is(err, repository.ErrTaskArchived) {
+			return nil, ErrArchived
+		}
+		if errors.Is(err, repository.ErrTaskNotArchived) {
+			return nil, ErrNotArchived
+		}
+		return nil, fmt.Errorf("failed to archive task: %w", err)
+	}
+
+	return response, nil
+}
+
+// Assign assigns a task to a user who has authenticated before, or to the
+// caller when req.Assignee is "me"
+func (s *TaskService) Assign(ctx context.Context, id string, req *model.AssignTaskRequest) (*model.TaskResponse, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, formatValidationErrors(err))
+	}
+
+	assignee, err := resolveAssignee(ctx, req.Assignee)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.users != nil {
+		exists, err := s.users.Exists(ctx, assignee)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrUnknownUser
+		}
+	}
+
+	return s.setAssignee(ctx, id, &assignee)
+}
+
+// Unassign removes a task's assignee
+func (s *TaskService) Unassign(ctx context.Context, id string) (*model.TaskResponse, error) {
+	return s.setAssignee(ctx, id, nil)
+}
+
+func (s *TaskService) setAssignee(ctx context.Context, id string, assignee *string) (*model.TaskResponse, error) {
+	if !isValidID(id) {
+		return nil, ErrTaskNotFound
+	}
+
+	s.auditBefore(ctx, id)
+	response, err := s.events.Write(ctx, model.EventTaskUpdated, func(ctx context.Context) (*model.Task, error) {
+		return s.repo.SetAssignee(ctx, id, assignee)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotOwned) {
+			return nil, denied(ErrTaskNotFound)
+		}
+		if errors.Is(err, repository.ErrTaskNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		if errors.Is(err, repository.ErrTaskArchived) {
+			return nil, ErrArchived
+		}
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, ErrUnknownUser
+		}
+		return nil, fmt.Errorf("failed to assign task: %w", err)
+	}
+
+	return response, nil
+}
+
+// SetTeam shares a task with a team, so its members can see and edit it,
+// or stops sharing it when team is nil. Whether the caller may share with
+// the team is for TeamService.Share to check.
+func (s *TaskService) SetTeam(ctx context.Context, id string, team *string) (*model.TaskResponse, error) {
+	if !isValidID(id) {
+		return nil, ErrTaskNotFound
+	}
+
+	s.auditBefore(ctx, id)
+	response, err := s.events.Write(ctx, model.EventTaskUpdated, func(ctx context.Context) (*model.Task, error) {
+		return s.repo.SetTeam(ctx, id, team)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotOwned) {
+			return nil, denied(ErrTaskNotFound)
+		}
+		if errors.Is(err, repository.ErrTaskNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		if errors.Is(err, repository.ErrTaskArchived) {
+			return nil, ErrArchived
+		}
+		if errors.Is(err, repository.ErrTeamNotFound) {
+			return nil, ErrTeamNotFound
+		}
+		return nil, fmt.Errorf("failed to share task: %w", err)
+	}
+
+	return response, nil
+}
+
+// Move places a task right before or right after another task in the
+// manual order that sort=position lists
+func (s *TaskService) Move(ctx context.Context, id string, req *model.MoveTaskRequest) (*model.TaskResponse, error) {
+	if !isValidID(id) {
+		return nil, ErrTaskNotFound
+	}
+	if (req.Before == "") == (req.After == "") {
+		return nil, fmt.Errorf("%w: exactly one of before or after is required", ErrValidation)
+	}
+
+	anchor, after := req.Before, false
+	if req.After != "" {
+		anchor, after = req.After, true
+	}
+	if anchor == id {
+		return nil, fmt.Errorf("%w: a task cannot be moved next to itself", ErrValidation)
+	}
+	if !isValidID(anchor) {
+		return nil, ErrUnknownAnchor
+	}
+
+	response, err := s.events.Write(ctx, model.EventTaskUpdated, func(ctx context.Context) (*model.Task, error) {
+		return s.repo.Move(ctx, id, anchor, after)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotOwned) {
+			return nil, denied(ErrTaskNotFound)
+		}
+		if errors.Is(err, repository.ErrTaskNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		if errors.Is(err, repository.ErrAnchorNotFound) {
+			return nil, ErrUnknownAnchor
+		}
+		if errors.Is(err, repository.ErrTaskArchived) {
+			return nil, ErrArchived
+		}
+		return nil, fmt.Errorf("failed to move task: %w", err)
+	}
+
+	return response, nil
+}
+
+// Scope returns ctx limited to the caller's own tasks. Signed-in users
+// other than admins only reach tasks they own and tasks shared with their
+// teams, and anonymous requests only tasks of no owner and no team;
+// admins and background jobs, which never pass through here, stay
+// unscoped.
+func (s *TaskService) Scope(ctx context.Context) context.Context {
+	principal := auth.FromContext(ctx)
+	if principal != nil && principal.Has(auth.ScopeAdmin) {
+		return ctx
+	}
+	if principal == nil || principal.User == "" {
+		return repository.WithScope(ctx, repository.Unowned())
+	}
+	return repository.WithScope(ctx, repository.OwnedBy(principal.User))
+}
+
+// taskOwner returns the signed-in caller a new task belongs to, or nil
+// for an anonymous request
+func taskOwner(ctx context.Context) *string {
+	principal := auth.FromContext(ctx)
+	if principal == nil || principal.User == "" {
+		return nil
+	}
+	return &principal.User
+}
+
+// resolveAssignee replaces "me" with the authenticated caller
+func resolveAssignee(ctx context.Context, assignee string) (string, error) {
+	if assignee != model.AssigneeMe {
+		return assignee, nil
+	}
+	principal := auth.FromContext(ctx)
+	if principal == nil {
+		return "", fmt.Errorf("%w: sign in to use assignee=me", ErrAnonymous)
+	}
+	return principal.User, nil
+}
+
+// auditBefore records the task as it was before an audited change, at the
+// cost of one more read
+func (s *TaskService) auditBefore(ctx context.Context, id string) {
+	if !audit.Audited(ctx) {
+		return
+	}
+	if task, err := s.repo.GetByID(ctx, id); err == nil {
+		audit.Before(ctx, task.ToResponse())
+	}
+}
+
+func (s *TaskService) delete(ctx context.Context, id string, expectedVersion int64, remove func(ctx context.Context, id string, expectedVersion int64) error) error {
+	if !isValidID(id) {
+		return ErrTaskNotFound
+	}
+
+	s.auditBefore(ctx, id)
+	err := s.events.Atomically(ctx, func(ctx context.Context) error {
+		deleteCtx := ctx
+		if s.comments != nil {
+			deleteCtx = s.comments.Protect(ctx)
+		}
+		if err := remove(deleteCtx, id, expectedVersion); err != nil {
+			return err
+		}
+		_, err := s.events.Record(ctx, model.EventTaskDeleted, id, nil)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrTaskCommented) {
+			return ErrTaskHasComments
+		}
+		if errors.Is(err, repository.ErrNotOwned) {
+			return denied(ErrTaskNotFound)
+		}
+		if errors.Is(err, repository.ErrTaskNotFound) {
+			return ErrTaskNotFound
+		}
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return ErrConflict
+		}
+		if errors.Is(err, repository.ErrTaskArchived) {
+			return ErrArchived
+		}
 		return fmt.Errorf("failed to delete task: %w", err)
 	}
 
 	return nil
 }
 
+// denied wraps a resource's not found error as ErrForbidden for a resource
+// owned by another user, leaving the handler's access policy to choose
+// between the two
+func denied(notFound error) error {
+	return fmt.Errorf("%w: %w", ErrForbidden, notFound)
+}
+
+// isValidID reports whether id is a well-formed UUID, so malformed IDs
+// are treated as missing instead of reaching the database
+func isValidID(id string) bool {
+	_, err := uuid.Parse(id)
+	return err == nil
+}
+
 // formatValidationErrors formats validation errors into a user-friendly message
 func formatValidationErrors(err error) string {
 	var validationErrors validator.ValidationErrors
@@ -130,11 +1089,29 @@ func formatValidationErrors(err error) string {
 			case "required":
 				message = fmt.Sprintf("%s is required", e.Field())
 			case "min":
+				if e.Kind() == reflect.Slice {
+					message = fmt.Sprintf("%s must contain at least %s items", e.Field(), e.Param())
+					break
+				}
 				message = fmt.Sprintf("%s must be at least %s characters", e.Field(), e.Param())
 			case "max":
 				message = fmt.Sprintf("%s must be at most %s characters", e.Field(), e.Param())
 			case "oneof":
 				message = fmt.Sprintf("%s must be one of: %s", e.Field(), e.Param())
+			case "project_key":
+				message = fmt.Sprintf("%s must be 2-16 uppercase letters or digits, starting with a letter", e.Field())
+			case "task_status":
+				message = (&model.InvalidStatusError{}).Error()
+			case "task_priority":
+				message = (&model.InvalidPriorityError{}).Error()
+			case "tag_name":
+				message = "tag names must be 1-32 lowercase letters, digits, - or _, starting with a letter or digit"
+			case "recurrence":
+				message = fmt.Sprintf("%s must be a cron expression such as \"0 9 * * MON\" or a descriptor such as @daily", e.Field())
+			case "username":
+				message = "username must be 3-64 lowercase letters, digits, ., - or _, starting with a letter or digit, and not a reserved name"
+			case "hexcolor":
+				message = fmt.Sprintf("%s must be a hex color such as #1f6feb", e.Field())
 			default:
 				message = fmt.Sprintf("%s is invalid", e.Field())
 			}
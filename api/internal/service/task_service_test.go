@@ -0,0 +1,435 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskService_Bulk(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryTaskRepository(0)
+	events := NewEventService(repository.NewMemoryEventRepository(), kvstore.NewMemory(), &config.EventsConfig{})
+	svc := NewTaskService(repo, nil, nil, events, nil, nil, nil, &config.TaskConfig{DefaultProject: "TASK", BulkMaxIDs: 5})
+
+	var ids []string
+	for _, title := range []string{"One", "Two", "Three"} {
+		task, err := svc.Create(ctx, &model.CreateTaskRequest{Title: title})
+		require.NoError(t, err)
+		ids = append(ids, task.ID)
+	}
+	missing := uuid.NewString()
+
+	// Unknown and malformed IDs are reported, duplicates are applied once
+	inProgress := model.StatusInProgress
+	updated, err := svc.BulkUpdate(ctx, &model.BulkUpdateRequest{
+		IDs:     []string{strings.ToUpper(ids[0]), ids[1], ids[0], missing, "nope"},
+		Changes: model.UpdateTaskRequest{Status: &inProgress},
+	})
+	require.NoError(t, err)
+	require.Len(t, updated.Updated, 2)
+	assert.Equal(t, ids[0], updated.Updated[0].ID)
+	assert.Equal(t, inProgress, updated.Updated[0].Status)
+	assert.Equal(t, int64(2), updated.Updated[0].Version)
+	assert.Equal(t, []string{"nope", missing}, updated.NotFound)
+
+	// Tasks whose status may not move to the new one are rejected
+	completed := model.StatusCompleted
+	updated, err = svc.BulkUpdate(ctx, &model.BulkUpdateRequest{IDs: ids, Changes: model.UpdateTaskRequest{Status: &completed}})
+	require.NoError(t, err)
+	require.Len(t, updated.Updated, 2)
+	assert.Equal(t, ids[2:], updated.Rejected)
+	assert.Empty(t, updated.NotFound)
+
+	_, err = svc.BulkUpdate(ctx, &model.BulkUpdateRequest{IDs: ids})
+	assert.ErrorIs(t, err, ErrValidation)
+	_, err = svc.BulkDelete(ctx, &model.BulkDeleteRequest{IDs: make([]string, 6)})
+	assert.ErrorIs(t, err, ErrValidation)
+	_, err = svc.BulkDelete(ctx, &model.BulkDeleteRequest{IDs: []string{}})
+	assert.EqualError(t, err, "validation error: IDs must contain at least 1 items")
+
+	// Deleted tasks count as not found on the next bulk request
+	deleted, err := svc.BulkDelete(ctx, &model.BulkDeleteRequest{IDs: ids[:2]})
+	require.NoError(t, err)
+	assert.Equal(t, ids[:2], deleted.Deleted)
+	assert.Empty(t, deleted.NotFound)
+
+	deleted, err = svc.BulkDelete(ctx, &model.BulkDeleteRequest{IDs: ids})
+	require.NoError(t, err)
+	assert.Equal(t, ids[2:], deleted.Deleted)
+	assert.Equal(t, ids[:2], deleted.NotFound)
+
+	latest, err := events.Latest(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), latest)
+}
+
+func TestTaskService_DueDate(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryTaskRepository(0)
+	events := NewEventService(repository.NewMemoryEventRepository(), kvstore.NewMemory(), &config.EventsConfig{})
+	guard := NewQueryGuard(&config.QueryGuardConfig{DefaultPerPage: 10, MaxPerPage: 10})
+	svc := NewTaskService(repo, guard, NewDegradation(&config.DegradationConfig{}), events, nil, nil, nil, &config.TaskConfig{DefaultProject: "TASK"})
+
+	past := time.Now().Add(-time.Hour)
+	_, err := svc.Create(ctx, &model.CreateTaskRequest{Title: "Late", DueDate: &past})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	soon := time.Now().Add(time.Hour)
+	task, err := svc.Create(ctx, &model.CreateTaskRequest{Title: "Soon", DueDate: &soon})
+	require.NoError(t, err)
+	assert.False(t, task.IsOverdue)
+	_, err = svc.Create(ctx, &model.CreateTaskRequest{Title: "Whenever"})
+	require.NoError(t, err)
+
+	// Updates may move the due date into the past, which makes the task overdue
+	task, err = svc.Update(ctx, task.ID, &model.UpdateTaskRequest{DueDate: &past}, repository.AnyVersion)
+	require.NoError(t, err)
+	assert.True(t, task.IsOverdue)
+
+	list, err := svc.GetAll(ctx, &model.ListOptions{Overdue: true})
+	require.NoError(t, err)
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, task.ID, list.Data[0].ID)
+
+	// Closed tasks are never overdue
+	cancelled := model.StatusCancelled
+	task, err = svc.Update(ctx, task.ID, &model.UpdateTaskRequest{Status: &cancelled}, repository.AnyVersion)
+	require.NoError(t, err)
+	assert.False(t, task.IsOverdue)
+
+	list, err = svc.GetAll(ctx, &model.ListOptions{Overdue: true})
+	require.NoError(t, err)
+	assert.Empty(t, list.Data)
+}
+
+func TestTaskService_StatusTransitions(t *testing.T) {
+	ctx := context.Background()
+	events := NewEventService(repository.NewMemoryEventRepository(), kvstore.NewMemory(), &config.EventsConfig{})
+	svc := NewTaskService(repository.NewMemoryTaskRepository(0), nil, nil, events, nil, nil, nil, &config.TaskConfig{DefaultProject: "TASK"})
+
+	task, err := svc.Create(ctx, &model.CreateTaskRequest{Title: "Ship it"})
+	require.NoError(t, err)
+
+	completed := model.StatusCompleted
+	_, err = svc.Update(ctx, task.ID, &model.UpdateTaskRequest{Status: &completed}, repository.AnyVersion)
+	var transitionErr *TransitionError
+	require.ErrorAs(t, err, &transitionErr)
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	assert.Equal(t, []model.Status{model.StatusInProgress, model.StatusCancelled}, transitionErr.Allowed)
+
+	task, err = svc.Patch(ctx, task.ID, []byte(`{"status": "in_progress"}`), task.Version)
+	require.NoError(t, err)
+	task, err = svc.Patch(ctx, task.ID, []byte(`{"status": "completed"}`), task.Version)
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusCompleted, task.Status)
+
+	// A custom machine can make completed tasks final
+	custom := NewTaskService(repository.NewMemoryTaskRepository(0), nil, nil, events, nil, nil, nil, &config.TaskConfig{
+		DefaultProject:    "TASK",
+		StatusTransitions: map[string][]string{"pending": {"completed"}, "completed": {}},
+	})
+	task, err = custom.Create(ctx, &model.CreateTaskRequest{Title: "Quick"})
+	require.NoError(t, err)
+	task, err = custom.Update(ctx, task.ID, &model.UpdateTaskRequest{Status: &completed}, repository.AnyVersion)
+	require.NoError(t, err)
+	_, err = custom.Patch(ctx, task.ID, []byte(`{"status": "pending"}`), repository.AnyVersion)
+	assert.EqualError(t, err, "a completed task cannot change status")
+}
+
+func TestTaskService_BoardDelta(t *testing.T) {
+	ctx := context.Background()
+	events := NewEventService(repository.NewMemoryEventRepository(), kvstore.NewMemory(), &config.EventsConfig{})
+	svc := NewTaskService(repository.NewMemoryTaskRepository(0), nil, nil, events, nil, nil, nil, &config.TaskConfig{DefaultProject: "TASK", BoardColumnLimit: 10})
+
+	task, err := svc.Create(ctx, &model.CreateTaskRequest{Title: "Plan"})
+	require.NoError(t, err)
+	other, err := svc.Create(ctx, &model.CreateTaskRequest{Title: "Build"})
+	require.NoError(t, err)
+
+	board, err := svc.Board(ctx, &model.BoardOptions{})
+	require.NoError(t, err)
+	assert.False(t, board.Delta)
+	require.Len(t, board.Columns, len(model.Statuses()))
+	assert.Equal(t, 2, board.Columns[0].Total)
+	assert.Len(t, board.Columns[0].Tasks, 2)
+	assert.ElementsMatch(t, []string{task.ID, other.ID}, board.Columns[0].IDs)
+
+	unchanged, err := svc.Board(ctx, &model.BoardOptions{Since: board.Token})
+	require.NoError(t, err)
+	assert.True(t, unchanged.Delta)
+	assert.Empty(t, unchanged.Columns)
+	assert.Equal(t, board.Token, unchanged.Token)
+
+	// Moving a task changes exactly the two columns involved
+	inProgress := model.StatusInProgress
+	_, err = svc.Update(ctx, task.ID, &model.UpdateTaskRequest{Status: &inProgress}, repository.AnyVersion)
+	require.NoError(t, err)
+
+	delta, err := svc.Board(ctx, &model.BoardOptions{Since: board.Token})
+	require.NoError(t, err)
+	require.Len(t, delta.Columns, 2)
+	assert.Equal(t, model.StatusPending, delta.Columns[0].Status)
+	assert.Equal(t, model.StatusInProgress, delta.Columns[1].Status)
+	assert.Equal(t, []model.Status{model.StatusCompleted, model.StatusCancelled}, delta.Unchanged)
+	assert.NotEqual(t, board.Token, delta.Token)
+
+	// The task that stayed is listed but not sent again, the moved one is
+	pending, moved := delta.Columns[0], delta.Columns[1]
+	assert.Equal(t, 1, pending.Total)
+	assert.Empty(t, pending.Tasks)
+	assert.Equal(t, []string{other.ID}, pending.IDs)
+	require.Len(t, moved.Tasks, 1)
+	assert.Equal(t, task.ID, moved.Tasks[0].ID)
+	assert.Equal(t, []string{task.ID}, moved.IDs)
+
+	// Writing one task of a column sends only that task
+	title := "Build it"
+	_, err = svc.Update(ctx, other.ID, &model.UpdateTaskRequest{Title: &title}, repository.AnyVersion)
+	require.NoError(t, err)
+
+	next, err := svc.Board(ctx, &model.BoardOptions{Since: delta.Token})
+	require.NoError(t, err)
+	require.Len(t, next.Columns, 1)
+	require.Len(t, next.Columns[0].Tasks, 1)
+	assert.Equal(t, "Build it", next.Columns[0].Tasks[0].Title)
+	assert.Equal(t, []model.Status{model.StatusInProgress, model.StatusCompleted, model.StatusCancelled}, next.Unchanged)
+
+	_, err = svc.Board(ctx, &model.BoardOptions{Since: "not-a-token"})
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+func TestTaskService_Search(t *testing.T) {
+	ctx := context.Background()
+	events := NewEventService(repository.NewMemoryEventRepository(), kvstore.NewMemory(), &config.EventsConfig{})
+	guard := NewQueryGuard(&config.QueryGuardConfig{DefaultPerPage: 10, MaxPerPage: 10})
+	degradation := NewDegradation(&config.DegradationConfig{})
+	svc := NewTaskService(repository.NewMemoryTaskRepository(0), guard, degradation, events, nil, nil, nil, &config.TaskConfig{DefaultProject: "TASK"})
+
+	for _, req := range []*model.CreateTaskRequest{
+		{Title: "Rotate certificates", Description: "Before the ingress expires"},
+		{Title: "Ingress timeouts", Description: "Raise them for uploads"},
+		{Title: "Upgrade ingress controller"},
+	} {
+		_, err := svc.Create(ctx, req)
+		require.NoError(t, err)
+	}
+	search := func(query string, page int) (*model.TaskListResponse, error) {
+		return svc.Search(ctx, &model.ListOptions{Params: listing.Params{Page: page}, Search: query})
+	}
+	titles := func(list *model.TaskListResponse) []string {
+		var titles []string
+		for _, task := range list.Data {
+			titles = append(titles, task.Title)
+		}
+		return titles
+	}
+
+	// Title matches first, newest first among equals
+	list, err := search("ingress", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Upgrade ingress controller", "Ingress timeouts", "Rotate certificates"}, titles(list))
+	assert.Equal(t, 3, list.Pagination.Total)
+
+	list, err = search(`  ingress -"upgrade ingress" or certificates  `, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Ingress timeouts", "Rotate certificates"}, titles(list))
+
+	// Queries with no words match nothing rather than failing
+	list, err = search(`!!! "" -`, 1)
+	require.NoError(t, err)
+	assert.NotNil(t, list.Data)
+	assert.Empty(t, list.Data)
+	assert.Equal(t, 0, list.Pagination.Total)
+
+	_, err = search("   ", 1)
+	assert.ErrorIs(t, err, ErrValidation)
+	_, err = svc.Search(ctx, &model.ListOptions{Params: listing.Params{Page: 1, PerPage: 50}, Search: "ingress"})
+	assert.ErrorIs(t, err, ErrQueryTooExpensive)
+
+	on := true
+	degradation.Update(DegradationUpdate{DisableSearch: &on})
+	_, err = search("ingress", 1)
+	assert.ErrorIs(t, err, ErrDegraded)
+}
+
+func TestTaskService_Archive(t *testing.T) {
+	ctx := context.Background()
+	events := NewEventService(repository.NewMemoryEventRepository(), kvstore.NewMemory(), &config.EventsConfig{})
+	guard := NewQueryGuard(&config.QueryGuardConfig{DefaultPerPage: 10, MaxPerPage: 10})
+	svc := NewTaskService(repository.NewMemoryTaskRepository(0), guard, nil, events, nil, nil, nil, &config.TaskConfig{DefaultProject: "TASK", BulkMaxIDs: 5})
+
+	task, err := svc.Create(ctx, &model.CreateTaskRequest{Title: "Done and dusted"})
+	require.NoError(t, err)
+
+	task, err = svc.Archive(ctx, task.ID)
+	require.NoError(t, err)
+	assert.True(t, task.Archived)
+	_, err = svc.Archive(ctx, task.ID)
+	assert.ErrorIs(t, err, ErrArchived)
+
+	// Hidden from the default list, still readable by id
+	list, err := svc.GetAll(ctx, &model.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, list.Data)
+	list, err = svc.GetAll(ctx, &model.ListOptions{IncludeArchived: true})
+	require.NoError(t, err)
+	assert.Len(t, list.Data, 1)
+	_, err = svc.GetByID(ctx, task.ID)
+	require.NoError(t, err)
+
+	// Read-only until unarchived
+	title := "Changed"
+	_, err = svc.Update(ctx, task.ID, &model.UpdateTaskRequest{Title: &title}, repository.AnyVersion)
+	assert.ErrorIs(t, err, ErrArchived)
+	assert.ErrorIs(t, svc.Delete(ctx, task.ID, repository.AnyVersion), ErrArchived)
+	bulk, err := svc.BulkDelete(ctx, &model.BulkDeleteRequest{IDs: []string{task.ID}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{task.ID}, bulk.Archived)
+
+	task, err = svc.Unarchive(ctx, task.ID)
+	require.NoError(t, err)
+	assert.False(t, task.Archived)
+	_, err = svc.Update(ctx, task.ID, &model.UpdateTaskRequest{Title: &title}, repository.AnyVersion)
+	require.NoError(t, err)
+}
+
+func TestTaskService_Duplicate(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryTaskRepository(0)
+	events := NewEventService(repository.NewMemoryEventRepository(), kvstore.NewMemory(), &config.EventsConfig{})
+	svc := NewTaskService(repo, nil, nil, events, nil, nil, nil, &config.TaskConfig{DefaultProject: "TASK"})
+
+	due := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	source, err := svc.Create(ctx, &model.CreateTaskRequest{Title: "Release", Description: "Tag and ship", Priority: model.PriorityHigh, DueDate: &due, Recurrence: "@weekly"})
+	require.NoError(t, err)
+	_, err = repo.CreateTag(ctx, &model.Tag{ID: uuid.NewString(), Name: "ops"})
+	require.NoError(t, err)
+	_, err = repo.AttachTags(ctx, source.ID, []string{"ops"})
+	require.NoError(t, err)
+
+	copied, err := svc.Duplicate(ctx, source.ID, &model.DuplicateOptions{Tags: true, DueDate: true})
+	require.NoError(t, err)
+	assert.NotEqual(t, source.ID, copied.ID)
+	assert.NotEqual(t, source.Ref, copied.Ref)
+	assert.Equal(t, "Release", copied.Title)
+	assert.Equal(t, "Tag and ship", copied.Description)
+	assert.Equal(t, model.PriorityHigh, copied.Priority)
+	assert.Equal(t, model.StatusPending, copied.Status)
+	require.NotNil(t, copied.DueDate)
+	assert.True(t, due.Equal(*copied.DueDate))
+	assert.Equal(t, []string{"ops"}, copied.Tags)
+	assert.Nil(t, copied.Recurrence, "a copy only recurs when asked to")
+
+	bare, err := svc.Duplicate(ctx, source.ID, &model.DuplicateOptions{Recurrence: true})
+	require.NoError(t, err)
+	assert.Nil(t, bare.DueDate)
+	assert.Empty(t, bare.Tags)
+	require.NotNil(t, bare.Recurrence)
+	assert.Equal(t, "@weekly", *bare.Recurrence)
+
+	_, err = svc.Duplicate(ctx, uuid.NewString(), &model.DuplicateOptions{})
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+}
+
+func TestTaskService_Assign(t *testing.T) {
+	ctx := context.Background()
+	events := NewEventService(repository.NewMemoryEventRepository(), kvstore.NewMemory(), &config.EventsConfig{})
+	guard := NewQueryGuard(&config.QueryGuardConfig{DefaultPerPage: 10, MaxPerPage: 10})
+	users := NewUserService(repository.NewMemoryUserRepository())
+	svc := NewTaskService(repository.NewMemoryTaskRepository(0), guard, nil, events, nil, nil, users, &config.TaskConfig{DefaultProject: "TASK"})
+
+	task, err := svc.Create(ctx, &model.CreateTaskRequest{Title: "Review the budget"})
+	require.NoError(t, err)
+	_, err = svc.Create(ctx, &model.CreateTaskRequest{Title: "Unowned"})
+	require.NoError(t, err)
+
+	// Only users who have signed in can be assigned
+	_, err = svc.Assign(ctx, task.ID, &model.AssignTaskRequest{Assignee: "alice"})
+	assert.ErrorIs(t, err, ErrUnknownUser)
+	_, err = svc.Assign(ctx, task.ID, &model.AssignTaskRequest{Assignee: model.AssigneeMe})
+	assert.ErrorIs(t, err, ErrAnonymous)
+
+	users.Seen(ctx, "alice")
+	alice := auth.WithPrincipal(ctx, &auth.Principal{User: "alice"})
+	task, err = svc.Assign(alice, task.ID, &model.AssignTaskRequest{Assignee: model.AssigneeMe})
+	require.NoError(t, err)
+	require.NotNil(t, task.Assignee)
+	assert.Equal(t, "alice", *task.Assignee)
+
+	mine, err := svc.GetAll(alice, &model.ListOptions{Assignee: model.AssigneeMe})
+	require.NoError(t, err)
+	require.Len(t, mine.Data, 1)
+	assert.Equal(t, task.ID, mine.Data[0].ID)
+	_, err = svc.GetAll(ctx, &model.ListOptions{Assignee: model.AssigneeMe})
+	assert.ErrorIs(t, err, ErrAnonymous)
+
+	task, err = svc.Unassign(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Nil(t, task.Assignee)
+	mine, err = svc.GetAll(ctx, &model.ListOptions{Assignee: "alice"})
+	require.NoError(t, err)
+	assert.Empty(t, mine.Data)
+
+	// Archived tasks are read-only
+	_, err = svc.Archive(ctx, task.ID)
+	require.NoError(t, err)
+	_, err = svc.Assign(ctx, task.ID, &model.AssignTaskRequest{Assignee: "alice"})
+	assert.ErrorIs(t, err, ErrArchived)
+}
+
+func TestTaskService_Ownership(t *testing.T) {
+	ctx := context.Background()
+	events := NewEventService(repository.NewMemoryEventRepository(), kvstore.NewMemory(), &config.EventsConfig{})
+	guard := NewQueryGuard(&config.QueryGuardConfig{DefaultPerPage: 10, MaxPerPage: 10})
+	svc := NewTaskService(repository.NewMemoryTaskRepository(0), guard, nil, events, nil, nil, nil, &config.TaskConfig{DefaultProject: "TASK"})
+
+	alice := svc.Scope(auth.WithPrincipal(ctx, &auth.Principal{User: "alice"}))
+	bob := svc.Scope(auth.WithPrincipal(ctx, &auth.Principal{User: "bob"}))
+	admin := svc.Scope(auth.WithPrincipal(ctx, &auth.Principal{User: "admin", Scopes: auth.Scopes()}))
+
+	task, err := svc.Create(alice, &model.CreateTaskRequest{Title: "Review the budget"})
+	require.NoError(t, err)
+	require.NotNil(t, task.Owner)
+	assert.Equal(t, "alice", *task.Owner)
+	_, err = svc.Create(bob, &model.CreateTaskRequest{Title: "Book the venue"})
+	require.NoError(t, err)
+
+	// Another user's task is denied, and still reads as not found
+	_, err = svc.GetByID(bob, task.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+	title := "Taken over"
+	_, err = svc.Update(bob, task.ID, &model.UpdateTaskRequest{Title: &title}, repository.AnyVersion)
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.ErrorIs(t, svc.Delete(bob, task.ID, repository.AnyVersion), ErrForbidden)
+	_, err = svc.Duplicate(bob, task.ID, &model.DuplicateOptions{})
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	list, err := svc.GetAll(bob, &model.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, "Book the venue", list.Data[0].Title)
+
+	// Admins see and change every task
+	list, err = svc.GetAll(admin, &model.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, list.Data, 2)
+	_, err = svc.Update(admin, task.ID, &model.UpdateTaskRequest{Title: &title}, repository.AnyVersion)
+	require.NoError(t, err)
+
+	copied, err := svc.Duplicate(alice, task.ID, &model.DuplicateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", *copied.Owner)
+}
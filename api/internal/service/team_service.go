@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+)
+
+var (
+	ErrTeamNotFound = errors.New("team not found")
+	ErrTeamExists   = errors.New("team already exists")
+	ErrNotMember    = errors.New("user is not a member of the team")
+	ErrLastMember   = errors.New("a team keeps at least one member, delete it instead")
+)
+
+// TeamService manages teams, which share tasks between their members. Any
+// signed-in user may create a team and becomes its first member; members
+// manage the team from then on. Admins reach every team. Teams of others
+// are denied like other per-user resources.
+type TeamService struct {
+	repo     repository.TeamStore
+	tasks    *TaskService
+	users    *UserService
+	validate *validator.Validate
+}
+
+// NewTeamService creates a new TeamService. users may be nil, in which
+// case new members are not checked.
+func NewTeamService(repo repository.TeamStore, tasks *TaskService, users *UserService) *TeamService {
+	return &TeamService{repo: repo, tasks: tasks, users: users, validate: validator.New()}
+}
+
+// Create creates a team with the caller as its first member
+func (s *TeamService) Create(ctx context.Context, principal *auth.Principal, req *model.CreateTeamRequest) (*model.TeamResponse, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, formatValidationErrors(err))
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate team id: %w", err)
+	}
+
+	created, err := s.repo.CreateTeam(ctx, &model.Team{ID: id.String(), Name: req.Name, CreatedBy: principal.User})
+	if err != nil {
+		if errors.Is(err, repository.ErrTeamExists) {
+			return nil, ErrTeamExists
+		}
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, ErrUnknownUser
+		}
+		return nil, fmt.Errorf("failed to create team: %w", err)
+	}
+
+	return created.ToResponse(), nil
+}
+
+// List returns the caller's teams, or every team for admins, ordered by
+// name and without their members
+func (s *TeamService) List(ctx context.Context, principal *auth.Principal) ([]*model.TeamResponse, error) {
+	user := principal.User
+	if principal.Has(auth.ScopeAdmin) {
+		user = ""
+	}
+
+	teams, err := s.repo.ListTeams(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list teams: %w", err)
+	}
+
+	responses := make([]*model.TeamResponse, 0, len(teams))
+	for _, team := range teams {
+		responses = append(responses, team.ToResponse())
+	}
+	return responses, nil
+}
+
+// Get returns a team with its members
+func (s *TeamService) Get(ctx context.Context, principal *auth.Principal, id string) (*model.TeamResponse, error) {
+	team, err := s.team(ctx, principal, id)
+	if err != nil {
+		return nil, err
+	}
+	return team.ToResponse(), nil
+}
+
+// Delete deletes a team. Its tasks stay with their owners.
+func (s *TeamService) Delete(ctx context.Context, principal *auth.Principal, id string) error {
+	if _, err := s.team(ctx, principal, id); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteTeam(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrTeamNotFound) {
+			return ErrTeamNotFound
+		}
+		return fmt.Errorf("failed to delete team: %w", err)
+	}
+	return nil
+}
+
+// AddMember adds a user who has authenticated before to a team
+func (s *TeamService) AddMember(ctx context.Context, principal *auth.Principal, id, user string) (*model.TeamResponse, error) {
+	if _, err := s.team(ctx, principal, id); err != nil {
+		return nil, err
+	}
+
+	user = strings.TrimSpace(user)
+	if s.users != nil {
+		exists, err := s.users.Exists(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrUnknownUser
+		}
+	}
+
+	if err := s.repo.AddMember(ctx, id, user); err != nil {
+		if errors.Is(err, repository.ErrTeamNotFound) {
+			return nil, ErrTeamNotFound
+		}
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, ErrUnknownUser
+		}
+		return nil, fmt.Errorf("failed to add team member: %w", err)
+	}
+
+	return s.Get(ctx, principal, id)
+}
+
+// RemoveMember removes a user from a team; members may leave on their own.
+// The last member cannot be removed.
+func (s *TeamService) RemoveMember(ctx context.Context, principal *auth.Principal, id, user string) (*model.TeamResponse, error) {
+	team, err := s.team(ctx, principal, id)
+	if err != nil {
+		return nil, err
+	}
+
+	user = strings.TrimSpace(user)
+	if !slices.Contains(team.Members, user) {
+		return nil, ErrNotMember
+	}
+	if len(team.Members) == 1 {
+		return nil, ErrLastMember
+	}
+
+	if err := s.repo.RemoveMember(ctx, id, user); err != nil {
+		if errors.Is(err, repository.ErrTeamNotFound) {
+			return nil, ErrTeamNotFound
+		}
+		if errors.Is(err, repository.ErrNotMember) {
+			return nil, ErrNotMember
+		}
+		return nil, fmt.Errorf("failed to remove team member: %w", err)
+	}
+
+	if user == principal.User && !principal.Has(auth.ScopeAdmin) {
+		// Having left, the caller no longer sees the team
+		team.Members = slices.DeleteFunc(team.Members, func(member string) bool { return member == user })
+		return team.ToResponse(), nil
+	}
+	return s.Get(ctx, principal, id)
+}
+
+// Share shares a task the caller reaches with one of their teams, or stops
+// sharing it when teamID is empty. ctx must carry the caller's task scope.
+func (s *TeamService) Share(ctx context.Context, principal *auth.Principal, taskID, teamID string) (*model.TaskResponse, error) {
+	if teamID == "" {
+		return s.tasks.SetTeam(ctx, taskID, nil)
+	}
+
+	if _, err := s.team(ctx, principal, teamID); err != nil {
+		return nil, err
+	}
+	return s.tasks.SetTeam(ctx, taskID, &teamID)
+}
+
+// team returns team id with its members, denying callers who are neither
+// members nor admins
+func (s *TeamService) team(ctx context.Context, principal *auth.Principal, id string) (*model.Team, error) {
+	if !isValidID(id) {
+		return nil, ErrTeamNotFound
+	}
+
+	team, err := s.repo.GetTeam(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrTeamNotFound) {
+			return nil, ErrTeamNotFound
+		}
+		return nil, fmt.Errorf("failed to get team: %w", err)
+	}
+
+	if !principal.Has(auth.ScopeAdmin) && !slices.Contains(team.Members, principal.User) {
+		return nil, denied(ErrTeamNotFound)
+	}
+	return team, nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamService(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryTaskRepository(0)
+	events := NewEventService(repository.NewMemoryEventRepository(), kvstore.NewMemory(), &config.EventsConfig{})
+	guard := NewQueryGuard(&config.QueryGuardConfig{DefaultPerPage: 10, MaxPerPage: 10})
+	tasks := NewTaskService(repo, guard, nil, events, nil, nil, nil, &config.TaskConfig{DefaultProject: "TASK"})
+	svc := NewTeamService(repo, tasks, nil)
+
+	principals := map[string]*auth.Principal{
+		"alice": {User: "alice"},
+		"bob":   {User: "bob"},
+		"carol": {User: "carol"},
+		"admin": {User: "admin", Scopes: auth.Scopes()},
+	}
+	as := func(user string) context.Context {
+		return tasks.Scope(auth.WithPrincipal(ctx, principals[user]))
+	}
+
+	team, err := svc.Create(ctx, principals["alice"], &model.CreateTeamRequest{Name: " Platform "})
+	require.NoError(t, err)
+	assert.Equal(t, "Platform", team.Name)
+	assert.Equal(t, []string{"alice"}, team.Members)
+	_, err = svc.Create(ctx, principals["bob"], &model.CreateTeamRequest{Name: "Platform"})
+	assert.ErrorIs(t, err, ErrTeamExists)
+
+	// Only members manage the team
+	_, err = svc.AddMember(ctx, principals["bob"], team.ID, "bob")
+	assert.ErrorIs(t, err, ErrForbidden)
+	team, err = svc.AddMember(ctx, principals["alice"], team.ID, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, team.Members)
+	listed, err := svc.List(ctx, principals["bob"])
+	require.NoError(t, err)
+	assert.Len(t, listed, 1)
+	listed, err = svc.List(ctx, principals["carol"])
+	require.NoError(t, err)
+	assert.Empty(t, listed)
+
+	// A shared task is seen and edited by every member, and no one else
+	task, err := tasks.Create(as("alice"), &model.CreateTaskRequest{Title: "Rotate certificates"})
+	require.NoError(t, err)
+	_, err = tasks.GetByID(as("bob"), task.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = svc.Share(as("carol"), principals["carol"], task.ID, team.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+	shared, err := svc.Share(as("alice"), principals["alice"], task.ID, team.ID)
+	require.NoError(t, err)
+	assert.Equal(t, team.ID, *shared.Team)
+
+	title := "Rotate certificates before Friday"
+	updated, err := tasks.Update(as("bob"), task.ID, &model.UpdateTaskRequest{Title: &title}, repository.AnyVersion)
+	require.NoError(t, err)
+	assert.Equal(t, title, updated.Title)
+	list, err := tasks.GetAll(as("bob"), &model.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, list.Data, 1)
+	_, err = tasks.GetByID(as("carol"), task.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	// Leaving the team, or the team going away, ends access
+	_, err = svc.RemoveMember(ctx, principals["bob"], team.ID, "bob")
+	require.NoError(t, err)
+	_, err = tasks.GetByID(as("bob"), task.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = svc.RemoveMember(ctx, principals["alice"], team.ID, "alice")
+	assert.ErrorIs(t, err, ErrLastMember)
+
+	_, err = svc.Get(ctx, principals["admin"], team.ID)
+	require.NoError(t, err)
+	require.NoError(t, svc.Delete(ctx, principals["admin"], team.ID))
+	got, err := tasks.GetByID(as("alice"), task.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got.Team)
+	_, err = svc.Get(ctx, principals["alice"], team.ID)
+	assert.ErrorIs(t, err, ErrTeamNotFound)
+}
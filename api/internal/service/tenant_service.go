@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+var (
+	ErrNotTenantMember = errors.New("not a member of the tenant")
+	ErrMemberNotFound  = errors.New("tenant member not found")
+)
+
+// TenantService decides who may sign in to a tenant. Every user belongs to
+// TENANT_DEFAULT; other tenants only admit the members an admin added.
+// Removing a member signs them out of the tenant: their sessions and
+// refresh tokens there are revoked and their API tokens there deleted.
+type TenantService struct {
+	members  repository.MemberStore
+	tokens   repository.TokenStore
+	refresh  repository.RefreshTokenStore
+	sessions repository.SessionStore
+	cfg      *config.TenancyConfig
+}
+
+// NewTenantService creates a new TenantService
+func NewTenantService(members repository.MemberStore, tokens repository.TokenStore, refresh repository.RefreshTokenStore, sessions repository.SessionStore, cfg *config.TenancyConfig) *TenantService {
+	return &TenantService{
+		members:  members,
+		tokens:   tokens,
+		refresh:  refresh,
+		sessions: sessions,
+		cfg:      cfg,
+	}
+}
+
+// IsMember reports whether user may act in tenant id. Without tenancy, and
+// in TENANT_DEFAULT, everyone may.
+func (s *TenantService) IsMember(ctx context.Context, id, user string) (bool, error) {
+	if !s.cfg.Enabled || id == "" || id == s.cfg.Default {
+		return true, nil
+	}
+	member, err := s.members.IsMember(ctx, id, user)
+	if err != nil {
+		return false, fmt.Errorf("failed to check tenant member: %w", err)
+	}
+	return member, nil
+}
+
+// Check returns ErrNotTenantMember unless user may act in the tenant of ctx
+func (s *TenantService) Check(ctx context.Context, user string) error {
+	member, err := s.IsMember(ctx, tenant.From(ctx), user)
+	if err != nil {
+		return err
+	}
+	if !member {
+		return ErrNotTenantMember
+	}
+	return nil
+}
+
+// List returns the members of tenant id
+func (s *TenantService) List(ctx context.Context, id string) (*model.TenantMemberListResponse, error) {
+	if !tenant.Valid(id) {
+		return nil, fmt.Errorf("%w: invalid tenant", ErrValidation)
+	}
+	members, err := s.members.List(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenant members: %w", err)
+	}
+	return &model.TenantMemberListResponse{Data: members}, nil
+}
+
+// Add makes user a member of tenant id
+func (s *TenantService) Add(ctx context.Context, id, user string) (*model.TenantMember, error) {
+	user = normalizeUsername(user)
+	if !tenant.Valid(id) {
+		return nil, fmt.Errorf("%w: invalid tenant", ErrValidation)
+	}
+	member, err := s.members.Add(ctx, id, user)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, ErrUnknownUser
+		}
+		return nil, fmt.Errorf("failed to add tenant member: %w", err)
+	}
+	return member, nil
+}
+
+// Remove takes user out of tenant id and revokes every credential they
+// hold there
+func (s *TenantService) Remove(ctx context.Context, id, user string) error {
+	user = normalizeUsername(user)
+	if !tenant.Valid(id) {
+		return fmt.Errorf("%w: invalid tenant", ErrValidation)
+	}
+	if err := s.members.Remove(ctx, id, user); err != nil {
+		if errors.Is(err, repository.ErrMemberNotFound) {
+			return ErrMemberNotFound
+		}
+		return fmt.Errorf("failed to remove tenant member: %w", err)
+	}
+
+	// Row level security keeps the revocations to the tenant's rows
+	ctx = tenant.With(ctx, id)
+	owner := repository.OwnedBy(user)
+	now := time.Now().UTC()
+	sessions, err := s.sessions.RevokeUser(ctx, owner, "", now)
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	if _, err := s.refresh.RevokeUser(ctx, owner, now); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	tokens, err := s.tokens.DeleteUser(ctx, owner)
+	if err != nil {
+		return fmt.Errorf("failed to delete API tokens: %w", err)
+	}
+
+	logger.Ctx(ctx).Info().
+		Str("tenant", id).
+		Str("user", user).
+		Int("sessions_revoked", len(sessions)).
+		Int("tokens_deleted", tokens).
+		Msg("Removed tenant member")
+	return nil
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+)
+
+var (
+	ErrTokenNotFound = errors.New("token not found")
+	ErrScopeDenied   = errors.New("scope not granted")
+)
+
+// TokenPrefix starts every API token so leaked tokens are easy to spot
+const TokenPrefix = "mtp_"
+
+// tokenTouchInterval limits how often last_used_at is written for a token
+const tokenTouchInterval = time.Minute
+
+// TokenService manages personal access tokens and verifies them for the
+// auth middleware
+type TokenService struct {
+	repo     repository.TokenStore
+	cfg      *config.AuthConfig
+	validate *validator.Validate
+}
+
+// NewTokenService creates a new TokenService
+func NewTokenService(repo repository.TokenStore, cfg *config.AuthConfig) *TokenService {
+	return &TokenService{
+		repo:     repo,
+		cfg:      cfg,
+		validate: validator.New(),
+	}
+}
+
+// Create issues a token for the principal. A token never gets scopes its
+// creator does not have, so tokens cannot be used to escalate.
+func (s *TokenService) Create(ctx context.Context, principal *auth.Principal, req *model.CreateTokenRequest) (*model.CreatedTokenResponse, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, formatValidationErrors(err))
+	}
+
+	var scopes []string
+	for _, name := range req.Scopes {
+		scope, ok := auth.ParseScope(name)
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown scope %q", ErrValidation, name)
+		}
+		if !principal.Has(scope) {
+			return nil, fmt.Errorf("%w: %s", ErrScopeDenied, scope)
+		}
+		if !slices.Contains(scopes, string(scope)) {
+			scopes = append(scopes, string(scope))
+		}
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(s.cfg.TokenDefaultTTL)
+	if req.ExpiresAt != nil {
+		expiresAt = req.ExpiresAt.UTC()
+	}
+	if !expiresAt.After(now) {
+		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrValidation)
+	}
+	if expiresAt.After(now.Add(s.cfg.TokenMaxTTL)) {
+		return nil, fmt.Errorf("%w: expires_at must be within %s", ErrValidation, s.cfg.TokenMaxTTL)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	raw := TokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	created, err := s.repo.Create(ctx, &model.APIToken{
+		ID:        uuid.NewString(),
+		User:      principal.User,
+		Name:      req.Name,
+		Prefix:    raw[:len(TokenPrefix)+8],
+		Hash:      hashToken(raw),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+		Tenant:    tenant.From(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token: %w", err)
+	}
+
+	return &model.CreatedTokenResponse{TokenResponse: created.ToResponse(), Token: raw}, nil
+}
+
+// List returns the user's tokens, newest first
+func (s *TokenService) List(ctx context.Context, user string) (*model.TokenListResponse, error) {
+	tokens, err := s.repo.ListByUser(ctx, repository.OwnedBy(user))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tokens: %w", err)
+	}
+
+	responses := make([]*model.TokenResponse, 0, len(tokens))
+	for _, token := range tokens {
+		responses = append(responses, token.ToResponse())
+	}
+
+	return &model.TokenListResponse{Data: responses}, nil
+}
+
+// Get returns one of the user's tokens. Another user's token is
+// ErrForbidden, which also matches ErrTokenNotFound.
+func (s *TokenService) Get(ctx context.Context, user, id string) (*model.TokenResponse, error) {
+	if !isValidID(id) {
+		return nil, ErrTokenNotFound
+	}
+
+	token, err := s.repo.Get(ctx, repository.OwnedBy(user), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrTokenNotFound) {
+			return nil, ErrTokenNotFound
+		}
+		if errors.Is(err, repository.ErrNotOwned) {
+			return nil, denied(ErrTokenNotFound)
+		}
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+
+	return token.ToResponse(), nil
+}
+
+// Delete revokes one of the user's tokens
+func (s *TokenService) Delete(ctx context.Context, user, id string) error {
+	if !isValidID(id) {
+		return ErrTokenNotFound
+	}
+
+	if err := s.repo.Delete(ctx, repository.OwnedBy(user), id); err != nil {
+		if errors.Is(err, repository.ErrTokenNotFound) {
+			return ErrTokenNotFound
+		}
+		if errors.Is(err, repository.ErrNotOwned) {
+			return denied(ErrTokenNotFound)
+		}
+		return fmt.Errorf("failed to delete token: %w", err)
+	}
+
+	return nil
+}
+
+// Verify returns the principal a raw API token authenticates, or
+// auth.ErrInvalidToken when it is unknown, revoked or expired
+func (s *TokenService) Verify(ctx context.Context, raw string) (*auth.Principal, error) {
+	if !strings.HasPrefix(raw, TokenPrefix) {
+		return nil, auth.ErrInvalidToken
+	}
+
+	token, err := s.repo.GetByHash(ctx, hashToken(raw))
+	if err != nil {
+		if errors.Is(err, repository.ErrTokenNotFound) {
+			return nil, auth.ErrInvalidToken
+		}
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+
+	now := time.Now().UTC()
+	if !token.ExpiresAt.After(now) {
+		return nil, auth.ErrInvalidToken
+	}
+
+	// Best effort, a missed update only makes last_used_at less precise
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= tokenTouchInterval {
+		_ = s.repo.Touch(ctx, token.ID, now)
+	}
+
+	// Tokens stored under legacy scope names keep working; unknown names
+	// grant nothing
+	scopes := make([]auth.Scope, 0, len(token.Scopes))
+	for _, name := range token.Scopes {
+		if scope, ok := auth.ParseScope(name); ok {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return &auth.Principal{User: token.User, TokenID: token.ID, Scopes: scopes, Tenant: token.Tenant}, nil
+}
+
+// hashToken returns the hex SHA-256 of a raw token. Tokens carry 256 bits
+// of randomness, so a fast unsalted hash is enough to make a leaked table
+// useless.
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenService(t *testing.T) {
+	ctx := context.Background()
+	svc := NewTokenService(repository.NewMemoryTokenRepository(), &config.AuthConfig{
+		TokenDefaultTTL: time.Hour,
+		TokenMaxTTL:     24 * time.Hour,
+	})
+	user := &auth.Principal{User: "alice", Scopes: []auth.Scope{auth.ScopeReadTasks, auth.ScopeWriteTasks}}
+
+	// Legacy scope names are stored under their current names
+	created, err := svc.Create(ctx, user, &model.CreateTokenRequest{Name: " ci ", Scopes: []string{"tasks:read", "read:tasks"}})
+	require.NoError(t, err)
+	assert.Equal(t, "ci", created.Name)
+	assert.Equal(t, []string{"tasks:read"}, created.Scopes)
+	assert.Contains(t, created.Token, created.Prefix)
+
+	principal, err := svc.Verify(ctx, created.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", principal.User)
+	assert.True(t, principal.Has(auth.ScopeReadTasks))
+	assert.False(t, principal.Has(auth.ScopeWriteTasks))
+
+	// Tokens cannot grant more than their creator has
+	_, err = svc.Create(ctx, user, &model.CreateTokenRequest{Name: "root", Scopes: []string{"admin"}})
+	assert.ErrorIs(t, err, ErrScopeDenied)
+	_, err = svc.Create(ctx, user, &model.CreateTokenRequest{Name: "typo", Scopes: []string{"task:write"}})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	tooLong := time.Now().Add(48 * time.Hour)
+	_, err = svc.Create(ctx, user, &model.CreateTokenRequest{Name: "forever", Scopes: []string{"tasks:read"}, ExpiresAt: &tooLong})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	// Listing never exposes secrets, and only the owner can revoke
+	list, err := svc.List(ctx, "alice")
+	require.NoError(t, err)
+	require.Len(t, list.Data, 1)
+	err = svc.Delete(ctx, "bob", created.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.ErrorIs(t, err, ErrTokenNotFound)
+
+	require.NoError(t, svc.Delete(ctx, "alice", created.ID))
+	_, err = svc.Verify(ctx, created.Token)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+	_, err = svc.Verify(ctx, "mtp_unknown")
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+}
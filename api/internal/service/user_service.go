@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+// userTouchInterval limits how often one replica writes last_seen_at for a user
+const userTouchInterval = time.Minute
+
+// UserService keeps the directory of users who have authenticated, which
+// task assignment is checked against
+type UserService struct {
+	repo repository.UserStore
+
+	mu      sync.Mutex
+	touched map[string]time.Time
+}
+
+// NewUserService creates a new UserService
+func NewUserService(repo repository.UserStore) *UserService {
+	return &UserService{repo: repo, touched: make(map[string]time.Time)}
+}
+
+// Seen records that user authenticated. Best effort: a failed write is
+// logged and retried on the user's next request.
+func (s *UserService) Seen(ctx context.Context, user string) {
+	now := time.Now().UTC()
+
+	s.mu.Lock()
+	if last, ok := s.touched[user]; ok && now.Sub(last) < userTouchInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.touched[user] = now
+	s.mu.Unlock()
+
+	if err := s.repo.Touch(ctx, user, now); err != nil {
+		s.mu.Lock()
+		delete(s.touched, user)
+		s.mu.Unlock()
+		logger.Ctx(ctx).WithComponent("users").Error().Err(err).Str("user", user).Msg("Failed to record user")
+	}
+}
+
+// Exists reports whether user has ever authenticated
+func (s *UserService) Exists(ctx context.Context, user string) (bool, error) {
+	exists, err := s.repo.Exists(ctx, user)
+	if err != nil {
+		return false, fmt.Errorf("failed to check user: %w", err)
+	}
+	return exists, nil
+}
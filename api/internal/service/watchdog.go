@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"runtime"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+// Watchdog looks for leaks at runtime. It counts goroutines and open
+// database connections every WATCHDOG_INTERVAL and warns when either grew
+// at each of the last WATCHDOG_SAMPLES counts: a stream or background
+// worker that leaks shows up as steady growth long before it exhausts
+// memory or the connection pool. Normal load goes up and down, which
+// resets the count.
+type Watchdog struct {
+	dbStats     func() sql.DBStats
+	cfg         *config.WatchdogConfig
+	goroutines  growth
+	connections growth
+}
+
+// NewWatchdog creates a Watchdog. dbStats is nil without a database, in
+// which case only goroutines are counted.
+func NewWatchdog(dbStats func() sql.DBStats, cfg *config.WatchdogConfig) *Watchdog {
+	return &Watchdog{dbStats: dbStats, cfg: cfg}
+}
+
+// Run counts every interval until ctx is cancelled
+func (w *Watchdog) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.check()
+		}
+	}
+}
+
+// check takes one count of each resource and warns about steady growth
+func (w *Watchdog) check() {
+	log := logger.Get().WithComponent("watchdog")
+
+	if from, to, ok := w.goroutines.observe(runtime.NumGoroutine(), w.cfg.Samples); ok {
+		log.Warn().
+			Int("from", from).
+			Int("to", to).
+			Int("samples", w.cfg.Samples).
+			Dur("interval", w.cfg.Interval).
+			Msg("Goroutine count keeps growing, possible goroutine leak")
+	}
+
+	if w.dbStats == nil {
+		return
+	}
+	stats := w.dbStats()
+	if from, to, ok := w.connections.observe(stats.OpenConnections, w.cfg.Samples); ok {
+		log.Warn().
+			Int("from", from).
+			Int("to", to).
+			Int("in_use", stats.InUse).
+			Int("samples", w.cfg.Samples).
+			Dur("interval", w.cfg.Interval).
+			Msg("Open database connections keep growing, possible connection leak")
+	}
+}
+
+// growth follows a count for an unbroken run of increases
+type growth struct {
+	seen   bool
+	start  int // the count the current run of increases started from
+	last   int
+	streak int
+}
+
+// observe records count and reports the run it ended, once count has
+// grown samples times in a row. The run then starts over from count, so
+// growth that keeps going is reported again every samples counts.
+func (g *growth) observe(count, samples int) (from, to int, grew bool) {
+	if g.seen && count > g.last {
+		g.streak++
+	} else {
+		g.start, g.streak = count, 0
+	}
+	g.seen, g.last = true, count
+
+	if samples <= 0 || g.streak < samples {
+		return 0, 0, false
+	}
+	from = g.start
+	g.start, g.streak = count, 0
+	return from, count, true
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrowth(t *testing.T) {
+	var g growth
+	observe := func(counts ...int) (from, to int, grew bool) {
+		for _, count := range counts {
+			if from, to, grew = g.observe(count, 3); grew {
+				return
+			}
+		}
+		return
+	}
+
+	// Load going up and down is not a leak
+	_, _, grew := observe(10, 12, 11, 15, 14, 20, 18)
+	assert.False(t, grew)
+
+	// Three increases in a row are
+	from, to, grew := observe(19, 21, 30)
+	assert.True(t, grew)
+	assert.Equal(t, 18, from)
+	assert.Equal(t, 30, to)
+
+	// Reported again only after three more
+	_, _, grew = observe(31, 32)
+	assert.False(t, grew)
+	from, to, grew = observe(33)
+	assert.True(t, grew)
+	assert.Equal(t, 30, from)
+	assert.Equal(t, 33, to)
+}
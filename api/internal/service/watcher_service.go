@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/repository"
+	"github.com/moabdelazem/mutlitier_app/pkg/listing"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+// WatcherService handles watching tasks and the caller's notifications.
+// Notifications are enqueued by NotificationFanout.
+type WatcherService struct {
+	watchers      repository.WatcherStore
+	notifications repository.NotificationStore
+	tasks         repository.TaskStore
+	guard         *QueryGuard
+	cfg           *config.NotificationConfig
+	validate      *validator.Validate
+}
+
+// NewWatcherService creates a new WatcherService
+func NewWatcherService(watchers repository.WatcherStore, notifications repository.NotificationStore, tasks repository.TaskStore, guard *QueryGuard, cfg *config.NotificationConfig) *WatcherService {
+	return &WatcherService{
+		watchers:      watchers,
+		notifications: notifications,
+		tasks:         tasks,
+		guard:         guard,
+		cfg:           cfg,
+		validate:      validator.New(),
+	}
+}
+
+// Watch subscribes the caller to changes of a live task
+func (s *WatcherService) Watch(ctx context.Context, taskID string) (*model.WatchResponse, error) {
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkTask(ctx, taskID); err != nil {
+		return nil, err
+	}
+
+	if err := s.watchers.Watch(ctx, taskID, user); err != nil {
+		if errors.Is(err, repository.ErrTaskNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, fmt.Errorf("failed to watch task: %w", err)
+	}
+
+	return s.watchStatus(ctx, taskID, user)
+}
+
+// Unwatch unsubscribes the caller from a live task
+func (s *WatcherService) Unwatch(ctx context.Context, taskID string) (*model.WatchResponse, error) {
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkTask(ctx, taskID); err != nil {
+		return nil, err
+	}
+
+	if err := s.watchers.Unwatch(ctx, taskID, user); err != nil {
+		return nil, fmt.Errorf("failed to unwatch task: %w", err)
+	}
+
+	return s.watchStatus(ctx, taskID, user)
+}
+
+// List returns the watchers of a live task
+func (s *WatcherService) List(ctx context.Context, taskID string) (*model.WatcherListResponse, error) {
+	if err := s.checkTask(ctx, taskID); err != nil {
+		return nil, err
+	}
+
+	watchers, err := s.watchers.ListByTask(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list watchers: %w", err)
+	}
+	if watchers == nil {
+		watchers = []*model.Watcher{}
+	}
+
+	return &model.WatcherListResponse{Data: watchers}, nil
+}
+
+// Expand sets the watchers of every task, loading them in one query
+func (s *WatcherService) Expand(ctx context.Context, tasks ...*model.TaskResponse) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	watchers, err := s.watchers.WatchersOf(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to list watchers: %w", err)
+	}
+
+	for _, task := range tasks {
+		task.Watchers = watchers[task.ID]
+	}
+
+	return nil
+}
+
+// Notifications returns a page of the caller's notifications, newest first
+func (s *WatcherService) Notifications(ctx context.Context, unreadOnly bool, opts *model.ListOptions) (*model.NotificationListResponse, error) {
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.guard.CheckPage(opts); err != nil {
+		return nil, err
+	}
+
+	notifications, err := s.notifications.ListByUser(ctx, repository.OwnedBy(user), unreadOnly, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+
+	total, err := s.notifications.CountByUser(ctx, repository.OwnedBy(user), unreadOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count notifications: %w", err)
+	}
+
+	unread := total
+	if !unreadOnly {
+		if unread, err = s.notifications.CountByUser(ctx, repository.OwnedBy(user), true); err != nil {
+			return nil, fmt.Errorf("failed to count notifications: %w", err)
+		}
+	}
+
+	if notifications == nil {
+		notifications = []*model.Notification{}
+	}
+
+	return &model.NotificationListResponse{
+		Data:       notifications,
+		Unread:     unread,
+		Pagination: listing.NewPagination(opts.Page, opts.PerPage, total),
+	}, nil
+}
+
+// MarkRead marks the caller's notifications as read and returns how many
+// were unread
+func (s *WatcherService) MarkRead(ctx context.Context, req *model.MarkNotificationsReadRequest) (int64, error) {
+	user, err := caller(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.validate.Struct(req); err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrValidation, formatValidationErrors(err))
+	}
+
+	marked, err := s.notifications.MarkRead(ctx, repository.OwnedBy(user), req.IDs, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+
+	return marked, nil
+}
+
+// PurgeEvery removes notifications older than the retention period on
+// every interval until ctx is done
+func (s *WatcherService) PurgeEvery(ctx context.Context, interval time.Duration) {
+	log := logger.Get().WithComponent("notifications")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.notifications.Purge(ctx, time.Now().Add(-s.cfg.Retention))
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to purge notifications")
+				continue
+			}
+			if purged > 0 {
+				log.Debug().Int64("purged", purged).Msg("Purged expired notifications")
+			}
+		}
+	}
+}
+
+func (s *WatcherService) watchStatus(ctx context.Context, taskID, user string) (*model.WatchResponse, error) {
+	watchers, err := s.watchers.ListByTask(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list watchers: %w", err)
+	}
+
+	status := &model.WatchResponse{TaskID: taskID, Watchers: len(watchers)}
+	for _, watcher := range watchers {
+		if watcher.User == user {
+			status.Watching = true
+		}
+	}
+	return status, nil
+}
+
+// checkTask returns ErrTaskNotFound unless taskID is a live task
+func (s *WatcherService) checkTask(ctx context.Context, taskID string) error {
+	if !isValidID(taskID) {
+		return ErrTaskNotFound
+	}
+
+	if _, err := s.tasks.GetByID(ctx, taskID); err != nil {
+		if errors.Is(err, repository.ErrNotOwned) {
+			return denied(ErrTaskNotFound)
+		}
+		if errors.Is(err, repository.ErrTaskNotFound) {
+			return ErrTaskNotFound
+		}
+		return fmt.Errorf("failed to get task: %w", err)
+	}
+
+	return nil
+}
+
+// caller returns the authenticated user a request acts for, ErrAnonymous
+// when there is none
+func caller(ctx context.Context) (string, error) {
+	principal := auth.FromContext(ctx)
+	if principal == nil || principal.User == "" {
+		return "", ErrAnonymous
+	}
+	return principal.User, nil
+}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// FileBackend stores files in a local directory, for development and
+// single-replica deployments with a persistent volume
+type FileBackend struct {
+	dir string
+}
+
+// NewFileBackend creates a FileBackend rooted at dir
+func NewFileBackend(dir string) *FileBackend {
+	return &FileBackend{dir: dir}
+}
+
+// Put implements Backend, writing through a temporary file so readers
+// never see a partial upload
+func (b *FileBackend) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	target, err := b.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("failed to create attachments directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", target, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return fmt.Errorf("failed to move %s into place: %w", target, err)
+	}
+	return nil
+}
+
+// Open implements Backend
+func (b *FileBackend) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	target, err := b.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(target)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to open %s: %w", target, err)
+	}
+	return file, nil
+}
+
+// Delete implements Backend
+func (b *FileBackend) Delete(ctx context.Context, key string) error {
+	target, err := b.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete %s: %w", target, err)
+	}
+	return nil
+}
+
+// List implements Backend
+func (b *FileBackend) List(ctx context.Context, prefix string) ([]string, error) {
+	prefix, err := validPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	root := filepath.Join(b.dir, filepath.FromSlash(prefix))
+	var keys []string
+	err = filepath.WalkDir(root, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		// Skip temporary files of uploads in progress
+		if entry.IsDir() || strings.HasSuffix(name, ".tmp") {
+			return nil
+		}
+		rel, err := filepath.Rel(b.dir, name)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", root, err)
+	}
+
+	slices.Sort(keys)
+	return keys, nil
+}
+
+func (b *FileBackend) path(key string) (string, error) {
+	if err := validKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(b.dir, filepath.FromSlash(key)), nil
+}
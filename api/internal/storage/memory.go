@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// MemoryBackend keeps files in memory, used by demo mode
+type MemoryBackend struct {
+	mu    sync.RWMutex
+	files map[string][]byte
+}
+
+// NewMemoryBackend creates a new MemoryBackend
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{files: make(map[string][]byte)}
+}
+
+// Reset removes all files
+func (b *MemoryBackend) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.files = make(map[string][]byte)
+}
+
+// Put implements Backend
+func (b *MemoryBackend) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", key, err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.files[key] = data
+	return nil
+}
+
+// Open implements Backend
+func (b *MemoryBackend) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := validKey(key); err != nil {
+		return nil, err
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	data, ok := b.files[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Delete implements Backend
+func (b *MemoryBackend) Delete(ctx context.Context, key string) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.files, key)
+	return nil
+}
+
+// List implements Backend
+func (b *MemoryBackend) List(ctx context.Context, prefix string) ([]string, error) {
+	prefix, err := validPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var keys []string
+	for key := range b.files {
+		if strings.HasPrefix(key, prefix+"/") {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
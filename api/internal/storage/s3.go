@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// S3Backend stores files in an S3-compatible bucket
+type S3Backend struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+// Put implements Backend
+func (b *S3Backend) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+
+	_, err := b.client.PutObject(ctx, b.bucket, b.object(key), r, size, minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		return fmt.Errorf("failed to upload s3://%s/%s: %w", b.bucket, b.object(key), err)
+	}
+	return nil
+}
+
+// Open implements Backend. GetObject does not fail for missing objects
+// until the first read, so the object is checked up front.
+func (b *S3Backend) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := validKey(key); err != nil {
+		return nil, err
+	}
+
+	object, err := b.client.GetObject(ctx, b.bucket, b.object(key), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get s3://%s/%s: %w", b.bucket, b.object(key), err)
+	}
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get s3://%s/%s: %w", b.bucket, b.object(key), err)
+	}
+	return object, nil
+}
+
+// Delete implements Backend
+func (b *S3Backend) Delete(ctx context.Context, key string) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+
+	if err := b.client.RemoveObject(ctx, b.bucket, b.object(key), minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to delete s3://%s/%s: %w", b.bucket, b.object(key), err)
+	}
+	return nil
+}
+
+// List implements Backend
+func (b *S3Backend) List(ctx context.Context, prefix string) ([]string, error) {
+	prefix, err := validPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	objectPrefix := b.object(prefix) + "/"
+	var keys []string
+	for object := range b.client.ListObjects(ctx, b.bucket, minio.ListObjectsOptions{Prefix: objectPrefix, Recursive: true}) {
+		if object.Err != nil {
+			return nil, fmt.Errorf("failed to list s3://%s/%s: %w", b.bucket, objectPrefix, object.Err)
+		}
+		keys = append(keys, prefix+"/"+strings.TrimPrefix(object.Key, objectPrefix))
+	}
+
+	slices.Sort(keys)
+	return keys, nil
+}
+
+func (b *S3Backend) object(key string) string {
+	return path.Join(b.prefix, key)
+}
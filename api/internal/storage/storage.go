@@ -0,0 +1,106 @@
+// Package storage keeps files such as task attachments and project
+// snapshots in a local directory, an S3-compatible bucket or, for demo
+// mode, in memory. A backend only maps keys to bytes; what the files mean
+// is up to its users.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+var (
+	ErrNotFound   = errors.New("stored file not found")
+	ErrInvalidKey = errors.New("invalid storage key")
+)
+
+// Backend stores files by key. Keys are slash-separated relative paths.
+type Backend interface {
+	// Put stores size bytes read from r under key, replacing any existing file
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+
+	// Open returns the contents of key, or ErrNotFound
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes key; deleting a missing key is not an error
+	Delete(ctx context.Context, key string) error
+
+	// List returns the keys under the directory prefix, sorted
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// S3Options configures the client for s3:// URLs
+type S3Options struct {
+	Endpoint  string
+	Region    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+}
+
+var (
+	_ Backend = (*FileBackend)(nil)
+	_ Backend = (*S3Backend)(nil)
+	_ Backend = (*MemoryBackend)(nil)
+)
+
+// New creates the Backend for rawURL: s3://bucket/prefix for
+// S3-compatible object storage, or file:///path for a local directory
+func New(rawURL string, opts S3Options) (Backend, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage URL: %w", err)
+	}
+
+	switch u.Scheme {
+	case "s3":
+		// Without static keys fall back to the instance or pod IAM role
+		creds := credentials.NewIAM("")
+		if opts.AccessKey != "" {
+			creds = credentials.NewStaticV4(opts.AccessKey, opts.SecretKey, "")
+		}
+		client, err := minio.New(opts.Endpoint, &minio.Options{
+			Creds:  creds,
+			Secure: opts.UseSSL,
+			Region: opts.Region,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create s3 client: %w", err)
+		}
+		return &S3Backend{client: client, bucket: u.Host, prefix: strings.Trim(u.Path, "/")}, nil
+	case "file":
+		return &FileBackend{dir: u.Path}, nil
+	default:
+		return nil, fmt.Errorf("unsupported storage URL scheme %q, use s3:// or file://", u.Scheme)
+	}
+}
+
+// validPrefix checks a List prefix, which is a key with an optional
+// trailing slash, and returns it without the slash
+func validPrefix(prefix string) (string, error) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if err := validKey(prefix); err != nil {
+		return "", err
+	}
+	return prefix, nil
+}
+
+// validKey rejects keys that could escape the backend's directory or prefix
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileBackend(t *testing.T) {
+	ctx := context.Background()
+	backend, err := New("file://"+t.TempDir(), S3Options{})
+	require.NoError(t, err)
+
+	require.NoError(t, backend.Put(ctx, "tasks/1/a", strings.NewReader("first"), 5, "text/plain"))
+	require.NoError(t, backend.Put(ctx, "tasks/1/a", strings.NewReader("second"), 6, "text/plain"))
+	file, err := backend.Open(ctx, "tasks/1/a")
+	require.NoError(t, err)
+	data, err := io.ReadAll(file)
+	require.NoError(t, err)
+	file.Close()
+	assert.Equal(t, "second", string(data))
+
+	require.NoError(t, backend.Put(ctx, "tasks/1/b", strings.NewReader("other"), 5, "text/plain"))
+	require.NoError(t, backend.Put(ctx, "tasks/10/a", strings.NewReader("other"), 5, "text/plain"))
+	keys, err := backend.List(ctx, "tasks/1/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tasks/1/a", "tasks/1/b"}, keys)
+	keys, err = backend.List(ctx, "missing")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	require.NoError(t, backend.Delete(ctx, "tasks/1/a"))
+	require.NoError(t, backend.Delete(ctx, "tasks/1/a"), "deleting twice is fine")
+	_, err = backend.Open(ctx, "tasks/1/a")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	for _, key := range []string{"", "/etc/passwd", "../escape", "tasks/../../escape", "tasks//a", `tasks\a`} {
+		assert.ErrorIs(t, backend.Put(ctx, key, strings.NewReader("x"), 1, "text/plain"), ErrInvalidKey, key)
+	}
+}
+
+func TestNew(t *testing.T) {
+	backend, err := New("s3://bucket/prefix", S3Options{Endpoint: "localhost:9000"})
+	require.NoError(t, err)
+	assert.Equal(t, "prefix/tasks/1/a", backend.(*S3Backend).object("tasks/1/a"))
+
+	_, err = New("ftp://host/path", S3Options{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,49 @@
+// Package tenant carries the tenant a request acts in down to the database
+// connection, where row level security keeps tenants' rows apart
+package tenant
+
+import (
+	"context"
+	"regexp"
+)
+
+// Default is the tenant of rows written before tenancy was enabled, and of
+// requests naming none when TENANT_DEFAULT is left at its default
+const Default = "default"
+
+// validID is a DNS label, so every tenant can also be named by a subdomain
+var validID = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+type contextKey struct{}
+
+// With returns a context whose database statements only reach id's rows
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// From returns the tenant of ctx, empty when it is unscoped, as for
+// background jobs and deployments without tenancy
+func From(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Valid reports whether id can name a tenant
+func Valid(id string) bool {
+	return validID.MatchString(id)
+}
+
+type claimKey struct{}
+
+// Claim returns ctx marked as acting in a tenant the caller only named,
+// without credentials showing they belong to it, as anonymous sign-in
+// requests do
+func Claim(ctx context.Context) context.Context {
+	return context.WithValue(ctx, claimKey{}, true)
+}
+
+// Claimed reports whether the tenant of ctx was only named by the caller
+func Claimed(ctx context.Context) bool {
+	claimed, _ := ctx.Value(claimKey{}).(bool)
+	return claimed
+}
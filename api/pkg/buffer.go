@@ -0,0 +1,51 @@
+package pkg
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/moabdelazem/mutlitier_app/pkg/codec"
+)
+
+// maxPooledBuffer is the largest buffer put back in bufferPool. The odd
+// huge response is left to the garbage collector rather than pinning its
+// memory in the pool for good.
+const maxPooledBuffer = 64 << 10
+
+// responseBuffer is an encoding buffer with an encoder bound to it, so a
+// pooled buffer also saves setting up the encoder
+type responseBuffer struct {
+	bytes.Buffer
+	encoder codec.Encoder
+	engine  string
+}
+
+var bufferPool = sync.Pool{
+	New: func() any { return new(responseBuffer) },
+}
+
+// getBuffer takes an empty buffer from the pool, rebinding its encoder
+// if the JSON engine changed since it was last used
+func getBuffer() *responseBuffer {
+	buf := bufferPool.Get().(*responseBuffer)
+	if name, engine := codec.Active(); buf.encoder == nil || buf.engine != name {
+		buf.encoder = engine.NewEncoder(&buf.Buffer)
+		buf.engine = name
+	}
+	return buf
+}
+
+// putBuffer returns buf to the pool. Nothing may hold on to its bytes
+// afterwards.
+func putBuffer(buf *responseBuffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// encode appends v to buf as JSON followed by a newline
+func (buf *responseBuffer) encode(v any) error {
+	return buf.encoder.Encode(v)
+}
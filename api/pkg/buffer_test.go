@@ -0,0 +1,141 @@
+package pkg
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/pkg/codec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Run with -race: every response must be exactly its own value, however
+// the pooled buffers are shared between goroutines
+func TestWriteJSONConcurrentResponsesDoNotMix(t *testing.T) {
+	t.Cleanup(func() { SetMaxListBytes(0) })
+	SetMaxListBytes(1 << 20)
+
+	var wg sync.WaitGroup
+	for g := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				// Sizes vary so buffers are grown, reused and dropped
+				name := fmt.Sprintf("%d-%d-%s", g, i, strings.Repeat("x", (g*i)%5000))
+				item := &testItem{Name: name}
+
+				rec := httptest.NewRecorder()
+				switch i % 3 {
+				case 0:
+					JSONSuccess(rec, item)
+				case 1:
+					JSONFields(rec, item, []string{"name"})
+				default:
+					JSONList(rec, testList{Data: []*testItem{item}, Total: 1})
+				}
+
+				want, _ := codec.Marshal(item)
+				if !assert.Contains(t, rec.Body.String(), string(want)) {
+					return
+				}
+				assert.Equal(t, strconv.Itoa(rec.Body.Len()), rec.Header().Get("Content-Length"))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestWriteJSONEncodeFailureIsInternalError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	JSONSuccess(rec, map[string]any{"bad": make(chan int)})
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.JSONEq(t, `{"error":"Failed to encode response"}`, rec.Body.String())
+
+	// The buffer that failed is clean for the next response
+	rec = httptest.NewRecorder()
+	JSONSuccess(rec, testItem{Name: "a"})
+	assert.Equal(t, "{\"name\":\"a\"}\n", rec.Body.String())
+}
+
+func TestGetBufferFollowsEngine(t *testing.T) {
+	t.Cleanup(func() { require.NoError(t, codec.Use("")) })
+
+	for _, engine := range codec.Engines() {
+		require.NoError(t, codec.Use(engine))
+		buf := getBuffer()
+		assert.Equal(t, engine, buf.engine)
+		putBuffer(buf)
+	}
+}
+
+func TestPutBufferDropsLargeBuffers(t *testing.T) {
+	buf := getBuffer()
+	buf.WriteString(strings.Repeat("x", 2*maxPooledBuffer))
+	putBuffer(buf)
+
+	// Pooled buffers are reset on the way in, so this one was dropped
+	assert.Equal(t, 2*maxPooledBuffer, buf.Len())
+}
+
+// discardWriter is a ResponseWriter that allocates nothing per response,
+// so the benchmarks only count the encoding
+type discardWriter struct{ header http.Header }
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardWriter) WriteHeader(int)             {}
+
+// BenchmarkWriteJSON encodes a single task-sized value the way CRUD
+// handlers answer, pooled against a fresh encoder per response:
+// go test -run x -bench WriteJSON -benchmem ./pkg
+func BenchmarkWriteJSON(b *testing.B) {
+	type task struct {
+		ID          string    `json:"id"`
+		Title       string    `json:"title"`
+		Description string    `json:"description"`
+		Status      string    `json:"status"`
+		Tags        []string  `json:"tags"`
+		Version     int       `json:"version"`
+		CreatedAt   time.Time `json:"created_at"`
+	}
+	value := Response{Message: "Task retrieved", Data: &task{
+		ID:          "6f1c2a4e-0b7d-4c1e-9a55-000000000001",
+		Title:       "Prepare the quarterly report",
+		Description: strings.Repeat("Collect the numbers and write it up. ", 8),
+		Status:      "in_progress",
+		Tags:        []string{"reports", "finance"},
+		Version:     3,
+		CreatedAt:   time.Now(),
+	}}
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			w := &discardWriter{header: make(http.Header)}
+			for pb.Next() {
+				JSONSuccess(w, value)
+			}
+		})
+	})
+
+	b.Run("unpooled", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			w := &discardWriter{header: make(http.Header)}
+			for pb.Next() {
+				// WriteJSON before responses were pooled
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+				codec.NewEncoder(w).Encode(value)
+			}
+		})
+	})
+}
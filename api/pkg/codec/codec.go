@@ -0,0 +1,176 @@
+// Package codec reads request bodies and writes responses as JSON or
+// YAML, chosen by the Content-Type and Accept headers. It serves the
+// declarative endpoints, whose source of truth is usually a YAML file in
+// Git. Both formats map onto the same json struct tags: YAML is converted
+// to JSON on the way in and back on the way out, so models need no yaml
+// tags and custom JSON unmarshalers still apply.
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Media types the codec speaks
+const (
+	JSON = "application/json"
+	YAML = "application/yaml"
+)
+
+var (
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	ErrInvalidYAML          = errors.New("invalid YAML")
+)
+
+// yamlTypes are the media types accepted as YAML, application/yaml first
+var yamlTypes = []string{YAML, "application/x-yaml", "text/yaml", "text/x-yaml"}
+
+// Decode reads the request body into v as JSON or, when the Content-Type
+// is a YAML type, as YAML. Unknown fields are an error in both formats,
+// since a misspelt key in a declaration file should not be ignored.
+func Decode(r *http.Request, v any) error {
+	mediaType := JSON
+	if header := r.Header.Get("Content-Type"); header != "" {
+		parsed, _, err := mime.ParseMediaType(header)
+		if err != nil {
+			return ErrUnsupportedMediaType
+		}
+		mediaType = parsed
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read body: %w", err)
+	}
+
+	switch {
+	case mediaType == JSON:
+	case isYAML(mediaType):
+		if body, err = yamlToJSON(body); err != nil {
+			return err
+		}
+	default:
+		return ErrUnsupportedMediaType
+	}
+
+	decoder := NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// Write writes v with status in the format the Accept header prefers,
+// JSON unless YAML is preferred
+func Write(w http.ResponseWriter, r *http.Request, status int, v any) {
+	w.Header().Add("Vary", "Accept")
+	if Negotiate(r.Header.Get("Accept")) != YAML {
+		w.Header().Set("Content-Type", JSON)
+		w.WriteHeader(status)
+		NewEncoder(w).Encode(v)
+		return
+	}
+
+	data, err := jsonToYAML(v)
+	if err != nil {
+		w.Header().Set("Content-Type", JSON)
+		w.WriteHeader(http.StatusInternalServerError)
+		NewEncoder(w).Encode(map[string]string{"error": "Failed to encode response"})
+		return
+	}
+	w.Header().Set("Content-Type", YAML)
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// Negotiate returns the media type, JSON or YAML, that an Accept header
+// prefers. Ties, wildcards and headers naming neither go to JSON.
+func Negotiate(accept string) string {
+	best, bestQ := JSON, -1.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		switch {
+		case isYAML(mediaType) && q > bestQ && q > 0:
+			best, bestQ = YAML, q
+		case (mediaType == JSON || mediaType == "*/*" || mediaType == "application/*") && q >= bestQ && q > 0:
+			best, bestQ = JSON, q
+		}
+	}
+	return best
+}
+
+// isYAML reports whether mediaType is one of the YAML media types
+func isYAML(mediaType string) bool {
+	for _, yamlType := range yamlTypes {
+		if mediaType == yamlType {
+			return true
+		}
+	}
+	return false
+}
+
+// yamlToJSON converts one YAML document to JSON
+func yamlToJSON(data []byte) ([]byte, error) {
+	var value any
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidYAML, strings.TrimPrefix(err.Error(), "yaml: "))
+	}
+	// Mappings with non-string keys have no JSON form
+	converted, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidYAML, err)
+	}
+	return converted, nil
+}
+
+// jsonToYAML encodes v as JSON and re-encodes that as block style YAML,
+// keeping the order of the JSON keys
+func jsonToYAML(v any) ([]byte, error) {
+	data, err := Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is YAML, so it parses into a node tree that keeps key order
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	blockStyle(&node)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// blockStyle drops the flow style and quoting that came with the JSON;
+// the encoder still quotes strings that would otherwise read as another type
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
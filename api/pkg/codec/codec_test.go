@@ -0,0 +1,69 @@
+package codec
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type declaration struct {
+	Name  string   `json:"name"`
+	Count int      `json:"count,omitempty"`
+	Tags  []string `json:"tags"`
+}
+
+func TestDecode(t *testing.T) {
+	request := func(contentType, body string) *http.Request {
+		r := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(body))
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		return r
+	}
+
+	var v declaration
+	require.NoError(t, Decode(request("application/yaml; charset=utf-8", "name: api\ncount: 2\ntags:\n  - a\n  - b\n"), &v))
+	assert.Equal(t, declaration{Name: "api", Count: 2, Tags: []string{"a", "b"}}, v)
+
+	v = declaration{}
+	require.NoError(t, Decode(request("", `{"name":"api","tags":[]}`), &v))
+	assert.Equal(t, "api", v.Name)
+
+	err := Decode(request("text/yaml", "name: api\nnmae: typo\n"), &declaration{})
+	assert.ErrorContains(t, err, `unknown field "nmae"`)
+	assert.ErrorIs(t, Decode(request(YAML, "name: [unclosed"), &declaration{}), ErrInvalidYAML)
+	assert.ErrorIs(t, Decode(request("text/plain", "name: api"), &declaration{}), ErrUnsupportedMediaType)
+}
+
+func TestWrite(t *testing.T) {
+	write := func(accept string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		Write(w, r, http.StatusOK, declaration{Name: "true", Tags: []string{"a"}})
+		return w
+	}
+
+	w := write("application/yaml")
+	assert.Equal(t, YAML, w.Header().Get("Content-Type"))
+	assert.Equal(t, "Accept", w.Header().Get("Vary"))
+	assert.Equal(t, "name: \"true\"\ntags:\n  - a\n", w.Body.String(), "keys keep their order and strings stay strings")
+
+	w = write("*/*")
+	assert.Equal(t, JSON, w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"name":"true","tags":["a"]}`, w.Body.String())
+}
+
+func TestNegotiate(t *testing.T) {
+	assert.Equal(t, JSON, Negotiate(""))
+	assert.Equal(t, JSON, Negotiate("*/*"))
+	assert.Equal(t, JSON, Negotiate("text/html"))
+	assert.Equal(t, YAML, Negotiate("application/yaml"))
+	assert.Equal(t, YAML, Negotiate("application/json;q=0.5, application/x-yaml"))
+	assert.Equal(t, JSON, Negotiate("application/yaml;q=0.5, application/json"))
+	assert.Equal(t, JSON, Negotiate("application/yaml;q=0"))
+}
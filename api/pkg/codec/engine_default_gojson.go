@@ -0,0 +1,8 @@
+//go:build gojson
+
+package codec
+
+// Building with -tags gojson makes go-json the default engine
+func init() {
+	defaultEngine = EngineGoJSON
+}
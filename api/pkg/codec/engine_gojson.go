@@ -0,0 +1,22 @@
+package codec
+
+import (
+	"io"
+
+	gojson "github.com/goccy/go-json"
+)
+
+// EngineGoJSON is github.com/goccy/go-json, a drop-in replacement for
+// encoding/json that caches per-type encoders and avoids reflection on
+// the hot path
+const EngineGoJSON = "go-json"
+
+func init() {
+	register(EngineGoJSON, goJSONEngine{})
+}
+
+type goJSONEngine struct{}
+
+func (goJSONEngine) Marshal(v any) ([]byte, error)  { return gojson.Marshal(v) }
+func (goJSONEngine) NewEncoder(w io.Writer) Encoder { return gojson.NewEncoder(w) }
+func (goJSONEngine) NewDecoder(r io.Reader) Decoder { return gojson.NewDecoder(r) }
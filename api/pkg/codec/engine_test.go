@@ -0,0 +1,59 @@
+package codec
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type record struct {
+	ID        string            `json:"id"`
+	Title     string            `json:"title"`
+	Done      bool              `json:"done"`
+	Due       *time.Time        `json:"due,omitempty"`
+	Tags      []string          `json:"tags"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// useEngine switches the active engine for the rest of the test
+func useEngine(t testing.TB, name string) {
+	t.Helper()
+	previous := EngineName()
+	require.NoError(t, Use(name))
+	t.Cleanup(func() { Use(previous) })
+}
+
+func TestEnginesMatchStd(t *testing.T) {
+	due := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	values := []any{
+		record{ID: "1", Title: "<b>&co</b>", Due: &due, Tags: []string{"a"}, Labels: map[string]string{"z": "1", "a": "2"}, CreatedAt: due},
+		[]record{{ID: "2", Title: "ünïcode  "}},
+		map[string]any{"n": 1.5, "nil": nil},
+	}
+
+	for _, name := range Engines() {
+		t.Run(name, func(t *testing.T) {
+			useEngine(t, name)
+			for _, v := range values {
+				var want, got bytes.Buffer
+				require.NoError(t, stdEngine{}.NewEncoder(&want).Encode(v))
+				require.NoError(t, NewEncoder(&got).Encode(v))
+				assert.Equal(t, want.String(), got.String())
+			}
+
+			decoder := NewDecoder(strings.NewReader(`{"id":"1","nmae":"typo"}`))
+			decoder.DisallowUnknownFields()
+			assert.ErrorContains(t, decoder.Decode(&record{}), `unknown field "nmae"`)
+		})
+	}
+}
+
+func TestUseRejectsUnknownEngine(t *testing.T) {
+	assert.Error(t, Use("simdjson"))
+	assert.Equal(t, defaultEngine, EngineName())
+}
@@ -0,0 +1,43 @@
+package cursor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodec_Decode(t *testing.T) {
+	codec := NewCodec([]byte("secret"), time.Hour)
+	filter := FilterHash("created_at", "desc")
+	token := codec.Encode(150, filter)
+
+	offset, err := codec.Decode(token, filter)
+	assert.NoError(t, err)
+	assert.Equal(t, 150, offset)
+
+	_, err = codec.Decode(token, FilterHash("created_at", "asc"))
+	assert.ErrorIs(t, err, ErrFilterMismatch)
+
+	_, err = NewCodec([]byte("other"), time.Hour).Decode(token, filter)
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	body, sig, _ := strings.Cut(token, ".")
+	_, err = codec.Decode(body[:len(body)-2]+"fQ."+sig, filter)
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	_, err = NewCodec([]byte("secret"), time.Nanosecond).Decode(token, filter)
+	assert.ErrorIs(t, err, ErrExpired)
+}
+
+func TestULID(t *testing.T) {
+	now := time.UnixMilli(time.Now().UnixMilli())
+	id := newULID(now)
+	assert.Len(t, id, 26)
+
+	issued, err := ulidTime(id)
+	assert.NoError(t, err)
+	assert.True(t, now.Equal(issued))
+	assert.Less(t, newULID(now), newULID(now.Add(time.Millisecond)))
+}
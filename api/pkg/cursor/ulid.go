@@ -0,0 +1,64 @@
+package cursor
+
+import (
+	"crypto/rand"
+	"errors"
+	"time"
+)
+
+// crockford is the Crockford base32 alphabet used by ULIDs
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// newULID returns a ULID: a 48-bit millisecond timestamp followed by 80
+// random bits, as 26 Crockford base32 characters that sort by time
+func newULID(t time.Time) string {
+	var id [16]byte
+	ms := uint64(t.UnixMilli())
+	for i := 5; i >= 0; i-- {
+		id[i] = byte(ms)
+		ms >>= 8
+	}
+	_, _ = rand.Read(id[6:])
+
+	// 128 bits in 130 bits of base32: the first character holds the top 3 bits
+	out := make([]byte, 26)
+	var acc uint32
+	bits := 2
+	pos := 0
+	for _, b := range id {
+		acc = acc<<8 | uint32(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			out[pos] = crockford[(acc>>bits)&31]
+			pos++
+		}
+	}
+	return string(out)
+}
+
+// ulidTime returns the timestamp encoded in a ULID
+func ulidTime(id string) (time.Time, error) {
+	if len(id) != 26 {
+		return time.Time{}, errors.New("ulid must be 26 characters")
+	}
+	// The timestamp is the first 10 characters (50 bits, top 2 always zero)
+	var ms uint64
+	for i := 0; i < 10; i++ {
+		v := indexOf(id[i])
+		if v < 0 {
+			return time.Time{}, errors.New("ulid contains invalid characters")
+		}
+		ms = ms<<5 | uint64(v)
+	}
+	return time.UnixMilli(int64(ms)), nil
+}
+
+func indexOf(c byte) int {
+	for i := 0; i < len(crockford); i++ {
+		if crockford[i] == c {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,95 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// JSONFields writes v like JSONSuccess, keeping only the keys in fields
+// when v encodes as an object. Nil fields keeps every key.
+func JSONFields(w http.ResponseWriter, v any, fields []string) {
+	if fields == nil {
+		JSONSuccess(w, v)
+		return
+	}
+
+	encoded, selected := getBuffer(), getBuffer()
+	defer putBuffer(encoded)
+	defer putBuffer(selected)
+
+	err := encoded.encode(v)
+	if err == nil {
+		err = selectFields(&selected.Buffer, bytes.TrimSuffix(encoded.Bytes(), newline), fieldSet(fields))
+	}
+	if err != nil {
+		InternalError(w, "Failed to encode response")
+		return
+	}
+	selected.Write(newline)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(selected.Len()))
+	w.WriteHeader(http.StatusOK)
+	w.Write(selected.Bytes())
+}
+
+// fieldSet turns a field selection into a set, nil for no selection
+func fieldSet(fields []string) map[string]bool {
+	if fields == nil {
+		return nil
+	}
+	keep := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		keep[field] = true
+	}
+	return keep
+}
+
+// selectFields writes data to dst without the keys of the encoded JSON
+// object that are not in keep, leaving the others and their values byte
+// for byte. Anything but an object is written unchanged.
+func selectFields(dst *bytes.Buffer, data []byte, keep map[string]bool) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		dst.Write(data)
+		return nil
+	}
+
+	dst.WriteByte('{')
+	first := true
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := token.(string)
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return err
+		}
+		if !keep[key] {
+			continue
+		}
+
+		if !first {
+			dst.WriteByte(',')
+		}
+		first = false
+		encodedKey, err := json.Marshal(key)
+		if err != nil {
+			return err
+		}
+		dst.Write(encodedKey)
+		dst.WriteByte(':')
+		dst.Write(value)
+	}
+	dst.WriteByte('}')
+	return nil
+}
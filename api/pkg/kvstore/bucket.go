@@ -0,0 +1,48 @@
+package kvstore
+
+import "time"
+
+// Tokens is the state of a token bucket after Take
+type Tokens struct {
+	// Taken is false when the bucket was empty
+	Taken     bool
+	Remaining int
+	// Wait is how long until the next token when none was taken
+	Wait time.Duration
+	// Full is how long until the bucket is full again
+	Full time.Duration
+}
+
+// Buckets are kept as the time they will be full again (the theoretical
+// arrival time of GCRA), so a take is a single read and write of one value
+// and an idle bucket simply expires.
+
+// refillInterval returns how often a bucket of capacity refilled over
+// window gains a token
+func refillInterval(capacity int, window time.Duration) time.Duration {
+	return window / time.Duration(max(capacity, 1))
+}
+
+// take takes a token at now from a bucket full again at full, returning
+// when it is full again after the take
+func take(full, now time.Time, capacity int, window time.Duration) (time.Time, bool) {
+	if full.Before(now) {
+		full = now
+	}
+	next := full.Add(refillInterval(capacity, window))
+	if next.Sub(now) > window {
+		return full, false
+	}
+	return next, true
+}
+
+// tokens describes a bucket full again at full, as seen at now
+func tokens(taken bool, full, now time.Time, capacity int, window time.Duration) Tokens {
+	interval := refillInterval(capacity, window)
+	t := Tokens{Taken: taken, Full: max(full.Sub(now), 0)}
+	t.Remaining = int((window - t.Full) / interval)
+	if !taken {
+		t.Wait = max(t.Full+interval-window, 0)
+	}
+	return t
+}
@@ -0,0 +1,56 @@
+package kvstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Store is a key-value store with TTL semantics. Implementations must be
+// safe for concurrent use; shared backends (Redis, Postgres) let several
+// replicas see the same state while Memory suits single-replica deployments.
+type Store interface {
+	// Get returns the value stored under key, or false if it is missing or expired
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+
+	// Set stores value under key for ttl, replacing any existing value
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// SetNX stores value under key for ttl only if the key does not exist.
+	// It returns false if the key was already present.
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+
+	// Incr increments the counter under key and returns the new value.
+	// A new counter starts at 1 and expires after ttl.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+
+	// Delete removes key
+	Delete(ctx context.Context, key string) error
+
+	// Take takes a token from the token bucket under key, which holds
+	// capacity tokens and refills them all over window. A new bucket
+	// starts full.
+	Take(ctx context.Context, key string, capacity int, window time.Duration) (Tokens, error)
+}
+
+// Supported backends
+const (
+	BackendMemory   = "memory"
+	BackendRedis    = "redis"
+	BackendPostgres = "postgres"
+)
+
+// New creates the Store for the configured backend
+func New(backend, redisURL string, db *sql.DB) (Store, error) {
+	switch backend {
+	case BackendMemory, "":
+		return NewMemory(), nil
+	case BackendRedis:
+		return NewRedis(redisURL)
+	case BackendPostgres:
+		return NewPostgres(db), nil
+	default:
+		return nil, fmt.Errorf("unknown kv store backend %q", backend)
+	}
+}
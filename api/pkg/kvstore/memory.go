@@ -0,0 +1,147 @@
+package kvstore
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// sweepEvery controls how many writes happen between expired-entry sweeps
+const sweepEvery = 1024
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// Memory is an in-process Store for single-replica deployments
+type Memory struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	writes  int
+	now     func() time.Time
+}
+
+// NewMemory creates a new in-memory Store
+func NewMemory() *Memory {
+	return &Memory{
+		entries: make(map[string]memoryEntry),
+		now:     time.Now,
+	}
+}
+
+// Get implements Store
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.live(key)
+	if !ok {
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+// Set implements Store
+func (m *Memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.put(key, value, ttl)
+	return nil
+}
+
+// SetNX implements Store
+func (m *Memory) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.live(key); ok {
+		return false, nil
+	}
+	m.put(key, value, ttl)
+	return true, nil
+}
+
+// Incr implements Store
+func (m *Memory) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.live(key)
+	if !ok {
+		m.put(key, []byte("1"), ttl)
+		return 1, nil
+	}
+
+	count, err := strconv.ParseInt(string(entry.value), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	count++
+
+	// Keep the original expiry, like Redis INCR
+	entry.value = []byte(strconv.FormatInt(count, 10))
+	m.entries[key] = entry
+	return count, nil
+}
+
+// Take implements Store
+func (m *Memory) Take(ctx context.Context, key string, capacity int, window time.Duration) (Tokens, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	full := now
+	if entry, ok := m.live(key); ok {
+		micros, err := strconv.ParseInt(string(entry.value), 10, 64)
+		if err != nil {
+			return Tokens{}, err
+		}
+		full = time.UnixMicro(micros)
+	}
+
+	full, taken := take(full, now, capacity, window)
+	if taken {
+		m.put(key, []byte(strconv.FormatInt(full.UnixMicro(), 10)), full.Sub(now))
+	}
+	return tokens(taken, full, now, capacity, window), nil
+}
+
+// Delete implements Store
+func (m *Memory) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	return nil
+}
+
+// live returns the entry for key if it exists and has not expired
+func (m *Memory) live(key string) (memoryEntry, bool) {
+	entry, ok := m.entries[key]
+	if !ok {
+		return memoryEntry{}, false
+	}
+	if m.now().After(entry.expiresAt) {
+		delete(m.entries, key)
+		return memoryEntry{}, false
+	}
+	return entry, true
+}
+
+// put stores an entry and periodically sweeps expired ones
+func (m *Memory) put(key string, value []byte, ttl time.Duration) {
+	now := m.now()
+	m.entries[key] = memoryEntry{value: value, expiresAt: now.Add(ttl)}
+
+	m.writes++
+	if m.writes%sweepEvery == 0 {
+		for k, entry := range m.entries {
+			if now.After(entry.expiresAt) {
+				delete(m.entries, k)
+			}
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package kvstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := NewMemory()
+	store.now = func() time.Time { return now }
+
+	ok, err := store.SetNX(ctx, "a", []byte("1"), time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = store.SetNX(ctx, "a", []byte("2"), time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	value, found, err := store.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []byte("1"), value)
+
+	count, _ := store.Incr(ctx, "counter", time.Minute)
+	assert.Equal(t, int64(1), count)
+	count, _ = store.Incr(ctx, "counter", time.Minute)
+	assert.Equal(t, int64(2), count)
+
+	// Everything expires after the TTL
+	now = now.Add(2 * time.Minute)
+	_, found, _ = store.Get(ctx, "a")
+	assert.False(t, found)
+	count, _ = store.Incr(ctx, "counter", time.Minute)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestMemory_Take(t *testing.T) {
+	ctx := context.Background()
+	// Buckets are kept to the microsecond
+	now := time.Now().Truncate(time.Microsecond)
+	store := NewMemory()
+	store.now = func() time.Time { return now }
+
+	// A new bucket starts full and empties one token per take
+	for want := 2; want >= 0; want-- {
+		tokens, err := store.Take(ctx, "bucket", 3, 3*time.Second)
+		assert.NoError(t, err)
+		assert.True(t, tokens.Taken)
+		assert.Equal(t, want, tokens.Remaining)
+	}
+	tokens, _ := store.Take(ctx, "bucket", 3, 3*time.Second)
+	assert.False(t, tokens.Taken)
+	assert.Equal(t, time.Second, tokens.Wait)
+	assert.Equal(t, 3*time.Second, tokens.Full)
+
+	// Tokens come back one interval at a time, not all at a window boundary
+	now = now.Add(time.Second)
+	tokens, _ = store.Take(ctx, "bucket", 3, 3*time.Second)
+	assert.True(t, tokens.Taken)
+	assert.Equal(t, 0, tokens.Remaining)
+
+	// An idle bucket fills up and expires
+	now = now.Add(time.Hour)
+	tokens, _ = store.Take(ctx, "bucket", 3, 3*time.Second)
+	assert.Equal(t, 2, tokens.Remaining)
+}
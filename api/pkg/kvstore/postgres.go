@@ -0,0 +1,134 @@
+package kvstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// Postgres is a Store shared by all replicas through the kv_store table
+type Postgres struct {
+	db     *sql.DB
+	writes atomic.Int64
+}
+
+// NewPostgres creates a new Postgres-backed Store
+func NewPostgres(db *sql.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+// Get implements Store
+func (p *Postgres) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	var value []byte
+	err := p.db.QueryRowContext(ctx,
+		`SELECT value FROM kv_store WHERE key = $1 AND expires_at > NOW()`, key,
+	).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set implements Store
+func (p *Postgres) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	p.maybePurge(ctx)
+
+	_, err := p.db.ExecContext(ctx, `
+		INSERT INTO kv_store (key, value, expires_at)
+		VALUES ($1, $2, NOW() + $3 * INTERVAL '1 millisecond')
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, counter = 0, expires_at = EXCLUDED.expires_at
+	`, key, value, ttl.Milliseconds())
+	return err
+}
+
+// SetNX implements Store. Expired rows are overwritten as if absent.
+func (p *Postgres) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	p.maybePurge(ctx)
+
+	result, err := p.db.ExecContext(ctx, `
+		INSERT INTO kv_store (key, value, expires_at)
+		VALUES ($1, $2, NOW() + $3 * INTERVAL '1 millisecond')
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, counter = 0, expires_at = EXCLUDED.expires_at
+		WHERE kv_store.expires_at <= NOW()
+	`, key, value, ttl.Milliseconds())
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected == 1, nil
+}
+
+// Incr implements Store. An expired counter restarts at 1 with a fresh TTL.
+func (p *Postgres) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	p.maybePurge(ctx)
+
+	var count int64
+	err := p.db.QueryRowContext(ctx, `
+		INSERT INTO kv_store (key, counter, expires_at)
+		VALUES ($1, 1, NOW() + $2 * INTERVAL '1 millisecond')
+		ON CONFLICT (key) DO UPDATE SET
+			counter = CASE WHEN kv_store.expires_at <= NOW() THEN 1 ELSE kv_store.counter + 1 END,
+			expires_at = CASE WHEN kv_store.expires_at <= NOW() THEN EXCLUDED.expires_at ELSE kv_store.expires_at END
+		RETURNING counter
+	`, key, ttl.Milliseconds()).Scan(&count)
+	return count, err
+}
+
+// Take implements Store. The bucket is kept in counter as the time in
+// microseconds it is full again, see take; an empty bucket is left as is.
+func (p *Postgres) Take(ctx context.Context, key string, capacity int, window time.Duration) (Tokens, error) {
+	p.maybePurge(ctx)
+
+	now := time.Now()
+	var micros int64
+	err := p.db.QueryRowContext(ctx, `
+		INSERT INTO kv_store (key, counter, expires_at)
+		VALUES ($1, $2 + $3, to_timestamp(($2 + $3) / 1e6))
+		ON CONFLICT (key) DO UPDATE SET
+			counter = GREATEST(kv_store.counter, $2) + $3,
+			expires_at = to_timestamp((GREATEST(kv_store.counter, $2) + $3) / 1e6)
+		WHERE GREATEST(kv_store.counter, $2) + $3 - $2 <= $4
+		RETURNING counter
+	`, key, now.UnixMicro(), refillInterval(capacity, window).Microseconds(), window.Microseconds()).Scan(&micros)
+	if err == nil {
+		return tokens(true, time.UnixMicro(micros), now, capacity, window), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return Tokens{}, err
+	}
+
+	// The bucket was empty; read when it fills up for the caller's wait
+	err = p.db.QueryRowContext(ctx, `SELECT counter FROM kv_store WHERE key = $1`, key).Scan(&micros)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return Tokens{}, err
+	}
+	return tokens(false, time.UnixMicro(micros), now, capacity, window), nil
+}
+
+// Delete implements Store
+func (p *Postgres) Delete(ctx context.Context, key string) error {
+	_, err := p.db.ExecContext(ctx, `DELETE FROM kv_store WHERE key = $1`, key)
+	return err
+}
+
+// Purge removes expired entries
+func (p *Postgres) Purge(ctx context.Context) error {
+	_, err := p.db.ExecContext(ctx, `DELETE FROM kv_store WHERE expires_at <= NOW()`)
+	return err
+}
+
+// maybePurge removes expired entries once every sweepEvery writes
+func (p *Postgres) maybePurge(ctx context.Context) {
+	if p.writes.Add(1)%sweepEvery == 0 {
+		p.Purge(ctx)
+	}
+}
@@ -0,0 +1,105 @@
+package kvstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// incrScript increments a counter and sets its TTL only when it is created
+var incrScript = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if count == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// takeScript takes a token from a bucket stored as the time in
+// microseconds it is full again, see take. It returns whether a token was
+// taken and the time the bucket is full again.
+var takeScript = redis.NewScript(`
+local now, interval, window = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+local full = math.max(tonumber(redis.call("GET", KEYS[1]) or now), now)
+local next = full + interval
+if next - now > window then
+	return {0, string.format("%d", full)}
+end
+redis.call("SET", KEYS[1], string.format("%d", next), "PX", math.ceil((next - now) / 1000))
+return {1, string.format("%d", next)}
+`)
+
+// Redis is a Store shared by all replicas through Redis
+type Redis struct {
+	client *redis.Client
+}
+
+// NewRedis creates a new Redis-backed Store from a redis:// URL
+func NewRedis(url string) (*Redis, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	return &Redis{client: redis.NewClient(opts)}, nil
+}
+
+// Get implements Store
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := r.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set implements Store
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.client.Set(ctx, key, value, ttl).Err()
+}
+
+// SetNX implements Store
+func (r *Redis) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, key, value, ttl).Result()
+}
+
+// Incr implements Store
+func (r *Redis) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return incrScript.Run(ctx, r.client, []string{key}, ttl.Milliseconds()).Int64()
+}
+
+// Take implements Store
+func (r *Redis) Take(ctx context.Context, key string, capacity int, window time.Duration) (Tokens, error) {
+	now := time.Now()
+	result, err := takeScript.Run(ctx, r.client, []string{key},
+		now.UnixMicro(), refillInterval(capacity, window).Microseconds(), window.Microseconds()).Slice()
+	if err != nil {
+		return Tokens{}, err
+	}
+	if len(result) != 2 {
+		return Tokens{}, fmt.Errorf("unexpected token bucket reply %v", result)
+	}
+	taken, _ := result[0].(int64)
+	full, _ := result[1].(string)
+	micros, err := strconv.ParseInt(full, 10, 64)
+	if err != nil {
+		return Tokens{}, err
+	}
+	return tokens(taken == 1, time.UnixMicro(micros), now, capacity, window), nil
+}
+
+// Delete implements Store
+func (r *Redis) Delete(ctx context.Context, key string) error {
+	return r.client.Del(ctx, key).Err()
+}
+
+// Close closes the Redis connection pool
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
@@ -0,0 +1,199 @@
+package pkg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/moabdelazem/mutlitier_app/pkg/codec"
+	"github.com/moabdelazem/mutlitier_app/pkg/metrics"
+)
+
+// maxListBytes caps the encoded size of a list response, 0 for no cap
+var maxListBytes atomic.Int64
+
+// errListTooLarge stops encoding once a list outgrows maxListBytes
+var errListTooLarge = errors.New("list response too large")
+
+// newline ends every value an Encoder writes
+var newline = []byte("\n")
+
+// dataPrefix is how a list envelope with a nil data field starts when
+// marshaled; the items are written in its place
+var dataPrefix = []byte(`{"data":null`)
+
+// ListTooLargeResponse is returned instead of a list that would exceed
+// the response size cap
+type ListTooLargeResponse struct {
+	Error    string `json:"error"`
+	MaxBytes int64  `json:"max_bytes"`
+}
+
+// SetMaxListBytes sets the largest list response JSONList will send, 0
+// for no limit
+func SetMaxListBytes(n int64) {
+	maxListBytes.Store(n)
+}
+
+// JSONList writes a list the way JSONSuccess does, but encodes its items
+// one at a time instead of marshaling the whole response in one go. The
+// list is either a slice or an envelope struct whose first field is the
+// `json:"data"` slice; the envelope's other fields follow the items.
+//
+// Without a size cap the items are streamed straight to the client. With
+// one, they are encoded into a buffer that is abandoned as soon as it
+// passes the cap, and the client gets 422 asking for a smaller page, so a
+// pathological page costs at most the cap in memory.
+func JSONList(w http.ResponseWriter, list any) {
+	JSONListFields(w, list, nil)
+}
+
+// JSONListFields is JSONList for a sparse fieldset: each item only keeps
+// the keys in fields, in their usual order. Nil fields keeps every key.
+func JSONListFields(w http.ResponseWriter, list any, fields []string) {
+	items, meta, err := splitList(list)
+	if err != nil {
+		JSONSuccess(w, list)
+		return
+	}
+	keep := fieldSet(fields)
+
+	limit := maxListBytes.Load()
+	if limit <= 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		writeList(w, items, meta, keep)
+		return
+	}
+
+	pooled := getBuffer()
+	defer putBuffer(pooled)
+	buf := &cappedBuffer{Buffer: &pooled.Buffer, limit: int(limit)}
+	if err := writeList(buf, items, meta, keep); err != nil {
+		if errors.Is(err, errListTooLarge) {
+			metrics.ListResponsesTooLarge.Inc()
+			WriteJSON(w, http.StatusUnprocessableEntity, ListTooLargeResponse{
+				Error:    fmt.Sprintf("Response would exceed %d bytes, request a smaller page with per_page or limit", limit),
+				MaxBytes: limit,
+			})
+			return
+		}
+		InternalError(w, "Failed to encode response")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// splitList separates a list into its items and the JSON that follows
+// them: nil for a bare slice, the rest of the envelope otherwise
+func splitList(list any) (reflect.Value, []byte, error) {
+	v := reflect.ValueOf(list)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return v, nil, errors.New("nil list")
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() == reflect.Slice {
+		return v, nil, nil
+	}
+	if v.Kind() != reflect.Struct || v.NumField() == 0 {
+		return v, nil, errors.New("not a list")
+	}
+	field := v.Type().Field(0)
+	if field.Tag.Get("json") != "data" || field.Type.Kind() != reflect.Slice {
+		return v, nil, errors.New("list envelope must start with a data slice")
+	}
+
+	envelope := reflect.New(v.Type()).Elem()
+	envelope.Set(v)
+	envelope.Field(0).SetZero()
+	encoded, err := codec.Marshal(envelope.Interface())
+	if err != nil || !bytes.HasPrefix(encoded, dataPrefix) {
+		return v, nil, errors.New("unexpected list envelope encoding")
+	}
+	return v.Field(0), encoded[len(dataPrefix):], nil
+}
+
+// writeList encodes items one by one, followed by meta when the list
+// came in an envelope. A nil slice is written as [] either way. With a
+// field set, items only keep the keys in it.
+func writeList(w io.Writer, items reflect.Value, meta []byte, keep map[string]bool) error {
+	open, end := "[", "]\n"
+	if meta != nil {
+		open, end = `{"data":[`, "]"
+	}
+	if _, err := io.WriteString(w, open); err != nil {
+		return err
+	}
+
+	// Items are encoded one at a time into the same pooled buffers
+	encoded, selected := getBuffer(), getBuffer()
+	defer putBuffer(encoded)
+	defer putBuffer(selected)
+
+	for i := range items.Len() {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		encoded.Reset()
+		if err := encoded.encode(items.Index(i).Interface()); err != nil {
+			return err
+		}
+		item := bytes.TrimSuffix(encoded.Bytes(), newline)
+		if keep != nil {
+			selected.Reset()
+			if err := selectFields(&selected.Buffer, item, keep); err != nil {
+				return err
+			}
+			item = selected.Bytes()
+		}
+		if _, err := w.Write(item); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.WriteString(w, end); err != nil {
+		return err
+	}
+	if meta != nil {
+		if _, err := w.Write(meta); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, "\n")
+		return err
+	}
+	return nil
+}
+
+// cappedBuffer is a bytes.Buffer that refuses to grow past limit
+type cappedBuffer struct {
+	*bytes.Buffer
+	limit int
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.limit {
+		return 0, errListTooLarge
+	}
+	return b.Buffer.Write(p)
+}
+
+func (b *cappedBuffer) WriteString(s string) (int, error) {
+	if b.Len()+len(s) > b.limit {
+		return 0, errListTooLarge
+	}
+	return b.Buffer.WriteString(s)
+}
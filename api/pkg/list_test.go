@@ -0,0 +1,150 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/pkg/codec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testItem struct {
+	Name string `json:"name"`
+}
+
+type testList struct {
+	Data  []*testItem `json:"data"`
+	Total int         `json:"total"`
+	Next  string      `json:"next,omitempty"`
+}
+
+func TestJSONListMatchesJSONSuccess(t *testing.T) {
+	t.Cleanup(func() { SetMaxListBytes(0) })
+
+	lists := []any{
+		testList{Data: []*testItem{{Name: "a"}, {Name: "<b>"}}, Total: 2, Next: "x"},
+		&testList{Data: []*testItem{{Name: "a"}}, Total: 1},
+		[]*testItem{{Name: "a"}, {Name: "b"}},
+		map[string]int{"not": 1},
+	}
+
+	for _, limit := range []int64{0, 1 << 20} {
+		SetMaxListBytes(limit)
+		for _, list := range lists {
+			want := httptest.NewRecorder()
+			JSONSuccess(want, list)
+			got := httptest.NewRecorder()
+			JSONList(got, list)
+
+			assert.Equal(t, http.StatusOK, got.Code)
+			assert.Equal(t, want.Body.String(), got.Body.String())
+		}
+	}
+}
+
+func TestJSONListEmptyDataIsArray(t *testing.T) {
+	rec := httptest.NewRecorder()
+	JSONList(rec, testList{})
+
+	assert.JSONEq(t, `{"data":[],"total":0}`, rec.Body.String())
+}
+
+func TestJSONListRefusesOversizedResponse(t *testing.T) {
+	SetMaxListBytes(64)
+	t.Cleanup(func() { SetMaxListBytes(0) })
+
+	list := testList{Data: []*testItem{{Name: strings.Repeat("x", 100)}}, Total: 1}
+	rec := httptest.NewRecorder()
+	JSONList(rec, list)
+
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	var body ListTooLargeResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, int64(64), body.MaxBytes)
+	assert.Contains(t, body.Error, "smaller page")
+
+	small := httptest.NewRecorder()
+	JSONList(small, testList{Data: []*testItem{{Name: "a"}}, Total: 1})
+	assert.Equal(t, http.StatusOK, small.Code)
+	assert.Equal(t, strconv.Itoa(small.Body.Len()), small.Header().Get("Content-Length"))
+}
+
+// BenchmarkJSONList encodes a full page of task-sized items with each
+// compiled-in engine: go test -bench JSONList -benchmem ./pkg
+func BenchmarkJSONList(b *testing.B) {
+	type task struct {
+		ID          string     `json:"id"`
+		Title       string     `json:"title"`
+		Description string     `json:"description"`
+		Status      string     `json:"status"`
+		Priority    string     `json:"priority"`
+		Tags        []string   `json:"tags"`
+		DueDate     *time.Time `json:"due_date,omitempty"`
+		Version     int        `json:"version"`
+		CreatedAt   time.Time  `json:"created_at"`
+		UpdatedAt   time.Time  `json:"updated_at"`
+	}
+	type page struct {
+		Data       []*task `json:"data"`
+		Total      int     `json:"total"`
+		Page       int     `json:"page"`
+		PerPage    int     `json:"per_page"`
+		TotalPages int     `json:"total_pages"`
+	}
+
+	now := time.Now()
+	list := page{Total: 100, Page: 1, PerPage: 100, TotalPages: 1}
+	for i := range 100 {
+		list.Data = append(list.Data, &task{
+			ID:          fmt.Sprintf("6f1c2a4e-0b7d-4c1e-9a55-%012d", i),
+			Title:       fmt.Sprintf("Task %d", i),
+			Description: strings.Repeat("Lorem ipsum dolor sit amet. ", 4),
+			Status:      "in_progress",
+			Priority:    "high",
+			Tags:        []string{"backend", "api"},
+			DueDate:     &now,
+			Version:     i,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		})
+	}
+
+	for _, name := range codec.Engines() {
+		b.Run(name, func(b *testing.B) {
+			require.NoError(b, codec.Use(name))
+			b.Cleanup(func() { codec.Use("") })
+			w := httptest.NewRecorder()
+			b.ReportAllocs()
+			for b.Loop() {
+				w.Body.Reset()
+				JSONList(w, list)
+			}
+		})
+	}
+}
+
+func TestJSONListFieldsKeepsSelectedKeys(t *testing.T) {
+	list := testList{Data: []*testItem{{Name: "a"}}, Total: 1, Next: "x"}
+
+	rec := httptest.NewRecorder()
+	JSONListFields(rec, list, []string{"missing"})
+	assert.JSONEq(t, `{"data":[{}],"total":1,"next":"x"}`, rec.Body.String(), "only items are filtered")
+
+	rec = httptest.NewRecorder()
+	JSONListFields(rec, list, []string{"name"})
+	assert.JSONEq(t, `{"data":[{"name":"a"}],"total":1,"next":"x"}`, rec.Body.String())
+
+	// Kept values are byte for byte what JSONSuccess writes
+	want := httptest.NewRecorder()
+	JSONSuccess(want, map[string]any{"b": "<b>"})
+	rec = httptest.NewRecorder()
+	JSONFields(rec, map[string]any{"b": "<b>", "a": 1}, []string{"b"})
+	assert.Equal(t, want.Body.String(), rec.Body.String())
+}
@@ -0,0 +1,53 @@
+package listing
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Int parses an optional integer query parameter, returning 0 when absent
+func Int(query url.Values, name string) (int, error) {
+	value := query.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, invalid(name, "must be a number")
+	}
+	return n, nil
+}
+
+// Bool parses an optional true or false query parameter, returning false
+// when absent
+func Bool(query url.Values, name string) (bool, error) {
+	value := query.Get(name)
+	if value == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, invalid(name, "must be true or false")
+	}
+	return b, nil
+}
+
+// Time parses an optional RFC 3339 timestamp or YYYY-MM-DD date (UTC
+// midnight) query parameter
+func Time(query url.Values, name string) (*time.Time, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		if t, err = time.Parse(time.DateOnly, value); err != nil {
+			return nil, invalid(name, "must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+		}
+	}
+	return &t, nil
+}
@@ -0,0 +1,136 @@
+// Package listing is the contract list endpoints share: page and per_page
+// pagination, sort and order, typed filters read from query parameters,
+// and the pagination metadata returned with every page. Parsing only
+// checks that values are well-formed; Normalize and NormalizeSort apply
+// the defaults and limits of the resource being listed.
+package listing
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrInvalid  = errors.New("invalid list parameter")
+	ErrTooLarge = errors.New("list page too large")
+)
+
+// Sort orders, the first being the default
+const (
+	Desc = "desc"
+	Asc  = "asc"
+)
+
+// Orders are the accepted sort orders, the first being the default
+var Orders = []string{Desc, Asc}
+
+// Error is a query parameter that is malformed or out of range. It wraps
+// ErrInvalid, or ErrTooLarge for a page larger than allowed.
+type Error struct {
+	Param  string // the query parameter at fault
+	Reason string // what is wrong with it, such as "must be positive"
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return e.Param + " " + e.Reason
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// invalid returns an Error wrapping ErrInvalid
+func invalid(param, reason string) *Error {
+	return &Error{Param: param, Reason: reason, Err: ErrInvalid}
+}
+
+// Params are the pagination and sort parameters of a list request
+type Params struct {
+	Page    int    // page: 1-based page number
+	PerPage int    // per_page: maximum number of items to return
+	Sort    string // sort: field to order by
+	Order   string // order: asc or desc
+}
+
+// Parse reads page, per_page, sort and order from query. Absent values
+// stay zero for Normalize and NormalizeSort to fill in.
+func Parse(query url.Values) (Params, error) {
+	params := Params{Sort: query.Get("sort"), Order: query.Get("order")}
+
+	var err error
+	if params.Page, err = Int(query, "page"); err != nil {
+		return Params{}, err
+	}
+	if params.PerPage, err = Int(query, "per_page"); err != nil {
+		return Params{}, err
+	}
+
+	return params, nil
+}
+
+// Limits are the page sizes a resource allows
+type Limits struct {
+	DefaultPerPage int
+	MaxPerPage     int
+	// Clamp lowers a per_page above MaxPerPage to it instead of
+	// rejecting the request with ErrTooLarge
+	Clamp bool
+}
+
+// Normalize checks the page and page size and fills in the first page and
+// the default page size when absent
+func (p *Params) Normalize(limits Limits) error {
+	if p.Page < 0 {
+		return invalid("page", "must be positive")
+	}
+	if p.Page == 0 {
+		p.Page = 1
+	}
+
+	if p.PerPage < 0 {
+		return invalid("per_page", "must be positive")
+	}
+	if p.PerPage == 0 {
+		p.PerPage = limits.DefaultPerPage
+	}
+	if p.PerPage > limits.MaxPerPage {
+		if !limits.Clamp {
+			return &Error{Param: "per_page", Reason: fmt.Sprintf("must be at most %d", limits.MaxPerPage), Err: ErrTooLarge}
+		}
+		p.PerPage = limits.MaxPerPage
+	}
+
+	return nil
+}
+
+// NormalizeSort lowercases sort and order and fills in the defaults: the
+// first of fields, and Orders[0]. A sort outside fields or an unknown
+// order is ErrInvalid.
+func (p *Params) NormalizeSort(fields []string) error {
+	p.Sort = strings.ToLower(strings.TrimSpace(p.Sort))
+	if p.Sort == "" {
+		p.Sort = fields[0]
+	}
+	if !slices.Contains(fields, p.Sort) {
+		return invalid("sort", "must be one of: "+strings.Join(fields, ", "))
+	}
+
+	p.Order = strings.ToLower(strings.TrimSpace(p.Order))
+	if p.Order == "" {
+		p.Order = Orders[0]
+	}
+	if !slices.Contains(Orders, p.Order) {
+		return invalid("order", "must be one of: "+strings.Join(Orders, ", "))
+	}
+
+	return nil
+}
+
+// Offset is how many items come before the page
+func (p *Params) Offset() int {
+	return (p.Page - 1) * p.PerPage
+}
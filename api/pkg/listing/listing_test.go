@@ -0,0 +1,150 @@
+package listing
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	query := url.Values{"page": {"2"}, "per_page": {"50"}, "sort": {"Title"}, "order": {"ASC"}}
+	params, err := Parse(query)
+	require.NoError(t, err)
+	assert.Equal(t, Params{Page: 2, PerPage: 50, Sort: "Title", Order: "ASC"}, params)
+
+	// Absent values stay zero for Normalize
+	params, err = Parse(url.Values{})
+	require.NoError(t, err)
+	assert.Equal(t, Params{}, params)
+
+	_, err = Parse(url.Values{"per_page": {"many"}})
+	assert.ErrorIs(t, err, ErrInvalid)
+	assert.EqualError(t, err, "per_page must be a number")
+}
+
+func TestNormalize(t *testing.T) {
+	limits := Limits{DefaultPerPage: 20, MaxPerPage: 100}
+
+	tests := []struct {
+		name     string
+		params   Params
+		limits   Limits
+		err      error
+		expected Params
+	}{
+		{"defaults", Params{}, limits, nil, Params{Page: 1, PerPage: 20}},
+		{"explicit", Params{Page: 3, PerPage: 100}, limits, nil, Params{Page: 3, PerPage: 100}},
+		{"negative page", Params{Page: -1}, limits, ErrInvalid, Params{}},
+		{"negative per_page", Params{PerPage: -1}, limits, ErrInvalid, Params{}},
+		{"huge page", Params{PerPage: 5000}, limits, ErrTooLarge, Params{}},
+		{"clamped page", Params{PerPage: 5000}, Limits{DefaultPerPage: 20, MaxPerPage: 100, Clamp: true}, nil, Params{Page: 1, PerPage: 100}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.params.Normalize(tt.limits)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, tt.params)
+		})
+	}
+
+	params := Params{PerPage: 5000}
+	err := params.Normalize(limits)
+	assert.EqualError(t, err, "per_page must be at most 100")
+}
+
+func TestNormalizeSort(t *testing.T) {
+	fields := []string{"created_at", "title"}
+
+	params := Params{}
+	require.NoError(t, params.NormalizeSort(fields))
+	assert.Equal(t, Params{Sort: "created_at", Order: Desc}, params)
+
+	params = Params{Sort: " Title ", Order: "ASC"}
+	require.NoError(t, params.NormalizeSort(fields))
+	assert.Equal(t, Params{Sort: "title", Order: Asc}, params)
+
+	var invalid *Error
+	params = Params{Sort: "description"}
+	err := params.NormalizeSort(fields)
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, "sort", invalid.Param)
+	assert.ErrorIs(t, err, ErrInvalid)
+	assert.EqualError(t, err, "sort must be one of: created_at, title")
+
+	params = Params{Order: "sideways"}
+	err = params.NormalizeSort(fields)
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, "order", invalid.Param)
+}
+
+func TestOffset(t *testing.T) {
+	assert.Equal(t, 0, (&Params{Page: 1, PerPage: 20}).Offset())
+	assert.Equal(t, 40, (&Params{Page: 3, PerPage: 20}).Offset())
+}
+
+func TestFilters(t *testing.T) {
+	query := url.Values{
+		"days":     {"7"},
+		"overdue":  {"true"},
+		"bad":      {"maybe"},
+		"from":     {"2026-10-01"},
+		"to":       {"2026-10-17T10:00:00Z"},
+		"sometime": {"last week"},
+	}
+
+	n, err := Int(query, "days")
+	require.NoError(t, err)
+	assert.Equal(t, 7, n)
+	n, err = Int(query, "absent")
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	_, err = Int(query, "overdue")
+	assert.EqualError(t, err, "overdue must be a number")
+
+	b, err := Bool(query, "overdue")
+	require.NoError(t, err)
+	assert.True(t, b)
+	b, err = Bool(query, "absent")
+	require.NoError(t, err)
+	assert.False(t, b)
+	_, err = Bool(query, "bad")
+	assert.ErrorIs(t, err, ErrInvalid)
+	assert.EqualError(t, err, "bad must be true or false")
+
+	from, err := Time(query, "from")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), *from)
+	to, err := Time(query, "to")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC), to.UTC())
+	absent, err := Time(query, "absent")
+	require.NoError(t, err)
+	assert.Nil(t, absent)
+	_, err = Time(query, "sometime")
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestNewPagination(t *testing.T) {
+	p := NewPagination(2, 10, 25)
+	assert.Equal(t, 3, p.TotalPages)
+	require.NotNil(t, p.NextPage)
+	assert.Equal(t, 3, *p.NextPage)
+	require.NotNil(t, p.PrevPage)
+	assert.Equal(t, 1, *p.PrevPage)
+
+	last := NewPagination(3, 10, 25)
+	assert.Nil(t, last.NextPage)
+
+	empty := NewPagination(1, 10, 0)
+	assert.Zero(t, empty.TotalPages)
+	assert.Nil(t, empty.NextPage)
+	assert.Nil(t, empty.PrevPage)
+}
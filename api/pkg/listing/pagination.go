@@ -0,0 +1,34 @@
+package listing
+
+// Pagination describes the position of a page within a list
+type Pagination struct {
+	Page       int  `json:"page"`
+	PerPage    int  `json:"per_page"`
+	Total      int  `json:"total"`
+	TotalPages int  `json:"total_pages"`
+	NextPage   *int `json:"next_page"`
+	PrevPage   *int `json:"prev_page"`
+
+	// NextCursor is a signed, opaque alternative to next_page that is only
+	// valid with the same filters
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NewPagination builds pagination metadata for a page of a list with total items
+func NewPagination(page, perPage, total int) Pagination {
+	p := Pagination{
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: (total + perPage - 1) / perPage,
+	}
+	if page < p.TotalPages {
+		next := page + 1
+		p.NextPage = &next
+	}
+	if page > 1 {
+		prev := page - 1
+		p.PrevPage = &prev
+	}
+	return p
+}
@@ -1,11 +1,13 @@
 package logger
 
 import (
+	"context"
 	"io"
 	"os"
 	"time"
 
 	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/pkg/tracing"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
@@ -66,6 +68,24 @@ func Get() *Logger {
 	return globalLogger
 }
 
+// Ctx returns the global logger with the trace context of ctx, so events
+// logged while serving a request carry its trace_id and span_id
+func Ctx(ctx context.Context) *Logger {
+	return Get().WithTrace(ctx)
+}
+
+// WithTrace creates a new logger with the trace and span IDs in ctx, or
+// returns l when ctx has none, as in background jobs
+func (l *Logger) WithTrace(ctx context.Context) *Logger {
+	sc, ok := tracing.FromContext(ctx)
+	if !ok {
+		return l
+	}
+	return &Logger{
+		Logger: l.With().Str("trace_id", sc.TraceID).Str("span_id", sc.SpanID).Logger(),
+	}
+}
+
 // WithRequestID creates a new logger with request ID context
 func (l *Logger) WithRequestID(requestID string) *Logger {
 	return &Logger{
@@ -0,0 +1,247 @@
+package metrics
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds all application metrics
+var Registry = prometheus.NewRegistry()
+
+// inFlight and capacity back the autoscaling gauges; reading both at
+// scrape time keeps the utilization ratio consistent with the count
+var inFlight, capacity atomic.Int64
+
+// failoverProbe reports whether a database failover is in progress
+var failoverProbe atomic.Pointer[func() bool]
+
+var (
+	// HTTPRequestDuration observes request latency per route, with trace
+	// exemplars for requests that arrive with a sampled traceparent
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by method, route pattern and status code.",
+		Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestQueries observes how many SQL statements each request ran
+	HTTPRequestQueries = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_db_queries",
+		Help:    "SQL statements run per HTTP request by route pattern.",
+		Buckets: []float64{0, 1, 2, 3, 5, 10, 20, 50, 100},
+	}, []string{"route"})
+
+	// HTTPRequestQueriesExceeded counts requests that ran more statements than QUERY_COUNT_THRESHOLD
+	HTTPRequestQueriesExceeded = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_request_db_queries_exceeded_total",
+		Help: "Requests that ran more SQL statements than the configured threshold, a likely N+1 pattern.",
+	}, []string{"route"})
+
+	// InFlightRequests, InFlightCapacity, InFlightUtilization and
+	// RequestQueueDepth are autoscaling signals for KEDA or the HPA. Their
+	// names are part of the deployment contract, do not rename them.
+	InFlightRequests = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "http_inflight_requests",
+		Help: "Task API requests currently being served or queued by this replica.",
+	}, func() float64 { return float64(inFlight.Load()) })
+
+	// InFlightCapacity is AUTOSCALING_CAPACITY
+	InFlightCapacity = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "http_inflight_capacity",
+		Help: "In-flight requests one replica is sized to serve (AUTOSCALING_CAPACITY).",
+	}, func() float64 { return float64(capacity.Load()) })
+
+	// InFlightUtilization is in-flight requests divided by capacity
+	InFlightUtilization = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "http_inflight_utilization_ratio",
+		Help: "In-flight requests divided by capacity; above 1 the replica is overloaded.",
+	}, func() float64 {
+		limit := capacity.Load()
+		if limit <= 0 {
+			return 0
+		}
+		return float64(inFlight.Load()) / float64(limit)
+	})
+
+	// RequestQueueDepth counts requests waiting for a tenant concurrency slot
+	RequestQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "http_request_queue_depth",
+		Help: "Requests waiting for a per-tenant concurrency slot on this replica.",
+	})
+
+	// RateLimitSoftExceeded counts requests served past the soft rate limit
+	RateLimitSoftExceeded = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "http_rate_limit_soft_exceeded_total",
+		Help: "Requests served with a rate limit warning after the soft threshold.",
+	})
+
+	// RateLimitHardExceeded counts requests rejected by the hard rate limit
+	RateLimitHardExceeded = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "http_rate_limit_hard_exceeded_total",
+		Help: "Requests rejected with 429 after the hard threshold.",
+	})
+
+	// TenantQueueWait observes how long requests waited for a tenant concurrency slot
+	TenantQueueWait = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "tenant_concurrency_queue_wait_seconds",
+		Help:    "Time requests spent waiting for a per-tenant concurrency slot.",
+		Buckets: []float64{.001, .005, .01, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"plan"})
+
+	// TenantConcurrencyRejected counts requests rejected because a tenant had no free slot
+	TenantConcurrencyRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tenant_concurrency_rejected_total",
+		Help: "Requests rejected by the per-tenant concurrency limit.",
+	}, []string{"plan", "status"})
+
+	// DegradationMode reports which degradation switches are on (1) or off (0)
+	DegradationMode = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "degradation_mode",
+		Help: "Whether a degradation switch is currently enabled.",
+	}, []string{"mode"})
+
+	// FailoverActive reports whether the database is failing over (1) or not (0)
+	FailoverActive = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "db_failover_active",
+		Help: "Whether this replica is in degraded mode during a database failover.",
+	}, func() float64 {
+		if probe := failoverProbe.Load(); probe != nil && (*probe)() {
+			return 1
+		}
+		return 0
+	})
+
+	// FailoverEntered counts database failovers detected
+	FailoverEntered = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "db_failover_entered_total",
+		Help: "Database failovers detected from connection errors or read-only writes.",
+	})
+
+	// FailoverReplicaReads counts reads served by the replica during a failover
+	FailoverReplicaReads = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "db_failover_replica_reads_total",
+		Help: "Reads run on the read replica while the primary was failing over.",
+	})
+
+	// FailoverRequests counts writes held back during a failover by outcome
+	FailoverRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_failover_requests_total",
+		Help: "Requests held back during a database failover by result (queued, rejected).",
+	}, []string{"result"})
+
+	// FeatureVariantRequests counts requests that opted into a feature flag variant
+	FeatureVariantRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "feature_variant_requests_total",
+		Help: "Requests served per feature flag variant chosen with X-Feature-Flags.",
+	}, []string{"variant"})
+
+	// RepositoryShadowComparisons counts shadow repository reads and writes by outcome
+	RepositoryShadowComparisons = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "repository_shadow_comparisons_total",
+		Help: "Shadow repository operations by method and result (match, diverged, error, skipped).",
+	}, []string{"method", "result"})
+
+	// SecurityEvents counts security events by type and outcome
+	SecurityEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "security_events_total",
+		Help: "Security events by type and result (recorded, deduplicated, dropped).",
+	}, []string{"type", "result"})
+
+	// AuditLogs counts audit logs by outcome
+	AuditLogs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "audit_logs_total",
+		Help: "Audit logs of mutations by result (recorded, dropped).",
+	}, []string{"result"})
+
+	// AbuseBlocks counts clients blocked by the abuse guard
+	AbuseBlocks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "abuse_blocks_total",
+		Help: "Clients temporarily blocked by kind of abuse (not_found_scan, credential_stuffing, oversized_payload).",
+	}, []string{"kind"})
+
+	// ExpansionDuration observes how long each expansion of a task response took
+	ExpansionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "task_expansion_duration_seconds",
+		Help:    "Time to load one expansion of task responses by expansion and result (ok, error, timeout).",
+		Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"expansion", "result"})
+
+	// AbuseRejected counts requests rejected because their client is blocked
+	AbuseRejected = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "abuse_rejected_total",
+		Help: "Requests rejected with 429 while their client was blocked for abuse.",
+	})
+
+	// ListResponsesTooLarge counts list responses refused for exceeding the size cap
+	ListResponsesTooLarge = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "http_list_response_too_large_total",
+		Help: "List responses replaced with 422 because they exceeded LIST_MAX_RESPONSE_BYTES.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestDuration,
+		HTTPRequestQueries,
+		HTTPRequestQueriesExceeded,
+		InFlightRequests,
+		InFlightCapacity,
+		InFlightUtilization,
+		RequestQueueDepth,
+		RateLimitSoftExceeded,
+		RateLimitHardExceeded,
+		TenantQueueWait,
+		TenantConcurrencyRejected,
+		DegradationMode,
+		FailoverActive,
+		FailoverEntered,
+		FailoverReplicaReads,
+		FailoverRequests,
+		FeatureVariantRequests,
+		RepositoryShadowComparisons,
+		SecurityEvents,
+		AuditLogs,
+		AbuseBlocks,
+		AbuseRejected,
+		ExpansionDuration,
+		ListResponsesTooLarge,
+	)
+}
+
+// SetCapacity sets the in-flight capacity used for the utilization ratio
+func SetCapacity(n int) {
+	capacity.Store(int64(n))
+}
+
+// SetFailoverProbe sets the func FailoverActive reads
+func SetFailoverProbe(probe func() bool) {
+	failoverProbe.Store(&probe)
+}
+
+// TrackInFlight counts a request as in flight until the returned func is called
+func TrackInFlight() (done func()) {
+	inFlight.Add(1)
+	return func() { inFlight.Add(-1) }
+}
+
+// ObserveWithTrace records value, attaching traceID as an exemplar so
+// dashboards can jump from a histogram bucket to a matching trace
+func ObserveWithTrace(observer prometheus.Observer, value float64, traceID string) {
+	if eo, ok := observer.(prometheus.ExemplarObserver); ok && traceID != "" {
+		eo.ObserveWithExemplar(value, prometheus.Labels{"trace_id": traceID})
+		return
+	}
+	observer.Observe(value)
+}
+
+// Handler returns the HTTP handler that exposes metrics in Prometheus
+// format, or OpenMetrics (which carries exemplars) when the scraper asks
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{EnableOpenMetrics: true})
+}
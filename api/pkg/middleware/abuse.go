@@ -0,0 +1,182 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+	"github.com/moabdelazem/mutlitier_app/pkg/metrics"
+)
+
+// Kinds of abuse the guard looks for
+const (
+	AbuseNotFoundScan       = "not_found_scan"
+	AbuseCredentialStuffing = "credential_stuffing"
+	AbuseOversizedPayload   = "oversized_payload"
+)
+
+// AbuseGuard temporarily blocks clients whose traffic looks like path
+// scanning (many 404s), credential stuffing (many failed sign-ins) or
+// oversized uploads. Offenders are identified by IP and, when they present
+// one, by API token, so rotating addresses does not shake off a block on a
+// stolen token. Counters and blocks live in the rate limiter's store, so
+// replicas sharing a backend share them, and every block raises an
+// abuse_detected security event.
+type AbuseGuard struct {
+	cfg      *config.AbuseConfig
+	store    kvstore.Store
+	security SecurityRecorder
+}
+
+// NewAbuseGuard creates a new AbuseGuard reporting blocks to security
+func NewAbuseGuard(cfg *config.AbuseConfig, store kvstore.Store, security SecurityRecorder) *AbuseGuard {
+	return &AbuseGuard{cfg: cfg, store: store, security: security}
+}
+
+// Middleware rejects blocked clients with 429 Too Many Requests and counts
+// 404 responses and oversized bodies against the rest
+func (g *AbuseGuard) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subjects := abuseSubjects(r)
+
+		for _, subject := range subjects {
+			until, blocked, err := g.blocked(r.Context(), subject)
+			if err != nil {
+				// Fail open like the rate limiter: a store outage should not take the API down
+				logger.Ctx(r.Context()).Warn().Err(err).Msg("Abuse guard store unavailable")
+				break
+			}
+			if blocked {
+				metrics.AbuseRejected.Inc()
+				w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(until).Seconds())+1))
+				pkg.TooManyRequests(w, "Temporarily blocked after suspicious activity")
+				return
+			}
+		}
+
+		limitPayload := g.cfg.MaxPayloadBytes > 0
+		oversized := limitPayload && r.ContentLength > g.cfg.MaxPayloadBytes
+		var body *countingBody
+		if limitPayload && !oversized && r.Body != nil && r.Body != http.NoBody {
+			// Chunked uploads have no Content-Length, so count what is read
+			body = &countingBody{ReadCloser: r.Body}
+			r.Body = body
+		}
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		if body != nil && body.n > g.cfg.MaxPayloadBytes {
+			oversized = true
+		}
+
+		// The request may already be cancelled, but the counts should still land
+		ctx := context.WithoutCancel(r.Context())
+		if ww.Status() == http.StatusNotFound {
+			g.strike(ctx, AbuseNotFoundScan, g.cfg.NotFoundLimit, subjects, SecurityEvent(r, model.SecurityAbuseDetected, ""))
+		}
+		if oversized {
+			g.strike(ctx, AbuseOversizedPayload, g.cfg.OversizedLimit, subjects, SecurityEvent(r, model.SecurityAbuseDetected, ""))
+		}
+	})
+}
+
+// Record passes event on to the security recorder, counting failed
+// sign-ins against the client's address. Hand the guard to Authenticate
+// in place of the recorder to detect credential stuffing.
+func (g *AbuseGuard) Record(ctx context.Context, event *model.SecurityEvent) {
+	if g.security != nil {
+		g.security.Record(ctx, event)
+	}
+
+	if event.Type == model.SecurityLoginFailed && event.IP != "" {
+		alert := *event
+		alert.Type = model.SecurityAbuseDetected
+		alert.Reason = ""
+		g.strike(context.WithoutCancel(ctx), AbuseCredentialStuffing, g.cfg.LoginFailureLimit, []string{"ip:" + event.IP}, &alert)
+	}
+}
+
+// strike counts one occurrence of kind against each subject, blocking
+// those that reach limit within the window. Only the request that reaches
+// the limit blocks, so each offence raises a single alert.
+func (g *AbuseGuard) strike(ctx context.Context, kind string, limit int, subjects []string, alert *model.SecurityEvent) {
+	if limit <= 0 {
+		return
+	}
+
+	windowStart := time.Now().Truncate(g.cfg.Window)
+	for _, subject := range subjects {
+		key := "abuse:" + kind + ":" + subject + ":" + strconv.FormatInt(windowStart.Unix(), 10)
+		count, err := g.store.Incr(ctx, key, g.cfg.Window)
+		if err != nil {
+			logger.Ctx(ctx).Warn().Err(err).Msg("Abuse guard store unavailable")
+			return
+		}
+		if count != int64(limit) {
+			continue
+		}
+
+		until := time.Now().Add(g.cfg.BlockDuration)
+		if err := g.store.Set(ctx, "abuse:block:"+subject, []byte(strconv.FormatInt(until.Unix(), 10)), g.cfg.BlockDuration); err != nil {
+			logger.Ctx(ctx).Error().Err(err).Str("subject", subject).Msg("Failed to block abusive client")
+			continue
+		}
+		metrics.AbuseBlocks.WithLabelValues(kind).Inc()
+
+		event := *alert
+		event.Reason = fmt.Sprintf("%s: %d within %s, %s blocked for %s", kind, count, g.cfg.Window, subject, g.cfg.BlockDuration)
+		if g.security != nil {
+			g.security.Record(ctx, &event)
+		}
+	}
+}
+
+// blocked reports whether subject is blocked and until when
+func (g *AbuseGuard) blocked(ctx context.Context, subject string) (time.Time, bool, error) {
+	value, ok, err := g.store.Get(ctx, "abuse:block:"+subject)
+	if err != nil || !ok {
+		return time.Time{}, false, err
+	}
+	unix, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid abuse block %q: %w", value, err)
+	}
+	return time.Unix(unix, 0), true, nil
+}
+
+// abuseSubjects names who a request is counted against: its IP and, when
+// it presents one, its API token. Tokens are hashed so the store never
+// holds a usable secret.
+func abuseSubjects(r *http.Request) []string {
+	subjects := []string{"ip:" + clientKey(r)}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && strings.TrimSpace(token) != "" {
+		sum := sha256.Sum256([]byte(strings.TrimSpace(token)))
+		subjects = append(subjects, "token:"+hex.EncodeToString(sum[:8]))
+	}
+	return subjects
+}
+
+// countingBody counts the bytes read from a request body
+type countingBody struct {
+	io.ReadCloser
+	n int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
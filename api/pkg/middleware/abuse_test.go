@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedEvents []*model.SecurityEvent
+
+func (e *recordedEvents) Record(_ context.Context, event *model.SecurityEvent) {
+	*e = append(*e, event)
+}
+
+func (e recordedEvents) ofType(eventType model.SecurityEventType) []*model.SecurityEvent {
+	var matched []*model.SecurityEvent
+	for _, event := range e {
+		if event.Type == eventType {
+			matched = append(matched, event)
+		}
+	}
+	return matched
+}
+
+func TestAbuseGuard_BlocksNotFoundScans(t *testing.T) {
+	cfg := &config.AbuseConfig{Window: time.Hour, BlockDuration: time.Hour, NotFoundLimit: 3}
+	var events recordedEvents
+	handler := NewAbuseGuard(cfg, kvstore.NewMemory(), &events).Middleware(http.NotFoundHandler())
+
+	status := func(remoteAddr string) int {
+		req := httptest.NewRequest(http.MethodGet, "/wp-admin", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusNotFound, status("10.0.0.1:1234"))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, status("10.0.0.1:1234"))
+	assert.Equal(t, http.StatusNotFound, status("10.0.0.2:1234"), "other clients are not blocked")
+
+	alerts := events.ofType(model.SecurityAbuseDetected)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "10.0.0.1", alerts[0].IP)
+	assert.Contains(t, alerts[0].Reason, AbuseNotFoundScan)
+}
+
+func TestAbuseGuard_BlocksCredentialStuffing(t *testing.T) {
+	cfg := &config.AbuseConfig{Window: time.Hour, BlockDuration: time.Hour, LoginFailureLimit: 2}
+	var events recordedEvents
+	guard := NewAbuseGuard(cfg, kvstore.NewMemory(), &events)
+	handler := guard.Middleware(Authenticate(&config.AuthConfig{}, &config.AdminConfig{}, rejectingVerifier{}, nil, guard)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	status := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, status("guess-1"))
+	assert.Equal(t, http.StatusUnauthorized, status("guess-2"))
+	assert.Equal(t, http.StatusTooManyRequests, status("guess-3"))
+
+	assert.Len(t, events.ofType(model.SecurityLoginFailed), 2)
+	require.Len(t, events.ofType(model.SecurityAbuseDetected), 1)
+	assert.Contains(t, events.ofType(model.SecurityAbuseDetected)[0].Reason, AbuseCredentialStuffing)
+}
+
+func TestAbuseGuard_CountsOversizedStreams(t *testing.T) {
+	cfg := &config.AbuseConfig{Window: time.Hour, BlockDuration: time.Hour, MaxPayloadBytes: 8, OversizedLimit: 1}
+	var events recordedEvents
+	handler := NewAbuseGuard(cfg, kvstore.NewMemory(), &events).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf := make([]byte, 4)
+		for {
+			if _, err := r.Body.Read(buf); err != nil {
+				return
+			}
+		}
+	}))
+
+	// No Content-Length, as with a chunked upload
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader("far more than eight bytes"))
+	req.ContentLength = -1
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader("{}")))
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Len(t, events.ofType(model.SecurityAbuseDetected), 1)
+}
+
+// rejectingVerifier rejects every token
+type rejectingVerifier struct{}
+
+func (rejectingVerifier) Verify(context.Context, string) (*auth.Principal, error) {
+	return nil, auth.ErrInvalidToken
+}
@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/moabdelazem/mutlitier_app/internal/audit"
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// auditBodyLimit is the largest response kept as the state after a change
+const auditBodyLimit = 64 << 10
+
+// AuditRecorder records the audit log of mutations
+type AuditRecorder interface {
+	Record(ctx context.Context, log *model.AuditLog)
+}
+
+// Audit returns a middleware recording every successful POST, PUT, PATCH
+// and DELETE made by an identified caller: who made it, the route and
+// entity, and the entity before (when the code handling it records it with
+// audit.Before) and after (the JSON response). It runs after Actor, and
+// requests of anonymous callers are not recorded.
+func Audit(recorder AuditRecorder) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			default:
+				next.ServeHTTP(w, r)
+				return
+			}
+			user := audit.Actor(r.Context())
+			if user == audit.Anonymous {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, change := audit.WithChange(r.Context())
+			aw := &auditWriter{ResponseWriter: w}
+			next.ServeHTTP(aw, r.WithContext(ctx))
+
+			if aw.status == 0 || aw.status >= http.StatusBadRequest {
+				return
+			}
+
+			log := &model.AuditLog{
+				User:      user,
+				Method:    r.Method,
+				Endpoint:  r.Method + " " + r.URL.Path,
+				Path:      r.URL.Path,
+				Status:    aw.status,
+				IP:        clientKey(r),
+				RequestID: middleware.GetReqID(r.Context()),
+			}
+			if principal := auth.FromContext(r.Context()); principal != nil {
+				log.TokenID = principal.TokenID
+			}
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				if pattern := rctx.RoutePattern(); pattern != "" {
+					log.Endpoint = r.Method + " " + pattern
+				}
+				if n := len(rctx.URLParams.Values); n > 0 {
+					log.EntityID = rctx.URLParams.Values[n-1]
+				}
+			}
+			if change.Before != nil {
+				log.Before, _ = json.Marshal(change.Before)
+			}
+			if aw.keep && !aw.truncated {
+				log.After = aw.body.Bytes()
+				// A created entity is identified by its new ID
+				var created struct {
+					ID any `json:"id"`
+				}
+				if aw.status == http.StatusCreated && json.Unmarshal(log.After, &created) == nil && created.ID != nil {
+					if id, ok := created.ID.(string); ok {
+						log.EntityID = id
+					}
+				}
+			}
+			recorder.Record(r.Context(), log)
+		})
+	}
+}
+
+// auditWriter keeps the status and a JSON response body
+type auditWriter struct {
+	http.ResponseWriter
+	status    int
+	keep      bool
+	truncated bool
+	body      bytes.Buffer
+}
+
+func (w *auditWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+		w.keep = strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *auditWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.keep && !w.truncated {
+		if w.body.Len()+len(b) > auditBodyLimit {
+			w.truncated = true
+			w.body = bytes.Buffer{}
+		} else {
+			w.body.Write(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *auditWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/moabdelazem/mutlitier_app/internal/audit"
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type auditLogs []*model.AuditLog
+
+func (l *auditLogs) Record(ctx context.Context, log *model.AuditLog) {
+	*l = append(*l, log)
+}
+
+func TestAudit(t *testing.T) {
+	var logs auditLogs
+	r := chi.NewRouter()
+	r.Use(Actor(&config.AdminConfig{}))
+	r.Use(Audit(&logs))
+	r.Post("/tasks", func(w http.ResponseWriter, r *http.Request) {
+		pkg.Created(w, map[string]string{"id": "t-1", "title": "Write docs"})
+	})
+	r.Patch("/tasks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		audit.Before(r.Context(), map[string]string{"id": "t-1", "status": "todo"})
+		pkg.JSONSuccess(w, map[string]string{"id": "t-1", "status": "done"})
+	})
+	r.Delete("/tasks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		pkg.NotFound(w, "Task not found")
+	})
+
+	send := func(method, path string, principal *auth.Principal) {
+		req := httptest.NewRequest(method, path, nil)
+		if principal != nil {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+		}
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	alice := &auth.Principal{User: "alice", TokenID: "k-1"}
+	send(http.MethodPost, "/tasks", alice)
+	send(http.MethodPatch, "/tasks/t-1", alice)
+
+	// Failed requests, reads and anonymous callers are not recorded
+	send(http.MethodDelete, "/tasks/t-2", alice)
+	send(http.MethodGet, "/tasks", alice)
+	send(http.MethodPost, "/tasks", nil)
+
+	require.Len(t, logs, 2)
+	assert.Equal(t, "alice", logs[0].User)
+	assert.Equal(t, "k-1", logs[0].TokenID)
+	assert.Equal(t, "POST /tasks", logs[0].Endpoint)
+	assert.Equal(t, "t-1", logs[0].EntityID)
+	assert.Equal(t, http.StatusCreated, logs[0].Status)
+	assert.Empty(t, logs[0].Before)
+	assert.JSONEq(t, `{"id":"t-1","title":"Write docs"}`, string(logs[0].After))
+
+	assert.Equal(t, "PATCH /tasks/{id}", logs[1].Endpoint)
+	assert.Equal(t, "/tasks/t-1", logs[1].Path)
+	assert.Equal(t, "t-1", logs[1].EntityID)
+	assert.JSONEq(t, `{"id":"t-1","status":"todo"}`, string(logs[1].Before))
+	assert.JSONEq(t, `{"id":"t-1","status":"done"}`, string(logs[1].After))
+}
@@ -0,0 +1,224 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+)
+
+// TokenVerifier resolves a bearer token to the principal it belongs to
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (*auth.Principal, error)
+}
+
+// ProxyScopes are granted to the users named by the sign-in proxy
+var ProxyScopes = []auth.Scope{auth.ScopeReadTasks, auth.ScopeWriteTasks, auth.ScopeReadStats}
+
+// isJWT reports whether a bearer token is a JWT session token rather than
+// an API token, which never contains a dot
+func isJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// userAgentLimit is the longest user agent recorded on a session
+const userAgentLimit = 512
+
+// Client notes the caller's address and user agent for the sign-ins made
+// by the request, which record them on the session they start
+func Client(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agent := r.UserAgent()
+		if len(agent) > userAgentLimit {
+			agent = strings.ToValidUTF8(agent[:userAgentLimit], "")
+		}
+		ctx := auth.WithClient(r.Context(), auth.Client{IP: clientKey(r), UserAgent: agent})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// authenticatedKey marks a request context Authenticate has run for
+type authenticatedKey struct{}
+
+// Authenticate returns a middleware that attaches the request's principal,
+// taken from, in order: an Authorization: Bearer API token or session
+// token, the admin token (user "admin" with every scope), or the user
+// named by the trusted proxy in AUTH_USER_HEADER (the session scopes).
+// The header is only believed on connections from AUTH_TRUSTED_PROXIES.
+// Requests with none stay anonymous; presenting a bad token is rejected
+// outright. sessions verifies the JWTs issued by POST /auth/login and is
+// nil when password sign-in is disabled. Sign-ins, token uses and failed
+// attempts are reported to security. A request Authenticate already ran
+// for, such as a batch sub-request carrying the batch request's context,
+// keeps the outcome, anonymous or not, rather than signing in again.
+func Authenticate(cfg *config.AuthConfig, admin *config.AdminConfig, tokens, sessions TokenVerifier, security SecurityRecorder) func(next http.Handler) http.Handler {
+	proxies := ParseProxies(cfg.TrustedProxies, "AUTH_TRUSTED_PROXIES")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Context().Value(authenticatedKey{}) != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			r = r.WithContext(context.WithValue(r.Context(), authenticatedKey{}, true))
+
+			var principal *auth.Principal
+
+			if header := r.Header.Get("Authorization"); header != "" {
+				token, ok := strings.CutPrefix(header, "Bearer ")
+				token = strings.TrimSpace(token)
+
+				verifier, credential := tokens, model.CredentialAPIToken
+				if isJWT(token) && sessions != nil {
+					verifier, credential = sessions, model.CredentialSession
+				}
+				failed := func(reason string) {
+					event := SecurityEvent(r, model.SecurityLoginFailed, reason)
+					event.Credential = credential
+					recordSecurity(security, r, event)
+				}
+
+				if !ok {
+					failed("malformed authorization header")
+					pkg.Unauthorized(w, "Authorization must be a Bearer token")
+					return
+				}
+
+				var err error
+				if principal, err = verifier.Verify(r.Context(), token); err != nil {
+					if errors.Is(err, auth.ErrInvalidToken) {
+						failed("invalid or expired token")
+						pkg.Unauthorized(w, "Invalid or expired token")
+						return
+					}
+					pkg.InternalError(w, "Failed to verify token")
+					return
+				}
+				r = r.WithContext(auth.WithPrincipal(r.Context(), principal))
+				if credential == model.CredentialSession {
+					event := SecurityEvent(r, model.SecurityLoginSucceeded, "")
+					event.Credential = credential
+					recordSecurity(security, r, event)
+				} else {
+					recordSecurity(security, r, SecurityEvent(r, model.SecurityTokenUsed, ""))
+				}
+			} else if IsAdmin(r, admin) {
+				principal = &auth.Principal{User: "admin", Scopes: auth.Scopes()}
+				r = r.WithContext(auth.WithPrincipal(r.Context(), principal))
+				event := SecurityEvent(r, model.SecurityLoginSucceeded, "")
+				event.Credential = model.CredentialAdminToken
+				recordSecurity(security, r, event)
+			} else if admin.Token != "" && r.Header.Get(AdminTokenHeader) != "" {
+				// Continue anonymously, as before, but keep a trace of the guess
+				event := SecurityEvent(r, model.SecurityLoginFailed, "invalid admin token")
+				event.Credential = model.CredentialAdminToken
+				recordSecurity(security, r, event)
+			} else if cfg.UserHeader != "" && r.Header.Get(cfg.UserHeader) != "" && !proxies.Trusts(r) {
+				// Anyone can send the header, only the proxy is believed
+				event := SecurityEvent(r, model.SecurityLoginFailed, "user header from an untrusted address")
+				event.Credential = model.CredentialProxy
+				recordSecurity(security, r, event)
+			} else if cfg.UserHeader != "" {
+				if user := strings.TrimSpace(r.Header.Get(cfg.UserHeader)); user != "" {
+					principal = &auth.Principal{User: user, Scopes: ProxyScopes}
+					r = r.WithContext(auth.WithPrincipal(r.Context(), principal))
+					event := SecurityEvent(r, model.SecurityLoginSucceeded, "")
+					event.Credential = model.CredentialProxy
+					recordSecurity(security, r, event)
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Authorize returns a middleware that requires tasks:read for safe methods
+// and tasks:write for everything else. Anonymous requests pass unless
+// AUTH_REQUIRED is set or they name a tenant other than TENANT_DEFAULT.
+// Rejections are reported to security.
+func Authorize(cfg *config.AuthConfig, security SecurityRecorder) func(next http.Handler) http.Handler {
+	return AuthorizeRoutes(cfg, security, TaskScope)
+}
+
+// AuthorizeRoutes is Authorize with the scope each request needs picked
+// by scopeOf, e.g. stats:read for the stats routes of a group
+func AuthorizeRoutes(cfg *config.AuthConfig, security SecurityRecorder, scopeOf func(*http.Request) auth.Scope) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := auth.FromContext(r.Context())
+			if principal == nil {
+				if cfg.Required {
+					recordSecurity(security, r, SecurityEvent(r, model.SecurityPermissionDenied, "authentication required"))
+					pkg.Unauthorized(w, "Authentication required")
+					return
+				}
+				// Only members reach a tenant other than TENANT_DEFAULT
+				if tenant.Claimed(r.Context()) {
+					recordSecurity(security, r, SecurityEvent(r, model.SecurityPermissionDenied, "anonymous request for tenant "+tenant.From(r.Context())))
+					pkg.Unauthorized(w, "Sign in to use this tenant")
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			scope := scopeOf(r)
+			if !principal.Has(scope) {
+				recordSecurity(security, r, SecurityEvent(r, model.SecurityPermissionDenied, "missing scope "+string(scope)))
+				pkg.Forbidden(w, "Token lacks the "+string(scope)+" scope")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// TaskScope is the scope a task request needs: tasks:read for safe
+// methods, tasks:write for everything else
+func TaskScope(r *http.Request) auth.Scope {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return auth.ScopeReadTasks
+	}
+	return auth.ScopeWriteTasks
+}
+
+// RequireAuth returns a middleware that rejects anonymous requests,
+// reporting them to security
+func RequireAuth(security SecurityRecorder) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if auth.FromContext(r.Context()) == nil {
+				recordSecurity(security, r, SecurityEvent(r, model.SecurityPermissionDenied, "authentication required"))
+				pkg.Unauthorized(w, "Authentication required")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// UserRecorder records the users who authenticate
+type UserRecorder interface {
+	Seen(ctx context.Context, user string)
+}
+
+// TrackUsers returns a middleware that adds every authenticated caller to
+// the user directory tasks are assigned from
+func TrackUsers(users UserRecorder) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if principal := auth.FromContext(r.Context()); principal != nil && principal.User != "" {
+				users.Seen(r.Context(), principal.User)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
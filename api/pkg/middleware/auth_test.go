@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorizeRoutes(t *testing.T) {
+	var events recordedEvents
+	scopeOf := func(r *http.Request) auth.Scope {
+		if r.URL.Path == "/tasks/stats" {
+			return auth.ScopeReadStats
+		}
+		return TaskScope(r)
+	}
+	handler := AuthorizeRoutes(&config.AuthConfig{}, &events, scopeOf)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	dashboard := &auth.Principal{User: "grafana", Scopes: []auth.Scope{auth.ScopeReadStats}}
+	ci := &auth.Principal{User: "ci", Scopes: []auth.Scope{auth.ScopeReadTasks, auth.ScopeWriteTasks}}
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		method    string
+		path      string
+		want      int
+	}{
+		{"stats with stats:read", dashboard, http.MethodGet, "/tasks/stats", http.StatusOK},
+		{"tasks with stats:read only", dashboard, http.MethodGet, "/tasks", http.StatusForbidden},
+		{"stats without stats:read", ci, http.MethodGet, "/tasks/stats", http.StatusForbidden},
+		{"write with tasks:write", ci, http.MethodPost, "/tasks", http.StatusOK},
+		{"anonymous", nil, http.MethodGet, "/tasks", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.principal != nil {
+				req = req.WithContext(auth.WithPrincipal(req.Context(), tt.principal))
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+
+	// Anonymous requests only claiming a tenant must sign in
+	req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+	req = req.WithContext(tenant.Claim(tenant.With(req.Context(), "acme")))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestAuthenticate_UserHeader(t *testing.T) {
+	var events recordedEvents
+	cfg := &config.AuthConfig{UserHeader: "X-Forwarded-User", TrustedProxies: []string{"10.0.0.0/8", "bad"}}
+	var user string
+	handler := Authenticate(cfg, &config.AdminConfig{}, nil, nil, &events)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user = ""
+		if principal := auth.FromContext(r.Context()); principal != nil {
+			user = principal.User
+		}
+	}))
+	handler = Peer(chimw.RealIP(handler))
+
+	do := func(remote, forwardedFor string) string {
+		req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		req.RemoteAddr = remote
+		req.Header.Set("X-Forwarded-User", "alice")
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		return user
+	}
+
+	assert.Equal(t, "alice", do("10.1.2.3:5000", "203.0.113.9"))
+	assert.Len(t, events.ofType(model.SecurityLoginSucceeded), 1)
+
+	// A client naming itself, directly or behind a forged forwarding header
+	assert.Empty(t, do("203.0.113.9:5000", ""))
+	assert.Empty(t, do("203.0.113.9:5000", "10.1.2.3"))
+	assert.Len(t, events.ofType(model.SecurityLoginFailed), 2)
+}
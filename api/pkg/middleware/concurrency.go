@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/metrics"
+	"github.com/moabdelazem/mutlitier_app/pkg/tracing"
+)
+
+// tenantSemaphore bounds the in-flight requests of one tenant. users
+// counts the requests holding or waiting for a slot, so the semaphore can
+// be dropped once the tenant goes idle.
+type tenantSemaphore struct {
+	slots chan struct{}
+	users int
+}
+
+// tenantSemaphores creates one semaphore per tenant sized by its plan,
+// keeping only those of tenants with requests in flight, so the map stays
+// bounded however many tenants come and go
+type tenantSemaphores struct {
+	mu    sync.Mutex
+	slots map[string]*tenantSemaphore
+}
+
+// acquire returns the semaphore of id, creating it with limit slots, and
+// counts the caller as a user until it calls release
+func (t *tenantSemaphores) acquire(id string, limit int) chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	sem, ok := t.slots[id]
+	if !ok {
+		sem = &tenantSemaphore{slots: make(chan struct{}, limit)}
+		t.slots[id] = sem
+	}
+	sem.users++
+	return sem.slots
+}
+
+// release stops counting a user of id's semaphore, dropping it when no
+// request holds or waits for it anymore
+func (t *tenantSemaphores) release(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if sem, ok := t.slots[id]; ok {
+		if sem.users--; sem.users == 0 {
+			delete(t.slots, id)
+		}
+	}
+}
+
+// TenantConcurrency returns a middleware that caps in-flight requests per
+// tenant so one noisy tenant cannot exhaust the shared database pool.
+// Requests queue for up to MaxWait before being rejected with the status
+// configured for the tenant's plan. The tenant is the one the Tenant
+// middleware resolved, never a header the client sets; requests outside
+// any tenant share the default tenant's slots.
+func TenantConcurrency(cfg *config.ConcurrencyConfig) func(next http.Handler) http.Handler {
+	semaphores := &tenantSemaphores{slots: make(map[string]*tenantSemaphore)}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := tenant.From(r.Context())
+			if id == "" {
+				id = tenant.Default
+			}
+			plan := cfg.Plan(id)
+			sem := semaphores.acquire(id, plan.Limit)
+			defer semaphores.release(id)
+
+			start := time.Now()
+			traceID := tracing.SampledTraceID(r.Context())
+			timer := time.NewTimer(cfg.MaxWait)
+			defer timer.Stop()
+
+			// Queued requests are an autoscaling signal, see metrics.RequestQueueDepth
+			metrics.RequestQueueDepth.Inc()
+			select {
+			case sem <- struct{}{}:
+				metrics.RequestQueueDepth.Dec()
+				metrics.ObserveWithTrace(metrics.TenantQueueWait.WithLabelValues(plan.Name), time.Since(start).Seconds(), traceID)
+			case <-timer.C:
+				metrics.RequestQueueDepth.Dec()
+				metrics.ObserveWithTrace(metrics.TenantQueueWait.WithLabelValues(plan.Name), time.Since(start).Seconds(), traceID)
+				metrics.TenantConcurrencyRejected.WithLabelValues(plan.Name, strconv.Itoa(plan.RejectStatus)).Inc()
+				rejectConcurrency(w, plan.RejectStatus)
+				return
+			case <-r.Context().Done():
+				metrics.RequestQueueDepth.Dec()
+				return
+			}
+			defer func() { <-sem }()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func rejectConcurrency(w http.ResponseWriter, status int) {
+	w.Header().Set("Retry-After", "1")
+	if status == http.StatusServiceUnavailable {
+		pkg.ServiceUnavailable(w, pkg.ErrorResponse{Error: "Too many concurrent requests for tenant"})
+		return
+	}
+	pkg.TooManyRequests(w, "Too many concurrent requests for tenant")
+}
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingHandler holds each request until release is closed, signalling
+// entered as requests get in
+func blockingHandler(entered chan<- struct{}, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// serveTenant serves a request of tenant id in the background, delivering
+// its status on the returned channel
+func serveTenant(handler http.Handler, id string) <-chan int {
+	status := make(chan int, 1)
+	go func() {
+		req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		if id != "" {
+			req = req.WithContext(tenant.With(req.Context(), id))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		status <- rec.Code
+	}()
+	return status
+}
+
+func TestTenantConcurrency_Queue(t *testing.T) {
+	cfg := &config.ConcurrencyConfig{MaxWait: 5 * time.Second, DefaultPlan: "free",
+		Plans: []config.ConcurrencyPlan{{Name: "free", Limit: 1, RejectStatus: http.StatusTooManyRequests}}}
+	entered, release := make(chan struct{}, 2), make(chan struct{})
+	handler := TenantConcurrency(cfg)(blockingHandler(entered, release))
+
+	first := serveTenant(handler, "acme")
+	<-entered
+	second := serveTenant(handler, "acme")
+
+	// The second request waits for the first one's slot
+	select {
+	case <-entered:
+		t.Fatal("second request ran while the tenant's only slot was taken")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	assert.Equal(t, http.StatusNoContent, <-first)
+	<-entered
+	assert.Equal(t, http.StatusNoContent, <-second)
+}
+
+func TestTenantConcurrency_Plans(t *testing.T) {
+	cfg := &config.ConcurrencyConfig{MaxWait: 20 * time.Millisecond, DefaultPlan: "free",
+		Plans: []config.ConcurrencyPlan{
+			{Name: "free", Limit: 1, RejectStatus: http.StatusTooManyRequests},
+			{Name: "pro", Limit: 2, RejectStatus: http.StatusServiceUnavailable},
+		},
+		TenantPlans: map[string]string{"acme": "pro"},
+	}
+	entered, release := make(chan struct{}, 4), make(chan struct{})
+	handler := TenantConcurrency(cfg)(blockingHandler(entered, release))
+
+	// Each tenant gets its plan's slots, and is rejected with its status
+	// once they are taken
+	held := []<-chan int{serveTenant(handler, "acme"), serveTenant(handler, "acme"), serveTenant(handler, "globex")}
+	for range held {
+		<-entered
+	}
+	assert.Equal(t, http.StatusServiceUnavailable, <-serveTenant(handler, "acme"))
+	assert.Equal(t, http.StatusTooManyRequests, <-serveTenant(handler, "globex"))
+
+	// A request outside any tenant counts against the default tenant,
+	// whatever header it sends
+	req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+	req.Header.Set("X-Tenant-ID", "acme")
+	held = append(held, serveTenant(handler, ""))
+	<-entered
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+
+	close(release)
+	for _, status := range held {
+		assert.Equal(t, http.StatusNoContent, <-status)
+	}
+}
+
+func TestTenantSemaphores_Evict(t *testing.T) {
+	semaphores := &tenantSemaphores{slots: make(map[string]*tenantSemaphore)}
+
+	sem := semaphores.acquire("acme", 1)
+	assert.Equal(t, sem, semaphores.acquire("acme", 1))
+	semaphores.release("acme")
+	require.Len(t, semaphores.slots, 1)
+
+	// The semaphore goes with its last user
+	semaphores.release("acme")
+	assert.Empty(t, semaphores.slots)
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/metrics"
+)
+
+// FailoverDetector reports database failovers, see database.Failover
+type FailoverDetector interface {
+	Active() bool
+	RetryAfter() time.Duration
+	Wait(ctx context.Context, limit time.Duration) bool
+}
+
+// Failover returns a middleware that holds back writes while the database
+// fails over. Reads pass, since the database retries them on the replica.
+// Writes that are safe to repeat (PUT, DELETE, and requests carrying an
+// Idempotency-Key or If-Match) queue for up to FAILOVER_WRITE_WAIT for the
+// failover to end; the rest, and those still waiting then, get 503 with
+// Retry-After.
+func Failover(cfg *config.FailoverConfig, detector FailoverDetector) func(next http.Handler) http.Handler {
+	var queued atomic.Int64
+
+	return func(next http.Handler) http.Handler {
+		if !cfg.Enabled {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !detector.Active() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if idempotentWrite(r) {
+				if queued.Add(1) <= int64(cfg.MaxQueued) {
+					recovered := detector.Wait(r.Context(), cfg.WriteWait)
+					queued.Add(-1)
+					if recovered {
+						metrics.FailoverRequests.WithLabelValues("queued").Inc()
+						next.ServeHTTP(w, r)
+						return
+					}
+				} else {
+					queued.Add(-1)
+				}
+			}
+
+			metrics.FailoverRequests.WithLabelValues("rejected").Inc()
+			w.Header().Set("Retry-After", strconv.Itoa(int(detector.RetryAfter().Seconds())+1))
+			pkg.ServiceUnavailable(w, pkg.ErrorResponse{Error: "Database failover in progress, retry later"})
+		})
+	}
+}
+
+// idempotentWrite reports whether repeating r cannot apply it twice
+func idempotentWrite(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return r.Header.Get(IdempotencyKeyHeader) != "" || r.Header.Get("If-Match") != ""
+}
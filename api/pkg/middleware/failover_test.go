@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubFailover is failing over until recovers is set
+type stubFailover struct {
+	active   bool
+	recovers bool
+}
+
+func (f *stubFailover) Active() bool              { return f.active }
+func (f *stubFailover) RetryAfter() time.Duration { return 12500 * time.Millisecond }
+func (f *stubFailover) Wait(ctx context.Context, limit time.Duration) bool {
+	return f.recovers
+}
+
+func TestFailover(t *testing.T) {
+	cfg := &config.FailoverConfig{Enabled: true, WriteWait: time.Second, MaxQueued: 10}
+	detector := &stubFailover{}
+	handler := Failover(cfg, detector)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	serve := func(method string, header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/tasks/a", nil)
+		for i := 0; i < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodPost).Code)
+
+	detector.active = true
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodGet).Code)
+
+	rec := serve(http.MethodPost)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "13", rec.Header().Get("Retry-After"))
+
+	// Idempotent writes wait for the failover and then pass
+	assert.Equal(t, http.StatusServiceUnavailable, serve(http.MethodPut).Code)
+	detector.recovers = true
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodPut).Code)
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodDelete).Code)
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodPost, IdempotencyKeyHeader, "k1").Code)
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodPatch, "If-Match", `"3"`).Code)
+	assert.Equal(t, http.StatusServiceUnavailable, serve(http.MethodPatch).Code)
+
+	// No queue room left sends them away as well
+	cfg.MaxQueued = 0
+	handler = Failover(cfg, detector)(handler)
+	assert.Equal(t, http.StatusServiceUnavailable, serve(http.MethodPut).Code)
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/features"
+	"github.com/moabdelazem/mutlitier_app/pkg/metrics"
+)
+
+// Feature toggle headers
+const (
+	FeatureFlagsHeader   = "X-Feature-Flags"
+	FeatureVariantHeader = "X-Feature-Variant"
+)
+
+// FeatureToggles returns a middleware that lets trusted clients opt a request
+// into flagged code paths with X-Feature-Flags. Only admin-authenticated
+// requests are honored and only flags listed in FEATURE_TOGGLES_ALLOWED are
+// enabled. The chosen variant is echoed in X-Feature-Variant, logged and counted.
+func FeatureToggles(cfg *config.FeatureToggleConfig, admin *config.AdminConfig) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get(FeatureFlagsHeader)
+			if header == "" || !IsAdmin(r, admin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			flags := features.Parse(header, cfg.Allowed)
+			variant := features.Variant(flags)
+
+			metrics.FeatureVariantRequests.WithLabelValues(variant).Inc()
+			w.Header().Set(FeatureVariantHeader, variant)
+
+			next.ServeHTTP(w, r.WithContext(features.With(r.Context(), flags)))
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+)
+
+// Idempotency headers
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	idempotencyPendingPayload = "pending"
+)
+
+// storedResponse is the response kept for replay under an idempotency
+// key, with the hash of the request body it answered
+type storedResponse struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+	BodyHash    string `json:"body_hash"`
+}
+
+// Idempotency returns a middleware that makes POST requests carrying an
+// Idempotency-Key safe to retry: the first response is stored and replayed
+// for repeated requests with the same key and body. Keys are scoped to the
+// tenant and principal, since clients choose them; reusing one with a
+// different body is refused with 422.
+func Idempotency(cfg *config.IdempotencyConfig, store kvstore.Store) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			idempotencyKey := r.Header.Get(IdempotencyKeyHeader)
+			if r.Method != http.MethodPost || idempotencyKey == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+			key := idempotencyStoreKey(r, idempotencyKey)
+
+			acquired, err := store.SetNX(ctx, key, []byte(idempotencyPendingPayload), cfg.TTL)
+			if err != nil {
+				pkg.InternalError(w, "Failed to check idempotency key")
+				return
+			}
+
+			if !acquired {
+				replayResponse(w, r, store, key)
+				return
+			}
+
+			// The pending marker goes unless a response replaces it, so a
+			// failed or panicking request can be retried
+			stored := false
+			defer func() {
+				if !stored {
+					store.Delete(context.WithoutCancel(ctx), key)
+				}
+			}()
+
+			// The body is hashed as the handler reads it, and whatever it
+			// leaves unread afterwards
+			hash := sha256.New()
+			body := r.Body
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.TeeReader(body, hash), body}
+
+			// Capture the response so it can be replayed later
+			var response bytes.Buffer
+			ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&response)
+
+			next.ServeHTTP(ww, r)
+
+			// Server errors are not cached so the client can retry
+			if ww.Status() >= http.StatusInternalServerError {
+				return
+			}
+			if _, err := io.Copy(hash, body); err != nil {
+				return
+			}
+
+			payload, err := json.Marshal(storedResponse{
+				Status:      ww.Status(),
+				ContentType: ww.Header().Get("Content-Type"),
+				Body:        response.Bytes(),
+				BodyHash:    hex.EncodeToString(hash.Sum(nil)),
+			})
+			if err == nil && store.Set(ctx, key, payload, cfg.TTL) == nil {
+				stored = true
+			}
+		})
+	}
+}
+
+// idempotencyStoreKey returns the store key of a client's idempotency key:
+// a hash of the key with the tenant, principal and path it was used for
+func idempotencyStoreKey(r *http.Request, idempotencyKey string) string {
+	user := ""
+	if principal := auth.FromContext(r.Context()); principal != nil {
+		user = principal.User
+	}
+	scope := sha256.Sum256([]byte(strings.Join([]string{tenant.From(r.Context()), user, r.URL.Path, idempotencyKey}, "\x00")))
+	return "idempotency:" + hex.EncodeToString(scope[:])
+}
+
+// replayResponse writes the stored response for key, 409 if the original
+// request is still being processed, or 422 if it had another body
+func replayResponse(w http.ResponseWriter, r *http.Request, store kvstore.Store, key string) {
+	payload, ok, err := store.Get(r.Context(), key)
+	if err != nil {
+		pkg.InternalError(w, "Failed to check idempotency key")
+		return
+	}
+	if !ok || string(payload) == idempotencyPendingPayload {
+		pkg.Conflict(w, "A request with this idempotency key is already in progress")
+		return
+	}
+
+	var stored storedResponse
+	if err := json.Unmarshal(payload, &stored); err != nil {
+		pkg.InternalError(w, "Failed to replay idempotent response")
+		return
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, r.Body); err != nil {
+		pkg.BadRequest(w, "Failed to read request body")
+		return
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != stored.BodyHash {
+		pkg.UnprocessableEntity(w, "This idempotency key was already used with a different request body")
+		return
+	}
+
+	if stored.ContentType != "" {
+		w.Header().Set("Content-Type", stored.ContentType)
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(stored.Status)
+	w.Write(stored.Body)
+}
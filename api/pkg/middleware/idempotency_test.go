@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotency(t *testing.T) {
+	calls := 0
+	handler := Idempotency(&config.IdempotencyConfig{TTL: time.Hour}, kvstore.NewMemory())(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			body, _ := io.ReadAll(r.Body)
+			if string(body) == "panic" {
+				panic("handler failed")
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write(body)
+		}))
+
+	post := func(user, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+		req.Header.Set(IdempotencyKeyHeader, key)
+		if user != "" {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), &auth.Principal{User: user}))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// A retry with the same body replays the first response
+	rec := post("alice", "k1", `{"title":"a"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	rec = post("alice", "k1", `{"title":"a"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "true", rec.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, `{"title":"a"}`, rec.Body.String())
+	assert.Equal(t, 1, calls)
+
+	// The same key with another body is refused
+	rec = post("alice", "k1", `{"title":"b"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, 1, calls)
+
+	// Keys are per principal: another user's key is their own
+	rec = post("bob", "k1", `{"title":"b"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Empty(t, rec.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, `{"title":"b"}`, rec.Body.String())
+	rec = post("", "k1", `{"title":"c"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, 3, calls)
+
+	// A request that panics leaves no pending marker behind
+	require.Panics(t, func() { post("alice", "k2", "panic") })
+	rec = post("alice", "k2", `{"title":"d"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, 5, calls)
+}
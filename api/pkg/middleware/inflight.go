@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"runtime/pprof"
+	"slices"
+	"sync"
+	"time"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/pkg/metrics"
+)
+
+// InFlight returns a middleware that counts requests in flight for the
+// autoscaling gauges. It runs before rate limiting and concurrency limits
+// so queued and rejected requests still show up as load.
+func InFlight(cfg *config.AutoscalingConfig) func(next http.Handler) http.Handler {
+	metrics.SetCapacity(cfg.Capacity)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			done := metrics.TrackInFlight()
+			defer done()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// goroutineLabel is the pprof label set on the goroutines serving a
+// request, so they can be told apart in goroutine and CPU profiles
+const goroutineLabel = "request_id"
+
+// InFlightRequest describes a request that has not finished yet
+type InFlightRequest struct {
+	Method          string            `json:"method"`
+	Path            string            `json:"path"`
+	RequestID       string            `json:"request_id"`
+	GoroutineLabels map[string]string `json:"goroutine_labels"`
+	StartedAt       time.Time         `json:"started_at"`
+	DurationSeconds float64           `json:"duration_seconds"`
+	// Cancelled is set once the client went away or the request timed
+	// out while the handler is still running, the usual sign of a hang
+	Cancelled bool `json:"cancelled"`
+}
+
+// RequestTracker keeps every request in flight, registered with its
+// context, so stuck requests can be listed without a goroutine dump
+type RequestTracker struct {
+	mu       sync.Mutex
+	next     uint64
+	requests map[uint64]*trackedRequest
+}
+
+type trackedRequest struct {
+	ctx       context.Context
+	method    string
+	path      string
+	requestID string
+	start     time.Time
+}
+
+// NewRequestTracker creates an empty RequestTracker
+func NewRequestTracker() *RequestTracker {
+	return &RequestTracker{requests: make(map[uint64]*trackedRequest)}
+}
+
+// Middleware registers each request until it returns and labels its
+// goroutine with the request ID. It belongs after RequestID and Timeout,
+// so the ID is known and timeouts show up as cancelled.
+func (t *RequestTracker) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := chimw.GetReqID(r.Context())
+		pprof.Do(r.Context(), pprof.Labels(goroutineLabel, requestID), func(ctx context.Context) {
+			done := t.add(&trackedRequest{
+				ctx:       ctx,
+				method:    r.Method,
+				path:      r.URL.Path,
+				requestID: requestID,
+				start:     time.Now(),
+			})
+			defer done()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+}
+
+func (t *RequestTracker) add(request *trackedRequest) func() {
+	t.mu.Lock()
+	id := t.next
+	t.next++
+	t.requests[id] = request
+	t.mu.Unlock()
+
+	return func() {
+		t.mu.Lock()
+		delete(t.requests, id)
+		t.mu.Unlock()
+	}
+}
+
+// List returns the requests in flight for at least minAge, oldest first
+func (t *RequestTracker) List(minAge time.Duration) []InFlightRequest {
+	now := time.Now()
+
+	t.mu.Lock()
+	requests := make([]InFlightRequest, 0, len(t.requests))
+	for _, request := range t.requests {
+		age := now.Sub(request.start)
+		if age < minAge {
+			continue
+		}
+		requests = append(requests, InFlightRequest{
+			Method:          request.method,
+			Path:            request.path,
+			RequestID:       request.requestID,
+			GoroutineLabels: map[string]string{goroutineLabel: request.requestID},
+			StartedAt:       request.start.UTC(),
+			DurationSeconds: age.Seconds(),
+			Cancelled:       request.ctx.Err() != nil,
+		})
+	}
+	t.mu.Unlock()
+
+	slices.SortFunc(requests, func(a, b InFlightRequest) int {
+		return a.StartedAt.Compare(b.StartedAt)
+	})
+	return requests
+}
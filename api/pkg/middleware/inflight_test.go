@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime/pprof"
+	"testing"
+	"time"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInFlight_Utilization(t *testing.T) {
+	var during float64
+	handler := InFlight(&config.AutoscalingConfig{Capacity: 4})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		during = testutil.ToFloat64(metrics.InFlightUtilization)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/tasks", nil))
+
+	assert.Equal(t, 0.25, during)
+	assert.Equal(t, 4.0, testutil.ToFloat64(metrics.InFlightCapacity))
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.InFlightRequests))
+}
+
+func TestRequestTracker_ListsRequestsUntilTheyReturn(t *testing.T) {
+	tracker := NewRequestTracker()
+	started, release := make(chan string), make(chan struct{})
+	handler := chimw.RequestID(tracker.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		label, _ := pprof.Label(r.Context(), "request_id")
+		started <- label
+		<-release
+	})))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		req := httptest.NewRequest(http.MethodPatch, "/tasks/a", nil).WithContext(ctx)
+		req.Header.Set(chimw.RequestIDHeader, "req-1")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+	assert.Equal(t, "req-1", <-started)
+
+	requests := tracker.List(0)
+	require.Len(t, requests, 1)
+	assert.Equal(t, http.MethodPatch, requests[0].Method)
+	assert.Equal(t, "/tasks/a", requests[0].Path)
+	assert.Equal(t, "req-1", requests[0].RequestID)
+	assert.Equal(t, map[string]string{"request_id": "req-1"}, requests[0].GoroutineLabels)
+	assert.False(t, requests[0].Cancelled)
+	assert.Empty(t, tracker.List(time.Hour))
+
+	// A client that gave up on a request still being served
+	cancel()
+	assert.True(t, tracker.List(0)[0].Cancelled)
+
+	close(release)
+	<-done
+	assert.Empty(t, tracker.List(0))
+}
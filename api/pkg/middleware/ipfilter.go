@@ -0,0 +1,224 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+// IP filter scopes
+const (
+	IPScopeGlobal = "global"
+	IPScopeAdmin  = "admin"
+)
+
+// IPRules are the allow and deny rules of one scope
+type IPRules struct {
+	Allow []netip.Prefix
+	Deny  []netip.Prefix
+}
+
+// denies reports whether addr is rejected, and by which rule. Deny rules
+// win; with allow rules, addresses matching none of them are rejected.
+func (r *IPRules) denies(addr netip.Addr, ok bool) (string, bool) {
+	if ok {
+		for _, prefix := range r.Deny {
+			if prefix.Contains(addr) {
+				return "deny " + prefix.String(), true
+			}
+		}
+	}
+	if len(r.Allow) == 0 {
+		return "", false
+	}
+	if ok {
+		for _, prefix := range r.Allow {
+			if prefix.Contains(addr) {
+				return "", false
+			}
+		}
+	}
+	return "not in allow list", true
+}
+
+// IPFilter rejects requests by client IP with CIDR rules from the
+// environment and, optionally, a rules file that is reloaded when it
+// changes, so a mounted ConfigMap can be edited without a restart.
+// Rejections are answered with 403 Forbidden and reported to security.
+type IPFilter struct {
+	cfg      *config.IPFilterConfig
+	security SecurityRecorder
+	rules    atomic.Pointer[map[string]*IPRules]
+
+	// Last seen rules file, only touched by Reload
+	fileMod  time.Time
+	fileSize int64
+}
+
+// NewIPFilter creates an IPFilter with the configured rules. Malformed
+// rules are logged and skipped; an unreadable rules file leaves only the
+// environment rules in force until it can be read.
+func NewIPFilter(cfg *config.IPFilterConfig, security SecurityRecorder) *IPFilter {
+	f := &IPFilter{cfg: cfg, security: security}
+	if err := f.Reload(); err != nil {
+		logger.Get().Error().Err(err).Str("file", cfg.RulesFile).Msg("Failed to load IP rules file")
+	}
+	return f
+}
+
+// Middleware returns a middleware enforcing the rules of scope
+func (f *IPFilter) Middleware(scope string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rules := (*f.rules.Load())[scope]
+			if rules == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			addr, err := netip.ParseAddr(clientKey(r))
+			rule, denied := rules.denies(addr.Unmap(), err == nil)
+			if !denied {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			recordSecurity(f.security, r, SecurityEvent(r, model.SecurityPermissionDenied, "ip "+scope+" "+rule))
+			pkg.Forbidden(w, "Access from this address is not allowed")
+		})
+	}
+}
+
+// Reload rebuilds the rules from the environment and the rules file. If
+// the file cannot be read the previous rules stay in force.
+func (f *IPFilter) Reload() error {
+	log := logger.Get().WithComponent("ipfilter")
+
+	rules := map[string]*IPRules{}
+	add := func(scope, action, value, source string) {
+		prefix, err := parsePrefix(value)
+		if err != nil {
+			log.Error().Err(err).Str("source", source).Msg("Skipping invalid IP rule")
+			return
+		}
+		if rules[scope] == nil {
+			rules[scope] = &IPRules{}
+		}
+		if action == "allow" {
+			rules[scope].Allow = append(rules[scope].Allow, prefix)
+		} else {
+			rules[scope].Deny = append(rules[scope].Deny, prefix)
+		}
+	}
+
+	for _, value := range f.cfg.Allow {
+		add(IPScopeGlobal, "allow", value, "IP_ALLOW")
+	}
+	for _, value := range f.cfg.Deny {
+		add(IPScopeGlobal, "deny", value, "IP_DENY")
+	}
+	for _, value := range f.cfg.AdminAllow {
+		add(IPScopeAdmin, "allow", value, "IP_ADMIN_ALLOW")
+	}
+	for _, value := range f.cfg.AdminDeny {
+		add(IPScopeAdmin, "deny", value, "IP_ADMIN_DENY")
+	}
+
+	var loadErr error
+	if f.cfg.RulesFile != "" {
+		info, err := os.Stat(f.cfg.RulesFile)
+		var data []byte
+		if err == nil {
+			data, err = os.ReadFile(f.cfg.RulesFile)
+		}
+		if err != nil {
+			if f.rules.Load() != nil {
+				return fmt.Errorf("failed to read IP rules file: %w", err)
+			}
+			loadErr = fmt.Errorf("failed to read IP rules file: %w", err)
+		} else {
+			f.fileMod, f.fileSize = info.ModTime(), info.Size()
+
+			scanner := bufio.NewScanner(bytes.NewReader(data))
+			for line := 1; scanner.Scan(); line++ {
+				text, _, _ := strings.Cut(scanner.Text(), "#")
+				fields := strings.Fields(text)
+				if len(fields) == 0 {
+					continue
+				}
+				source := fmt.Sprintf("%s:%d", f.cfg.RulesFile, line)
+				if len(fields) != 3 ||
+					(fields[0] != IPScopeGlobal && fields[0] != IPScopeAdmin) ||
+					(fields[1] != "allow" && fields[1] != "deny") {
+					log.Error().Str("source", source).Str("rule", text).
+						Msg("Skipping invalid IP rule, expected \"global|admin allow|deny cidr\"")
+					continue
+				}
+				add(fields[0], fields[1], fields[2], source)
+			}
+		}
+	}
+
+	f.rules.Store(&rules)
+	return loadErr
+}
+
+// WatchEvery reloads the rules whenever the rules file changes, checking
+// on every interval until ctx is done. Kubernetes updates a mounted
+// ConfigMap by swapping a symlink, which shows up as a new modification time.
+func (f *IPFilter) WatchEvery(ctx context.Context, interval time.Duration) {
+	log := logger.Get().WithComponent("ipfilter")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(f.cfg.RulesFile)
+			if err != nil {
+				log.Warn().Err(err).Str("file", f.cfg.RulesFile).Msg("Failed to check IP rules file")
+				continue
+			}
+			if info.ModTime().Equal(f.fileMod) && info.Size() == f.fileSize {
+				continue
+			}
+			if err := f.Reload(); err != nil {
+				log.Error().Err(err).Str("file", f.cfg.RulesFile).Msg("Failed to reload IP rules, keeping the previous ones")
+				continue
+			}
+			log.Info().Str("file", f.cfg.RulesFile).Msg("Reloaded IP rules")
+		}
+	}
+}
+
+// parsePrefix parses a CIDR, or a bare address as a single-address prefix
+func parsePrefix(value string) (netip.Prefix, error) {
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
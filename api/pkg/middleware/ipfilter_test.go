@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPFilter_Rules(t *testing.T) {
+	cfg := &config.IPFilterConfig{
+		Deny:       []string{"203.0.113.0/24"},
+		AdminAllow: []string{"10.0.0.0/8", "192.0.2.7"},
+		AdminDeny:  []string{"10.9.0.0/16"},
+	}
+	var events recordedEvents
+	filter := NewIPFilter(cfg, &events)
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	global := filter.Middleware(IPScopeGlobal)(ok)
+	admin := filter.Middleware(IPScopeAdmin)(ok)
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		ip      string
+		want    int
+	}{
+		{"global allows anyone not denied", global, "198.51.100.1", http.StatusOK},
+		{"global deny", global, "203.0.113.9", http.StatusForbidden},
+		{"admin allow list", admin, "10.1.2.3", http.StatusOK},
+		{"admin single address", admin, "192.0.2.7", http.StatusOK},
+		{"admin not in allow list", admin, "198.51.100.1", http.StatusForbidden},
+		{"admin deny wins over allow", admin, "10.9.1.1", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/routes", nil)
+			req.RemoteAddr = tt.ip + ":1234"
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+
+	denied := events.ofType(model.SecurityPermissionDenied)
+	require.Len(t, denied, 3)
+	assert.Equal(t, "ip global deny 203.0.113.0/24", denied[0].Reason)
+	assert.Equal(t, "ip admin not in allow list", denied[1].Reason)
+}
+
+func TestIPFilter_ReloadsRulesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ip-rules")
+	require.NoError(t, os.WriteFile(path, []byte("# office only\nadmin allow 10.0.0.0/8\n"), 0o644))
+
+	filter := NewIPFilter(&config.IPFilterConfig{RulesFile: path}, nil)
+	handler := filter.Middleware(IPScopeAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	status := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/admin/routes", nil)
+		req.RemoteAddr = "192.0.2.7:1234"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusForbidden, status())
+
+	require.NoError(t, os.WriteFile(path, []byte("admin allow 10.0.0.0/8\nadmin allow 192.0.2.0/24\n"), 0o644))
+	require.NoError(t, filter.Reload())
+	assert.Equal(t, http.StatusOK, status())
+
+	// A missing file keeps the rules last loaded
+	require.NoError(t, os.Remove(path))
+	assert.Error(t, filter.Reload())
+	assert.Equal(t, http.StatusOK, status())
+}
+
+func TestIPFilter_TrustedProxies(t *testing.T) {
+	var events recordedEvents
+	filter := NewIPFilter(&config.IPFilterConfig{Deny: []string{"203.0.113.0/24"}}, &events)
+	var client string
+	handler := Peer(RealIP(ParseProxies([]string{"10.0.0.0/8"}, "TRUSTED_PROXIES"))(filter.Middleware(IPScopeGlobal)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { client = r.RemoteAddr }))))
+
+	tests := []struct {
+		name      string
+		peer      string
+		forwarded string
+		realIP    string
+		want      int
+		client    string
+	}{
+		{"direct client keeps its address", "198.51.100.1:1234", "", "", http.StatusOK, "198.51.100.1:1234"},
+		{"direct client cannot pick an address", "203.0.113.9:1234", "198.51.100.1", "198.51.100.1", http.StatusForbidden, ""},
+		{"proxy reports the client", "10.0.0.2:1234", "203.0.113.9", "", http.StatusForbidden, ""},
+		{"hops before an untrusted one are ignored", "10.0.0.2:1234", "198.51.100.1, 203.0.113.9, 10.0.0.3", "", http.StatusForbidden, ""},
+		{"proxy forwards an allowed client", "10.0.0.2:1234", "198.51.100.1, 10.0.0.3", "", http.StatusOK, "198.51.100.1"},
+		{"proxy reports X-Real-IP", "10.0.0.2:1234", "", "198.51.100.2", http.StatusOK, "198.51.100.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client = ""
+			req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			req.RemoteAddr = tt.peer
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.want, rec.Code)
+			assert.Equal(t, tt.client, client)
+		})
+	}
+}
@@ -32,12 +32,18 @@ func RequestLogger(log *logger.Logger) func(next http.Handler) http.Handler {
 			// Calculate duration
 			duration := time.Since(start)
 
-			// Log the request
-			logEvent := log.Info()
+			// Log the request, with its trace context from tracing.Middleware
+			reqLog := log.WithTrace(r.Context())
+			logEvent := reqLog.Info()
 			if ww.Status() >= 500 {
-				logEvent = log.Error()
+				logEvent = reqLog.Error()
 			} else if ww.Status() >= 400 {
-				logEvent = log.Warn()
+				logEvent = reqLog.Warn()
+			}
+
+			// Canary comparisons rely on the variant being in the request log
+			if variant := ww.Header().Get(FeatureVariantHeader); variant != "" {
+				logEvent = logEvent.Str("feature_variant", variant)
 			}
 
 			logEvent.
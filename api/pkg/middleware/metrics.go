@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/moabdelazem/mutlitier_app/pkg/metrics"
+	"github.com/moabdelazem/mutlitier_app/pkg/tracing"
+)
+
+// unmatchedRoute labels requests that matched no route, so scanners
+// probing random paths cannot blow up the metric's cardinality
+const unmatchedRoute = "unmatched"
+
+// Metrics records request latency per route pattern. Requests carrying a
+// sampled traceparent (see tracing.Middleware) attach their trace ID as
+// an exemplar.
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		route := routePattern(r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		observer := metrics.HTTPRequestDuration.WithLabelValues(r.Method, route, strconv.Itoa(status))
+		metrics.ObserveWithTrace(observer, time.Since(start).Seconds(), tracing.SampledTraceID(r.Context()))
+	})
+}
+
+// routePattern returns the matched route for metric labels. The pattern is
+// only complete once routing has finished, so call it after next.ServeHTTP.
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+		return rctx.RoutePattern()
+	}
+	return unmatchedRoute
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/moabdelazem/mutlitier_app/pkg/metrics"
+	"github.com/moabdelazem/mutlitier_app/pkg/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_Exemplars(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(tracing.Middleware)
+	r.Use(Metrics)
+	r.Get("/widgets/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	send := func(path, traceparent string) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if traceparent != "" {
+			req.Header.Set(tracing.TraceparentHeader, traceparent)
+		}
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Unsampled traces are recorded without exemplars
+	send("/widgets/1", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-00")
+	send("/widgets/2", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	send("/nowhere", "")
+
+	families, err := metrics.Registry.Gather()
+	require.NoError(t, err)
+
+	var exemplars []string
+	routes := map[string]uint64{}
+	for _, family := range families {
+		if family.GetName() != "http_request_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			routes[labels["route"]+" "+labels["status"]] += metric.GetHistogram().GetSampleCount()
+			for _, bucket := range metric.GetHistogram().GetBucket() {
+				if exemplar := bucket.GetExemplar(); exemplar != nil {
+					exemplars = append(exemplars, exemplar.GetLabel()[0].GetValue())
+				}
+			}
+		}
+	}
+
+	assert.Equal(t, uint64(2), routes["/widgets/{id} 418"])
+	assert.Equal(t, uint64(1), routes["unmatched 404"])
+	assert.Equal(t, []string{"4bf92f3577b34da6a3ce929d0e0e4736"}, exemplars)
+}
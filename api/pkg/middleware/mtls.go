@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+// Route groups client certificates can be restricted to. CertGroupGlobal
+// covers every route of the listener it is used on.
+const (
+	CertGroupGlobal   = "global"
+	CertGroupTasks    = "tasks"
+	CertGroupProjects = "projects"
+	CertGroupTags     = "tags"
+	CertGroupTeams    = "teams"
+	CertGroupEvents   = "events"
+	CertGroupAuth     = "auth"
+	CertGroupMe       = "me"
+	CertGroupAdmin    = "admin"
+)
+
+// CertGroups returns every route group known to MTLS_ALLOW
+func CertGroups() []string {
+	return []string{CertGroupGlobal, CertGroupTasks, CertGroupProjects, CertGroupTags, CertGroupTeams,
+		CertGroupEvents, CertGroupAuth, CertGroupMe, CertGroupAdmin}
+}
+
+// ClientCertificate puts the identity of the verified client certificate,
+// if the TLS handshake carried one, in the request context
+func ClientCertificate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+			cert := auth.CertificateOf(r.TLS.VerifiedChains[0][0])
+			r = r.WithContext(auth.WithCertificate(r.Context(), cert))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CertAllowlist restricts route groups to the client certificates whose
+// common name or a subject alternative name it lists. Groups it lists
+// nothing for admit any verified certificate. Rejections are answered
+// with 403 Forbidden and reported to security.
+type CertAllowlist struct {
+	allow    map[string][]string
+	security SecurityRecorder
+}
+
+// NewCertAllowlist creates a CertAllowlist from MTLS_ALLOW, logging the
+// groups it does not know
+func NewCertAllowlist(cfg *config.TLSConfig, security SecurityRecorder) *CertAllowlist {
+	for group := range cfg.Allow {
+		if !slices.Contains(CertGroups(), group) {
+			logger.Get().Warn().Str("group", group).Strs("known", CertGroups()).Msg("Ignoring MTLS_ALLOW entry for an unknown route group")
+		}
+	}
+	return &CertAllowlist{allow: cfg.Allow, security: security}
+}
+
+// Middleware returns a middleware admitting only the certificates listed
+// for group, or every request when none are
+func (a *CertAllowlist) Middleware(group string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		identities := a.allow[group]
+		if len(identities) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if auth.CertificateFrom(r.Context()).Matches(identities) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			recordSecurity(a.security, r, SecurityEvent(r, model.SecurityPermissionDenied, "certificate "+group+" not in allow list"))
+			pkg.Forbidden(w, "Client certificate is not allowed")
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCertAllowlist(t *testing.T) {
+	cfg := &config.TLSConfig{MTLS: true, Allow: map[string][]string{
+		CertGroupAdmin: {"ops-cli", "spiffe://cluster.local/ns/ops/sa/runner"},
+	}}
+	var events recordedEvents
+	certs := NewCertAllowlist(cfg, &events)
+
+	var seen *auth.Certificate
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = auth.CertificateFrom(r.Context())
+	})
+	admin := ClientCertificate(certs.Middleware(CertGroupAdmin)(ok))
+	tasks := ClientCertificate(certs.Middleware(CertGroupTasks)(ok))
+
+	runner, _ := url.Parse("spiffe://cluster.local/ns/ops/sa/runner")
+	tests := []struct {
+		name    string
+		handler http.Handler
+		cert    *x509.Certificate
+		want    int
+	}{
+		{"common name listed", admin, &x509.Certificate{Subject: pkix.Name{CommonName: "ops-cli"}}, http.StatusOK},
+		{"URI SAN listed", admin, &x509.Certificate{Subject: pkix.Name{CommonName: "runner"}, URIs: []*url.URL{runner}}, http.StatusOK},
+		{"not listed", admin, &x509.Certificate{Subject: pkix.Name{CommonName: "billing"}, DNSNames: []string{"billing.svc"}}, http.StatusForbidden},
+		{"no certificate", admin, nil, http.StatusForbidden},
+		{"group without allow list", tasks, &x509.Certificate{Subject: pkix.Name{CommonName: "billing"}}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/routes", nil)
+			if tt.cert != nil {
+				tt.cert.SerialNumber = big.NewInt(7)
+				req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{tt.cert}}}
+			}
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+
+	require.NotNil(t, seen)
+	assert.Equal(t, "billing", seen.CommonName)
+	assert.Equal(t, "7", seen.Serial)
+
+	denied := events.ofType(model.SecurityPermissionDenied)
+	require.Len(t, denied, 2)
+	assert.Equal(t, "certificate admin not in allow list", denied[0].Reason)
+}
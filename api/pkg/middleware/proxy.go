@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+type peerKey struct{}
+
+// Peer records the address of the connection a request came on, before
+// RealIP replaces it with the client address a proxy reports. A request
+// dispatched again within the process, such as a batch sub-request, keeps
+// the peer of the request it came from.
+func Peer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Value(peerKey{}).(string); ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), peerKey{}, r.RemoteAddr)))
+	})
+}
+
+// peerAddr returns the address of the connection r came on, its remote
+// address when Peer did not run
+func peerAddr(r *http.Request) (netip.Addr, bool) {
+	remote, ok := r.Context().Value(peerKey{}).(string)
+	if !ok {
+		remote = r.RemoteAddr
+	}
+	host, _, err := net.SplitHostPort(remote)
+	if err != nil {
+		host = remote
+	}
+	addr, err := netip.ParseAddr(host)
+	return addr.Unmap(), err == nil
+}
+
+// Proxies are the networks of the proxies trusted to say who the client is
+type Proxies []netip.Prefix
+
+// ParseProxies parses the CIDRs or addresses of trusted proxies read from
+// source. Malformed entries are logged and skipped.
+func ParseProxies(values []string, source string) Proxies {
+	proxies := make(Proxies, 0, len(values))
+	for _, value := range values {
+		prefix, err := parsePrefix(value)
+		if err != nil {
+			logger.Get().Error().Err(err).Str("source", source).Msg("Skipping invalid trusted proxy")
+			continue
+		}
+		proxies = append(proxies, prefix)
+	}
+	return proxies
+}
+
+// Trusts reports whether r came straight from a trusted proxy
+func (p Proxies) Trusts(r *http.Request) bool {
+	addr, ok := peerAddr(r)
+	return ok && p.contains(addr)
+}
+
+func (p Proxies) contains(addr netip.Addr) bool {
+	for _, prefix := range p {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// RealIP replaces the remote address of requests that came through a
+// trusted proxy with the client the proxies report: the nearest address
+// in X-Forwarded-For that is not a trusted proxy itself, or X-Real-IP.
+// Other requests keep the address of their connection, so clients cannot
+// pick the address IP rules, rate limits and logs see.
+func RealIP(proxies Proxies) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if proxies.Trusts(r) {
+				if client, ok := proxies.client(r); ok {
+					r.RemoteAddr = client.String()
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// client returns the client reported by the proxies r passed through
+func (p Proxies) client(r *http.Request) (netip.Addr, bool) {
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		// Each proxy appends the address it was reached from, so only the
+		// hops added by trusted proxies are believed
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		var client netip.Addr
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				break
+			}
+			client = addr.Unmap()
+			if !p.contains(client) {
+				break
+			}
+		}
+		return client, client.IsValid()
+	}
+
+	addr, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP")))
+	return addr.Unmap(), err == nil
+}
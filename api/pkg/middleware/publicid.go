@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/publicid"
+)
+
+// maxPublicIDBody caps request bodies read whole to translate their IDs
+const maxPublicIDBody = 16 << 20
+
+// maxPublicIDPending caps how much of a streamed response is held back
+// waiting for the rest of a UUID; a longer run of hex is written as is
+const maxPublicIDPending = 4 << 10
+
+// publicIDHeaders are response headers that may carry task URLs
+var publicIDHeaders = []string{"Location", "Content-Location", "Link"}
+
+type originalRequestKey struct{}
+
+// originalRequest is the request as the client sent it, before public IDs
+// were translated, which is what request signatures cover
+type originalRequest struct {
+	uri  string
+	body []byte
+}
+
+// PublicIDs returns a middleware that hides internal UUIDs from clients.
+// Public IDs in the path, the query and JSON or YAML bodies are replaced
+// with the UUIDs they stand for, and every UUID in text responses and in
+// Location headers is replaced with its public ID. Downloads
+// (Content-Disposition: attachment) are sent untouched. Raw UUIDs are
+// still accepted, so links issued before the switch keep working.
+func PublicIDs(codec *publicid.Codec) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			original := &originalRequest{uri: r.URL.RequestURI()}
+
+			r.URL.Path = codec.DecodeString(r.URL.Path)
+			r.URL.RawPath = ""
+			r.URL.RawQuery = codec.DecodeString(r.URL.RawQuery)
+
+			if r.Body != nil && translatableMediaType(r.Header.Get("Content-Type"), false) {
+				body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPublicIDBody))
+				if err != nil {
+					var tooLarge *http.MaxBytesError
+					if errors.As(err, &tooLarge) {
+						pkg.RequestEntityTooLarge(w, "Request body is too large")
+						return
+					}
+					pkg.BadRequest(w, "Failed to read request body")
+					return
+				}
+				original.body = body
+				body = codec.DecodeAll(body)
+				r.Body = io.NopCloser(bytes.NewReader(body))
+				r.ContentLength = int64(len(body))
+			}
+
+			r = r.WithContext(context.WithValue(r.Context(), originalRequestKey{}, original))
+			pw := &publicIDWriter{ResponseWriter: w, codec: codec}
+			next.ServeHTTP(pw, r)
+			pw.writePending()
+		})
+	}
+}
+
+// signedRequest returns the URI and body a request signature covers: those
+// the client sent, even if public IDs in them have since been translated
+func signedRequest(r *http.Request, body []byte) (string, []byte) {
+	original, ok := r.Context().Value(originalRequestKey{}).(*originalRequest)
+	if !ok {
+		return r.URL.RequestURI(), body
+	}
+	if original.body != nil {
+		body = original.body
+	}
+	return original.uri, body
+}
+
+// translatableMediaType reports whether a body of this type is text the
+// API produces or parses, as opposed to uploaded or downloaded files
+func translatableMediaType(contentType string, response bool) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case mediaType == "application/json", strings.HasSuffix(mediaType, "+json"),
+		mediaType == "application/yaml", mediaType == "application/x-yaml", mediaType == "text/yaml":
+		return true
+	case response:
+		return mediaType == "application/x-ndjson" || strings.HasPrefix(mediaType, "text/")
+	}
+	return false
+}
+
+// publicIDWriter replaces UUIDs in the response with public IDs. Output is
+// translated as it is written, holding back a trailing run of UUID
+// characters until the next write so a UUID split across writes is caught.
+type publicIDWriter struct {
+	http.ResponseWriter
+	codec       *publicid.Codec
+	wroteHeader bool
+	translate   bool
+	pending     []byte
+}
+
+func (w *publicIDWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		header := w.Header()
+		w.translate = !strings.HasPrefix(header.Get("Content-Disposition"), "attachment") &&
+			translatableMediaType(header.Get("Content-Type"), true)
+		if w.translate {
+			header.Del("Content-Length")
+		}
+		for _, name := range publicIDHeaders {
+			if value := header.Get(name); value != "" {
+				header.Set(name, w.codec.EncodeString(value))
+			}
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *publicIDWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.translate {
+		return w.ResponseWriter.Write(b)
+	}
+
+	w.pending = append(w.pending, b...)
+	held := publicid.Pending(w.pending)
+	if held > maxPublicIDPending {
+		held = 0
+	}
+	if ready := len(w.pending) - held; ready > 0 {
+		if _, err := w.ResponseWriter.Write(w.codec.EncodeAll(w.pending[:ready])); err != nil {
+			return 0, err
+		}
+		w.pending = w.pending[:copy(w.pending, w.pending[ready:])]
+	}
+	return len(b), nil
+}
+
+// Flush writes held back output before flushing, for event streams
+func (w *publicIDWriter) Flush() {
+	w.writePending()
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *publicIDWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *publicIDWriter) writePending() {
+	if len(w.pending) > 0 {
+		_, _ = w.ResponseWriter.Write(w.codec.EncodeAll(w.pending))
+		w.pending = w.pending[:0]
+	}
+}
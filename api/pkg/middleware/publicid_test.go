@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/mutlitier_app/pkg/publicid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublicIDs(t *testing.T) {
+	codec, err := publicid.New("a-secret-of-sufficient-length")
+	require.NoError(t, err)
+	id := uuid.NewString()
+	public, _ := codec.Encode(id)
+
+	var gotPath, gotBody string
+	handler := PublicIDs(codec)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+
+		if r.URL.Query().Get("download") != "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Disposition", "attachment")
+			_, _ = w.Write([]byte(id))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "/tasks/"+id)
+		w.WriteHeader(http.StatusCreated)
+		// Split mid-UUID to check held back output is translated whole
+		_, _ = w.Write([]byte(`{"id":"` + id[:10]))
+		_, _ = w.Write([]byte(id[10:] + `"}`))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/tasks/"+public, strings.NewReader(`{"ids":["`+public+`"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, "/tasks/"+id, gotPath)
+	assert.Equal(t, `{"ids":["`+id+`"]}`, gotBody)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, `{"id":"`+public+`"}`, rec.Body.String())
+	assert.Equal(t, "/tasks/"+public, rec.Header().Get("Location"))
+
+	// Raw UUIDs still resolve; downloads are sent as stored
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tasks/"+id+"?download=1", nil))
+	assert.Equal(t, "/tasks/"+id, gotPath)
+	assert.Equal(t, id, rec.Body.String())
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/database"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+	"github.com/moabdelazem/mutlitier_app/pkg/metrics"
+)
+
+// ServerTimingHeader reports server-side timings to clients and browser devtools
+const ServerTimingHeader = "Server-Timing"
+
+// QueryCount returns a middleware that counts the SQL statements each
+// request runs, reports them in Server-Timing and warns when a request
+// exceeds the threshold, which usually means an N+1 query pattern.
+// Event streams are skipped because they query for as long as they are open.
+func QueryCount(cfg *config.QueryCountConfig) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !cfg.Enabled {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, counter := database.WithQueryCounter(r.Context())
+			qw := &queryCountWriter{ResponseWriter: w, counter: counter, serverTiming: cfg.ServerTiming}
+
+			next.ServeHTTP(qw, r.WithContext(ctx))
+
+			if qw.streaming {
+				return
+			}
+
+			route := routePattern(r)
+			queries := counter.Queries()
+			metrics.HTTPRequestQueries.WithLabelValues(route).Observe(float64(queries))
+
+			if queries > cfg.Threshold {
+				metrics.HTTPRequestQueriesExceeded.WithLabelValues(route).Inc()
+				logger.Ctx(r.Context()).Warn().
+					Str("request_id", middleware.GetReqID(r.Context())).
+					Str("method", r.Method).
+					Str("route", route).
+					Int("queries", queries).
+					Int("threshold", cfg.Threshold).
+					Dur("db_duration", counter.Duration()).
+					Msg("Request exceeded the SQL query threshold, check for N+1 queries")
+			}
+		})
+	}
+}
+
+// queryCountWriter adds the Server-Timing header just before the response
+// headers are sent; queries run after that are still logged and measured
+type queryCountWriter struct {
+	http.ResponseWriter
+	counter      *database.QueryCounter
+	serverTiming bool
+	wroteHeader  bool
+	streaming    bool
+}
+
+func (w *queryCountWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.streaming = strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream")
+		if w.serverTiming && !w.streaming {
+			w.Header().Add(ServerTimingHeader, w.counter.ServerTiming())
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *queryCountWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *queryCountWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryCount_ServerTiming(t *testing.T) {
+	cfg := &config.QueryCountConfig{Enabled: true, Threshold: 10, ServerTiming: true}
+
+	handler := QueryCount(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/events" {
+			w.Header().Set("Content-Type", "text/event-stream")
+		}
+		w.Write([]byte("ok"))
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks", nil))
+	assert.Equal(t, `db;dur=0.000;desc="0 queries"`, w.Header().Get(ServerTimingHeader))
+
+	// Streams keep querying after the headers are sent, so they get no count
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+	assert.Empty(t, w.Header().Get(ServerTimingHeader))
+}
@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+	"github.com/moabdelazem/mutlitier_app/pkg/metrics"
+)
+
+// Rate limit response headers
+const (
+	RateLimitLimitHeader     = "X-RateLimit-Limit"
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+	RateLimitResetHeader     = "X-RateLimit-Reset"
+	RateLimitSoftHeader      = "X-RateLimit-Soft-Limit"
+)
+
+// RateLimit returns a middleware enforcing a two-tier per-client limit.
+// Past the soft limit responses carry warning headers; past the hard limit
+// requests are rejected with 429 Too Many Requests. Counters live in store
+// so replicas sharing a backend share limits. Clients are counted in fixed
+// windows, or with the token-bucket algorithm in buckets of the hard limit
+// refilled over the window, which smooths bursts at window boundaries.
+func RateLimit(cfg *config.RateLimitConfig, store kvstore.Store) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			prefix := "ratelimit:"
+			if cfg.Scope != "" {
+				prefix += cfg.Scope + ":"
+			}
+			key := prefix + rateLimitKey(r, cfg.Key)
+
+			var q quota
+			var err error
+			if cfg.Algorithm == config.RateLimitTokenBucket {
+				q, err = bucketQuota(r, store, cfg, key)
+			} else {
+				q, err = windowQuota(r, store, cfg, key)
+			}
+			if err != nil {
+				// Fail open: a store outage should not take the API down
+				logger.Ctx(r.Context()).Warn().Err(err).Msg("Rate limit store unavailable")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set(RateLimitLimitHeader, strconv.Itoa(cfg.HardLimit))
+			w.Header().Set(RateLimitRemainingHeader, strconv.FormatInt(q.remaining, 10))
+			w.Header().Set(RateLimitResetHeader, strconv.FormatInt(q.reset.Unix(), 10))
+
+			if q.retryAfter > 0 {
+				metrics.RateLimitHardExceeded.Inc()
+				w.Header().Set("Retry-After", strconv.Itoa(int(q.retryAfter.Seconds())+1))
+				pkg.TooManyRequests(w, "Rate limit exceeded")
+				return
+			}
+
+			if q.used > int64(cfg.SoftLimit) {
+				metrics.RateLimitSoftExceeded.Inc()
+				w.Header().Set(RateLimitSoftHeader, strconv.Itoa(cfg.SoftLimit))
+				w.Header().Set("Warning", `199 - "Approaching rate limit, requests will be rejected past the hard limit"`)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// quota is a client's standing after counting a request
+type quota struct {
+	used, remaining int64
+	// reset is when the client has its whole limit again
+	reset time.Time
+	// retryAfter is set when the request is rejected
+	retryAfter time.Duration
+}
+
+// windowQuota counts the request in a fixed window, aligned to the clock so
+// every replica agrees on boundaries
+func windowQuota(r *http.Request, store kvstore.Store, cfg *config.RateLimitConfig, key string) (quota, error) {
+	windowStart := time.Now().Truncate(cfg.Window)
+	reset := windowStart.Add(cfg.Window)
+
+	count, err := store.Incr(r.Context(), key+":"+strconv.FormatInt(windowStart.Unix(), 10), cfg.Window)
+	if err != nil {
+		return quota{}, err
+	}
+
+	q := quota{used: count, remaining: max(int64(cfg.HardLimit)-count, 0), reset: reset}
+	if count > int64(cfg.HardLimit) {
+		q.retryAfter = max(time.Until(reset), time.Nanosecond)
+	}
+	return q, nil
+}
+
+// bucketQuota takes a token from the client's bucket
+func bucketQuota(r *http.Request, store kvstore.Store, cfg *config.RateLimitConfig, key string) (quota, error) {
+	tokens, err := store.Take(r.Context(), key+":bucket", cfg.HardLimit, cfg.Window)
+	if err != nil {
+		return quota{}, err
+	}
+
+	q := quota{
+		used:      int64(cfg.HardLimit - tokens.Remaining),
+		remaining: int64(tokens.Remaining),
+		reset:     time.Now().Add(tokens.Full),
+	}
+	if !tokens.Taken {
+		q.retryAfter = max(tokens.Wait, time.Nanosecond)
+	}
+	return q, nil
+}
+
+// rateLimitKey identifies the client a request counts against: its API
+// key, its user or its IP address, falling back to the next when the
+// request has none
+func rateLimitKey(r *http.Request, by string) string {
+	principal := auth.FromContext(r.Context())
+	if principal != nil && by == config.RateLimitByKey && principal.TokenID != "" {
+		return "key:" + principal.TokenID
+	}
+	if principal != nil && by != config.RateLimitByIP && principal.User != "" {
+		return "user:" + principal.User
+	}
+	return clientKey(r)
+}
+
+// clientKey identifies the caller by IP address (RealIP has already run)
+func clientKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// RateLimitGroups returns a middleware that counts each request against the
+// bucket of the route group picked by group, so expensive endpoints get
+// stricter limits without eating into the general CRUD budget. Requests in
+// no group, or in a group without configured limits, share the general bucket.
+func RateLimitGroups(cfg *config.RateLimitConfig, store kvstore.Store, group func(*http.Request) string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		general := RateLimit(cfg, store)(next)
+		grouped := make(map[string]http.Handler, len(cfg.Groups))
+		for _, g := range cfg.Groups {
+			grouped[g.Name] = RateLimit(cfg.Group(g.Name), store)(next)
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if h, ok := grouped[group(r)]; ok {
+				h.ServeHTTP(w, r)
+				return
+			}
+			general.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RouteGroup places every request in the named rate limit group
+func RouteGroup(name string) func(*http.Request) string {
+	return func(*http.Request) string { return name }
+}
@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/pkg/kvstore"
+	"github.com/moabdelazem/mutlitier_app/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// rateLimitSend sends a request through handler and returns the response
+func rateLimitSend(handler http.Handler) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tasks", nil))
+	return rec
+}
+
+func TestRateLimit_SoftAndHardTiers(t *testing.T) {
+	cfg := &config.RateLimitConfig{Enabled: true, SoftLimit: 2, HardLimit: 4, Window: time.Hour}
+	handler := RateLimit(cfg, kvstore.NewMemory())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	soft, hard := testutil.ToFloat64(metrics.RateLimitSoftExceeded), testutil.ToFloat64(metrics.RateLimitHardExceeded)
+
+	// Under both limits requests pass without a warning
+	for i := 0; i < 2; i++ {
+		rec := rateLimitSend(handler)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get("Warning"))
+		assert.Empty(t, rec.Header().Get(RateLimitSoftHeader))
+	}
+
+	// Past the soft limit they are still served, with a warning
+	for i := 0; i < 2; i++ {
+		rec := rateLimitSend(handler)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotEmpty(t, rec.Header().Get("Warning"))
+		assert.Equal(t, "2", rec.Header().Get(RateLimitSoftHeader))
+	}
+	assert.Equal(t, soft+2, testutil.ToFloat64(metrics.RateLimitSoftExceeded))
+	assert.Equal(t, hard, testutil.ToFloat64(metrics.RateLimitHardExceeded))
+
+	// Past the hard limit they are rejected
+	rec := rateLimitSend(handler)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "4", rec.Header().Get(RateLimitLimitHeader))
+	assert.Equal(t, "0", rec.Header().Get(RateLimitRemainingHeader))
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+	assert.Equal(t, soft+2, testutil.ToFloat64(metrics.RateLimitSoftExceeded))
+	assert.Equal(t, hard+1, testutil.ToFloat64(metrics.RateLimitHardExceeded))
+}
+
+func TestRateLimit_StricterTierWins(t *testing.T) {
+	// A hard limit at or below the soft one rejects requests before any
+	// warning is given
+	for name, softLimit := range map[string]int{"equal": 2, "soft above hard": 5} {
+		t.Run(name, func(t *testing.T) {
+			cfg := &config.RateLimitConfig{Enabled: true, SoftLimit: softLimit, HardLimit: 2, Window: time.Hour}
+			handler := RateLimit(cfg, kvstore.NewMemory())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			for i := 0; i < 2; i++ {
+				rec := rateLimitSend(handler)
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Empty(t, rec.Header().Get("Warning"))
+			}
+			rec := rateLimitSend(handler)
+			assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+			assert.Empty(t, rec.Header().Get("Warning"))
+			assert.Empty(t, rec.Header().Get(RateLimitSoftHeader))
+		})
+	}
+
+	// A soft limit of zero warns from the first request, up to the hard one
+	cfg := &config.RateLimitConfig{Enabled: true, SoftLimit: 0, HardLimit: 1, Window: time.Hour}
+	handler := RateLimit(cfg, kvstore.NewMemory())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	rec := rateLimitSend(handler)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("Warning"))
+	assert.Equal(t, http.StatusTooManyRequests, rateLimitSend(handler).Code)
+}
+
+func TestRateLimitGroups_SeparateBuckets(t *testing.T) {
+	cfg := &config.RateLimitConfig{
+		Enabled:   true,
+		SoftLimit: 3,
+		HardLimit: 3,
+		Window:    time.Hour,
+		Groups:    []config.RateLimitGroup{{Name: "search", SoftLimit: 1, HardLimit: 1, Window: time.Hour}},
+	}
+	group := func(r *http.Request) string {
+		if r.URL.Path == "/tasks/search" {
+			return "search"
+		}
+		return ""
+	}
+	handler := RateLimitGroups(cfg, kvstore.NewMemory(), group)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	status := func(path string) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, status("/tasks/search"))
+	assert.Equal(t, http.StatusTooManyRequests, status("/tasks/search"))
+
+	// The search bucket is exhausted but CRUD still has its own budget
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, status("/tasks"))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, status("/tasks"))
+}
+
+func TestRateLimit_TokenBucketByUser(t *testing.T) {
+	cfg := &config.RateLimitConfig{
+		Enabled:   true,
+		SoftLimit: 1,
+		HardLimit: 2,
+		Window:    time.Hour,
+		Algorithm: config.RateLimitTokenBucket,
+		Key:       config.RateLimitByKey,
+	}
+	handler := RateLimit(cfg, kvstore.NewMemory())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	send := func(principal *auth.Principal) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		if principal != nil {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	alice := &auth.Principal{User: "alice"}
+	rec := send(alice)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get(RateLimitLimitHeader))
+	assert.Equal(t, "1", rec.Header().Get(RateLimitRemainingHeader))
+	assert.Empty(t, rec.Header().Get(RateLimitSoftHeader))
+
+	rec = send(alice)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get(RateLimitRemainingHeader))
+	assert.Equal(t, "1", rec.Header().Get(RateLimitSoftHeader))
+
+	rec = send(alice)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Contains(t, []string{"1800", "1801"}, rec.Header().Get("Retry-After"))
+
+	// Alice's API key, another user and anonymous callers have their own buckets
+	assert.Equal(t, http.StatusOK, send(&auth.Principal{User: "alice", TokenID: "t-1"}).Code)
+	assert.Equal(t, http.StatusOK, send(&auth.Principal{User: "bob"}).Code)
+	assert.Equal(t, http.StatusOK, send(nil).Code)
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+)
+
+// SecurityRecorder records authentication and authorization events
+type SecurityRecorder interface {
+	Record(ctx context.Context, event *model.SecurityEvent)
+}
+
+// SecurityEvent describes an event of the given type for the request,
+// attributed to the request's principal when it has one
+func SecurityEvent(r *http.Request, eventType model.SecurityEventType, reason string) *model.SecurityEvent {
+	event := &model.SecurityEvent{
+		Type:      eventType,
+		Reason:    reason,
+		IP:        clientKey(r),
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		RequestID: middleware.GetReqID(r.Context()),
+	}
+	if principal := auth.FromContext(r.Context()); principal != nil {
+		event.User = principal.User
+		if principal.TokenID != "" {
+			event.TokenID = principal.TokenID
+			event.Credential = model.CredentialAPIToken
+		}
+	}
+	return event
+}
+
+// recordSecurity records event when a recorder is configured
+func recordSecurity(recorder SecurityRecorder, r *http.Request, event *model.SecurityEvent) {
+	if recorder != nil {
+		recorder.Record(r.Context(), event)
+	}
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryNonceStore is an in-memory NonceStore for testing
+type memoryNonceStore struct {
+	seen map[string]bool
+}
+
+func (s *memoryNonceStore) Remember(ctx context.Context, nonce string, expiresAt time.Time) (bool, error) {
+	if s.seen[nonce] {
+		return false, nil
+	}
+	s.seen[nonce] = true
+	return true, nil
+}
+
+func newSignedRequest(secret, nonce string, signedAt time.Time, body string) *http.Request {
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(NonceHeader, nonce)
+	req.Header.Set(SignatureHeader, Sign(secret, timestamp, nonce, http.MethodPost, "/tasks", []byte(body)))
+	return req
+}
+
+func TestSignature(t *testing.T) {
+	cfg := &config.SigningConfig{Secret: "s3cret", Window: time.Minute, MaxBody: 64}
+	store := &memoryNonceStore{seen: map[string]bool{}}
+	handler := Signature(cfg, store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name     string
+		req      *http.Request
+		expected int
+	}{
+		{"valid", newSignedRequest("s3cret", "n1", time.Now(), `{"title":"a"}`), http.StatusOK},
+		{"replayed", newSignedRequest("s3cret", "n1", time.Now(), `{"title":"a"}`), http.StatusUnauthorized},
+		{"wrong secret", newSignedRequest("other", "n2", time.Now(), `{"title":"a"}`), http.StatusUnauthorized},
+		{"expired", newSignedRequest("s3cret", "n3", time.Now().Add(-2*time.Minute), `{}`), http.StatusUnauthorized},
+		{"unsigned", httptest.NewRequest(http.MethodPost, "/tasks", nil), http.StatusUnauthorized},
+		{"too large", newSignedRequest("s3cret", "n4", time.Now(), `{"title":"`+strings.Repeat("a", 64)+`"}`), http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, tt.req)
+			assert.Equal(t, tt.expected, w.Code)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+	"github.com/moabdelazem/mutlitier_app/pkg"
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+// MemberChecker reports whether a user may act in a tenant
+type MemberChecker interface {
+	IsMember(ctx context.Context, tenant, user string) (bool, error)
+}
+
+// Tenant returns a middleware that resolves the tenant of each request and
+// scopes its database statements to it. Credentials issued in a tenant (a
+// session's tenant claim or an API token's tenant) decide it; otherwise
+// the TENANT_HEADER header, then the subdomain of TENANT_DOMAIN, then
+// TENANT_DEFAULT name it. Naming another tenant than the credentials are
+// bound to is refused with 403 and reported to security, and so is naming
+// a tenant the caller is not a member of, unless they are an admin.
+// Anonymous requests naming a tenant other than TENANT_DEFAULT only claim
+// it: Authorize refuses them, leaving the sign-in routes, which check
+// membership themselves. It must run after Authenticate.
+func Tenant(cfg *config.TenancyConfig, members MemberChecker, security SecurityRecorder) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !cfg.Enabled {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			named := r.Header.Get(cfg.Header)
+			if named == "" {
+				named = subdomain(r.Host, cfg.Domain)
+			}
+
+			principal := auth.FromContext(r.Context())
+			id := named
+			if principal != nil && principal.Tenant != "" {
+				if named != "" && named != principal.Tenant {
+					recordSecurity(security, r, SecurityEvent(r, model.SecurityPermissionDenied, "credentials of tenant "+principal.Tenant+" used for "+named))
+					pkg.Forbidden(w, "Credentials belong to another tenant")
+					return
+				}
+				id = principal.Tenant
+			}
+			if id == "" {
+				id = cfg.Default
+			}
+
+			if id == "" {
+				pkg.BadRequest(w, "Tenant required, set the "+cfg.Header+" header")
+				return
+			}
+			if !tenant.Valid(id) {
+				pkg.BadRequest(w, "Invalid tenant, use up to 63 lowercase letters, digits and hyphens")
+				return
+			}
+
+			ctx := tenant.With(r.Context(), id)
+			switch {
+			case id == cfg.Default:
+			case principal == nil:
+				ctx = tenant.Claim(ctx)
+			case principal.Tenant == "" && !principal.Has(auth.ScopeAdmin) && members != nil:
+				// Users of the sign-in proxy are bound to no tenant
+				member, err := members.IsMember(r.Context(), id, principal.User)
+				if err != nil {
+					logger.Ctx(r.Context()).Error().Err(err).Str("tenant", id).Msg("Failed to check tenant member")
+					pkg.ServiceUnavailable(w, pkg.ErrorResponse{Error: "Failed to check tenant membership"})
+					return
+				}
+				if !member {
+					recordSecurity(security, r, SecurityEvent(r, model.SecurityPermissionDenied, "not a member of tenant "+id))
+					pkg.Forbidden(w, "Not a member of this tenant")
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// subdomain returns the label host adds in front of domain, empty when
+// host is not a direct subdomain of it
+func subdomain(host, domain string) string {
+	if domain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	label, ok := strings.CutSuffix(strings.ToLower(host), "."+domain)
+	if !ok || strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/moabdelazem/mutlitier_app/internal/auth"
+	"github.com/moabdelazem/mutlitier_app/internal/config"
+	"github.com/moabdelazem/mutlitier_app/internal/model"
+	"github.com/moabdelazem/mutlitier_app/internal/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+// members is a MemberChecker admitting the listed tenant/user pairs
+type members map[[2]string]bool
+
+func (m members) IsMember(ctx context.Context, tenant, user string) (bool, error) {
+	return m[[2]string{tenant, user}], nil
+}
+
+func TestTenant(t *testing.T) {
+	cfg := &config.TenancyConfig{Enabled: true, Header: "X-Tenant-ID", Domain: "tasks.example.com", Default: "default"}
+	var events recordedEvents
+	handler := Tenant(cfg, members{{"acme", "bob"}: true}, &events)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if tenant.Claimed(r.Context()) {
+			w.Write([]byte("claimed:"))
+		}
+		w.Write([]byte(tenant.From(r.Context())))
+	}))
+
+	resolve := func(host, header string, principal *auth.Principal) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		req.Host = host
+		if header != "" {
+			req.Header.Set("X-Tenant-ID", header)
+		}
+		if principal != nil {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, tt := range []struct {
+		name, host, header string
+		principal          *auth.Principal
+		tenant             string
+	}{
+		{name: "header", host: "api.internal", header: "acme", tenant: "claimed:acme"},
+		{name: "subdomain", host: "globex.tasks.example.com:8888", tenant: "claimed:globex"},
+		{name: "header before subdomain", host: "globex.tasks.example.com", header: "acme", tenant: "claimed:acme"},
+		{name: "default header", host: "api.internal", header: "default", tenant: "default"},
+		{name: "nested subdomain ignored", host: "eu.globex.tasks.example.com", tenant: "default"},
+		{name: "default", host: "tasks.example.com", tenant: "default"},
+		{name: "credentials", host: "api.internal", principal: &auth.Principal{User: "alice", Tenant: "acme"}, tenant: "acme"},
+		{name: "credentials and same header", host: "api.internal", header: "acme", principal: &auth.Principal{User: "alice", Tenant: "acme"}, tenant: "acme"},
+		{name: "proxy user member", host: "api.internal", header: "acme", principal: &auth.Principal{User: "bob"}, tenant: "acme"},
+		{name: "proxy user default", host: "api.internal", principal: &auth.Principal{User: "alice"}, tenant: "default"},
+		{name: "admin", host: "api.internal", header: "globex", principal: &auth.Principal{User: "admin", Scopes: auth.Scopes()}, tenant: "globex"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := resolve(tt.host, tt.header, tt.principal)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tt.tenant, rec.Body.String())
+		})
+	}
+
+	// Credentials cannot be carried into another tenant
+	rec := resolve("globex.tasks.example.com", "", &auth.Principal{User: "alice", Tenant: "acme"})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Len(t, events.ofType(model.SecurityPermissionDenied), 1)
+
+	// Users bound to no tenant need to be members of the one they name
+	rec = resolve("api.internal", "acme", &auth.Principal{User: "alice"})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Len(t, events.ofType(model.SecurityPermissionDenied), 2)
+
+	assert.Equal(t, http.StatusBadRequest, resolve("api.internal", "Not_A_Tenant", nil).Code)
+
+	cfg.Default = ""
+	assert.Equal(t, http.StatusBadRequest, resolve("api.internal", "", nil).Code)
+}
@@ -0,0 +1,139 @@
+// Package publicid translates internal UUIDs to opaque public IDs and
+// back, so clients never see the time-ordered UUIDs the database uses and
+// cannot guess neighbouring IDs
+package publicid
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// MinSecretLength is the shortest secret New accepts
+const MinSecretLength = 16
+
+// tagSize is the length of the authentication tag appended to each ID, so
+// arbitrary text of the right shape is not mistaken for an ID
+const tagSize = 4
+
+var (
+	// ErrShortSecret is returned by New for secrets under MinSecretLength
+	ErrShortSecret = errors.New("public id secret is too short")
+
+	encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	uuidPattern   = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	publicPattern = regexp.MustCompile(`\b[a-z2-7]{32}\b`)
+)
+
+// Codec maps UUIDs to public IDs with a keyed permutation: each UUID is
+// encrypted as a single AES block and followed by a truncated HMAC of the
+// ciphertext. Both directions are pure computation, no lookups.
+type Codec struct {
+	block cipher.Block
+	mac   []byte
+}
+
+// New creates a Codec keyed by secret. The same secret must be used by
+// every replica and kept, or previously issued IDs stop resolving.
+func New(secret string) (*Codec, error) {
+	if len(secret) < MinSecretLength {
+		return nil, ErrShortSecret
+	}
+
+	key := sha256.Sum256([]byte("publicid:aes:" + secret))
+	block, err := aes.NewCipher(key[:16])
+	if err != nil {
+		return nil, err
+	}
+	mac := sha256.Sum256([]byte("publicid:mac:" + secret))
+
+	return &Codec{block: block, mac: mac[:]}, nil
+}
+
+// Encode returns the public ID of a UUID, false if id is not one
+func (c *Codec) Encode(id string) (string, bool) {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return "", false
+	}
+
+	var out [aes.BlockSize + tagSize]byte
+	c.block.Encrypt(out[:aes.BlockSize], parsed[:])
+	copy(out[aes.BlockSize:], c.tag(out[:aes.BlockSize]))
+
+	return strings.ToLower(encoding.EncodeToString(out[:])), true
+}
+
+// Decode returns the UUID behind a public ID, false if public was not
+// issued with this codec's secret
+func (c *Codec) Decode(public string) (string, bool) {
+	raw, err := encoding.DecodeString(strings.ToUpper(public))
+	if err != nil || len(raw) != aes.BlockSize+tagSize {
+		return "", false
+	}
+	if !hmac.Equal(raw[aes.BlockSize:], c.tag(raw[:aes.BlockSize])) {
+		return "", false
+	}
+
+	var id uuid.UUID
+	c.block.Decrypt(id[:], raw[:aes.BlockSize])
+	return id.String(), true
+}
+
+// EncodeAll replaces every UUID in text with its public ID
+func (c *Codec) EncodeAll(text []byte) []byte {
+	return uuidPattern.ReplaceAllFunc(text, func(match []byte) []byte {
+		public, _ := c.Encode(string(match))
+		return []byte(public)
+	})
+}
+
+// DecodeAll replaces every public ID in text with its UUID, leaving
+// anything that only looks like one untouched
+func (c *Codec) DecodeAll(text []byte) []byte {
+	return publicPattern.ReplaceAllFunc(text, func(match []byte) []byte {
+		if id, ok := c.Decode(string(match)); ok {
+			return []byte(id)
+		}
+		return match
+	})
+}
+
+// EncodeString is EncodeAll for strings
+func (c *Codec) EncodeString(text string) string {
+	return string(c.EncodeAll([]byte(text)))
+}
+
+// DecodeString is DecodeAll for strings
+func (c *Codec) DecodeString(text string) string {
+	return string(c.DecodeAll([]byte(text)))
+}
+
+// Pending returns how many trailing bytes of text are UUID characters.
+// Streamed output holds them back until the next write, so a UUID split
+// across two writes is still translated.
+func Pending(text []byte) int {
+	n := 0
+	for i := len(text) - 1; i >= 0; i-- {
+		b := text[i]
+		if !(b >= '0' && b <= '9' || b >= 'a' && b <= 'f' || b >= 'A' && b <= 'F' || b == '-') {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+func (c *Codec) tag(ciphertext []byte) []byte {
+	h := hmac.New(sha256.New, c.mac)
+	h.Write(ciphertext)
+	return h.Sum(nil)[:tagSize]
+}
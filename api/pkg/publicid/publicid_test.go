@@ -0,0 +1,67 @@
+package publicid
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodec(t *testing.T) {
+	_, err := New("short")
+	require.ErrorIs(t, err, ErrShortSecret)
+
+	codec, err := New("a-secret-of-sufficient-length")
+	require.NoError(t, err)
+
+	id := uuid.Must(uuid.NewV7()).String()
+	public, ok := codec.Encode(id)
+	require.True(t, ok)
+	assert.Len(t, public, 32)
+	assert.NotContains(t, public, id[:8])
+
+	// Deterministic, case-insensitive on the way in
+	again, _ := codec.Encode(strings.ToUpper(id))
+	assert.Equal(t, public, again)
+	decoded, ok := codec.Decode(public)
+	require.True(t, ok)
+	assert.Equal(t, id, decoded)
+
+	// Neighbouring UUIDs give unrelated IDs
+	next := uuid.MustParse(id)
+	next[15]++
+	neighbour, _ := codec.Encode(next.String())
+	assert.NotEqual(t, public[:16], neighbour[:16])
+
+	// Tampered IDs and IDs from another secret are rejected
+	tampered := []byte(public)
+	tampered[3] = map[bool]byte{true: 'b', false: 'a'}[tampered[3] == 'a']
+	_, ok = codec.Decode(string(tampered))
+	assert.False(t, ok)
+	other, _ := New("another-secret-of-sufficient-length")
+	_, ok = other.Decode(public)
+	assert.False(t, ok)
+	_, ok = codec.Encode("not-a-uuid")
+	assert.False(t, ok)
+}
+
+func TestCodec_All(t *testing.T) {
+	codec, err := New("a-secret-of-sufficient-length")
+	require.NoError(t, err)
+
+	id := uuid.NewString()
+	public, _ := codec.Encode(id)
+
+	encoded := codec.EncodeString(`{"id":"` + id + `","tags":[]}`)
+	assert.Equal(t, `{"id":"`+public+`","tags":[]}`, encoded)
+	assert.Equal(t, `{"id":"`+id+`","tags":[]}`, codec.DecodeString(encoded))
+
+	// Text that merely looks like a public ID is left alone
+	lookalike := `{"title":"abcdefghijklmnopqrstuvwxyz234567"}`
+	assert.Equal(t, lookalike, codec.DecodeString(lookalike))
+
+	assert.Equal(t, 9, Pending([]byte(`{"id":"0190a1b2-`)))
+	assert.Equal(t, 0, Pending([]byte(`{"id":"`)))
+}
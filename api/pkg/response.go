@@ -1,10 +1,14 @@
 package pkg
 
 import (
-	"encoding/json"
 	"net/http"
+	"strconv"
 )
 
+// jsonContentType is shared by every JSON response instead of allocating
+// a header value each time; headers are only ever replaced, not edited
+var jsonContentType = []string{"application/json"}
+
 type Response struct {
 	Message string `json:"message"`
 	Data    any    `json:"data"`
@@ -14,10 +18,24 @@ type ErrorResponse struct {
 	Error string `json:"error"`
 }
 
+// WriteJSON encodes data into a pooled buffer before sending it, so the
+// response carries a Content-Length and a value that fails to encode
+// becomes a 500 instead of a truncated body
 func WriteJSON(w http.ResponseWriter, statusCode int, data any) {
-	w.Header().Set("Content-Type", "application/json")
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if err := buf.encode(data); err != nil {
+		buf.Reset()
+		statusCode = http.StatusInternalServerError
+		buf.encode(ErrorResponse{Error: "Failed to encode response"})
+	}
+
+	header := w.Header()
+	header["Content-Type"] = jsonContentType
+	header.Set("Content-Length", strconv.Itoa(buf.Len()))
 	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(data)
+	w.Write(buf.Bytes())
 }
 
 func JSONSuccess(w http.ResponseWriter, data any) {
@@ -36,10 +54,50 @@ func BadRequest(w http.ResponseWriter, message string) {
 	WriteJSON(w, http.StatusBadRequest, ErrorResponse{Error: message})
 }
 
+func Unauthorized(w http.ResponseWriter, message string) {
+	WriteJSON(w, http.StatusUnauthorized, ErrorResponse{Error: message})
+}
+
+func Forbidden(w http.ResponseWriter, message string) {
+	WriteJSON(w, http.StatusForbidden, ErrorResponse{Error: message})
+}
+
 func NotFound(w http.ResponseWriter, message string) {
 	WriteJSON(w, http.StatusNotFound, ErrorResponse{Error: message})
 }
 
+func Conflict(w http.ResponseWriter, message string) {
+	WriteJSON(w, http.StatusConflict, ErrorResponse{Error: message})
+}
+
+func Gone(w http.ResponseWriter, message string) {
+	WriteJSON(w, http.StatusGone, ErrorResponse{Error: message})
+}
+
+func PreconditionFailed(w http.ResponseWriter, message string) {
+	WriteJSON(w, http.StatusPreconditionFailed, ErrorResponse{Error: message})
+}
+
+func PreconditionRequired(w http.ResponseWriter, message string) {
+	WriteJSON(w, http.StatusPreconditionRequired, ErrorResponse{Error: message})
+}
+
+func RequestEntityTooLarge(w http.ResponseWriter, message string) {
+	WriteJSON(w, http.StatusRequestEntityTooLarge, ErrorResponse{Error: message})
+}
+
+func UnsupportedMediaType(w http.ResponseWriter, message string) {
+	WriteJSON(w, http.StatusUnsupportedMediaType, ErrorResponse{Error: message})
+}
+
+func UnprocessableEntity(w http.ResponseWriter, message string) {
+	WriteJSON(w, http.StatusUnprocessableEntity, ErrorResponse{Error: message})
+}
+
+func TooManyRequests(w http.ResponseWriter, message string) {
+	WriteJSON(w, http.StatusTooManyRequests, ErrorResponse{Error: message})
+}
+
 func InternalError(w http.ResponseWriter, message string) {
 	WriteJSON(w, http.StatusInternalServerError, ErrorResponse{Error: message})
 }
@@ -0,0 +1,112 @@
+// Package server runs the HTTP listeners of the process, each with its own
+// port and handler, and shuts them down one after another
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+// Listener is one named HTTP server
+type Listener struct {
+	Name   string
+	Server *http.Server
+	ln     net.Listener
+}
+
+// Group runs HTTP listeners. Shutdown drains them in the order they were
+// added, so listeners added last, like metrics, keep answering while the
+// earlier ones drain.
+type Group struct {
+	listeners []*Listener
+	errs      chan error
+}
+
+// NewGroup creates an empty Group
+func NewGroup() *Group {
+	return &Group{errs: make(chan error, 1)}
+}
+
+// Add adds a listener serving srv on srv.Addr, over TLS when srv has a
+// TLSConfig
+func (g *Group) Add(name string, srv *http.Server) {
+	g.listeners = append(g.listeners, &Listener{Name: name, Server: srv})
+}
+
+// Start binds every listener, failing before any serves when a port is
+// taken, and serves them in the background
+func (g *Group) Start() error {
+	for _, l := range g.listeners {
+		ln, err := net.Listen("tcp", l.Server.Addr)
+		if err != nil {
+			for _, bound := range g.listeners {
+				if bound.ln != nil {
+					bound.ln.Close()
+				}
+			}
+			return fmt.Errorf("failed to listen for %s on %s: %w", l.Name, l.Server.Addr, err)
+		}
+		l.ln = ln
+	}
+
+	log := logger.Get()
+	for _, l := range g.listeners {
+		log.Info().Str("listener", l.Name).Str("addr", l.ln.Addr().String()).Bool("tls", l.Server.TLSConfig != nil).Msg("Server started")
+		go func() {
+			var err error
+			if l.Server.TLSConfig != nil {
+				err = l.Server.ServeTLS(l.ln, "", "")
+			} else {
+				err = l.Server.Serve(l.ln)
+			}
+			if !errors.Is(err, http.ErrServerClosed) {
+				select {
+				case g.errs <- fmt.Errorf("%s listener failed: %w", l.Name, err):
+				default:
+				}
+			}
+		}()
+	}
+	return nil
+}
+
+// Err returns a channel receiving the first error a listener stopped with
+func (g *Group) Err() <-chan error {
+	return g.errs
+}
+
+// Addr returns the address the named listener is bound to, nil before
+// Start or for an unknown name
+func (g *Group) Addr(name string) net.Addr {
+	for _, l := range g.listeners {
+		if l.Name == name && l.ln != nil {
+			return l.ln.Addr()
+		}
+	}
+	return nil
+}
+
+// Shutdown drains the listeners one at a time, giving each up to timeout,
+// and returns the errors of those cut short
+func (g *Group) Shutdown(ctx context.Context, timeout time.Duration) error {
+	log := logger.Get()
+
+	var errs []error
+	for _, l := range g.listeners {
+		drainCtx, cancel := context.WithTimeout(ctx, timeout)
+		if err := l.Server.Shutdown(drainCtx); err != nil {
+			l.Server.Close()
+			errs = append(errs, fmt.Errorf("%s listener: %w", l.Name, err))
+		} else {
+			log.Info().Str("listener", l.Name).Msg("Listener stopped")
+		}
+		cancel()
+	}
+	return errors.Join(errs...)
+}
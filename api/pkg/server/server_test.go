@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, addr net.Addr) string {
+	resp, err := http.Get("http://" + addr.String())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func named(name string) *http.Server {
+	return &http.Server{Addr: "127.0.0.1:0", Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, name)
+	})}
+}
+
+func TestGroup(t *testing.T) {
+	g := NewGroup()
+	g.Add("api", named("api"))
+	g.Add("metrics", named("metrics"))
+	require.NoError(t, g.Start())
+
+	// Each listener serves its own handler on its own port
+	assert.Equal(t, "api", get(t, g.Addr("api")))
+	assert.Equal(t, "metrics", get(t, g.Addr("metrics")))
+	assert.NotEqual(t, g.Addr("api").String(), g.Addr("metrics").String())
+	assert.Nil(t, g.Addr("debug"))
+
+	http.DefaultClient.CloseIdleConnections()
+	require.NoError(t, g.Shutdown(context.Background(), time.Second))
+	_, err := http.Get("http://" + g.Addr("api").String())
+	assert.Error(t, err)
+}
+
+func TestGroupShutdownOrder(t *testing.T) {
+	// The API holds a request open; metrics keep answering while it drains
+	release := make(chan struct{})
+	started := make(chan struct{})
+	api := &http.Server{Addr: "127.0.0.1:0", Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})}
+
+	g := NewGroup()
+	g.Add("api", api)
+	g.Add("metrics", named("metrics"))
+	require.NoError(t, g.Start())
+
+	client := &http.Client{Transport: &http.Transport{}}
+	defer client.CloseIdleConnections()
+	go client.Get("http://" + g.Addr("api").String())
+	<-started
+
+	done := make(chan error, 1)
+	go func() { done <- g.Shutdown(context.Background(), time.Second) }()
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, "metrics", get(t, g.Addr("metrics")))
+	http.DefaultClient.CloseIdleConnections()
+	close(release)
+	require.NoError(t, <-done)
+}
+
+func TestGroupShutdownTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	api := &http.Server{Addr: "127.0.0.1:0", Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})}
+
+	g := NewGroup()
+	g.Add("api", api)
+	require.NoError(t, g.Start())
+
+	client := &http.Client{Transport: &http.Transport{}}
+	defer client.CloseIdleConnections()
+	go client.Get("http://" + g.Addr("api").String())
+	<-started
+
+	// A listener still busy after the timeout is closed and reported
+	err := g.Shutdown(context.Background(), 50*time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "api listener")
+}
+
+func TestGroupStartPortTaken(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer taken.Close()
+
+	// No listener serves when one cannot bind
+	g := NewGroup()
+	g.Add("api", named("api"))
+	g.Add("admin", &http.Server{Addr: taken.Addr().String()})
+	err = g.Start()
+	assert.ErrorContains(t, err, "failed to listen for admin")
+}
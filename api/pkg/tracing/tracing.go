@@ -0,0 +1,114 @@
+// Package tracing carries the W3C trace context of incoming requests so
+// logs and metrics can point at the trace recorded by the caller's tracer.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// TraceparentHeader is the W3C Trace Context propagation header
+const TraceparentHeader = "traceparent"
+
+// TraceIDHeader returns the trace ID of a request to the caller, for
+// users to quote in bug reports
+const TraceIDHeader = "X-Trace-ID"
+
+// SpanContext identifies a span
+type SpanContext struct {
+	TraceID      string
+	SpanID       string
+	ParentSpanID string // the caller's span, empty when the trace started here
+	Sampled      bool   // the caller's tracer recorded this trace
+}
+
+type contextKey struct{}
+
+// ParseTraceparent parses a version 00 traceparent header, e.g.
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func ParseTraceparent(value string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || parts[0] == "ff" || len(parts[0]) != 2 {
+		return SpanContext{}, false
+	}
+	// Version 00 has exactly four fields, later versions may append more
+	if parts[0] == "00" && len(parts) != 4 {
+		return SpanContext{}, false
+	}
+
+	traceID, spanID, flags := parts[1], parts[2], parts[3]
+	if !isHex(traceID, 32) || !isHex(spanID, 16) || !isHex(flags, 2) {
+		return SpanContext{}, false
+	}
+	if strings.Trim(traceID, "0") == "" || strings.Trim(spanID, "0") == "" {
+		return SpanContext{}, false
+	}
+
+	flagBits, _ := hex.DecodeString(flags)
+	return SpanContext{TraceID: traceID, SpanID: spanID, Sampled: flagBits[0]&0x01 != 0}, true
+}
+
+// WithSpanContext returns a copy of ctx carrying sc
+func WithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, contextKey{}, sc)
+}
+
+// FromContext returns the span context stored in ctx, if any
+func FromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(contextKey{}).(SpanContext)
+	return sc, ok
+}
+
+// SampledTraceID returns the trace ID in ctx when the caller recorded the
+// trace, so exemplars never link to traces that were dropped
+func SampledTraceID(ctx context.Context) string {
+	sc, ok := FromContext(ctx)
+	if !ok || !sc.Sampled {
+		return ""
+	}
+	return sc.TraceID
+}
+
+// Middleware gives every request a span of its own: a child of the
+// caller's span when a valid traceparent header arrives, or the root of a
+// new, unsampled trace otherwise, as for malformed headers. The trace ID
+// is returned in X-Trace-ID.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sc := SpanContext{TraceID: randomHex(16), SpanID: randomHex(8)}
+		if parent, ok := ParseTraceparent(r.Header.Get(TraceparentHeader)); ok {
+			sc.TraceID, sc.ParentSpanID, sc.Sampled = parent.TraceID, parent.SpanID, parent.Sampled
+		}
+
+		w.Header().Set(TraceIDHeader, sc.TraceID)
+		next.ServeHTTP(w, r.WithContext(WithSpanContext(r.Context(), sc)))
+	})
+}
+
+// randomHex returns n random bytes as lowercase hex, never all zeros,
+// which the spec reserves for invalid IDs
+func randomHex(n int) string {
+	b := make([]byte, n)
+	for {
+		rand.Read(b)
+		if id := hex.EncodeToString(b); strings.Trim(id, "0") != "" {
+			return id
+		}
+	}
+}
+
+// isHex reports whether s is n lowercase hex digits, as the spec requires
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
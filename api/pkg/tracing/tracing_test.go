@@ -0,0 +1,65 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	sc, ok := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.True(t, ok)
+	assert.Equal(t, SpanContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7", Sampled: true}, sc)
+
+	sc, ok = ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	assert.True(t, ok)
+	assert.False(t, sc.Sampled)
+
+	// Future versions may append fields
+	_, ok = ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra")
+	assert.True(t, ok)
+
+	for _, value := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",
+	} {
+		_, ok := ParseTraceparent(value)
+		assert.False(t, ok, value)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var got SpanContext
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = FromContext(r.Context())
+	}))
+
+	// A valid traceparent is continued in a span of our own
+	req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+	req.Header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", got.TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", got.ParentSpanID)
+	assert.True(t, isHex(got.SpanID, 16))
+	assert.NotEqual(t, got.ParentSpanID, got.SpanID)
+	assert.True(t, got.Sampled)
+	assert.Equal(t, got.TraceID, rec.Header().Get(TraceIDHeader))
+
+	// Without one a new, unsampled trace starts, so exemplars skip it
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tasks", nil))
+	assert.True(t, isHex(got.TraceID, 32))
+	assert.Empty(t, got.ParentSpanID)
+	assert.False(t, got.Sampled)
+	assert.Equal(t, got.TraceID, rec.Header().Get(TraceIDHeader))
+	assert.Empty(t, SampledTraceID(WithSpanContext(context.Background(), got)))
+}
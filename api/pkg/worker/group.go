@@ -0,0 +1,110 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/moabdelazem/mutlitier_app/pkg/logger"
+)
+
+// releaseGrace bounds how long Shutdown waits for workers to release their
+// claims once in-flight work has been cancelled
+const releaseGrace = 5 * time.Second
+
+// Group runs background workers that claim shared work, such as a lease or
+// a scheduled export, and shuts them down in two phases. Each worker gets
+// two contexts: stop is cancelled as soon as shutdown starts and means
+// "claim nothing new", work is only cancelled once the drain deadline
+// passes and aborts whatever is still in flight. Workers release their
+// claims on the way out so other replicas can pick the work up at once.
+type Group struct {
+	stop       context.Context
+	cancelStop context.CancelFunc
+	work       context.Context
+	cancelWork context.CancelFunc
+	wg         sync.WaitGroup
+
+	mu      sync.Mutex
+	running map[string]int
+}
+
+// NewGroup creates a Group whose contexts derive from parent
+func NewGroup(parent context.Context) *Group {
+	g := &Group{running: make(map[string]int)}
+	g.work, g.cancelWork = context.WithCancel(parent)
+	g.stop, g.cancelStop = context.WithCancel(g.work)
+	return g
+}
+
+// Go runs fn in its own goroutine until it returns
+func (g *Group) Go(name string, fn func(stop, work context.Context)) {
+	g.wg.Add(1)
+	g.track(name, 1)
+
+	go func() {
+		defer g.wg.Done()
+		defer g.track(name, -1)
+		fn(g.stop, g.work)
+	}()
+}
+
+// Shutdown stops workers from claiming new work and waits for in-flight
+// work until ctx is done. Work still running then is cancelled and given
+// a short grace period to release its claims. It returns ctx's error when
+// the deadline cut work short.
+func (g *Group) Shutdown(ctx context.Context) error {
+	log := logger.Get().WithComponent("worker")
+
+	g.cancelStop()
+
+	select {
+	case <-g.wait():
+		g.cancelWork()
+		return nil
+	case <-ctx.Done():
+	}
+
+	log.Warn().Strs("workers", g.names()).Msg("Drain deadline passed, cancelling in-flight work")
+	g.cancelWork()
+
+	select {
+	case <-g.wait():
+	case <-time.After(releaseGrace):
+		log.Error().Strs("workers", g.names()).Msg("Workers did not exit after cancellation, their claims expire on their own")
+	}
+
+	return ctx.Err()
+}
+
+// wait returns a channel closed once every worker has returned
+func (g *Group) wait() <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+	return done
+}
+
+func (g *Group) track(name string, delta int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.running[name] += delta
+	if g.running[name] == 0 {
+		delete(g.running, name)
+	}
+}
+
+// names returns the workers that are still running
+func (g *Group) names() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	names := make([]string, 0, len(g.running))
+	for name := range g.running {
+		names = append(names, name)
+	}
+	return names
+}
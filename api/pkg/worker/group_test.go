@@ -0,0 +1,52 @@
+package worker
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup_Shutdown(t *testing.T) {
+	t.Run("in-flight work finishes", func(t *testing.T) {
+		g := NewGroup(context.Background())
+
+		var finished atomic.Int32
+		g.Go("job", func(stop, work context.Context) {
+			<-stop.Done()
+			// Still in flight after stop: work must stay usable
+			select {
+			case <-work.Done():
+				return
+			case <-time.After(20 * time.Millisecond):
+			}
+			finished.Add(1)
+		})
+		g.Go("idle", func(stop, work context.Context) {
+			<-stop.Done()
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		assert.NoError(t, g.Shutdown(ctx))
+		assert.Equal(t, int32(1), finished.Load())
+		assert.Empty(t, g.names())
+	})
+
+	t.Run("deadline cancels work and waits for release", func(t *testing.T) {
+		g := NewGroup(context.Background())
+
+		var released atomic.Bool
+		g.Go("stuck", func(stop, work context.Context) {
+			defer released.Store(true)
+			<-work.Done()
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, g.Shutdown(ctx), context.DeadlineExceeded)
+		assert.True(t, released.Load())
+	})
+}